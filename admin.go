@@ -0,0 +1,137 @@
+package duckdb
+
+import (
+	"encoding/json"
+	"net/http"
+	"sync/atomic"
+
+	"github.com/tobilg/caddy-duckdb-module/auth"
+	"go.uber.org/zap"
+)
+
+// fullAccessOperations are the operations a role needs on all tables ('*') to
+// use the admin endpoints.
+var fullAccessOperations = []auth.Operation{
+	auth.OperationCreate,
+	auth.OperationRead,
+	auth.OperationUpdate,
+	auth.OperationDelete,
+	auth.OperationQuery,
+}
+
+// maintenanceModeRequest is the body of POST /admin/maintenance.
+type maintenanceModeRequest struct {
+	ReadOnly *bool `json:"read_only"`
+}
+
+// MaintenanceMode reports whether runtime maintenance mode is on. While it is,
+// all write requests are rejected with 503, whatever the access_mode.
+func (d *DuckDB) MaintenanceMode() bool {
+	return atomic.LoadInt32(&d.maintenanceMode) == 1
+}
+
+// SetMaintenanceMode turns runtime maintenance mode on or off.
+func (d *DuckDB) SetMaintenanceMode(readOnly bool) {
+	var v int32
+	if readOnly {
+		v = 1
+	}
+	atomic.StoreInt32(&d.maintenanceMode, v)
+}
+
+// serveMaintenanceMode handles /admin/maintenance. GET reports the maintenance
+// mode and POST with {"read_only": true|false} switches it, without a config
+// reload. Both require a role with full access to all tables.
+func (d *DuckDB) serveMaintenanceMode(w http.ResponseWriter, r *http.Request) {
+	requestID := auth.GetRequestIDFromContext(r.Context())
+	role := auth.GetRoleFromContext(r.Context())
+	if !d.authorizeAdmin(w, r) {
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+	case http.MethodPost:
+		defer r.Body.Close()
+		var req maintenanceModeRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.ReadOnly == nil {
+			writeModuleError(w, r, `Invalid JSON in request body (expected {"read_only": true|false})`, http.StatusBadRequest)
+			return
+		}
+		d.SetMaintenanceMode(*req.ReadOnly)
+		d.logger.Warn("Maintenance mode changed",
+			zap.Bool("read_only", *req.ReadOnly),
+			zap.String("role", role),
+			zap.String("request_id", requestID),
+		)
+	default:
+		writeModuleError(w, r, "Method not allowed. Use GET or POST.", http.StatusMethodNotAllowed)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]interface{}{"read_only": d.MaintenanceMode()})
+}
+
+// serveIndexRecommendations handles GET /admin/indexes, which lists the
+// columns the index advisor recommends indexing. Requires a role with full
+// access to all tables.
+func (d *DuckDB) serveIndexRecommendations(w http.ResponseWriter, r *http.Request) {
+	if !d.authorizeAdmin(w, r) {
+		return
+	}
+	if r.Method != http.MethodGet {
+		writeModuleError(w, r, "Method not allowed. Use GET.", http.StatusMethodNotAllowed)
+		return
+	}
+	if d.indexAdvisor == nil {
+		writeModuleError(w, r, "Index advisor is not enabled", http.StatusNotFound)
+		return
+	}
+
+	recommendations, err := d.indexAdvisor.Recommendations()
+	if err != nil {
+		d.logger.Error("Failed to list index recommendations", zap.Error(err), zap.String("request_id", auth.GetRequestIDFromContext(r.Context())))
+		writeModuleError(w, r, "Failed to list index recommendations", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]interface{}{"recommendations": recommendations})
+}
+
+// authorizeAdmin checks that the request's role has full access to all tables,
+// writing an error response and returning false otherwise.
+func (d *DuckDB) authorizeAdmin(w http.ResponseWriter, r *http.Request) bool {
+	requestID := auth.GetRequestIDFromContext(r.Context())
+	role := auth.GetRoleFromContext(r.Context())
+
+	for _, op := range fullAccessOperations {
+		allowed, err := d.authorizer.CheckPermission(role, "*", op)
+		if err != nil {
+			d.logger.Error("Failed to check permission", zap.Error(err), zap.String("request_id", requestID))
+			writeModuleError(w, r, "Failed to check permission", http.StatusInternalServerError)
+			return false
+		}
+		if !allowed {
+			writeModuleError(w, r, "Forbidden: the admin endpoints require full access to all tables", http.StatusForbidden)
+			return false
+		}
+	}
+	return true
+}
+
+// writeModuleError writes an error response in the module's error format,
+// including the request ID.
+func writeModuleError(w http.ResponseWriter, r *http.Request, message string, statusCode int) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"error":      http.StatusText(statusCode),
+		"message":    message,
+		"code":       statusCode,
+		"request_id": auth.GetRequestIDFromContext(r.Context()),
+	})
+}
@@ -0,0 +1,200 @@
+package duckdb
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/tobilg/caddy-duckdb-module/handlers"
+)
+
+// setupMaintenanceModule creates a module with the table and query handlers
+// wired to its maintenance mode.
+func setupMaintenanceModule(t *testing.T) (*DuckDB, func()) {
+	d, cleanup := setupTestModule(t)
+	d.crudHandler = handlers.NewCRUDHandler(d.dbMgr, d.authorizer, d.MaxRowsPerPage, d.AbsoluteMaxRows, d.logger)
+	d.crudHandler.SetMaintenanceMode(d.MaintenanceMode)
+	d.queryHandler = handlers.NewQueryHandler(d.dbMgr, d.authorizer, d.logger)
+	d.queryHandler.SetMaintenanceMode(d.MaintenanceMode)
+	return d, cleanup
+}
+
+// serve sends a request with the given API key through the module.
+func serve(t *testing.T, d *DuckDB, method, path, body, apiKey string) *httptest.ResponseRecorder {
+	t.Helper()
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	req.Header.Set("X-API-Key", apiKey)
+	req.Header.Set("Content-Type", "application/json")
+	rec := httptest.NewRecorder()
+	if err := d.ServeHTTP(rec, req, &mockNextHandler{}); err != nil {
+		t.Fatalf("ServeHTTP returned error: %v", err)
+	}
+	return rec
+}
+
+func TestMaintenanceMode_Toggle(t *testing.T) {
+	d, cleanup := setupMaintenanceModule(t)
+	defer cleanup()
+
+	rec := serve(t, d, "GET", "/duckdb/admin/maintenance", "", "test-api-key")
+	if rec.Code != http.StatusOK || strings.TrimSpace(rec.Body.String()) != `{"read_only":false}` {
+		t.Fatalf("Expected maintenance mode to be off, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	rec = serve(t, d, "POST", "/duckdb/admin/maintenance", `{"read_only": true}`, "test-api-key")
+	if rec.Code != http.StatusOK || !d.MaintenanceMode() {
+		t.Fatalf("Expected maintenance mode to be on, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	// Writes are rejected
+	writes := []struct {
+		method string
+		path   string
+		body   string
+	}{
+		{"POST", "/duckdb/api/test_data", `{"id": 1, "value": "a"}`},
+		{"PUT", "/duckdb/api/test_data", `{"where": {"id": 1}, "set": {"value": "b"}}`},
+		{"DELETE", "/duckdb/api/test_data?where=id:eq:1", ""},
+		{"POST", "/duckdb/query", `{"sql": "INSERT INTO test_data VALUES (2, 'b')"}`},
+	}
+	for _, tt := range writes {
+		rec := serve(t, d, tt.method, tt.path, tt.body, "test-api-key")
+		if rec.Code != http.StatusServiceUnavailable || !strings.Contains(rec.Body.String(), "Maintenance mode") {
+			t.Errorf("%s %s: expected 503 maintenance mode, got %d: %s", tt.method, tt.path, rec.Code, rec.Body.String())
+		}
+	}
+
+	// Reads continue
+	if rec := serve(t, d, "GET", "/duckdb/api/test_data", "", "test-api-key"); rec.Code != http.StatusOK {
+		t.Errorf("Expected table reads to succeed, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if rec := serve(t, d, "POST", "/duckdb/query", `{"sql": "SELECT COUNT(*) FROM test_data"}`, "test-api-key"); rec.Code != http.StatusOK {
+		t.Errorf("Expected SELECT queries to succeed, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	// Clearing the flag re-enables writes
+	rec = serve(t, d, "POST", "/duckdb/admin/maintenance", `{"read_only": false}`, "test-api-key")
+	if rec.Code != http.StatusOK || d.MaintenanceMode() {
+		t.Fatalf("Expected maintenance mode to be off, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if rec := serve(t, d, "POST", "/duckdb/api/test_data", `{"id": 1, "value": "a"}`, "test-api-key"); rec.Code != http.StatusCreated {
+		t.Errorf("Expected the insert to succeed, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if rec := serve(t, d, "POST", "/duckdb/query", `{"sql": "INSERT INTO test_data VALUES (2, 'b')"}`, "test-api-key"); rec.Code != http.StatusOK {
+		t.Errorf("Expected the write query to succeed, got %d: %s", rec.Code, rec.Body.String())
+	}
+}
+
+func TestMaintenanceMode_Endpoint(t *testing.T) {
+	d, cleanup := setupMaintenanceModule(t)
+	defer cleanup()
+	if err := d.authorizer.CreateAPIKey("editor-api-key", "editor", nil); err != nil {
+		t.Fatalf("Failed to create API key: %v", err)
+	}
+
+	tests := []struct {
+		name   string
+		method string
+		body   string
+		apiKey string
+		status int
+	}{
+		{"missing flag", "POST", `{}`, "test-api-key", http.StatusBadRequest},
+		{"invalid JSON", "POST", `read_only`, "test-api-key", http.StatusBadRequest},
+		{"wrong method", "DELETE", "", "test-api-key", http.StatusMethodNotAllowed},
+		{"role without full access", "POST", `{"read_only": true}`, "editor-api-key", http.StatusForbidden},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := serve(t, d, tt.method, "/duckdb/admin/maintenance", tt.body, tt.apiKey)
+			if rec.Code != tt.status {
+				t.Errorf("Expected status %d, got %d: %s", tt.status, rec.Code, rec.Body.String())
+			}
+			var body map[string]interface{}
+			if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil || body["code"] != float64(tt.status) {
+				t.Errorf("Expected an error response, got %s", rec.Body.String())
+			}
+		})
+	}
+	if d.MaintenanceMode() {
+		t.Error("Expected maintenance mode to stay off")
+	}
+}
+
+func TestIndexRecommendations_Endpoint(t *testing.T) {
+	d, cleanup := setupMaintenanceModule(t)
+	defer cleanup()
+
+	// Disabled by default
+	if rec := serve(t, d, "GET", "/duckdb/admin/indexes", "", "test-api-key"); rec.Code != http.StatusNotFound {
+		t.Errorf("Expected 404 while the index advisor is disabled, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	d.indexAdvisor = handlers.NewIndexAdvisor(d.dbMgr, handlers.IndexAdvisorConfig{Enabled: true, MinUses: 2}, d.logger)
+	d.crudHandler.SetIndexAdvisor(d.indexAdvisor)
+	for i := 0; i < 2; i++ {
+		if rec := serve(t, d, "GET", "/duckdb/api/test_data?filter=value:eq:a", "", "test-api-key"); rec.Code != http.StatusOK {
+			t.Fatalf("Expected read to succeed, got %d: %s", rec.Code, rec.Body.String())
+		}
+	}
+
+	rec := serve(t, d, "GET", "/duckdb/admin/indexes", "", "test-api-key")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var result struct {
+		Recommendations []handlers.IndexRecommendation `json:"recommendations"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &result); err != nil {
+		t.Fatalf("Failed to parse response: %v", err)
+	}
+	if len(result.Recommendations) != 1 || result.Recommendations[0].Table != "test_data" || result.Recommendations[0].Column != "value" {
+		t.Errorf("Expected a recommendation for test_data.value, got %+v", result.Recommendations)
+	}
+
+	if rec := serve(t, d, "POST", "/duckdb/admin/indexes", "", "test-api-key"); rec.Code != http.StatusMethodNotAllowed {
+		t.Errorf("Expected 405 for POST, got %d", rec.Code)
+	}
+}
+
+func TestMetrics_Endpoint(t *testing.T) {
+	d, cleanup := setupMaintenanceModule(t)
+	defer cleanup()
+	if err := d.authorizer.CreateAPIKey("editor-api-key", "editor", nil); err != nil {
+		t.Fatalf("Failed to create API key: %v", err)
+	}
+
+	// Authentication and full access are required by default
+	if rec := serve(t, d, "GET", "/duckdb/metrics", "", ""); rec.Code != http.StatusUnauthorized {
+		t.Errorf("Expected 401 without an API key, got %d", rec.Code)
+	}
+	if rec := serve(t, d, "GET", "/duckdb/metrics", "", "editor-api-key"); rec.Code != http.StatusForbidden {
+		t.Errorf("Expected 403 for a role without full access, got %d", rec.Code)
+	}
+
+	// Counters reflect the queries served
+	if rec := serve(t, d, "POST", "/duckdb/api/test_data", `{"id": 1, "value": "a"}`, "test-api-key"); rec.Code != http.StatusCreated {
+		t.Fatalf("Expected insert to succeed, got %d: %s", rec.Code, rec.Body.String())
+	}
+	rec := serve(t, d, "GET", "/duckdb/metrics", "", "test-api-key")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if ct := rec.Header().Get("Content-Type"); ct != handlers.MetricsContentType {
+		t.Errorf("Expected Prometheus content type, got %q", ct)
+	}
+	if !strings.Contains(rec.Body.String(), `duckdb_queries_total{type="insert"} 1`+"\n") {
+		t.Errorf("Expected one counted insert, got:\n%s", rec.Body.String())
+	}
+	if !strings.Contains(rec.Body.String(), `duckdb_pool_open_connections{pool="main"}`) {
+		t.Errorf("Expected main pool statistics, got:\n%s", rec.Body.String())
+	}
+
+	// metrics_public serves scrapers without an API key
+	d.MetricsPublic = true
+	if rec := serve(t, d, "GET", "/duckdb/metrics", "", ""); rec.Code != http.StatusOK {
+		t.Errorf("Expected 200 without an API key, got %d: %s", rec.Code, rec.Body.String())
+	}
+}
@@ -2,7 +2,9 @@ package auth
 
 import (
 	"database/sql"
+	"errors"
 	"fmt"
+	"sync"
 	"time"
 
 	"github.com/hashicorp/golang-lru/v2/expirable"
@@ -12,11 +14,42 @@ import (
 // This provides a safety net even if cache invalidation is missed
 const defaultCacheTTL = 5 * time.Minute
 
+var (
+	// ErrUnknownAPIKey is returned for keys that are not in the auth database.
+	ErrUnknownAPIKey = errors.New("invalid API key")
+	// ErrExpiredAPIKey is returned for keys whose expires_at has passed.
+	ErrExpiredAPIKey = errors.New("API key has expired")
+	// ErrDisabledAPIKey is returned for keys with is_active = false.
+	ErrDisabledAPIKey = errors.New("API key has been disabled")
+)
+
 // Authorizer handles authentication and authorization.
 type Authorizer struct {
 	authDB          *sql.DB
 	permissionCache *expirable.LRU[string, bool]
 	apiKeyCache     *expirable.LRU[string, *APIKey]
+
+	// inheritanceOnce detects whether the roles table has the parent_role column.
+	// Auth databases created before role inheritance lack it and resolve
+	// permissions from the role alone.
+	inheritanceOnce sync.Once
+	inheritance     bool
+
+	// namespaceOnce detects whether the api_keys table has the namespace
+	// column. Keys in auth databases created before namespaces have none.
+	namespaceOnce sync.Once
+	namespaces    bool
+
+	// defaultAccessOnce detects whether the roles table has the
+	// default_table_access column. Without it every role defaults to deny.
+	defaultAccessOnce sync.Once
+	defaultAccess     bool
+
+	// deniedColumnsOnce detects whether the permissions table has the
+	// denied_columns column. Without it no column is restricted.
+	deniedColumnsOnce sync.Once
+	deniedColumns     bool
+	columnCache       *expirable.LRU[string, []string]
 }
 
 // NewAuthorizer creates a new authorizer with permission and API key caching.
@@ -35,6 +68,7 @@ func NewAuthorizer(authDB *sql.DB) *Authorizer {
 		authDB:          authDB,
 		permissionCache: permCache,
 		apiKeyCache:     apiKeyCache,
+		columnCache:     expirable.NewLRU[string, []string](1000, nil, defaultCacheTTL),
 	}
 }
 
@@ -47,10 +81,13 @@ func NewAuthorizerWithTTL(authDB *sql.DB, cacheTTL time.Duration) *Authorizer {
 		authDB:          authDB,
 		permissionCache: permCache,
 		apiKeyCache:     apiKeyCache,
+		columnCache:     expirable.NewLRU[string, []string](1000, nil, cacheTTL),
 	}
 }
 
 // AuthenticateAPIKey validates an API key and returns the associated role.
+// Unknown, expired, and disabled keys are rejected with ErrUnknownAPIKey,
+// ErrExpiredAPIKey, and ErrDisabledAPIKey respectively.
 // Results are cached in memory for performance - cache is invalidated on API key changes.
 func (a *Authorizer) AuthenticateAPIKey(apiKey string) (*APIKey, error) {
 	// Check cache first
@@ -59,7 +96,7 @@ func (a *Authorizer) AuthenticateAPIKey(apiKey string) (*APIKey, error) {
 		if cached.ExpiresAt != nil && cached.ExpiresAt.Before(time.Now()) {
 			// Key has expired since caching, remove from cache and return error
 			a.apiKeyCache.Remove(apiKey)
-			return nil, fmt.Errorf("API key has expired")
+			return nil, ErrExpiredAPIKey
 		}
 		return cached, nil
 	}
@@ -78,38 +115,50 @@ func (a *Authorizer) AuthenticateAPIKey(apiKey string) (*APIKey, error) {
 
 // authenticateAPIKeyDB performs the actual database lookup for API key authentication.
 func (a *Authorizer) authenticateAPIKeyDB(apiKey string) (*APIKey, error) {
+	namespace := "NULL"
+	if a.hasNamespaces() {
+		namespace = "namespace"
+	}
 	query := `
-		SELECT key, role_name, created_at, expires_at, is_active
+		SELECT key, role_name, created_at, expires_at, is_active, ` + namespace + `
 		FROM api_keys
-		WHERE key = $1 AND is_active = true
+		WHERE ` + apiKeyMatch + `
 	`
 
 	var key APIKey
+	var storedKey string
 	var expiresAt sql.NullTime
+	var keyNamespace sql.NullString
 
-	err := a.authDB.QueryRow(query, apiKey).Scan(
-		&key.Key,
+	err := a.authDB.QueryRow(query, HashAPIKey(apiKey), apiKey).Scan(
+		&storedKey,
 		&key.RoleName,
 		&key.CreatedAt,
 		&expiresAt,
 		&key.IsActive,
+		&keyNamespace,
 	)
 
 	if err == sql.ErrNoRows {
-		return nil, fmt.Errorf("invalid API key")
+		return nil, ErrUnknownAPIKey
 	}
 	if err != nil {
 		return nil, fmt.Errorf("failed to query API key: %w", err)
 	}
 
-	// Check expiration
+	// Check status and expiration
+	if !key.IsActive {
+		return nil, ErrDisabledAPIKey
+	}
 	if expiresAt.Valid && expiresAt.Time.Before(time.Now()) {
-		return nil, fmt.Errorf("API key has expired")
+		return nil, ErrExpiredAPIKey
 	}
 
 	if expiresAt.Valid {
 		key.ExpiresAt = &expiresAt.Time
 	}
+	key.Key = apiKey
+	key.Namespace = keyNamespace.String
 
 	return &key, nil
 }
@@ -137,10 +186,47 @@ func (a *Authorizer) CheckPermission(roleName string, tableName string, operatio
 	return allowed, nil
 }
 
-// checkPermissionDB performs the actual database lookup for permissions.
+// checkPermissionDB resolves a permission, walking up the role's parent chain.
+// The first role in the chain with a grant for the table (table-specific or '*')
+// decides, so a child's grants override those it inherits. If no role in the
+// chain has a grant, the role's own default table access decides.
 func (a *Authorizer) checkPermissionDB(roleName string, tableName string, operation Operation) (bool, error) {
+	visited := make(map[string]bool)
+	for role := roleName; role != ""; {
+		if visited[role] {
+			return false, fmt.Errorf("role inheritance cycle detected at role '%s'", role)
+		}
+		visited[role] = true
+
+		perm, found, err := a.lookupPermission(role, tableName)
+		if err != nil {
+			return false, err
+		}
+		if found {
+			return perm.allows(operation)
+		}
+
+		if role, err = a.parentRole(role); err != nil {
+			return false, err
+		}
+	}
+
+	access, err := a.roleDefaultAccess(roleName)
+	if err != nil {
+		return false, err
+	}
+	return defaultAccessAllows(access, operation), nil
+}
+
+// lookupPermission returns the role's own grant for a table, preferring a
+// table-specific grant over '*'.
+func (a *Authorizer) lookupPermission(roleName string, tableName string) (Permission, bool, error) {
+	deniedColumns := "NULL"
+	if a.hasDeniedColumns() {
+		deniedColumns = "denied_columns"
+	}
 	query := `
-		SELECT can_create, can_read, can_update, can_delete, can_query
+		SELECT can_create, can_read, can_update, can_delete, can_query, ` + deniedColumns + `
 		FROM permissions
 		WHERE role_name = $1 AND (table_name = $2 OR table_name = '*')
 		ORDER BY CASE WHEN table_name = $2 THEN 1 ELSE 2 END
@@ -148,42 +234,238 @@ func (a *Authorizer) checkPermissionDB(roleName string, tableName string, operat
 	`
 
 	var perm Permission
+	var denied sql.NullString
 	err := a.authDB.QueryRow(query, roleName, tableName).Scan(
 		&perm.CanCreate,
 		&perm.CanRead,
 		&perm.CanUpdate,
 		&perm.CanDelete,
 		&perm.CanQuery,
+		&denied,
 	)
 
 	if err == sql.ErrNoRows {
-		return false, nil
+		return perm, false, nil
 	}
 	if err != nil {
-		return false, fmt.Errorf("failed to query permissions: %w", err)
+		return perm, false, fmt.Errorf("failed to query permissions: %w", err)
 	}
+	perm.DeniedColumns = ParseColumnList(denied.String)
+	return perm, true, nil
+}
 
-	// Check the specific operation
+// allows reports whether the permission grants the operation.
+func (p Permission) allows(operation Operation) (bool, error) {
 	switch operation {
 	case OperationCreate:
-		return perm.CanCreate, nil
+		return p.CanCreate, nil
 	case OperationRead:
-		return perm.CanRead, nil
+		return p.CanRead, nil
 	case OperationUpdate:
-		return perm.CanUpdate, nil
+		return p.CanUpdate, nil
 	case OperationDelete:
-		return perm.CanDelete, nil
+		return p.CanDelete, nil
 	case OperationQuery:
-		return perm.CanQuery, nil
+		return p.CanQuery, nil
 	default:
 		return false, fmt.Errorf("unknown operation: %s", operation)
 	}
 }
 
+// hasInheritance reports whether the auth database supports role inheritance.
+func (a *Authorizer) hasInheritance() bool {
+	a.inheritanceOnce.Do(func() {
+		query := `
+			SELECT EXISTS (
+				SELECT 1 FROM information_schema.columns
+				WHERE table_name = 'roles' AND column_name = 'parent_role'
+			)
+		`
+		if err := a.authDB.QueryRow(query).Scan(&a.inheritance); err != nil {
+			a.inheritance = false
+		}
+	})
+	return a.inheritance
+}
+
+// hasDefaultAccess reports whether the auth database stores default table access.
+func (a *Authorizer) hasDefaultAccess() bool {
+	a.defaultAccessOnce.Do(func() {
+		query := `
+			SELECT EXISTS (
+				SELECT 1 FROM information_schema.columns
+				WHERE table_name = 'roles' AND column_name = 'default_table_access'
+			)
+		`
+		if err := a.authDB.QueryRow(query).Scan(&a.defaultAccess); err != nil {
+			a.defaultAccess = false
+		}
+	})
+	return a.defaultAccess
+}
+
+// hasNamespaces reports whether the auth database stores API key namespaces.
+func (a *Authorizer) hasNamespaces() bool {
+	a.namespaceOnce.Do(func() {
+		query := `
+			SELECT EXISTS (
+				SELECT 1 FROM information_schema.columns
+				WHERE table_name = 'api_keys' AND column_name = 'namespace'
+			)
+		`
+		if err := a.authDB.QueryRow(query).Scan(&a.namespaces); err != nil {
+			a.namespaces = false
+		}
+	})
+	return a.namespaces
+}
+
+// parentRole returns the parent of a role, or "" if it has none.
+func (a *Authorizer) parentRole(roleName string) (string, error) {
+	if !a.hasInheritance() {
+		return "", nil
+	}
+	var parent sql.NullString
+	err := a.authDB.QueryRow(`SELECT parent_role FROM roles WHERE role_name = $1`, roleName).Scan(&parent)
+	if err == sql.ErrNoRows {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to query parent role: %w", err)
+	}
+	return parent.String, nil
+}
+
+// roleDefaultAccess returns the default table access of a role, or
+// DefaultAccessDeny if it has none.
+func (a *Authorizer) roleDefaultAccess(roleName string) (string, error) {
+	if !a.hasDefaultAccess() {
+		return DefaultAccessDeny, nil
+	}
+	var access sql.NullString
+	err := a.authDB.QueryRow(`SELECT default_table_access FROM roles WHERE role_name = $1`, roleName).Scan(&access)
+	if err == sql.ErrNoRows {
+		return DefaultAccessDeny, nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to query default table access: %w", err)
+	}
+	if access.String == "" {
+		return DefaultAccessDeny, nil
+	}
+	return access.String, nil
+}
+
+// ValidDefaultAccess reports whether access is a default table access level.
+func ValidDefaultAccess(access string) bool {
+	switch access {
+	case DefaultAccessDeny, DefaultAccessRead, DefaultAccessCRUD:
+		return true
+	}
+	return false
+}
+
+// defaultAccessAllows reports whether a default table access level grants the
+// operation. Raw queries are never granted by default.
+func defaultAccessAllows(access string, operation Operation) bool {
+	switch access {
+	case DefaultAccessRead:
+		return operation == OperationRead
+	case DefaultAccessCRUD:
+		return operation == OperationCreate || operation == OperationRead ||
+			operation == OperationUpdate || operation == OperationDelete
+	}
+	return false
+}
+
+// SetRoleDefaultAccess sets the access a role has to tables no grant matches:
+// DefaultAccessDeny, DefaultAccessRead, or DefaultAccessCRUD. Invalidates the
+// permission cache.
+func (a *Authorizer) SetRoleDefaultAccess(roleName, access string) error {
+	if !a.hasDefaultAccess() {
+		return fmt.Errorf("auth database does not support default table access (roles table has no default_table_access column)")
+	}
+	if !ValidDefaultAccess(access) {
+		return fmt.Errorf("invalid default table access '%s' (must be deny, read, or crud)", access)
+	}
+
+	result, err := a.authDB.Exec(`UPDATE roles SET default_table_access = $1 WHERE role_name = $2`, access, roleName)
+	if err != nil {
+		return fmt.Errorf("failed to set default table access: %w", err)
+	}
+
+	rows, _ := result.RowsAffected()
+	if rows == 0 {
+		return fmt.Errorf("role '%s' not found", roleName)
+	}
+
+	// Invalidate cache since effective permissions have changed
+	a.InvalidatePermissionCache()
+
+	return nil
+}
+
+// SetRoleParent makes a role inherit the permissions of parentRole, or removes
+// its parent if parentRole is empty. Assignments that would create an
+// inheritance cycle are rejected. Invalidates the permission cache.
+func (a *Authorizer) SetRoleParent(roleName, parentRole string) error {
+	if !a.hasInheritance() {
+		return fmt.Errorf("auth database does not support role inheritance (roles table has no parent_role column)")
+	}
+
+	if parentRole != "" {
+		// Walk up from the new parent; reaching the role itself means a cycle
+		visited := make(map[string]bool)
+		for role := parentRole; role != ""; {
+			if role == roleName {
+				return fmt.Errorf("cannot set parent of role '%s' to '%s': inheritance cycle", roleName, parentRole)
+			}
+			if visited[role] {
+				break
+			}
+			visited[role] = true
+
+			var exists bool
+			if err := a.authDB.QueryRow(`SELECT EXISTS (SELECT 1 FROM roles WHERE role_name = $1)`, role).Scan(&exists); err != nil {
+				return fmt.Errorf("failed to query role: %w", err)
+			}
+			if !exists {
+				return fmt.Errorf("role '%s' not found", role)
+			}
+
+			next, err := a.parentRole(role)
+			if err != nil {
+				return err
+			}
+			role = next
+		}
+	}
+
+	var parent interface{}
+	if parentRole != "" {
+		parent = parentRole
+	}
+	result, err := a.authDB.Exec(`UPDATE roles SET parent_role = $1 WHERE role_name = $2`, parent, roleName)
+	if err != nil {
+		return fmt.Errorf("failed to set parent role: %w", err)
+	}
+
+	rows, _ := result.RowsAffected()
+	if rows == 0 {
+		return fmt.Errorf("role '%s' not found", roleName)
+	}
+
+	// Invalidate cache since effective permissions have changed
+	a.InvalidatePermissionCache()
+
+	return nil
+}
+
 // InvalidatePermissionCache clears the permission cache.
 // Call this when permissions are modified to ensure cache consistency.
 func (a *Authorizer) InvalidatePermissionCache() {
 	a.permissionCache.Purge()
+	a.columnCache.Purge()
 }
 
 // InvalidateAPIKeyCache clears the entire API key cache.
@@ -198,14 +480,39 @@ func (a *Authorizer) InvalidateAPIKey(apiKey string) {
 	a.apiKeyCache.Remove(apiKey)
 }
 
-// CreateAPIKey creates a new API key with the specified role.
+// CreateAPIKey creates a new API key with the specified role. Only the hash
+// of the key is stored.
 func (a *Authorizer) CreateAPIKey(apiKey, roleName string, expiresAt *time.Time) error {
 	query := `
 		INSERT INTO api_keys (key, role_name, expires_at)
 		VALUES ($1, $2, $3)
 	`
 
-	_, err := a.authDB.Exec(query, apiKey, roleName, expiresAt)
+	_, err := a.authDB.Exec(query, HashAPIKey(apiKey), roleName, expiresAt)
+	if err != nil {
+		return fmt.Errorf("failed to create API key: %w", err)
+	}
+
+	return nil
+}
+
+// CreateNamespacedAPIKey creates a new API key with the specified role in a
+// namespace. The key must start with the namespace and NamespaceSeparator.
+// Only the hash of the key is stored.
+func (a *Authorizer) CreateNamespacedAPIKey(apiKey, roleName, namespace string, expiresAt *time.Time) error {
+	if err := CheckNamespacedKey(apiKey, namespace); err != nil {
+		return err
+	}
+	if !a.hasNamespaces() {
+		return fmt.Errorf("auth database does not support key namespaces (api_keys table has no namespace column)")
+	}
+
+	query := `
+		INSERT INTO api_keys (key, role_name, expires_at, namespace)
+		VALUES ($1, $2, $3, $4)
+	`
+
+	_, err := a.authDB.Exec(query, HashAPIKey(apiKey), roleName, expiresAt, namespace)
 	if err != nil {
 		return fmt.Errorf("failed to create API key: %w", err)
 	}
@@ -219,10 +526,10 @@ func (a *Authorizer) RevokeAPIKey(apiKey string) error {
 	query := `
 		UPDATE api_keys
 		SET is_active = false
-		WHERE key = $1
+		WHERE ` + apiKeyMatch + `
 	`
 
-	result, err := a.authDB.Exec(query, apiKey)
+	result, err := a.authDB.Exec(query, HashAPIKey(apiKey), apiKey)
 	if err != nil {
 		return fmt.Errorf("failed to revoke API key: %w", err)
 	}
@@ -350,6 +657,13 @@ func (a *Authorizer) DeleteRole(roleName string) error {
 		return fmt.Errorf("failed to delete role API keys: %w", err)
 	}
 
+	// Roles inheriting from this role no longer have a parent
+	if a.hasInheritance() {
+		if _, err := a.authDB.Exec(`UPDATE roles SET parent_role = NULL WHERE parent_role = $1`, roleName); err != nil {
+			return fmt.Errorf("failed to detach child roles: %w", err)
+		}
+	}
+
 	// Finally delete the role itself
 	roleQuery := `DELETE FROM roles WHERE role_name = $1`
 	result, err := a.authDB.Exec(roleQuery, roleName)
@@ -371,8 +685,12 @@ func (a *Authorizer) DeleteRole(roleName string) error {
 
 // GetPermissions returns all permissions for a role.
 func (a *Authorizer) GetPermissions(roleName string) ([]Permission, error) {
+	deniedColumns := "NULL"
+	if a.hasDeniedColumns() {
+		deniedColumns = "denied_columns"
+	}
 	query := `
-		SELECT id, role_name, table_name, can_create, can_read, can_update, can_delete, can_query
+		SELECT id, role_name, table_name, can_create, can_read, can_update, can_delete, can_query, ` + deniedColumns + `
 		FROM permissions
 		WHERE role_name = $1
 	`
@@ -386,6 +704,7 @@ func (a *Authorizer) GetPermissions(roleName string) ([]Permission, error) {
 	var permissions []Permission
 	for rows.Next() {
 		var perm Permission
+		var denied sql.NullString
 		err := rows.Scan(
 			&perm.ID,
 			&perm.RoleName,
@@ -395,10 +714,12 @@ func (a *Authorizer) GetPermissions(roleName string) ([]Permission, error) {
 			&perm.CanUpdate,
 			&perm.CanDelete,
 			&perm.CanQuery,
+			&denied,
 		)
 		if err != nil {
 			return nil, fmt.Errorf("failed to scan permission: %w", err)
 		}
+		perm.DeniedColumns = ParseColumnList(denied.String)
 		permissions = append(permissions, perm)
 	}
 
@@ -2,6 +2,7 @@ package auth
 
 import (
 	"database/sql"
+	"errors"
 	"testing"
 	"time"
 
@@ -18,7 +19,9 @@ func setupTestDB(t *testing.T) *sql.DB {
 	schema := `
 		CREATE TABLE IF NOT EXISTS roles (
 			role_name VARCHAR PRIMARY KEY,
-			description VARCHAR
+			description VARCHAR,
+			parent_role VARCHAR,
+			default_table_access VARCHAR
 		);
 
 		CREATE TABLE IF NOT EXISTS api_keys (
@@ -27,6 +30,7 @@ func setupTestDB(t *testing.T) *sql.DB {
 			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
 			expires_at TIMESTAMP,
 			is_active BOOLEAN DEFAULT true,
+			namespace VARCHAR,
 			FOREIGN KEY (role_name) REFERENCES roles(role_name)
 		);
 
@@ -39,6 +43,7 @@ func setupTestDB(t *testing.T) *sql.DB {
 			can_update BOOLEAN DEFAULT false,
 			can_delete BOOLEAN DEFAULT false,
 			can_query BOOLEAN DEFAULT false,
+			denied_columns VARCHAR,
 			FOREIGN KEY (role_name) REFERENCES roles(role_name),
 			UNIQUE(role_name, table_name)
 		);
@@ -150,6 +155,45 @@ func TestAuthenticateAPIKey_Inactive(t *testing.T) {
 	}
 }
 
+func TestAuthenticateAPIKey_Status(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	auth := NewAuthorizer(db)
+
+	past := time.Now().Add(-time.Hour)
+	future := time.Now().Add(24 * time.Hour)
+	if err := auth.CreateAPIKey("expired-key", "admin", &past); err != nil {
+		t.Fatalf("Failed to create API key: %v", err)
+	}
+	if err := auth.CreateAPIKey("future-key", "admin", &future); err != nil {
+		t.Fatalf("Failed to create API key: %v", err)
+	}
+	// Deactivated directly in the database, as key rotation scripts do
+	if _, err := db.Exec("INSERT INTO api_keys (key, role_name, is_active) VALUES ($1, 'admin', false)", HashAPIKey("disabled-key")); err != nil {
+		t.Fatalf("Failed to insert API key: %v", err)
+	}
+
+	tests := []struct {
+		key     string
+		wantErr error
+	}{
+		{"expired-key", ErrExpiredAPIKey},
+		{"disabled-key", ErrDisabledAPIKey},
+		{"unknown-key", ErrUnknownAPIKey},
+		{"future-key", nil},
+	}
+	for _, tt := range tests {
+		key, err := auth.AuthenticateAPIKey(tt.key)
+		if !errors.Is(err, tt.wantErr) {
+			t.Errorf("%s: expected error %v, got %v", tt.key, tt.wantErr, err)
+		}
+		if tt.wantErr == nil && (key == nil || key.ExpiresAt == nil) {
+			t.Errorf("%s: expected a key with an expiry, got %+v", tt.key, key)
+		}
+	}
+}
+
 func TestCreateAPIKey(t *testing.T) {
 	db := setupTestDB(t)
 	defer db.Close()
@@ -356,3 +400,249 @@ func TestGetPermissions(t *testing.T) {
 		t.Errorf("Expected 2 permissions, got %d", len(perms))
 	}
 }
+
+func TestCheckPermission_InheritsFromParent(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	auth := NewAuthorizer(db)
+	if err := auth.CreatePermission(Permission{RoleName: "reader", TableName: "*", CanRead: true}); err != nil {
+		t.Fatalf("Failed to create permission: %v", err)
+	}
+	if err := auth.CreateRole("analyst", "Reader with query access on reports"); err != nil {
+		t.Fatalf("Failed to create role: %v", err)
+	}
+	if err := auth.SetRoleParent("analyst", "reader"); err != nil {
+		t.Fatalf("Failed to set parent role: %v", err)
+	}
+
+	// No grant of its own: the parent's '*' grant applies
+	allowed, err := auth.CheckPermission("analyst", "orders", OperationRead)
+	if err != nil || !allowed {
+		t.Errorf("Expected analyst to inherit read on orders, got %v (err: %v)", allowed, err)
+	}
+	allowed, _ = auth.CheckPermission("analyst", "orders", OperationUpdate)
+	if allowed {
+		t.Error("Expected analyst not to inherit update")
+	}
+}
+
+func TestCheckPermission_ChildOverridesParent(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	auth := NewAuthorizer(db)
+	auth.CreatePermission(Permission{RoleName: "reader", TableName: "*", CanRead: true})
+	auth.CreateRole("restricted", "Reader without access to salaries")
+	if err := auth.SetRoleParent("restricted", "reader"); err != nil {
+		t.Fatalf("Failed to set parent role: %v", err)
+	}
+	if err := auth.CreatePermission(Permission{RoleName: "restricted", TableName: "salaries"}); err != nil {
+		t.Fatalf("Failed to create permission: %v", err)
+	}
+
+	if allowed, _ := auth.CheckPermission("restricted", "salaries", OperationRead); allowed {
+		t.Error("Expected the child's own grant to override the inherited one")
+	}
+	if allowed, _ := auth.CheckPermission("restricted", "orders", OperationRead); !allowed {
+		t.Error("Expected other tables to still be inherited")
+	}
+}
+
+func TestCheckPermission_DefaultTableAccess(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	auth := NewAuthorizer(db)
+	auth.CreateRole("dev", "Permissive development role")
+	auth.CreateRole("viewer", "Reads unconfigured tables")
+	if err := auth.CreatePermission(Permission{RoleName: "dev", TableName: "secrets"}); err != nil {
+		t.Fatalf("Failed to create permission: %v", err)
+	}
+
+	// Default stays deny
+	if allowed, err := auth.CheckPermission("dev", "new_table", OperationRead); err != nil || allowed {
+		t.Errorf("Expected deny without default access, got %v (err: %v)", allowed, err)
+	}
+
+	if err := auth.SetRoleDefaultAccess("dev", DefaultAccessCRUD); err != nil {
+		t.Fatalf("Failed to set default access: %v", err)
+	}
+	if err := auth.SetRoleDefaultAccess("viewer", DefaultAccessRead); err != nil {
+		t.Fatalf("Failed to set default access: %v", err)
+	}
+
+	tests := []struct {
+		role      string
+		table     string
+		operation Operation
+		want      bool
+	}{
+		{"dev", "new_table", OperationCreate, true},
+		{"dev", "new_table", OperationRead, true},
+		{"dev", "new_table", OperationUpdate, true},
+		{"dev", "new_table", OperationDelete, true},
+		{"dev", "*", OperationQuery, false},
+		{"dev", "secrets", OperationRead, false}, // a specific grant still decides
+		{"viewer", "new_table", OperationRead, true},
+		{"viewer", "new_table", OperationUpdate, false},
+		{"unknown", "new_table", OperationRead, false},
+	}
+	for _, tt := range tests {
+		allowed, err := auth.CheckPermission(tt.role, tt.table, tt.operation)
+		if err != nil {
+			t.Fatalf("CheckPermission(%s, %s, %s) failed: %v", tt.role, tt.table, tt.operation, err)
+		}
+		if allowed != tt.want {
+			t.Errorf("CheckPermission(%s, %s, %s) = %v, want %v", tt.role, tt.table, tt.operation, allowed, tt.want)
+		}
+	}
+
+	// Resetting to deny takes effect immediately
+	if err := auth.SetRoleDefaultAccess("viewer", DefaultAccessDeny); err != nil {
+		t.Fatalf("Failed to reset default access: %v", err)
+	}
+	if allowed, _ := auth.CheckPermission("viewer", "new_table", OperationRead); allowed {
+		t.Error("Expected deny after resetting default access")
+	}
+
+	if err := auth.SetRoleDefaultAccess("dev", "all"); err == nil {
+		t.Error("Expected error for invalid default access")
+	}
+	if err := auth.SetRoleDefaultAccess("missing", DefaultAccessRead); err == nil {
+		t.Error("Expected error for unknown role")
+	}
+}
+
+func TestSetRoleParent_Cycle(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	auth := NewAuthorizer(db)
+	auth.CreateRole("a", "")
+	auth.CreateRole("b", "")
+	if err := auth.SetRoleParent("b", "a"); err != nil {
+		t.Fatalf("Failed to set parent role: %v", err)
+	}
+	if err := auth.SetRoleParent("a", "b"); err == nil {
+		t.Error("Expected error for inheritance cycle")
+	}
+	if err := auth.SetRoleParent("a", "a"); err == nil {
+		t.Error("Expected error for self inheritance")
+	}
+	if err := auth.SetRoleParent("a", "missing"); err == nil {
+		t.Error("Expected error for unknown parent role")
+	}
+
+	// A cycle written directly to the database is detected at resolution time
+	if _, err := db.Exec(`UPDATE roles SET parent_role = 'b' WHERE role_name = 'a'`); err != nil {
+		t.Fatalf("Failed to create cycle: %v", err)
+	}
+	if _, err := auth.CheckPermission("a", "orders", OperationRead); err == nil {
+		t.Error("Expected error for inheritance cycle during resolution")
+	}
+}
+
+func TestCheckPermission_WithoutParentRoleColumn(t *testing.T) {
+	db, err := sql.Open("duckdb", ":memory:")
+	if err != nil {
+		t.Fatalf("Failed to open test database: %v", err)
+	}
+	defer db.Close()
+
+	// Auth databases created before role inheritance have no parent_role column
+	if _, err := db.Exec(`
+		CREATE TABLE roles (role_name VARCHAR PRIMARY KEY, description VARCHAR);
+		CREATE TABLE permissions (
+			id INTEGER PRIMARY KEY, role_name VARCHAR, table_name VARCHAR,
+			can_create BOOLEAN, can_read BOOLEAN, can_update BOOLEAN, can_delete BOOLEAN, can_query BOOLEAN
+		);
+		INSERT INTO roles VALUES ('reader', 'Read-only access');
+		INSERT INTO permissions VALUES (1, 'reader', '*', false, true, false, false, false);
+	`); err != nil {
+		t.Fatalf("Failed to create schema: %v", err)
+	}
+
+	auth := NewAuthorizer(db)
+	if allowed, err := auth.CheckPermission("reader", "orders", OperationRead); err != nil || !allowed {
+		t.Errorf("Expected read permission, got %v (err: %v)", allowed, err)
+	}
+	if allowed, err := auth.CheckPermission("unknown", "orders", OperationRead); err != nil || allowed {
+		t.Errorf("Expected no permission for unknown role, got %v (err: %v)", allowed, err)
+	}
+	if err := auth.SetRoleParent("reader", "admin"); err == nil {
+		t.Error("Expected error setting a parent without parent_role column")
+	}
+	if err := auth.SetRoleDefaultAccess("reader", DefaultAccessRead); err == nil {
+		t.Error("Expected error setting default access without default_table_access column")
+	}
+	if denied, err := auth.DeniedColumns("reader", "orders"); err != nil || denied != nil {
+		t.Errorf("Expected no denied columns without denied_columns column, got %v (err: %v)", denied, err)
+	}
+	if err := auth.SetDeniedColumns("reader", "*", []string{"ssn"}); err == nil {
+		t.Error("Expected error setting denied columns without denied_columns column")
+	}
+}
+
+func TestCreateNamespacedAPIKey(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	auth := NewAuthorizer(db)
+	if err := auth.CreateNamespacedAPIKey("app1_secret", "reader", "app1", nil); err != nil {
+		t.Fatalf("Failed to create namespaced API key: %v", err)
+	}
+	if err := auth.CreateAPIKey("plain-key", "reader", nil); err != nil {
+		t.Fatalf("Failed to create API key: %v", err)
+	}
+	if err := auth.CreateNamespacedAPIKey("secret", "reader", "app1", nil); err == nil {
+		t.Error("Expected error for a key without the namespace prefix")
+	}
+
+	key, err := auth.AuthenticateAPIKey("app1_secret")
+	if err != nil {
+		t.Fatalf("Expected authentication to succeed, got error: %v", err)
+	}
+	if key.Namespace != "app1" {
+		t.Errorf("Expected namespace 'app1', got %q", key.Namespace)
+	}
+
+	key, err = auth.AuthenticateAPIKey("plain-key")
+	if err != nil {
+		t.Fatalf("Expected authentication to succeed, got error: %v", err)
+	}
+	if key.Namespace != "" {
+		t.Errorf("Expected no namespace, got %q", key.Namespace)
+	}
+}
+
+func TestAuthenticateAPIKey_WithoutNamespaceColumn(t *testing.T) {
+	db, err := sql.Open("duckdb", ":memory:")
+	if err != nil {
+		t.Fatalf("Failed to open test database: %v", err)
+	}
+	defer db.Close()
+
+	// Auth databases created before key namespaces have no namespace column
+	if _, err := db.Exec(`
+		CREATE TABLE api_keys (
+			key VARCHAR PRIMARY KEY, role_name VARCHAR NOT NULL,
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP, expires_at TIMESTAMP, is_active BOOLEAN DEFAULT true
+		);
+		INSERT INTO api_keys (key, role_name) VALUES ('app1_old', 'reader');
+	`); err != nil {
+		t.Fatalf("Failed to create schema: %v", err)
+	}
+
+	auth := NewAuthorizer(db)
+	key, err := auth.AuthenticateAPIKey("app1_old")
+	if err != nil {
+		t.Fatalf("Expected authentication to succeed, got error: %v", err)
+	}
+	if key.Namespace != "" {
+		t.Errorf("Expected no namespace, got %q", key.Namespace)
+	}
+	if err := auth.CreateNamespacedAPIKey("app1_new", "reader", "app1", nil); err == nil {
+		t.Error("Expected error creating a namespaced key without namespace column")
+	}
+}
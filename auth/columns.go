@@ -0,0 +1,96 @@
+package auth
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ParseColumnList parses a comma-separated list of column names, as stored in
+// permissions.denied_columns. Blank entries are dropped; an empty list is nil.
+func ParseColumnList(list string) []string {
+	var columns []string
+	for _, col := range strings.Split(list, ",") {
+		if col = strings.TrimSpace(col); col != "" {
+			columns = append(columns, col)
+		}
+	}
+	return columns
+}
+
+// DeniedColumns returns the columns of a table that a role may not read. It
+// resolves like CheckPermission: the first role in the parent chain with a
+// grant for the table (table-specific or '*') decides. Roles reading through
+// their default table access have no column restrictions.
+func (a *Authorizer) DeniedColumns(roleName, tableName string) ([]string, error) {
+	cacheKey := roleName + ":" + tableName
+	if cached, ok := a.columnCache.Get(cacheKey); ok {
+		return cached, nil
+	}
+
+	var denied []string
+	visited := make(map[string]bool)
+	for role := roleName; role != ""; {
+		if visited[role] {
+			return nil, fmt.Errorf("role inheritance cycle detected at role '%s'", role)
+		}
+		visited[role] = true
+
+		perm, found, err := a.lookupPermission(role, tableName)
+		if err != nil {
+			return nil, err
+		}
+		if found {
+			denied = perm.DeniedColumns
+			break
+		}
+
+		if role, err = a.parentRole(role); err != nil {
+			return nil, err
+		}
+	}
+
+	a.columnCache.Add(cacheKey, denied)
+	return denied, nil
+}
+
+// SetDeniedColumns replaces the columns a role's grant for a table denies
+// reading, and invalidates the permission cache. An empty list lifts the
+// restriction.
+func (a *Authorizer) SetDeniedColumns(roleName, tableName string, columns []string) error {
+	if !a.hasDeniedColumns() {
+		return fmt.Errorf("auth database does not support column restrictions (permissions table has no denied_columns column)")
+	}
+
+	var list interface{}
+	if len(columns) > 0 {
+		list = strings.Join(columns, ",")
+	}
+	result, err := a.authDB.Exec(`UPDATE permissions SET denied_columns = $1 WHERE role_name = $2 AND table_name = $3`, list, roleName, tableName)
+	if err != nil {
+		return fmt.Errorf("failed to update denied columns: %w", err)
+	}
+
+	rows, _ := result.RowsAffected()
+	if rows == 0 {
+		return fmt.Errorf("permission not found for role '%s' and table '%s'", roleName, tableName)
+	}
+
+	a.InvalidatePermissionCache()
+	return nil
+}
+
+// hasDeniedColumns reports whether the auth database stores column restrictions.
+func (a *Authorizer) hasDeniedColumns() bool {
+	a.deniedColumnsOnce.Do(func() {
+		query := `
+			SELECT EXISTS (
+				SELECT 1 FROM information_schema.columns
+				WHERE table_name = 'permissions' AND column_name = 'denied_columns'
+			)
+		`
+		if err := a.authDB.QueryRow(query).Scan(&a.deniedColumns); err != nil {
+			a.deniedColumns = false
+		}
+	})
+	return a.deniedColumns
+}
@@ -0,0 +1,93 @@
+package auth
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseColumnList(t *testing.T) {
+	tests := []struct {
+		list string
+		want []string
+	}{
+		{"", nil},
+		{" , ", nil},
+		{"customer_ssn", []string{"customer_ssn"}},
+		{"customer_ssn, card_number ,", []string{"customer_ssn", "card_number"}},
+	}
+	for _, tt := range tests {
+		if got := ParseColumnList(tt.list); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("ParseColumnList(%q) = %v, want %v", tt.list, got, tt.want)
+		}
+	}
+}
+
+func TestDeniedColumns(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	auth := NewAuthorizer(db)
+	if err := auth.CreatePermission(Permission{RoleName: "reader", TableName: "*", CanRead: true}); err != nil {
+		t.Fatalf("Failed to create permission: %v", err)
+	}
+	if err := auth.CreatePermission(Permission{RoleName: "reader", TableName: "orders", CanRead: true}); err != nil {
+		t.Fatalf("Failed to create permission: %v", err)
+	}
+	if denied, err := auth.DeniedColumns("reader", "orders"); err != nil || denied != nil {
+		t.Fatalf("Expected no denied columns, got %v (err: %v)", denied, err)
+	}
+
+	// Setting a restriction invalidates the cached lookup
+	if err := auth.SetDeniedColumns("reader", "orders", []string{"customer_ssn", "card_number"}); err != nil {
+		t.Fatalf("SetDeniedColumns failed: %v", err)
+	}
+	denied, err := auth.DeniedColumns("reader", "orders")
+	if err != nil || !reflect.DeepEqual(denied, []string{"customer_ssn", "card_number"}) {
+		t.Errorf("Expected customer_ssn and card_number to be denied, got %v (err: %v)", denied, err)
+	}
+
+	// Other tables fall back to the '*' grant, which has no restriction
+	if denied, _ := auth.DeniedColumns("reader", "products"); denied != nil {
+		t.Errorf("Expected no denied columns on products, got %v", denied)
+	}
+
+	// Child roles inherit the restriction of the grant they inherit
+	if err := auth.CreateRole("analyst", "Reader"); err != nil {
+		t.Fatalf("Failed to create role: %v", err)
+	}
+	if err := auth.SetRoleParent("analyst", "reader"); err != nil {
+		t.Fatalf("Failed to set parent role: %v", err)
+	}
+	if denied, _ := auth.DeniedColumns("analyst", "orders"); !reflect.DeepEqual(denied, []string{"customer_ssn", "card_number"}) {
+		t.Errorf("Expected analyst to inherit denied columns, got %v", denied)
+	}
+
+	// A role's own grant replaces the inherited restriction
+	if err := auth.CreatePermission(Permission{RoleName: "analyst", TableName: "orders", CanRead: true}); err != nil {
+		t.Fatalf("Failed to create permission: %v", err)
+	}
+	if denied, _ := auth.DeniedColumns("analyst", "orders"); denied != nil {
+		t.Errorf("Expected analyst's own grant to lift the restriction, got %v", denied)
+	}
+
+	perms, err := auth.GetPermissions("reader")
+	if err != nil {
+		t.Fatalf("GetPermissions failed: %v", err)
+	}
+	for _, p := range perms {
+		if p.TableName == "orders" && len(p.DeniedColumns) != 2 {
+			t.Errorf("Expected GetPermissions to report the denied columns, got %+v", p)
+		}
+	}
+
+	// An empty list lifts the restriction
+	if err := auth.SetDeniedColumns("reader", "orders", nil); err != nil {
+		t.Fatalf("SetDeniedColumns failed: %v", err)
+	}
+	if denied, _ := auth.DeniedColumns("reader", "orders"); denied != nil {
+		t.Errorf("Expected no denied columns after clearing, got %v", denied)
+	}
+	if err := auth.SetDeniedColumns("reader", "customers", []string{"email"}); err == nil {
+		t.Error("Expected error for a table without a grant")
+	}
+}
@@ -0,0 +1,124 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"time"
+)
+
+var (
+	// ErrDownloadTokenNotFound is returned for tokens that were never issued.
+	ErrDownloadTokenNotFound = errors.New("download token not found")
+	// ErrDownloadTokenGone is returned for tokens that expired or were already redeemed.
+	ErrDownloadTokenGone = errors.New("download token has expired or was already used")
+)
+
+// downloadTokenRetention is how long expired tokens are kept so that redeeming
+// them reports 410 Gone instead of 404 Not Found.
+const downloadTokenRetention = 24 * time.Hour
+
+// DownloadGrant is what a download token allows: a single read of a table with
+// fixed parameters, executed with the permissions of the role that minted it.
+type DownloadGrant struct {
+	RoleName  string
+	TableName string
+	Query     string // URL-encoded read parameters (filter, sort, ...)
+	Format    string
+	ExpiresAt time.Time
+}
+
+// InitDownloadTokens creates the download_tokens table if it does not exist.
+// Only token hashes are stored, so a leaked auth database does not leak links.
+func (a *Authorizer) InitDownloadTokens() error {
+	schema := `
+		CREATE TABLE IF NOT EXISTS download_tokens (
+			token_hash VARCHAR PRIMARY KEY,
+			role_name VARCHAR NOT NULL,
+			table_name VARCHAR NOT NULL,
+			query VARCHAR,
+			format VARCHAR NOT NULL,
+			expires_at TIMESTAMP NOT NULL,
+			used_at TIMESTAMP
+		)
+	`
+	if _, err := a.authDB.Exec(schema); err != nil {
+		return fmt.Errorf("failed to create download_tokens table: %w", err)
+	}
+	return nil
+}
+
+// hashDownloadToken returns the stored form of a token.
+func hashDownloadToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// CreateDownloadToken stores a grant and returns the single-use token for it.
+// Tokens that expired more than a day ago are removed along the way.
+func (a *Authorizer) CreateDownloadToken(grant DownloadGrant) (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate download token: %w", err)
+	}
+	token := base64.RawURLEncoding.EncodeToString(b)
+
+	now := time.Now().UTC()
+	if _, err := a.authDB.Exec(`DELETE FROM download_tokens WHERE expires_at < $1`, now.Add(-downloadTokenRetention)); err != nil {
+		return "", fmt.Errorf("failed to remove expired download tokens: %w", err)
+	}
+
+	query := `
+		INSERT INTO download_tokens (token_hash, role_name, table_name, query, format, expires_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+	`
+	_, err := a.authDB.Exec(query, hashDownloadToken(token), grant.RoleName, grant.TableName, grant.Query, grant.Format, grant.ExpiresAt.UTC())
+	if err != nil {
+		return "", fmt.Errorf("failed to create download token: %w", err)
+	}
+
+	return token, nil
+}
+
+// RedeemDownloadToken marks a token as used and returns its grant. A token can
+// be redeemed exactly once before it expires; afterwards ErrDownloadTokenGone is
+// returned.
+func (a *Authorizer) RedeemDownloadToken(token string) (*DownloadGrant, error) {
+	hash := hashDownloadToken(token)
+	now := time.Now().UTC()
+
+	query := `
+		UPDATE download_tokens
+		SET used_at = $2
+		WHERE token_hash = $1 AND used_at IS NULL AND expires_at > $2
+		RETURNING role_name, table_name, COALESCE(query, ''), format, expires_at
+	`
+	var grant DownloadGrant
+	err := a.authDB.QueryRow(query, hash, now).Scan(
+		&grant.RoleName,
+		&grant.TableName,
+		&grant.Query,
+		&grant.Format,
+		&grant.ExpiresAt,
+	)
+	if err == nil {
+		return &grant, nil
+	}
+	if err != sql.ErrNoRows {
+		return nil, fmt.Errorf("failed to redeem download token: %w", err)
+	}
+
+	// Distinguish unknown tokens from used or expired ones
+	var exists bool
+	if err := a.authDB.QueryRow(`SELECT EXISTS (SELECT 1 FROM download_tokens WHERE token_hash = $1)`, hash).Scan(&exists); err != nil {
+		return nil, fmt.Errorf("failed to query download token: %w", err)
+	}
+	if exists {
+		return nil, ErrDownloadTokenGone
+	}
+	return nil, ErrDownloadTokenNotFound
+}
@@ -0,0 +1,71 @@
+package auth
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestDownloadToken_RedeemOnce(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	auth := NewAuthorizer(db)
+	if err := auth.InitDownloadTokens(); err != nil {
+		t.Fatalf("InitDownloadTokens failed: %v", err)
+	}
+
+	token, err := auth.CreateDownloadToken(DownloadGrant{
+		RoleName:  "reader",
+		TableName: "orders",
+		Query:     "filter=status%3Aeq%3Aopen",
+		Format:    "csv",
+		ExpiresAt: time.Now().Add(time.Minute),
+	})
+	if err != nil {
+		t.Fatalf("CreateDownloadToken failed: %v", err)
+	}
+
+	// Only the hash is stored
+	var stored int
+	db.QueryRow("SELECT COUNT(*) FROM download_tokens WHERE token_hash = ?", token).Scan(&stored)
+	if stored != 0 {
+		t.Error("Expected the raw token not to be stored")
+	}
+
+	grant, err := auth.RedeemDownloadToken(token)
+	if err != nil {
+		t.Fatalf("RedeemDownloadToken failed: %v", err)
+	}
+	if grant.RoleName != "reader" || grant.TableName != "orders" || grant.Format != "csv" || grant.Query != "filter=status%3Aeq%3Aopen" {
+		t.Errorf("Unexpected grant: %+v", grant)
+	}
+
+	if _, err := auth.RedeemDownloadToken(token); !errors.Is(err, ErrDownloadTokenGone) {
+		t.Errorf("Expected ErrDownloadTokenGone on second redemption, got %v", err)
+	}
+	if _, err := auth.RedeemDownloadToken("unknown"); !errors.Is(err, ErrDownloadTokenNotFound) {
+		t.Errorf("Expected ErrDownloadTokenNotFound, got %v", err)
+	}
+}
+
+func TestDownloadToken_Expired(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	auth := NewAuthorizer(db)
+	auth.InitDownloadTokens()
+
+	token, err := auth.CreateDownloadToken(DownloadGrant{
+		RoleName:  "reader",
+		TableName: "orders",
+		Format:    "json",
+		ExpiresAt: time.Now().Add(-time.Second),
+	})
+	if err != nil {
+		t.Fatalf("CreateDownloadToken failed: %v", err)
+	}
+	if _, err := auth.RedeemDownloadToken(token); !errors.Is(err, ErrDownloadTokenGone) {
+		t.Errorf("Expected ErrDownloadTokenGone for expired token, got %v", err)
+	}
+}
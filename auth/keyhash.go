@@ -0,0 +1,70 @@
+package auth
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strings"
+)
+
+// HashedKeyPrefix marks API keys stored as a hash rather than in plaintext.
+const HashedKeyPrefix = "sha256:"
+
+// HashAPIKey returns the value stored in the api_keys table for apiKey:
+// HashedKeyPrefix followed by the hex-encoded SHA-256 digest of the key.
+// API keys are random tokens, so an unsalted digest is sufficient.
+func HashAPIKey(apiKey string) string {
+	sum := sha256.Sum256([]byte(apiKey))
+	return HashedKeyPrefix + hex.EncodeToString(sum[:])
+}
+
+// IsHashedAPIKey reports whether a stored key is a hash produced by HashAPIKey.
+func IsHashedAPIKey(stored string) bool {
+	return strings.HasPrefix(stored, HashedKeyPrefix)
+}
+
+// apiKeyMatch is the WHERE condition matching a presented API key: $1 is its
+// hash and $2 the key itself. Keys stored before hashing was introduced match
+// in plaintext until migrated; a stored hash is never accepted as a key.
+const apiKeyMatch = `(key = $1 OR (key = $2 AND NOT starts_with(key, '` + HashedKeyPrefix + `')))`
+
+// MigratePlaintextAPIKeys replaces every API key stored in plaintext with its
+// hash and returns the number of migrated keys. Clients keep using the same
+// keys. The migration runs in a transaction and is safe to repeat.
+func (a *Authorizer) MigratePlaintextAPIKeys() (int, error) {
+	tx, err := a.authDB.Begin()
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin migration: %w", err)
+	}
+	defer tx.Rollback()
+
+	rows, err := tx.Query(`SELECT key FROM api_keys WHERE NOT starts_with(key, '` + HashedKeyPrefix + `')`)
+	if err != nil {
+		return 0, fmt.Errorf("failed to query API keys: %w", err)
+	}
+	var plaintext []string
+	for rows.Next() {
+		var key string
+		if err := rows.Scan(&key); err != nil {
+			rows.Close()
+			return 0, fmt.Errorf("failed to scan API key: %w", err)
+		}
+		plaintext = append(plaintext, key)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, fmt.Errorf("failed to query API keys: %w", err)
+	}
+
+	for _, key := range plaintext {
+		if _, err := tx.Exec("UPDATE api_keys SET key = $1 WHERE key = $2", HashAPIKey(key), key); err != nil {
+			return 0, fmt.Errorf("failed to migrate API key: %w", err)
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to commit migration: %w", err)
+	}
+
+	a.InvalidateAPIKeyCache()
+	return len(plaintext), nil
+}
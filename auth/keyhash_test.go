@@ -0,0 +1,111 @@
+package auth
+
+import (
+	"testing"
+)
+
+func TestHashAPIKey(t *testing.T) {
+	hash := HashAPIKey("test-key")
+	if !IsHashedAPIKey(hash) || len(hash) != len(HashedKeyPrefix)+64 {
+		t.Errorf("Unexpected hash format: %s", hash)
+	}
+	if hash != HashAPIKey("test-key") {
+		t.Error("Expected hashing to be deterministic")
+	}
+	if hash == HashAPIKey("other-key") {
+		t.Error("Expected different keys to have different hashes")
+	}
+	if IsHashedAPIKey("test-key") {
+		t.Error("Expected a plaintext key not to be reported as hashed")
+	}
+}
+
+func TestCreateAPIKey_StoresHash(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+	auth := NewAuthorizer(db)
+
+	if err := auth.CreateAPIKey("hashed-key-12345", "admin", nil); err != nil {
+		t.Fatalf("Failed to create API key: %v", err)
+	}
+
+	var stored string
+	if err := db.QueryRow("SELECT key FROM api_keys").Scan(&stored); err != nil {
+		t.Fatalf("Failed to read stored key: %v", err)
+	}
+	if stored != HashAPIKey("hashed-key-12345") {
+		t.Errorf("Expected the hash to be stored, got %s", stored)
+	}
+
+	// The stored hash is not itself a valid key
+	if _, err := auth.AuthenticateAPIKey(stored); err == nil {
+		t.Error("Expected authentication with the stored hash to fail")
+	}
+}
+
+func TestAuthenticateAPIKey_Plaintext(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+	auth := NewAuthorizer(db)
+
+	// A key stored before hashing was introduced
+	if _, err := db.Exec("INSERT INTO api_keys (key, role_name) VALUES ('legacy-key-12345', 'reader')"); err != nil {
+		t.Fatalf("Failed to insert key: %v", err)
+	}
+
+	key, err := auth.AuthenticateAPIKey("legacy-key-12345")
+	if err != nil {
+		t.Fatalf("Expected plaintext key to authenticate: %v", err)
+	}
+	if key.Key != "legacy-key-12345" || key.RoleName != "reader" {
+		t.Errorf("Unexpected key: %+v", key)
+	}
+
+	if err := auth.RevokeAPIKey("legacy-key-12345"); err != nil {
+		t.Fatalf("Failed to revoke plaintext key: %v", err)
+	}
+	if _, err := auth.AuthenticateAPIKey("legacy-key-12345"); err == nil {
+		t.Error("Expected revoked plaintext key to fail authentication")
+	}
+}
+
+func TestMigratePlaintextAPIKeys(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+	auth := NewAuthorizer(db)
+
+	if _, err := db.Exec("INSERT INTO api_keys (key, role_name) VALUES ('legacy-one', 'admin'), ('legacy-two', 'reader')"); err != nil {
+		t.Fatalf("Failed to insert keys: %v", err)
+	}
+	if err := auth.CreateAPIKey("already-hashed", "admin", nil); err != nil {
+		t.Fatalf("Failed to create API key: %v", err)
+	}
+	// Cache a plaintext key before migrating
+	if _, err := auth.AuthenticateAPIKey("legacy-one"); err != nil {
+		t.Fatalf("Expected plaintext key to authenticate: %v", err)
+	}
+
+	migrated, err := auth.MigratePlaintextAPIKeys()
+	if err != nil {
+		t.Fatalf("Migration failed: %v", err)
+	}
+	if migrated != 2 {
+		t.Errorf("Expected 2 migrated keys, got %d", migrated)
+	}
+
+	var plaintext int
+	db.QueryRow("SELECT COUNT(*) FROM api_keys WHERE NOT starts_with(key, 'sha256:')").Scan(&plaintext)
+	if plaintext != 0 {
+		t.Errorf("Expected no plaintext keys after migration, got %d", plaintext)
+	}
+	for _, k := range []string{"legacy-one", "legacy-two", "already-hashed"} {
+		if _, err := auth.AuthenticateAPIKey(k); err != nil {
+			t.Errorf("Expected %s to authenticate after migration: %v", k, err)
+		}
+	}
+
+	// Repeating the migration is a no-op
+	if migrated, err := auth.MigratePlaintextAPIKeys(); err != nil || migrated != 0 {
+		t.Errorf("Expected no keys to migrate, got %d (%v)", migrated, err)
+	}
+}
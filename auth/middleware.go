@@ -3,8 +3,12 @@ package auth
 import (
 	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"net/http"
+	"strconv"
 	"strings"
+	"time"
 )
 
 // contextKey is a custom type for context keys to avoid collisions.
@@ -19,9 +23,15 @@ const (
 	ContextKeyRequestID contextKey = "request_id"
 )
 
+// DefaultAPIKeyHeader is the request header carrying the API key unless
+// another one is configured.
+const DefaultAPIKeyHeader = "X-API-Key"
+
 // Middleware provides authentication and authorization middleware.
 type Middleware struct {
 	authorizer *Authorizer
+	header     string
+	limiter    *RateLimiter
 }
 
 // NewMiddleware creates a new auth middleware.
@@ -31,29 +41,93 @@ func NewMiddleware(authorizer *Authorizer) *Middleware {
 	}
 }
 
+// SetAPIKeyHeader sets the request header carrying the API key. With
+// "Authorization" the key is sent as a bearer token ("Authorization: Bearer <key>").
+// An empty name restores DefaultAPIKeyHeader.
+func (m *Middleware) SetAPIKeyHeader(name string) {
+	m.header = name
+}
+
+// SetRateLimiter sets the limiter applied to authenticated requests. nil
+// disables rate limiting.
+func (m *Middleware) SetRateLimiter(limiter *RateLimiter) {
+	m.limiter = limiter
+}
+
+// CheckRateLimit takes a request from the rate limit of the authenticated API
+// key in the request context, sets the RateLimit-Limit, RateLimit-Remaining,
+// and RateLimit-Reset headers, and returns false with a Retry-After header if
+// the key has exceeded its limit. Without a rate limiter it does nothing.
+func (m *Middleware) CheckRateLimit(w http.ResponseWriter, r *http.Request) bool {
+	status, allowed := m.limiter.Allow(r.Context())
+	if status.Limit == 0 {
+		return true
+	}
+	w.Header().Set("RateLimit-Limit", strconv.Itoa(status.Limit))
+	w.Header().Set("RateLimit-Remaining", strconv.Itoa(status.Remaining))
+	w.Header().Set("RateLimit-Reset", strconv.Itoa(ceilSeconds(status.Reset)))
+	if !allowed {
+		w.Header().Set("Retry-After", strconv.Itoa(ceilSeconds(status.RetryAfter)))
+	}
+	return allowed
+}
+
+// ceilSeconds returns d in whole seconds, rounded up.
+func ceilSeconds(d time.Duration) int {
+	return int((d + time.Second - 1) / time.Second)
+}
+
+// APIKeyHeader returns the name of the request header carrying the API key.
+func (m *Middleware) APIKeyHeader() string {
+	if m.header == "" {
+		return DefaultAPIKeyHeader
+	}
+	return m.header
+}
+
+// ExtractAPIKey returns the API key sent with the request, or "" if there is none.
+func (m *Middleware) ExtractAPIKey(r *http.Request) string {
+	header := m.APIKeyHeader()
+	value := strings.TrimSpace(r.Header.Get(header))
+	if !strings.EqualFold(header, "Authorization") {
+		return value
+	}
+	scheme, token, ok := strings.Cut(value, " ")
+	if !ok || !strings.EqualFold(scheme, "Bearer") {
+		return ""
+	}
+	return strings.TrimSpace(token)
+}
+
 // Authenticate extracts and validates the API key from the request.
 func (m *Middleware) Authenticate(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		// Extract API key from header
-		apiKey := r.Header.Get("X-API-Key")
+		apiKey := m.ExtractAPIKey(r)
 		if apiKey == "" {
-			m.sendError(w, "Missing X-API-Key header", http.StatusUnauthorized)
+			m.sendError(w, fmt.Sprintf("Missing %s header", m.APIKeyHeader()), http.StatusUnauthorized)
 			return
 		}
 
 		// Validate API key
 		key, err := m.authorizer.AuthenticateAPIKey(apiKey)
 		if err != nil {
-			m.sendError(w, "Invalid or expired API key", http.StatusUnauthorized)
+			m.sendError(w, AuthenticationErrorMessage(err), http.StatusUnauthorized)
 			return
 		}
 
 		// Add API key and role to context
 		ctx := context.WithValue(r.Context(), ContextKeyAPIKey, key)
 		ctx = context.WithValue(ctx, ContextKeyRole, key.RoleName)
+		r = r.WithContext(ctx)
+
+		if !m.CheckRateLimit(w, r) {
+			m.sendError(w, "Rate limit exceeded", http.StatusTooManyRequests)
+			return
+		}
 
 		// Call next handler
-		next.ServeHTTP(w, r.WithContext(ctx))
+		next.ServeHTTP(w, r)
 	})
 }
 
@@ -87,6 +161,20 @@ func (m *Middleware) Authorize(tableName string, operation Operation) func(http.
 }
 
 // sendError sends a JSON error response.
+// AuthenticationErrorMessage returns the client-facing message for an
+// AuthenticateAPIKey error, telling expired and disabled keys apart from
+// unknown ones. Other errors (e.g. a failed lookup) are not exposed.
+func AuthenticationErrorMessage(err error) string {
+	switch {
+	case errors.Is(err, ErrExpiredAPIKey):
+		return "API key has expired"
+	case errors.Is(err, ErrDisabledAPIKey):
+		return "API key has been disabled"
+	default:
+		return "Invalid API key"
+	}
+}
+
 func (m *Middleware) sendError(w http.ResponseWriter, message string, statusCode int) {
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(statusCode)
@@ -110,7 +198,7 @@ func GetAPIKeyFromContext(ctx context.Context) *APIKey {
 }
 
 // ExtractTableName extracts the table name from the request path.
-// Expects paths like /duckdb/api/{table}
+// Expects paths like /duckdb/api/{table}; a trailing slash is ignored.
 func ExtractTableName(path string) string {
 	parts := strings.Split(strings.Trim(path, "/"), "/")
 	if len(parts) >= 3 && parts[0] == "duckdb" && parts[1] == "api" {
@@ -122,9 +210,10 @@ func ExtractTableName(path string) string {
 // IsInternalTable checks if a table is an internal auth table.
 func IsInternalTable(tableName string) bool {
 	internalTables := map[string]bool{
-		"api_keys":    true,
-		"roles":       true,
-		"permissions": true,
+		"api_keys":        true,
+		"roles":           true,
+		"permissions":     true,
+		"download_tokens": true,
 	}
 	return internalTables[tableName]
 }
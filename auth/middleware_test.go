@@ -89,6 +89,68 @@ func TestMiddleware_Authenticate_ValidKey(t *testing.T) {
 	}
 }
 
+func TestMiddleware_Authenticate_NamespacedKey(t *testing.T) {
+	mw, authorizer, cleanup := setupMiddlewareTest(t)
+	defer cleanup()
+
+	if err := authorizer.CreateNamespacedAPIKey("app1_key", "reader", "app1", nil); err != nil {
+		t.Fatalf("Failed to create namespaced API key: %v", err)
+	}
+
+	var namespace string
+	handler := mw.Authenticate(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		namespace = GetNamespaceFromContext(r.Context())
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	req := httptest.NewRequest("GET", "/", nil)
+	req.Header.Set("X-API-Key", "app1_key")
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", rec.Code)
+	}
+	if namespace != "app1" {
+		t.Errorf("Expected namespace 'app1' in context, got %q", namespace)
+	}
+}
+
+func TestMiddleware_Authenticate_RateLimit(t *testing.T) {
+	mw, _, cleanup := setupMiddlewareTest(t)
+	defer cleanup()
+	mw.SetRateLimiter(NewRateLimiter(2, time.Minute))
+
+	handler := mw.Authenticate(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	var codes []int
+	var remaining []string
+	var rec *httptest.ResponseRecorder
+	for i := 0; i < 4; i++ {
+		req := httptest.NewRequest("GET", "/", nil)
+		req.Header.Set("X-API-Key", "test-key")
+		rec = httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		codes = append(codes, rec.Code)
+		remaining = append(remaining, rec.Header().Get("RateLimit-Remaining"))
+	}
+
+	if codes[0] != http.StatusOK || codes[1] != http.StatusOK || codes[2] != http.StatusTooManyRequests || codes[3] != http.StatusTooManyRequests {
+		t.Errorf("Expected 200, 200, 429, 429, got %v", codes)
+	}
+	if remaining[0] != "1" || remaining[1] != "0" {
+		t.Errorf("Expected RateLimit-Remaining to count down 1, 0, got %v", remaining)
+	}
+	if rec.Header().Get("RateLimit-Limit") != "2" {
+		t.Errorf("Expected RateLimit-Limit 2, got %q", rec.Header().Get("RateLimit-Limit"))
+	}
+	if rec.Header().Get("Retry-After") != "30" {
+		t.Errorf("Expected Retry-After 30, got %q", rec.Header().Get("Retry-After"))
+	}
+}
+
 func TestMiddleware_Authenticate_MissingKey(t *testing.T) {
 	mw, _, cleanup := setupMiddlewareTest(t)
 	defer cleanup()
@@ -146,6 +208,54 @@ func TestMiddleware_Authenticate_InvalidKey(t *testing.T) {
 	}
 }
 
+func TestMiddleware_APIKeyHeader(t *testing.T) {
+	mw, _, cleanup := setupMiddlewareTest(t)
+	defer cleanup()
+
+	tests := []struct {
+		name       string
+		configured string
+		header     string
+		value      string
+		wantStatus int
+	}{
+		{"default header", "", "X-API-Key", "test-key", http.StatusOK},
+		{"default ignores others", "", "X-Gateway-Key", "test-key", http.StatusUnauthorized},
+		{"custom header", "X-Gateway-Key", "X-Gateway-Key", "test-key", http.StatusOK},
+		{"custom ignores default", "X-Gateway-Key", "X-API-Key", "test-key", http.StatusUnauthorized},
+		{"bearer token", "Authorization", "Authorization", "Bearer test-key", http.StatusOK},
+		{"bearer scheme case", "authorization", "Authorization", "bearer  test-key", http.StatusOK},
+		{"bare authorization", "Authorization", "Authorization", "test-key", http.StatusUnauthorized},
+		{"basic scheme", "Authorization", "Authorization", "Basic test-key", http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mw.SetAPIKeyHeader(tt.configured)
+			handler := mw.Authenticate(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusOK)
+			}))
+
+			req := httptest.NewRequest("GET", "/", nil)
+			req.Header.Set(tt.header, tt.value)
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+
+			if rec.Code != tt.wantStatus {
+				t.Errorf("Expected status %d, got %d: %s", tt.wantStatus, rec.Code, rec.Body.String())
+			}
+		})
+	}
+
+	mw.SetAPIKeyHeader("X-Gateway-Key")
+	rec := httptest.NewRecorder()
+	mw.Authenticate(http.NotFoundHandler()).ServeHTTP(rec, httptest.NewRequest("GET", "/", nil))
+	var result map[string]interface{}
+	json.Unmarshal(rec.Body.Bytes(), &result)
+	if result["message"] != "Missing X-Gateway-Key header" {
+		t.Errorf("Expected the configured header in the error, got %v", result["message"])
+	}
+}
+
 func TestMiddleware_Authorize_Allowed(t *testing.T) {
 	mw, _, cleanup := setupMiddlewareTest(t)
 	defer cleanup()
@@ -296,6 +406,7 @@ func TestExtractTableName(t *testing.T) {
 		{"/api/users", ""},
 		{"/duckdb/users", ""},
 		{"/duckdb/api/users/123", "users"},
+		{"/duckdb/api/users/", "users"},
 		{"/", ""},
 		{"", ""},
 	}
@@ -486,3 +597,46 @@ func BenchmarkMiddleware_Authorize(b *testing.B) {
 		handler.ServeHTTP(rec, req)
 	}
 }
+
+func TestMiddleware_AuthenticationErrors(t *testing.T) {
+	mw, authorizer, cleanup := setupMiddlewareTest(t)
+	defer cleanup()
+
+	past := time.Now().Add(-time.Hour)
+	if err := authorizer.CreateAPIKey("expired-key", "admin", &past); err != nil {
+		t.Fatalf("Failed to create API key: %v", err)
+	}
+	if err := authorizer.CreateAPIKey("disabled-key", "admin", nil); err != nil {
+		t.Fatalf("Failed to create API key: %v", err)
+	}
+	if err := authorizer.RevokeAPIKey("disabled-key"); err != nil {
+		t.Fatalf("Failed to revoke API key: %v", err)
+	}
+
+	handler := mw.Authenticate(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	tests := []struct {
+		key         string
+		wantMessage string
+	}{
+		{"expired-key", "API key has expired"},
+		{"disabled-key", "API key has been disabled"},
+		{"unknown-key", "Invalid API key"},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest("GET", "/duckdb/api/users", nil)
+		req.Header.Set("X-API-Key", tt.key)
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+
+		if rec.Code != http.StatusUnauthorized {
+			t.Errorf("%s: expected status 401, got %d", tt.key, rec.Code)
+		}
+		var body map[string]interface{}
+		json.Unmarshal(rec.Body.Bytes(), &body)
+		if body["message"] != tt.wantMessage {
+			t.Errorf("%s: expected message %q, got %v", tt.key, tt.wantMessage, body["message"])
+		}
+	}
+}
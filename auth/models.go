@@ -4,19 +4,31 @@ import "time"
 
 // APIKey represents an API key in the system.
 type APIKey struct {
-	Key       string
+	Key       string // the key as presented; the auth database stores only its hash
 	RoleName  string
 	CreatedAt time.Time
 	ExpiresAt *time.Time
 	IsActive  bool
+	Namespace string // optional; the application the key belongs to
 }
 
 // Role represents a role in the system.
 type Role struct {
 	RoleName    string
 	Description string
+	ParentRole  string // optional; the role inherits the parent's permissions
+	// DefaultTableAccess is the access to tables no grant matches (see
+	// DefaultAccessDeny). Empty means deny.
+	DefaultTableAccess string
 }
 
+// Default table access levels of a role.
+const (
+	DefaultAccessDeny = "deny" // no access (default)
+	DefaultAccessRead = "read" // read only
+	DefaultAccessCRUD = "crud" // create, read, update, and delete; never raw queries
+)
+
 // Permission represents a permission for a role on a table.
 type Permission struct {
 	ID        int
@@ -27,6 +39,8 @@ type Permission struct {
 	CanUpdate bool
 	CanDelete bool
 	CanQuery  bool
+	// DeniedColumns are the columns of the table the role may not read.
+	DeniedColumns []string
 }
 
 // Operation represents a database operation type.
@@ -0,0 +1,46 @@
+package auth
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// NamespaceSeparator separates a namespace from the random part of a namespaced
+// API key, e.g. app1_<random>.
+const NamespaceSeparator = "_"
+
+// ValidateNamespace checks that a namespace is non-empty and contains only
+// letters, digits, and hyphens, so it cannot contain the separator.
+func ValidateNamespace(namespace string) error {
+	if namespace == "" {
+		return fmt.Errorf("namespace cannot be empty")
+	}
+	for _, r := range namespace {
+		if !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '-') {
+			return fmt.Errorf("invalid namespace '%s': only letters, digits, and '-' are allowed", namespace)
+		}
+	}
+	return nil
+}
+
+// CheckNamespacedKey checks that an API key carries the namespace prefix.
+func CheckNamespacedKey(apiKey, namespace string) error {
+	if err := ValidateNamespace(namespace); err != nil {
+		return err
+	}
+	prefix := namespace + NamespaceSeparator
+	if !strings.HasPrefix(apiKey, prefix) || len(apiKey) == len(prefix) {
+		return fmt.Errorf("API key for namespace '%s' must start with '%s'", namespace, prefix)
+	}
+	return nil
+}
+
+// GetNamespaceFromContext returns the namespace of the authenticated API key,
+// or "" if the key has none.
+func GetNamespaceFromContext(ctx context.Context) string {
+	if key := GetAPIKeyFromContext(ctx); key != nil {
+		return key.Namespace
+	}
+	return ""
+}
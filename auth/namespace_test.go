@@ -0,0 +1,49 @@
+package auth
+
+import (
+	"context"
+	"testing"
+)
+
+func TestValidateNamespace(t *testing.T) {
+	for _, ns := range []string{"app1", "billing-service", "A"} {
+		if err := ValidateNamespace(ns); err != nil {
+			t.Errorf("Expected %q to be valid, got %v", ns, err)
+		}
+	}
+	for _, ns := range []string{"", "app_1", "app 1", "app/1", "äpp"} {
+		if err := ValidateNamespace(ns); err == nil {
+			t.Errorf("Expected %q to be invalid", ns)
+		}
+	}
+}
+
+func TestCheckNamespacedKey(t *testing.T) {
+	tests := []struct {
+		key       string
+		namespace string
+		valid     bool
+	}{
+		{"app1_abc123", "app1", true},
+		{"app1_", "app1", false},
+		{"app2_abc123", "app1", false},
+		{"app1abc123", "app1", false},
+		{"app_1_abc", "app_1", false},
+	}
+	for _, tt := range tests {
+		err := CheckNamespacedKey(tt.key, tt.namespace)
+		if (err == nil) != tt.valid {
+			t.Errorf("CheckNamespacedKey(%q, %q) = %v, expected valid=%v", tt.key, tt.namespace, err, tt.valid)
+		}
+	}
+}
+
+func TestGetNamespaceFromContext(t *testing.T) {
+	if ns := GetNamespaceFromContext(context.Background()); ns != "" {
+		t.Errorf("Expected empty namespace without an API key, got %q", ns)
+	}
+	ctx := SetContextValues(context.Background(), &APIKey{Key: "app1_abc", RoleName: "reader", Namespace: "app1"}, "reader")
+	if ns := GetNamespaceFromContext(ctx); ns != "app1" {
+		t.Errorf("Expected namespace 'app1', got %q", ns)
+	}
+}
@@ -0,0 +1,46 @@
+package auth
+
+import (
+	"context"
+	"fmt"
+)
+
+// QueryLabelHeader is the request header a client sets to label its requests,
+// e.g. with its team or application, for usage attribution.
+const QueryLabelHeader = "X-Query-Label"
+
+// MaxQueryLabelLength is the maximum length of a query label.
+const MaxQueryLabelLength = 64
+
+// ContextKeyQueryLabel is the context key for the query label of the request.
+const ContextKeyQueryLabel contextKey = "query_label"
+
+// ValidateQueryLabel checks that a query label is non-empty, at most
+// MaxQueryLabelLength characters long, and contains only letters, digits,
+// '.', '_', and '-', so it is safe in query tags, logs, and metric labels.
+func ValidateQueryLabel(label string) error {
+	if label == "" {
+		return fmt.Errorf("query label cannot be empty")
+	}
+	if len(label) > MaxQueryLabelLength {
+		return fmt.Errorf("query label is longer than %d characters", MaxQueryLabelLength)
+	}
+	for _, r := range label {
+		if !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '.' || r == '_' || r == '-') {
+			return fmt.Errorf("invalid query label '%s': only letters, digits, '.', '_', and '-' are allowed", label)
+		}
+	}
+	return nil
+}
+
+// SetQueryLabel sets the query label in the context.
+func SetQueryLabel(ctx context.Context, label string) context.Context {
+	return context.WithValue(ctx, ContextKeyQueryLabel, label)
+}
+
+// GetQueryLabelFromContext returns the query label of the request, or "" if
+// the client sent none.
+func GetQueryLabelFromContext(ctx context.Context) string {
+	label, _ := ctx.Value(ContextKeyQueryLabel).(string)
+	return label
+}
@@ -0,0 +1,31 @@
+package auth
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestValidateQueryLabel(t *testing.T) {
+	for _, label := range []string{"team-analytics", "app_1", "billing.v2", strings.Repeat("a", MaxQueryLabelLength)} {
+		if err := ValidateQueryLabel(label); err != nil {
+			t.Errorf("Expected %q to be valid, got %v", label, err)
+		}
+	}
+	for _, label := range []string{"", "team analytics", "a*/b", "a\nb", "täm", strings.Repeat("a", MaxQueryLabelLength+1)} {
+		if err := ValidateQueryLabel(label); err == nil {
+			t.Errorf("Expected %q to be invalid", label)
+		}
+	}
+}
+
+func TestQueryLabelContext(t *testing.T) {
+	ctx := context.Background()
+	if label := GetQueryLabelFromContext(ctx); label != "" {
+		t.Errorf("Expected no label, got %q", label)
+	}
+	ctx = SetQueryLabel(ctx, "team-analytics")
+	if label := GetQueryLabelFromContext(ctx); label != "team-analytics" {
+		t.Errorf("Expected label team-analytics, got %q", label)
+	}
+}
@@ -0,0 +1,125 @@
+package auth
+
+import (
+	"context"
+	"math"
+	"sync"
+	"time"
+)
+
+// RateLimiter limits the request rate of each API key with a token bucket:
+// a key may send up to limit requests at once, and regains limit requests
+// per window at an even rate.
+type RateLimiter struct {
+	mu      sync.Mutex
+	limit   int
+	window  time.Duration
+	buckets map[string]*rateBucket
+	swept   time.Time
+	now     func() time.Time
+}
+
+// rateBucket is the token bucket of one API key.
+type rateBucket struct {
+	tokens  float64
+	updated time.Time
+}
+
+// RateLimitStatus is the state of an API key's bucket after a request.
+type RateLimitStatus struct {
+	// Limit is the configured number of requests per window.
+	Limit int
+	// Remaining is the number of requests the key may send right away.
+	Remaining int
+	// Reset is the time until the bucket is full again.
+	Reset time.Duration
+	// RetryAfter is the time until the next request is allowed; zero unless
+	// the request was refused.
+	RetryAfter time.Duration
+}
+
+// NewRateLimiter creates a rate limiter allowing limit requests per window and
+// API key. A limit of 0 disables it.
+func NewRateLimiter(limit int, window time.Duration) *RateLimiter {
+	return &RateLimiter{
+		limit:   limit,
+		window:  window,
+		buckets: make(map[string]*rateBucket),
+		now:     time.Now,
+	}
+}
+
+// Allow takes a token from the bucket of the API key in the context. It returns
+// false if the bucket is empty, with the bucket's status either way. A nil or
+// disabled limiter and requests without an API key are never limited.
+func (l *RateLimiter) Allow(ctx context.Context) (RateLimitStatus, bool) {
+	key := GetAPIKeyFromContext(ctx)
+	if l == nil || l.limit <= 0 || l.window <= 0 || key == nil {
+		return RateLimitStatus{}, true
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	now := l.now()
+	l.sweep(now)
+
+	b, ok := l.buckets[key.Key]
+	if !ok {
+		b = &rateBucket{tokens: float64(l.limit), updated: now}
+		l.buckets[key.Key] = b
+	}
+	b.tokens = math.Min(float64(l.limit), b.tokens+now.Sub(b.updated).Seconds()*l.rate())
+	b.updated = now
+
+	allowed := b.tokens >= 1
+	if allowed {
+		b.tokens--
+	}
+	status := RateLimitStatus{
+		Limit:     l.limit,
+		Remaining: int(b.tokens),
+		Reset:     l.refillTime(float64(l.limit) - b.tokens),
+	}
+	if !allowed {
+		status.RetryAfter = l.refillTime(1 - b.tokens)
+	}
+	return status, allowed
+}
+
+// rate returns the number of tokens regained per second.
+func (l *RateLimiter) rate() float64 {
+	return float64(l.limit) / l.window.Seconds()
+}
+
+// refillTime returns the time it takes to regain the given number of tokens.
+func (l *RateLimiter) refillTime(tokens float64) time.Duration {
+	if tokens <= 0 {
+		return 0
+	}
+	return time.Duration(math.Ceil(tokens / l.rate() * float64(time.Second)))
+}
+
+// sweep drops the buckets of keys that have been idle long enough to be full
+// again, at most once per window, so that keys that stop sending requests do
+// not accumulate. A dropped bucket is recreated full. Must be called with l.mu held.
+func (l *RateLimiter) sweep(now time.Time) {
+	if now.Sub(l.swept) < l.window {
+		return
+	}
+	l.swept = now
+	for key, b := range l.buckets {
+		if now.Sub(b.updated) >= l.window {
+			delete(l.buckets, key)
+		}
+	}
+}
+
+// Len returns the number of API keys currently tracked.
+func (l *RateLimiter) Len() int {
+	if l == nil {
+		return 0
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return len(l.buckets)
+}
@@ -0,0 +1,92 @@
+package auth
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestRateLimiter_Allow(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	l := NewRateLimiter(3, time.Minute)
+	l.now = func() time.Time { return now }
+	ctx := context.WithValue(context.Background(), ContextKeyAPIKey, &APIKey{Key: "key-a"})
+
+	// The full bucket allows a burst of 3 requests
+	for i := 0; i < 3; i++ {
+		status, ok := l.Allow(ctx)
+		if !ok {
+			t.Fatalf("Request %d: expected to be allowed", i+1)
+		}
+		if status.Remaining != 2-i {
+			t.Errorf("Request %d: expected remaining %d, got %d", i+1, 2-i, status.Remaining)
+		}
+	}
+
+	status, ok := l.Allow(ctx)
+	if ok {
+		t.Fatal("Expected the 4th request to be refused")
+	}
+	if status.Limit != 3 || status.Remaining != 0 {
+		t.Errorf("Expected limit 3 and remaining 0, got %+v", status)
+	}
+	// One token is regained every 20 seconds
+	if status.RetryAfter != 20*time.Second {
+		t.Errorf("Expected retry after 20s, got %v", status.RetryAfter)
+	}
+	if status.Reset != time.Minute {
+		t.Errorf("Expected reset in 1m, got %v", status.Reset)
+	}
+
+	// Other keys have their own bucket
+	other := context.WithValue(context.Background(), ContextKeyAPIKey, &APIKey{Key: "key-b"})
+	if _, ok := l.Allow(other); !ok {
+		t.Error("Expected another key to be allowed")
+	}
+
+	now = now.Add(20 * time.Second)
+	if _, ok := l.Allow(ctx); !ok {
+		t.Error("Expected a request to be allowed after a token was regained")
+	}
+	if _, ok := l.Allow(ctx); ok {
+		t.Error("Expected the next request to be refused again")
+	}
+}
+
+func TestRateLimiter_Sweep(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	l := NewRateLimiter(2, time.Second)
+	l.now = func() time.Time { return now }
+
+	for _, key := range []string{"a", "b", "c"} {
+		l.Allow(context.WithValue(context.Background(), ContextKeyAPIKey, &APIKey{Key: key}))
+	}
+	if l.Len() != 3 {
+		t.Fatalf("Expected 3 tracked keys, got %d", l.Len())
+	}
+
+	// Idle keys are dropped once their bucket would be full again
+	now = now.Add(2 * time.Second)
+	l.Allow(context.WithValue(context.Background(), ContextKeyAPIKey, &APIKey{Key: "a"}))
+	if l.Len() != 1 {
+		t.Errorf("Expected idle keys to be dropped, got %d tracked", l.Len())
+	}
+}
+
+func TestRateLimiter_Unlimited(t *testing.T) {
+	ctx := context.WithValue(context.Background(), ContextKeyAPIKey, &APIKey{Key: "key"})
+
+	var nilLimiter *RateLimiter
+	if _, ok := nilLimiter.Allow(ctx); !ok {
+		t.Error("Expected a nil limiter to allow requests")
+	}
+	disabled := NewRateLimiter(0, time.Minute)
+	for i := 0; i < 10; i++ {
+		if _, ok := disabled.Allow(ctx); !ok {
+			t.Fatal("Expected a disabled limiter to allow requests")
+		}
+	}
+	if _, ok := NewRateLimiter(1, time.Minute).Allow(context.Background()); !ok {
+		t.Error("Expected requests without an API key to be allowed")
+	}
+}
@@ -0,0 +1,75 @@
+package auth
+
+import (
+	"context"
+	"sync"
+)
+
+// StreamLimiter caps the number of concurrent long-lived streams (SSE query
+// streams, change long-polls) that a single API key may hold open.
+type StreamLimiter struct {
+	mu     sync.Mutex
+	max    int
+	active map[string]int
+}
+
+// NewStreamLimiter creates a stream limiter allowing max concurrent streams per
+// API key. A max of 0 disables the limit.
+func NewStreamLimiter(max int) *StreamLimiter {
+	return &StreamLimiter{
+		max:    max,
+		active: make(map[string]int),
+	}
+}
+
+// Max returns the configured per-key stream limit (0 means unlimited).
+func (l *StreamLimiter) Max() int {
+	if l == nil {
+		return 0
+	}
+	return l.max
+}
+
+// Acquire reserves a stream slot for the API key in the context. It returns a
+// release function that must be called when the stream completes, and false if
+// the key already holds the maximum number of open streams. A nil or unlimited
+// limiter and requests without an API key are never limited.
+func (l *StreamLimiter) Acquire(ctx context.Context) (func(), bool) {
+	key := GetAPIKeyFromContext(ctx)
+	if l == nil || l.max <= 0 || key == nil {
+		return func() {}, true
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.active[key.Key] >= l.max {
+		return nil, false
+	}
+	l.active[key.Key]++
+
+	var once sync.Once
+	return func() {
+		once.Do(func() { l.release(key.Key) })
+	}, true
+}
+
+// Active returns the number of streams currently open for an API key.
+func (l *StreamLimiter) Active(apiKey string) int {
+	if l == nil {
+		return 0
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.active[apiKey]
+}
+
+// release frees a stream slot of an API key.
+func (l *StreamLimiter) release(apiKey string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.active[apiKey] <= 1 {
+		delete(l.active, apiKey)
+		return
+	}
+	l.active[apiKey]--
+}
@@ -0,0 +1,68 @@
+package auth
+
+import (
+	"context"
+	"testing"
+)
+
+func keyContext(key string) context.Context {
+	return SetContextValues(context.Background(), &APIKey{Key: key, RoleName: "admin"}, "admin")
+}
+
+func TestStreamLimiter_AcquireRelease(t *testing.T) {
+	limiter := NewStreamLimiter(2)
+	ctx := keyContext("key-a")
+
+	release1, ok := limiter.Acquire(ctx)
+	if !ok {
+		t.Fatal("Expected first stream to be allowed")
+	}
+	release2, ok := limiter.Acquire(ctx)
+	if !ok {
+		t.Fatal("Expected second stream to be allowed")
+	}
+	if _, ok := limiter.Acquire(ctx); ok {
+		t.Fatal("Expected third stream to be rejected")
+	}
+	if n := limiter.Active("key-a"); n != 2 {
+		t.Errorf("Expected 2 active streams, got %d", n)
+	}
+
+	// Other keys have their own budget
+	if _, ok := limiter.Acquire(keyContext("key-b")); !ok {
+		t.Error("Expected stream for another key to be allowed")
+	}
+
+	// Releasing frees a slot; releasing twice does not free another
+	release1()
+	release1()
+	if n := limiter.Active("key-a"); n != 1 {
+		t.Errorf("Expected 1 active stream after release, got %d", n)
+	}
+	if _, ok := limiter.Acquire(ctx); !ok {
+		t.Error("Expected stream to be allowed after release")
+	}
+	release2()
+}
+
+func TestStreamLimiter_Unlimited(t *testing.T) {
+	var nilLimiter *StreamLimiter
+	if _, ok := nilLimiter.Acquire(keyContext("key-a")); !ok {
+		t.Error("Expected nil limiter to allow streams")
+	}
+
+	limiter := NewStreamLimiter(0)
+	for i := 0; i < 5; i++ {
+		if _, ok := limiter.Acquire(keyContext("key-a")); !ok {
+			t.Fatal("Expected unlimited limiter to allow streams")
+		}
+	}
+
+	// Requests without an API key are not limited
+	limiter = NewStreamLimiter(1)
+	for i := 0; i < 3; i++ {
+		if _, ok := limiter.Acquire(context.Background()); !ok {
+			t.Fatal("Expected request without API key to be allowed")
+		}
+	}
+}
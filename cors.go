@@ -0,0 +1,113 @@
+package duckdb
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/tobilg/caddy-duckdb-module/auth"
+	"github.com/tobilg/caddy-duckdb-module/handlers"
+)
+
+// defaultCORSMethods are the methods allowed in preflights when cors_methods
+// is not configured: every method of the API.
+var defaultCORSMethods = []string{http.MethodGet, http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete}
+
+// corsOriginAllowed reports whether the browser origin may call the API.
+func (d *DuckDB) corsOriginAllowed(origin string) bool {
+	for _, allowed := range d.CORSOrigins {
+		if allowed == "*" || strings.EqualFold(allowed, origin) {
+			return true
+		}
+	}
+	return false
+}
+
+// corsAllowHeaders returns the request headers browsers may send: the API key
+// and request ID headers, the query label, and any required headers.
+func (d *DuckDB) corsAllowHeaders() string {
+	headers := []string{"Content-Type", auth.DefaultAPIKeyHeader, "X-Request-ID", auth.QueryLabelHeader}
+	if header := d.authMw.APIKeyHeader(); header != auth.DefaultAPIKeyHeader {
+		headers = append(headers, header)
+	}
+	for _, required := range d.requiredHeaders {
+		headers = append(headers, required.name)
+	}
+	return strings.Join(headers, ", ")
+}
+
+// corsExposeHeaders are the response headers browser clients may read besides
+// the CORS-safelisted ones: every header the module sets for clients to act on.
+var corsExposeHeaders = strings.Join([]string{
+	"X-Request-ID",
+	"Retry-After",
+	"RateLimit-Limit",
+	"RateLimit-Remaining",
+	"RateLimit-Reset",
+	handlers.QueryTimeHeader,
+	handlers.RowsReadHeader,
+	"Server-Timing",
+	handlers.NextCursorHeader,
+	handlers.MaxModifiedHeader,
+	"X-Ignored-Columns",
+	"Preference-Applied",
+	"Content-Disposition",
+}, ", ")
+
+// handleCORS adds the CORS headers for requests from an allowed origin and
+// answers preflight requests. It reports whether the request was answered.
+// It must run before anything writes a response: the headers then apply to
+// every response of the request, including redirects and errors.
+func (d *DuckDB) handleCORS(w http.ResponseWriter, r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	if len(d.CORSOrigins) == 0 || origin == "" {
+		return false
+	}
+	w.Header().Add("Vary", "Origin")
+	preflight := r.Method == http.MethodOptions && r.Header.Get("Access-Control-Request-Method") != ""
+	if !d.corsOriginAllowed(origin) {
+		if preflight {
+			writeModuleError(w, r, fmt.Sprintf("Origin '%s' is not allowed", origin), http.StatusForbidden)
+			return true
+		}
+		// Without the headers, the browser does not expose the response
+		return false
+	}
+
+	w.Header().Set("Access-Control-Allow-Origin", origin)
+	if !preflight {
+		w.Header().Set("Access-Control-Expose-Headers", corsExposeHeaders)
+		return false
+	}
+
+	methods := d.CORSMethods
+	if len(methods) == 0 {
+		methods = defaultCORSMethods
+	}
+	w.Header().Add("Vary", "Access-Control-Request-Method")
+	w.Header().Add("Vary", "Access-Control-Request-Headers")
+	w.Header().Set("Access-Control-Allow-Methods", strings.Join(methods, ", "))
+	w.Header().Set("Access-Control-Allow-Headers", d.corsAllowHeaders())
+	w.WriteHeader(http.StatusNoContent)
+	return true
+}
+
+// validateCORS checks the cors_origins and cors_methods configuration.
+func validateCORS(origins, methods []string) error {
+	for _, origin := range origins {
+		if origin == "*" {
+			continue
+		}
+		u, err := url.Parse(origin)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" || u.Path != "" || u.RawQuery != "" || u.Fragment != "" || u.User != nil {
+			return fmt.Errorf("invalid cors_origins entry '%s' (must be '*' or scheme://host[:port])", origin)
+		}
+	}
+	for _, method := range methods {
+		if !isHeaderName(method) || method != strings.ToUpper(method) {
+			return fmt.Errorf("invalid cors_methods entry '%s' (must be an upper-case HTTP method)", method)
+		}
+	}
+	return nil
+}
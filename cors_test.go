@@ -0,0 +1,202 @@
+package duckdb
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/caddyserver/caddy/v2/caddyconfig/caddyfile"
+)
+
+func TestServeHTTP_CORS(t *testing.T) {
+	d, cleanup := setupMaintenanceModule(t)
+	defer cleanup()
+	d.CORSOrigins = []string{"https://app.example.com"}
+
+	send := func(method, origin string, header http.Header) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, "/duckdb/api/test_data", nil)
+		for name, values := range header {
+			req.Header[name] = values
+		}
+		if origin != "" {
+			req.Header.Set("Origin", origin)
+		}
+		rec := httptest.NewRecorder()
+		if err := d.ServeHTTP(rec, req, &mockNextHandler{}); err != nil {
+			t.Fatalf("ServeHTTP returned error: %v", err)
+		}
+		return rec
+	}
+	preflight := http.Header{
+		"Access-Control-Request-Method":  {"DELETE"},
+		"Access-Control-Request-Headers": {"x-api-key, x-request-id"},
+	}
+
+	// Preflights are answered without an API key
+	rec := send(http.MethodOptions, "https://app.example.com", preflight)
+	if rec.Code != http.StatusNoContent {
+		t.Fatalf("Expected status 204, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if got := rec.Header().Get("Access-Control-Allow-Origin"); got != "https://app.example.com" {
+		t.Errorf("Expected the origin to be echoed, got %q", got)
+	}
+	if got := rec.Header().Get("Access-Control-Allow-Methods"); got != "GET, POST, PUT, PATCH, DELETE" {
+		t.Errorf("Unexpected Access-Control-Allow-Methods: %q", got)
+	}
+	allowHeaders := rec.Header().Get("Access-Control-Allow-Headers")
+	for _, header := range []string{"X-API-Key", "X-Request-ID", "Content-Type"} {
+		if !strings.Contains(allowHeaders, header) {
+			t.Errorf("Expected %s in Access-Control-Allow-Headers, got %q", header, allowHeaders)
+		}
+	}
+
+	// Actual requests echo the origin
+	rec = send(http.MethodGet, "https://app.example.com", http.Header{"X-Api-Key": {"test-api-key"}})
+	if rec.Code != http.StatusOK || rec.Header().Get("Access-Control-Allow-Origin") != "https://app.example.com" {
+		t.Errorf("Expected 200 with the origin echoed, got %d %v", rec.Code, rec.Header())
+	}
+	exposed := strings.Split(rec.Header().Get("Access-Control-Expose-Headers"), ", ")
+	for _, header := range []string{
+		"X-Request-ID", "Retry-After",
+		"RateLimit-Limit", "RateLimit-Remaining", "RateLimit-Reset",
+		"X-Query-Time-Ms", "X-Rows-Read", "Server-Timing",
+		"X-Next-Cursor", "X-Max-Modified", "X-Ignored-Columns", "Preference-Applied", "Content-Disposition",
+	} {
+		if !slices.Contains(exposed, header) {
+			t.Errorf("Expected %s to be exposed, got %q", header, exposed)
+		}
+	}
+
+	// Configured methods replace the defaults
+	d.CORSMethods = []string{"GET", "POST"}
+	rec = send(http.MethodOptions, "https://app.example.com", preflight)
+	if got := rec.Header().Get("Access-Control-Allow-Methods"); got != "GET, POST" {
+		t.Errorf("Expected the configured methods, got %q", got)
+	}
+}
+
+func TestServeHTTP_CORS_DisallowedOrigin(t *testing.T) {
+	d, cleanup := setupMaintenanceModule(t)
+	defer cleanup()
+	d.CORSOrigins = []string{"https://app.example.com"}
+
+	req := httptest.NewRequest(http.MethodOptions, "/duckdb/api/test_data", nil)
+	req.Header.Set("Origin", "https://evil.example.com")
+	req.Header.Set("Access-Control-Request-Method", "GET")
+	rec := httptest.NewRecorder()
+	if err := d.ServeHTTP(rec, req, &mockNextHandler{}); err != nil {
+		t.Fatalf("ServeHTTP returned error: %v", err)
+	}
+	if rec.Code != http.StatusForbidden {
+		t.Errorf("Expected status 403, got %d", rec.Code)
+	}
+	if rec.Header().Get("Access-Control-Allow-Origin") != "" {
+		t.Error("Expected no Access-Control-Allow-Origin for a disallowed origin")
+	}
+
+	// Actual requests are served, but without CORS headers the browser hides the response
+	req = httptest.NewRequest(http.MethodGet, "/duckdb/api/test_data", nil)
+	req.Header.Set("Origin", "https://evil.example.com")
+	req.Header.Set("X-API-Key", "test-api-key")
+	rec = httptest.NewRecorder()
+	if err := d.ServeHTTP(rec, req, &mockNextHandler{}); err != nil {
+		t.Fatalf("ServeHTTP returned error: %v", err)
+	}
+	if rec.Header().Get("Access-Control-Allow-Origin") != "" {
+		t.Error("Expected no Access-Control-Allow-Origin for a disallowed origin")
+	}
+
+	// Without cors_origins, CORS is disabled and preflights need an API key
+	d.CORSOrigins = nil
+	req = httptest.NewRequest(http.MethodOptions, "/duckdb/api/test_data", nil)
+	req.Header.Set("Origin", "https://app.example.com")
+	req.Header.Set("Access-Control-Request-Method", "GET")
+	rec = httptest.NewRecorder()
+	if err := d.ServeHTTP(rec, req, &mockNextHandler{}); err != nil {
+		t.Fatalf("ServeHTTP returned error: %v", err)
+	}
+	if rec.Code != http.StatusUnauthorized {
+		t.Errorf("Expected status 401 with CORS disabled, got %d", rec.Code)
+	}
+}
+
+func TestUnmarshalCaddyfile_CORS(t *testing.T) {
+	dispenser := caddyfile.NewTestDispenser(`duckdb {
+		cors_origins https://app.example.com
+		cors_origins http://localhost:3000
+		cors_methods get post
+	}`)
+	d := &DuckDB{}
+	if err := d.UnmarshalCaddyfile(dispenser); err != nil {
+		t.Fatalf("UnmarshalCaddyfile failed: %v", err)
+	}
+	if strings.Join(d.CORSOrigins, ",") != "https://app.example.com,http://localhost:3000" {
+		t.Errorf("Unexpected origins: %v", d.CORSOrigins)
+	}
+	if strings.Join(d.CORSMethods, ",") != "GET,POST" {
+		t.Errorf("Unexpected methods: %v", d.CORSMethods)
+	}
+}
+
+func TestValidateCORS(t *testing.T) {
+	if err := validateCORS([]string{"*", "https://app.example.com", "http://localhost:3000"}, []string{"GET", "POST"}); err != nil {
+		t.Errorf("Expected a valid configuration, got %v", err)
+	}
+	for _, origin := range []string{"app.example.com", "https://app.example.com/", "ftp://example.com", "https://"} {
+		if err := validateCORS([]string{origin}, nil); err == nil {
+			t.Errorf("Expected origin %q to be rejected", origin)
+		}
+	}
+	if err := validateCORS(nil, []string{"get"}); err == nil {
+		t.Error("Expected a lower-case method to be rejected")
+	}
+}
+
+func TestServeHTTP_CORS_ErrorResponses(t *testing.T) {
+	d, cleanup := setupMaintenanceModule(t)
+	defer cleanup()
+	d.CORSOrigins = []string{"https://app.example.com"}
+
+	send := func(method, path, apiKey string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, nil)
+		req.Header.Set("Origin", "https://app.example.com")
+		if apiKey != "" {
+			req.Header.Set("X-API-Key", apiKey)
+		}
+		rec := httptest.NewRecorder()
+		if err := d.ServeHTTP(rec, req, &mockNextHandler{}); err != nil {
+			t.Fatalf("ServeHTTP returned error: %v", err)
+		}
+		return rec
+	}
+	assertCORS := func(name string, rec *httptest.ResponseRecorder, code int) {
+		t.Helper()
+		if rec.Code != code {
+			t.Errorf("%s: expected status %d, got %d: %s", name, code, rec.Code, rec.Body.String())
+		}
+		if got := rec.Header().Get("Access-Control-Allow-Origin"); got != "https://app.example.com" {
+			t.Errorf("%s: expected the origin to be echoed, got %q", name, got)
+		}
+		if !strings.Contains(rec.Header().Get("Access-Control-Expose-Headers"), "X-Request-ID") {
+			t.Errorf("%s: expected X-Request-ID to be exposed, got %q", name, rec.Header().Get("Access-Control-Expose-Headers"))
+		}
+	}
+
+	// Authentication failures
+	assertCORS("missing key", send("GET", "/duckdb/api/test_data", ""), http.StatusUnauthorized)
+	assertCORS("invalid key", send("GET", "/duckdb/api/test_data", "wrong-key"), http.StatusUnauthorized)
+
+	// Errors written by the handlers
+	assertCORS("unknown column", send("GET", "/duckdb/api/test_data?filter=nope:eq:1", "test-api-key"), http.StatusBadRequest)
+
+	// Errors written before authentication
+	d.RequireTLS = true
+	assertCORS("plain HTTP", send("GET", "/duckdb/api/test_data", "test-api-key"), http.StatusForbidden)
+	d.RequireTLS = false
+
+	// Redirects to the canonical path
+	d.TrailingSlash = TrailingSlashRedirect
+	assertCORS("trailing slash", send("GET", "/duckdb/api/test_data/", "test-api-key"), http.StatusPermanentRedirect)
+}
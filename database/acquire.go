@@ -0,0 +1,44 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+)
+
+// ErrDatabaseBusy is returned when no connection of a pool became available
+// within the acquire timeout (see Config.AcquireTimeout).
+var ErrDatabaseBusy = errors.New("database busy: no connection available")
+
+// executor runs statements on a pool (*sql.DB) or on a connection taken from
+// it (*sql.Conn).
+type executor interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+	BeginTx(ctx context.Context, opts *sql.TxOptions) (*sql.Tx, error)
+}
+
+// acquire returns the executor for a statement on db and a function that
+// releases it. Without an acquire timeout, that is db itself, which waits for
+// a free connection as long as the statement's context allows. Otherwise a
+// connection is taken from db first, failing with ErrDatabaseBusy if none is
+// free within the timeout. release must be called once the statement has been
+// started; the connection returns to the pool when the rows or transaction
+// running on it are closed.
+func (m *Manager) acquire(parent context.Context, db *sql.DB) (executor, func(), error) {
+	if m.acquireTimeout <= 0 {
+		return db, func() {}, nil
+	}
+	ctx, cancel := context.WithTimeout(parent, m.acquireTimeout)
+	defer cancel()
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		if errors.Is(err, context.DeadlineExceeded) && parent.Err() == nil {
+			return nil, nil, ErrDatabaseBusy
+		}
+		return nil, nil, err
+	}
+	// Close waits until rows and transactions on the connection are closed
+	return conn, func() { go conn.Close() }, nil
+}
@@ -0,0 +1,100 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestAcquireTimeout_PoolExhausted(t *testing.T) {
+	mgr := setupTestManager(t)
+	defer mgr.Close()
+	mgr.acquireTimeout = 50 * time.Millisecond
+	mgr.MainDB().SetMaxOpenConns(1)
+
+	held, err := mgr.MainDB().Conn(context.Background())
+	if err != nil {
+		t.Fatalf("Failed to take connection: %v", err)
+	}
+
+	start := time.Now()
+	_, err = mgr.QueryMain("SELECT 1")
+	if !errors.Is(err, ErrDatabaseBusy) {
+		t.Fatalf("Expected ErrDatabaseBusy from QueryMain, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("Expected QueryMain to fail fast, took %v", elapsed)
+	}
+	if _, err := mgr.ExecMain("INSERT INTO test_users (id, name) VALUES (1, 'Alice')"); !errors.Is(err, ErrDatabaseBusy) {
+		t.Errorf("Expected ErrDatabaseBusy from ExecMain, got %v", err)
+	}
+	if _, err := mgr.BeginTxMain(); !errors.Is(err, ErrDatabaseBusy) {
+		t.Errorf("Expected ErrDatabaseBusy from BeginTxMain, got %v", err)
+	}
+	var n int
+	if err := mgr.QueryRowScanMain("SELECT 1", []interface{}{&n}); !errors.Is(err, ErrDatabaseBusy) {
+		t.Errorf("Expected ErrDatabaseBusy from QueryRowScanMain, got %v", err)
+	}
+
+	// Parameterized raw SQL bypasses the query plan cache to honor the timeout
+	if mgr.queryPlans, err = newQueryPlanCache(8); err != nil {
+		t.Fatalf("Failed to create query plan cache: %v", err)
+	}
+	if _, err := mgr.QueryPreparedContext(context.Background(), "SELECT $1", 1); !errors.Is(err, ErrDatabaseBusy) {
+		t.Errorf("Expected ErrDatabaseBusy from QueryPreparedContext, got %v", err)
+	}
+	if _, err := mgr.ExecPreparedContext(context.Background(), "DELETE FROM test_users WHERE id = $1", 1); !errors.Is(err, ErrDatabaseBusy) {
+		t.Errorf("Expected ErrDatabaseBusy from ExecPreparedContext, got %v", err)
+	}
+
+	held.Close()
+	if err := mgr.QueryRowScanMain("SELECT 1", []interface{}{&n}); err != nil {
+		t.Fatalf("Expected query to succeed once the connection is free, got %v", err)
+	}
+}
+
+func TestAcquireTimeout_ReleasesConnection(t *testing.T) {
+	mgr := setupTestManager(t)
+	defer mgr.Close()
+	mgr.acquireTimeout = time.Second
+	mgr.MainDB().SetMaxOpenConns(1)
+
+	// Each statement must return its connection to the pool, or the next one
+	// would find the single-connection pool exhausted.
+	for i := 1; i <= 3; i++ {
+		if _, err := mgr.ExecMain("INSERT INTO test_users (id, name) VALUES (?, 'user')", i); err != nil {
+			t.Fatalf("Insert %d failed: %v", i, err)
+		}
+		rows, err := mgr.QueryMain("SELECT id FROM test_users")
+		if err != nil {
+			t.Fatalf("Query %d failed: %v", i, err)
+		}
+		count := 0
+		for rows.Next() {
+			count++
+		}
+		rows.Close()
+		if count != i {
+			t.Errorf("Expected %d rows, got %d", i, count)
+		}
+	}
+
+	tx, err := mgr.BeginTxMain()
+	if err != nil {
+		t.Fatalf("BeginTxMain failed: %v", err)
+	}
+	if _, err := tx.Exec("DELETE FROM test_users"); err != nil {
+		t.Fatalf("Delete in transaction failed: %v", err)
+	}
+	if err := tx.Commit(); err != nil {
+		t.Fatalf("Commit failed: %v", err)
+	}
+	var n int
+	if err := mgr.QueryRowScanMain("SELECT COUNT(*) FROM test_users", []interface{}{&n}); err != nil {
+		t.Fatalf("Count failed: %v", err)
+	}
+	if n != 0 {
+		t.Errorf("Expected 0 rows after delete, got %d", n)
+	}
+}
@@ -0,0 +1,36 @@
+package database
+
+import (
+	"context"
+	"fmt"
+)
+
+// DistinctCountStatement builds the query counting the distinct non-NULL values
+// of column among the rows matching the filters. Unless exact is set it uses
+// approx_count_distinct, a HyperLogLog estimate that is much cheaper than
+// COUNT(DISTINCT) on large tables. The column name must be validated by the caller.
+func DistinctCountStatement(table string, derived []DerivedColumn, column string, filters []Filter, exact bool) Statement {
+	where, values := buildWhereClause(filters, 1)
+	fn := "approx_count_distinct(%s)"
+	if exact {
+		fn = "COUNT(DISTINCT %s)"
+	}
+	query := fmt.Sprintf("SELECT %s FROM %s%s", fmt.Sprintf(fn, column), selectSource(table, derived), where)
+	return Statement{SQL: query, Params: values}
+}
+
+// ApproxDistinct estimates the number of distinct non-NULL values of column
+// among the rows matching the filters.
+func (m *Manager) ApproxDistinct(table, column string, filters []Filter) (int64, error) {
+	return m.DistinctCountContext(context.Background(), table, nil, column, filters, false)
+}
+
+// DistinctCountContext counts the distinct non-NULL values of column among the
+// rows matching the filters, estimated unless exact is set. It is cancelled when
+// ctx is done and carries the context's query tag.
+func (m *Manager) DistinctCountContext(ctx context.Context, table string, derived []DerivedColumn, column string, filters []Filter, exact bool) (int64, error) {
+	stmt := DistinctCountStatement(table, derived, column, filters, exact)
+	var count int64
+	err := m.QueryRowScanMainContext(ctx, stmt.SQL, []interface{}{&count}, stmt.Params...)
+	return count, err
+}
@@ -0,0 +1,84 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"testing"
+)
+
+func TestDistinctCountStatement(t *testing.T) {
+	filters := []Filter{{Column: "status", Operator: "eq", Value: 200}}
+	stmt := DistinctCountStatement("requests", nil, "path", filters, false)
+	if stmt.SQL != "SELECT approx_count_distinct(path) FROM requests WHERE status = $1" {
+		t.Errorf("Unexpected SQL: %s", stmt.SQL)
+	}
+	if len(stmt.Params) != 1 || stmt.Params[0] != 200 {
+		t.Errorf("Unexpected params: %v", stmt.Params)
+	}
+
+	stmt = DistinctCountStatement("requests", nil, "path", nil, true)
+	if stmt.SQL != "SELECT COUNT(DISTINCT path) FROM requests" {
+		t.Errorf("Unexpected exact SQL: %s", stmt.SQL)
+	}
+}
+
+func TestApproxDistinct(t *testing.T) {
+	mgr := setupTestManager(t)
+	defer mgr.Close()
+
+	if _, err := mgr.ExecMain(`CREATE TABLE visits (id INTEGER, visitor VARCHAR, country VARCHAR)`); err != nil {
+		t.Fatalf("Failed to create table: %v", err)
+	}
+	// 200 visits by 50 visitors; the even visitors are from "de"
+	for i := 0; i < 200; i++ {
+		visitor := i % 50
+		country := "us"
+		if visitor%2 == 0 {
+			country = "de"
+		}
+		if _, err := mgr.ExecMain(`INSERT INTO visits VALUES (?, ?, ?)`, i, fmt.Sprintf("v%d", visitor), country); err != nil {
+			t.Fatalf("Failed to insert row: %v", err)
+		}
+	}
+	if _, err := mgr.ExecMain(`INSERT INTO visits VALUES (200, NULL, 'us')`); err != nil {
+		t.Fatalf("Failed to insert row: %v", err)
+	}
+
+	exact, err := mgr.DistinctCountContext(context.Background(), "visits", nil, "visitor", nil, true)
+	if err != nil {
+		t.Fatalf("DistinctCountContext failed: %v", err)
+	}
+	if exact != 50 {
+		t.Errorf("Expected 50 distinct visitors, got %d", exact)
+	}
+
+	// The estimate is close to the exact count on a small dataset
+	approx, err := mgr.ApproxDistinct("visits", "visitor", nil)
+	if err != nil {
+		t.Fatalf("ApproxDistinct failed: %v", err)
+	}
+	if approx < 45 || approx > 55 {
+		t.Errorf("Expected an estimate close to %d, got %d", exact, approx)
+	}
+
+	// Filters apply before counting
+	filters := []Filter{{Column: "country", Operator: "eq", Value: "de"}}
+	exact, err = mgr.DistinctCountContext(context.Background(), "visits", nil, "visitor", filters, true)
+	if err != nil {
+		t.Fatalf("DistinctCountContext with filters failed: %v", err)
+	}
+	if exact != 25 {
+		t.Errorf("Expected 25 distinct visitors from de, got %d", exact)
+	}
+	approx, err = mgr.ApproxDistinct("visits", "visitor", filters)
+	if err != nil {
+		t.Fatalf("ApproxDistinct with filters failed: %v", err)
+	}
+	if approx < 22 || approx > 28 {
+		t.Errorf("Expected an estimate close to %d, got %d", exact, approx)
+	}
+
+	if _, err := mgr.ApproxDistinct("visits", "missing", nil); err == nil {
+		t.Error("Expected error for unknown column")
+	}
+}
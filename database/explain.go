@@ -0,0 +1,52 @@
+package database
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+)
+
+// ExplainStatement wraps stmt in EXPLAIN (FORMAT JSON), which returns DuckDB's
+// physical plan for the statement instead of running it.
+func ExplainStatement(stmt Statement) Statement {
+	return Statement{SQL: "EXPLAIN (FORMAT JSON) " + stmt.SQL, Params: stmt.Params}
+}
+
+// ExplainSelectContext returns the query plan of the read SelectColumnsContext
+// would run for the same arguments, decoded from DuckDB's JSON plan output.
+func (m *Manager) ExplainSelectContext(ctx context.Context, table string, derived []DerivedColumn, columns []string, filters []Filter, window *Window, sample *Sample, sorts []Sort, limit, offset int) (interface{}, error) {
+	stmt, err := SelectColumnsStatement(table, derived, columns, filters, window, sample, sorts, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	return m.explainContext(ctx, ExplainStatement(stmt))
+}
+
+// explainContext runs an EXPLAIN (FORMAT JSON) statement. DuckDB returns one
+// (explain_key, explain_value) row per plan; the physical plan is the only one
+// without EXPLAIN ANALYZE.
+func (m *Manager) explainContext(ctx context.Context, stmt Statement) (interface{}, error) {
+	rows, err := m.QueryMainContext(ctx, stmt.SQL, stmt.Params...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var plan interface{}
+	for rows.Next() {
+		var key, value string
+		if err := rows.Scan(&key, &value); err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal([]byte(value), &plan); err != nil {
+			return nil, fmt.Errorf("failed to decode %s: %w", key, err)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	if plan == nil {
+		return nil, fmt.Errorf("EXPLAIN returned no plan")
+	}
+	return plan, nil
+}
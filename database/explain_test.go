@@ -0,0 +1,46 @@
+package database
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestExplainStatement(t *testing.T) {
+	stmt := ExplainStatement(Statement{SQL: "SELECT * FROM users WHERE age > $1", Params: []interface{}{18}})
+	if stmt.SQL != "EXPLAIN (FORMAT JSON) SELECT * FROM users WHERE age > $1" {
+		t.Errorf("Unexpected SQL: %s", stmt.SQL)
+	}
+	if len(stmt.Params) != 1 || stmt.Params[0] != 18 {
+		t.Errorf("Unexpected params: %v", stmt.Params)
+	}
+}
+
+func TestExplainSelectContext(t *testing.T) {
+	mgr := setupTestManager(t)
+	defer mgr.Close()
+
+	if _, err := mgr.ExecMain(`INSERT INTO test_users (id, name, email, age) VALUES (1, 'Alice', 'alice@example.com', 30)`); err != nil {
+		t.Fatalf("Failed to insert row: %v", err)
+	}
+
+	filters := []Filter{{Column: "age", Operator: "gt", Value: 18}}
+	sorts := []Sort{{Column: "name", Direction: "asc"}}
+	plan, err := mgr.ExplainSelectContext(context.Background(), "test_users", nil, nil, filters, nil, nil, sorts, 10, 0)
+	if err != nil {
+		t.Fatalf("ExplainSelectContext failed: %v", err)
+	}
+	nodes, ok := plan.([]interface{})
+	if !ok || len(nodes) == 0 {
+		t.Fatalf("Expected a JSON array of plan nodes, got %T: %v", plan, plan)
+	}
+	if _, ok := nodes[0].(map[string]interface{}); !ok {
+		t.Errorf("Expected plan nodes to be objects, got %T", nodes[0])
+	}
+
+	if _, err := mgr.ExplainSelectContext(context.Background(), "missing_table", nil, nil, nil, nil, nil, nil, 10, 0); err == nil {
+		t.Error("Expected error for unknown table")
+	} else if !strings.Contains(err.Error(), "missing_table") {
+		t.Errorf("Expected the error to name the table, got %v", err)
+	}
+}
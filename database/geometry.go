@@ -0,0 +1,59 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// GeometryType is the column type of the spatial extension's geometries.
+const GeometryType = "GEOMETRY"
+
+// GeometryColumns returns the table's GEOMETRY columns in ordinal order. Tables
+// can only have them if the spatial extension is installed.
+func (m *Manager) GeometryColumns(ctx context.Context, table string) ([]string, error) {
+	catalogClause, args := catalogCondition(table)
+	args = append(args, GeometryType)
+	query := fmt.Sprintf(`
+		SELECT column_name
+		FROM information_schema.columns
+		WHERE table_name = $1 AND %s AND data_type = $%d
+		ORDER BY ordinal_position
+	`, catalogClause, len(args))
+
+	rows, err := m.QueryMainContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query geometry columns: %w", err)
+	}
+	defer rows.Close()
+
+	var columns []string
+	for rows.Next() {
+		var col string
+		if err := rows.Scan(&col); err != nil {
+			return nil, fmt.Errorf("failed to scan column name: %w", err)
+		}
+		columns = append(columns, col)
+	}
+	return columns, rows.Err()
+}
+
+// WKTProjection returns the projection for SelectColumnsStatement that selects
+// columns with the geometry columns among them converted to WKT text with
+// ST_AsText, under their own name. Matching is case-insensitive, like DuckDB
+// identifiers.
+func WKTProjection(columns, geometry []string) []string {
+	isGeometry := make(map[string]bool, len(geometry))
+	for _, col := range geometry {
+		isGeometry[strings.ToLower(col)] = true
+	}
+	projection := make([]string, len(columns))
+	for i, col := range columns {
+		if isGeometry[strings.ToLower(col)] {
+			projection[i] = fmt.Sprintf("ST_AsText(%s) AS %s", quoteIdentifier(col), quoteIdentifier(col))
+		} else {
+			projection[i] = quoteIdentifier(col)
+		}
+	}
+	return projection
+}
@@ -0,0 +1,51 @@
+package database
+
+import (
+	"context"
+	"reflect"
+	"testing"
+)
+
+// loadSpatial loads the spatial extension, skipping the test if it is not available.
+func loadSpatial(t *testing.T, mgr *Manager) {
+	t.Helper()
+	if _, err := mgr.ExecMain("INSTALL spatial; LOAD spatial"); err != nil {
+		t.Skipf("spatial extension not available: %v", err)
+	}
+}
+
+func TestWKTProjection(t *testing.T) {
+	got := WKTProjection([]string{"id", "Geom", "name"}, []string{"geom"})
+	want := []string{`"id"`, `ST_AsText("Geom") AS "Geom"`, `"name"`}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("WKTProjection() = %v, want %v", got, want)
+	}
+}
+
+func TestGeometryColumns(t *testing.T) {
+	mgr := setupTestManager(t)
+	defer mgr.Close()
+
+	if _, err := mgr.ExecMain("CREATE TABLE plain (id INTEGER, name VARCHAR)"); err != nil {
+		t.Fatalf("Failed to create table: %v", err)
+	}
+	columns, err := mgr.GeometryColumns(context.Background(), "plain")
+	if err != nil {
+		t.Fatalf("GeometryColumns failed: %v", err)
+	}
+	if len(columns) != 0 {
+		t.Errorf("Expected no geometry columns, got %v", columns)
+	}
+
+	loadSpatial(t, mgr)
+	if _, err := mgr.ExecMain("CREATE TABLE places (id INTEGER, location GEOMETRY, name VARCHAR, area GEOMETRY)"); err != nil {
+		t.Fatalf("Failed to create table: %v", err)
+	}
+	columns, err = mgr.GeometryColumns(context.Background(), "places")
+	if err != nil {
+		t.Fatalf("GeometryColumns failed: %v", err)
+	}
+	if want := []string{"location", "area"}; !reflect.DeepEqual(columns, want) {
+		t.Errorf("GeometryColumns() = %v, want %v", columns, want)
+	}
+}
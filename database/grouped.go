@@ -0,0 +1,67 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// GroupedRead aggregates the rows of a table per distinct combination of the
+// group columns, e.g. the total amount and number of sales per region:
+//
+//	SELECT region, SUM(amount) AS "sum_amount", COUNT(*) AS "count"
+//	FROM sales GROUP BY region ORDER BY 1
+//
+// Without group columns the aggregates cover all matching rows in one row.
+type GroupedRead struct {
+	// GroupBy are the grouping columns, returned first in each row.
+	GroupBy []string
+	// Aggregates are computed per group, named as by AggregateAlias. COUNT may take "*" as its column.
+	Aggregates []Aggregate
+}
+
+// Validate checks the aggregates and that result column names are unique.
+func (g *GroupedRead) Validate() error {
+	return validateGrouping(nil, g.GroupBy, g.Aggregates)
+}
+
+// GroupedStatement builds the GROUP BY query run by GroupedContext. Rows are
+// ordered by the group columns. Column names must be validated by the caller.
+// A positive limit caps the number of rows.
+func GroupedStatement(table string, derived []DerivedColumn, filters []Filter, g *GroupedRead, limit int) (Statement, error) {
+	if err := g.Validate(); err != nil {
+		return Statement{}, err
+	}
+	clause, values := buildWhereClause(filters, 1)
+
+	projections := make([]string, 0, len(g.GroupBy)+len(g.Aggregates))
+	projections = append(projections, g.GroupBy...)
+	for _, a := range g.Aggregates {
+		expr, _ := a.ToSQL()
+		projections = append(projections, fmt.Sprintf(`%s AS "%s"`, expr, AggregateAlias(a)))
+	}
+
+	query := fmt.Sprintf("SELECT %s FROM %s%s", strings.Join(projections, ", "), selectSource(table, derived), clause)
+	if len(g.GroupBy) > 0 {
+		order := make([]string, len(g.GroupBy))
+		for i := range g.GroupBy {
+			order[i] = strconv.Itoa(i + 1)
+		}
+		query += fmt.Sprintf(" GROUP BY %s ORDER BY %s", strings.Join(g.GroupBy, ", "), strings.Join(order, ", "))
+	}
+	if limit > 0 {
+		query += fmt.Sprintf(" LIMIT %d", limit)
+	}
+	return Statement{SQL: query, Params: values}, nil
+}
+
+// GroupedContext runs the grouped aggregation over the rows matching the filters.
+func (m *Manager) GroupedContext(ctx context.Context, table string, derived []DerivedColumn, filters []Filter, g *GroupedRead, limit int) (*sql.Rows, error) {
+	stmt, err := GroupedStatement(table, derived, filters, g, limit)
+	if err != nil {
+		return nil, err
+	}
+	return m.QueryMainContext(ctx, stmt.SQL, stmt.Params...)
+}
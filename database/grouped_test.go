@@ -0,0 +1,91 @@
+package database
+
+import (
+	"context"
+	"testing"
+)
+
+func TestGroupedStatement(t *testing.T) {
+	g := &GroupedRead{
+		GroupBy:    []string{"region"},
+		Aggregates: []Aggregate{{Function: "sum", Column: "amount"}, {Function: "count", Column: "*"}},
+	}
+	stmt, err := GroupedStatement("sales", nil, []Filter{{Column: "year", Operator: "eq", Value: 2024}}, g, 100)
+	if err != nil {
+		t.Fatalf("GroupedStatement failed: %v", err)
+	}
+	want := `SELECT region, SUM(amount) AS "sum_amount", COUNT(*) AS "count" FROM sales WHERE year = $1 GROUP BY region ORDER BY 1 LIMIT 100`
+	if stmt.SQL != want {
+		t.Errorf("Unexpected SQL:\n got: %s\nwant: %s", stmt.SQL, want)
+	}
+	if len(stmt.Params) != 1 || stmt.Params[0] != 2024 {
+		t.Errorf("Unexpected params: %v", stmt.Params)
+	}
+
+	// Without group columns the aggregates cover all rows
+	stmt, err = GroupedStatement("sales", nil, nil, &GroupedRead{Aggregates: []Aggregate{{Function: "avg", Column: "amount"}}}, 0)
+	if err != nil {
+		t.Fatalf("GroupedStatement failed: %v", err)
+	}
+	if want := `SELECT AVG(amount) AS "avg_amount" FROM sales`; stmt.SQL != want {
+		t.Errorf("Unexpected SQL:\n got: %s\nwant: %s", stmt.SQL, want)
+	}
+
+	invalid := []*GroupedRead{
+		{GroupBy: []string{"region"}},
+		{Aggregates: []Aggregate{{Function: "median", Column: "amount"}}},
+		{Aggregates: []Aggregate{{Function: "max", Column: "*"}}},
+		{GroupBy: []string{"count"}, Aggregates: []Aggregate{{Function: "count", Column: "*"}}},
+		{GroupBy: []string{"region", "Region"}, Aggregates: g.Aggregates},
+	}
+	for i, g := range invalid {
+		if _, err := GroupedStatement("sales", nil, nil, g, 0); err == nil {
+			t.Errorf("Case %d: expected an error", i)
+		}
+	}
+}
+
+func TestGroupedContext(t *testing.T) {
+	mgr := setupTestManager(t)
+	defer mgr.Close()
+
+	if _, err := mgr.ExecMain(`CREATE TABLE sales (region VARCHAR, amount DOUBLE)`); err != nil {
+		t.Fatalf("Failed to create table: %v", err)
+	}
+	if _, err := mgr.ExecMain(`INSERT INTO sales VALUES ('west', 10), ('east', 5), ('west', 30), ('east', 15), ('north', 1)`); err != nil {
+		t.Fatalf("Failed to insert rows: %v", err)
+	}
+
+	g := &GroupedRead{
+		GroupBy:    []string{"region"},
+		Aggregates: []Aggregate{{Function: "sum", Column: "amount"}, {Function: "count", Column: "*"}},
+	}
+	rows, err := mgr.GroupedContext(context.Background(), "sales", nil, []Filter{{Column: "amount", Operator: "gt", Value: 2}}, g, 0)
+	if err != nil {
+		t.Fatalf("GroupedContext failed: %v", err)
+	}
+	defer rows.Close()
+
+	var regions []string
+	var sums []float64
+	for rows.Next() {
+		var region string
+		var sum float64
+		var count int64
+		if err := rows.Scan(&region, &sum, &count); err != nil {
+			t.Fatalf("Scan failed: %v", err)
+		}
+		if count != 2 {
+			t.Errorf("Expected 2 rows for %s, got %d", region, count)
+		}
+		regions = append(regions, region)
+		sums = append(sums, sum)
+	}
+	// The filtered-out region has no group; groups are ordered by region
+	if len(regions) != 2 || regions[0] != "east" || regions[1] != "west" {
+		t.Errorf("Expected regions [east west], got %v", regions)
+	}
+	if len(sums) != 2 || sums[0] != 20 || sums[1] != 40 {
+		t.Errorf("Expected sums [20 40], got %v", sums)
+	}
+}
@@ -0,0 +1,175 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Source health check types.
+const (
+	SourceTypeDatabase = "database" // a database attached to the main DuckDB instance
+	SourceTypeProbe    = "source"   // an operator-configured probe query
+)
+
+// SourceHealth reports the reachability of an attached database or configured source.
+type SourceHealth struct {
+	Name      string `json:"name"`
+	Type      string `json:"type"`
+	Healthy   bool   `json:"healthy"`
+	Error     string `json:"error,omitempty"`
+	LatencyMs int64  `json:"latency_ms"`
+}
+
+// PingTimeout bounds each SELECT 1 of PingMain and PingAuth, so an
+// unresponsive database fails the health check instead of stalling it.
+const PingTimeout = 2 * time.Second
+
+// PoolHealth summarizes a connection pool for the health check.
+type PoolHealth struct {
+	MaxOpen   int   `json:"max_open_connections"`
+	Open      int   `json:"open_connections"`
+	InUse     int   `json:"in_use"`
+	Idle      int   `json:"idle"`
+	WaitCount int64 `json:"wait_count"`
+}
+
+// PingMain runs SELECT 1 against the main database.
+func (m *Manager) PingMain(ctx context.Context) error {
+	return ping(ctx, m.mainDB)
+}
+
+// PingAuth runs SELECT 1 against the auth database.
+func (m *Manager) PingAuth(ctx context.Context) error {
+	return ping(ctx, m.authDB)
+}
+
+// ping runs SELECT 1 on db, bounded by PingTimeout.
+func ping(parent context.Context, db *sql.DB) error {
+	if db == nil {
+		return errors.New("database is not open")
+	}
+	ctx, cancel := context.WithTimeout(parent, PingTimeout)
+	defer cancel()
+	var one int
+	return db.QueryRowContext(ctx, "SELECT 1").Scan(&one)
+}
+
+// PoolHealth returns the health summary of each connection pool, keyed by
+// the pool names of Metrics.
+func (m *Manager) PoolHealth() map[string]PoolHealth {
+	pools := make(map[string]PoolHealth)
+	for name, stats := range m.Metrics().Pools {
+		pools[name] = PoolHealth{
+			MaxOpen:   stats.MaxOpenConnections,
+			Open:      stats.OpenConnections,
+			InUse:     stats.InUse,
+			Idle:      stats.Idle,
+			WaitCount: stats.WaitCount,
+		}
+	}
+	return pools
+}
+
+// CheckAttachedDatabases verifies that every database attached to the main instance
+// is reachable. For each database, the catalog is queried and, if the database has
+// tables, one row is read from the first table so that an unavailable file or
+// remote source surfaces as an error.
+func (m *Manager) CheckAttachedDatabases() ([]SourceHealth, error) {
+	rows, err := m.QueryMain(`
+		SELECT database_name
+		FROM duckdb_databases()
+		WHERE NOT internal
+		ORDER BY database_name
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list attached databases: %w", err)
+	}
+
+	var names []string
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("failed to scan database name: %w", err)
+		}
+		names = append(names, name)
+	}
+	err = rows.Err()
+	rows.Close()
+	if err != nil {
+		return nil, fmt.Errorf("error iterating databases: %w", err)
+	}
+
+	results := make([]SourceHealth, 0, len(names))
+	for _, name := range names {
+		results = append(results, m.checkDatabase(name))
+	}
+	return results, nil
+}
+
+// checkDatabase probes a single attached database.
+func (m *Manager) checkDatabase(name string) SourceHealth {
+	start := time.Now()
+	result := SourceHealth{Name: name, Type: SourceTypeDatabase}
+
+	var schema, table string
+	err := m.QueryRowScanMain(`
+		SELECT schema_name, table_name
+		FROM duckdb_tables()
+		WHERE database_name = $1
+		ORDER BY schema_name, table_name
+		LIMIT 1
+	`, []interface{}{&schema, &table}, name)
+	if err == nil {
+		query := fmt.Sprintf("SELECT 1 FROM %s.%s.%s LIMIT 1", quoteIdentifier(name), quoteIdentifier(schema), quoteIdentifier(table))
+		err = m.probe(query)
+	} else if errors.Is(err, sql.ErrNoRows) {
+		// Database without tables: the catalog lookup succeeding is enough
+		err = nil
+	}
+
+	result.LatencyMs = time.Since(start).Milliseconds()
+	if err != nil {
+		result.Error = err.Error()
+	} else {
+		result.Healthy = true
+	}
+	return result
+}
+
+// CheckSource runs an operator-configured probe query (e.g. a LIMIT 1 read from
+// an S3 parquet file) and reports whether it succeeded.
+func (m *Manager) CheckSource(name, query string) SourceHealth {
+	start := time.Now()
+	result := SourceHealth{Name: name, Type: SourceTypeProbe}
+
+	err := m.probe(query)
+	result.LatencyMs = time.Since(start).Milliseconds()
+	if err != nil {
+		result.Error = err.Error()
+	} else {
+		result.Healthy = true
+	}
+	return result
+}
+
+// probe executes a query and drains its result.
+func (m *Manager) probe(query string) error {
+	rows, err := m.QueryMain(query)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	for rows.Next() {
+	}
+	return rows.Err()
+}
+
+// quoteIdentifier quotes a SQL identifier, escaping embedded double quotes.
+func quoteIdentifier(name string) string {
+	return `"` + strings.ReplaceAll(name, `"`, `""`) + `"`
+}
@@ -0,0 +1,93 @@
+package database
+
+import (
+	"context"
+	"testing"
+)
+
+func TestCheckAttachedDatabases(t *testing.T) {
+	mgr := setupTestManager(t)
+	defer mgr.Close()
+
+	if _, err := mgr.ExecMain(`ATTACH ':memory:' AS ext`); err != nil {
+		t.Fatalf("Failed to attach database: %v", err)
+	}
+	if _, err := mgr.ExecMain(`CREATE TABLE ext.events (id INTEGER)`); err != nil {
+		t.Fatalf("Failed to create table in attached database: %v", err)
+	}
+
+	results, err := mgr.CheckAttachedDatabases()
+	if err != nil {
+		t.Fatalf("CheckAttachedDatabases failed: %v", err)
+	}
+
+	found := false
+	for _, r := range results {
+		if r.Type != SourceTypeDatabase {
+			t.Errorf("Expected type %q for %s, got %q", SourceTypeDatabase, r.Name, r.Type)
+		}
+		if !r.Healthy {
+			t.Errorf("Expected %s to be healthy, got error: %s", r.Name, r.Error)
+		}
+		if r.Name == "ext" {
+			found = true
+		}
+	}
+	if !found {
+		t.Errorf("Expected attached database 'ext' in results, got %+v", results)
+	}
+}
+
+func TestCheckSource(t *testing.T) {
+	mgr := setupTestManager(t)
+	defer mgr.Close()
+
+	ok := mgr.CheckSource("users", "SELECT * FROM test_users LIMIT 1")
+	if !ok.Healthy || ok.Error != "" {
+		t.Errorf("Expected healthy source, got %+v", ok)
+	}
+	if ok.Type != SourceTypeProbe {
+		t.Errorf("Expected type %q, got %q", SourceTypeProbe, ok.Type)
+	}
+
+	missing := mgr.CheckSource("archive", "SELECT * FROM read_parquet('/nonexistent/archive.parquet') LIMIT 1")
+	if missing.Healthy {
+		t.Error("Expected missing source to be unhealthy")
+	}
+	if missing.Error == "" {
+		t.Error("Expected an error message for missing source")
+	}
+}
+
+func TestQuoteIdentifier(t *testing.T) {
+	if got := quoteIdentifier(`my"db`); got != `"my""db"` {
+		t.Errorf("Expected escaped identifier, got %s", got)
+	}
+}
+
+func TestPing(t *testing.T) {
+	mgr := setupTestManager(t)
+	defer mgr.Close()
+
+	if err := mgr.PingMain(context.Background()); err != nil {
+		t.Errorf("PingMain failed: %v", err)
+	}
+	if err := mgr.PingAuth(context.Background()); err != nil {
+		t.Errorf("PingAuth failed: %v", err)
+	}
+	pools := mgr.PoolHealth()
+	if _, ok := pools["main"]; !ok {
+		t.Errorf("Expected a main pool, got %v", pools)
+	}
+	if _, ok := pools["auth"]; !ok {
+		t.Errorf("Expected an auth pool, got %v", pools)
+	}
+
+	mgr.AuthDB().Close()
+	if err := mgr.PingAuth(context.Background()); err == nil {
+		t.Error("Expected PingAuth to fail on a closed database")
+	}
+	if err := mgr.PingMain(context.Background()); err != nil {
+		t.Errorf("Expected PingMain to be unaffected, got %v", err)
+	}
+}
@@ -0,0 +1,83 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"go.uber.org/zap"
+)
+
+// IndexedColumns returns the lowercased columns of a table that are the only
+// column of an index or of a primary key or unique constraint, so lookups on
+// them are already served by an ART index. A catalog-qualified name
+// ("catalog.table") is looked up in that catalog.
+func (m *Manager) IndexedColumns(table string) (map[string]bool, error) {
+	catalog, name := SplitTableName(table)
+	catalogClause, args := "database_name = current_database()", []interface{}{name}
+	if catalog != "" {
+		catalogClause, args = "database_name = $2", []interface{}{name, catalog}
+	}
+
+	rows, err := m.QueryMain(`
+		SELECT sql FROM duckdb_indexes()
+		WHERE table_name = $1 AND `+catalogClause+`
+		UNION ALL
+		SELECT constraint_column_names[1] FROM duckdb_constraints()
+		WHERE table_name = $1 AND `+catalogClause+`
+			AND constraint_type IN ('PRIMARY KEY', 'UNIQUE') AND len(constraint_column_names) = 1`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	columns := make(map[string]bool)
+	for rows.Next() {
+		var definition sql.NullString
+		if err := rows.Scan(&definition); err != nil {
+			return nil, err
+		}
+		if col := indexColumn(definition.String); col != "" {
+			columns[col] = true
+		}
+	}
+	return columns, rows.Err()
+}
+
+// indexColumn returns the lowercased column of a single-column index given its
+// CREATE INDEX statement, or the column name itself for constraints. It
+// returns "" for multi-column and expression indexes.
+func indexColumn(definition string) string {
+	if open := strings.Index(definition, "("); open >= 0 {
+		end := strings.LastIndex(definition, ")")
+		if end < open {
+			return ""
+		}
+		definition = definition[open+1 : end]
+	}
+	col := strings.Trim(strings.TrimSpace(definition), `"`)
+	if col == "" || strings.ContainsAny(col, ",() ") {
+		return ""
+	}
+	return strings.ToLower(col)
+}
+
+// IndexName returns the name used for an index on a single table column.
+func IndexName(table, column string) string {
+	return "idx_" + strings.ReplaceAll(table, ".", "_") + "_" + column
+}
+
+// CreateIndex creates an ART index on a single table column if an index of
+// that name does not exist yet. Table and column names must be sanitized by
+// the caller.
+func (m *Manager) CreateIndex(table, column string) error {
+	query := fmt.Sprintf("CREATE INDEX IF NOT EXISTS %s ON %s (%s)", IndexName(table, column), table, column)
+	if _, err := m.ExecMain(query); err != nil {
+		return fmt.Errorf("failed to create index: %w", err)
+	}
+	m.logger.Info("Created index",
+		zap.String("table", table),
+		zap.String("column", column),
+	)
+	return nil
+}
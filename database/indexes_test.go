@@ -0,0 +1,49 @@
+package database
+
+import "testing"
+
+func TestIndexColumn(t *testing.T) {
+	tests := []struct {
+		definition string
+		expected   string
+	}{
+		{"CREATE INDEX idx_name ON test_users(name);", "name"},
+		{`CREATE INDEX idx_name ON test_users ("Email");`, "email"},
+		{"CREATE INDEX idx_both ON test_users(name, age);", ""},
+		{"CREATE INDEX idx_lower ON test_users(lower(name));", ""},
+		{"id", "id"},
+		{"", ""},
+	}
+	for _, tt := range tests {
+		if got := indexColumn(tt.definition); got != tt.expected {
+			t.Errorf("indexColumn(%q) = %q, expected %q", tt.definition, got, tt.expected)
+		}
+	}
+}
+
+func TestIndexedColumns(t *testing.T) {
+	mgr := setupTestManager(t)
+	defer mgr.Close()
+
+	if _, err := mgr.ExecMain(`CREATE INDEX idx_both ON test_users (name, age)`); err != nil {
+		t.Fatalf("Failed to create index: %v", err)
+	}
+	if err := mgr.CreateIndex("test_users", "email"); err != nil {
+		t.Fatalf("CreateIndex failed: %v", err)
+	}
+	// Creating it again is a no-op
+	if err := mgr.CreateIndex("test_users", "email"); err != nil {
+		t.Fatalf("CreateIndex failed on existing index: %v", err)
+	}
+
+	indexed, err := mgr.IndexedColumns("test_users")
+	if err != nil {
+		t.Fatalf("IndexedColumns failed: %v", err)
+	}
+	if !indexed["id"] || !indexed["email"] {
+		t.Errorf("Expected the primary key and email to be indexed, got %v", indexed)
+	}
+	if indexed["name"] || indexed["age"] {
+		t.Errorf("Expected columns of a multi-column index not to count, got %v", indexed)
+	}
+}
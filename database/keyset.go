@@ -0,0 +1,80 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+)
+
+// Keyset is the position of a keyset (cursor) paginated read: rows are read in
+// ascending order of Column, starting after the key After. Unlike OFFSET, the
+// position is a range condition, so DuckDB does not scan the rows of earlier
+// pages. Column must be unique for pages to neither skip nor repeat rows; rows
+// with a NULL key are never returned.
+type Keyset struct {
+	Column string
+	// After is the key of the last row of the previous page, nil for the first page.
+	After interface{}
+}
+
+// Filters returns filters with the keyset's range condition added.
+func (k *Keyset) Filters(filters []Filter) []Filter {
+	position := Filter{Column: k.Column, Operator: "is_not_null"}
+	if k.After != nil {
+		position = Filter{Column: k.Column, Operator: "gt", Value: k.After}
+	}
+	return append(append(make([]Filter, 0, len(filters)+1), filters...), position)
+}
+
+// Sorts returns the keyset's order.
+func (k *Keyset) Sorts() []Sort {
+	return []Sort{{Column: k.Column, Direction: "asc"}}
+}
+
+// NextKeyContext returns the key of the last row of the keyset page of limit
+// rows that SelectContext returns for keyset.Filters(filters) and
+// keyset.Sorts(), cast to text, and whether more rows follow it. The key is
+// empty for an empty page. Filters must not include the keyset condition.
+func (m *Manager) NextKeyContext(ctx context.Context, table string, derived []DerivedColumn, filters []Filter, keyset *Keyset, limit int) (next string, more bool, err error) {
+	// Read one row past the page to learn whether another page follows
+	stmt, err := SelectColumnsStatement(table, derived, []string{keyset.Column}, keyset.Filters(filters), nil, nil, keyset.Sorts(), limit+1, 0)
+	if err != nil {
+		return "", false, err
+	}
+	query := fmt.Sprintf(
+		"WITH page AS (%s) SELECT (SELECT COUNT(*) FROM page), (SELECT CAST(MAX(%s) AS VARCHAR) FROM (SELECT %s FROM page ORDER BY %s LIMIT %d))",
+		stmt.SQL, keyset.Column, keyset.Column, keyset.Column, limit,
+	)
+
+	var count int64
+	var key sql.NullString
+	if err := m.QueryRowScanMainContext(ctx, query, []interface{}{&count, &key}, stmt.Params...); err != nil {
+		return "", false, err
+	}
+	return key.String, count > int64(limit), nil
+}
+
+// PrevKeyContext returns the position of the keyset page of limit rows before
+// the page that starts after keyset.After: the key of the row preceding that
+// page, cast to text, or first = true if the previous page is the first page.
+// Filters must not include the keyset condition, and keyset.After must be set.
+func (m *Manager) PrevKeyContext(ctx context.Context, table string, derived []DerivedColumn, filters []Filter, keyset *Keyset, limit int) (prev string, first bool, err error) {
+	// The previous page holds the limit largest keys up to and including
+	// After; its position is the key right below them
+	upTo := append(append(make([]Filter, 0, len(filters)+1), filters...), Filter{Column: keyset.Column, Operator: "lte", Value: keyset.After})
+	stmt, err := SelectColumnsStatement(table, derived, []string{keyset.Column}, upTo, nil, nil, []Sort{{Column: keyset.Column, Direction: "desc"}}, 1, limit)
+	if err != nil {
+		return "", false, err
+	}
+	query := fmt.Sprintf("SELECT CAST(%s AS VARCHAR) FROM (%s)", keyset.Column, stmt.SQL)
+
+	var key sql.NullString
+	err = m.QueryRowScanMainContext(ctx, query, []interface{}{&key}, stmt.Params...)
+	if err == sql.ErrNoRows {
+		return "", true, nil
+	}
+	if err != nil {
+		return "", false, err
+	}
+	return key.String, false, nil
+}
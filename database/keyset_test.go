@@ -0,0 +1,130 @@
+package database
+
+import (
+	"context"
+	"testing"
+)
+
+func TestKeyset_Filters(t *testing.T) {
+	base := []Filter{{Column: "age", Operator: "gt", Value: "18"}}
+
+	first := &Keyset{Column: "id"}
+	filters := first.Filters(base)
+	if len(filters) != 2 || filters[1].Operator != "is_not_null" || filters[1].Column != "id" {
+		t.Errorf("Expected an is_not_null condition for the first page, got %+v", filters)
+	}
+	if len(base) != 1 {
+		t.Errorf("Expected the base filters to be unchanged, got %+v", base)
+	}
+
+	next := &Keyset{Column: "id", After: "5"}
+	filters = next.Filters(base)
+	if len(filters) != 2 || filters[1].Operator != "gt" || filters[1].Value != "5" {
+		t.Errorf("Expected a gt condition after the key, got %+v", filters)
+	}
+
+	sorts := next.Sorts()
+	if len(sorts) != 1 || sorts[0].Column != "id" || sorts[0].Direction != "asc" {
+		t.Errorf("Expected ascending order by id, got %+v", sorts)
+	}
+}
+
+func TestNextKeyContext(t *testing.T) {
+	mgr := setupTestManager(t)
+	defer mgr.Close()
+
+	for i := 1; i <= 5; i++ {
+		if _, err := mgr.Insert("test_users", map[string]interface{}{"id": i * 10, "name": "user", "age": 20 + i}); err != nil {
+			t.Fatalf("Insert failed: %v", err)
+		}
+	}
+	ctx := context.Background()
+
+	// Walk the table two rows at a time
+	var keys []string
+	keyset := &Keyset{Column: "id"}
+	for {
+		next, more, err := mgr.NextKeyContext(ctx, "test_users", nil, nil, keyset, 2)
+		if err != nil {
+			t.Fatalf("NextKeyContext failed: %v", err)
+		}
+		keys = append(keys, next)
+		if !more {
+			break
+		}
+		keyset = &Keyset{Column: "id", After: next}
+	}
+	if len(keys) != 3 || keys[0] != "20" || keys[1] != "40" || keys[2] != "50" {
+		t.Errorf("Expected page keys [20 40 50], got %v", keys)
+	}
+
+	// A full last page has no next page
+	_, more, err := mgr.NextKeyContext(ctx, "test_users", nil, nil, &Keyset{Column: "id", After: "30"}, 2)
+	if err != nil {
+		t.Fatalf("NextKeyContext failed: %v", err)
+	}
+	if more {
+		t.Error("Expected no more rows after the last full page")
+	}
+
+	// Filters apply to the page
+	filters := []Filter{{Column: "age", Operator: "gte", Value: "24"}}
+	next, more, err := mgr.NextKeyContext(ctx, "test_users", nil, filters, &Keyset{Column: "id"}, 5)
+	if err != nil {
+		t.Fatalf("NextKeyContext failed: %v", err)
+	}
+	if next != "50" || more {
+		t.Errorf("Expected last key 50 and no more rows, got %q, %v", next, more)
+	}
+
+	// Past the end the page is empty
+	next, more, err = mgr.NextKeyContext(ctx, "test_users", nil, nil, &Keyset{Column: "id", After: "50"}, 2)
+	if err != nil {
+		t.Fatalf("NextKeyContext failed: %v", err)
+	}
+	if next != "" || more {
+		t.Errorf("Expected an empty page, got %q, %v", next, more)
+	}
+}
+
+func TestPrevKeyContext(t *testing.T) {
+	mgr := setupTestManager(t)
+	defer mgr.Close()
+
+	for i := 1; i <= 5; i++ {
+		if _, err := mgr.Insert("test_users", map[string]interface{}{"id": i * 10, "name": "user", "age": 20 + i}); err != nil {
+			t.Fatalf("Insert failed: %v", err)
+		}
+	}
+	ctx := context.Background()
+
+	tests := []struct {
+		after     string
+		wantPrev  string
+		wantFirst bool
+	}{
+		{"40", "20", false}, // page [50], previous page [30 40]
+		{"30", "10", false}, // page [40 50], previous page [20 30]
+		{"20", "", true},    // page [30 40], previous page [10 20]
+		{"10", "", true},    // page [20 30], previous page [10]
+	}
+	for _, tt := range tests {
+		prev, first, err := mgr.PrevKeyContext(ctx, "test_users", nil, nil, &Keyset{Column: "id", After: tt.after}, 2)
+		if err != nil {
+			t.Fatalf("PrevKeyContext failed: %v", err)
+		}
+		if prev != tt.wantPrev || first != tt.wantFirst {
+			t.Errorf("After %s: expected (%q, %v), got (%q, %v)", tt.after, tt.wantPrev, tt.wantFirst, prev, first)
+		}
+	}
+
+	// Filters apply to the previous page too
+	filters := []Filter{{Column: "age", Operator: "gt", Value: "21"}}
+	prev, first, err := mgr.PrevKeyContext(ctx, "test_users", nil, filters, &Keyset{Column: "id", After: "40"}, 2)
+	if err != nil {
+		t.Fatalf("PrevKeyContext failed: %v", err)
+	}
+	if prev != "20" || first {
+		t.Errorf("Expected previous position 20 with filters, got (%q, %v)", prev, first)
+	}
+}
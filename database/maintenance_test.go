@@ -0,0 +1,92 @@
+package database
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+func TestStartMaintenance_FileDB(t *testing.T) {
+	dir := t.TempDir()
+	mgr, err := NewManagerForTesting(Config{
+		MainDBPath:   filepath.Join(dir, "main.db"),
+		AuthDBPath:   filepath.Join(dir, "auth.db"),
+		Threads:      1,
+		AccessMode:   "read_write",
+		QueryTimeout: 30 * time.Second,
+		Logger:       zap.NewNop(),
+	})
+	if err != nil {
+		t.Fatalf("Failed to create manager: %v", err)
+	}
+	defer mgr.Close()
+
+	if _, err := mgr.ExecMain(`CREATE TABLE events (id INTEGER, payload VARCHAR)`); err != nil {
+		t.Fatalf("Failed to create table: %v", err)
+	}
+
+	mt := mgr.StartMaintenance(20*time.Millisecond, true)
+	if mt == nil {
+		t.Fatal("Expected maintenance to be scheduled for a file database")
+	}
+
+	// Keep writing while maintenance runs; plain CHECKPOINT must not abort writes
+	deadline := time.Now().Add(5 * time.Second)
+	for i := 0; mt.Runs() < 2; i++ {
+		if time.Now().After(deadline) {
+			mt.Stop()
+			t.Fatalf("Expected at least 2 maintenance runs, got %d", mt.Runs())
+		}
+		if _, err := mgr.ExecMain(`INSERT INTO events VALUES ($1, 'x')`, i); err != nil {
+			mt.Stop()
+			t.Fatalf("Insert failed during maintenance: %v", err)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	mt.Stop()
+	runs := mt.Runs()
+	time.Sleep(50 * time.Millisecond)
+	if mt.Runs() != runs {
+		t.Error("Expected no maintenance runs after Stop")
+	}
+	mt.Stop() // stopping twice is harmless
+}
+
+func TestStartMaintenance_Skipped(t *testing.T) {
+	mgr := setupTestManager(t)
+	defer mgr.Close()
+
+	if mt := mgr.StartMaintenance(time.Minute, false); mt != nil {
+		mt.Stop()
+		t.Error("Expected maintenance to be skipped for an in-memory database")
+	}
+
+	dir := t.TempDir()
+	fileMgr, err := NewManagerForTesting(Config{
+		MainDBPath:   filepath.Join(dir, "main.db"),
+		AuthDBPath:   filepath.Join(dir, "auth.db"),
+		Threads:      1,
+		AccessMode:   "read_write",
+		QueryTimeout: 30 * time.Second,
+		Logger:       zap.NewNop(),
+	})
+	if err != nil {
+		t.Fatalf("Failed to create manager: %v", err)
+	}
+	defer fileMgr.Close()
+
+	if mt := fileMgr.StartMaintenance(0, false); mt != nil {
+		mt.Stop()
+		t.Error("Expected maintenance to be disabled with a zero interval")
+	}
+
+	// A nil task is safe to stop
+	var disabled *Maintenance
+	disabled.Stop()
+	if disabled.Runs() != 0 {
+		t.Error("Expected zero runs from a nil task")
+	}
+}
@@ -3,7 +3,10 @@ package database
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"fmt"
+	"sort"
+	"strings"
 	"sync"
 	"time"
 
@@ -21,26 +24,66 @@ type Config struct {
 	EnableObjectCache bool
 	TempDirectory     string
 	QueryTimeout      time.Duration
-	Logger            *zap.Logger
+	// AcquireTimeout bounds the wait for a free connection of a pool, so
+	// statements fail fast with ErrDatabaseBusy when the pool is exhausted
+	// instead of queueing for the query timeout. Zero disables it.
+	AcquireTimeout time.Duration
+	// QueryTagging prefixes queries executed with a tagged context
+	// (see WithQueryTag) with a comment naming the request ID and role.
+	QueryTagging bool
+	// QueryPlanCacheSize is the number of prepared statements kept for
+	// parameterized raw SQL (see QueryPreparedContext). Zero disables the cache,
+	// as does an AcquireTimeout.
+	QueryPlanCacheSize int
+	// QueryCacheTTL caches the results of table reads and counts for this long
+	// (see SelectResultContext). Writes through the manager invalidate the
+	// cached results of their table; raw statements purge the cache. Zero
+	// disables the cache.
+	QueryCacheTTL time.Duration
+	// ReadPoolSize opens a dedicated pool of this many connections to the main
+	// database for reads, so reads never wait for a connection held by a write.
+	// Zero routes reads and writes through the same pool.
+	ReadPoolSize int
+	// TablePools route writes on the listed tables through dedicated pools of
+	// connections to the main database (see TablePool).
+	TablePools []TablePool
+	Logger     *zap.Logger
 }
 
 // Manager handles both the main database and the internal auth database.
 type Manager struct {
-	mainDB        *sql.DB
-	authDB        *sql.DB
-	authDBPath    string   // stored for error messages
-	tableSchemas  sync.Map // map[string][]string - cache of table->columns
-	preparedStmts sync.Map // map[string]*sql.Stmt - cache of query->statement
-	queryTimeout  time.Duration
-	logger        *zap.Logger
+	mainDB         *sql.DB
+	authDB         *sql.DB
+	authDBPath     string             // stored for error messages
+	tableSchemas   sync.Map           // map[string][]string - cache of table->columns
+	preparedStmts  sync.Map           // map[string]*sql.Stmt - cache of query->statement
+	queryPlans     *queryPlanCache    // prepared raw SQL statements; nil when disabled
+	results        *resultCache       // cached read results; nil when disabled
+	counters       queryCounters      // queries and errors by type (see Metrics)
+	mainDBPath     string             // empty for an in-memory database
+	readDB         *sql.DB            // dedicated pool for reads; nil routes reads to mainDB
+	tablePools     map[string]*sql.DB // dedicated write pools by name
+	tableRoutes    map[string]string  // lower-cased table name -> table pool name
+	readOnly       bool
+	queryTimeout   time.Duration
+	acquireTimeout time.Duration // see Config.AcquireTimeout
+	queryTagging   bool
+	logger         *zap.Logger
+
+	mainCatalogOnce sync.Once
+	mainCatalog     string // see MainCatalog
 }
 
 // NewManager creates a new database manager.
 func NewManager(cfg Config) (*Manager, error) {
 	mgr := &Manager{
-		queryTimeout: cfg.QueryTimeout,
-		logger:       cfg.Logger,
-		authDBPath:   cfg.AuthDBPath,
+		queryTimeout:   cfg.QueryTimeout,
+		acquireTimeout: cfg.AcquireTimeout,
+		queryTagging:   cfg.QueryTagging,
+		logger:         cfg.Logger,
+		authDBPath:     cfg.AuthDBPath,
+		mainDBPath:     cfg.MainDBPath,
+		readOnly:       cfg.AccessMode == "read_only",
 	}
 
 	// Initialize main database
@@ -67,9 +110,16 @@ func NewManager(cfg Config) (*Manager, error) {
 		mainDSN = fmt.Sprintf("%s&temp_directory=%s", mainDSN, cfg.TempDirectory)
 	}
 
-	var err error
-	mgr.mainDB, err = sql.Open("duckdb", mainDSN)
+	if err := ValidateTablePools(cfg.TablePools); err != nil {
+		return nil, err
+	}
+	queryPlans, err := newQueryPlanCache(cfg.QueryPlanCacheSize)
 	if err != nil {
+		return nil, err
+	}
+	mgr.queryPlans = queryPlans
+	mgr.results = newResultCache(cfg.QueryCacheTTL)
+	if err := mgr.openMainDB(mainDSN, cfg.ReadPoolSize, cfg.TablePools); err != nil {
 		return nil, fmt.Errorf("failed to open main database: %w", err)
 	}
 
@@ -82,9 +132,25 @@ func NewManager(cfg Config) (*Manager, error) {
 
 	// Test main database connection
 	if err := mgr.mainDB.Ping(); err != nil {
-		mgr.mainDB.Close()
+		mgr.closeMain()
 		return nil, fmt.Errorf("failed to ping main database: %w", err)
 	}
+	if mgr.readDB != nil {
+		mgr.logger.Info("Read pool enabled",
+			zap.Int("max_open_conns", cfg.ReadPoolSize),
+		)
+	}
+	if err := mgr.checkTablePools(); err != nil {
+		mgr.closeMain()
+		return nil, err
+	}
+	for _, p := range cfg.TablePools {
+		mgr.logger.Info("Table pool enabled",
+			zap.String("pool", p.Name),
+			zap.Int("max_open_conns", p.Size),
+			zap.Strings("tables", p.Tables),
+		)
+	}
 
 	mgr.logger.Info("Main database connected",
 		zap.String("dsn", mainDSN),
@@ -97,7 +163,7 @@ func NewManager(cfg Config) (*Manager, error) {
 	authDSN := fmt.Sprintf("%s?threads=%d", cfg.AuthDBPath, cfg.Threads)
 	mgr.authDB, err = sql.Open("duckdb", authDSN)
 	if err != nil {
-		mgr.mainDB.Close()
+		mgr.closeMain()
 		return nil, fmt.Errorf("failed to open auth database: %w", err)
 	}
 
@@ -108,7 +174,7 @@ func NewManager(cfg Config) (*Manager, error) {
 
 	// Test auth database connection
 	if err := mgr.authDB.Ping(); err != nil {
-		mgr.mainDB.Close()
+		mgr.closeMain()
 		mgr.authDB.Close()
 		return nil, fmt.Errorf("failed to ping auth database: %w", err)
 	}
@@ -121,7 +187,7 @@ func NewManager(cfg Config) (*Manager, error) {
 
 	// Initialize auth database schema
 	if err := mgr.initAuthSchema(); err != nil {
-		mgr.mainDB.Close()
+		mgr.closeMain()
 		mgr.authDB.Close()
 		return nil, fmt.Errorf("failed to initialize auth schema: %w", err)
 	}
@@ -170,6 +236,21 @@ func (m *Manager) initAuthSchema() error {
 			"Please add at least one role using: auth-db role add -d <path> -n <role_name>")
 	}
 
+	// Role inheritance needs the parent_role column added in newer auth databases
+	var hasParentRole bool
+	err = m.authDB.QueryRowContext(ctx, `
+		SELECT EXISTS (
+			SELECT 1 FROM information_schema.columns
+			WHERE table_name = 'roles' AND column_name = 'parent_role'
+		)
+	`).Scan(&hasParentRole)
+	if err != nil {
+		return fmt.Errorf("failed to check roles schema: %w", err)
+	}
+	if !hasParentRole {
+		m.logger.Warn("Auth database roles table has no parent_role column; role inheritance is disabled")
+	}
+
 	m.logger.Info("Auth database schema validated",
 		zap.Int("roles", roleCount),
 	)
@@ -180,9 +261,13 @@ func (m *Manager) initAuthSchema() error {
 // This is ONLY for use in tests - production should use the auth-db CLI tool.
 func NewManagerForTesting(cfg Config) (*Manager, error) {
 	mgr := &Manager{
-		queryTimeout: cfg.QueryTimeout,
-		logger:       cfg.Logger,
-		authDBPath:   cfg.AuthDBPath,
+		queryTimeout:   cfg.QueryTimeout,
+		acquireTimeout: cfg.AcquireTimeout,
+		queryTagging:   cfg.QueryTagging,
+		logger:         cfg.Logger,
+		authDBPath:     cfg.AuthDBPath,
+		mainDBPath:     cfg.MainDBPath,
+		readOnly:       cfg.AccessMode == "read_only",
 	}
 
 	if mgr.logger == nil {
@@ -196,23 +281,34 @@ func NewManagerForTesting(cfg Config) (*Manager, error) {
 	}
 	mainDSN = fmt.Sprintf("%s?threads=%d&access_mode=%s", mainDSN, cfg.Threads, cfg.AccessMode)
 
-	var err error
-	mgr.mainDB, err = sql.Open("duckdb", mainDSN)
+	if err := ValidateTablePools(cfg.TablePools); err != nil {
+		return nil, err
+	}
+	queryPlans, err := newQueryPlanCache(cfg.QueryPlanCacheSize)
 	if err != nil {
+		return nil, err
+	}
+	mgr.queryPlans = queryPlans
+	mgr.results = newResultCache(cfg.QueryCacheTTL)
+	if err := mgr.openMainDB(mainDSN, cfg.ReadPoolSize, cfg.TablePools); err != nil {
 		return nil, fmt.Errorf("failed to open main database: %w", err)
 	}
+	if err := mgr.checkTablePools(); err != nil {
+		mgr.closeMain()
+		return nil, err
+	}
 
 	// Initialize auth database
 	authDSN := fmt.Sprintf("%s?threads=%d", cfg.AuthDBPath, cfg.Threads)
 	mgr.authDB, err = sql.Open("duckdb", authDSN)
 	if err != nil {
-		mgr.mainDB.Close()
+		mgr.closeMain()
 		return nil, fmt.Errorf("failed to open auth database: %w", err)
 	}
 
 	// Initialize auth schema for testing (instead of validating)
 	if err := mgr.InitAuthSchemaForTesting(); err != nil {
-		mgr.mainDB.Close()
+		mgr.closeMain()
 		mgr.authDB.Close()
 		return nil, fmt.Errorf("failed to initialize auth schema: %w", err)
 	}
@@ -227,7 +323,9 @@ func (m *Manager) InitAuthSchemaForTesting() error {
 		-- Roles table
 		CREATE TABLE IF NOT EXISTS roles (
 			role_name VARCHAR PRIMARY KEY,
-			description VARCHAR
+			description VARCHAR,
+			parent_role VARCHAR,
+			default_table_access VARCHAR
 		);
 
 		-- API Keys table
@@ -237,6 +335,7 @@ func (m *Manager) InitAuthSchemaForTesting() error {
 			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
 			expires_at TIMESTAMP,
 			is_active BOOLEAN DEFAULT true,
+			namespace VARCHAR,
 			FOREIGN KEY (role_name) REFERENCES roles(role_name)
 		);
 
@@ -250,6 +349,7 @@ func (m *Manager) InitAuthSchemaForTesting() error {
 			can_update BOOLEAN DEFAULT false,
 			can_delete BOOLEAN DEFAULT false,
 			can_query BOOLEAN DEFAULT false,
+			denied_columns VARCHAR,
 			FOREIGN KEY (role_name) REFERENCES roles(role_name),
 			UNIQUE(role_name, table_name)
 		);
@@ -300,6 +400,42 @@ func (m *Manager) MainDB() *sql.DB {
 	return m.mainDB
 }
 
+// MainCatalog returns the catalog name of the main database, e.g. "memory" for an
+// in-memory database or the file name without extension. It is looked up once;
+// "" means it could not be determined.
+func (m *Manager) MainCatalog() string {
+	m.mainCatalogOnce.Do(func() {
+		if err := m.mainDB.QueryRow("SELECT current_database()").Scan(&m.mainCatalog); err != nil {
+			m.logger.Warn("Failed to look up the main catalog", zap.Error(err))
+		}
+	})
+	return m.mainCatalog
+}
+
+// AttachedCatalog returns the name of the attached database that name refers
+// to, as DuckDB spells it, or "" if no database of that name is attached.
+// Internal catalogs (system, temp) are not reported.
+func (m *Manager) AttachedCatalog(name string) (string, error) {
+	var catalog string
+	err := m.QueryRowScanMain(`SELECT database_name FROM duckdb_databases() WHERE NOT internal AND lower(database_name) = lower($1)`, []interface{}{&catalog}, name)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to look up catalog: %w", err)
+	}
+	return catalog, nil
+}
+
+// ReadDB returns the pool used for reads on the main database: the dedicated
+// read pool if one is configured, the main pool otherwise.
+func (m *Manager) ReadDB() *sql.DB {
+	if m.readDB != nil {
+		return m.readDB
+	}
+	return m.mainDB
+}
+
 // AuthDB returns the auth database connection.
 func (m *Manager) AuthDB() *sql.DB {
 	return m.authDB
@@ -310,11 +446,11 @@ func (m *Manager) QueryTimeout() time.Duration {
 	return m.queryTimeout
 }
 
-// Close closes both database connections.
+// Close closes the main (and read) and auth database connections.
 func (m *Manager) Close() error {
 	var err1, err2 error
 	if m.mainDB != nil {
-		err1 = m.mainDB.Close()
+		err1 = m.closeMain()
 	}
 	if m.authDB != nil {
 		err2 = m.authDB.Close()
@@ -328,21 +464,59 @@ func (m *Manager) Close() error {
 
 // ExecMain executes a query on the main database with timeout.
 func (m *Manager) ExecMain(query string, args ...interface{}) (sql.Result, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), m.queryTimeout)
+	return m.ExecMainContext(context.Background(), query, args...)
+}
+
+// ExecMainContext executes a statement on the main database that is cancelled
+// when the parent context is done. The query timeout still applies.
+func (m *Manager) ExecMainContext(parent context.Context, query string, args ...interface{}) (sql.Result, error) {
+	result, err := m.execMain(parent, query, args...)
+	m.counters.record(statementType(query), err)
+	m.purgeResults()
+	return result, err
+}
+
+// execMain is ExecMainContext without counting the statement.
+func (m *Manager) execMain(parent context.Context, query string, args ...interface{}) (sql.Result, error) {
+	db, release, err := m.acquire(parent, m.mainDB)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	ctx, cancel := context.WithTimeout(parent, m.queryTimeout)
 	defer cancel()
-	return m.mainDB.ExecContext(ctx, query, args...)
+	return db.ExecContext(ctx, m.QueryTagPrefix(parent)+query, args...)
 }
 
 // QueryMain executes a query on the main database with timeout.
 // Note: The caller is responsible for closing the returned rows.
 // The context will automatically be cleaned up when the timeout expires.
 func (m *Manager) QueryMain(query string, args ...interface{}) (*sql.Rows, error) {
+	return m.QueryMainContext(context.Background(), query, args...)
+}
+
+// QueryMainContext executes a query on the main database that is cancelled
+// when the parent context is done (e.g., when an HTTP client disconnects).
+// The query timeout still applies.
+func (m *Manager) QueryMainContext(parent context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	rows, err := m.queryMain(parent, query, args...)
+	m.counters.record(statementType(query), err)
+	return rows, err
+}
+
+// queryMain is QueryMainContext without counting the query.
+func (m *Manager) queryMain(parent context.Context, query string, args ...interface{}) (*sql.Rows, error) {
 	// We intentionally don't defer cancel() here because the context needs to
 	// stay alive while the caller iterates over the rows. The context will be
 	// cleaned up automatically when the timeout expires or when rows.Close()
 	// is called. Using a longer timeout ensures rows can be fully read.
-	ctx, cancel := context.WithTimeout(context.Background(), m.queryTimeout)
-	rows, err := m.mainDB.QueryContext(ctx, query, args...)
+	db, release, err := m.acquire(parent, m.ReadDB())
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	ctx, cancel := context.WithTimeout(parent, m.queryTimeout)
+	rows, err := db.QueryContext(ctx, m.QueryTagPrefix(parent)+query, args...)
 	if err != nil {
 		cancel()
 		return nil, err
@@ -360,7 +534,7 @@ func (m *Manager) QueryMain(query string, args ...interface{}) (*sql.Rows, error
 func (m *Manager) QueryRowMain(query string, args ...interface{}) *sql.Row {
 	ctx, cancel := context.WithTimeout(context.Background(), m.queryTimeout)
 	defer cancel()
-	return m.mainDB.QueryRowContext(ctx, query, args...)
+	return m.ReadDB().QueryRowContext(ctx, query, args...)
 }
 
 // ExecAuth executes a query on the auth database with timeout.
@@ -407,16 +581,32 @@ func (m *Manager) BeginTxMain() (*sql.Tx, error) {
 	// Use background context instead of timeout context to avoid resource leaks.
 	// Individual operations within the transaction (Exec, Query) will use
 	// their own timeouts via the Manager's Exec/Query methods.
-	return m.mainDB.BeginTx(context.Background(), nil)
+	db, release, err := m.acquire(context.Background(), m.mainDB)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	return db.BeginTx(context.Background(), nil)
 }
 
 // QueryRowScanMain executes a query that returns a single row and scans it immediately.
 // This is the safe version of QueryRowMain that avoids context cancellation race conditions.
 // Use this when you need to scan a single row into variables.
 func (m *Manager) QueryRowScanMain(query string, dest []interface{}, args ...interface{}) error {
-	ctx, cancel := context.WithTimeout(context.Background(), m.queryTimeout)
-	defer cancel()
-	return m.mainDB.QueryRowContext(ctx, query, args...).Scan(dest...)
+	return m.QueryRowScanMainContext(context.Background(), query, dest, args...)
+}
+
+// QueryRowScanMainContext is like QueryRowScanMain but is cancelled when the parent context is done.
+func (m *Manager) QueryRowScanMainContext(parent context.Context, query string, dest []interface{}, args ...interface{}) error {
+	db, release, err := m.acquire(parent, m.ReadDB())
+	if err == nil {
+		ctx, cancel := context.WithTimeout(parent, m.queryTimeout)
+		err = db.QueryRowContext(ctx, m.QueryTagPrefix(parent)+query, args...).Scan(dest...)
+		cancel()
+		release()
+	}
+	m.counters.record(statementType(query), err)
+	return err
 }
 
 // QueryRowScanAuth executes a query that returns a single row and scans it immediately.
@@ -479,14 +669,15 @@ func (m *Manager) getTableColumns(table string) ([]string, error) {
 	}
 
 	// Cache miss - query information_schema
+	catalogClause, args := catalogCondition(table)
 	query := `
 		SELECT column_name
 		FROM information_schema.columns
-		WHERE table_name = $1
+		WHERE table_name = $1 AND ` + catalogClause + `
 		ORDER BY ordinal_position
 	`
 
-	rows, err := m.QueryMain(query, table)
+	rows, err := m.QueryMain(query, args...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to query table schema: %w", err)
 	}
@@ -520,10 +711,94 @@ func (m *Manager) getTableColumns(table string) ([]string, error) {
 	return columns, nil
 }
 
+// TableColumns returns the column names of a table in ordinal order.
+// Results are cached; see InvalidateTableSchema.
+func (m *Manager) TableColumns(table string) ([]string, error) {
+	return m.getTableColumns(table)
+}
+
+// ColumnSchema is a column of a table as reported by information_schema.
+type ColumnSchema struct {
+	Name     string
+	Type     string
+	Nullable bool
+}
+
+// TableSchema is a table or view of the main database with its columns in ordinal order.
+type TableSchema struct {
+	Name string
+	// Type is the information_schema table type, BASE TABLE or VIEW.
+	Type    string
+	Columns []ColumnSchema
+}
+
+// TableSchemas returns the tables and views of the main database's main schema
+// with their columns, ordered by name. Unlike TableColumns, it is not cached.
+func (m *Manager) TableSchemas(ctx context.Context) ([]TableSchema, error) {
+	rows, err := m.QueryMainContext(ctx, `
+		SELECT c.table_name, t.table_type, c.column_name, c.data_type, c.is_nullable = 'YES'
+		FROM information_schema.columns c
+		JOIN information_schema.tables t
+			ON t.table_catalog = c.table_catalog AND t.table_schema = c.table_schema AND t.table_name = c.table_name
+		WHERE c.table_catalog = current_database() AND c.table_schema = 'main'
+		ORDER BY c.table_name, c.ordinal_position`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query table schemas: %w", err)
+	}
+	defer rows.Close()
+
+	var tables []TableSchema
+	for rows.Next() {
+		var table, tableType string
+		var col ColumnSchema
+		if err := rows.Scan(&table, &tableType, &col.Name, &col.Type, &col.Nullable); err != nil {
+			return nil, fmt.Errorf("failed to scan column: %w", err)
+		}
+		if len(tables) == 0 || tables[len(tables)-1].Name != table {
+			tables = append(tables, TableSchema{Name: table, Type: tableType})
+		}
+		tables[len(tables)-1].Columns = append(tables[len(tables)-1].Columns, col)
+	}
+	return tables, rows.Err()
+}
+
+// UnknownColumns returns the given columns that do not exist in the table
+// (case-insensitive, like DuckDB identifiers). If the cached schema lacks a column,
+// it is reloaded once so that columns added since it was cached are recognized.
+func (m *Manager) UnknownColumns(table string, columns []string) ([]string, error) {
+	unknown, err := m.unknownColumns(table, columns)
+	if err != nil || len(unknown) == 0 {
+		return unknown, err
+	}
+	m.tableSchemas.Delete(table)
+	return m.unknownColumns(table, columns)
+}
+
+// unknownColumns checks columns against the cached table schema.
+func (m *Manager) unknownColumns(table string, columns []string) ([]string, error) {
+	tableColumns, err := m.getTableColumns(table)
+	if err != nil {
+		return nil, err
+	}
+	known := make(map[string]bool, len(tableColumns))
+	for _, col := range tableColumns {
+		known[strings.ToLower(col)] = true
+	}
+	var unknown []string
+	for _, col := range columns {
+		if !known[strings.ToLower(col)] {
+			unknown = append(unknown, col)
+		}
+	}
+	sort.Strings(unknown)
+	return unknown, nil
+}
+
 // InvalidateTableSchema removes a table's schema from the cache.
 // Call this when a table's structure changes (ALTER TABLE).
 func (m *Manager) InvalidateTableSchema(table string) {
 	m.tableSchemas.Delete(table)
+	m.invalidateResults(table)
 
 	// Also invalidate prepared statements for this table
 	m.preparedStmts.Range(func(key, value interface{}) bool {
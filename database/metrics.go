@@ -0,0 +1,182 @@
+package database
+
+import (
+	"database/sql"
+	"errors"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"unicode"
+)
+
+// Query types counted by the query metrics.
+const (
+	QuerySelect = "select"
+	QueryInsert = "insert"
+	QueryUpdate = "update"
+	QueryDelete = "delete"
+	// QueryRaw counts statements of the raw SQL API, whatever they do.
+	QueryRaw = "raw"
+	// QueryOther counts internal statements such as DDL and CHECKPOINT.
+	QueryOther = "other"
+)
+
+// maxQueryLabels bounds the number of distinct query labels counted, so clients
+// cannot grow the metrics without limit. Requests with further labels are
+// counted under QueryLabelOverflow.
+const maxQueryLabels = 1000
+
+// QueryLabelOverflow is the label under which requests are counted once
+// maxQueryLabels distinct labels have been seen. It is not a valid query label.
+const QueryLabelOverflow = "(other)"
+
+// queryTypes lists the query types in exposition order.
+var queryTypes = [...]string{QuerySelect, QueryInsert, QueryUpdate, QueryDelete, QueryRaw, QueryOther}
+
+// queryCounters counts the queries run on the main database and their errors
+// by query type.
+type queryCounters struct {
+	queries [len(queryTypes)]atomic.Int64
+	errors  [len(queryTypes)]atomic.Int64
+
+	labelsMu sync.Mutex
+	labels   map[string]int64
+}
+
+// record counts a query of the given type and, if err is non-nil, its error.
+// A single-row query that finds no row is not an error.
+func (c *queryCounters) record(queryType string, err error) {
+	for i, t := range queryTypes {
+		if t == queryType {
+			c.queries[i].Add(1)
+			if err != nil && !errors.Is(err, sql.ErrNoRows) {
+				c.errors[i].Add(1)
+			}
+			return
+		}
+	}
+}
+
+// CountQueryLabel counts a request carrying a client-supplied query label.
+func (m *Manager) CountQueryLabel(label string) {
+	c := &m.counters
+	c.labelsMu.Lock()
+	defer c.labelsMu.Unlock()
+	if c.labels == nil {
+		c.labels = make(map[string]int64)
+	}
+	if _, ok := c.labels[label]; !ok && len(c.labels) >= maxQueryLabels {
+		label = QueryLabelOverflow
+	}
+	c.labels[label]++
+}
+
+// statementType classifies a statement by its leading keyword.
+func statementType(query string) string {
+	query = strings.TrimSpace(query)
+	// Skip leading comments, e.g. query tags
+	for strings.HasPrefix(query, "/*") {
+		end := strings.Index(query, "*/")
+		if end < 0 {
+			break
+		}
+		query = strings.TrimSpace(query[end+2:])
+	}
+	keyword := query
+	if end := strings.IndexFunc(query, func(r rune) bool { return unicode.IsSpace(r) || r == '(' || r == ';' }); end >= 0 {
+		keyword = query[:end]
+	}
+	switch strings.ToUpper(keyword) {
+	case "SELECT", "WITH", "FROM", "SUMMARIZE", "DESCRIBE", "SHOW", "EXPLAIN":
+		return QuerySelect
+	case "INSERT":
+		return QueryInsert
+	case "UPDATE":
+		return QueryUpdate
+	case "DELETE":
+		return QueryDelete
+	default:
+		return QueryOther
+	}
+}
+
+// recordWrite counts a write of the given type on table once its retries are
+// done and drops the table's cached read results.
+func (m *Manager) recordWrite(queryType, table string, fn func() error) error {
+	err := retryOnConflict(fn)
+	m.counters.record(queryType, err)
+	m.invalidateResults(table)
+	return err
+}
+
+// Metrics is a snapshot of the connection pools and query counters.
+type Metrics struct {
+	// Pools holds the statistics of each connection pool by name: main, read
+	// (if a dedicated read pool is configured), auth, and table:<name> for
+	// each table pool.
+	Pools map[string]sql.DBStats
+
+	// Queries and Errors count the queries run on the main database and the
+	// ones that failed, by query type (see QuerySelect and the other types).
+	Queries map[string]int64
+	Errors  map[string]int64
+
+	// Labels counts the requests by their client-supplied query label.
+	Labels map[string]int64
+}
+
+// PoolNames returns the names of the pools in m, sorted.
+func (m Metrics) PoolNames() []string {
+	names := make([]string, 0, len(m.Pools))
+	for name := range m.Pools {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// LabelNames returns the query labels in m, sorted.
+func (m Metrics) LabelNames() []string {
+	names := make([]string, 0, len(m.Labels))
+	for name := range m.Labels {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// QueryTypes returns the query types of the counters in exposition order.
+func QueryTypes() []string {
+	return append([]string(nil), queryTypes[:]...)
+}
+
+// Metrics returns a snapshot of the connection pool statistics and the query
+// counters.
+func (m *Manager) Metrics() Metrics {
+	metrics := Metrics{
+		Pools:   map[string]sql.DBStats{"main": m.mainDB.Stats()},
+		Queries: make(map[string]int64, len(queryTypes)),
+		Errors:  make(map[string]int64, len(queryTypes)),
+	}
+	if m.readDB != nil {
+		metrics.Pools["read"] = m.readDB.Stats()
+	}
+	if m.authDB != nil {
+		metrics.Pools["auth"] = m.authDB.Stats()
+	}
+	for name, db := range m.tablePools {
+		metrics.Pools["table:"+name] = db.Stats()
+	}
+	for i, t := range queryTypes {
+		metrics.Queries[t] = m.counters.queries[i].Load()
+		metrics.Errors[t] = m.counters.errors[i].Load()
+	}
+	m.counters.labelsMu.Lock()
+	metrics.Labels = make(map[string]int64, len(m.counters.labels))
+	for label, n := range m.counters.labels {
+		metrics.Labels[label] = n
+	}
+	m.counters.labelsMu.Unlock()
+	return metrics
+}
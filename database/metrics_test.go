@@ -0,0 +1,128 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"testing"
+)
+
+func TestStatementType(t *testing.T) {
+	tests := []struct {
+		query    string
+		expected string
+	}{
+		{"SELECT 1", QuerySelect},
+		{"  with t AS (SELECT 1) SELECT * FROM t", QuerySelect},
+		{"/* request_id=abc */ SELECT * FROM t", QuerySelect},
+		{"SELECT\n*", QuerySelect},
+		{"SELECT\t1", QuerySelect},
+		{"select(1)", QuerySelect},
+		{"DELETE FROM t;", QueryDelete},
+		{"INSERT INTO t VALUES (1)", QueryInsert},
+		{"update t SET a = 1", QueryUpdate},
+		{"DELETE FROM t", QueryDelete},
+		{"CREATE TABLE t (a INTEGER)", QueryOther},
+		{"CHECKPOINT", QueryOther},
+		{"", QueryOther},
+	}
+	for _, tt := range tests {
+		if got := statementType(tt.query); got != tt.expected {
+			t.Errorf("statementType(%q) = %q, expected %q", tt.query, got, tt.expected)
+		}
+	}
+}
+
+func TestCountQueryLabel(t *testing.T) {
+	mgr := setupTestManager(t)
+	defer mgr.Close()
+
+	mgr.CountQueryLabel("team-a")
+	mgr.CountQueryLabel("team-a")
+	mgr.CountQueryLabel("team-b")
+	metrics := mgr.Metrics()
+	if metrics.Labels["team-a"] != 2 || metrics.Labels["team-b"] != 1 {
+		t.Errorf("Expected team-a=2 and team-b=1, got %v", metrics.Labels)
+	}
+	if names := metrics.LabelNames(); len(names) != 2 || names[0] != "team-a" {
+		t.Errorf("Expected sorted label names, got %v", names)
+	}
+
+	// Labels beyond the limit are counted together
+	for i := 0; i < maxQueryLabels; i++ {
+		mgr.CountQueryLabel(fmt.Sprintf("label-%d", i))
+	}
+	metrics = mgr.Metrics()
+	if len(metrics.Labels) != maxQueryLabels+1 {
+		t.Errorf("Expected %d labels, got %d", maxQueryLabels+1, len(metrics.Labels))
+	}
+	if metrics.Labels[QueryLabelOverflow] != 2 {
+		t.Errorf("Expected 2 requests counted under %s, got %d", QueryLabelOverflow, metrics.Labels[QueryLabelOverflow])
+	}
+	mgr.CountQueryLabel("team-a")
+	if mgr.Metrics().Labels["team-a"] != 3 {
+		t.Error("Expected known labels to keep counting")
+	}
+}
+
+func TestMetrics_Counters(t *testing.T) {
+	mgr := setupTestManager(t)
+	defer mgr.Close()
+
+	before := mgr.Metrics()
+
+	if _, err := mgr.Insert("test_users", map[string]interface{}{"id": 1, "name": "Alice", "email": "alice@example.com", "age": 30}); err != nil {
+		t.Fatalf("Insert failed: %v", err)
+	}
+	rows, err := mgr.QueryMain("SELECT * FROM test_users")
+	if err != nil {
+		t.Fatalf("QueryMain failed: %v", err)
+	}
+	rows.Close()
+	if _, err := mgr.Update("test_users", map[string]interface{}{"age": 31}, map[string]interface{}{"id": 1}); err != nil {
+		t.Fatalf("Update failed: %v", err)
+	}
+	if _, err := mgr.Delete("test_users", map[string]interface{}{"id": 1}); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+	if _, err := mgr.ExecMain("SELECT * FROM missing_table"); err == nil {
+		t.Fatal("Expected an error for a missing table")
+	}
+	rows, err = mgr.QueryPreparedContext(context.Background(), "SELECT 1")
+	if err != nil {
+		t.Fatalf("QueryPreparedContext failed: %v", err)
+	}
+	rows.Close()
+
+	after := mgr.Metrics()
+	// Schema lookups may add selects of their own
+	if got := after.Queries[QuerySelect] - before.Queries[QuerySelect]; got < 2 {
+		t.Errorf("Expected at least 2 select queries, got %d", got)
+	}
+	if got := after.Errors[QuerySelect] - before.Errors[QuerySelect]; got != 1 {
+		t.Errorf("Expected 1 select error, got %d", got)
+	}
+	expected := map[string][2]int64{
+		QueryInsert: {1, 0},
+		QueryUpdate: {1, 0},
+		QueryDelete: {1, 0},
+		QueryRaw:    {1, 0},
+	}
+	for queryType, counts := range expected {
+		if got := after.Queries[queryType] - before.Queries[queryType]; got != counts[0] {
+			t.Errorf("Expected %d %s queries, got %d", counts[0], queryType, got)
+		}
+		if got := after.Errors[queryType] - before.Errors[queryType]; got != counts[1] {
+			t.Errorf("Expected %d %s errors, got %d", counts[1], queryType, got)
+		}
+	}
+
+	if _, ok := after.Pools["main"]; !ok {
+		t.Error("Expected main pool statistics")
+	}
+	if _, ok := after.Pools["auth"]; !ok {
+		t.Error("Expected auth pool statistics")
+	}
+	if names := after.PoolNames(); len(names) < 2 || names[0] != "auth" {
+		t.Errorf("Expected sorted pool names, got %v", names)
+	}
+}
@@ -1,9 +1,12 @@
 package database
 
 import (
+	"context"
 	"database/sql"
+	"errors"
 	"fmt"
 	"math"
+	"sort"
 	"strings"
 	"time"
 
@@ -65,11 +68,31 @@ func retryOnConflict(fn func() error) error {
 	return fmt.Errorf("transaction failed after %d retries: %w", maxRetries, lastErr)
 }
 
+// Default is a column value that makes Insert use the column's DEFAULT instead
+// of binding a value. Omitted columns are set to NULL.
+var Default = defaultValue{}
+
+type defaultValue struct{}
+
 // Insert inserts a single row into the specified table.
 // Automatically retries on transaction conflicts with exponential backoff.
 // Uses prepared statements with schema normalization for optimal performance.
 // User API: clients can omit nullable columns - they will be set to NULL internally.
+// Columns set to Default are left out of the statement so DuckDB applies their default.
 func (m *Manager) Insert(table string, data map[string]interface{}) (*InsertResult, error) {
+	return m.insert(table, data, true)
+}
+
+// InsertDryRun executes the insert of Insert in a transaction that is rolled
+// back, so type, NOT NULL, CHECK, primary key, and unique violations surface as
+// errors without changing the table.
+func (m *Manager) InsertDryRun(table string, data map[string]interface{}) error {
+	_, err := m.insert(table, data, false)
+	return err
+}
+
+// insert inserts a single row, committing it only if commit is true.
+func (m *Manager) insert(table string, data map[string]interface{}, commit bool) (*InsertResult, error) {
 	if len(data) == 0 {
 		return nil, fmt.Errorf("no data provided for insert")
 	}
@@ -80,10 +103,33 @@ func (m *Manager) Insert(table string, data map[string]interface{}) (*InsertResu
 		return nil, fmt.Errorf("failed to get table schema: %w", err)
 	}
 
+	// Leave defaulted columns out of the column list
+	var defaulted []string
+	for _, col := range columns {
+		if data[col] == Default {
+			defaulted = append(defaulted, col)
+		}
+	}
+	if len(defaulted) > 0 {
+		bound := make([]string, 0, len(columns)-len(defaulted))
+		for _, col := range columns {
+			if data[col] != Default {
+				bound = append(bound, col)
+			}
+		}
+		columns = bound
+	}
+
 	var result *InsertResult
-	err = retryOnConflict(func() error {
+	err = m.recordWrite(QueryInsert, table, func() error {
 		// Get or create prepared statement for this table
-		stmt, err := m.getOrPrepareInsert(table, columns)
+		var stmt *sql.Stmt
+		var err error
+		if len(defaulted) > 0 {
+			stmt, err = m.getOrPrepareInsertWithDefaults(table, columns, defaulted)
+		} else {
+			stmt, err = m.getOrPrepareInsert(table, columns)
+		}
 		if err != nil {
 			return fmt.Errorf("failed to prepare insert statement: %w", err)
 		}
@@ -99,7 +145,7 @@ func (m *Manager) Insert(table string, data map[string]interface{}) (*InsertResu
 		}
 
 		// Use transaction for atomicity
-		tx, err := m.BeginTxMain()
+		tx, err := m.beginTx(table)
 		if err != nil {
 			return fmt.Errorf("failed to begin transaction: %w", err)
 		}
@@ -112,8 +158,10 @@ func (m *Manager) Insert(table string, data map[string]interface{}) (*InsertResu
 			return fmt.Errorf("failed to execute insert: %w", err)
 		}
 
-		if err := tx.Commit(); err != nil {
-			return fmt.Errorf("failed to commit transaction: %w", err)
+		if commit {
+			if err := tx.Commit(); err != nil {
+				return fmt.Errorf("failed to commit transaction: %w", err)
+			}
 		}
 
 		rowsAffected, _ := execResult.RowsAffected()
@@ -124,6 +172,117 @@ func (m *Manager) Insert(table string, data map[string]interface{}) (*InsertResu
 	return result, err
 }
 
+// InsertBatchError reports the row of a batch insert that failed. No row of the batch is inserted.
+type InsertBatchError struct {
+	Index int
+	Err   error
+}
+
+func (e *InsertBatchError) Error() string {
+	return fmt.Sprintf("row %d: %v", e.Index, e.Err)
+}
+
+func (e *InsertBatchError) Unwrap() error {
+	return e.Err
+}
+
+// BatchInsert inserts rows in order within a single transaction. Each row is
+// normalized like in Insert: omitted columns are set to NULL and columns set to
+// Default get their DEFAULT. Rows with the same defaulted columns share one
+// prepared statement. If any row fails, the transaction is rolled back and the
+// error is an *InsertBatchError.
+// Automatically retries on transaction conflicts with exponential backoff.
+func (m *Manager) BatchInsert(table string, rows []map[string]interface{}) (*InsertResult, error) {
+	if len(rows) == 0 {
+		return nil, fmt.Errorf("no rows provided for insert")
+	}
+	for i, row := range rows {
+		if len(row) == 0 {
+			return nil, &InsertBatchError{Index: i, Err: fmt.Errorf("no data provided for insert")}
+		}
+	}
+
+	// Get table schema for normalization
+	columns, err := m.getTableColumns(table)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get table schema: %w", err)
+	}
+
+	var result *InsertResult
+	err = m.recordWrite(QueryInsert, table, func() error {
+		tx, err := m.beginTx(table)
+		if err != nil {
+			return fmt.Errorf("failed to begin transaction: %w", err)
+		}
+		defer tx.Rollback()
+
+		prepared := make(map[string]*sql.Stmt)
+		defer func() {
+			for _, stmt := range prepared {
+				stmt.Close()
+			}
+		}()
+
+		var total int64
+		for i, row := range rows {
+			// Leave defaulted columns out; NULL for omitted columns
+			bound := make([]string, 0, len(columns))
+			values := make([]interface{}, 0, len(columns))
+			for _, col := range columns {
+				if row[col] == Default {
+					continue
+				}
+				bound = append(bound, col)
+				values = append(values, row[col])
+			}
+
+			query := insertSQL(table, bound)
+			txStmt, ok := prepared[query]
+			if !ok {
+				if txStmt, err = tx.Prepare(query); err != nil {
+					return &InsertBatchError{Index: i, Err: fmt.Errorf("failed to prepare insert: %w", err)}
+				}
+				prepared[query] = txStmt
+			}
+			execResult, err := txStmt.Exec(values...)
+			if err != nil {
+				return &InsertBatchError{Index: i, Err: fmt.Errorf("failed to execute insert: %w", err)}
+			}
+			n, _ := execResult.RowsAffected()
+			total += n
+		}
+
+		if err := tx.Commit(); err != nil {
+			return fmt.Errorf("failed to commit transaction: %w", err)
+		}
+		result = &InsertResult{RowsAffected: total}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// insertSQL builds an INSERT statement binding the given columns, or inserting
+// the defaults of all columns if there are none.
+func insertSQL(table string, columns []string) string {
+	if len(columns) == 0 {
+		return fmt.Sprintf("INSERT INTO %s DEFAULT VALUES", table)
+	}
+	placeholders := make([]string, len(columns))
+	for i := range columns {
+		placeholders[i] = fmt.Sprintf("$%d", i+1)
+	}
+	return fmt.Sprintf(
+		"INSERT INTO %s (%s) VALUES (%s)",
+		table,
+		strings.Join(columns, ", "),
+		strings.Join(placeholders, ", "),
+	)
+}
+
 // getOrPrepareInsert gets or creates a prepared INSERT statement for a table.
 func (m *Manager) getOrPrepareInsert(table string, columns []string) (*sql.Stmt, error) {
 	stmtKey := fmt.Sprintf("%s:insert", table)
@@ -147,7 +306,7 @@ func (m *Manager) getOrPrepareInsert(table string, columns []string) (*sql.Stmt,
 	)
 
 	// Prepare statement
-	stmt, err := m.mainDB.Prepare(query)
+	stmt, err := m.writeDB(table).Prepare(query)
 	if err != nil {
 		return nil, fmt.Errorf("failed to prepare statement: %w", err)
 	}
@@ -163,6 +322,30 @@ func (m *Manager) getOrPrepareInsert(table string, columns []string) (*sql.Stmt,
 	return stmt, nil
 }
 
+// getOrPrepareInsertWithDefaults gets or creates a prepared INSERT statement that
+// binds only the given columns, so the defaulted columns get their DEFAULT.
+func (m *Manager) getOrPrepareInsertWithDefaults(table string, columns, defaulted []string) (*sql.Stmt, error) {
+	stmtKey := fmt.Sprintf("%s:insert:default=%s", table, strings.Join(defaulted, ","))
+
+	// Check cache first
+	if cached, ok := m.preparedStmts.Load(stmtKey); ok {
+		return cached.(*sql.Stmt), nil
+	}
+
+	stmt, err := m.writeDB(table).Prepare(insertSQL(table, columns))
+	if err != nil {
+		return nil, fmt.Errorf("failed to prepare statement: %w", err)
+	}
+	m.preparedStmts.Store(stmtKey, stmt)
+
+	m.logger.Debug("Prepared INSERT statement with defaults",
+		zap.String("table", table),
+		zap.Strings("defaulted", defaulted),
+	)
+
+	return stmt, nil
+}
+
 // Update updates rows in the specified table based on the where clause.
 // Automatically retries on transaction conflicts with exponential backoff.
 // Uses prepared statements for common UPDATE patterns (cached by column signature).
@@ -176,7 +359,7 @@ func (m *Manager) Update(table string, set map[string]interface{}, where map[str
 	}
 
 	var result *UpdateResult
-	err := retryOnConflict(func() error {
+	err := m.recordWrite(QueryUpdate, table, func() error {
 		// Try to get or prepare an UPDATE statement for this column pattern
 		stmt, setCols, whereCols, err := m.getOrPrepareUpdate(table, set, where)
 		if err != nil {
@@ -193,7 +376,7 @@ func (m *Manager) Update(table string, set map[string]interface{}, where map[str
 		}
 
 		// Use transaction for atomicity
-		tx, err := m.BeginTxMain()
+		tx, err := m.beginTx(table)
 		if err != nil {
 			return fmt.Errorf("failed to begin transaction: %w", err)
 		}
@@ -219,7 +402,7 @@ func (m *Manager) Update(table string, set map[string]interface{}, where map[str
 }
 
 // UpdateWithFilters updates rows in the specified table based on filter conditions.
-// Supports all filter operators (eq, ne, gt, gte, lt, lte, like, in).
+// Supports all filter operators (eq, ne, gt, gte, lt, lte, like, notlike, in, not_in, between, is_null, is_not_null).
 // Automatically retries on transaction conflicts with exponential backoff.
 func (m *Manager) UpdateWithFilters(table string, set map[string]interface{}, filters []Filter) (*UpdateResult, error) {
 	if len(set) == 0 {
@@ -229,59 +412,18 @@ func (m *Manager) UpdateWithFilters(table string, set map[string]interface{}, fi
 		return nil, fmt.Errorf("no filters provided for update (safety check)")
 	}
 
-	// Build SET clause with stable column order
-	setCols := make([]string, 0, len(set))
-	for col := range set {
-		setCols = append(setCols, col)
-	}
-	// Sort for consistent query generation
-	sortStrings := func(s []string) {
-		for i := 0; i < len(s); i++ {
-			for j := i + 1; j < len(s); j++ {
-				if s[i] > s[j] {
-					s[i], s[j] = s[j], s[i]
-				}
-			}
-		}
-	}
-	sortStrings(setCols)
-
-	// Build UPDATE query dynamically
-	query := fmt.Sprintf("UPDATE %s SET ", table)
-	values := make([]interface{}, 0)
-	paramIndex := 1
-
-	// Build SET clause
-	setClauses := make([]string, len(setCols))
-	for i, col := range setCols {
-		setClauses[i] = fmt.Sprintf("%s = $%d", col, paramIndex)
-		values = append(values, set[col])
-		paramIndex++
-	}
-	query += strings.Join(setClauses, ", ")
-
-	// Build WHERE clause from filters
-	whereClauses := make([]string, 0, len(filters))
-	for _, f := range filters {
-		clause, val := f.ToSQL(paramIndex)
-		whereClauses = append(whereClauses, clause)
-		if val != nil {
-			values = append(values, val)
-			paramIndex++
-		}
-	}
-	query += " WHERE " + strings.Join(whereClauses, " AND ")
+	stmt := UpdateStatement(table, set, filters)
 
 	var result *UpdateResult
-	err := retryOnConflict(func() error {
+	err := m.recordWrite(QueryUpdate, table, func() error {
 		// Use transaction for atomicity
-		tx, err := m.BeginTxMain()
+		tx, err := m.beginTx(table)
 		if err != nil {
 			return fmt.Errorf("failed to begin transaction: %w", err)
 		}
 		defer tx.Rollback()
 
-		execResult, err := tx.Exec(query, values...)
+		execResult, err := tx.Exec(stmt.SQL, stmt.Params...)
 		if err != nil {
 			return fmt.Errorf("failed to execute update: %w", err)
 		}
@@ -298,6 +440,88 @@ func (m *Manager) UpdateWithFilters(table string, set map[string]interface{}, fi
 	return result, err
 }
 
+// UpdateItem is one update of a batch: the SET values for the rows matching the filters.
+type UpdateItem struct {
+	Set     map[string]interface{}
+	Filters []Filter
+}
+
+// UpdateBatchError reports the item of a batch that failed. No item of the batch is applied.
+type UpdateBatchError struct {
+	Index int
+	Err   error
+}
+
+func (e *UpdateBatchError) Error() string {
+	return fmt.Sprintf("update %d: %v", e.Index, e.Err)
+}
+
+func (e *UpdateBatchError) Unwrap() error {
+	return e.Err
+}
+
+// UpdateBatch applies each item's update in order within a single transaction and
+// returns the rows affected by each item. Items with the same SET columns and
+// filters share one prepared statement. If any item fails, the transaction is
+// rolled back and the error is an *UpdateBatchError.
+// Automatically retries on transaction conflicts with exponential backoff.
+func (m *Manager) UpdateBatch(table string, items []UpdateItem) ([]int64, error) {
+	if len(items) == 0 {
+		return nil, fmt.Errorf("no updates provided")
+	}
+	for i, item := range items {
+		if len(item.Set) == 0 {
+			return nil, &UpdateBatchError{Index: i, Err: fmt.Errorf("no data provided for update")}
+		}
+		if len(item.Filters) == 0 {
+			return nil, &UpdateBatchError{Index: i, Err: fmt.Errorf("no filters provided for update (safety check)")}
+		}
+	}
+
+	var affected []int64
+	err := m.recordWrite(QueryUpdate, table, func() error {
+		tx, err := m.beginTx(table)
+		if err != nil {
+			return fmt.Errorf("failed to begin transaction: %w", err)
+		}
+		defer tx.Rollback()
+
+		prepared := make(map[string]*sql.Stmt)
+		defer func() {
+			for _, stmt := range prepared {
+				stmt.Close()
+			}
+		}()
+
+		affected = make([]int64, len(items))
+		for i, item := range items {
+			stmt := UpdateStatement(table, item.Set, item.Filters)
+			txStmt, ok := prepared[stmt.SQL]
+			if !ok {
+				if txStmt, err = tx.Prepare(stmt.SQL); err != nil {
+					return &UpdateBatchError{Index: i, Err: fmt.Errorf("failed to prepare update: %w", err)}
+				}
+				prepared[stmt.SQL] = txStmt
+			}
+			execResult, err := txStmt.Exec(stmt.Params...)
+			if err != nil {
+				return &UpdateBatchError{Index: i, Err: fmt.Errorf("failed to execute update: %w", err)}
+			}
+			affected[i], _ = execResult.RowsAffected()
+		}
+
+		if err := tx.Commit(); err != nil {
+			return fmt.Errorf("failed to commit transaction: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return affected, nil
+}
+
 // getOrPrepareUpdate gets or creates a prepared UPDATE statement for a specific column pattern.
 // Returns the statement and the ordered column lists for SET and WHERE clauses.
 func (m *Manager) getOrPrepareUpdate(table string, set map[string]interface{}, where map[string]interface{}) (*sql.Stmt, []string, []string, error) {
@@ -351,7 +575,7 @@ func (m *Manager) getOrPrepareUpdate(table string, set map[string]interface{}, w
 	)
 
 	// Prepare statement
-	stmt, err := m.mainDB.Prepare(query)
+	stmt, err := m.writeDB(table).Prepare(query)
 	if err != nil {
 		return nil, nil, nil, fmt.Errorf("failed to prepare statement: %w", err)
 	}
@@ -378,7 +602,7 @@ func (m *Manager) Delete(table string, where map[string]interface{}) (*DeleteRes
 	}
 
 	var result *DeleteResult
-	err := retryOnConflict(func() error {
+	err := m.recordWrite(QueryDelete, table, func() error {
 		// Try to get or prepare a DELETE statement for this column pattern
 		stmt, whereCols, err := m.getOrPrepareDelete(table, where)
 		if err != nil {
@@ -392,7 +616,7 @@ func (m *Manager) Delete(table string, where map[string]interface{}) (*DeleteRes
 		}
 
 		// Use transaction for atomicity
-		tx, err := m.BeginTxMain()
+		tx, err := m.beginTx(table)
 		if err != nil {
 			return fmt.Errorf("failed to begin transaction: %w", err)
 		}
@@ -418,40 +642,25 @@ func (m *Manager) Delete(table string, where map[string]interface{}) (*DeleteRes
 }
 
 // DeleteWithFilters deletes rows from the specified table based on filter conditions.
-// Supports all filter operators (eq, ne, gt, gte, lt, lte, like, in).
+// Supports all filter operators (eq, ne, gt, gte, lt, lte, like, notlike, in, not_in, between, is_null, is_not_null).
 // Automatically retries on transaction conflicts with exponential backoff.
 func (m *Manager) DeleteWithFilters(table string, filters []Filter) (*DeleteResult, error) {
 	if len(filters) == 0 {
 		return nil, fmt.Errorf("no filters provided for delete (safety check)")
 	}
 
-	// Build DELETE query dynamically based on filters
-	query := fmt.Sprintf("DELETE FROM %s", table)
-	values := make([]interface{}, 0)
-	paramIndex := 1
-
-	// Build WHERE clause from filters
-	whereClauses := make([]string, 0, len(filters))
-	for _, f := range filters {
-		clause, val := f.ToSQL(paramIndex)
-		whereClauses = append(whereClauses, clause)
-		if val != nil {
-			values = append(values, val)
-			paramIndex++
-		}
-	}
-	query += " WHERE " + strings.Join(whereClauses, " AND ")
+	stmt := DeleteStatement(table, filters)
 
 	var result *DeleteResult
-	err := retryOnConflict(func() error {
+	err := m.recordWrite(QueryDelete, table, func() error {
 		// Use transaction for atomicity
-		tx, err := m.BeginTxMain()
+		tx, err := m.beginTx(table)
 		if err != nil {
 			return fmt.Errorf("failed to begin transaction: %w", err)
 		}
 		defer tx.Rollback()
 
-		execResult, err := tx.Exec(query, values...)
+		execResult, err := tx.Exec(stmt.SQL, stmt.Params...)
 		if err != nil {
 			return fmt.Errorf("failed to execute delete: %w", err)
 		}
@@ -468,6 +677,122 @@ func (m *Manager) DeleteWithFilters(table string, filters []Filter) (*DeleteResu
 	return result, err
 }
 
+// SoftDeleteWithFilters marks rows matching the filters as deleted by setting the
+// given timestamp column to CURRENT_TIMESTAMP. Rows that are already soft-deleted
+// are left untouched so their original deletion time is preserved.
+// Automatically retries on transaction conflicts with exponential backoff.
+func (m *Manager) SoftDeleteWithFilters(table, column string, filters []Filter) (*DeleteResult, error) {
+	if len(filters) == 0 {
+		return nil, fmt.Errorf("no filters provided for delete (safety check)")
+	}
+
+	stmt := SoftDeleteStatement(table, column, filters)
+
+	var result *DeleteResult
+	err := m.recordWrite(QueryDelete, table, func() error {
+		rowsAffected, err := m.execInTx(table, stmt.SQL, stmt.Params...)
+		if err != nil {
+			return fmt.Errorf("failed to execute soft delete: %w", err)
+		}
+		result = &DeleteResult{RowsAffected: rowsAffected}
+		return nil
+	})
+
+	return result, err
+}
+
+// RestoreWithFilters clears the soft-delete column for soft-deleted rows matching
+// the filters, making them visible to regular reads again.
+// Automatically retries on transaction conflicts with exponential backoff.
+func (m *Manager) RestoreWithFilters(table, column string, filters []Filter) (*UpdateResult, error) {
+	if len(filters) == 0 {
+		return nil, fmt.Errorf("no filters provided for restore (safety check)")
+	}
+
+	query := fmt.Sprintf("UPDATE %s SET %s = NULL", table, column)
+	whereClause, values := buildWhereClause(append([]Filter{{Column: column, Operator: "is_not_null"}}, filters...), 1)
+	query += whereClause
+
+	var result *UpdateResult
+	err := m.recordWrite(QueryUpdate, table, func() error {
+		rowsAffected, err := m.execInTx(table, query, values...)
+		if err != nil {
+			return fmt.Errorf("failed to execute restore: %w", err)
+		}
+		result = &UpdateResult{RowsAffected: rowsAffected}
+		return nil
+	})
+
+	return result, err
+}
+
+// PurgeSoftDeleted permanently deletes rows that were soft-deleted before the given time.
+// Rows that are not soft-deleted are never removed by this operation.
+// Automatically retries on transaction conflicts with exponential backoff.
+func (m *Manager) PurgeSoftDeleted(table, column string, before time.Time) (*DeleteResult, error) {
+	query := fmt.Sprintf("DELETE FROM %s WHERE %s IS NOT NULL AND %s < $1", table, column, column)
+
+	var result *DeleteResult
+	err := m.recordWrite(QueryDelete, table, func() error {
+		rowsAffected, err := m.execInTx(table, query, before)
+		if err != nil {
+			return fmt.Errorf("failed to execute purge: %w", err)
+		}
+		result = &DeleteResult{RowsAffected: rowsAffected}
+		return nil
+	})
+
+	return result, err
+}
+
+// execInTx executes a statement on the table inside its own transaction and
+// returns the number of affected rows.
+func (m *Manager) execInTx(table, query string, args ...interface{}) (int64, error) {
+	tx, err := m.beginTx(table)
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	execResult, err := tx.Exec(query, args...)
+	if err != nil {
+		return 0, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	rowsAffected, _ := execResult.RowsAffected()
+	return rowsAffected, nil
+}
+
+// buildWhereClause builds a " WHERE ..." clause from filters, numbering parameters
+// starting at paramIndex. Returns an empty clause when there are no filters.
+func buildWhereClause(filters []Filter, paramIndex int) (string, []interface{}) {
+	values := make([]interface{}, 0, len(filters))
+	if len(filters) == 0 {
+		return "", values
+	}
+
+	whereClauses := make([]string, 0, len(filters))
+	for _, f := range filters {
+		clause, vals := f.ToSQL(paramIndex)
+		whereClauses = append(whereClauses, clause)
+		values = append(values, vals...)
+		paramIndex += len(vals)
+	}
+	return " WHERE " + strings.Join(whereClauses, " AND "), values
+}
+
+// DescribeFilters returns the parameterized condition the given filters produce
+// (without the WHERE keyword) along with the bound parameter values.
+// Values are never interpolated into the returned SQL.
+func DescribeFilters(filters []Filter) (string, []interface{}) {
+	clause, values := buildWhereClause(filters, 1)
+	return strings.TrimPrefix(clause, " WHERE "), values
+}
+
 // CountWithFilters returns the count of rows matching the given filters.
 // Useful for dry-run delete operations to preview affected rows.
 func (m *Manager) CountWithFilters(table string, filters []Filter) (int64, error) {
@@ -516,7 +841,7 @@ func (m *Manager) getOrPrepareDelete(table string, where map[string]interface{})
 	)
 
 	// Prepare statement
-	stmt, err := m.mainDB.Prepare(query)
+	stmt, err := m.writeDB(table).Prepare(query)
 	if err != nil {
 		return nil, nil, fmt.Errorf("failed to prepare statement: %w", err)
 	}
@@ -532,71 +857,108 @@ func (m *Manager) getOrPrepareDelete(table string, where map[string]interface{})
 	return stmt, whereCols, nil
 }
 
-// Select executes a SELECT query with optional filters, sorting, and pagination.
-// This is a read-only operation and does not use transactions for better performance.
-func (m *Manager) Select(table string, filters []Filter, sorts []Sort, limit, offset int) (*sql.Rows, error) {
-	query := fmt.Sprintf("SELECT * FROM %s", table)
-	values := make([]interface{}, 0)
-	paramIndex := 1
-
-	// Add WHERE clause if filters exist
-	if len(filters) > 0 {
-		whereClauses := make([]string, 0, len(filters))
-		for _, f := range filters {
-			clause, val := f.ToSQL(paramIndex)
-			whereClauses = append(whereClauses, clause)
-			if val != nil {
-				values = append(values, val)
-				paramIndex++
-			}
-		}
-		query += " WHERE " + strings.Join(whereClauses, " AND ")
-	}
+// DerivedColumn is a computed column added to the projection of table reads.
+// The expression is defined by the operator and is never taken from client input.
+type DerivedColumn struct {
+	Name       string `json:"name"`
+	Expression string `json:"expression"`
+}
 
-	// Add ORDER BY clause if sorts exist
-	if len(sorts) > 0 {
-		sortClauses := make([]string, 0, len(sorts))
-		for _, s := range sorts {
-			sortClauses = append(sortClauses, s.ToSQL())
-		}
-		query += " ORDER BY " + strings.Join(sortClauses, ", ")
+// RowHashColumn is the name of the per-row hash column added to reads on request.
+const RowHashColumn = "_row_hash"
+
+// RowHashExpression returns a SQL expression computing an MD5 hash over the given
+// columns, for cheap change detection. Values are cast to VARCHAR, prefixed with
+// 'v' (NULL becomes 'n', so it differs from an empty string), and joined with a
+// unit separator.
+func RowHashExpression(columns []string) string {
+	parts := make([]string, len(columns))
+	for i, col := range columns {
+		parts[i] = fmt.Sprintf("COALESCE('v' || CAST(%s AS VARCHAR), 'n')", quoteIdentifier(col))
 	}
+	return fmt.Sprintf("md5(concat_ws(chr(31), %s))", strings.Join(parts, ", "))
+}
 
-	// Add LIMIT and OFFSET
-	if limit > 0 {
-		query += fmt.Sprintf(" LIMIT %d", limit)
+// selectSource returns the FROM target for reads. With derived columns, the table
+// is wrapped in a subquery aliased to the (unqualified) table name so that filters
+// and sorts can reference the derived columns by name.
+func selectSource(table string, derived []DerivedColumn) string {
+	if len(derived) == 0 {
+		return table
 	}
-	if offset > 0 {
-		query += fmt.Sprintf(" OFFSET %d", offset)
+	projections := make([]string, 0, len(derived)+1)
+	projections = append(projections, "*")
+	for _, d := range derived {
+		projections = append(projections, fmt.Sprintf("(%s) AS %s", d.Expression, d.Name))
 	}
+	_, alias := SplitTableName(table)
+	return fmt.Sprintf("(SELECT %s FROM %s) AS %s", strings.Join(projections, ", "), table, alias)
+}
+
+// Select executes a SELECT query with optional filters, sorting, and pagination.
+// This is a read-only operation and does not use transactions for better performance.
+func (m *Manager) Select(table string, filters []Filter, sorts []Sort, limit, offset int) (*sql.Rows, error) {
+	return m.SelectWithDerived(table, nil, filters, sorts, limit, offset)
+}
+
+// SelectWithDerived is like Select but adds the derived columns to the projection.
+// Filters and sorts may reference derived columns by name.
+func (m *Manager) SelectWithDerived(table string, derived []DerivedColumn, filters []Filter, sorts []Sort, limit, offset int) (*sql.Rows, error) {
+	return m.SelectContext(context.Background(), table, derived, filters, nil, nil, sorts, limit, offset)
+}
+
+// SelectContext is like SelectWithDerived but is cancelled when ctx is done and
+// carries the context's query tag. A non-nil window adds a QUALIFY clause; a
+// non-nil sample reads from a random sample of the table.
+func (m *Manager) SelectContext(ctx context.Context, table string, derived []DerivedColumn, filters []Filter, window *Window, sample *Sample, sorts []Sort, limit, offset int) (*sql.Rows, error) {
+	return m.SelectColumnsContext(ctx, table, derived, nil, filters, window, sample, sorts, limit, offset)
+}
 
-	return m.QueryMain(query, values...)
+// SelectColumnsContext is like SelectContext but returns only the given columns,
+// in order. Filters and sorts may still reference columns that are not selected.
+func (m *Manager) SelectColumnsContext(ctx context.Context, table string, derived []DerivedColumn, columns []string, filters []Filter, window *Window, sample *Sample, sorts []Sort, limit, offset int) (*sql.Rows, error) {
+	stmt, err := SelectColumnsStatement(table, derived, columns, filters, window, sample, sorts, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	return m.QueryMainContext(ctx, stmt.SQL, stmt.Params...)
 }
 
 // Count returns the total number of rows in a table matching the filters.
 // This is a read-only operation and does not use transactions for better performance.
 func (m *Manager) Count(table string, filters []Filter) (int64, error) {
-	query := fmt.Sprintf("SELECT COUNT(*) FROM %s", table)
-	values := make([]interface{}, 0)
-	paramIndex := 1
-
-	// Add WHERE clause if filters exist
-	if len(filters) > 0 {
-		whereClauses := make([]string, 0, len(filters))
-		for _, f := range filters {
-			clause, val := f.ToSQL(paramIndex)
-			whereClauses = append(whereClauses, clause)
-			if val != nil {
-				values = append(values, val)
-				paramIndex++
-			}
-		}
-		query += " WHERE " + strings.Join(whereClauses, " AND ")
+	return m.CountWithDerived(table, nil, filters)
+}
+
+// CountWithDerived is like Count but allows filters to reference derived columns.
+func (m *Manager) CountWithDerived(table string, derived []DerivedColumn, filters []Filter) (int64, error) {
+	return m.CountContext(context.Background(), table, derived, filters, nil, nil)
+}
+
+// CountContext is like CountWithDerived but is cancelled when ctx is done and
+// carries the context's query tag. A non-nil window counts the rows it qualifies;
+// a non-nil sample counts the matching rows of the sample.
+func (m *Manager) CountContext(ctx context.Context, table string, derived []DerivedColumn, filters []Filter, window *Window, sample *Sample) (int64, error) {
+	stmt, err := CountStatement(table, derived, filters, window, sample)
+	if err != nil {
+		return 0, err
 	}
 
-	var count int64
-	err := m.QueryRowScanMain(query, []interface{}{&count}, values...)
-	return count, err
+	return m.cachedCount(ctx, table, sample, stmt)
+}
+
+// PageMaxContext returns the largest value of column among the rows SelectContext
+// returns for the same arguments, or nil if the page is empty.
+func (m *Manager) PageMaxContext(ctx context.Context, table string, derived []DerivedColumn, filters []Filter, window *Window, sample *Sample, sorts []Sort, limit, offset int, column string) (interface{}, error) {
+	stmt, err := SelectStatement(table, derived, filters, window, sample, sorts, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+
+	var max interface{}
+	query := fmt.Sprintf("SELECT MAX(%s) FROM (%s)", column, stmt.SQL)
+	err = m.QueryRowScanMainContext(ctx, query, []interface{}{&max}, stmt.Params...)
+	return max, err
 }
 
 // Filter represents a query filter.
@@ -606,29 +968,65 @@ type Filter struct {
 	Value    interface{}
 }
 
-// ToSQL converts the filter to SQL.
-func (f Filter) ToSQL(paramIndex int) (string, interface{}) {
+// ToSQL converts the filter to SQL, numbering its parameters starting at
+// paramIndex, and returns the values to bind: none for is_null and
+// is_not_null (whose Value is ignored), two for between, one otherwise.
+func (f Filter) ToSQL(paramIndex int) (string, []interface{}) {
 	switch f.Operator {
 	case "eq":
-		return fmt.Sprintf("%s = $%d", f.Column, paramIndex), f.Value
+		return fmt.Sprintf("%s = $%d", f.Column, paramIndex), []interface{}{f.Value}
 	case "ne":
-		return fmt.Sprintf("%s != $%d", f.Column, paramIndex), f.Value
+		return fmt.Sprintf("%s != $%d", f.Column, paramIndex), []interface{}{f.Value}
 	case "gt":
-		return fmt.Sprintf("%s > $%d", f.Column, paramIndex), f.Value
+		return fmt.Sprintf("%s > $%d", f.Column, paramIndex), []interface{}{f.Value}
 	case "gte":
-		return fmt.Sprintf("%s >= $%d", f.Column, paramIndex), f.Value
+		return fmt.Sprintf("%s >= $%d", f.Column, paramIndex), []interface{}{f.Value}
 	case "lt":
-		return fmt.Sprintf("%s < $%d", f.Column, paramIndex), f.Value
+		return fmt.Sprintf("%s < $%d", f.Column, paramIndex), []interface{}{f.Value}
 	case "lte":
-		return fmt.Sprintf("%s <= $%d", f.Column, paramIndex), f.Value
+		return fmt.Sprintf("%s <= $%d", f.Column, paramIndex), []interface{}{f.Value}
 	case "like":
-		return fmt.Sprintf("%s LIKE $%d", f.Column, paramIndex), f.Value
+		return fmt.Sprintf("%s LIKE $%d", f.Column, paramIndex), []interface{}{f.Value}
+	case "notlike":
+		return fmt.Sprintf("%s NOT LIKE $%d", f.Column, paramIndex), []interface{}{f.Value}
 	case "in":
 		// For IN operator, value should be a slice
-		return fmt.Sprintf("%s IN $%d", f.Column, paramIndex), f.Value
+		return fmt.Sprintf("%s IN $%d", f.Column, paramIndex), []interface{}{f.Value}
+	case "not_in":
+		return fmt.Sprintf("%s NOT IN $%d", f.Column, paramIndex), []interface{}{f.Value}
+	case "between":
+		// Malformed bounds bind as NULL, which matches no rows
+		low, high, _ := BetweenBounds(f.Value)
+		return fmt.Sprintf("%s BETWEEN $%d AND $%d", f.Column, paramIndex, paramIndex+1), []interface{}{low, high}
+	case "is_null":
+		return fmt.Sprintf("%s IS NULL", f.Column), nil
+	case "is_not_null":
+		return fmt.Sprintf("%s IS NOT NULL", f.Column), nil
 	default:
-		return fmt.Sprintf("%s = $%d", f.Column, paramIndex), f.Value
+		return fmt.Sprintf("%s = $%d", f.Column, paramIndex), []interface{}{f.Value}
+	}
+}
+
+// IsNullOperator reports whether operator is is_null or is_not_null, which
+// take no value and bind no parameter.
+func IsNullOperator(operator string) bool {
+	return operator == "is_null" || operator == "is_not_null"
+}
+
+// BetweenBounds returns the lower and upper bound of a between filter value,
+// which must be a two-element slice. ok is false for any other value.
+func BetweenBounds(value interface{}) (low, high interface{}, ok bool) {
+	switch v := value.(type) {
+	case []string:
+		if len(v) == 2 {
+			return v[0], v[1], true
+		}
+	case []interface{}:
+		if len(v) == 2 {
+			return v[0], v[1], true
+		}
 	}
+	return nil, nil, false
 }
 
 // Sort represents a sort order.
@@ -646,17 +1044,125 @@ func (s Sort) ToSQL() string {
 	return fmt.Sprintf("%s %s", s.Column, dir)
 }
 
-// TableExists checks if a table exists in the main database.
+// TableExists checks if a table exists in the main database. A catalog-qualified
+// name ("catalog.table") is looked up in that attached catalog; an unqualified
+// name in the current catalog.
 func (m *Manager) TableExists(table string) (bool, error) {
+	catalogClause, args := catalogCondition(table)
 	query := `
 		SELECT COUNT(*)
 		FROM information_schema.tables
-		WHERE table_name = $1
-	`
+		WHERE table_name = $1 AND ` + catalogClause
 	var count int
-	err := m.QueryRowScanMain(query, []interface{}{&count}, table)
+	err := m.QueryRowScanMain(query, []interface{}{&count}, args...)
 	if err != nil {
 		return false, err
 	}
 	return count > 0, nil
 }
+
+// RelationNames returns the names of all tables and views in the main database
+// and its attached catalogs, including DuckDB's internal views, lowercased.
+func (m *Manager) RelationNames(ctx context.Context) ([]string, error) {
+	rows, err := m.QueryMainContext(ctx, `
+		SELECT lower(table_name) FROM duckdb_tables()
+		UNION
+		SELECT lower(view_name) FROM duckdb_views()
+		ORDER BY 1`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var names []string
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, err
+		}
+		names = append(names, name)
+	}
+	return names, rows.Err()
+}
+
+// SplitTableName splits a possibly catalog-qualified table name ("catalog.table")
+// into its catalog and table parts. The catalog is empty for unqualified names.
+func SplitTableName(table string) (catalog, name string) {
+	if i := strings.LastIndex(table, "."); i >= 0 {
+		return table[:i], table[i+1:]
+	}
+	return "", table
+}
+
+// catalogCondition returns an information_schema condition matching the table's
+// catalog ($2) or the current catalog, and the arguments for $1 (table name) and $2.
+func catalogCondition(table string) (string, []interface{}) {
+	catalog, name := SplitTableName(table)
+	if catalog == "" {
+		return "table_catalog = current_database()", []interface{}{name}
+	}
+	return "table_catalog = $2", []interface{}{name, catalog}
+}
+
+// InferColumnType returns the DuckDB column type for a decoded JSON value.
+// Whole numbers map to BIGINT, other numbers to DOUBLE, booleans to BOOLEAN,
+// objects and arrays to JSON, and strings (or null) to VARCHAR.
+func InferColumnType(value interface{}) string {
+	switch v := value.(type) {
+	case float64:
+		if v == math.Trunc(v) && !math.IsInf(v, 0) && math.Abs(v) < 1<<63 {
+			return "BIGINT"
+		}
+		return "DOUBLE"
+	case float32:
+		return "DOUBLE"
+	case int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64:
+		return "BIGINT"
+	case bool:
+		return "BOOLEAN"
+	case map[string]interface{}, []interface{}:
+		return "JSON"
+	default:
+		return "VARCHAR"
+	}
+}
+
+// ErrTableExists is returned by CreateTableFromRow when the name already
+// resolves to a table.
+var ErrTableExists = errors.New("table already exists")
+
+// CreateTableFromRow creates a table whose columns and types are inferred from a row
+// (see InferColumnType). Columns are created in alphabetical order.
+// Fails with ErrTableExists if DuckDB resolves the name to an existing table.
+// Table and column names must be sanitized by the caller.
+func (m *Manager) CreateTableFromRow(table string, data map[string]interface{}) error {
+	if len(data) == 0 {
+		return fmt.Errorf("no columns provided to create table")
+	}
+
+	columns := make([]string, 0, len(data))
+	for col := range data {
+		columns = append(columns, col)
+	}
+	sort.Strings(columns)
+
+	definitions := make([]string, len(columns))
+	for i, col := range columns {
+		definitions[i] = fmt.Sprintf("%s %s", col, InferColumnType(data[col]))
+	}
+
+	query := fmt.Sprintf("CREATE TABLE %s (%s)", table, strings.Join(definitions, ", "))
+	if _, err := m.ExecMain(query); err != nil {
+		if strings.Contains(err.Error(), "already exists") {
+			return ErrTableExists
+		}
+		return fmt.Errorf("failed to create table: %w", err)
+	}
+
+	m.InvalidateTableSchema(table)
+	m.logger.Info("Created table from row",
+		zap.String("table", table),
+		zap.Strings("columns", definitions),
+	)
+	return nil
+}
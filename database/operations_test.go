@@ -1,6 +1,10 @@
 package database
 
 import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
 	"testing"
 	"time"
 
@@ -73,6 +77,42 @@ func TestInsert(t *testing.T) {
 	}
 }
 
+func TestInsert_Default(t *testing.T) {
+	mgr := setupTestManager(t)
+	defer mgr.Close()
+
+	if _, err := mgr.ExecMain(`CREATE TABLE tasks (id INTEGER, status VARCHAR DEFAULT 'open', priority INTEGER DEFAULT 3)`); err != nil {
+		t.Fatalf("Failed to create table: %v", err)
+	}
+
+	// Defaulted columns get their DEFAULT, omitted columns get NULL
+	if _, err := mgr.Insert("tasks", map[string]interface{}{"id": 1, "status": Default}); err != nil {
+		t.Fatalf("Insert with default failed: %v", err)
+	}
+	var status string
+	var priority sql.NullInt64
+	if err := mgr.QueryRowScanMain("SELECT status, priority FROM tasks WHERE id = 1", []interface{}{&status, &priority}); err != nil {
+		t.Fatalf("Failed to read row: %v", err)
+	}
+	if status != "open" {
+		t.Errorf("Expected default status 'open', got %q", status)
+	}
+	if priority.Valid {
+		t.Errorf("Expected omitted priority to be NULL, got %d", priority.Int64)
+	}
+
+	// Every column defaulted
+	if _, err := mgr.Insert("tasks", map[string]interface{}{"id": Default, "status": Default, "priority": Default}); err != nil {
+		t.Fatalf("Insert with all defaults failed: %v", err)
+	}
+	var count int
+	if err := mgr.QueryRowScanMain("SELECT COUNT(*) FROM tasks WHERE id IS NULL AND status = 'open' AND priority = 3", []interface{}{&count}); err != nil {
+		t.Fatalf("Failed to count rows: %v", err)
+	}
+	if count != 1 {
+		t.Errorf("Expected 1 fully defaulted row, got %d", count)
+	}
+}
 func TestUpdate(t *testing.T) {
 	mgr := setupTestManager(t)
 	defer mgr.Close()
@@ -117,6 +157,104 @@ func TestUpdate(t *testing.T) {
 	}
 }
 
+func TestUpdateBatch(t *testing.T) {
+	mgr := setupTestManager(t)
+	defer mgr.Close()
+
+	for i, name := range []string{"Alice", "Bob", "Carol"} {
+		if _, err := mgr.Insert("test_users", map[string]interface{}{"id": i + 1, "name": name, "age": 20}); err != nil {
+			t.Fatalf("Failed to insert test data: %v", err)
+		}
+	}
+
+	// Each row gets a different value; the first two items share a statement
+	items := []UpdateItem{
+		{Set: map[string]interface{}{"age": 31}, Filters: []Filter{{Column: "id", Operator: "eq", Value: 1}}},
+		{Set: map[string]interface{}{"age": 42}, Filters: []Filter{{Column: "id", Operator: "eq", Value: 2}}},
+		{Set: map[string]interface{}{"age": 53, "email": "carol@example.com"}, Filters: []Filter{{Column: "id", Operator: "eq", Value: 3}}},
+		{Set: map[string]interface{}{"age": 99}, Filters: []Filter{{Column: "id", Operator: "eq", Value: 4}}},
+	}
+	affected, err := mgr.UpdateBatch("test_users", items)
+	if err != nil {
+		t.Fatalf("UpdateBatch failed: %v", err)
+	}
+	if len(affected) != 4 || affected[0] != 1 || affected[1] != 1 || affected[2] != 1 || affected[3] != 0 {
+		t.Errorf("Expected rows affected [1 1 1 0], got %v", affected)
+	}
+
+	var ages string
+	if err := mgr.QueryRowScanMain("SELECT string_agg(age::VARCHAR, ',' ORDER BY id) FROM test_users", []interface{}{&ages}); err != nil {
+		t.Fatalf("Failed to query ages: %v", err)
+	}
+	if ages != "31,42,53" {
+		t.Errorf("Expected ages 31,42,53, got %s", ages)
+	}
+
+	// A failing item rolls back the whole batch
+	items = []UpdateItem{
+		{Set: map[string]interface{}{"age": 1}, Filters: []Filter{{Column: "id", Operator: "eq", Value: 1}}},
+		{Set: map[string]interface{}{"salary": 1}, Filters: []Filter{{Column: "id", Operator: "eq", Value: 2}}},
+	}
+	_, err = mgr.UpdateBatch("test_users", items)
+	var batchErr *UpdateBatchError
+	if !errors.As(err, &batchErr) || batchErr.Index != 1 {
+		t.Fatalf("Expected an UpdateBatchError for item 1, got %v", err)
+	}
+	var age int
+	if err := mgr.QueryRowScanMain("SELECT age FROM test_users WHERE id = 1", []interface{}{&age}); err != nil {
+		t.Fatalf("Failed to query age: %v", err)
+	}
+	if age != 31 {
+		t.Errorf("Expected the failed batch to be rolled back, got age %d", age)
+	}
+}
+
+func TestBatchInsert(t *testing.T) {
+	mgr := setupTestManager(t)
+	defer mgr.Close()
+
+	// Omitted columns are NULL
+	rows := []map[string]interface{}{
+		{"id": 1, "name": "Alice", "email": "alice@example.com", "age": 30},
+		{"id": 2, "name": "Bob"},
+		{"id": 3, "name": "Carol", "age": 41},
+	}
+	result, err := mgr.BatchInsert("test_users", rows)
+	if err != nil {
+		t.Fatalf("BatchInsert failed: %v", err)
+	}
+	if result.RowsAffected != 3 {
+		t.Errorf("Expected 3 rows affected, got %d", result.RowsAffected)
+	}
+
+	var names string
+	var nullEmails int
+	if err := mgr.QueryRowScanMain("SELECT string_agg(name, ',' ORDER BY id), count(*) FILTER (WHERE email IS NULL) FROM test_users", []interface{}{&names, &nullEmails}); err != nil {
+		t.Fatalf("Failed to query rows: %v", err)
+	}
+	if names != "Alice,Bob,Carol" || nullEmails != 2 {
+		t.Errorf("Unexpected rows: names %s, %d NULL emails", names, nullEmails)
+	}
+
+	// A failing row rolls back the whole batch
+	rows = []map[string]interface{}{
+		{"id": 4, "name": "Dave"},
+		{"id": 1, "name": "Duplicate"},
+	}
+	_, err = mgr.BatchInsert("test_users", rows)
+	var batchErr *InsertBatchError
+	if !errors.As(err, &batchErr) || batchErr.Index != 1 {
+		t.Fatalf("Expected an InsertBatchError for row 1, got %v", err)
+	}
+	var count int
+	if err := mgr.QueryRowScanMain("SELECT count(*) FROM test_users", []interface{}{&count}); err != nil {
+		t.Fatalf("Failed to count rows: %v", err)
+	}
+	if count != 3 {
+		t.Errorf("Expected the failed batch to be rolled back, got %d rows", count)
+	}
+}
+
 func TestDelete(t *testing.T) {
 	mgr := setupTestManager(t)
 	defer mgr.Close()
@@ -230,6 +368,140 @@ func TestSelectWithFilters(t *testing.T) {
 	}
 }
 
+func TestBetweenFilter(t *testing.T) {
+	mgr := setupTestManager(t)
+	defer mgr.Close()
+
+	for i, name := range []string{"Alice", "Bob", "Charlie", "Dave"} {
+		data := map[string]interface{}{"id": i + 1, "name": name, "email": name + "@example.com", "age": 20 + i*5}
+		if _, err := mgr.Insert("test_users", data); err != nil {
+			t.Fatalf("Failed to insert test data: %v", err)
+		}
+	}
+
+	// Ages 20, 25, 30, 35: the bounds are inclusive and the second filter must
+	// be bound to the parameter after both bounds
+	filters := []Filter{
+		{Column: "age", Operator: "between", Value: []string{"25", "35"}},
+		{Column: "name", Operator: "ne", Value: "Dave"},
+	}
+
+	rows, err := mgr.Select("test_users", filters, nil, 0, 0)
+	if err != nil {
+		t.Fatalf("Select with between failed: %v", err)
+	}
+	count := 0
+	for rows.Next() {
+		count++
+	}
+	rows.Close()
+	if count != 2 {
+		t.Errorf("Expected 2 selected rows, got %d", count)
+	}
+
+	if n, err := mgr.Count("test_users", filters); err != nil || n != 2 {
+		t.Errorf("Expected count 2, got %d (%v)", n, err)
+	}
+
+	updated, err := mgr.UpdateWithFilters("test_users", map[string]interface{}{"email": "updated"}, filters)
+	if err != nil {
+		t.Fatalf("UpdateWithFilters with between failed: %v", err)
+	}
+	if updated.RowsAffected != 2 {
+		t.Errorf("Expected 2 updated rows, got %d", updated.RowsAffected)
+	}
+
+	deleted, err := mgr.DeleteWithFilters("test_users", filters)
+	if err != nil {
+		t.Fatalf("DeleteWithFilters with between failed: %v", err)
+	}
+	if deleted.RowsAffected != 2 {
+		t.Errorf("Expected 2 deleted rows, got %d", deleted.RowsAffected)
+	}
+	if n, _ := mgr.Count("test_users", nil); n != 2 {
+		t.Errorf("Expected 2 remaining rows, got %d", n)
+	}
+}
+
+func TestNegatedAndNullFilters(t *testing.T) {
+	mgr := setupTestManager(t)
+	defer mgr.Close()
+
+	testData := []map[string]interface{}{
+		{"id": 1, "name": "Alice", "email": "alice@example.com", "age": 25},
+		{"id": 2, "name": "Bob", "email": nil, "age": 30},
+		{"id": 3, "name": "Charlie", "email": nil, "age": 35},
+		{"id": 4, "name": "Dave", "email": "dave@example.com", "age": 40},
+	}
+	for _, data := range testData {
+		if _, err := mgr.Insert("test_users", data); err != nil {
+			t.Fatalf("Failed to insert test data: %v", err)
+		}
+	}
+
+	// The null filters bind no parameter, so the filters after them must be
+	// numbered from where the previous value filter left off
+	tests := []struct {
+		name     string
+		filters  []Filter
+		expected int64
+	}{
+		{"is_null", []Filter{{Column: "email", Operator: "is_null"}}, 2},
+		{"is_not_null", []Filter{{Column: "email", Operator: "is_not_null"}}, 2},
+		{"not_in", []Filter{{Column: "name", Operator: "not_in", Value: []string{"Alice", "Bob"}}}, 2},
+		{"notlike", []Filter{{Column: "name", Operator: "notlike", Value: "%a%"}}, 2},
+		{"mixed", []Filter{
+			{Column: "age", Operator: "gt", Value: 25},
+			{Column: "email", Operator: "is_null"},
+			{Column: "name", Operator: "ne", Value: "Bob"},
+		}, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			n, err := mgr.Count("test_users", tt.filters)
+			if err != nil {
+				t.Fatalf("Count failed: %v", err)
+			}
+			if n != tt.expected {
+				t.Errorf("Expected %d rows, got %d", tt.expected, n)
+			}
+
+			rows, err := mgr.Select("test_users", tt.filters, nil, 0, 0)
+			if err != nil {
+				t.Fatalf("Select failed: %v", err)
+			}
+			selected := int64(0)
+			for rows.Next() {
+				selected++
+			}
+			rows.Close()
+			if selected != tt.expected {
+				t.Errorf("Expected %d selected rows, got %d", tt.expected, selected)
+			}
+		})
+	}
+
+	mixed := tests[len(tests)-1].filters
+	updated, err := mgr.UpdateWithFilters("test_users", map[string]interface{}{"email": "charlie@example.com"}, mixed)
+	if err != nil {
+		t.Fatalf("UpdateWithFilters failed: %v", err)
+	}
+	if updated.RowsAffected != 1 {
+		t.Errorf("Expected 1 updated row, got %d", updated.RowsAffected)
+	}
+
+	deleted, err := mgr.DeleteWithFilters("test_users", []Filter{
+		{Column: "email", Operator: "is_null"},
+		{Column: "age", Operator: "gte", Value: 30},
+	})
+	if err != nil {
+		t.Fatalf("DeleteWithFilters failed: %v", err)
+	}
+	if deleted.RowsAffected != 1 {
+		t.Errorf("Expected only Bob to be deleted, got %d rows", deleted.RowsAffected)
+	}
+}
+
 func TestSelectWithPagination(t *testing.T) {
 	mgr := setupTestManager(t)
 	defer mgr.Close()
@@ -264,6 +536,107 @@ func TestSelectWithPagination(t *testing.T) {
 	}
 }
 
+func TestSelectWithDerived(t *testing.T) {
+	mgr := setupTestManager(t)
+	defer mgr.Close()
+
+	testData := []map[string]interface{}{
+		{"id": 1, "name": "Alice", "email": "alice@example.com", "age": 25},
+		{"id": 2, "name": "Bob", "email": "bob@example.com", "age": 30},
+		{"id": 3, "name": "Charlie", "email": "charlie@example.com", "age": 35},
+	}
+	for _, data := range testData {
+		if _, err := mgr.Insert("test_users", data); err != nil {
+			t.Fatalf("Failed to insert test data: %v", err)
+		}
+	}
+
+	derived := []DerivedColumn{
+		{Name: "label", Expression: "CONCAT(name, ' <', email, '>')"},
+		{Name: "age_next_year", Expression: "age + 1"},
+	}
+	filters := []Filter{{Column: "age_next_year", Operator: "gt", Value: 30}}
+	sorts := []Sort{{Column: "label", Direction: "desc"}}
+
+	rows, err := mgr.SelectWithDerived("test_users", derived, filters, sorts, 0, 0)
+	if err != nil {
+		t.Fatalf("SelectWithDerived failed: %v", err)
+	}
+	defer rows.Close()
+
+	columns, _ := rows.Columns()
+	if columns[len(columns)-2] != "label" || columns[len(columns)-1] != "age_next_year" {
+		t.Errorf("Expected derived columns at the end of the projection, got %v", columns)
+	}
+
+	var labels []string
+	for rows.Next() {
+		values := make([]interface{}, len(columns))
+		ptrs := make([]interface{}, len(columns))
+		for i := range values {
+			ptrs[i] = &values[i]
+		}
+		if err := rows.Scan(ptrs...); err != nil {
+			t.Fatalf("Scan failed: %v", err)
+		}
+		labels = append(labels, values[len(columns)-2].(string))
+	}
+	if len(labels) != 2 || labels[0] != "Charlie <charlie@example.com>" || labels[1] != "Bob <bob@example.com>" {
+		t.Errorf("Unexpected labels: %v", labels)
+	}
+
+	count, err := mgr.CountWithDerived("test_users", derived, filters)
+	if err != nil {
+		t.Fatalf("CountWithDerived failed: %v", err)
+	}
+	if count != 2 {
+		t.Errorf("Expected count 2, got %d", count)
+	}
+}
+
+func TestRowHashExpression(t *testing.T) {
+	mgr := setupTestManager(t)
+	defer mgr.Close()
+
+	if _, err := mgr.ExecMain(`
+		CREATE TABLE hashed AS SELECT * FROM (VALUES
+			(1, 'a', 'x'), (2, 'a', 'x'), (3, 'a', ''), (4, 'a', NULL), (5, 'ax', '')
+		) AS t(id, name, note)
+	`); err != nil {
+		t.Fatalf("Failed to create table: %v", err)
+	}
+
+	query := fmt.Sprintf("SELECT id, %s FROM hashed ORDER BY id", RowHashExpression([]string{"name", "note"}))
+	rows, err := mgr.QueryMain(query)
+	if err != nil {
+		t.Fatalf("Query failed: %v", err)
+	}
+	defer rows.Close()
+
+	hashes := make(map[int]string)
+	for rows.Next() {
+		var id int
+		var hash string
+		if err := rows.Scan(&id, &hash); err != nil {
+			t.Fatalf("Scan failed: %v", err)
+		}
+		hashes[id] = hash
+	}
+
+	if hashes[1] != hashes[2] {
+		t.Error("Expected identical rows to have identical hashes")
+	}
+	if hashes[1] == hashes[3] {
+		t.Error("Expected changed rows to have different hashes")
+	}
+	if hashes[3] == hashes[4] {
+		t.Error("Expected NULL and empty string to hash differently")
+	}
+	if hashes[3] == hashes[5] {
+		t.Error("Expected column boundaries to affect the hash")
+	}
+}
+
 func TestCount(t *testing.T) {
 	mgr := setupTestManager(t)
 	defer mgr.Close()
@@ -316,6 +689,95 @@ func TestTableExists(t *testing.T) {
 	}
 }
 
+func TestRelationNames(t *testing.T) {
+	mgr := setupTestManager(t)
+	defer mgr.Close()
+
+	if _, err := mgr.ExecMain(`CREATE VIEW Adult_Users AS SELECT * FROM test_users WHERE age >= 18`); err != nil {
+		t.Fatalf("Failed to create view: %v", err)
+	}
+
+	names, err := mgr.RelationNames(context.Background())
+	if err != nil {
+		t.Fatalf("RelationNames failed: %v", err)
+	}
+	found := make(map[string]bool, len(names))
+	for _, name := range names {
+		found[name] = true
+	}
+	for _, name := range []string{"test_users", "adult_users", "duckdb_columns"} {
+		if !found[name] {
+			t.Errorf("Expected %s in relation names, got %v", name, names)
+		}
+	}
+}
+
+func TestInferColumnType(t *testing.T) {
+	tests := []struct {
+		value    interface{}
+		expected string
+	}{
+		{float64(42), "BIGINT"},
+		{float64(-7), "BIGINT"},
+		{3.14, "DOUBLE"},
+		{true, "BOOLEAN"},
+		{"hello", "VARCHAR"},
+		{nil, "VARCHAR"},
+		{map[string]interface{}{"a": 1}, "JSON"},
+		{[]interface{}{1, 2}, "JSON"},
+	}
+
+	for _, tt := range tests {
+		if got := InferColumnType(tt.value); got != tt.expected {
+			t.Errorf("InferColumnType(%v) = %s, expected %s", tt.value, got, tt.expected)
+		}
+	}
+}
+
+func TestCreateTableFromRow(t *testing.T) {
+	mgr := setupTestManager(t)
+	defer mgr.Close()
+
+	row := map[string]interface{}{"name": "Widget", "price": 9.5, "qty": float64(3), "active": true}
+	if err := mgr.CreateTableFromRow("products", row); err != nil {
+		t.Fatalf("CreateTableFromRow failed: %v", err)
+	}
+
+	rows, err := mgr.QueryMain("SELECT column_name, data_type FROM information_schema.columns WHERE table_name = 'products' ORDER BY ordinal_position")
+	if err != nil {
+		t.Fatalf("Failed to query columns: %v", err)
+	}
+	defer rows.Close()
+
+	got := map[string]string{}
+	var order []string
+	for rows.Next() {
+		var name, typ string
+		if err := rows.Scan(&name, &typ); err != nil {
+			t.Fatalf("Failed to scan: %v", err)
+		}
+		got[name] = typ
+		order = append(order, name)
+	}
+
+	expected := map[string]string{"active": "BOOLEAN", "name": "VARCHAR", "price": "DOUBLE", "qty": "BIGINT"}
+	for col, typ := range expected {
+		if got[col] != typ {
+			t.Errorf("Expected column %s of type %s, got %s", col, typ, got[col])
+		}
+	}
+	if len(order) != 4 || order[0] != "active" || order[3] != "qty" {
+		t.Errorf("Expected alphabetical column order, got %v", order)
+	}
+
+	// Creating again fails, including through another name of the table
+	for _, name := range []string{"products", "main.products"} {
+		if err := mgr.CreateTableFromRow(name, map[string]interface{}{"other": 1.0}); !errors.Is(err, ErrTableExists) {
+			t.Errorf("Expected ErrTableExists for %s, got %v", name, err)
+		}
+	}
+}
+
 func TestFilterToSQL(t *testing.T) {
 	tests := []struct {
 		filter   Filter
@@ -328,13 +790,40 @@ func TestFilterToSQL(t *testing.T) {
 		{Filter{Column: "age", Operator: "lt", Value: 30}, "age < $1"},
 		{Filter{Column: "age", Operator: "lte", Value: 30}, "age <= $1"},
 		{Filter{Column: "name", Operator: "like", Value: "John%"}, "name LIKE $1"},
+		{Filter{Column: "age", Operator: "between", Value: []string{"18", "65"}}, "age BETWEEN $1 AND $2"},
+		{Filter{Column: "name", Operator: "notlike", Value: "John%"}, "name NOT LIKE $1"},
+		{Filter{Column: "age", Operator: "not_in", Value: []string{"1", "2"}}, "age NOT IN $1"},
+		{Filter{Column: "email", Operator: "is_null", Value: "ignored"}, "email IS NULL"},
+		{Filter{Column: "email", Operator: "is_not_null"}, "email IS NOT NULL"},
 	}
 
 	for _, tt := range tests {
-		sql, _ := tt.filter.ToSQL(1)
+		sql, values := tt.filter.ToSQL(1)
 		if sql != tt.expected {
 			t.Errorf("Expected SQL '%s', got '%s'", tt.expected, sql)
 		}
+		if IsNullOperator(tt.filter.Operator) && len(values) != 0 {
+			t.Errorf("Expected no values for %s, got %v", tt.filter.Operator, values)
+		}
+	}
+}
+
+func TestDescribeFilters(t *testing.T) {
+	clause, params := DescribeFilters([]Filter{
+		{Column: "age", Operator: "gt", Value: 30},
+		{Column: "deleted_at", Operator: "is_null"},
+		{Column: "name", Operator: "like", Value: "J%"},
+	})
+
+	if expected := "age > $1 AND deleted_at IS NULL AND name LIKE $2"; clause != expected {
+		t.Errorf("Expected clause '%s', got '%s'", expected, clause)
+	}
+	if len(params) != 2 || params[0] != 30 || params[1] != "J%" {
+		t.Errorf("Expected params [30 J%%], got %v", params)
+	}
+
+	if clause, params := DescribeFilters(nil); clause != "" || len(params) != 0 {
+		t.Errorf("Expected empty clause for no filters, got '%s' %v", clause, params)
 	}
 }
 
@@ -0,0 +1,122 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+
+	lru "github.com/hashicorp/golang-lru/v2"
+)
+
+// queryPlanCache is a bounded LRU cache of prepared statements for raw SQL,
+// keyed by the pool they were prepared on and the normalized SQL. Evicted
+// statements are closed.
+type queryPlanCache = lru.Cache[string, *sql.Stmt]
+
+// newQueryPlanCache returns a cache of size statements, or nil for size <= 0.
+func newQueryPlanCache(size int) (*queryPlanCache, error) {
+	if size <= 0 {
+		return nil, nil
+	}
+	cache, err := lru.NewWithEvict(size, func(_ string, stmt *sql.Stmt) {
+		stmt.Close()
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create query plan cache: %w", err)
+	}
+	return cache, nil
+}
+
+// normalizeQuery returns the cache key form of a query: without surrounding
+// whitespace and trailing semicolons. The body is left intact, since spaces
+// inside string literals are significant.
+func normalizeQuery(query string) string {
+	return strings.TrimSpace(strings.TrimRight(strings.TrimSpace(query), ";"))
+}
+
+// cachedStmt returns the prepared statement for query on db, preparing and
+// caching it on a miss. ok is false when the statement should not be cached:
+// the cache is disabled, the query has no parameters (ad-hoc literals would
+// only churn the cache), query tagging embeds a per-request comment, or an
+// acquire timeout is set (a prepared statement takes its connection from the
+// pool itself, so it could not fail fast with ErrDatabaseBusy).
+func (m *Manager) cachedStmt(parent context.Context, pool string, db *sql.DB, query string, args []interface{}) (stmt *sql.Stmt, ok bool, err error) {
+	if m.queryPlans == nil || len(args) == 0 || m.QueryTagPrefix(parent) != "" || m.acquireTimeout > 0 {
+		return nil, false, nil
+	}
+	query = normalizeQuery(query)
+	key := pool + ":" + query
+	if stmt, found := m.queryPlans.Get(key); found {
+		return stmt, true, nil
+	}
+
+	ctx, cancel := context.WithTimeout(parent, m.queryTimeout)
+	defer cancel()
+	stmt, err = db.PrepareContext(ctx, query)
+	if err != nil {
+		return nil, true, err
+	}
+	// Another request may have prepared the same query meanwhile
+	if previous, found, _ := m.queryPlans.PeekOrAdd(key, stmt); found {
+		stmt.Close()
+		return previous, true, nil
+	}
+	return stmt, true, nil
+}
+
+// QueryPreparedContext is like QueryMainContext but runs parameterized queries
+// from a cache of prepared statements (see Config.QueryPlanCacheSize), so
+// repeated executions skip parsing and planning. Queries without parameters
+// run uncached. The query is counted as a raw query (see Metrics).
+func (m *Manager) QueryPreparedContext(parent context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	rows, err := m.queryPrepared(parent, query, args...)
+	m.counters.record(QueryRaw, err)
+	return rows, err
+}
+
+func (m *Manager) queryPrepared(parent context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	stmt, ok, err := m.cachedStmt(parent, "read", m.ReadDB(), query, args)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return m.queryMain(parent, query, args...)
+	}
+
+	// As in QueryMainContext, the context stays alive while the rows are read
+	ctx, cancel := context.WithTimeout(parent, m.queryTimeout)
+	rows, err := stmt.QueryContext(ctx, args...)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	go func() {
+		<-ctx.Done()
+		cancel()
+	}()
+	return rows, nil
+}
+
+// ExecPreparedContext is like ExecMainContext but runs parameterized statements
+// from the cache of prepared statements, like QueryPreparedContext.
+func (m *Manager) ExecPreparedContext(parent context.Context, query string, args ...interface{}) (sql.Result, error) {
+	result, err := m.execPrepared(parent, query, args...)
+	m.counters.record(QueryRaw, err)
+	m.purgeResults()
+	return result, err
+}
+
+func (m *Manager) execPrepared(parent context.Context, query string, args ...interface{}) (sql.Result, error) {
+	stmt, ok, err := m.cachedStmt(parent, "write", m.mainDB, query, args)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return m.execMain(parent, query, args...)
+	}
+
+	ctx, cancel := context.WithTimeout(parent, m.queryTimeout)
+	defer cancel()
+	return stmt.ExecContext(ctx, args...)
+}
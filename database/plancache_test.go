@@ -0,0 +1,173 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+func setupPlanCacheManager(tb testing.TB, size int) *Manager {
+	tb.Helper()
+	mgr, err := NewManagerForTesting(Config{
+		MainDBPath:         ":memory:",
+		AuthDBPath:         ":memory:",
+		Threads:            1,
+		AccessMode:         "read_write",
+		QueryTimeout:       30 * time.Second,
+		QueryPlanCacheSize: size,
+		Logger:             zap.NewNop(),
+	})
+	if err != nil {
+		tb.Fatalf("Failed to create manager: %v", err)
+	}
+	if _, err := mgr.ExecMain("CREATE TABLE items (id INTEGER PRIMARY KEY, name VARCHAR)"); err != nil {
+		tb.Fatalf("Failed to create table: %v", err)
+	}
+	return mgr
+}
+
+func TestNormalizeQuery(t *testing.T) {
+	tests := map[string]string{
+		"SELECT 1":             "SELECT 1",
+		"  SELECT 1;  ":        "SELECT 1",
+		"SELECT 1;;\n":         "SELECT 1",
+		"SELECT 'a  b' FROM t": "SELECT 'a  b' FROM t",
+	}
+	for in, want := range tests {
+		if got := normalizeQuery(in); got != want {
+			t.Errorf("normalizeQuery(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestPreparedContext_RepeatedParams(t *testing.T) {
+	mgr := setupPlanCacheManager(t, 4)
+	defer mgr.Close()
+	ctx := context.Background()
+
+	insert := "INSERT INTO items (id, name) VALUES ($1, $2)"
+	for i := 1; i <= 5; i++ {
+		if _, err := mgr.ExecPreparedContext(ctx, insert, i, fmt.Sprintf("item-%d", i)); err != nil {
+			t.Fatalf("insert %d failed: %v", i, err)
+		}
+	}
+
+	query := "SELECT name FROM items WHERE id = $1"
+	for i := 1; i <= 5; i++ {
+		rows, err := mgr.QueryPreparedContext(ctx, query+";", i)
+		if err != nil {
+			t.Fatalf("query %d failed: %v", i, err)
+		}
+		var name string
+		if !rows.Next() {
+			t.Fatalf("query %d returned no rows", i)
+		}
+		if err := rows.Scan(&name); err != nil {
+			t.Fatalf("scan %d failed: %v", i, err)
+		}
+		rows.Close()
+		if want := fmt.Sprintf("item-%d", i); name != want {
+			t.Errorf("query %d: expected %q, got %q", i, want, name)
+		}
+	}
+
+	// The insert and the select (with and without semicolon) share two entries
+	if got := mgr.queryPlans.Len(); got != 2 {
+		t.Errorf("Expected 2 cached statements, got %d", got)
+	}
+}
+
+func TestPreparedContext_NotCached(t *testing.T) {
+	mgr := setupPlanCacheManager(t, 4)
+	defer mgr.Close()
+
+	// Queries without params run uncached
+	rows, err := mgr.QueryPreparedContext(context.Background(), "SELECT COUNT(*) FROM items")
+	if err != nil {
+		t.Fatalf("query failed: %v", err)
+	}
+	rows.Close()
+
+	// Tagged queries carry a per-request comment
+	mgr.queryTagging = true
+	ctx := WithQueryTag(context.Background(), QueryTag{RequestID: "req-1", Role: "admin"})
+	rows, err = mgr.QueryPreparedContext(ctx, "SELECT name FROM items WHERE id = $1", 1)
+	if err != nil {
+		t.Fatalf("tagged query failed: %v", err)
+	}
+	rows.Close()
+
+	if got := mgr.queryPlans.Len(); got != 0 {
+		t.Errorf("Expected no cached statements, got %d", got)
+	}
+}
+
+func TestPreparedContext_Eviction(t *testing.T) {
+	mgr := setupPlanCacheManager(t, 2)
+	defer mgr.Close()
+	ctx := context.Background()
+
+	for i := 0; i < 5; i++ {
+		query := fmt.Sprintf("SELECT id + %d FROM items WHERE id = $1", i)
+		rows, err := mgr.QueryPreparedContext(ctx, query, 1)
+		if err != nil {
+			t.Fatalf("query %d failed: %v", i, err)
+		}
+		rows.Close()
+	}
+	if got := mgr.queryPlans.Len(); got != 2 {
+		t.Errorf("Expected cache bounded at 2 statements, got %d", got)
+	}
+
+	// A statement evicted earlier is prepared again
+	rows, err := mgr.QueryPreparedContext(ctx, "SELECT id + 0 FROM items WHERE id = $1", 1)
+	if err != nil {
+		t.Fatalf("query after eviction failed: %v", err)
+	}
+	rows.Close()
+}
+
+func TestPreparedContext_Disabled(t *testing.T) {
+	mgr := setupPlanCacheManager(t, 0)
+	defer mgr.Close()
+
+	if mgr.queryPlans != nil {
+		t.Fatal("Expected no cache with size 0")
+	}
+	if _, err := mgr.ExecPreparedContext(context.Background(), "INSERT INTO items VALUES ($1, $2)", 1, "a"); err != nil {
+		t.Fatalf("insert failed: %v", err)
+	}
+}
+
+func benchmarkPreparedQuery(b *testing.B, size int) {
+	mgr := setupPlanCacheManager(b, size)
+	defer mgr.Close()
+	ctx := context.Background()
+	if _, err := mgr.ExecMain("INSERT INTO items SELECT range, 'item-' || range FROM range(1000)"); err != nil {
+		b.Fatalf("seed failed: %v", err)
+	}
+
+	query := "SELECT i.id, i.name, o.name FROM items i JOIN items o ON o.id = i.id + 1 WHERE i.id BETWEEN $1 AND $2 ORDER BY i.id"
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		lo := i % 900
+		rows, err := mgr.QueryPreparedContext(ctx, query, lo, lo+10)
+		if err != nil {
+			b.Fatal(err)
+		}
+		for rows.Next() {
+		}
+		rows.Close()
+	}
+}
+
+func BenchmarkQueryPrepared_Uncached(b *testing.B) {
+	benchmarkPreparedQuery(b, 0)
+}
+
+func BenchmarkQueryPrepared_Cached(b *testing.B) {
+	benchmarkPreparedQuery(b, 64)
+}
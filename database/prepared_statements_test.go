@@ -281,6 +281,57 @@ func TestTableSchemaCaching(t *testing.T) {
 	}
 }
 
+// TestUnknownColumns verifies unknown column detection, including columns added after caching.
+func TestUnknownColumns(t *testing.T) {
+	mgr, err := NewManagerForTesting(Config{
+		MainDBPath:   ":memory:",
+		AuthDBPath:   ":memory:",
+		Threads:      1,
+		AccessMode:   "read_write",
+		QueryTimeout: 5 * time.Second,
+		Logger:       zap.NewNop(),
+	})
+	if err != nil {
+		t.Fatalf("Failed to create manager: %v", err)
+	}
+	defer mgr.Close()
+
+	if _, err := mgr.ExecMain(`CREATE TABLE items (id INTEGER, name VARCHAR)`); err != nil {
+		t.Fatalf("Failed to create table: %v", err)
+	}
+
+	unknown, err := mgr.UnknownColumns("items", []string{"id", "NAME", "stale", "legacy"})
+	if err != nil {
+		t.Fatalf("UnknownColumns failed: %v", err)
+	}
+	if len(unknown) != 2 || unknown[0] != "legacy" || unknown[1] != "stale" {
+		t.Errorf("Expected [legacy stale], got %v", unknown)
+	}
+
+	// A column added after the schema was cached is recognized
+	if _, err := mgr.ExecMain(`ALTER TABLE items ADD COLUMN stale VARCHAR`); err != nil {
+		t.Fatalf("Failed to add column: %v", err)
+	}
+	unknown, err = mgr.UnknownColumns("items", []string{"id", "stale"})
+	if err != nil {
+		t.Fatalf("UnknownColumns failed: %v", err)
+	}
+	if len(unknown) != 0 {
+		t.Errorf("Expected no unknown columns after ALTER TABLE, got %v", unknown)
+	}
+
+	// Known columns are checked against the cached schema without querying it
+	before := mgr.Metrics().Queries[QuerySelect]
+	for i := 0; i < 3; i++ {
+		if unknown, err := mgr.UnknownColumns("items", []string{"id", "name"}); err != nil || len(unknown) != 0 {
+			t.Fatalf("Expected no unknown columns, got %v (%v)", unknown, err)
+		}
+	}
+	if after := mgr.Metrics().Queries[QuerySelect]; after != before {
+		t.Errorf("Expected the schema cache to be used, got %d schema queries", after-before)
+	}
+}
+
 // Helper functions
 func strPtr(s string) *string {
 	return &s
@@ -0,0 +1,89 @@
+package database
+
+import (
+	"context"
+	"strings"
+)
+
+// maxQueryTagValueLength bounds each value embedded in a query tag.
+const maxQueryTagValueLength = 64
+
+// QueryTag identifies the API request that issued a query.
+type QueryTag struct {
+	RequestID string
+	Role      string
+	Namespace string
+	Label     string
+}
+
+type queryTagKey struct{}
+
+// WithQueryTag returns a context carrying the query tag. When query tagging is
+// enabled, queries executed with this context are prefixed with the tag as a comment.
+func WithQueryTag(ctx context.Context, tag QueryTag) context.Context {
+	return context.WithValue(ctx, queryTagKey{}, tag)
+}
+
+// QueryTagFromContext returns the query tag stored in the context, if any.
+func QueryTagFromContext(ctx context.Context) (QueryTag, bool) {
+	tag, ok := ctx.Value(queryTagKey{}).(QueryTag)
+	return tag, ok
+}
+
+// Comment renders the tag as a SQL comment followed by a newline, e.g.
+// "/* req=3f2a... role=admin ns=app1 label=team-a */\n". Values are reduced to
+// letters, digits and ".", "_", ":", "-", so client-supplied request IDs and
+// labels cannot close the comment.
+// Returns "" if the tag is empty.
+func (t QueryTag) Comment() string {
+	req := sanitizeQueryTagValue(t.RequestID)
+	role := sanitizeQueryTagValue(t.Role)
+	ns := sanitizeQueryTagValue(t.Namespace)
+	label := sanitizeQueryTagValue(t.Label)
+	if req == "" && role == "" && ns == "" && label == "" {
+		return ""
+	}
+	parts := make([]string, 0, 4)
+	if req != "" {
+		parts = append(parts, "req="+req)
+	}
+	if role != "" {
+		parts = append(parts, "role="+role)
+	}
+	if ns != "" {
+		parts = append(parts, "ns="+ns)
+	}
+	if label != "" {
+		parts = append(parts, "label="+label)
+	}
+	return "/* " + strings.Join(parts, " ") + " */\n"
+}
+
+// sanitizeQueryTagValue strips everything but safe characters and truncates the value.
+func sanitizeQueryTagValue(value string) string {
+	var b strings.Builder
+	for _, r := range value {
+		if b.Len() >= maxQueryTagValueLength {
+			break
+		}
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9',
+			r == '.', r == '_', r == ':', r == '-':
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// QueryTagPrefix returns the comment that is prepended to queries executed with ctx,
+// or "" if query tagging is disabled or the context carries no tag.
+func (m *Manager) QueryTagPrefix(ctx context.Context) string {
+	if !m.queryTagging || ctx == nil {
+		return ""
+	}
+	tag, ok := QueryTagFromContext(ctx)
+	if !ok {
+		return ""
+	}
+	return tag.Comment()
+}
@@ -0,0 +1,79 @@
+package database
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+func TestQueryTag_Comment(t *testing.T) {
+	tests := []struct {
+		name     string
+		tag      QueryTag
+		expected string
+	}{
+		{"request and role", QueryTag{RequestID: "3f2a1b4c-0000-4000-8000-000000000001", Role: "admin"}, "/* req=3f2a1b4c-0000-4000-8000-000000000001 role=admin */\n"},
+		{"role only", QueryTag{Role: "reader"}, "/* role=reader */\n"},
+		{"namespace", QueryTag{RequestID: "req-1", Role: "reader", Namespace: "app1"}, "/* req=req-1 role=reader ns=app1 */\n"},
+		{"label", QueryTag{RequestID: "req-1", Role: "reader", Label: "team-analytics"}, "/* req=req-1 role=reader label=team-analytics */\n"},
+		{"empty", QueryTag{}, ""},
+		{"comment injection", QueryTag{RequestID: "abc */ DROP TABLE users; /*", Role: "admin"}, "/* req=abcDROPTABLEusers role=admin */\n"},
+		{"newline injection", QueryTag{RequestID: "abc\n--", Role: "ad min"}, "/* req=abc-- role=admin */\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.tag.Comment(); got != tt.expected {
+				t.Errorf("Expected %q, got %q", tt.expected, got)
+			}
+		})
+	}
+
+	long := QueryTag{RequestID: strings.Repeat("a", 200)}
+	if got := long.Comment(); len(got) != len("/* req= */\n")+maxQueryTagValueLength {
+		t.Errorf("Expected request ID truncated to %d characters, got %q", maxQueryTagValueLength, got)
+	}
+}
+
+func TestManager_QueryTagging(t *testing.T) {
+	mgr, err := NewManagerForTesting(Config{
+		MainDBPath:   ":memory:",
+		AuthDBPath:   ":memory:",
+		Threads:      1,
+		AccessMode:   "read_write",
+		QueryTimeout: 30 * time.Second,
+		QueryTagging: true,
+		Logger:       zap.NewNop(),
+	})
+	if err != nil {
+		t.Fatalf("Failed to create manager: %v", err)
+	}
+	defer mgr.Close()
+
+	ctx := WithQueryTag(context.Background(), QueryTag{RequestID: "req-1", Role: "admin"})
+
+	var query string
+	rows, err := mgr.QueryMainContext(ctx, "SELECT current_query()")
+	if err != nil {
+		t.Fatalf("Query failed: %v", err)
+	}
+	if rows.Next() {
+		rows.Scan(&query)
+	}
+	rows.Close()
+	if !strings.HasPrefix(query, "/* req=req-1 role=admin */\n") {
+		t.Errorf("Expected tagged query, got %q", query)
+	}
+
+	// Untagged contexts and disabled tagging leave queries unchanged
+	if prefix := mgr.QueryTagPrefix(context.Background()); prefix != "" {
+		t.Errorf("Expected no prefix without a tag, got %q", prefix)
+	}
+	mgr.queryTagging = false
+	if prefix := mgr.QueryTagPrefix(ctx); prefix != "" {
+		t.Errorf("Expected no prefix with tagging disabled, got %q", prefix)
+	}
+}
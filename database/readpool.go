@@ -0,0 +1,74 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"strings"
+	"time"
+
+	duckdb "github.com/duckdb/duckdb-go/v2"
+)
+
+// openMainDB opens the main database pool, the table pools, and, if
+// readPoolSize is positive, a dedicated read pool on the same database.
+//
+// DuckDB refuses to open a read-only handle on a file that is already open
+// read-write in the same process, so the read pool is not a second database
+// instance: it draws its connections from the main pool's connector. Each pool
+// then has its own connections and limits, so reads never queue for a
+// connection held by a long-running write, and DuckDB's MVCC gives every read
+// a consistent snapshot.
+func (m *Manager) openMainDB(dsn string, readPoolSize int, tablePools []TablePool) error {
+	connector, err := duckdb.NewConnector(dsn, nil)
+	if err != nil {
+		return err
+	}
+	m.mainDB = sql.OpenDB(connector)
+	m.tablePools = openTablePools(connector, tablePools)
+	m.tableRoutes = make(map[string]string)
+	for _, p := range tablePools {
+		for _, table := range p.Tables {
+			m.tableRoutes[strings.ToLower(table)] = p.Name
+		}
+	}
+	if readPoolSize <= 0 {
+		return nil
+	}
+
+	m.readDB = sql.OpenDB(sharedConnector{connector})
+	m.readDB.SetMaxOpenConns(readPoolSize)
+	m.readDB.SetMaxIdleConns(readPoolSize)
+	m.readDB.SetConnMaxLifetime(time.Hour)
+	return nil
+}
+
+// sharedConnector lends a connector to a second pool. It hides the connector's
+// Close so that closing the borrowing pool leaves the database open; the pool
+// that owns the connector closes it.
+type sharedConnector struct {
+	connector driver.Connector
+}
+
+func (c sharedConnector) Connect(ctx context.Context) (driver.Conn, error) {
+	return c.connector.Connect(ctx)
+}
+
+func (c sharedConnector) Driver() driver.Driver {
+	return c.connector.Driver()
+}
+
+// closeMain closes the read and table pools, if any, and then the main pool,
+// which closes the database.
+func (m *Manager) closeMain() error {
+	if m.queryPlans != nil {
+		m.queryPlans.Purge()
+	}
+	if m.readDB != nil {
+		m.readDB.Close()
+	}
+	for _, db := range m.tablePools {
+		db.Close()
+	}
+	return m.mainDB.Close()
+}
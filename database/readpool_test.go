@@ -0,0 +1,118 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// setupReadPoolManager creates a manager whose main pool has a single
+// connection, so a test can occupy it with an open transaction.
+func setupReadPoolManager(t *testing.T, readPoolSize int) *Manager {
+	t.Helper()
+	mgr, err := NewManagerForTesting(Config{
+		MainDBPath:   ":memory:",
+		AuthDBPath:   ":memory:",
+		Threads:      1,
+		AccessMode:   "read_write",
+		QueryTimeout: 30 * time.Second,
+		ReadPoolSize: readPoolSize,
+		Logger:       zap.NewNop(),
+	})
+	if err != nil {
+		t.Fatalf("Failed to create manager: %v", err)
+	}
+	mgr.MainDB().SetMaxOpenConns(1)
+	if _, err := mgr.ExecMain(`CREATE TABLE test_items (id INTEGER)`); err != nil {
+		t.Fatalf("Failed to create table: %v", err)
+	}
+	if _, err := mgr.ExecMain(`INSERT INTO test_items VALUES (1), (2)`); err != nil {
+		t.Fatalf("Failed to insert rows: %v", err)
+	}
+	return mgr
+}
+
+func TestReadPool_RoutesReadsAndWrites(t *testing.T) {
+	mgr := setupReadPoolManager(t, 2)
+	defer mgr.Close()
+
+	if mgr.ReadDB() == mgr.MainDB() {
+		t.Fatal("Expected a dedicated read pool")
+	}
+	if n := mgr.ReadDB().Stats().MaxOpenConnections; n != 2 {
+		t.Errorf("Expected the read pool to allow 2 connections, got %d", n)
+	}
+
+	// Occupy the only write connection with an uncommitted write
+	tx, err := mgr.BeginTxMain()
+	if err != nil {
+		t.Fatalf("Failed to begin transaction: %v", err)
+	}
+	defer tx.Rollback()
+	if _, err := tx.Exec(`INSERT INTO test_items VALUES (3)`); err != nil {
+		t.Fatalf("Failed to insert in transaction: %v", err)
+	}
+
+	// Reads are served by the read pool and see only committed data
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	var count int
+	if err := mgr.QueryRowScanMainContext(ctx, `SELECT COUNT(*) FROM test_items`, []interface{}{&count}); err != nil {
+		t.Fatalf("Expected the read to use the read pool, got %v", err)
+	}
+	if count != 2 {
+		t.Errorf("Expected 2 committed rows, got %d", count)
+	}
+	rows, err := mgr.QueryMainContext(ctx, `SELECT id FROM test_items`)
+	if err != nil {
+		t.Fatalf("Expected the query to use the read pool, got %v", err)
+	}
+	rows.Close()
+	if open := mgr.ReadDB().Stats().OpenConnections; open == 0 {
+		t.Error("Expected the read pool to have opened a connection")
+	}
+
+	// Writes wait for the write pool
+	writeCtx, writeCancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer writeCancel()
+	if _, err := mgr.ExecMainContext(writeCtx, `INSERT INTO test_items VALUES (4)`); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected the write to wait for the write pool, got %v", err)
+	}
+	if err := tx.Commit(); err != nil {
+		t.Fatalf("Failed to commit: %v", err)
+	}
+
+	// Both pools see committed writes
+	if err := mgr.QueryRowScanMain(`SELECT COUNT(*) FROM test_items`, []interface{}{&count}); err != nil {
+		t.Fatalf("Failed to count rows: %v", err)
+	}
+	if count != 3 {
+		t.Errorf("Expected 3 rows after commit, got %d", count)
+	}
+}
+
+func TestReadPool_Disabled(t *testing.T) {
+	mgr := setupReadPoolManager(t, 0)
+	defer mgr.Close()
+
+	if mgr.ReadDB() != mgr.MainDB() {
+		t.Fatal("Expected reads to use the main pool")
+	}
+
+	tx, err := mgr.BeginTxMain()
+	if err != nil {
+		t.Fatalf("Failed to begin transaction: %v", err)
+	}
+	defer tx.Rollback()
+
+	// With a single shared pool, a read waits for the write connection
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+	var count int
+	if err := mgr.QueryRowScanMainContext(ctx, `SELECT COUNT(*) FROM test_items`, []interface{}{&count}); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected the read to wait for the shared pool, got %v", err)
+	}
+}
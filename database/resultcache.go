@@ -0,0 +1,208 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/hashicorp/golang-lru/v2/expirable"
+)
+
+// resultCacheSize is the number of results kept by the query result cache.
+const resultCacheSize = 1000
+
+// ResultSet is the scanned result of a SELECT: the column names and the values
+// of each row in column order. Byte arrays are converted to strings. Result sets
+// returned by the query result cache are shared and must not be modified.
+type ResultSet struct {
+	Columns []string
+	Rows    [][]interface{}
+}
+
+// Maps returns the rows as new column-name-keyed maps, which the caller may modify.
+func (rs *ResultSet) Maps() []map[string]interface{} {
+	data := make([]map[string]interface{}, len(rs.Rows))
+	for i, row := range rs.Rows {
+		rowMap := make(map[string]interface{}, len(rs.Columns))
+		for j, col := range rs.Columns {
+			rowMap[col] = row[j]
+		}
+		data[i] = rowMap
+	}
+	return data
+}
+
+// cachedResult is an entry of the query result cache: a result set or a count.
+type cachedResult struct {
+	set   *ResultSet
+	count int64
+}
+
+// resultCache caches the results of idempotent table reads for a TTL, keyed by
+// the table, the SQL, and its parameters. Each table has a generation that is
+// part of its keys: invalidating a table bumps it and removes the table's
+// entries, so a read that started before a write cannot store its stale result
+// under a key later reads would find.
+type resultCache struct {
+	entries *expirable.LRU[string, cachedResult]
+
+	mu          sync.Mutex
+	epoch       uint64            // bumped by purge
+	generations map[string]uint64 // lower-cased table name -> generation
+}
+
+// newResultCache returns a cache whose entries expire after ttl, or nil for ttl <= 0.
+func newResultCache(ttl time.Duration) *resultCache {
+	if ttl <= 0 {
+		return nil
+	}
+	return &resultCache{
+		entries:     expirable.NewLRU[string, cachedResult](resultCacheSize, nil, ttl),
+		generations: make(map[string]uint64),
+	}
+}
+
+// key returns the cache key of a statement reading table, a name normalized
+// with cacheTable.
+func (c *resultCache) key(kind, table string, stmt Statement) string {
+	c.mu.Lock()
+	epoch, generation := c.epoch, c.generations[table]
+	c.mu.Unlock()
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s\x00%d\x00%d\x00%s\x00%s", table, epoch, generation, kind, normalizeQuery(stmt.SQL))
+	for _, param := range stmt.Params {
+		// The type is part of the key: 1 and "1" may match different rows
+		fmt.Fprintf(&b, "\x00%T:%v", param, param)
+	}
+	return b.String()
+}
+
+// invalidate drops the cached results of table, a name normalized with cacheTable.
+func (c *resultCache) invalidate(table string) {
+	c.mu.Lock()
+	c.generations[table]++
+	c.mu.Unlock()
+
+	prefix := table + "\x00"
+	for _, key := range c.entries.Keys() {
+		if strings.HasPrefix(key, prefix) {
+			c.entries.Remove(key)
+		}
+	}
+}
+
+// purge drops all cached results.
+func (c *resultCache) purge() {
+	c.mu.Lock()
+	c.epoch++
+	c.mu.Unlock()
+	c.entries.Purge()
+}
+
+// ResultCacheEnabled reports whether reads are served from the query result
+// cache (see Config.QueryCacheTTL).
+func (m *Manager) ResultCacheEnabled() bool {
+	return m.results != nil
+}
+
+// cacheTable returns the name the result cache knows table by: lower-cased
+// and without the main catalog, so "Items" and "memory.items" share the
+// entries and invalidations of "items".
+func (m *Manager) cacheTable(table string) string {
+	if catalog, name := SplitTableName(table); catalog != "" && strings.EqualFold(catalog, m.MainCatalog()) {
+		table = name
+	}
+	return strings.ToLower(table)
+}
+
+// invalidateResults drops the cached results of table, if the cache is enabled.
+func (m *Manager) invalidateResults(table string) {
+	if m.results != nil {
+		m.results.invalidate(m.cacheTable(table))
+	}
+}
+
+// purgeResults drops all cached results, if the cache is enabled. Raw statements
+// may write any table, so they purge the whole cache.
+func (m *Manager) purgeResults() {
+	if m.results != nil {
+		m.results.purge()
+	}
+}
+
+// SelectResultContext is like SelectColumnsContext but returns the scanned
+// result. With the query result cache enabled, results are served from and
+// stored in the cache, except for sampled reads, which are random.
+func (m *Manager) SelectResultContext(ctx context.Context, table string, derived []DerivedColumn, columns []string, filters []Filter, window *Window, sample *Sample, sorts []Sort, limit, offset int) (*ResultSet, error) {
+	stmt, err := SelectColumnsStatement(table, derived, columns, filters, window, sample, sorts, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+
+	var key string
+	if m.results != nil && sample == nil {
+		key = m.results.key("select", m.cacheTable(table), stmt)
+		if entry, found := m.results.entries.Get(key); found {
+			return entry.set, nil
+		}
+	}
+
+	rows, err := m.QueryMainContext(ctx, stmt.SQL, stmt.Params...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	set := &ResultSet{Rows: make([][]interface{}, 0)}
+	if set.Columns, err = rows.Columns(); err != nil {
+		return nil, fmt.Errorf("failed to get columns: %w", err)
+	}
+	for rows.Next() {
+		values := make([]interface{}, len(set.Columns))
+		valuePtrs := make([]interface{}, len(set.Columns))
+		for i := range values {
+			valuePtrs[i] = &values[i]
+		}
+		if err := rows.Scan(valuePtrs...); err != nil {
+			return nil, fmt.Errorf("failed to scan row: %w", err)
+		}
+		for i, val := range values {
+			if b, ok := val.([]byte); ok {
+				values[i] = string(b)
+			}
+		}
+		set.Rows = append(set.Rows, values)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating rows: %w", err)
+	}
+
+	if key != "" {
+		m.results.entries.Add(key, cachedResult{set: set})
+	}
+	return set, nil
+}
+
+// cachedCount returns the count of a CountStatement from the query result
+// cache, running and caching it on a miss. Sampled counts are never cached.
+func (m *Manager) cachedCount(ctx context.Context, table string, sample *Sample, stmt Statement) (int64, error) {
+	var key string
+	if m.results != nil && sample == nil {
+		key = m.results.key("count", m.cacheTable(table), stmt)
+		if entry, found := m.results.entries.Get(key); found {
+			return entry.count, nil
+		}
+	}
+
+	var count int64
+	if err := m.QueryRowScanMainContext(ctx, stmt.SQL, []interface{}{&count}, stmt.Params...); err != nil {
+		return 0, err
+	}
+	if key != "" {
+		m.results.entries.Add(key, cachedResult{count: count})
+	}
+	return count, nil
+}
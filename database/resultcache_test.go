@@ -0,0 +1,201 @@
+package database
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+func setupResultCacheManager(t *testing.T, ttl time.Duration) *Manager {
+	t.Helper()
+	mgr, err := NewManagerForTesting(Config{
+		MainDBPath:    ":memory:",
+		AuthDBPath:    ":memory:",
+		Threads:       1,
+		AccessMode:    "read_write",
+		QueryTimeout:  30 * time.Second,
+		QueryCacheTTL: ttl,
+		Logger:        zap.NewNop(),
+	})
+	if err != nil {
+		t.Fatalf("Failed to create manager: %v", err)
+	}
+	if _, err := mgr.ExecMain("CREATE TABLE items (id INTEGER PRIMARY KEY, name VARCHAR)"); err != nil {
+		t.Fatalf("Failed to create table: %v", err)
+	}
+	if _, err := mgr.Insert("items", map[string]interface{}{"id": 1, "name": "one"}); err != nil {
+		t.Fatalf("Failed to insert: %v", err)
+	}
+	return mgr
+}
+
+// selectNames reads the names of items through the result cache.
+func selectNames(t *testing.T, mgr *Manager) []interface{} {
+	t.Helper()
+	set, err := mgr.SelectResultContext(context.Background(), "items", nil, []string{"name"}, nil, nil, nil, []Sort{{Column: "id", Direction: "asc"}}, 100, 0)
+	if err != nil {
+		t.Fatalf("SelectResultContext failed: %v", err)
+	}
+	names := make([]interface{}, len(set.Rows))
+	for i, row := range set.Rows {
+		names[i] = row[0]
+	}
+	return names
+}
+
+// renameBehindCache changes a row without going through the manager's write
+// methods, so the result cache is not invalidated.
+func renameBehindCache(t *testing.T, mgr *Manager, name string) {
+	t.Helper()
+	if _, err := mgr.MainDB().Exec("UPDATE items SET name = $1 WHERE id = 1", name); err != nil {
+		t.Fatalf("Failed to update: %v", err)
+	}
+}
+
+func TestResultCache_Hit(t *testing.T) {
+	mgr := setupResultCacheManager(t, time.Minute)
+	defer mgr.Close()
+
+	if names := selectNames(t, mgr); len(names) != 1 || names[0] != "one" {
+		t.Fatalf("Expected [one], got %v", names)
+	}
+	count, err := mgr.CountContext(context.Background(), "items", nil, nil, nil, nil)
+	if err != nil || count != 1 {
+		t.Fatalf("Expected count 1, got %d (%v)", count, err)
+	}
+	before := mgr.Metrics().Queries[QuerySelect]
+
+	renameBehindCache(t, mgr, "uno")
+	if names := selectNames(t, mgr); names[0] != "one" {
+		t.Errorf("Expected the cached name 'one', got %v", names[0])
+	}
+	if count, _ := mgr.CountContext(context.Background(), "items", nil, nil, nil, nil); count != 1 {
+		t.Errorf("Expected the cached count 1, got %d", count)
+	}
+	if after := mgr.Metrics().Queries[QuerySelect]; after != before {
+		t.Errorf("Expected cache hits to run no queries, ran %d", after-before)
+	}
+
+	// Different parameters are a different entry
+	set, err := mgr.SelectResultContext(context.Background(), "items", nil, []string{"name"}, []Filter{{Column: "id", Operator: "eq", Value: 1}}, nil, nil, nil, 100, 0)
+	if err != nil {
+		t.Fatalf("SelectResultContext failed: %v", err)
+	}
+	if set.Rows[0][0] != "uno" {
+		t.Errorf("Expected 'uno' for an uncached read, got %v", set.Rows[0][0])
+	}
+}
+
+func TestResultCache_Expiry(t *testing.T) {
+	mgr := setupResultCacheManager(t, 50*time.Millisecond)
+	defer mgr.Close()
+
+	selectNames(t, mgr)
+	renameBehindCache(t, mgr, "uno")
+	time.Sleep(100 * time.Millisecond)
+
+	if names := selectNames(t, mgr); names[0] != "uno" {
+		t.Errorf("Expected 'uno' after the TTL, got %v", names[0])
+	}
+}
+
+func TestResultCache_InvalidatedByWrite(t *testing.T) {
+	mgr := setupResultCacheManager(t, time.Minute)
+	defer mgr.Close()
+	ctx := context.Background()
+
+	selectNames(t, mgr)
+	mgr.CountContext(ctx, "items", nil, nil, nil, nil)
+
+	if _, err := mgr.Insert("items", map[string]interface{}{"id": 2, "name": "two"}); err != nil {
+		t.Fatalf("Failed to insert: %v", err)
+	}
+	if names := selectNames(t, mgr); len(names) != 2 {
+		t.Errorf("Expected 2 rows after an insert, got %v", names)
+	}
+	if count, _ := mgr.CountContext(ctx, "items", nil, nil, nil, nil); count != 2 {
+		t.Errorf("Expected count 2 after an insert, got %d", count)
+	}
+
+	if _, err := mgr.Update("items", map[string]interface{}{"name": "uno"}, map[string]interface{}{"id": 1}); err != nil {
+		t.Fatalf("Failed to update: %v", err)
+	}
+	if names := selectNames(t, mgr); names[0] != "uno" {
+		t.Errorf("Expected 'uno' after an update, got %v", names[0])
+	}
+
+	if _, err := mgr.Delete("items", map[string]interface{}{"id": 2}); err != nil {
+		t.Fatalf("Failed to delete: %v", err)
+	}
+	if names := selectNames(t, mgr); len(names) != 1 {
+		t.Errorf("Expected 1 row after a delete, got %v", names)
+	}
+
+	// Raw statements purge the whole cache
+	if _, err := mgr.ExecPreparedContext(ctx, "UPDATE items SET name = $1", "eins"); err != nil {
+		t.Fatalf("Failed to update: %v", err)
+	}
+	if names := selectNames(t, mgr); names[0] != "eins" {
+		t.Errorf("Expected 'eins' after a raw update, got %v", names[0])
+	}
+}
+
+func TestResultCache_QualifiedNames(t *testing.T) {
+	mgr := setupResultCacheManager(t, time.Minute)
+	defer mgr.Close()
+	ctx := context.Background()
+
+	// Writes through another spelling of the table invalidate its reads
+	selectNames(t, mgr)
+	for i, table := range []string{"memory.items", "items"} {
+		if _, err := mgr.Insert(table, map[string]interface{}{"id": i + 2, "name": "more"}); err != nil {
+			t.Fatalf("Failed to insert into %s: %v", table, err)
+		}
+		if names := selectNames(t, mgr); len(names) != i+2 {
+			t.Errorf("Expected %d rows after an insert into %s, got %v", i+2, table, names)
+		}
+	}
+
+	// Qualified reads are invalidated by writes to the bare name
+	for _, table := range []string{"memory.items", "MEMORY.Items"} {
+		if count, err := mgr.CountContext(ctx, table, nil, nil, nil, nil); err != nil || count != 3 {
+			t.Fatalf("Expected count 3 for %s, got %d (%v)", table, count, err)
+		}
+	}
+	if _, err := mgr.Delete("items", map[string]interface{}{"id": 3}); err != nil {
+		t.Fatalf("Failed to delete: %v", err)
+	}
+	for _, table := range []string{"memory.items", "MEMORY.Items"} {
+		if count, _ := mgr.CountContext(ctx, table, nil, nil, nil, nil); count != 2 {
+			t.Errorf("Expected count 2 for %s after a delete, got %d", table, count)
+		}
+	}
+}
+
+func TestResultCache_Disabled(t *testing.T) {
+	mgr := setupResultCacheManager(t, 0)
+	defer mgr.Close()
+
+	if mgr.ResultCacheEnabled() {
+		t.Fatal("Expected the result cache to be disabled")
+	}
+	selectNames(t, mgr)
+	renameBehindCache(t, mgr, "uno")
+	if names := selectNames(t, mgr); names[0] != "uno" {
+		t.Errorf("Expected 'uno' without a cache, got %v", names[0])
+	}
+}
+
+func TestResultSet_Maps(t *testing.T) {
+	set := &ResultSet{Columns: []string{"id", "name"}, Rows: [][]interface{}{{1, "one"}}}
+	data := set.Maps()
+	if len(data) != 1 || data[0]["id"] != 1 || data[0]["name"] != "one" {
+		t.Fatalf("Unexpected maps: %v", data)
+	}
+	data[0]["name"] = "changed"
+	if set.Maps()[0]["name"] != "one" {
+		t.Error("Expected modifying the maps to leave the result set unchanged")
+	}
+}
@@ -0,0 +1,100 @@
+package database
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// sampleMethods are the DuckDB sampling methods allowed in reads.
+var sampleMethods = map[string]bool{
+	"reservoir": true,
+	"bernoulli": true,
+	"system":    true,
+}
+
+// IsSampleMethod reports whether method is an allowed sampling method.
+func IsSampleMethod(method string) bool {
+	return sampleMethods[strings.ToLower(method)]
+}
+
+// Sample restricts a read to a random sample of the table, taken before
+// filters are applied, e.g. a reproducible 10% sample:
+//
+//	USING SAMPLE 10% (reservoir, 42)
+//
+// Exactly one of Percent and Rows is set. Without a method DuckDB picks one
+// (system for percentages, reservoir for row counts); a seed without a method
+// uses reservoir sampling, which returns the same rows for the same seed and data.
+type Sample struct {
+	Percent float64
+	Rows    int
+	Method  string
+	Seed    *int64
+}
+
+// Validate checks the size, method, and seed combination.
+func (s *Sample) Validate() error {
+	switch {
+	case s.Percent != 0 && s.Rows != 0:
+		return fmt.Errorf("sample must be either a percentage or a row count")
+	case s.Rows < 0:
+		return fmt.Errorf("sample row count must be positive")
+	case s.Rows == 0 && (s.Percent <= 0 || s.Percent > 100):
+		return fmt.Errorf("sample percentage must be greater than 0 and at most 100")
+	}
+	if s.Method != "" && !IsSampleMethod(s.Method) {
+		return fmt.Errorf("unsupported sample method: %s (must be reservoir, bernoulli, or system)", s.Method)
+	}
+	if s.Rows > 0 && s.Method != "" && !strings.EqualFold(s.Method, "reservoir") {
+		return fmt.Errorf("sampling a row count requires the reservoir method")
+	}
+	if s.Seed != nil && *s.Seed < 0 {
+		return fmt.Errorf("sample seed must be >= 0")
+	}
+	return nil
+}
+
+// ToSQL converts the sample to a USING SAMPLE clause (with the keywords).
+func (s *Sample) ToSQL() (string, error) {
+	if err := s.Validate(); err != nil {
+		return "", err
+	}
+	size := strconv.FormatFloat(s.Percent, 'f', -1, 64) + "%"
+	if s.Rows > 0 {
+		size = fmt.Sprintf("%d ROWS", s.Rows)
+	}
+	method := strings.ToLower(s.Method)
+	if method == "" && s.Seed != nil {
+		method = "reservoir"
+	}
+	switch {
+	case s.Seed != nil:
+		return fmt.Sprintf("USING SAMPLE %s (%s, %d)", size, method, *s.Seed), nil
+	case method != "":
+		return fmt.Sprintf("USING SAMPLE %s (%s)", size, method), nil
+	default:
+		return "USING SAMPLE " + size, nil
+	}
+}
+
+// readSource returns the FROM target for reads like selectSource, sampling the
+// table first if sample is non-nil. The sample is taken in a subquery aliased to
+// the (unqualified) table name, so filters, sorts, and derived columns apply to
+// the sampled rows.
+func readSource(table string, derived []DerivedColumn, sample *Sample) (string, error) {
+	if sample == nil {
+		return selectSource(table, derived), nil
+	}
+	clause, err := sample.ToSQL()
+	if err != nil {
+		return "", err
+	}
+	projections := make([]string, 0, len(derived)+1)
+	projections = append(projections, "*")
+	for _, d := range derived {
+		projections = append(projections, fmt.Sprintf("(%s) AS %s", d.Expression, d.Name))
+	}
+	_, alias := SplitTableName(table)
+	return fmt.Sprintf("(SELECT %s FROM %s %s) AS %s", strings.Join(projections, ", "), table, clause, alias), nil
+}
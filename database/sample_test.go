@@ -0,0 +1,84 @@
+package database
+
+import (
+	"context"
+	"testing"
+)
+
+func TestSampleToSQL(t *testing.T) {
+	seed := int64(42)
+	tests := []struct {
+		sample Sample
+		want   string
+	}{
+		{Sample{Percent: 10}, "USING SAMPLE 10%"},
+		{Sample{Percent: 2.5, Method: "bernoulli"}, "USING SAMPLE 2.5% (bernoulli)"},
+		{Sample{Percent: 10, Seed: &seed}, "USING SAMPLE 10% (reservoir, 42)"},
+		{Sample{Percent: 10, Method: "System", Seed: &seed}, "USING SAMPLE 10% (system, 42)"},
+		{Sample{Rows: 50, Seed: &seed}, "USING SAMPLE 50 ROWS (reservoir, 42)"},
+	}
+	for _, tt := range tests {
+		got, err := tt.sample.ToSQL()
+		if err != nil {
+			t.Errorf("ToSQL(%+v) failed: %v", tt.sample, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("ToSQL(%+v) = %q, want %q", tt.sample, got, tt.want)
+		}
+	}
+
+	negative := int64(-1)
+	invalid := []Sample{
+		{},
+		{Percent: 150},
+		{Percent: 10, Rows: 5},
+		{Rows: -5},
+		{Percent: 10, Method: "random"},
+		{Rows: 5, Method: "bernoulli"},
+		{Percent: 10, Seed: &negative},
+	}
+	for _, s := range invalid {
+		if _, err := s.ToSQL(); err == nil {
+			t.Errorf("Expected error for %+v", s)
+		}
+	}
+}
+
+func TestSelectContext_Sample(t *testing.T) {
+	mgr := setupTestManager(t)
+	defer mgr.Close()
+	if _, err := mgr.ExecMain("INSERT INTO test_users SELECT i, 'user' || i, NULL, i % 100 FROM range(1, 1001) t(i)"); err != nil {
+		t.Fatalf("Failed to insert test data: %v", err)
+	}
+
+	ids := func(seed int64) string {
+		t.Helper()
+		sample := &Sample{Percent: 5, Seed: &seed}
+		stmt, err := SelectStatement("test_users", nil, nil, nil, sample, []Sort{{Column: "id", Direction: "asc"}}, 0, 0)
+		if err != nil {
+			t.Fatalf("SelectStatement failed: %v", err)
+		}
+		var result string
+		if err := mgr.QueryRowScanMain("SELECT string_agg(id::VARCHAR, ',') FROM ("+stmt.SQL+")", []interface{}{&result}); err != nil {
+			t.Fatalf("Failed to read sample: %v", err)
+		}
+		return result
+	}
+	if a, b := ids(42), ids(42); a != b {
+		t.Errorf("Expected the same seed to return the same rows, got %s and %s", a, b)
+	}
+	if a, b := ids(42), ids(7); a == b {
+		t.Errorf("Expected different seeds to return different rows, got %s for both", a)
+	}
+
+	// Filters apply to the sampled rows
+	seed := int64(42)
+	count, err := mgr.CountContext(context.Background(), "test_users", nil, []Filter{{Column: "id", Operator: "gt", Value: 0}}, nil, &Sample{Rows: 20, Seed: &seed})
+	if err != nil {
+		t.Fatalf("CountContext failed: %v", err)
+	}
+	if count != 20 {
+		t.Errorf("Expected 20 sampled rows, got %d", count)
+	}
+}
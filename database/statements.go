@@ -0,0 +1,108 @@
+package database
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Statement is a generated SQL statement together with its bound parameter values.
+type Statement struct {
+	SQL    string        `json:"sql"`
+	Params []interface{} `json:"params"`
+}
+
+// SelectStatement builds the paginated SELECT run by SelectContext.
+func SelectStatement(table string, derived []DerivedColumn, filters []Filter, window *Window, sample *Sample, sorts []Sort, limit, offset int) (Statement, error) {
+	return SelectColumnsStatement(table, derived, nil, filters, window, sample, sorts, limit, offset)
+}
+
+// SelectColumnsStatement is like SelectStatement but selects only the given
+// columns, in order. Column names must be validated by the caller; no columns
+// selects all of them. Columns may also be projections built by WKTProjection.
+func SelectColumnsStatement(table string, derived []DerivedColumn, columns []string, filters []Filter, window *Window, sample *Sample, sorts []Sort, limit, offset int) (Statement, error) {
+	clauses, values, err := buildReadClauses(filters, window)
+	if err != nil {
+		return Statement{}, err
+	}
+	source, err := readSource(table, derived, sample)
+	if err != nil {
+		return Statement{}, err
+	}
+	projection := "*"
+	if len(columns) > 0 {
+		projection = strings.Join(columns, ", ")
+	}
+	query := fmt.Sprintf("SELECT %s FROM %s%s", projection, source, clauses)
+
+	// Add ORDER BY clause if sorts exist
+	if len(sorts) > 0 {
+		sortClauses := make([]string, 0, len(sorts))
+		for _, s := range sorts {
+			sortClauses = append(sortClauses, s.ToSQL())
+		}
+		query += " ORDER BY " + strings.Join(sortClauses, ", ")
+	}
+
+	// Add LIMIT and OFFSET
+	if limit > 0 {
+		query += fmt.Sprintf(" LIMIT %d", limit)
+	}
+	if offset > 0 {
+		query += fmt.Sprintf(" OFFSET %d", offset)
+	}
+
+	return Statement{SQL: query, Params: values}, nil
+}
+
+// CountStatement builds the SELECT COUNT(*) run by CountContext.
+func CountStatement(table string, derived []DerivedColumn, filters []Filter, window *Window, sample *Sample) (Statement, error) {
+	clauses, values, err := buildReadClauses(filters, window)
+	if err != nil {
+		return Statement{}, err
+	}
+	source, err := readSource(table, derived, sample)
+	if err != nil {
+		return Statement{}, err
+	}
+	query := fmt.Sprintf("SELECT COUNT(*) FROM %s%s", source, clauses)
+	if window != nil {
+		// QUALIFY is evaluated after aggregation, so count the qualified rows in a subquery
+		query = fmt.Sprintf("SELECT COUNT(*) FROM (SELECT * FROM %s%s)", source, clauses)
+	}
+	return Statement{SQL: query, Params: values}, nil
+}
+
+// UpdateStatement builds the UPDATE run by UpdateWithFilters. SET columns are
+// sorted so the same request always produces the same SQL.
+func UpdateStatement(table string, set map[string]interface{}, filters []Filter) Statement {
+	setCols := make([]string, 0, len(set))
+	for col := range set {
+		setCols = append(setCols, col)
+	}
+	sort.Strings(setCols)
+
+	values := make([]interface{}, 0, len(set)+len(filters))
+	setClauses := make([]string, len(setCols))
+	for i, col := range setCols {
+		setClauses[i] = fmt.Sprintf("%s = $%d", col, i+1)
+		values = append(values, set[col])
+	}
+
+	whereClause, whereValues := buildWhereClause(filters, len(setCols)+1)
+	query := fmt.Sprintf("UPDATE %s SET %s%s", table, strings.Join(setClauses, ", "), whereClause)
+	return Statement{SQL: query, Params: append(values, whereValues...)}
+}
+
+// DeleteStatement builds the DELETE run by DeleteWithFilters.
+func DeleteStatement(table string, filters []Filter) Statement {
+	whereClause, values := buildWhereClause(filters, 1)
+	return Statement{SQL: fmt.Sprintf("DELETE FROM %s%s", table, whereClause), Params: values}
+}
+
+// SoftDeleteStatement builds the UPDATE run by SoftDeleteWithFilters. Rows that
+// are already soft-deleted are excluded.
+func SoftDeleteStatement(table, column string, filters []Filter) Statement {
+	whereClause, values := buildWhereClause(append([]Filter{{Column: column, Operator: "is_null"}}, filters...), 1)
+	return Statement{SQL: fmt.Sprintf("UPDATE %s SET %s = CURRENT_TIMESTAMP%s", table, column, whereClause), Params: values}
+}
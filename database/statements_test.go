@@ -0,0 +1,126 @@
+package database
+
+import "testing"
+
+func TestUpdateStatement(t *testing.T) {
+	stmt := UpdateStatement("users", map[string]interface{}{"name": "Bob", "age": 31}, []Filter{
+		{Column: "deleted_at", Operator: "is_null"},
+		{Column: "id", Operator: "eq", Value: 2},
+	})
+	if stmt.SQL != "UPDATE users SET age = $1, name = $2 WHERE deleted_at IS NULL AND id = $3" {
+		t.Errorf("Unexpected SQL: %s", stmt.SQL)
+	}
+	if len(stmt.Params) != 3 || stmt.Params[0] != 31 || stmt.Params[1] != "Bob" || stmt.Params[2] != 2 {
+		t.Errorf("Unexpected params: %v", stmt.Params)
+	}
+}
+
+func TestNullFilterStatements(t *testing.T) {
+	filters := []Filter{
+		{Column: "age", Operator: "gt", Value: 18},
+		{Column: "email", Operator: "is_not_null", Value: "ignored"},
+		{Column: "name", Operator: "not_in", Value: []string{"Bob"}},
+	}
+
+	stmt := UpdateStatement("users", map[string]interface{}{"status": "active"}, filters)
+	if stmt.SQL != "UPDATE users SET status = $1 WHERE age > $2 AND email IS NOT NULL AND name NOT IN $3" {
+		t.Errorf("Unexpected update SQL: %s", stmt.SQL)
+	}
+	if len(stmt.Params) != 3 {
+		t.Errorf("Expected 3 params, got %v", stmt.Params)
+	}
+
+	stmt = DeleteStatement("users", filters)
+	if stmt.SQL != "DELETE FROM users WHERE age > $1 AND email IS NOT NULL AND name NOT IN $2" {
+		t.Errorf("Unexpected delete SQL: %s", stmt.SQL)
+	}
+	if len(stmt.Params) != 2 {
+		t.Errorf("Expected 2 params, got %v", stmt.Params)
+	}
+}
+
+func TestDeleteStatements(t *testing.T) {
+	filters := []Filter{{Column: "id", Operator: "eq", Value: 2}}
+
+	if stmt := DeleteStatement("users", filters); stmt.SQL != "DELETE FROM users WHERE id = $1" {
+		t.Errorf("Unexpected delete SQL: %s", stmt.SQL)
+	}
+	stmt := SoftDeleteStatement("users", "deleted_at", filters)
+	if stmt.SQL != "UPDATE users SET deleted_at = CURRENT_TIMESTAMP WHERE deleted_at IS NULL AND id = $1" {
+		t.Errorf("Unexpected soft delete SQL: %s", stmt.SQL)
+	}
+	if len(stmt.Params) != 1 || stmt.Params[0] != 2 {
+		t.Errorf("Unexpected soft delete params: %v", stmt.Params)
+	}
+}
+
+func TestSelectStatement(t *testing.T) {
+	stmt, err := SelectStatement("users", nil, []Filter{{Column: "age", Operator: "gte", Value: 30}}, nil, nil, []Sort{{Column: "name", Direction: "desc"}}, 10, 20)
+	if err != nil {
+		t.Fatalf("SelectStatement failed: %v", err)
+	}
+	if stmt.SQL != "SELECT * FROM users WHERE age >= $1 ORDER BY name DESC LIMIT 10 OFFSET 20" {
+		t.Errorf("Unexpected SQL: %s", stmt.SQL)
+	}
+	if len(stmt.Params) != 1 || stmt.Params[0] != 30 {
+		t.Errorf("Unexpected params: %v", stmt.Params)
+	}
+}
+
+func TestSelectColumnsStatement(t *testing.T) {
+	stmt, err := SelectColumnsStatement("users", nil, []string{"id", "name"}, nil, nil, nil, []Sort{{Column: "age", Direction: "asc"}}, 10, 0)
+	if err != nil {
+		t.Fatalf("SelectColumnsStatement failed: %v", err)
+	}
+	if stmt.SQL != "SELECT id, name FROM users ORDER BY age ASC LIMIT 10" {
+		t.Errorf("Unexpected SQL: %s", stmt.SQL)
+	}
+}
+
+func TestBetweenStatements(t *testing.T) {
+	filters := []Filter{
+		{Column: "age", Operator: "between", Value: []string{"18", "65"}},
+		{Column: "name", Operator: "ne", Value: "Bob"},
+	}
+	checkParams := func(name string, params []interface{}, expected ...interface{}) {
+		t.Helper()
+		if len(params) != len(expected) {
+			t.Fatalf("%s: expected params %v, got %v", name, expected, params)
+		}
+		for i := range expected {
+			if params[i] != expected[i] {
+				t.Errorf("%s: expected params %v, got %v", name, expected, params)
+			}
+		}
+	}
+
+	stmt, err := SelectStatement("users", nil, filters, nil, nil, nil, 10, 0)
+	if err != nil {
+		t.Fatalf("SelectStatement failed: %v", err)
+	}
+	if stmt.SQL != "SELECT * FROM users WHERE age BETWEEN $1 AND $2 AND name != $3 LIMIT 10" {
+		t.Errorf("Unexpected select SQL: %s", stmt.SQL)
+	}
+	checkParams("select", stmt.Params, "18", "65", "Bob")
+
+	stmt, err = CountStatement("users", nil, filters, nil, nil)
+	if err != nil {
+		t.Fatalf("CountStatement failed: %v", err)
+	}
+	if stmt.SQL != "SELECT COUNT(*) FROM users WHERE age BETWEEN $1 AND $2 AND name != $3" {
+		t.Errorf("Unexpected count SQL: %s", stmt.SQL)
+	}
+	checkParams("count", stmt.Params, "18", "65", "Bob")
+
+	stmt = UpdateStatement("users", map[string]interface{}{"status": "active"}, filters)
+	if stmt.SQL != "UPDATE users SET status = $1 WHERE age BETWEEN $2 AND $3 AND name != $4" {
+		t.Errorf("Unexpected update SQL: %s", stmt.SQL)
+	}
+	checkParams("update", stmt.Params, "active", "18", "65", "Bob")
+
+	stmt = DeleteStatement("users", filters)
+	if stmt.SQL != "DELETE FROM users WHERE age BETWEEN $1 AND $2 AND name != $3" {
+		t.Errorf("Unexpected delete SQL: %s", stmt.SQL)
+	}
+	checkParams("delete", stmt.Params, "18", "65", "Bob")
+}
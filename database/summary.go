@@ -0,0 +1,116 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// summaryFunctions maps the aggregate functions allowed in read summaries to
+// their SQL names. Each takes a single column.
+var summaryFunctions = map[string]string{
+	"sum":   "SUM",
+	"avg":   "AVG",
+	"min":   "MIN",
+	"max":   "MAX",
+	"count": "COUNT",
+}
+
+// listFunctions maps the aggregate functions that collect a column's values
+// into a list to their SQL names. They are allowed per group, but not in read
+// summaries, which would list the column of every matching row.
+var listFunctions = map[string]string{
+	"array_agg": "ARRAY_AGG",
+	"list":      "LIST",
+}
+
+// IsSummaryFunction reports whether name is an allowed summary aggregate.
+func IsSummaryFunction(name string) bool {
+	_, ok := summaryFunctions[strings.ToLower(name)]
+	return ok
+}
+
+// IsGroupingFunction reports whether name is an allowed aggregate of grouped
+// reads and time series: a summary aggregate or a list aggregate.
+func IsGroupingFunction(name string) bool {
+	_, ok := listFunctions[strings.ToLower(name)]
+	return ok || IsSummaryFunction(name)
+}
+
+// Aggregate is a single aggregate of a read summary, e.g. SUM(amount).
+type Aggregate struct {
+	Function string
+	Column   string
+}
+
+// ToSQL converts the aggregate to a SQL expression. List aggregates order
+// their values by the column so results are deterministic.
+// The column name must be validated by the caller; "*" is only allowed for count.
+func (a Aggregate) ToSQL() (string, error) {
+	if fn, ok := listFunctions[strings.ToLower(a.Function)]; ok {
+		if a.Column == "*" {
+			return "", fmt.Errorf("%s requires a column", a.Function)
+		}
+		return fmt.Sprintf("%s(%s ORDER BY %s)", fn, a.Column, a.Column), nil
+	}
+	fn, ok := summaryFunctions[strings.ToLower(a.Function)]
+	if !ok {
+		return "", fmt.Errorf("unsupported summary function: %s", a.Function)
+	}
+	if a.Column == "*" && fn != "COUNT" {
+		return "", fmt.Errorf("%s requires a column", a.Function)
+	}
+	return fmt.Sprintf("%s(%s)", fn, a.Column), nil
+}
+
+// SummaryContext computes the aggregates over every row matching the filters and
+// window (of the sample, if non-nil), independent of pagination. The result maps each column to its
+// aggregates by function name, e.g. {"amount": {"sum": 1200, "avg": 40}}.
+func (m *Manager) SummaryContext(ctx context.Context, table string, derived []DerivedColumn, filters []Filter, window *Window, sample *Sample, aggregates []Aggregate) (map[string]map[string]interface{}, error) {
+	if len(aggregates) == 0 {
+		return nil, nil
+	}
+	clauses, values, err := buildReadClauses(filters, window)
+	if err != nil {
+		return nil, err
+	}
+
+	projections := make([]string, len(aggregates))
+	for i, a := range aggregates {
+		if projections[i], err = a.ToSQL(); err != nil {
+			return nil, err
+		}
+	}
+	source, err := readSource(table, derived, sample)
+	if err != nil {
+		return nil, err
+	}
+	if window != nil {
+		// QUALIFY is evaluated after aggregation, so aggregate the qualified rows in a subquery
+		source = fmt.Sprintf("(SELECT * FROM %s%s)", source, clauses)
+		clauses = ""
+	}
+	query := fmt.Sprintf("SELECT %s FROM %s%s", strings.Join(projections, ", "), source, clauses)
+
+	results := make([]interface{}, len(aggregates))
+	dest := make([]interface{}, len(aggregates))
+	for i := range results {
+		dest[i] = &results[i]
+	}
+	if err := m.QueryRowScanMainContext(ctx, query, dest, values...); err != nil {
+		return nil, err
+	}
+
+	summary := make(map[string]map[string]interface{})
+	for i, a := range aggregates {
+		if summary[a.Column] == nil {
+			summary[a.Column] = make(map[string]interface{})
+		}
+		value := results[i]
+		if b, ok := value.([]byte); ok {
+			value = string(b)
+		}
+		summary[a.Column][strings.ToLower(a.Function)] = value
+	}
+	return summary, nil
+}
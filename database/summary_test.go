@@ -0,0 +1,75 @@
+package database
+
+import (
+	"context"
+	"testing"
+)
+
+func TestAggregateToSQL(t *testing.T) {
+	expr, err := Aggregate{Function: "AVG", Column: "price"}.ToSQL()
+	if err != nil {
+		t.Fatalf("ToSQL failed: %v", err)
+	}
+	if expr != "AVG(price)" {
+		t.Errorf("Expected AVG(price), got %q", expr)
+	}
+	if _, err := (Aggregate{Function: "median", Column: "price"}).ToSQL(); err == nil {
+		t.Error("Expected error for unsupported summary function")
+	}
+
+	// List aggregates order their values
+	if expr, err := (Aggregate{Function: "array_agg", Column: "id"}).ToSQL(); err != nil || expr != "ARRAY_AGG(id ORDER BY id)" {
+		t.Errorf("Expected ARRAY_AGG(id ORDER BY id), got %q (%v)", expr, err)
+	}
+	if _, err := (Aggregate{Function: "list", Column: "*"}).ToSQL(); err == nil {
+		t.Error("Expected error for list:*")
+	}
+	if IsSummaryFunction("array_agg") || !IsGroupingFunction("array_agg") || !IsGroupingFunction("LIST") || !IsGroupingFunction("sum") {
+		t.Error("Expected list aggregates to be grouping functions only")
+	}
+}
+
+func TestSummaryContext(t *testing.T) {
+	mgr := setupTestManager(t)
+	defer mgr.Close()
+
+	if _, err := mgr.ExecMain(`CREATE TABLE products (id INTEGER, category VARCHAR, price DOUBLE)`); err != nil {
+		t.Fatalf("Failed to create table: %v", err)
+	}
+	if _, err := mgr.ExecMain(`
+		INSERT INTO products VALUES
+			(1, 'books', 10.0), (2, 'books', 25.0), (3, 'books', 15.0),
+			(4, 'games', 60.0), (5, 'games', 40.0), (6, 'toys', 5.0)
+	`); err != nil {
+		t.Fatalf("Failed to insert rows: %v", err)
+	}
+
+	aggregates := []Aggregate{{Function: "min", Column: "price"}, {Function: "max", Column: "price"}, {Function: "count", Column: "id"}}
+	filters := []Filter{{Column: "category", Operator: "eq", Value: "books"}}
+	summary, err := mgr.SummaryContext(context.Background(), "products", nil, filters, nil, nil, aggregates)
+	if err != nil {
+		t.Fatalf("SummaryContext failed: %v", err)
+	}
+	if summary["price"]["min"] != 10.0 || summary["price"]["max"] != 25.0 {
+		t.Errorf("Unexpected price summary: %v", summary["price"])
+	}
+	if summary["id"]["count"] != int64(3) {
+		t.Errorf("Expected count 3, got %v", summary["id"]["count"])
+	}
+
+	// With a window, only the qualified rows are aggregated
+	window := &Window{
+		Function:  "row_number",
+		Partition: []string{"category"},
+		Order:     []Sort{{Column: "price", Direction: "desc"}},
+		Operator:  "<=",
+		Value:     int64(1),
+	}
+	summary, err = mgr.SummaryContext(context.Background(), "products", nil, nil, window, nil, []Aggregate{{Function: "min", Column: "price"}})
+	if err != nil {
+		t.Fatalf("SummaryContext with window failed: %v", err)
+	}
+	if summary["price"]["min"] != 5.0 {
+		t.Errorf("Expected min of the per-category maxima to be 5, got %v", summary["price"]["min"])
+	}
+}
@@ -0,0 +1,110 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// TablePool is a named pool of connections dedicated to writes on a set of
+// tables, e.g. a hot table whose transaction conflicts and retries should not
+// hold connections that other tables' writes need.
+type TablePool struct {
+	Name   string   `json:"name"`
+	Size   int      `json:"size"`
+	Tables []string `json:"tables"`
+}
+
+// ValidateTablePools checks that pool names are unique, sizes positive, and
+// that every table is routed to at most one pool.
+func ValidateTablePools(pools []TablePool) error {
+	names := make(map[string]bool, len(pools))
+	tables := make(map[string]string)
+	for _, p := range pools {
+		if p.Name == "" {
+			return fmt.Errorf("table pool name must not be empty")
+		}
+		if names[p.Name] {
+			return fmt.Errorf("duplicate table pool '%s'", p.Name)
+		}
+		names[p.Name] = true
+		if p.Size <= 0 {
+			return fmt.Errorf("table pool '%s' size must be greater than 0", p.Name)
+		}
+		if len(p.Tables) == 0 {
+			return fmt.Errorf("table pool '%s' must list at least one table", p.Name)
+		}
+		for _, table := range p.Tables {
+			key := strings.ToLower(table)
+			if other, ok := tables[key]; ok {
+				return fmt.Errorf("table '%s' is assigned to both pool '%s' and pool '%s'", table, other, p.Name)
+			}
+			tables[key] = p.Name
+		}
+	}
+	return nil
+}
+
+// openTablePools opens the configured table pools on the main database's
+// connector, like the read pool, and returns them by name.
+func openTablePools(connector driver.Connector, pools []TablePool) map[string]*sql.DB {
+	if len(pools) == 0 {
+		return nil
+	}
+	dbs := make(map[string]*sql.DB, len(pools))
+	for _, p := range pools {
+		db := sql.OpenDB(sharedConnector{connector})
+		db.SetMaxOpenConns(p.Size)
+		db.SetMaxIdleConns(p.Size)
+		db.SetConnMaxLifetime(time.Hour)
+		dbs[p.Name] = db
+	}
+	return dbs
+}
+
+// checkTablePools verifies that every table pool reaches the same database
+// as the main pool.
+func (m *Manager) checkTablePools() error {
+	var mainName string
+	if err := m.mainDB.QueryRow("SELECT current_database()").Scan(&mainName); err != nil {
+		return fmt.Errorf("failed to query main database: %w", err)
+	}
+	for name, db := range m.tablePools {
+		var poolName string
+		if err := db.QueryRow("SELECT current_database()").Scan(&poolName); err != nil {
+			return fmt.Errorf("failed to query table pool '%s': %w", name, err)
+		}
+		if poolName != mainName {
+			return fmt.Errorf("table pool '%s' is connected to database '%s' instead of '%s'", name, poolName, mainName)
+		}
+	}
+	return nil
+}
+
+// TablePoolDB returns the table pool with the given name, or nil if there is none.
+func (m *Manager) TablePoolDB(name string) *sql.DB {
+	return m.tablePools[name]
+}
+
+// writeDB returns the pool for writes on the table: its table pool if one is
+// configured, the main pool otherwise.
+func (m *Manager) writeDB(table string) *sql.DB {
+	if name, ok := m.tableRoutes[strings.ToLower(table)]; ok {
+		return m.tablePools[name]
+	}
+	return m.mainDB
+}
+
+// beginTx begins a write transaction on the table's pool.
+// The caller is responsible for committing or rolling back the transaction.
+func (m *Manager) beginTx(table string) (*sql.Tx, error) {
+	db, release, err := m.acquire(context.Background(), m.writeDB(table))
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	return db.BeginTx(context.Background(), nil)
+}
@@ -0,0 +1,115 @@
+package database
+
+import (
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+func TestValidateTablePools(t *testing.T) {
+	tests := []struct {
+		name    string
+		pools   []TablePool
+		wantErr bool
+	}{
+		{"none", nil, false},
+		{"valid", []TablePool{{Name: "hot", Size: 2, Tables: []string{"events"}}, {Name: "bulk", Size: 1, Tables: []string{"imports", "staging"}}}, false},
+		{"empty name", []TablePool{{Size: 2, Tables: []string{"events"}}}, true},
+		{"duplicate name", []TablePool{{Name: "hot", Size: 2, Tables: []string{"events"}}, {Name: "hot", Size: 1, Tables: []string{"imports"}}}, true},
+		{"zero size", []TablePool{{Name: "hot", Size: 0, Tables: []string{"events"}}}, true},
+		{"no tables", []TablePool{{Name: "hot", Size: 2}}, true},
+		{"table in two pools", []TablePool{{Name: "hot", Size: 2, Tables: []string{"events"}}, {Name: "bulk", Size: 1, Tables: []string{"Events"}}}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := ValidateTablePools(tt.pools); (err != nil) != tt.wantErr {
+				t.Errorf("ValidateTablePools() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+// waitFor runs fn and fails the test if it does not return within timeout.
+func waitFor(t *testing.T, timeout time.Duration, what string, fn func() error) {
+	t.Helper()
+	done := make(chan error, 1)
+	go func() { done <- fn() }()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatalf("%s failed: %v", what, err)
+		}
+	case <-time.After(timeout):
+		t.Fatalf("%s waited for the main pool", what)
+	}
+}
+
+func TestTablePool_RoutesWrites(t *testing.T) {
+	mgr, err := NewManagerForTesting(Config{
+		MainDBPath:   ":memory:",
+		AuthDBPath:   ":memory:",
+		Threads:      1,
+		AccessMode:   "read_write",
+		QueryTimeout: 30 * time.Second,
+		TablePools:   []TablePool{{Name: "hot", Size: 2, Tables: []string{"hot_events"}}},
+		Logger:       zap.NewNop(),
+	})
+	if err != nil {
+		t.Fatalf("Failed to create manager: %v", err)
+	}
+	defer mgr.Close()
+
+	pool := mgr.TablePoolDB("hot")
+	if pool == nil || pool == mgr.MainDB() {
+		t.Fatal("Expected a dedicated table pool")
+	}
+	if n := pool.Stats().MaxOpenConnections; n != 2 {
+		t.Errorf("Expected the table pool to allow 2 connections, got %d", n)
+	}
+	if mgr.writeDB("HOT_EVENTS") != pool || mgr.writeDB("other") != mgr.MainDB() {
+		t.Error("Expected writes to be routed by table name")
+	}
+
+	if _, err := mgr.ExecMain(`CREATE TABLE hot_events (id INTEGER, status VARCHAR)`); err != nil {
+		t.Fatalf("Failed to create table: %v", err)
+	}
+	// Schema lookups are reads; load the cached schema before the main pool is busy
+	if _, err := mgr.Insert("hot_events", map[string]interface{}{"id": 1, "status": "new"}); err != nil {
+		t.Fatalf("Failed to insert row: %v", err)
+	}
+
+	// Occupy the only main connection with an open transaction
+	mgr.MainDB().SetMaxOpenConns(1)
+	tx, err := mgr.BeginTxMain()
+	if err != nil {
+		t.Fatalf("Failed to begin transaction: %v", err)
+	}
+	defer tx.Rollback()
+
+	// Writes on the pooled table do not need a main connection
+	waitFor(t, 5*time.Second, "Insert", func() error {
+		_, err := mgr.Insert("hot_events", map[string]interface{}{"id": 2, "status": "new"})
+		return err
+	})
+	waitFor(t, 5*time.Second, "UpdateWithFilters", func() error {
+		_, err := mgr.UpdateWithFilters("hot_events", map[string]interface{}{"status": "done"},
+			[]Filter{{Column: "id", Operator: "eq", Value: 1}})
+		return err
+	})
+	if open := pool.Stats().OpenConnections; open == 0 {
+		t.Error("Expected the table pool to have opened a connection")
+	}
+	if err := tx.Commit(); err != nil {
+		t.Fatalf("Failed to commit: %v", err)
+	}
+
+	// The table pool writes to the same database
+	var status string
+	if err := mgr.QueryRowScanMain(`SELECT status FROM hot_events WHERE id = 1`, []interface{}{&status}); err != nil {
+		t.Fatalf("Failed to read row: %v", err)
+	}
+	if status != "done" {
+		t.Errorf("Expected status done, got %s", status)
+	}
+}
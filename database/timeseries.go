@@ -0,0 +1,146 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// TimeBucketColumn is the name of the bucket start column of time series rows.
+const TimeBucketColumn = "bucket"
+
+// intervalPattern matches compact bucket intervals such as 15m, 1h, or 7d.
+var intervalPattern = regexp.MustCompile(`^([0-9]+)(s|m|h|d|w|mo|y)$`)
+
+// intervalUnits maps the units of compact intervals to DuckDB interval units.
+var intervalUnits = map[string]string{
+	"s":  "seconds",
+	"m":  "minutes",
+	"h":  "hours",
+	"d":  "days",
+	"w":  "weeks",
+	"mo": "months",
+	"y":  "years",
+}
+
+// ParseInterval converts a compact interval (a positive count followed by s, m,
+// h, d, w, mo, or y, e.g. 1h) into a DuckDB interval such as "1 hours".
+func ParseInterval(value string) (string, error) {
+	match := intervalPattern.FindStringSubmatch(strings.ToLower(strings.TrimSpace(value)))
+	if match == nil {
+		return "", fmt.Errorf("invalid interval: %s (expected a count and a unit, e.g. 15m, 1h, 7d; units: s, m, h, d, w, mo, y)", value)
+	}
+	count, err := strconv.Atoi(match[1])
+	if err != nil || count <= 0 {
+		return "", fmt.Errorf("invalid interval: %s (count must be positive)", value)
+	}
+	return fmt.Sprintf("%d %s", count, intervalUnits[match[2]]), nil
+}
+
+// TimeSeries aggregates the rows of a table per time bucket, optionally per
+// group, e.g. the number of requests per hour and status:
+//
+//	SELECT time_bucket(INTERVAL '1 hours', ts) AS bucket, status, COUNT(*) AS count
+//	FROM requests GROUP BY ALL ORDER BY 1, 2
+type TimeSeries struct {
+	// TimeColumn is the TIMESTAMP or DATE column to bucket.
+	TimeColumn string
+	// Interval is the bucket width in compact form (see ParseInterval), e.g. 1h.
+	Interval string
+	// Aggregates are computed per bucket. COUNT may take "*" as its column.
+	Aggregates []Aggregate
+	// GroupBy are additional grouping columns.
+	GroupBy []string
+}
+
+// AggregateAlias returns the result column name of a time series aggregate:
+// the function name for count:*, otherwise function_column (e.g. avg_latency).
+func AggregateAlias(a Aggregate) string {
+	if a.Column == "*" {
+		return strings.ToLower(a.Function)
+	}
+	return strings.ToLower(a.Function) + "_" + a.Column
+}
+
+// Validate checks the interval, aggregates, and that result column names are unique.
+func (ts *TimeSeries) Validate() error {
+	if ts.TimeColumn == "" {
+		return fmt.Errorf("time column is required")
+	}
+	if _, err := ParseInterval(ts.Interval); err != nil {
+		return err
+	}
+	return validateGrouping([]string{TimeBucketColumn}, ts.GroupBy, ts.Aggregates)
+}
+
+// validateGrouping checks the aggregates and that the result columns of a
+// grouped query (the reserved names, group columns, and aggregate aliases) are unique.
+func validateGrouping(reserved, groupBy []string, aggregates []Aggregate) error {
+	if len(aggregates) == 0 {
+		return fmt.Errorf("at least one aggregate is required")
+	}
+
+	names := make(map[string]bool)
+	for _, col := range reserved {
+		names[strings.ToLower(col)] = true
+	}
+	for _, col := range groupBy {
+		if names[strings.ToLower(col)] {
+			return fmt.Errorf("duplicate result column '%s'", col)
+		}
+		names[strings.ToLower(col)] = true
+	}
+	for _, a := range aggregates {
+		if _, err := a.ToSQL(); err != nil {
+			return err
+		}
+		alias := AggregateAlias(a)
+		if names[strings.ToLower(alias)] {
+			return fmt.Errorf("duplicate result column '%s'", alias)
+		}
+		names[strings.ToLower(alias)] = true
+	}
+	return nil
+}
+
+// TimeSeriesStatement builds the grouped time_bucket query run by TimeSeriesContext.
+// Column names must be validated by the caller. A positive limit caps the number of rows.
+func TimeSeriesStatement(table string, derived []DerivedColumn, filters []Filter, ts *TimeSeries, limit int) (Statement, error) {
+	if err := ts.Validate(); err != nil {
+		return Statement{}, err
+	}
+	interval, _ := ParseInterval(ts.Interval)
+	clause, values := buildWhereClause(filters, 1)
+
+	projections := make([]string, 0, 1+len(ts.GroupBy)+len(ts.Aggregates))
+	projections = append(projections, fmt.Sprintf("time_bucket(INTERVAL '%s', %s) AS %s", interval, ts.TimeColumn, TimeBucketColumn))
+	projections = append(projections, ts.GroupBy...)
+	for _, a := range ts.Aggregates {
+		expr, _ := a.ToSQL()
+		projections = append(projections, fmt.Sprintf(`%s AS "%s"`, expr, AggregateAlias(a)))
+	}
+	order := make([]string, 0, 1+len(ts.GroupBy))
+	for i := 0; i <= len(ts.GroupBy); i++ {
+		order = append(order, strconv.Itoa(i+1))
+	}
+
+	query := fmt.Sprintf("SELECT %s FROM %s%s GROUP BY ALL ORDER BY %s",
+		strings.Join(projections, ", "), selectSource(table, derived), clause, strings.Join(order, ", "))
+	if limit > 0 {
+		query += fmt.Sprintf(" LIMIT %d", limit)
+	}
+	return Statement{SQL: query, Params: values}, nil
+}
+
+// TimeSeriesContext runs the time series aggregation over the rows matching the filters.
+// The rows are ordered by bucket, then by the group columns.
+func (m *Manager) TimeSeriesContext(ctx context.Context, table string, derived []DerivedColumn, filters []Filter, ts *TimeSeries, limit int) (*sql.Rows, error) {
+	stmt, err := TimeSeriesStatement(table, derived, filters, ts, limit)
+	if err != nil {
+		return nil, err
+	}
+	return m.QueryMainContext(ctx, stmt.SQL, stmt.Params...)
+}
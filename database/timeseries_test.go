@@ -0,0 +1,110 @@
+package database
+
+import (
+	"context"
+	"testing"
+)
+
+func TestParseInterval(t *testing.T) {
+	valid := map[string]string{
+		"1h":   "1 hours",
+		"15m":  "15 minutes",
+		"30s":  "30 seconds",
+		"7D":   "7 days",
+		"2w":   "2 weeks",
+		"1mo":  "1 months",
+		"1y":   "1 years",
+		" 1h ": "1 hours",
+	}
+	for value, want := range valid {
+		got, err := ParseInterval(value)
+		if err != nil || got != want {
+			t.Errorf("ParseInterval(%q) = (%q, %v), want %q", value, got, err, want)
+		}
+	}
+	for _, value := range []string{"", "h", "0h", "1", "1 hour", "-1h", "1h'; DROP TABLE x; --", "1.5h"} {
+		if _, err := ParseInterval(value); err == nil {
+			t.Errorf("Expected ParseInterval(%q) to fail", value)
+		}
+	}
+}
+
+func TestTimeSeriesStatement(t *testing.T) {
+	ts := &TimeSeries{
+		TimeColumn: "ts",
+		Interval:   "1h",
+		Aggregates: []Aggregate{{Function: "count", Column: "*"}, {Function: "avg", Column: "latency"}},
+		GroupBy:    []string{"status"},
+	}
+	stmt, err := TimeSeriesStatement("requests", nil, []Filter{{Column: "method", Operator: "eq", Value: "GET"}}, ts, 100)
+	if err != nil {
+		t.Fatalf("TimeSeriesStatement failed: %v", err)
+	}
+	want := `SELECT time_bucket(INTERVAL '1 hours', ts) AS bucket, status, COUNT(*) AS "count", AVG(latency) AS "avg_latency" FROM requests WHERE method = $1 GROUP BY ALL ORDER BY 1, 2 LIMIT 100`
+	if stmt.SQL != want {
+		t.Errorf("Unexpected SQL:\n got: %s\nwant: %s", stmt.SQL, want)
+	}
+	if len(stmt.Params) != 1 || stmt.Params[0] != "GET" {
+		t.Errorf("Unexpected params: %v", stmt.Params)
+	}
+
+	invalid := []*TimeSeries{
+		{TimeColumn: "ts", Interval: "1x", Aggregates: ts.Aggregates},
+		{TimeColumn: "ts", Interval: "1h"},
+		{TimeColumn: "ts", Interval: "1h", Aggregates: []Aggregate{{Function: "sum", Column: "*"}}},
+		{TimeColumn: "ts", Interval: "1h", Aggregates: ts.Aggregates, GroupBy: []string{"bucket"}},
+		{TimeColumn: "ts", Interval: "1h", Aggregates: ts.Aggregates, GroupBy: []string{"count"}},
+	}
+	for i, ts := range invalid {
+		if _, err := TimeSeriesStatement("requests", nil, nil, ts, 0); err == nil {
+			t.Errorf("Case %d: expected an error", i)
+		}
+	}
+}
+
+func TestTimeSeriesContext(t *testing.T) {
+	mgr := setupTestManager(t)
+	defer mgr.Close()
+
+	if _, err := mgr.ExecMain(`CREATE TABLE requests (ts TIMESTAMP, status INTEGER, latency DOUBLE)`); err != nil {
+		t.Fatalf("Failed to create table: %v", err)
+	}
+	if _, err := mgr.ExecMain(`
+		INSERT INTO requests VALUES
+			('2024-06-01 10:05:00', 200, 10), ('2024-06-01 10:40:00', 200, 20), ('2024-06-01 10:59:59', 500, 90),
+			('2024-06-01 12:00:00', 200, 30)
+	`); err != nil {
+		t.Fatalf("Failed to insert rows: %v", err)
+	}
+
+	ts := &TimeSeries{
+		TimeColumn: "ts",
+		Interval:   "1h",
+		Aggregates: []Aggregate{{Function: "count", Column: "*"}, {Function: "max", Column: "latency"}},
+	}
+	rows, err := mgr.TimeSeriesContext(context.Background(), "requests", nil, nil, ts, 0)
+	if err != nil {
+		t.Fatalf("TimeSeriesContext failed: %v", err)
+	}
+	defer rows.Close()
+
+	var counts []int64
+	var maxLatency []float64
+	for rows.Next() {
+		var bucket interface{}
+		var count int64
+		var latency float64
+		if err := rows.Scan(&bucket, &count, &latency); err != nil {
+			t.Fatalf("Scan failed: %v", err)
+		}
+		counts = append(counts, count)
+		maxLatency = append(maxLatency, latency)
+	}
+	// Empty buckets (11:00) are not returned
+	if len(counts) != 2 || counts[0] != 3 || counts[1] != 1 {
+		t.Errorf("Expected bucket counts [3 1], got %v", counts)
+	}
+	if len(maxLatency) != 2 || maxLatency[0] != 90 || maxLatency[1] != 30 {
+		t.Errorf("Expected max latencies [90 30], got %v", maxLatency)
+	}
+}
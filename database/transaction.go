@@ -0,0 +1,156 @@
+package database
+
+import "fmt"
+
+// Transaction operations.
+const (
+	TxInsert = "insert"
+	TxUpdate = "update"
+	TxDelete = "delete"
+)
+
+// TxOperation is a single write of a transaction (see RunTransaction).
+type TxOperation struct {
+	// Op is TxInsert, TxUpdate, or TxDelete.
+	Op    string
+	Table string
+
+	// Data is the row to insert. Omitted columns are set to NULL and columns set
+	// to Default get their DEFAULT, like in Insert.
+	Data map[string]interface{}
+
+	// Set holds the values of an update.
+	Set map[string]interface{}
+
+	// Filters select the rows to update or delete.
+	Filters []Filter
+
+	// SoftDeleteColumn, if set, turns a delete into a soft delete that sets the
+	// column to the current timestamp.
+	SoftDeleteColumn string
+}
+
+// TransactionError reports the operation of a transaction that failed. No
+// operation of the transaction is applied.
+type TransactionError struct {
+	Index int
+	Err   error
+}
+
+func (e *TransactionError) Error() string {
+	return fmt.Sprintf("operation %d: %v", e.Index, e.Err)
+}
+
+func (e *TransactionError) Unwrap() error {
+	return e.Err
+}
+
+// check validates the operation before anything is executed.
+func (op TxOperation) check() error {
+	switch op.Op {
+	case TxInsert:
+		if len(op.Data) == 0 {
+			return fmt.Errorf("no data provided for insert")
+		}
+	case TxUpdate:
+		if len(op.Set) == 0 {
+			return fmt.Errorf("no data provided for update")
+		}
+		if len(op.Filters) == 0 {
+			return fmt.Errorf("no filters provided for update (safety check)")
+		}
+	case TxDelete:
+		if len(op.Filters) == 0 {
+			return fmt.Errorf("no filters provided for delete (safety check)")
+		}
+	default:
+		return fmt.Errorf("unsupported operation '%s'", op.Op)
+	}
+	return nil
+}
+
+// statement builds the SQL of the operation. columns are the table's columns,
+// only needed for inserts.
+func (op TxOperation) statement(columns []string) Statement {
+	switch op.Op {
+	case TxInsert:
+		// Leave defaulted columns out; NULL for omitted columns
+		bound := make([]string, 0, len(columns))
+		values := make([]interface{}, 0, len(columns))
+		for _, col := range columns {
+			if op.Data[col] == Default {
+				continue
+			}
+			bound = append(bound, col)
+			values = append(values, op.Data[col])
+		}
+		return Statement{SQL: insertSQL(op.Table, bound), Params: values}
+	case TxUpdate:
+		return UpdateStatement(op.Table, op.Set, op.Filters)
+	default:
+		if op.SoftDeleteColumn != "" {
+			return SoftDeleteStatement(op.Table, op.SoftDeleteColumn, op.Filters)
+		}
+		return DeleteStatement(op.Table, op.Filters)
+	}
+}
+
+// RunTransaction executes the operations in order within a single transaction
+// on the main database and returns the rows affected by each of them. The
+// operations may span several tables. If any operation fails, the transaction
+// is rolled back and the error is a *TransactionError.
+// Automatically retries on transaction conflicts with exponential backoff.
+func (m *Manager) RunTransaction(ops []TxOperation) ([]int64, error) {
+	if len(ops) == 0 {
+		return nil, fmt.Errorf("no operations provided")
+	}
+
+	// Build every statement up front so the transaction only executes them
+	statements := make([]Statement, len(ops))
+	for i, op := range ops {
+		if err := op.check(); err != nil {
+			return nil, &TransactionError{Index: i, Err: err}
+		}
+		var columns []string
+		if op.Op == TxInsert {
+			var err error
+			if columns, err = m.getTableColumns(op.Table); err != nil {
+				return nil, &TransactionError{Index: i, Err: fmt.Errorf("failed to get table schema: %w", err)}
+			}
+		}
+		statements[i] = op.statement(columns)
+	}
+
+	var affected []int64
+	err := retryOnConflict(func() error {
+		tx, err := m.BeginTxMain()
+		if err != nil {
+			return fmt.Errorf("failed to begin transaction: %w", err)
+		}
+		defer tx.Rollback()
+
+		affected = make([]int64, len(ops))
+		for i, stmt := range statements {
+			execResult, err := tx.Exec(stmt.SQL, stmt.Params...)
+			if err != nil {
+				return &TransactionError{Index: i, Err: fmt.Errorf("failed to execute %s: %w", ops[i].Op, err)}
+			}
+			affected[i], _ = execResult.RowsAffected()
+		}
+
+		if err := tx.Commit(); err != nil {
+			return fmt.Errorf("failed to commit transaction: %w", err)
+		}
+		return nil
+	})
+	// A failed transaction counts as an error of each of its operations
+	for _, op := range ops {
+		m.counters.record(op.Op, err)
+		m.invalidateResults(op.Table)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return affected, nil
+}
@@ -0,0 +1,126 @@
+package database
+
+import (
+	"errors"
+	"testing"
+)
+
+// setupTransactionTables adds an orders table next to test_users.
+func setupTransactionTables(t *testing.T) *Manager {
+	t.Helper()
+	mgr := setupTestManager(t)
+	if _, err := mgr.ExecMain(`CREATE TABLE orders (id INTEGER PRIMARY KEY, user_id INTEGER, total DOUBLE, status VARCHAR DEFAULT 'new', deleted_at TIMESTAMP)`); err != nil {
+		t.Fatalf("Failed to create table: %v", err)
+	}
+	if _, err := mgr.ExecMain(`INSERT INTO test_users VALUES (1, 'Alice', 'alice@example.com', 30)`); err != nil {
+		t.Fatalf("Failed to insert row: %v", err)
+	}
+	return mgr
+}
+
+func countRows(t *testing.T, mgr *Manager, query string) int {
+	t.Helper()
+	var count int
+	if err := mgr.QueryRowScanMain(query, []interface{}{&count}); err != nil {
+		t.Fatalf("Failed to count rows: %v", err)
+	}
+	return count
+}
+
+func TestRunTransaction(t *testing.T) {
+	mgr := setupTransactionTables(t)
+	defer mgr.Close()
+
+	affected, err := mgr.RunTransaction([]TxOperation{
+		{Op: TxInsert, Table: "test_users", Data: map[string]interface{}{"id": 2, "name": "Bob"}},
+		{Op: TxInsert, Table: "orders", Data: map[string]interface{}{"id": 10, "user_id": 2, "total": 9.5, "status": Default}},
+		{Op: TxInsert, Table: "orders", Data: map[string]interface{}{"id": 11, "user_id": 1, "total": 20}},
+		{Op: TxUpdate, Table: "test_users", Set: map[string]interface{}{"age": 31}, Filters: []Filter{{Column: "id", Operator: "eq", Value: 1}}},
+		{Op: TxDelete, Table: "orders", Filters: []Filter{{Column: "id", Operator: "eq", Value: 11}}, SoftDeleteColumn: "deleted_at"},
+		{Op: TxDelete, Table: "orders", Filters: []Filter{{Column: "id", Operator: "eq", Value: 99}}},
+	})
+	if err != nil {
+		t.Fatalf("RunTransaction failed: %v", err)
+	}
+	want := []int64{1, 1, 1, 1, 1, 0}
+	if len(affected) != len(want) {
+		t.Fatalf("Expected %d results, got %v", len(want), affected)
+	}
+	for i := range want {
+		if affected[i] != want[i] {
+			t.Errorf("Operation %d: expected %d rows affected, got %d", i, want[i], affected[i])
+		}
+	}
+
+	if n := countRows(t, mgr, `SELECT COUNT(*) FROM test_users`); n != 2 {
+		t.Errorf("Expected 2 users, got %d", n)
+	}
+	if n := countRows(t, mgr, `SELECT COUNT(*) FROM test_users WHERE id = 1 AND age = 31`); n != 1 {
+		t.Error("Expected Alice to be updated")
+	}
+	if n := countRows(t, mgr, `SELECT COUNT(*) FROM orders WHERE id = 10 AND status = 'new'`); n != 1 {
+		t.Error("Expected order 10 to get the default status")
+	}
+	if n := countRows(t, mgr, `SELECT COUNT(*) FROM orders WHERE id = 11 AND deleted_at IS NOT NULL`); n != 1 {
+		t.Error("Expected order 11 to be soft-deleted")
+	}
+}
+
+func TestRunTransaction_Rollback(t *testing.T) {
+	mgr := setupTransactionTables(t)
+	defer mgr.Close()
+
+	// The third operation violates the primary key of test_users
+	_, err := mgr.RunTransaction([]TxOperation{
+		{Op: TxInsert, Table: "orders", Data: map[string]interface{}{"id": 10, "user_id": 1, "total": 5}},
+		{Op: TxUpdate, Table: "test_users", Set: map[string]interface{}{"age": 40}, Filters: []Filter{{Column: "id", Operator: "eq", Value: 1}}},
+		{Op: TxInsert, Table: "test_users", Data: map[string]interface{}{"id": 1, "name": "Duplicate"}},
+	})
+	var txErr *TransactionError
+	if !errors.As(err, &txErr) {
+		t.Fatalf("Expected a TransactionError, got %v", err)
+	}
+	if txErr.Index != 2 {
+		t.Errorf("Expected operation 2 to fail, got %d", txErr.Index)
+	}
+
+	// Nothing was applied
+	if n := countRows(t, mgr, `SELECT COUNT(*) FROM orders`); n != 0 {
+		t.Errorf("Expected no orders after rollback, got %d", n)
+	}
+	if n := countRows(t, mgr, `SELECT COUNT(*) FROM test_users WHERE age = 30`); n != 1 {
+		t.Error("Expected Alice's update to be rolled back")
+	}
+}
+
+func TestRunTransaction_Invalid(t *testing.T) {
+	mgr := setupTransactionTables(t)
+	defer mgr.Close()
+
+	if _, err := mgr.RunTransaction(nil); err == nil {
+		t.Error("Expected error for an empty transaction")
+	}
+
+	tests := []struct {
+		name string
+		op   TxOperation
+	}{
+		{"unknown op", TxOperation{Op: "merge", Table: "orders"}},
+		{"insert without data", TxOperation{Op: TxInsert, Table: "orders"}},
+		{"update without filters", TxOperation{Op: TxUpdate, Table: "orders", Set: map[string]interface{}{"total": 1}}},
+		{"delete without filters", TxOperation{Op: TxDelete, Table: "orders"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ops := []TxOperation{{Op: TxInsert, Table: "orders", Data: map[string]interface{}{"id": 1}}, tt.op}
+			_, err := mgr.RunTransaction(ops)
+			var txErr *TransactionError
+			if !errors.As(err, &txErr) || txErr.Index != 1 {
+				t.Errorf("Expected a TransactionError for operation 1, got %v", err)
+			}
+		})
+	}
+	if n := countRows(t, mgr, `SELECT COUNT(*) FROM orders`); n != 0 {
+		t.Errorf("Expected invalid transactions to apply nothing, got %d orders", n)
+	}
+}
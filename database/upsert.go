@@ -0,0 +1,171 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// UpsertResult represents the result of an upsert operation.
+type UpsertResult struct {
+	RowsAffected int64
+}
+
+// UniqueKey is the column set of a primary key or unique constraint.
+type UniqueKey struct {
+	Columns []string
+	Primary bool
+}
+
+// UniqueKeys returns the primary key and unique constraints of a table, the
+// primary key first, as reported by information_schema. These are the column
+// sets an upsert may use as its conflict target.
+func (m *Manager) UniqueKeys(table string) ([]UniqueKey, error) {
+	catalogClause, args := catalogCondition(table)
+	query := `
+		SELECT c.constraint_type = 'PRIMARY KEY', k.constraint_name, k.column_name
+		FROM (
+			SELECT * FROM information_schema.key_column_usage
+			WHERE table_name = $1 AND ` + catalogClause + `
+		) k
+		JOIN information_schema.table_constraints c
+			ON c.constraint_name = k.constraint_name AND c.table_name = k.table_name
+			AND c.table_schema = k.table_schema AND c.table_catalog = k.table_catalog
+		WHERE c.constraint_type IN ('PRIMARY KEY', 'UNIQUE')
+		ORDER BY 1 DESC, k.constraint_name, k.ordinal_position
+	`
+
+	rows, err := m.QueryMain(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query table constraints: %w", err)
+	}
+	defer rows.Close()
+
+	var keys []UniqueKey
+	var current string
+	for rows.Next() {
+		var primary bool
+		var name, column string
+		if err := rows.Scan(&primary, &name, &column); err != nil {
+			return nil, fmt.Errorf("failed to scan constraint column: %w", err)
+		}
+		if len(keys) == 0 || name != current {
+			keys = append(keys, UniqueKey{Primary: primary})
+			current = name
+		}
+		keys[len(keys)-1].Columns = append(keys[len(keys)-1].Columns, column)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating constraints: %w", err)
+	}
+	return keys, nil
+}
+
+// Upsert inserts rows in order within a single transaction; a row that
+// conflicts with an existing row on the conflict columns (which must form the
+// primary key or a unique constraint) updates that row instead, via INSERT ...
+// ON CONFLICT DO UPDATE. Only the columns present in a row are written, so
+// omitted columns keep their current value on update and get their DEFAULT on
+// insert; columns set to Default are left out the same way. A row of only
+// conflict columns is skipped if it exists. If any row fails, the transaction
+// is rolled back and the error is an *InsertBatchError. Column names must be
+// validated by the caller.
+// Automatically retries on transaction conflicts with exponential backoff.
+func (m *Manager) Upsert(table string, rows []map[string]interface{}, conflict []string) (*UpsertResult, error) {
+	if len(rows) == 0 {
+		return nil, fmt.Errorf("no rows provided for upsert")
+	}
+	if len(conflict) == 0 {
+		return nil, fmt.Errorf("no conflict columns provided for upsert")
+	}
+
+	var result *UpsertResult
+	err := m.recordWrite(QueryInsert, table, func() error {
+		tx, err := m.beginTx(table)
+		if err != nil {
+			return fmt.Errorf("failed to begin transaction: %w", err)
+		}
+		defer tx.Rollback()
+
+		prepared := make(map[string]*sql.Stmt)
+		defer func() {
+			for _, stmt := range prepared {
+				stmt.Close()
+			}
+		}()
+
+		var total int64
+		for i, row := range rows {
+			columns := make([]string, 0, len(row))
+			for col, val := range row {
+				if val != Default {
+					columns = append(columns, col)
+				}
+			}
+			if len(columns) == 0 {
+				return &InsertBatchError{Index: i, Err: fmt.Errorf("no data provided for upsert")}
+			}
+			sort.Strings(columns)
+			values := make([]interface{}, len(columns))
+			for j, col := range columns {
+				values[j] = row[col]
+			}
+
+			query := upsertSQL(table, columns, conflict)
+			txStmt, ok := prepared[query]
+			if !ok {
+				if txStmt, err = tx.Prepare(query); err != nil {
+					return &InsertBatchError{Index: i, Err: fmt.Errorf("failed to prepare upsert: %w", err)}
+				}
+				prepared[query] = txStmt
+			}
+			execResult, err := txStmt.Exec(values...)
+			if err != nil {
+				return &InsertBatchError{Index: i, Err: fmt.Errorf("failed to execute upsert: %w", err)}
+			}
+			n, _ := execResult.RowsAffected()
+			total += n
+		}
+
+		if err := tx.Commit(); err != nil {
+			return fmt.Errorf("failed to commit transaction: %w", err)
+		}
+		result = &UpsertResult{RowsAffected: total}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// upsertSQL builds an INSERT ... ON CONFLICT statement binding the given
+// columns, which updates every bound column that is not a conflict column.
+func upsertSQL(table string, columns, conflict []string) string {
+	isConflict := make(map[string]bool, len(conflict))
+	for _, col := range conflict {
+		isConflict[col] = true
+	}
+	placeholders := make([]string, len(columns))
+	var updates []string
+	for i, col := range columns {
+		placeholders[i] = fmt.Sprintf("$%d", i+1)
+		if !isConflict[col] {
+			updates = append(updates, fmt.Sprintf("%s = EXCLUDED.%s", col, col))
+		}
+	}
+	action := "DO NOTHING"
+	if len(updates) > 0 {
+		action = "DO UPDATE SET " + strings.Join(updates, ", ")
+	}
+	return fmt.Sprintf(
+		"INSERT INTO %s (%s) VALUES (%s) ON CONFLICT (%s) %s",
+		table,
+		strings.Join(columns, ", "),
+		strings.Join(placeholders, ", "),
+		strings.Join(conflict, ", "),
+		action,
+	)
+}
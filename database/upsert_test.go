@@ -0,0 +1,116 @@
+package database
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestUpsertSQL(t *testing.T) {
+	got := upsertSQL("users", []string{"age", "id", "name"}, []string{"id"})
+	want := "INSERT INTO users (age, id, name) VALUES ($1, $2, $3) ON CONFLICT (id) DO UPDATE SET age = EXCLUDED.age, name = EXCLUDED.name"
+	if got != want {
+		t.Errorf("upsertSQL() =\n%s\nwant\n%s", got, want)
+	}
+
+	got = upsertSQL("users", []string{"id"}, []string{"id"})
+	if want := "INSERT INTO users (id) VALUES ($1) ON CONFLICT (id) DO NOTHING"; got != want {
+		t.Errorf("upsertSQL() = %s, want %s", got, want)
+	}
+}
+
+func TestUniqueKeys(t *testing.T) {
+	mgr := setupTestManager(t)
+	defer mgr.Close()
+
+	keys, err := mgr.UniqueKeys("test_users")
+	if err != nil {
+		t.Fatalf("UniqueKeys failed: %v", err)
+	}
+	if len(keys) != 1 || !keys[0].Primary || len(keys[0].Columns) != 1 || keys[0].Columns[0] != "id" {
+		t.Errorf("Expected primary key (id), got %+v", keys)
+	}
+
+	if _, err := mgr.ExecMain(`CREATE TABLE test_accounts (tenant VARCHAR, name VARCHAR, email VARCHAR UNIQUE, PRIMARY KEY (tenant, name))`); err != nil {
+		t.Fatalf("Failed to create table: %v", err)
+	}
+	keys, err = mgr.UniqueKeys("test_accounts")
+	if err != nil {
+		t.Fatalf("UniqueKeys failed: %v", err)
+	}
+	if len(keys) != 2 {
+		t.Fatalf("Expected 2 keys, got %+v", keys)
+	}
+	if !keys[0].Primary || len(keys[0].Columns) != 2 || keys[0].Columns[0] != "tenant" || keys[0].Columns[1] != "name" {
+		t.Errorf("Expected primary key (tenant, name) first, got %+v", keys[0])
+	}
+	if keys[1].Primary || len(keys[1].Columns) != 1 || keys[1].Columns[0] != "email" {
+		t.Errorf("Expected unique key (email), got %+v", keys[1])
+	}
+
+	if _, err := mgr.ExecMain(`CREATE TABLE test_log (message VARCHAR)`); err != nil {
+		t.Fatalf("Failed to create table: %v", err)
+	}
+	if keys, err := mgr.UniqueKeys("test_log"); err != nil || len(keys) != 0 {
+		t.Errorf("Expected no keys, got %+v (%v)", keys, err)
+	}
+}
+
+func TestUpsert(t *testing.T) {
+	mgr := setupTestManager(t)
+	defer mgr.Close()
+
+	if _, err := mgr.Insert("test_users", map[string]interface{}{"id": 1, "name": "Alice", "email": "alice@example.com", "age": 30}); err != nil {
+		t.Fatalf("Insert failed: %v", err)
+	}
+
+	// Row 1 exists and is updated; row 2 is inserted
+	rows := []map[string]interface{}{
+		{"id": 1, "age": 31},
+		{"id": 2, "name": "Bob", "age": 25},
+	}
+	result, err := mgr.Upsert("test_users", rows, []string{"id"})
+	if err != nil {
+		t.Fatalf("Upsert failed: %v", err)
+	}
+	if result.RowsAffected != 2 {
+		t.Errorf("Expected 2 rows affected, got %d", result.RowsAffected)
+	}
+
+	var name, email string
+	var age int
+	if err := mgr.QueryRowScanMain("SELECT name, email, age FROM test_users WHERE id = 1", []interface{}{&name, &email, &age}); err != nil {
+		t.Fatalf("Failed to read row: %v", err)
+	}
+	if name != "Alice" || email != "alice@example.com" || age != 31 {
+		t.Errorf("Expected only age to be updated, got %s, %s, %d", name, email, age)
+	}
+	var count int
+	if err := mgr.QueryRowScanMain("SELECT COUNT(*) FROM test_users", []interface{}{&count}); err != nil {
+		t.Fatalf("Failed to count rows: %v", err)
+	}
+	if count != 2 {
+		t.Errorf("Expected 2 rows, got %d", count)
+	}
+
+	// A failing row rolls back the whole batch
+	rows = []map[string]interface{}{
+		{"id": 3, "name": "Charlie"},
+		{"id": 1, "age": "not a number"},
+	}
+	_, err = mgr.Upsert("test_users", rows, []string{"id"})
+	var batchErr *InsertBatchError
+	if !errors.As(err, &batchErr) || batchErr.Index != 1 {
+		t.Fatalf("Expected an InsertBatchError for row 1, got %v", err)
+	}
+	if err := mgr.QueryRowScanMain("SELECT COUNT(*) FROM test_users", []interface{}{&count}); err != nil {
+		t.Fatalf("Failed to count rows: %v", err)
+	}
+	if count != 2 {
+		t.Errorf("Expected the batch to be rolled back, got %d rows", count)
+	}
+
+	// The conflict target must be a key
+	if _, err := mgr.Upsert("test_users", []map[string]interface{}{{"id": 4, "name": "Dave"}}, []string{"name"}); err == nil {
+		t.Error("Expected error for a conflict target that is not a key")
+	}
+}
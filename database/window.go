@@ -0,0 +1,83 @@
+package database
+
+import (
+	"fmt"
+	"strings"
+)
+
+// windowFunctions maps the ranking window functions allowed in QUALIFY reads
+// to their SQL names. None of them take arguments.
+var windowFunctions = map[string]string{
+	"row_number":   "ROW_NUMBER",
+	"rank":         "RANK",
+	"dense_rank":   "DENSE_RANK",
+	"percent_rank": "PERCENT_RANK",
+	"cume_dist":    "CUME_DIST",
+}
+
+// qualifyOperators are the comparison operators allowed against a window function.
+var qualifyOperators = map[string]bool{
+	"=": true, "!=": true, "<": true, "<=": true, ">": true, ">=": true,
+}
+
+// IsWindowFunction reports whether name is an allowed window function.
+func IsWindowFunction(name string) bool {
+	_, ok := windowFunctions[strings.ToLower(name)]
+	return ok
+}
+
+// IsQualifyOperator reports whether op is an allowed QUALIFY comparison operator.
+func IsQualifyOperator(op string) bool {
+	return qualifyOperators[op]
+}
+
+// Window restricts a read to rows whose window function value satisfies a
+// comparison, e.g. the top 3 rows per category:
+//
+//	QUALIFY ROW_NUMBER() OVER (PARTITION BY category ORDER BY price DESC) <= 3
+type Window struct {
+	Function  string
+	Partition []string
+	Order     []Sort
+	Operator  string
+	Value     interface{}
+}
+
+// ToSQL converts the window to a QUALIFY condition (without the keyword).
+// Column names must be validated by the caller; the value is bound as a parameter.
+func (w *Window) ToSQL(paramIndex int) (string, interface{}, error) {
+	fn, ok := windowFunctions[strings.ToLower(w.Function)]
+	if !ok {
+		return "", nil, fmt.Errorf("unsupported window function: %s", w.Function)
+	}
+	if !qualifyOperators[w.Operator] {
+		return "", nil, fmt.Errorf("unsupported qualify operator: %s", w.Operator)
+	}
+
+	over := make([]string, 0, 2)
+	if len(w.Partition) > 0 {
+		over = append(over, "PARTITION BY "+strings.Join(w.Partition, ", "))
+	}
+	if len(w.Order) > 0 {
+		orders := make([]string, 0, len(w.Order))
+		for _, s := range w.Order {
+			orders = append(orders, s.ToSQL())
+		}
+		over = append(over, "ORDER BY "+strings.Join(orders, ", "))
+	}
+	return fmt.Sprintf("%s() OVER (%s) %s $%d", fn, strings.Join(over, " "), w.Operator, paramIndex), w.Value, nil
+}
+
+// buildReadClauses builds the WHERE and QUALIFY clauses of a read, numbering
+// parameters from 1.
+func buildReadClauses(filters []Filter, window *Window) (string, []interface{}, error) {
+	clause, values := buildWhereClause(filters, 1)
+	if window == nil {
+		return clause, values, nil
+	}
+	qualify, value, err := window.ToSQL(len(values) + 1)
+	if err != nil {
+		return "", nil, err
+	}
+	return clause + " QUALIFY " + qualify, append(values, value), nil
+}
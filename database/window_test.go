@@ -0,0 +1,94 @@
+package database
+
+import (
+	"context"
+	"testing"
+)
+
+func TestWindowToSQL(t *testing.T) {
+	window := &Window{
+		Function:  "row_number",
+		Partition: []string{"category"},
+		Order:     []Sort{{Column: "price", Direction: "desc"}},
+		Operator:  "<=",
+		Value:     int64(3),
+	}
+	clause, value, err := window.ToSQL(2)
+	if err != nil {
+		t.Fatalf("ToSQL failed: %v", err)
+	}
+	want := "ROW_NUMBER() OVER (PARTITION BY category ORDER BY price DESC) <= $2"
+	if clause != want {
+		t.Errorf("Expected %q, got %q", want, clause)
+	}
+	if value != int64(3) {
+		t.Errorf("Expected value 3, got %v", value)
+	}
+
+	if _, _, err := (&Window{Function: "sum", Operator: "<="}).ToSQL(1); err == nil {
+		t.Error("Expected error for unsupported window function")
+	}
+	if _, _, err := (&Window{Function: "rank", Operator: "<= 1 OR 1="}).ToSQL(1); err == nil {
+		t.Error("Expected error for unsupported operator")
+	}
+}
+
+func TestSelectWithWindow(t *testing.T) {
+	mgr := setupTestManager(t)
+	defer mgr.Close()
+
+	if _, err := mgr.ExecMain(`
+		CREATE TABLE products AS SELECT * FROM (VALUES
+			(1, 'books', 10.0), (2, 'books', 25.0), (3, 'books', 15.0),
+			(4, 'games', 60.0), (5, 'games', 40.0), (6, 'toys', 5.0)
+		) AS t(id, category, price)
+	`); err != nil {
+		t.Fatalf("Failed to create table: %v", err)
+	}
+
+	// Top 2 most expensive products per category
+	window := &Window{
+		Function:  "row_number",
+		Partition: []string{"category"},
+		Order:     []Sort{{Column: "price", Direction: "desc"}},
+		Operator:  "<=",
+		Value:     int64(2),
+	}
+	filters := []Filter{{Column: "price", Operator: "gt", Value: 5}}
+	sorts := []Sort{{Column: "id", Direction: "asc"}}
+
+	rows, err := mgr.SelectContext(context.Background(), "products", nil, filters, window, nil, sorts, 0, 0)
+	if err != nil {
+		t.Fatalf("SelectContext failed: %v", err)
+	}
+	defer rows.Close()
+
+	var ids []int
+	for rows.Next() {
+		var id int
+		var category string
+		var price float64
+		if err := rows.Scan(&id, &category, &price); err != nil {
+			t.Fatalf("Scan failed: %v", err)
+		}
+		ids = append(ids, id)
+	}
+	want := []int{2, 3, 4, 5}
+	if len(ids) != len(want) {
+		t.Fatalf("Expected ids %v, got %v", want, ids)
+	}
+	for i := range want {
+		if ids[i] != want[i] {
+			t.Errorf("Expected ids %v, got %v", want, ids)
+			break
+		}
+	}
+
+	count, err := mgr.CountContext(context.Background(), "products", nil, filters, window, nil)
+	if err != nil {
+		t.Fatalf("CountContext failed: %v", err)
+	}
+	if count != 4 {
+		t.Errorf("Expected count 4, got %d", count)
+	}
+}
@@ -13,9 +13,43 @@ import (
 	"github.com/apache/arrow/go/v18/arrow/memory"
 )
 
+// DefaultArrowBatchSize is the number of rows per Arrow record batch when
+// ArrowOptions.BatchSize is not set.
+const DefaultArrowBatchSize = 1024
+
+// ArrowOptions controls optional encodings of Arrow output.
+type ArrowOptions struct {
+	// DictionaryThreshold dictionary-encodes string columns with at most this
+	// many distinct values in the first record batch. 0 disables dictionary encoding.
+	DictionaryThreshold int
+
+	// BatchSize is the number of rows per record batch. 0 means DefaultArrowBatchSize.
+	BatchSize int
+}
+
+// batchSize returns the configured batch size, defaulting to DefaultArrowBatchSize.
+func (o ArrowOptions) batchSize() int {
+	if o.BatchSize <= 0 {
+		return DefaultArrowBatchSize
+	}
+	return o.BatchSize
+}
+
 // WriteArrowIPC writes query results as Apache Arrow IPC stream format.
 // This format is ideal for HTTP streaming and zero-copy data transfer.
 func WriteArrowIPC(w http.ResponseWriter, rows *sql.Rows) error {
+	return WriteArrowIPCWithOptions(w, rows, ArrowOptions{})
+}
+
+// WriteArrowIPCWithOptions is like WriteArrowIPC but applies the given options.
+// The first record batch is read before anything is written, so that
+// low-cardinality string columns can be declared as dictionaries in the schema.
+// Each record batch is flushed to the client as soon as it is written, so the
+// response is streamed (chunked) and clients can consume it immediately.
+func WriteArrowIPCWithOptions(w http.ResponseWriter, rows *sql.Rows, opts ArrowOptions) error {
+	batchSize := opts.batchSize()
+	flusher, _ := w.(http.Flusher)
+
 	// Get column types and names
 	columnTypes, err := rows.ColumnTypes()
 	if err != nil {
@@ -27,10 +61,18 @@ func WriteArrowIPC(w http.ResponseWriter, rows *sql.Rows) error {
 		return fmt.Errorf("failed to get column names: %w", err)
 	}
 
+	first, err := scanBatch(rows, batchSize, len(columnTypes))
+	if err != nil {
+		return fmt.Errorf("failed to build record batch: %w", err)
+	}
+
 	// Build Arrow schema
 	fields := make([]arrow.Field, len(columnNames))
 	for i, colType := range columnTypes {
 		arrowType, nullable := sqlTypeToArrowType(colType)
+		if arrowType.ID() == arrow.STRING && opts.DictionaryThreshold > 0 && lowCardinality(first, i, opts.DictionaryThreshold) {
+			arrowType = &arrow.DictionaryType{IndexType: arrow.PrimitiveTypes.Int32, ValueType: arrow.BinaryTypes.String}
+		}
 		fields[i] = arrow.Field{
 			Name:     columnNames[i],
 			Type:     arrowType,
@@ -50,38 +92,111 @@ func WriteArrowIPC(w http.ResponseWriter, rows *sql.Rows) error {
 	writer := ipc.NewWriter(w, ipc.WithSchema(schema), ipc.WithAllocator(pool))
 	defer writer.Close()
 
-	// Process rows in batches for memory efficiency
-	const batchSize = 1024
-
-	for {
-		// Build record batch
-		record, hasMore, err := buildRecordBatch(rows, schema, pool, batchSize, columnTypes)
-		if err != nil {
-			return fmt.Errorf("failed to build record batch: %w", err)
-		}
-
-		if record == nil {
-			break
-		}
+	if len(first) == 0 {
+		return nil
+	}
+	record, err := newRecordBatch(schema, pool, first, columnTypes)
+	if err != nil {
+		return fmt.Errorf("failed to build record batch: %w", err)
+	}
+	hasMore := len(first) == batchSize
 
-		// Write record batch to stream
+	// Process remaining rows in batches for memory efficiency
+	for record != nil {
+		// Write record batch to stream. Dictionaries are built per batch and
+		// sent as replacement dictionaries when they change.
 		if err := writer.Write(record); err != nil {
 			record.Release()
 			return fmt.Errorf("failed to write record batch: %w", err)
 		}
 
 		record.Release()
+		if flusher != nil {
+			flusher.Flush()
+		}
 
 		if !hasMore {
 			break
 		}
+
+		// Build next record batch
+		record, hasMore, err = buildRecordBatch(rows, schema, pool, batchSize, columnTypes)
+		if err != nil {
+			return fmt.Errorf("failed to build record batch: %w", err)
+		}
 	}
 
 	return nil
 }
 
+// lowCardinality reports whether column col has at most threshold distinct
+// non-null values in batch.
+func lowCardinality(batch [][]interface{}, col, threshold int) bool {
+	distinct := make(map[string]struct{})
+	for _, row := range batch {
+		switch v := row[col].(type) {
+		case string:
+			distinct[v] = struct{}{}
+		case []byte:
+			distinct[string(v)] = struct{}{}
+		case nil:
+		default:
+			return false
+		}
+		if len(distinct) > threshold {
+			return false
+		}
+	}
+	return true
+}
+
 // buildRecordBatch builds a single Arrow record batch from sql.Rows
 func buildRecordBatch(rows *sql.Rows, schema *arrow.Schema, pool memory.Allocator, batchSize int, columnTypes []*sql.ColumnType) (arrow.Record, bool, error) {
+	batch, err := scanBatch(rows, batchSize, len(columnTypes))
+	if err != nil {
+		return nil, false, err
+	}
+	if len(batch) == 0 {
+		return nil, false, nil
+	}
+
+	record, err := newRecordBatch(schema, pool, batch, columnTypes)
+	if err != nil {
+		return nil, false, err
+	}
+
+	// Check if there are more rows
+	hasMore := len(batch) == batchSize
+
+	return record, hasMore, nil
+}
+
+// scanBatch scans up to batchSize rows.
+func scanBatch(rows *sql.Rows, batchSize, numColumns int) ([][]interface{}, error) {
+	var batch [][]interface{}
+	for len(batch) < batchSize && rows.Next() {
+		// Create scan targets
+		values := make([]interface{}, numColumns)
+		valuePtrs := make([]interface{}, numColumns)
+		for i := range values {
+			valuePtrs[i] = &values[i]
+		}
+
+		if err := rows.Scan(valuePtrs...); err != nil {
+			return nil, fmt.Errorf("failed to scan row: %w", err)
+		}
+		batch = append(batch, values)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating rows: %w", err)
+	}
+
+	return batch, nil
+}
+
+// newRecordBatch builds an Arrow record from scanned rows.
+func newRecordBatch(schema *arrow.Schema, pool memory.Allocator, batch [][]interface{}, columnTypes []*sql.ColumnType) (arrow.Record, error) {
 	// Create builders for each column
 	builders := make([]array.Builder, len(schema.Fields()))
 	for i, field := range schema.Fields() {
@@ -93,36 +208,13 @@ func buildRecordBatch(rows *sql.Rows, schema *arrow.Schema, pool memory.Allocato
 		}
 	}()
 
-	// Scan rows into builders
-	rowCount := 0
-	for rowCount < batchSize && rows.Next() {
-		// Create scan targets
-		values := make([]interface{}, len(columnTypes))
-		valuePtrs := make([]interface{}, len(columnTypes))
-		for i := range columnTypes {
-			valuePtrs[i] = &values[i]
-		}
-
-		if err := rows.Scan(valuePtrs...); err != nil {
-			return nil, false, fmt.Errorf("failed to scan row: %w", err)
-		}
-
-		// Append values to builders
+	// Append values to builders
+	for _, values := range batch {
 		for i, val := range values {
 			if err := appendValueToBuilder(builders[i], val, columnTypes[i]); err != nil {
-				return nil, false, fmt.Errorf("failed to append value to builder at column %d: %w", i, err)
+				return nil, fmt.Errorf("failed to append value to builder at column %d: %w", i, err)
 			}
 		}
-
-		rowCount++
-	}
-
-	if err := rows.Err(); err != nil {
-		return nil, false, fmt.Errorf("error iterating rows: %w", err)
-	}
-
-	if rowCount == 0 {
-		return nil, false, nil
 	}
 
 	// Build arrays from builders
@@ -132,17 +224,14 @@ func buildRecordBatch(rows *sql.Rows, schema *arrow.Schema, pool memory.Allocato
 	}
 
 	// Create record
-	record := array.NewRecord(schema, arrays, int64(rowCount))
+	record := array.NewRecord(schema, arrays, int64(len(batch)))
 
 	// Release arrays (record holds references)
 	for _, arr := range arrays {
 		arr.Release()
 	}
 
-	// Check if there are more rows
-	hasMore := rowCount == batchSize
-
-	return record, hasMore, nil
+	return record, nil
 }
 
 // sqlTypeToArrowType maps SQL column types to Arrow types
@@ -228,12 +317,21 @@ func appendValueToBuilder(builder array.Builder, val interface{}, colType *sql.C
 
 	// Convert byte arrays to strings first for string types
 	if b, ok := val.([]byte); ok {
-		if _, isStringBuilder := builder.(*array.StringBuilder); isStringBuilder {
+		switch builder.(type) {
+		case *array.StringBuilder, *array.BinaryDictionaryBuilder:
 			val = string(b)
 		}
 	}
 
 	switch b := builder.(type) {
+	case *array.BinaryDictionaryBuilder:
+		if v, ok := val.(string); ok {
+			if err := b.AppendString(v); err != nil {
+				return err
+			}
+		} else {
+			return fmt.Errorf("expected string, got %T", val)
+		}
 	case *array.BooleanBuilder:
 		if v, ok := val.(bool); ok {
 			b.Append(v)
@@ -7,6 +7,7 @@ import (
 	"testing"
 
 	"github.com/apache/arrow/go/v18/arrow"
+	"github.com/apache/arrow/go/v18/arrow/array"
 	"github.com/apache/arrow/go/v18/arrow/ipc"
 	"github.com/apache/arrow/go/v18/arrow/memory"
 )
@@ -539,3 +540,155 @@ var _ interface {
 	DatabaseTypeName() string
 	Nullable() (nullable, ok bool)
 } = (*sql.ColumnType)(nil)
+
+func TestWriteArrowIPCWithOptions_Dictionary(t *testing.T) {
+	db, err := createTestDB()
+	if err != nil {
+		t.Fatalf("Failed to create test DB: %v", err)
+	}
+	defer db.Close()
+
+	// 3000 rows span several record batches; status has 3 distinct values and NULLs
+	query := `
+		SELECT i AS id,
+			CASE WHEN i % 7 = 0 THEN NULL ELSE ['active', 'inactive', 'pending'][i % 3 + 1] END AS status,
+			'user' || i AS name
+		FROM range(3000) t(i)
+		ORDER BY i
+	`
+	expectedStatus := func(i int) (string, bool) {
+		if i%7 == 0 {
+			return "", false
+		}
+		return []string{"active", "inactive", "pending"}[i%3], true
+	}
+
+	read := func(opts ArrowOptions) (*arrow.Schema, []arrow.Record) {
+		rows, err := db.Query(query)
+		if err != nil {
+			t.Fatalf("Failed to query: %v", err)
+		}
+		defer rows.Close()
+
+		rec := httptest.NewRecorder()
+		if err := WriteArrowIPCWithOptions(rec, rows, opts); err != nil {
+			t.Fatalf("WriteArrowIPCWithOptions failed: %v", err)
+		}
+		reader, err := ipc.NewReader(bytes.NewReader(rec.Body.Bytes()))
+		if err != nil {
+			t.Fatalf("Failed to create Arrow IPC reader: %v", err)
+		}
+		defer reader.Release()
+
+		var records []arrow.Record
+		for reader.Next() {
+			record := reader.Record()
+			record.Retain()
+			records = append(records, record)
+		}
+		if err := reader.Err(); err != nil {
+			t.Fatalf("Failed to read Arrow IPC stream: %v", err)
+		}
+		return reader.Schema(), records
+	}
+
+	schema, records := read(ArrowOptions{DictionaryThreshold: 10})
+	if id := schema.Field(1).Type.ID(); id != arrow.DICTIONARY {
+		t.Errorf("Expected status to be dictionary-encoded, got %s", schema.Field(1).Type)
+	}
+	if id := schema.Field(2).Type.ID(); id != arrow.STRING {
+		t.Errorf("Expected high-cardinality name to stay a string, got %s", schema.Field(2).Type)
+	}
+
+	row := 0
+	for _, record := range records {
+		dict, ok := record.Column(1).(*array.Dictionary)
+		if !ok {
+			t.Fatalf("Expected a dictionary array, got %T", record.Column(1))
+		}
+		values := dict.Dictionary().(*array.String)
+		for i := 0; i < dict.Len(); i++ {
+			want, valid := expectedStatus(row)
+			if dict.IsNull(i) == valid {
+				t.Fatalf("Row %d: expected valid=%v", row, valid)
+			}
+			if valid {
+				if got := values.Value(dict.GetValueIndex(i)); got != want {
+					t.Fatalf("Row %d: expected status %q, got %q", row, want, got)
+				}
+			}
+			row++
+		}
+		record.Release()
+	}
+	if row != 3000 {
+		t.Errorf("Expected 3000 rows, got %d", row)
+	}
+
+	// A threshold below the column's cardinality, or none, keeps plain strings
+	for _, opts := range []ArrowOptions{{}, {DictionaryThreshold: 2}} {
+		schema, records := read(opts)
+		if id := schema.Field(1).Type.ID(); id != arrow.STRING {
+			t.Errorf("Threshold %d: expected status to stay a string, got %s", opts.DictionaryThreshold, schema.Field(1).Type)
+		}
+		for _, record := range records {
+			record.Release()
+		}
+	}
+}
+
+// flushCounter records the response size at each flush.
+type flushCounter struct {
+	*httptest.ResponseRecorder
+	flushedAt []int
+}
+
+func (f *flushCounter) Flush() {
+	f.flushedAt = append(f.flushedAt, f.Body.Len())
+	f.ResponseRecorder.Flush()
+}
+
+func TestWriteArrowIPCWithOptions_BatchSizeAndFlush(t *testing.T) {
+	db, err := createTestDB()
+	if err != nil {
+		t.Fatalf("Failed to create test DB: %v", err)
+	}
+	defer db.Close()
+
+	rows, err := db.Query(`SELECT i AS id, 'row' || i AS name FROM range(2500) t(i) ORDER BY i`)
+	if err != nil {
+		t.Fatalf("Failed to query: %v", err)
+	}
+	defer rows.Close()
+
+	rec := &flushCounter{ResponseRecorder: httptest.NewRecorder()}
+	if err := WriteArrowIPCWithOptions(rec, rows, ArrowOptions{BatchSize: 1000}); err != nil {
+		t.Fatalf("WriteArrowIPCWithOptions failed: %v", err)
+	}
+	if rec.Header().Get("Content-Length") != "" {
+		t.Error("Expected no Content-Length, so the response is chunked")
+	}
+
+	// One flush per batch, each after more of the stream was written
+	if len(rec.flushedAt) != 3 {
+		t.Fatalf("Expected 3 flushes, got %d", len(rec.flushedAt))
+	}
+	for i := 1; i < len(rec.flushedAt); i++ {
+		if rec.flushedAt[i] <= rec.flushedAt[i-1] {
+			t.Errorf("Expected each flush to follow a new batch, got sizes %v", rec.flushedAt)
+		}
+	}
+
+	reader, err := ipc.NewReader(bytes.NewReader(rec.Body.Bytes()))
+	if err != nil {
+		t.Fatalf("Failed to create Arrow IPC reader: %v", err)
+	}
+	defer reader.Release()
+	var sizes []int64
+	for reader.Next() {
+		sizes = append(sizes, reader.Record().NumRows())
+	}
+	if len(sizes) != 3 || sizes[0] != 1000 || sizes[1] != 1000 || sizes[2] != 500 {
+		t.Errorf("Expected batches of 1000, 1000 and 500 rows, got %v", sizes)
+	}
+}
@@ -0,0 +1,44 @@
+package formats
+
+import (
+	"strings"
+
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/charmap"
+)
+
+// DefaultCharset is the charset used for text output when none is configured or requested.
+const DefaultCharset = "utf-8"
+
+// supportedCharsets maps accepted charset names (lowercase) to their canonical name.
+// Only this whitelist may be used for transcoding output.
+var supportedCharsets = map[string]string{
+	"utf-8":        "utf-8",
+	"utf8":         "utf-8",
+	"iso-8859-1":   "iso-8859-1",
+	"latin1":       "iso-8859-1",
+	"iso-8859-15":  "iso-8859-15",
+	"latin9":       "iso-8859-15",
+	"windows-1252": "windows-1252",
+	"cp1252":       "windows-1252",
+}
+
+// charsetEncodings holds the encoder for each canonical non-UTF-8 charset.
+var charsetEncodings = map[string]encoding.Encoding{
+	"iso-8859-1":   charmap.ISO8859_1,
+	"iso-8859-15":  charmap.ISO8859_15,
+	"windows-1252": charmap.Windows1252,
+}
+
+// NormalizeCharset returns the canonical name of a supported charset.
+// Returns false if the charset is not in the whitelist.
+func NormalizeCharset(name string) (string, bool) {
+	canonical, ok := supportedCharsets[strings.ToLower(strings.TrimSpace(name))]
+	return canonical, ok
+}
+
+// charsetEncoding returns the encoding for a canonical charset name.
+// Returns nil for UTF-8, which needs no transcoding.
+func charsetEncoding(charset string) encoding.Encoding {
+	return charsetEncodings[charset]
+}
@@ -0,0 +1,41 @@
+package formats
+
+import "testing"
+
+func TestNormalizeCharset(t *testing.T) {
+	tests := []struct {
+		name   string
+		want   string
+		wantOK bool
+	}{
+		{"utf-8", "utf-8", true},
+		{"UTF8", "utf-8", true},
+		{"latin1", "iso-8859-1", true},
+		{"ISO-8859-1", "iso-8859-1", true},
+		{"latin9", "iso-8859-15", true},
+		{" windows-1252 ", "windows-1252", true},
+		{"cp1252", "windows-1252", true},
+		{"shift_jis", "", false},
+		{"", "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := NormalizeCharset(tt.name)
+			if ok != tt.wantOK || got != tt.want {
+				t.Errorf("NormalizeCharset(%q) = %q, %v; want %q, %v", tt.name, got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestCharsetEncoding(t *testing.T) {
+	if enc := charsetEncoding("utf-8"); enc != nil {
+		t.Error("Expected no encoder for utf-8")
+	}
+	for _, cs := range []string{"iso-8859-1", "iso-8859-15", "windows-1252"} {
+		if enc := charsetEncoding(cs); enc == nil {
+			t.Errorf("Expected encoder for %s", cs)
+		}
+	}
+}
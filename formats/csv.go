@@ -1,14 +1,72 @@
 package formats
 
 import (
+	"bufio"
 	"database/sql"
 	"encoding/csv"
 	"fmt"
+	"io"
 	"net/http"
+	"strings"
+
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/transform"
 )
 
-// WriteCSV writes query results as CSV.
+// CSVOptions controls the dialect of CSV output.
+type CSVOptions struct {
+	// Charset is the output charset (see NormalizeCharset). Empty uses DefaultCharset.
+	Charset string
+	// Delimiter separates fields, one of the values of CSVDelimiters.
+	// 0 uses a comma.
+	Delimiter rune
+	// QuoteAll quotes every field, not only those containing the delimiter,
+	// a quote, or a line break.
+	QuoteAll bool
+}
+
+// CSVDelimiters maps the supported delimiter names and characters to delimiters.
+var CSVDelimiters = map[string]rune{
+	",":         ',',
+	"comma":     ',',
+	";":         ';',
+	"semicolon": ';',
+	"\t":        '\t',
+	"tab":       '\t',
+	"|":         '|',
+	"pipe":      '|',
+}
+
+// WriteCSV writes query results as UTF-8 CSV.
 func WriteCSV(w http.ResponseWriter, rows *sql.Rows) error {
+	return WriteCSVWithOptions(w, rows, CSVOptions{})
+}
+
+// WriteCSVWithCharset writes query results as CSV transcoded to the given charset.
+// The charset must be one of the supported charsets (see NormalizeCharset).
+// Characters that cannot be represented in the target charset are replaced.
+func WriteCSVWithCharset(w http.ResponseWriter, rows *sql.Rows, charset string) error {
+	return WriteCSVWithOptions(w, rows, CSVOptions{Charset: charset})
+}
+
+// WriteCSVWithOptions is like WriteCSV but applies the given options.
+func WriteCSVWithOptions(w http.ResponseWriter, rows *sql.Rows, opts CSVOptions) error {
+	charset := opts.Charset
+	if charset == "" {
+		charset = DefaultCharset
+	}
+	canonical, ok := NormalizeCharset(charset)
+	if !ok {
+		return fmt.Errorf("unsupported charset: %s", charset)
+	}
+	comma := opts.Delimiter
+	if comma == 0 {
+		comma = ','
+	}
+	if comma != ',' && comma != ';' && comma != '\t' && comma != '|' {
+		return fmt.Errorf("unsupported delimiter: %q", comma)
+	}
+
 	// Get column names
 	columns, err := rows.Columns()
 	if err != nil {
@@ -16,12 +74,27 @@ func WriteCSV(w http.ResponseWriter, rows *sql.Rows) error {
 	}
 
 	// Set CSV headers
-	w.Header().Set("Content-Type", "text/csv")
-	w.Header().Set("Content-Disposition", "attachment; filename=\"export.csv\"")
+	w.Header().Set("Content-Type", "text/csv; charset="+canonical)
+	setDefaultDisposition(w, "export.csv")
 	w.WriteHeader(http.StatusOK)
 
+	// Transcode output if a non-UTF-8 charset was requested
+	var out io.Writer = w
+	if enc := charsetEncoding(canonical); enc != nil {
+		transcoder := transform.NewWriter(w, encoding.ReplaceUnsupported(enc.NewEncoder()))
+		defer transcoder.Close()
+		out = transcoder
+	}
+
 	// Create CSV writer
-	csvWriter := csv.NewWriter(w)
+	var csvWriter csvRecordWriter
+	if opts.QuoteAll {
+		csvWriter = &quoteAllWriter{w: bufio.NewWriter(out), comma: comma}
+	} else {
+		stdWriter := csv.NewWriter(out)
+		stdWriter.Comma = comma
+		csvWriter = stdWriter
+	}
 	defer csvWriter.Flush()
 
 	// Write header row
@@ -60,6 +133,46 @@ func WriteCSV(w http.ResponseWriter, rows *sql.Rows) error {
 	return nil
 }
 
+// csvRecordWriter writes CSV records; implemented by csv.Writer and quoteAllWriter.
+type csvRecordWriter interface {
+	Write(record []string) error
+	Flush()
+}
+
+// quoteAllWriter writes CSV records with every field quoted, which csv.Writer
+// cannot do. Quotes inside fields are doubled, as in RFC 4180.
+type quoteAllWriter struct {
+	w     *bufio.Writer
+	comma rune
+}
+
+func (q *quoteAllWriter) Write(record []string) error {
+	for i, field := range record {
+		if i > 0 {
+			if _, err := q.w.WriteRune(q.comma); err != nil {
+				return err
+			}
+		}
+		if _, err := q.w.WriteString(`"` + strings.ReplaceAll(field, `"`, `""`) + `"`); err != nil {
+			return err
+		}
+	}
+	_, err := q.w.WriteString("\n")
+	return err
+}
+
+func (q *quoteAllWriter) Flush() {
+	q.w.Flush()
+}
+
+// setDefaultDisposition marks the response as an attachment named filename,
+// unless the caller already set a Content-Disposition (e.g. a download link).
+func setDefaultDisposition(w http.ResponseWriter, filename string) {
+	if w.Header().Get("Content-Disposition") == "" {
+		w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+	}
+}
+
 // formatCSVValue converts a database value to a string for CSV output.
 func formatCSVValue(val interface{}) string {
 	if val == nil {
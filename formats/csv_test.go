@@ -37,8 +37,8 @@ func TestWriteCSV_BasicOutput(t *testing.T) {
 	if rec.Code != 200 {
 		t.Errorf("Expected status 200, got %d", rec.Code)
 	}
-	if ct := rec.Header().Get("Content-Type"); ct != "text/csv" {
-		t.Errorf("Expected Content-Type 'text/csv', got '%s'", ct)
+	if ct := rec.Header().Get("Content-Type"); ct != "text/csv; charset=utf-8" {
+		t.Errorf("Expected Content-Type 'text/csv; charset=utf-8', got '%s'", ct)
 	}
 	if cd := rec.Header().Get("Content-Disposition"); !strings.Contains(cd, "attachment") {
 		t.Errorf("Expected Content-Disposition with attachment, got '%s'", cd)
@@ -305,3 +305,170 @@ func BenchmarkWriteCSV(b *testing.B) {
 		rows.Close()
 	}
 }
+
+func TestWriteCSVWithCharset_Windows1252(t *testing.T) {
+	db, err := createTestDB()
+	if err != nil {
+		t.Fatalf("Failed to create test DB: %v", err)
+	}
+	defer db.Close()
+
+	rows, err := db.Query("SELECT 'Café' AS name, '€5' AS price")
+	if err != nil {
+		t.Fatalf("Failed to query: %v", err)
+	}
+	defer rows.Close()
+
+	rec := httptest.NewRecorder()
+	if err := WriteCSVWithCharset(rec, rows, "cp1252"); err != nil {
+		t.Fatalf("WriteCSVWithCharset failed: %v", err)
+	}
+
+	if ct := rec.Header().Get("Content-Type"); ct != "text/csv; charset=windows-1252" {
+		t.Errorf("Expected Content-Type 'text/csv; charset=windows-1252', got '%s'", ct)
+	}
+
+	// é is 0xE9 and € is 0x80 in windows-1252
+	want := []byte("name,price\nCaf\xe9,\x805\n")
+	if got := rec.Body.Bytes(); string(got) != string(want) {
+		t.Errorf("Expected windows-1252 bytes %q, got %q", want, got)
+	}
+}
+
+func TestWriteCSVWithCharset_Unsupported(t *testing.T) {
+	db, err := createTestDB()
+	if err != nil {
+		t.Fatalf("Failed to create test DB: %v", err)
+	}
+	defer db.Close()
+
+	rows, err := db.Query("SELECT 1 AS n")
+	if err != nil {
+		t.Fatalf("Failed to query: %v", err)
+	}
+	defer rows.Close()
+
+	rec := httptest.NewRecorder()
+	if err := WriteCSVWithCharset(rec, rows, "shift_jis"); err == nil {
+		t.Error("Expected error for unsupported charset")
+	}
+}
+
+func TestWriteCSVWithOptions_Delimiters(t *testing.T) {
+	tests := []struct {
+		name      string
+		delimiter rune
+		want      string
+	}{
+		{"semicolon", ';', "name;note\nx;\"a;b\"\ny;plain, with comma\n"},
+		{"tab", '\t', "name\tnote\nx\t\"a\tb\"\ny\tplain, with comma\n"},
+		{"pipe", '|', "name|note\nx|\"a|b\"\ny|plain, with comma\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, err := createTestDB()
+			if err != nil {
+				t.Fatalf("Failed to create test DB: %v", err)
+			}
+			defer db.Close()
+
+			// The first note contains the delimiter and must be quoted
+			sep := string(tt.delimiter)
+			rows, err := db.Query("SELECT * FROM (VALUES ('x', 'a' || CAST(? AS VARCHAR) || 'b'), ('y', 'plain, with comma')) t(name, note) ORDER BY name", sep)
+			if err != nil {
+				t.Fatalf("Failed to query: %v", err)
+			}
+			defer rows.Close()
+
+			rec := httptest.NewRecorder()
+			if err := WriteCSVWithOptions(rec, rows, CSVOptions{Delimiter: tt.delimiter}); err != nil {
+				t.Fatalf("WriteCSVWithOptions failed: %v", err)
+			}
+
+			if got := rec.Body.String(); got != tt.want {
+				t.Errorf("Expected %q, got %q", tt.want, got)
+			}
+
+			// The output parses back with the same delimiter
+			reader := csv.NewReader(strings.NewReader(rec.Body.String()))
+			reader.Comma = tt.delimiter
+			records, err := reader.ReadAll()
+			if err != nil {
+				t.Fatalf("Failed to parse CSV: %v", err)
+			}
+			if records[1][1] != "a"+sep+"b" {
+				t.Errorf("Expected note 'a%sb', got %q", sep, records[1][1])
+			}
+		})
+	}
+}
+
+func TestWriteCSVWithOptions_QuoteAll(t *testing.T) {
+	db, err := createTestDB()
+	if err != nil {
+		t.Fatalf("Failed to create test DB: %v", err)
+	}
+	defer db.Close()
+
+	rows, err := db.Query(`SELECT 1 AS id, 'say "hi"' AS note, NULL AS empty`)
+	if err != nil {
+		t.Fatalf("Failed to query: %v", err)
+	}
+	defer rows.Close()
+
+	rec := httptest.NewRecorder()
+	if err := WriteCSVWithOptions(rec, rows, CSVOptions{Delimiter: ';', QuoteAll: true}); err != nil {
+		t.Fatalf("WriteCSVWithOptions failed: %v", err)
+	}
+
+	want := "\"id\";\"note\";\"empty\"\n\"1\";\"say \"\"hi\"\"\";\"\"\n"
+	if got := rec.Body.String(); got != want {
+		t.Errorf("Expected %q, got %q", want, got)
+	}
+}
+
+func TestWriteCSVWithOptions_UnsupportedDelimiter(t *testing.T) {
+	db, err := createTestDB()
+	if err != nil {
+		t.Fatalf("Failed to create test DB: %v", err)
+	}
+	defer db.Close()
+
+	rows, err := db.Query("SELECT 1 AS n")
+	if err != nil {
+		t.Fatalf("Failed to query: %v", err)
+	}
+	defer rows.Close()
+
+	rec := httptest.NewRecorder()
+	if err := WriteCSVWithOptions(rec, rows, CSVOptions{Delimiter: ':'}); err == nil {
+		t.Error("Expected error for unsupported delimiter")
+	}
+}
+
+func TestWriteCSV_KeepsContentDisposition(t *testing.T) {
+	db, err := createTestDB()
+	if err != nil {
+		t.Fatalf("Failed to create test DB: %v", err)
+	}
+	defer db.Close()
+	if err := createTestTable(db); err != nil {
+		t.Fatalf("Failed to create test table: %v", err)
+	}
+
+	rows, err := getTestRows(db)
+	if err != nil {
+		t.Fatalf("Failed to get test rows: %v", err)
+	}
+	defer rows.Close()
+
+	rec := httptest.NewRecorder()
+	rec.Header().Set("Content-Disposition", `attachment; filename="users.csv"`)
+	if err := WriteCSV(rec, rows); err != nil {
+		t.Fatalf("WriteCSV failed: %v", err)
+	}
+	if cd := rec.Header().Get("Content-Disposition"); cd != `attachment; filename="users.csv"` {
+		t.Errorf("Expected the preset Content-Disposition to be kept, got '%s'", cd)
+	}
+}
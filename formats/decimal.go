@@ -0,0 +1,34 @@
+package formats
+
+import (
+	"encoding/json"
+
+	duckdb "github.com/duckdb/duckdb-go/v2"
+)
+
+// decimalValue returns the exact JSON representation of a DECIMAL value: a
+// string, or with asNumber a JSON number with the same digits. Neither goes
+// through float64, so no precision is lost.
+func decimalValue(d duckdb.Decimal, asNumber bool) interface{} {
+	if asNumber {
+		return json.Number(d.String())
+	}
+	return d.String()
+}
+
+// ConvertDecimals replaces the DECIMAL values of scanned rows (see ScanRows) by
+// their exact JSON representation: strings, or with asNumber JSON numbers.
+func ConvertDecimals(data []map[string]interface{}, asNumber bool) {
+	for _, row := range data {
+		convertRowDecimals(row, asNumber)
+	}
+}
+
+// convertRowDecimals is ConvertDecimals for a single row.
+func convertRowDecimals(row map[string]interface{}, asNumber bool) {
+	for col, val := range row {
+		if d, ok := val.(duckdb.Decimal); ok {
+			row[col] = decimalValue(d, asNumber)
+		}
+	}
+}
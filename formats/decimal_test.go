@@ -0,0 +1,99 @@
+package formats
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestWriteJSONWithOptions_Decimal(t *testing.T) {
+	db, err := createTestDB()
+	if err != nil {
+		t.Fatalf("Failed to create test DB: %v", err)
+	}
+	defer db.Close()
+
+	if _, err := db.Exec(`
+		CREATE TABLE prices (id INTEGER, price DECIMAL(12,2), rate DECIMAL(38,10));
+		INSERT INTO prices VALUES (1, 12345.67, 0.1000000001), (2, NULL, -3.5);
+	`); err != nil {
+		t.Fatalf("Failed to create test table: %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		opts     JSONOptions
+		expected []string
+	}{
+		{"strings by default", JSONOptions{}, []string{`"price":"12345.67"`, `"rate":"0.1000000001"`, `"price":null`, `"rate":"-3.5"`}},
+		{"exact numbers", JSONOptions{DecimalAsNumber: true}, []string{`"price":12345.67`, `"rate":0.1000000001`, `"price":null`, `"rate":-3.5`}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rows, err := db.Query("SELECT * FROM prices ORDER BY id")
+			if err != nil {
+				t.Fatalf("Failed to query: %v", err)
+			}
+			defer rows.Close()
+
+			rec := httptest.NewRecorder()
+			if err := WriteJSONWithOptions(rec, rows, 0, 0, 0, false, 0, nil, tt.opts); err != nil {
+				t.Fatalf("WriteJSONWithOptions failed: %v", err)
+			}
+			body := rec.Body.String()
+			for _, want := range tt.expected {
+				if !strings.Contains(body, want) {
+					t.Errorf("Expected %s in %s", want, body)
+				}
+			}
+			if strings.Contains(body, "12345.670000") {
+				t.Errorf("Expected no float rounding, got %s", body)
+			}
+		})
+	}
+}
+
+func TestWriteNDJSONWithOptions_Decimal(t *testing.T) {
+	db, err := createTestDB()
+	if err != nil {
+		t.Fatalf("Failed to create test DB: %v", err)
+	}
+	defer db.Close()
+
+	rows, err := db.Query("SELECT 12345.67::DECIMAL(12,2) AS price")
+	if err != nil {
+		t.Fatalf("Failed to query: %v", err)
+	}
+	defer rows.Close()
+
+	rec := httptest.NewRecorder()
+	if err := WriteNDJSONWithOptions(rec, rows, JSONOptions{DecimalAsNumber: true}); err != nil {
+		t.Fatalf("WriteNDJSONWithOptions failed: %v", err)
+	}
+	if body := strings.TrimSpace(rec.Body.String()); body != `{"price":12345.67}` {
+		t.Errorf("Expected exact number, got %s", body)
+	}
+}
+
+func TestConvertDecimals(t *testing.T) {
+	db, err := createTestDB()
+	if err != nil {
+		t.Fatalf("Failed to create test DB: %v", err)
+	}
+	defer db.Close()
+
+	rows, err := db.Query("SELECT 'a' AS name, 0.05::DECIMAL(4,2) AS fee, 7 AS n")
+	if err != nil {
+		t.Fatalf("Failed to query: %v", err)
+	}
+	defer rows.Close()
+
+	_, data, _, err := ScanRows(rows, 0)
+	if err != nil {
+		t.Fatalf("ScanRows failed: %v", err)
+	}
+	ConvertDecimals(data, false)
+	if data[0]["fee"] != "0.05" || data[0]["name"] != "a" || data[0]["n"] != int32(7) {
+		t.Errorf("Unexpected row after conversion: %#v", data[0])
+	}
+}
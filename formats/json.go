@@ -3,9 +3,11 @@ package formats
 import (
 	"database/sql"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 	"net/url"
+	"time"
 )
 
 // LinksConfig contains configuration for generating HATEOAS links.
@@ -13,65 +15,132 @@ type LinksConfig struct {
 	Enabled  bool       // Whether to include _links in response
 	BasePath string     // Base path for generating links (e.g., "/duckdb/api/users")
 	Query    url.Values // Original query parameters to preserve
+
+	// KeysetColumn links the pages of a keyset (cursor) paginated read by
+	// cursor instead of page number: first reads after=KeysetColumn, next
+	// continues with JSONOptions.NextCursor.
+	KeysetColumn string
+	// HasPrev reports whether a keyset page has a previous page, which
+	// continues with PrevCursor, or is the first page if PrevCursor is "".
+	HasPrev    bool
+	PrevCursor string
+}
+
+// ErrUnknownKeyColumn is returned when the key_by column is not part of the result.
+var ErrUnknownKeyColumn = errors.New("unknown key column")
+
+// ErrDuplicateKey is returned when rows share a key_by value and duplicates are not allowed.
+var ErrDuplicateKey = errors.New("duplicate key")
+
+// JSONOptions controls optional transformations of JSON output.
+type JSONOptions struct {
+	// KeyCase transforms the column names used as row keys (see KeyCaseCamel).
+	KeyCase string
+	// KeyBy emits data as an object keyed by this column's value instead of an array.
+	KeyBy string
+	// KeyByLastWins keeps the last row for duplicate keys instead of failing with ErrDuplicateKey.
+	KeyByLastWins bool
+	// Summary is included as the summary object when non-nil.
+	Summary map[string]map[string]interface{}
+	// Debug is included as the debug object when non-nil.
+	Debug map[string]interface{}
+	// Shape is the response envelope shape (see ShapeResult). Default is ShapeDefault.
+	Shape string
+	// DecimalAsNumber writes DECIMAL values as JSON numbers instead of strings.
+	// Both keep the exact digits; strings are safer for clients that parse
+	// numbers as floats.
+	DecimalAsNumber bool
+	// Keyset replaces the page-based pagination metadata with the limit and
+	// NextCursor, for keyset (cursor) paginated reads.
+	Keyset bool
+	// NextCursor is the cursor of the next page, or "" on the last page.
+	NextCursor string
 }
 
 // WriteJSON writes query results as JSON with pagination.
 func WriteJSON(w http.ResponseWriter, rows *sql.Rows, page, limit int, totalRows int64, paginationRequested bool, safetyLimit int, linksConfig *LinksConfig) error {
-	// Get column names
-	columns, err := rows.Columns()
+	return WriteJSONWithOptions(w, rows, page, limit, totalRows, paginationRequested, safetyLimit, linksConfig, JSONOptions{})
+}
+
+// WriteJSONWithOptions is like WriteJSON but applies the given output options.
+// Errors wrapping ErrUnknownKeyColumn or ErrDuplicateKey are returned before anything is written.
+func WriteJSONWithOptions(w http.ResponseWriter, rows *sql.Rows, page, limit int, totalRows int64, paginationRequested bool, safetyLimit int, linksConfig *LinksConfig, opts JSONOptions) error {
+	// Scan all rows (row limits are applied by the query)
+	columns, data, _, err := ScanRows(rows, 0)
 	if err != nil {
-		return fmt.Errorf("failed to get columns: %w", err)
+		return err
 	}
+	return WriteJSONDataWithOptions(w, columns, data, page, limit, totalRows, paginationRequested, safetyLimit, linksConfig, opts)
+}
 
-	// Prepare data structure
-	data := make([]map[string]interface{}, 0)
-	rowCount := 0
-
-	// Scan rows
-	for rows.Next() {
-		rowCount++
-
-		// Create a slice of interface{} to hold each column
-		values := make([]interface{}, len(columns))
-		valuePtrs := make([]interface{}, len(columns))
-		for i := range columns {
-			valuePtrs[i] = &values[i]
+// WriteJSONDataWithOptions is like WriteJSONWithOptions but writes rows that
+// were already scanned (see ScanRows). The row maps may be modified.
+func WriteJSONDataWithOptions(w http.ResponseWriter, columns []string, data []map[string]interface{}, page, limit int, totalRows int64, paginationRequested bool, safetyLimit int, linksConfig *LinksConfig, opts JSONOptions) error {
+	var keyColumn string
+	if opts.KeyBy != "" {
+		for _, col := range columns {
+			if col == opts.KeyBy || (opts.KeyCase == KeyCaseCamel && ToCamelCase(col) == opts.KeyBy) {
+				keyColumn = col
+				break
+			}
 		}
-
-		if err := rows.Scan(valuePtrs...); err != nil {
-			return fmt.Errorf("failed to scan row: %w", err)
+		if keyColumn == "" {
+			return fmt.Errorf("%w: '%s' is not a column of the result", ErrUnknownKeyColumn, opts.KeyBy)
 		}
+	}
 
-		// Create a map for this row
-		rowMap := make(map[string]interface{})
-		for i, col := range columns {
-			val := values[i]
+	ConvertDecimals(data, opts.DecimalAsNumber)
 
-			// Handle NULL values and byte arrays
-			switch v := val.(type) {
-			case nil:
-				rowMap[col] = nil
-			case []byte:
-				rowMap[col] = string(v)
-			default:
-				rowMap[col] = v
+	if mapKey := keyMapper(opts.KeyCase); mapKey != nil {
+		for i, row := range data {
+			mapped := make(map[string]interface{}, len(row))
+			for col, val := range row {
+				mapped[mapKey(col)] = val
 			}
+			data[i] = mapped
+		}
+		if keyColumn != "" {
+			keyColumn = mapKey(keyColumn)
 		}
-
-		data = append(data, rowMap)
-	}
-
-	if err := rows.Err(); err != nil {
-		return fmt.Errorf("error iterating rows: %w", err)
 	}
+	rowCount := len(data)
 
 	// Build response
 	response := map[string]interface{}{
 		"data": data,
 	}
+	if keyColumn != "" {
+		keyed, err := keyRows(data, keyColumn, opts.KeyByLastWins)
+		if err != nil {
+			return err
+		}
+		response["data"] = keyed
+	}
+	if opts.Summary != nil {
+		for _, values := range opts.Summary {
+			convertRowDecimals(values, opts.DecimalAsNumber)
+		}
+		response["summary"] = opts.Summary
+	}
+	if opts.Debug != nil {
+		response["debug"] = opts.Debug
+	}
 
 	// Add pagination metadata if requested
-	if paginationRequested && limit > 0 {
+	if opts.Keyset {
+		var nextCursor interface{}
+		if opts.NextCursor != "" {
+			nextCursor = opts.NextCursor
+		}
+		response["pagination"] = map[string]interface{}{
+			"limit":       limit,
+			"next_cursor": nextCursor,
+		}
+
+		if linksConfig != nil && linksConfig.Enabled && linksConfig.KeysetColumn != "" {
+			response["_links"] = generateKeysetLinks(linksConfig, opts.NextCursor)
+		}
+	} else if paginationRequested && limit > 0 {
 		totalPages := 0
 		if totalRows > 0 {
 			totalPages = int((totalRows + int64(limit) - 1) / int64(limit))
@@ -82,6 +151,7 @@ func WriteJSON(w http.ResponseWriter, rows *sql.Rows, page, limit int, totalRows
 			"limit":       limit,
 			"total_rows":  totalRows,
 			"total_pages": totalPages,
+			"has_next":    page < totalPages,
 		}
 
 		// Add HATEOAS links if enabled
@@ -90,13 +160,9 @@ func WriteJSON(w http.ResponseWriter, rows *sql.Rows, page, limit int, totalRows
 			response["_links"] = links
 		}
 	} else if !paginationRequested {
-		// No pagination requested - check if results were truncated by safety limit
-		truncated := false
+		// No pagination requested - check if results were truncated by safety limit.
+		// A result that exactly fills the limit is complete unless the count says otherwise.
 		if safetyLimit > 0 && int64(rowCount) >= int64(safetyLimit) && int64(rowCount) < totalRows {
-			truncated = true
-		}
-
-		if truncated {
 			response["truncated"] = true
 			response["message"] = fmt.Sprintf("Results limited to %d rows by safety limit. Use pagination (?limit=X&page=Y) to access more data.", safetyLimit)
 			response["total_available"] = totalRows
@@ -106,7 +172,101 @@ func WriteJSON(w http.ResponseWriter, rows *sql.Rows, page, limit int, totalRows
 	// Write response
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
-	return json.NewEncoder(w).Encode(response)
+	return json.NewEncoder(w).Encode(ApplyShape(opts.Shape, response))
+}
+
+// keyRows converts rows into an object keyed by the string form of the key column's value.
+func keyRows(data []map[string]interface{}, keyColumn string, lastWins bool) (map[string]interface{}, error) {
+	keyed := make(map[string]interface{}, len(data))
+	for _, row := range data {
+		key := keyString(row[keyColumn])
+		if _, exists := keyed[key]; exists && !lastWins {
+			return nil, fmt.Errorf("%w: '%s' appears more than once in column '%s'", ErrDuplicateKey, key, keyColumn)
+		}
+		keyed[key] = row
+	}
+	return keyed, nil
+}
+
+// keyString formats a value for use as a JSON object key.
+func keyString(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return "null"
+	case string:
+		return v
+	case time.Time:
+		return v.Format(time.RFC3339Nano)
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// ScanRows reads rows into a slice of column-name-keyed maps.
+// If maxRows is greater than 0, at most maxRows rows are read and truncated
+// reports whether more rows were available. Byte arrays are converted to strings.
+func ScanRows(rows *sql.Rows, maxRows int) (columns []string, data []map[string]interface{}, truncated bool, err error) {
+	// Get column names
+	columns, err = rows.Columns()
+	if err != nil {
+		return nil, nil, false, fmt.Errorf("failed to get columns: %w", err)
+	}
+
+	// Prepare data structure
+	data = make([]map[string]interface{}, 0)
+
+	// Scan rows
+	for rows.Next() {
+		if maxRows > 0 && len(data) >= maxRows {
+			truncated = true
+			break
+		}
+
+		rowMap, err := ScanRowMap(rows, columns)
+		if err != nil {
+			return nil, nil, false, err
+		}
+		data = append(data, rowMap)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, nil, false, fmt.Errorf("error iterating rows: %w", err)
+	}
+
+	return columns, data, truncated, nil
+}
+
+// ScanRowMap scans the current row into a column-name-keyed map.
+// Byte arrays are converted to strings.
+func ScanRowMap(rows *sql.Rows, columns []string) (map[string]interface{}, error) {
+	// Create a slice of interface{} to hold each column
+	values := make([]interface{}, len(columns))
+	valuePtrs := make([]interface{}, len(columns))
+	for i := range columns {
+		valuePtrs[i] = &values[i]
+	}
+
+	if err := rows.Scan(valuePtrs...); err != nil {
+		return nil, fmt.Errorf("failed to scan row: %w", err)
+	}
+
+	// Create a map for this row
+	rowMap := make(map[string]interface{}, len(columns))
+	for i, col := range columns {
+		val := values[i]
+
+		// Handle NULL values and byte arrays
+		switch v := val.(type) {
+		case nil:
+			rowMap[col] = nil
+		case []byte:
+			rowMap[col] = string(v)
+		default:
+			rowMap[col] = v
+		}
+	}
+
+	return rowMap, nil
 }
 
 // generateHATEOASLinks generates navigation links for paginated responses.
@@ -152,3 +312,49 @@ func generateHATEOASLinks(basePath string, query url.Values, page, limit, totalP
 
 	return links
 }
+
+// generateKeysetLinks generates HATEOAS links for a keyset paginated page.
+// Pages are addressed by cursor, so there is no last link, and next is omitted
+// on the last page.
+func generateKeysetLinks(config *LinksConfig, nextCursor string) map[string]string {
+	links := make(map[string]string)
+
+	// Helper to build URL with the given position parameter
+	buildURL := func(key, value string) string {
+		q := make(url.Values)
+		// Copy existing query params except the position
+		for k, values := range config.Query {
+			if k != "after" && k != "cursor" && k != "links" {
+				for _, v := range values {
+					q.Add(k, v)
+				}
+			}
+		}
+		q.Set(key, value)
+		q.Set("links", "true")
+		return fmt.Sprintf("%s?%s", config.BasePath, q.Encode())
+	}
+
+	// Self link (current position as requested)
+	if cursor := config.Query.Get("cursor"); cursor != "" {
+		links["self"] = buildURL("cursor", cursor)
+	} else {
+		links["self"] = buildURL("after", config.Query.Get("after"))
+	}
+
+	links["first"] = buildURL("after", config.KeysetColumn)
+
+	if config.HasPrev {
+		if config.PrevCursor != "" {
+			links["prev"] = buildURL("cursor", config.PrevCursor)
+		} else {
+			links["prev"] = links["first"]
+		}
+	}
+
+	if nextCursor != "" {
+		links["next"] = buildURL("cursor", nextCursor)
+	}
+
+	return links
+}
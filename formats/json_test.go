@@ -2,8 +2,10 @@ package formats
 
 import (
 	"encoding/json"
+	"errors"
 	"net/http/httptest"
 	"net/url"
+	"strings"
 	"testing"
 )
 
@@ -117,6 +119,43 @@ func TestWriteJSON_WithPagination(t *testing.T) {
 	if totalPages := pagination["total_pages"].(float64); totalPages != 10 {
 		t.Errorf("Expected total_pages 10, got %v", totalPages)
 	}
+	if hasNext := pagination["has_next"].(bool); !hasNext {
+		t.Error("Expected has_next true on page 1 of 10")
+	}
+}
+
+func TestWriteJSON_PaginationLastPage(t *testing.T) {
+	db, err := createTestDB()
+	if err != nil {
+		t.Fatalf("Failed to create test DB: %v", err)
+	}
+	defer db.Close()
+
+	rows, err := db.Query("SELECT 1 AS n")
+	if err != nil {
+		t.Fatalf("Failed to query: %v", err)
+	}
+	defer rows.Close()
+
+	rec := httptest.NewRecorder()
+	if err := WriteJSON(rec, rows, 3, 10, 21, true, 0, nil); err != nil {
+		t.Fatalf("WriteJSON failed: %v", err)
+	}
+
+	var result map[string]interface{}
+	if err := json.Unmarshal(rec.Body.Bytes(), &result); err != nil {
+		t.Fatalf("Failed to parse JSON response: %v", err)
+	}
+	pagination := result["pagination"].(map[string]interface{})
+	if totalPages := pagination["total_pages"].(float64); totalPages != 3 {
+		t.Errorf("Expected total_pages 3, got %v", totalPages)
+	}
+	if hasNext := pagination["has_next"].(bool); hasNext {
+		t.Error("Expected has_next false on the last page")
+	}
+	if _, ok := result["truncated"]; ok {
+		t.Error("Expected no 'truncated' field for paginated results")
+	}
 }
 
 func TestWriteJSON_WithHATEOASLinks(t *testing.T) {
@@ -287,11 +326,156 @@ func TestWriteJSON_SafetyLimitTruncation(t *testing.T) {
 	if !ok || !truncated {
 		t.Error("Expected 'truncated' to be true")
 	}
-	if _, ok := result["message"]; !ok {
-		t.Error("Expected 'message' field for truncated results")
+	if message, _ := result["message"].(string); !strings.Contains(message, "limited to 3 rows") {
+		t.Errorf("Expected message naming the safety limit, got %q", message)
 	}
-	if _, ok := result["total_available"]; !ok {
-		t.Error("Expected 'total_available' field for truncated results")
+	if total, _ := result["total_available"].(float64); total != 10 {
+		t.Errorf("Expected total_available 10, got %v", result["total_available"])
+	}
+	if data := result["data"].([]interface{}); len(data) != 3 {
+		t.Errorf("Expected 3 rows, got %d", len(data))
+	}
+	if _, ok := result["pagination"]; ok {
+		t.Error("Expected no 'pagination' field without requested pagination")
+	}
+}
+
+func TestWriteJSON_SafetyLimitNotTruncated(t *testing.T) {
+	tests := []struct {
+		name        string
+		safetyLimit int
+		totalRows   int64
+	}{
+		{"below limit", 10, 3},
+		{"exactly at limit", 3, 3},
+		{"no limit", 0, 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, err := createTestDB()
+			if err != nil {
+				t.Fatalf("Failed to create test DB: %v", err)
+			}
+			defer db.Close()
+
+			rows, err := db.Query("SELECT * FROM range(3) t(n)")
+			if err != nil {
+				t.Fatalf("Failed to query: %v", err)
+			}
+			defer rows.Close()
+
+			rec := httptest.NewRecorder()
+			if err := WriteJSON(rec, rows, 0, 0, tt.totalRows, false, tt.safetyLimit, nil); err != nil {
+				t.Fatalf("WriteJSON failed: %v", err)
+			}
+
+			var result map[string]interface{}
+			if err := json.Unmarshal(rec.Body.Bytes(), &result); err != nil {
+				t.Fatalf("Failed to parse JSON response: %v", err)
+			}
+			for _, field := range []string{"truncated", "message", "total_available"} {
+				if _, ok := result[field]; ok {
+					t.Errorf("Expected no '%s' field for complete results", field)
+				}
+			}
+		})
+	}
+}
+
+func TestWriteJSONWithOptions_KeyBy(t *testing.T) {
+	db, err := createTestDB()
+	if err != nil {
+		t.Fatalf("Failed to create test DB: %v", err)
+	}
+	defer db.Close()
+
+	if err := createTestTable(db); err != nil {
+		t.Fatalf("Failed to create test table: %v", err)
+	}
+	if err := insertTestData(db); err != nil {
+		t.Fatalf("Failed to insert test data: %v", err)
+	}
+
+	rows, err := getTestRows(db)
+	if err != nil {
+		t.Fatalf("Failed to get test rows: %v", err)
+	}
+	defer rows.Close()
+
+	rec := httptest.NewRecorder()
+	if err := WriteJSONWithOptions(rec, rows, 0, 0, 0, false, 0, nil, JSONOptions{KeyBy: "id"}); err != nil {
+		t.Fatalf("WriteJSONWithOptions failed: %v", err)
+	}
+
+	var result map[string]interface{}
+	if err := json.Unmarshal(rec.Body.Bytes(), &result); err != nil {
+		t.Fatalf("Failed to parse JSON: %v", err)
+	}
+	data, ok := result["data"].(map[string]interface{})
+	if !ok {
+		t.Fatalf("Expected data to be an object, got %T", result["data"])
+	}
+	if len(data) != 3 {
+		t.Fatalf("Expected 3 keys, got %d", len(data))
+	}
+	bob := data["2"].(map[string]interface{})
+	if bob["name"] != "Bob" {
+		t.Errorf("Expected row keyed '2' to be Bob, got %v", bob)
+	}
+}
+
+func TestWriteJSONWithOptions_KeyByErrors(t *testing.T) {
+	db, err := createTestDB()
+	if err != nil {
+		t.Fatalf("Failed to create test DB: %v", err)
+	}
+	defer db.Close()
+
+	if err := createTestTable(db); err != nil {
+		t.Fatalf("Failed to create test table: %v", err)
+	}
+	if err := insertTestData(db); err != nil {
+		t.Fatalf("Failed to insert test data: %v", err)
+	}
+
+	write := func(opts JSONOptions) (*httptest.ResponseRecorder, error) {
+		rows, err := db.Query("SELECT id, name, active FROM test_data ORDER BY id")
+		if err != nil {
+			t.Fatalf("Failed to query: %v", err)
+		}
+		defer rows.Close()
+		rec := httptest.NewRecorder()
+		return rec, WriteJSONWithOptions(rec, rows, 0, 0, 0, false, 0, nil, opts)
+	}
+
+	// Unknown key column
+	if _, err := write(JSONOptions{KeyBy: "missing"}); !errors.Is(err, ErrUnknownKeyColumn) {
+		t.Errorf("Expected ErrUnknownKeyColumn, got %v", err)
+	}
+
+	// Duplicate keys (active is true for Alice and Charlie)
+	rec, err := write(JSONOptions{KeyBy: "active"})
+	if !errors.Is(err, ErrDuplicateKey) {
+		t.Errorf("Expected ErrDuplicateKey, got %v", err)
+	}
+	if rec.Body.Len() != 0 {
+		t.Errorf("Expected nothing written on error, got %s", rec.Body.String())
+	}
+
+	// Last wins
+	rec, err = write(JSONOptions{KeyBy: "active", KeyByLastWins: true})
+	if err != nil {
+		t.Fatalf("Expected no error with last-wins, got %v", err)
+	}
+	var result map[string]interface{}
+	json.Unmarshal(rec.Body.Bytes(), &result)
+	data := result["data"].(map[string]interface{})
+	if len(data) != 2 {
+		t.Errorf("Expected 2 keys, got %v", data)
+	}
+	if data["true"].(map[string]interface{})["name"] != "Charlie" {
+		t.Errorf("Expected last row to win for key 'true', got %v", data["true"])
 	}
 }
 
@@ -427,3 +611,124 @@ func BenchmarkWriteJSON(b *testing.B) {
 		rows.Close()
 	}
 }
+
+func TestWriteJSONWithOptions_Keyset(t *testing.T) {
+	db, err := createTestDB()
+	if err != nil {
+		t.Fatalf("Failed to create test DB: %v", err)
+	}
+	defer db.Close()
+
+	if err := createTestTable(db); err != nil {
+		t.Fatalf("Failed to create test table: %v", err)
+	}
+	if err := insertTestData(db); err != nil {
+		t.Fatalf("Failed to insert test data: %v", err)
+	}
+
+	for _, next := range []string{"abc", ""} {
+		rows, err := getTestRows(db)
+		if err != nil {
+			t.Fatalf("Failed to get test rows: %v", err)
+		}
+		rec := httptest.NewRecorder()
+		err = WriteJSONWithOptions(rec, rows, 1, 3, 0, true, 3, nil, JSONOptions{Keyset: true, NextCursor: next})
+		rows.Close()
+		if err != nil {
+			t.Fatalf("WriteJSONWithOptions failed: %v", err)
+		}
+
+		var result map[string]interface{}
+		if err := json.Unmarshal(rec.Body.Bytes(), &result); err != nil {
+			t.Fatalf("Failed to parse JSON: %v", err)
+		}
+		pagination, ok := result["pagination"].(map[string]interface{})
+		if !ok {
+			t.Fatalf("Expected pagination metadata, got %v", result)
+		}
+		if pagination["limit"] != float64(3) {
+			t.Errorf("Expected limit 3, got %v", pagination["limit"])
+		}
+		if _, ok := pagination["page"]; ok {
+			t.Errorf("Expected no page-based metadata, got %v", pagination)
+		}
+		cursor, present := pagination["next_cursor"]
+		if !present {
+			t.Fatalf("Expected a next_cursor key, got %v", pagination)
+		}
+		if next == "" && cursor != nil {
+			t.Errorf("Expected a null next_cursor on the last page, got %v", cursor)
+		}
+		if next != "" && cursor != next {
+			t.Errorf("Expected next_cursor %q, got %v", next, cursor)
+		}
+	}
+}
+
+func TestGenerateKeysetLinks(t *testing.T) {
+	config := &LinksConfig{
+		Enabled:      true,
+		BasePath:     "/api/users",
+		Query:        url.Values{"cursor": []string{"cur2"}, "limit": []string{"10"}, "filter": []string{"age:gt:18"}},
+		KeysetColumn: "id",
+		HasPrev:      true,
+		PrevCursor:   "cur1",
+	}
+	links := generateKeysetLinks(config, "cur3")
+
+	want := map[string]url.Values{
+		"self":  {"cursor": []string{"cur2"}},
+		"first": {"after": []string{"id"}},
+		"prev":  {"cursor": []string{"cur1"}},
+		"next":  {"cursor": []string{"cur3"}},
+	}
+	for name, position := range want {
+		link, ok := links[name]
+		if !ok {
+			t.Errorf("Expected %q link, got %v", name, links)
+			continue
+		}
+		parsed, err := url.Parse(link)
+		if err != nil {
+			t.Fatalf("Failed to parse %s link: %v", name, err)
+		}
+		q := parsed.Query()
+		if parsed.Path != "/api/users" || q.Get("limit") != "10" || q.Get("filter") != "age:gt:18" || q.Get("links") != "true" {
+			t.Errorf("Expected %s link to preserve the query, got %s", name, link)
+		}
+		for key, values := range position {
+			if q.Get(key) != values[0] {
+				t.Errorf("Expected %s link with %s=%s, got %s", name, key, values[0], link)
+			}
+		}
+		if q.Has("after") && q.Has("cursor") {
+			t.Errorf("Expected %s link to carry a single position, got %s", name, link)
+		}
+	}
+	if _, ok := links["last"]; ok {
+		t.Error("Expected no last link for keyset pages")
+	}
+
+	// The page after the first page links back to the first page
+	config.PrevCursor = ""
+	if links := generateKeysetLinks(config, ""); links["prev"] != links["first"] {
+		t.Errorf("Expected prev to be the first page, got %v", links)
+	}
+	if _, ok := links["next"]; !ok {
+		t.Error("Expected next link when there is a next cursor")
+	}
+
+	// The first page has neither prev nor, when it is also the last, next
+	config.HasPrev = false
+	config.Query = url.Values{"after": []string{"id"}}
+	links = generateKeysetLinks(config, "")
+	if _, ok := links["prev"]; ok {
+		t.Errorf("Expected no prev link on the first page, got %v", links)
+	}
+	if _, ok := links["next"]; ok {
+		t.Errorf("Expected no next link on the last page, got %v", links)
+	}
+	if links["self"] != links["first"] {
+		t.Errorf("Expected self to be the first page, got %v", links)
+	}
+}
@@ -0,0 +1,84 @@
+package formats
+
+import (
+	"strings"
+	"unicode"
+)
+
+// Supported JSON key casing modes.
+const (
+	KeyCaseNone  = "none"  // column names are passed through unchanged
+	KeyCaseCamel = "camel" // snake_case column names are written as camelCase
+)
+
+// IsValidKeyCase reports whether the key casing mode is supported.
+func IsValidKeyCase(keyCase string) bool {
+	return keyCase == "" || keyCase == KeyCaseNone || keyCase == KeyCaseCamel
+}
+
+// ToCamelCase converts a snake_case name to camelCase (e.g. "created_at" -> "createdAt").
+// Names that would not convert back to the same snake_case name (such as "address_2",
+// "_id" or "HTTPCode") are returned unchanged, so the mapping is always lossless.
+func ToCamelCase(name string) string {
+	if !strings.Contains(name, "_") {
+		return name
+	}
+	parts := strings.Split(name, "_")
+	var b strings.Builder
+	b.Grow(len(name))
+	b.WriteString(parts[0])
+	for _, part := range parts[1:] {
+		if part == "" {
+			return name
+		}
+		runes := []rune(part)
+		runes[0] = unicode.ToUpper(runes[0])
+		b.WriteString(string(runes))
+	}
+	camel := b.String()
+	if ToSnakeCase(camel) != name {
+		return name
+	}
+	return camel
+}
+
+// ToSnakeCase converts a camelCase name to snake_case (e.g. "createdAt" -> "created_at").
+// Names without uppercase letters are returned unchanged.
+func ToSnakeCase(name string) string {
+	if strings.IndexFunc(name, unicode.IsUpper) == -1 {
+		return name
+	}
+	var b strings.Builder
+	b.Grow(len(name) + 4)
+	for i, r := range name {
+		if unicode.IsUpper(r) {
+			if i > 0 {
+				b.WriteByte('_')
+			}
+			r = unicode.ToLower(r)
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// keyMapper returns the output key transformation for a key casing mode, or nil for pass-through.
+func keyMapper(keyCase string) func(string) string {
+	if keyCase == KeyCaseCamel {
+		return ToCamelCase
+	}
+	return nil
+}
+
+// MapInputKeys converts the keys of a request body back to column names for the
+// given key casing mode. Keys that are already snake_case are accepted as-is.
+func MapInputKeys(data map[string]interface{}, keyCase string) map[string]interface{} {
+	if keyCase != KeyCaseCamel {
+		return data
+	}
+	mapped := make(map[string]interface{}, len(data))
+	for key, value := range data {
+		mapped[ToSnakeCase(key)] = value
+	}
+	return mapped
+}
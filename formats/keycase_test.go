@@ -0,0 +1,114 @@
+package formats
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestToCamelCase(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"id", "id"},
+		{"created_at", "createdAt"},
+		{"user_account_id", "userAccountId"},
+		{"address_2", "address_2"}, // digits cannot be round-tripped
+		{"_private", "_private"},   // leading underscore
+		{"trailing_", "trailing_"}, // trailing underscore
+		{"double__under", "double__under"},
+		{"HTTP_code", "HTTP_code"}, // uppercase cannot be round-tripped
+		{"userId", "userId"},       // already camelCase
+	}
+
+	for _, tt := range tests {
+		if got := ToCamelCase(tt.input); got != tt.expected {
+			t.Errorf("ToCamelCase(%q) = %q, expected %q", tt.input, got, tt.expected)
+		}
+	}
+}
+
+func TestToSnakeCase(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"id", "id"},
+		{"createdAt", "created_at"},
+		{"userAccountId", "user_account_id"},
+		{"created_at", "created_at"},
+		{"address_2", "address_2"},
+	}
+
+	for _, tt := range tests {
+		if got := ToSnakeCase(tt.input); got != tt.expected {
+			t.Errorf("ToSnakeCase(%q) = %q, expected %q", tt.input, got, tt.expected)
+		}
+	}
+}
+
+func TestKeyCase_RoundTrip(t *testing.T) {
+	names := []string{"id", "created_at", "first_name", "is_active", "user_account_id", "address_2", "_id", "a_b_c"}
+	for _, name := range names {
+		if got := ToSnakeCase(ToCamelCase(name)); got != name {
+			t.Errorf("Round trip of %q produced %q", name, got)
+		}
+	}
+}
+
+func TestMapInputKeys(t *testing.T) {
+	data := map[string]interface{}{"firstName": "Ada", "last_name": "Lovelace", "id": 1}
+
+	mapped := MapInputKeys(data, KeyCaseCamel)
+	for _, key := range []string{"first_name", "last_name", "id"} {
+		if _, ok := mapped[key]; !ok {
+			t.Errorf("Expected key %q in %v", key, mapped)
+		}
+	}
+
+	if passthrough := MapInputKeys(data, KeyCaseNone); passthrough["firstName"] != "Ada" {
+		t.Errorf("Expected keys unchanged without key casing, got %v", passthrough)
+	}
+}
+
+func TestWriteJSONWithOptions_CamelKeys(t *testing.T) {
+	db, err := createTestDB()
+	if err != nil {
+		t.Fatalf("Failed to create test DB: %v", err)
+	}
+	defer db.Close()
+
+	if err := createTestTable(db); err != nil {
+		t.Fatalf("Failed to create test table: %v", err)
+	}
+	if err := insertTestData(db); err != nil {
+		t.Fatalf("Failed to insert test data: %v", err)
+	}
+
+	rows, err := getTestRows(db)
+	if err != nil {
+		t.Fatalf("Failed to get test rows: %v", err)
+	}
+	defer rows.Close()
+
+	rec := httptest.NewRecorder()
+	if err := WriteJSONWithOptions(rec, rows, 0, 0, 0, false, 0, nil, JSONOptions{KeyCase: KeyCaseCamel}); err != nil {
+		t.Fatalf("WriteJSONWithOptions failed: %v", err)
+	}
+
+	var result map[string]interface{}
+	if err := json.Unmarshal(rec.Body.Bytes(), &result); err != nil {
+		t.Fatalf("Failed to parse JSON: %v", err)
+	}
+	row := result["data"].([]interface{})[0].(map[string]interface{})
+	if _, ok := row["createdAt"]; !ok {
+		t.Errorf("Expected 'createdAt' key, got %v", row)
+	}
+	if _, ok := row["created_at"]; ok {
+		t.Errorf("Expected 'created_at' to be renamed, got %v", row)
+	}
+	if row["name"] != "Alice" {
+		t.Errorf("Expected single-word keys unchanged, got %v", row)
+	}
+}
@@ -0,0 +1,59 @@
+package formats
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+)
+
+// NDJSONContentType is the content type of newline-delimited JSON responses.
+const NDJSONContentType = "application/x-ndjson"
+
+// ndjsonFlushInterval is the number of rows written between flushes.
+const ndjsonFlushInterval = 1000
+
+// WriteNDJSON streams query results as newline-delimited JSON, one object per row.
+// Rows are encoded as they are read instead of being buffered, and the response
+// is flushed periodically if the writer supports it.
+func WriteNDJSON(w http.ResponseWriter, rows *sql.Rows) error {
+	return WriteNDJSONWithOptions(w, rows, JSONOptions{})
+}
+
+// WriteNDJSONWithOptions is like WriteNDJSON but applies the DecimalAsNumber
+// option. The other options describe the JSON envelope and do not apply.
+func WriteNDJSONWithOptions(w http.ResponseWriter, rows *sql.Rows, opts JSONOptions) error {
+	columns, err := rows.Columns()
+	if err != nil {
+		return fmt.Errorf("failed to get columns: %w", err)
+	}
+
+	w.Header().Set("Content-Type", NDJSONContentType)
+	w.WriteHeader(http.StatusOK)
+
+	flusher, _ := w.(http.Flusher)
+	encoder := json.NewEncoder(w)
+	count := 0
+	for rows.Next() {
+		row, err := ScanRowMap(rows, columns)
+		if err != nil {
+			return err
+		}
+		convertRowDecimals(row, opts.DecimalAsNumber)
+		if err := encoder.Encode(row); err != nil {
+			return fmt.Errorf("failed to write row: %w", err)
+		}
+		count++
+		if flusher != nil && count%ndjsonFlushInterval == 0 {
+			flusher.Flush()
+		}
+	}
+
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("error iterating rows: %w", err)
+	}
+	if flusher != nil {
+		flusher.Flush()
+	}
+	return nil
+}
@@ -0,0 +1,99 @@
+package formats
+
+import (
+	"bufio"
+	"encoding/json"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestWriteNDJSON_BasicOutput(t *testing.T) {
+	db, err := createTestDB()
+	if err != nil {
+		t.Fatalf("Failed to create test DB: %v", err)
+	}
+	defer db.Close()
+
+	if err := createTestTable(db); err != nil {
+		t.Fatalf("Failed to create test table: %v", err)
+	}
+	if err := insertTestData(db); err != nil {
+		t.Fatalf("Failed to insert test data: %v", err)
+	}
+	if err := insertNullData(db); err != nil {
+		t.Fatalf("Failed to insert null data: %v", err)
+	}
+
+	rows, err := getTestRows(db)
+	if err != nil {
+		t.Fatalf("Failed to get test rows: %v", err)
+	}
+	defer rows.Close()
+
+	rec := httptest.NewRecorder()
+	if err := WriteNDJSON(rec, rows); err != nil {
+		t.Fatalf("WriteNDJSON failed: %v", err)
+	}
+
+	if rec.Code != 200 {
+		t.Errorf("Expected status 200, got %d", rec.Code)
+	}
+	if ct := rec.Header().Get("Content-Type"); ct != NDJSONContentType {
+		t.Errorf("Expected Content-Type %q, got %q", NDJSONContentType, ct)
+	}
+	if !rec.Flushed {
+		t.Error("Expected the response to be flushed")
+	}
+
+	// Parse each line back into a record
+	var records []map[string]interface{}
+	scanner := bufio.NewScanner(strings.NewReader(rec.Body.String()))
+	for scanner.Scan() {
+		var record map[string]interface{}
+		if err := json.Unmarshal(scanner.Bytes(), &record); err != nil {
+			t.Fatalf("Failed to parse line %q: %v", scanner.Text(), err)
+		}
+		records = append(records, record)
+	}
+
+	if len(records) != 4 {
+		t.Fatalf("Expected 4 records, got %d", len(records))
+	}
+	if records[0]["name"] != "Alice" || records[0]["age"] != float64(30) {
+		t.Errorf("Unexpected first record: %v", records[0])
+	}
+	if records[2]["name"] != "Charlie" || records[2]["active"] != true {
+		t.Errorf("Unexpected third record: %v", records[2])
+	}
+	if records[3]["name"] != nil {
+		t.Errorf("Expected null name in the last record, got %v", records[3]["name"])
+	}
+}
+
+func TestWriteNDJSON_EmptyResult(t *testing.T) {
+	db, err := createTestDB()
+	if err != nil {
+		t.Fatalf("Failed to create test DB: %v", err)
+	}
+	defer db.Close()
+
+	if err := createTestTable(db); err != nil {
+		t.Fatalf("Failed to create test table: %v", err)
+	}
+
+	rows, err := getEmptyRows(db)
+	if err != nil {
+		t.Fatalf("Failed to get empty rows: %v", err)
+	}
+	defer rows.Close()
+
+	rec := httptest.NewRecorder()
+	if err := WriteNDJSON(rec, rows); err != nil {
+		t.Fatalf("WriteNDJSON failed: %v", err)
+	}
+
+	if rec.Body.Len() != 0 {
+		t.Errorf("Expected an empty body, got %q", rec.Body.String())
+	}
+}
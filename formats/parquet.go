@@ -6,16 +6,62 @@ import (
 	"net/http"
 
 	"github.com/apache/arrow/go/v18/arrow"
-	"github.com/apache/arrow/go/v18/arrow/array"
 	"github.com/apache/arrow/go/v18/arrow/memory"
 	"github.com/apache/arrow/go/v18/parquet"
 	"github.com/apache/arrow/go/v18/parquet/compress"
 	"github.com/apache/arrow/go/v18/parquet/pqarrow"
 )
 
+// DefaultParquetRowGroupSize is the number of rows per Parquet row group when
+// none is configured (the same as DuckDB's own Parquet writer).
+const DefaultParquetRowGroupSize = 122880
+
+// ParquetOptions controls the layout of Parquet output.
+type ParquetOptions struct {
+	// RowGroupSize is the number of rows per row group. Rows are read and
+	// written one row group at a time, so it also bounds the rows held in
+	// memory. 0 uses DefaultParquetRowGroupSize.
+	RowGroupSize int
+	// Compression is the codec of the column chunks, one of the keys of
+	// ParquetCompressionCodecs. Empty uses DefaultParquetCompression.
+	Compression string
+}
+
+// DefaultParquetCompression is the codec used when none is requested.
+const DefaultParquetCompression = "snappy"
+
+// ParquetCompressionCodecs maps the supported compression names to their codecs.
+var ParquetCompressionCodecs = map[string]compress.Compression{
+	"zstd":   compress.Codecs.Zstd,
+	"snappy": compress.Codecs.Snappy,
+	"gzip":   compress.Codecs.Gzip,
+	"none":   compress.Codecs.Uncompressed,
+}
+
 // WriteParquet writes query results as Parquet format.
-// This function converts SQL rows to Arrow Table and then writes to Parquet format.
+// This function converts SQL rows to Arrow record batches and then writes them to Parquet format.
 func WriteParquet(w http.ResponseWriter, rows *sql.Rows) error {
+	return WriteParquetWithOptions(w, rows, ParquetOptions{})
+}
+
+// WriteParquetWithOptions is like WriteParquet but applies the given options.
+// Each record batch of RowGroupSize rows is written as one row group as soon
+// as it is read. The first batch is read before anything is written, so a
+// failing query still produces an error response.
+func WriteParquetWithOptions(w http.ResponseWriter, rows *sql.Rows, opts ParquetOptions) error {
+	rowGroupSize := opts.RowGroupSize
+	if rowGroupSize <= 0 {
+		rowGroupSize = DefaultParquetRowGroupSize
+	}
+	compression := opts.Compression
+	if compression == "" {
+		compression = DefaultParquetCompression
+	}
+	codec, ok := ParquetCompressionCodecs[compression]
+	if !ok {
+		return fmt.Errorf("unsupported parquet compression: %s", compression)
+	}
+
 	// Get column types and names
 	columnTypes, err := rows.ColumnTypes()
 	if err != nil {
@@ -42,61 +88,16 @@ func WriteParquet(w http.ResponseWriter, rows *sql.Rows) error {
 	// Create memory allocator
 	pool := memory.NewGoAllocator()
 
-	// Collect all rows into record batches
-	const batchSize = 10000 // Larger batch size for Parquet
-	var recordBatches []arrow.Record
-
-	for {
-		record, hasMore, err := buildRecordBatch(rows, schema, pool, batchSize, columnTypes)
-		if err != nil {
-			// Clean up any previously created records
-			for _, r := range recordBatches {
-				r.Release()
-			}
-			return fmt.Errorf("failed to build record batch: %w", err)
-		}
-
-		if record == nil {
-			break
-		}
-
-		recordBatches = append(recordBatches, record)
-
-		if !hasMore {
-			break
-		}
-	}
-
-	// If no data, create an empty record with proper column arrays
-	if len(recordBatches) == 0 {
-		// Create empty arrays for each column in the schema
-		emptyArrays := make([]arrow.Array, len(schema.Fields()))
-		for i, field := range schema.Fields() {
-			builder := array.NewBuilder(pool, field.Type)
-			emptyArrays[i] = builder.NewArray()
-			builder.Release()
-		}
-		emptyBatch := array.NewRecord(schema, emptyArrays, 0)
-		// Release the empty arrays (record holds references)
-		for _, arr := range emptyArrays {
-			arr.Release()
-		}
-		recordBatches = append(recordBatches, emptyBatch)
-	}
-
-	// Create Arrow Table from record batches
-	table := array.NewTableFromRecords(schema, recordBatches)
-	defer table.Release()
-
-	// Release record batches (table holds references)
-	for _, record := range recordBatches {
-		record.Release()
+	record, hasMore, err := buildRecordBatch(rows, schema, pool, rowGroupSize, columnTypes)
+	if err != nil {
+		return fmt.Errorf("failed to build record batch: %w", err)
 	}
 
 	// Configure Parquet writer properties
 	writerProps := parquet.NewWriterProperties(
-		parquet.WithCompression(compress.Codecs.Snappy), // Use Snappy compression
-		parquet.WithDictionaryDefault(true),             // Enable dictionary encoding
+		parquet.WithCompression(codec),      // Snappy unless requested otherwise
+		parquet.WithDictionaryDefault(true), // Enable dictionary encoding
+		parquet.WithMaxRowGroupLength(int64(rowGroupSize)),
 	)
 
 	arrowWriterProps := pqarrow.NewArrowWriterProperties(
@@ -105,14 +106,37 @@ func WriteParquet(w http.ResponseWriter, rows *sql.Rows) error {
 
 	// Set content type for Parquet
 	w.Header().Set("Content-Type", "application/parquet")
-	w.Header().Set("Content-Disposition", "attachment; filename=\"query_result.parquet\"")
+	setDefaultDisposition(w, "query_result.parquet")
 	w.WriteHeader(http.StatusOK)
 
-	// Write table to Parquet format directly to HTTP response
-	err = pqarrow.WriteTable(table, w, table.NumRows(), writerProps, arrowWriterProps)
+	writer, err := pqarrow.NewFileWriter(schema, w, writerProps, arrowWriterProps)
 	if err != nil {
-		return fmt.Errorf("failed to write parquet: %w", err)
+		if record != nil {
+			record.Release()
+		}
+		return fmt.Errorf("failed to create parquet writer: %w", err)
 	}
 
+	// Write one row group per batch, directly to the HTTP response
+	for record != nil {
+		err = writer.Write(record)
+		record.Release()
+		if err != nil {
+			writer.Close()
+			return fmt.Errorf("failed to write parquet: %w", err)
+		}
+		if !hasMore {
+			break
+		}
+		record, hasMore, err = buildRecordBatch(rows, schema, pool, rowGroupSize, columnTypes)
+		if err != nil {
+			writer.Close()
+			return fmt.Errorf("failed to build record batch: %w", err)
+		}
+	}
+
+	if err := writer.Close(); err != nil {
+		return fmt.Errorf("failed to write parquet: %w", err)
+	}
 	return nil
 }
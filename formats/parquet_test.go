@@ -6,6 +6,7 @@ import (
 	"strings"
 	"testing"
 
+	"github.com/apache/arrow/go/v18/parquet/compress"
 	"github.com/apache/arrow/go/v18/parquet/file"
 )
 
@@ -260,17 +261,115 @@ func TestWriteParquet_Compression(t *testing.T) {
 	}
 
 	// Verify the file uses Snappy compression by checking metadata
+	if codec := parquetCodec(t, rec.Body.Bytes(), 3); codec != compress.Codecs.Snappy {
+		t.Errorf("Expected Snappy compression by default, got %s", codec)
+	}
+}
+
+func TestWriteParquetWithOptions_Compression(t *testing.T) {
+	db, err := createTestDB()
+	if err != nil {
+		t.Fatalf("Failed to create test DB: %v", err)
+	}
+	defer db.Close()
+
+	if err := createTestTable(db); err != nil {
+		t.Fatalf("Failed to create test table: %v", err)
+	}
+	if err := insertTestData(db); err != nil {
+		t.Fatalf("Failed to insert test data: %v", err)
+	}
+
+	for name, expected := range ParquetCompressionCodecs {
+		t.Run(name, func(t *testing.T) {
+			rows, err := getTestRows(db)
+			if err != nil {
+				t.Fatalf("Failed to get test rows: %v", err)
+			}
+			defer rows.Close()
+
+			rec := httptest.NewRecorder()
+			if err := WriteParquetWithOptions(rec, rows, ParquetOptions{Compression: name}); err != nil {
+				t.Fatalf("WriteParquetWithOptions failed: %v", err)
+			}
+			if codec := parquetCodec(t, rec.Body.Bytes(), 3); codec != expected {
+				t.Errorf("Expected %s compression, got %s", expected, codec)
+			}
+		})
+	}
+
+	rows, err := getTestRows(db)
+	if err != nil {
+		t.Fatalf("Failed to get test rows: %v", err)
+	}
+	defer rows.Close()
+	if err := WriteParquetWithOptions(httptest.NewRecorder(), rows, ParquetOptions{Compression: "lzo"}); err == nil {
+		t.Error("Expected an error for an unsupported codec")
+	}
+}
+
+// parquetCodec reads back a Parquet file, checks its row count, and returns
+// the codec of every column chunk, failing if they differ.
+func parquetCodec(t *testing.T, data []byte, expectedRows int64) compress.Compression {
+	t.Helper()
+	reader, err := file.NewParquetReader(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("Failed to create Parquet reader: %v", err)
+	}
+	defer reader.Close()
+
+	if reader.NumRows() != expectedRows {
+		t.Errorf("Expected %d rows, got %d", expectedRows, reader.NumRows())
+	}
+	rowGroup := reader.MetaData().RowGroup(0)
+	var codec compress.Compression
+	for i := 0; i < rowGroup.NumColumns(); i++ {
+		chunk, err := rowGroup.ColumnChunk(i)
+		if err != nil {
+			t.Fatalf("Failed to read column chunk metadata: %v", err)
+		}
+		if i > 0 && chunk.Compression() != codec {
+			t.Fatalf("Column %d uses %s, column 0 uses %s", i, chunk.Compression(), codec)
+		}
+		codec = chunk.Compression()
+	}
+	return codec
+}
+
+func TestWriteParquet_RowGroups(t *testing.T) {
+	db, err := createTestDB()
+	if err != nil {
+		t.Fatalf("Failed to create test DB: %v", err)
+	}
+	defer db.Close()
+
+	rows, err := db.Query("SELECT range AS id, 'value ' || range AS value FROM range(2500)")
+	if err != nil {
+		t.Fatalf("Failed to query rows: %v", err)
+	}
+	defer rows.Close()
+
+	rec := httptest.NewRecorder()
+	if err := WriteParquetWithOptions(rec, rows, ParquetOptions{RowGroupSize: 1000}); err != nil {
+		t.Fatalf("WriteParquetWithOptions failed: %v", err)
+	}
+
 	reader, err := file.NewParquetReader(bytes.NewReader(rec.Body.Bytes()))
 	if err != nil {
 		t.Fatalf("Failed to create Parquet reader: %v", err)
 	}
 	defer reader.Close()
 
-	// The file should be valid and readable regardless of compression
-	// We can't easily check compression type without diving deeper into metadata
-	// but we can verify the file is readable which means compression/decompression works
-	if reader.NumRows() != 3 {
-		t.Errorf("Expected 3 rows, got %d", reader.NumRows())
+	if reader.NumRows() != 2500 {
+		t.Errorf("Expected 2500 rows, got %d", reader.NumRows())
+	}
+	if reader.NumRowGroups() != 3 {
+		t.Fatalf("Expected 3 row groups, got %d", reader.NumRowGroups())
+	}
+	for i, expected := range []int64{1000, 1000, 500} {
+		if n := reader.RowGroup(i).NumRows(); n != expected {
+			t.Errorf("Expected %d rows in row group %d, got %d", expected, i, n)
+		}
 	}
 }
 
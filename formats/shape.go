@@ -0,0 +1,36 @@
+package formats
+
+// Supported JSON response shapes. Shapes are a fixed set of envelope variants
+// for clients that expect an existing API contract; the rows themselves are
+// written the same way in every shape.
+const (
+	ShapeDefault = "default" // {"data": [...], "pagination": {...}, ...}
+	ShapeResult  = "result"  // the default envelope wrapped as {"result": {...}}
+	ShapeItems   = "items"   // the default envelope with "data" renamed to "items"
+)
+
+// IsValidShape reports whether the response shape is supported.
+func IsValidShape(shape string) bool {
+	switch shape {
+	case "", ShapeDefault, ShapeResult, ShapeItems:
+		return true
+	}
+	return false
+}
+
+// ApplyShape transforms a fully built JSON response envelope into the given
+// shape. It runs after the envelope is assembled and before it is encoded, so
+// it only moves top-level members and never touches row data. Unknown shapes
+// and the default shape return the envelope unchanged.
+func ApplyShape(shape string, response map[string]interface{}) map[string]interface{} {
+	switch shape {
+	case ShapeResult:
+		return map[string]interface{}{"result": response}
+	case ShapeItems:
+		if data, ok := response["data"]; ok {
+			delete(response, "data")
+			response["items"] = data
+		}
+	}
+	return response
+}
@@ -0,0 +1,99 @@
+package formats
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestIsValidShape(t *testing.T) {
+	for _, shape := range []string{"", ShapeDefault, ShapeResult, ShapeItems} {
+		if !IsValidShape(shape) {
+			t.Errorf("Expected %q to be valid", shape)
+		}
+	}
+	for _, shape := range []string{"Result", "rows", "{result: .}"} {
+		if IsValidShape(shape) {
+			t.Errorf("Expected %q to be invalid", shape)
+		}
+	}
+}
+
+// writeShapedJSON writes the three test rows with pagination in the given shape.
+func writeShapedJSON(t *testing.T, shape string) map[string]interface{} {
+	t.Helper()
+	db, err := createTestDB()
+	if err != nil {
+		t.Fatalf("Failed to create test DB: %v", err)
+	}
+	defer db.Close()
+	if err := createTestTable(db); err != nil {
+		t.Fatalf("Failed to create test table: %v", err)
+	}
+	if err := insertTestData(db); err != nil {
+		t.Fatalf("Failed to insert test data: %v", err)
+	}
+	rows, err := getTestRows(db)
+	if err != nil {
+		t.Fatalf("Failed to get test rows: %v", err)
+	}
+	defer rows.Close()
+
+	rec := httptest.NewRecorder()
+	if err := WriteJSONWithOptions(rec, rows, 1, 10, 3, true, 0, nil, JSONOptions{Shape: shape}); err != nil {
+		t.Fatalf("WriteJSONWithOptions failed: %v", err)
+	}
+	var result map[string]interface{}
+	if err := json.Unmarshal(rec.Body.Bytes(), &result); err != nil {
+		t.Fatalf("Failed to parse JSON: %v", err)
+	}
+	return result
+}
+
+func TestWriteJSONWithOptions_Shapes(t *testing.T) {
+	t.Run("default", func(t *testing.T) {
+		for _, shape := range []string{"", ShapeDefault} {
+			result := writeShapedJSON(t, shape)
+			if data, ok := result["data"].([]interface{}); !ok || len(data) != 3 {
+				t.Errorf("Expected 3 rows in data, got %v", result["data"])
+			}
+			if _, ok := result["pagination"]; !ok {
+				t.Error("Expected pagination at the top level")
+			}
+		}
+	})
+
+	t.Run("result", func(t *testing.T) {
+		result := writeShapedJSON(t, ShapeResult)
+		if len(result) != 1 {
+			t.Fatalf("Expected only the result member, got %v", result)
+		}
+		inner, ok := result["result"].(map[string]interface{})
+		if !ok {
+			t.Fatalf("Expected result to be an object, got %T", result["result"])
+		}
+		if data, ok := inner["data"].([]interface{}); !ok || len(data) != 3 {
+			t.Errorf("Expected 3 rows in result.data, got %v", inner["data"])
+		}
+		if _, ok := inner["pagination"]; !ok {
+			t.Error("Expected pagination inside result")
+		}
+	})
+
+	t.Run("items", func(t *testing.T) {
+		result := writeShapedJSON(t, ShapeItems)
+		if _, ok := result["data"]; ok {
+			t.Error("Expected data to be renamed")
+		}
+		items, ok := result["items"].([]interface{})
+		if !ok || len(items) != 3 {
+			t.Fatalf("Expected 3 rows in items, got %v", result["items"])
+		}
+		if first := items[0].(map[string]interface{}); first["name"] != "Alice" {
+			t.Errorf("Expected rows to be unchanged, got %v", first)
+		}
+		if _, ok := result["pagination"]; !ok {
+			t.Error("Expected pagination at the top level")
+		}
+	})
+}
@@ -0,0 +1,136 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/tobilg/caddy-duckdb-module/auth"
+	"github.com/tobilg/caddy-duckdb-module/formats"
+	"go.uber.org/zap"
+)
+
+// maxBatchQueries is the maximum number of statements accepted in a single batch.
+const maxBatchQueries = 50
+
+// BatchQuery is a single statement in a batch request.
+type BatchQuery struct {
+	Name   string        `json:"name,omitempty"`
+	SQL    string        `json:"sql"`
+	Params []interface{} `json:"params,omitempty"`
+}
+
+// handleBatch executes a batch of read-only queries in order and returns all result sets.
+// Request body format:
+//
+//	{
+//	  "queries": [
+//	    {"name": "users", "sql": "SELECT * FROM users"},
+//	    {"sql": "SELECT COUNT(*) AS n FROM orders WHERE status = $1", "params": ["open"]}
+//	  ]
+//	}
+//
+// Results are keyed by name, or by index for unnamed queries.
+func (h *QueryHandler) handleBatch(w http.ResponseWriter, r *http.Request) {
+	requestID := auth.GetRequestIDFromContext(r.Context())
+
+	if r.Method != http.MethodPost {
+		h.sendErrorWithRequest(w, r, "Method not allowed. Use POST to execute a query batch.", http.StatusMethodNotAllowed)
+		return
+	}
+
+	defer r.Body.Close()
+
+	var req struct {
+		Queries []BatchQuery `json:"queries"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.sendErrorWithRequest(w, r, "Invalid JSON in request body", http.StatusBadRequest)
+		return
+	}
+
+	if len(req.Queries) == 0 {
+		h.sendErrorWithRequest(w, r, "At least one query is required", http.StatusBadRequest)
+		return
+	}
+	if len(req.Queries) > maxBatchQueries {
+		h.sendErrorWithRequest(w, r, fmt.Sprintf("Too many queries in batch: %d (maximum %d)", len(req.Queries), maxBatchQueries), http.StatusBadRequest)
+		return
+	}
+
+	// Validate every statement before executing any of them
+	keys := make([]string, len(req.Queries))
+	seen := make(map[string]bool, len(req.Queries))
+	for i, q := range req.Queries {
+		key := q.Name
+		if key == "" {
+			key = strconv.Itoa(i)
+		}
+		if seen[key] {
+			h.sendErrorWithRequest(w, r, fmt.Sprintf("Duplicate query name '%s'", key), http.StatusBadRequest)
+			return
+		}
+		seen[key] = true
+		keys[i] = key
+
+		if q.SQL == "" {
+			h.sendErrorWithRequest(w, r, fmt.Sprintf("Query '%s': SQL query is required", key), http.StatusBadRequest)
+			return
+		}
+		if !h.isSelectQuery(q.SQL) {
+			h.sendErrorWithRequest(w, r, fmt.Sprintf("Query '%s': only read-only queries (SELECT, WITH, SHOW, DESCRIBE, EXPLAIN) are allowed in a batch", key), http.StatusBadRequest)
+			return
+		}
+		if h.containsInternalTables(q.SQL) {
+			h.sendErrorWithRequest(w, r, "Access to internal auth tables is forbidden", http.StatusForbidden)
+			return
+		}
+	}
+
+	h.logger.Info("Executing query batch",
+		zap.String("role", auth.GetRoleFromContext(r.Context())),
+		zap.Int("queries", len(req.Queries)),
+		zap.String("label", auth.GetQueryLabelFromContext(r.Context())),
+		zap.String("request_id", requestID),
+	)
+
+	results := make(map[string]interface{}, len(req.Queries))
+	for i, q := range req.Queries {
+		startTime := time.Now()
+
+		rows, err := h.dbMgr.QueryPreparedContext(r.Context(), q.SQL, q.Params...)
+		if err != nil {
+			h.logger.Error("Failed to execute batch query", zap.Error(err), zap.String("sql", q.SQL), zap.String("request_id", requestID))
+			h.sendQueryErrorWithRequest(w, r, fmt.Sprintf("Query '%s' failed", keys[i]), err, q.SQL)
+			return
+		}
+
+		columns, data, truncated, err := formats.ScanRows(rows, h.absoluteMaxRows)
+		rows.Close()
+		if err != nil {
+			h.logger.Error("Failed to read batch query results", zap.Error(err), zap.String("sql", q.SQL), zap.String("request_id", requestID))
+			h.sendDetailedErrorWithRequest(w, r, fmt.Sprintf("Query '%s' failed", keys[i]), err, http.StatusInternalServerError)
+			return
+		}
+		formats.ConvertDecimals(data, h.decimalAsNumber)
+
+		result := map[string]interface{}{
+			"columns":           columns,
+			"data":              data,
+			"execution_time_ms": time.Since(startTime).Milliseconds(),
+		}
+		if truncated {
+			result["truncated"] = true
+			result["message"] = fmt.Sprintf("Results limited to %d rows by safety limit.", h.absoluteMaxRows)
+		}
+		results[keys[i]] = result
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"results": results,
+	})
+}
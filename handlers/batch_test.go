@@ -0,0 +1,118 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestQueryHandler_Batch_TwoQueries(t *testing.T) {
+	handler, _, cleanup := setupQueryHandler(t)
+	defer cleanup()
+
+	body := `{"queries": [
+		{"name": "all", "sql": "SELECT * FROM test_query ORDER BY id"},
+		{"sql": "SELECT COUNT(*) AS n FROM test_query WHERE value > $1", "params": [150]}
+	]}`
+	req := httptest.NewRequest("POST", "/duckdb/query/batch", bytes.NewBufferString(body))
+	req.Header.Set("Content-Type", "application/json")
+	req = addQueryAuthContext(req, "admin")
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	var result map[string]interface{}
+	if err := json.Unmarshal(rec.Body.Bytes(), &result); err != nil {
+		t.Fatalf("Failed to parse response: %v", err)
+	}
+	results, ok := result["results"].(map[string]interface{})
+	if !ok {
+		t.Fatalf("Expected 'results' object in response: %s", rec.Body.String())
+	}
+
+	all, ok := results["all"].(map[string]interface{})
+	if !ok {
+		t.Fatal("Expected result set 'all'")
+	}
+	if data := all["data"].([]interface{}); len(data) != 3 {
+		t.Errorf("Expected 3 rows in 'all', got %d", len(data))
+	}
+	if columns := all["columns"].([]interface{}); len(columns) != 3 {
+		t.Errorf("Expected 3 columns in 'all', got %d", len(columns))
+	}
+	if _, ok := all["execution_time_ms"]; !ok {
+		t.Error("Expected execution_time_ms in result set")
+	}
+
+	count, ok := results["1"].(map[string]interface{})
+	if !ok {
+		t.Fatal("Expected result set '1' for unnamed query")
+	}
+	row := count["data"].([]interface{})[0].(map[string]interface{})
+	if row["n"].(float64) != 2 {
+		t.Errorf("Expected count 2, got %v", row["n"])
+	}
+}
+
+func TestQueryHandler_Batch_RowCap(t *testing.T) {
+	handler, _, cleanup := setupQueryHandler(t)
+	defer cleanup()
+	handler.SetAbsoluteMaxRows(2)
+
+	body := `{"queries": [{"sql": "SELECT * FROM test_query"}]}`
+	req := httptest.NewRequest("POST", "/duckdb/query/batch", bytes.NewBufferString(body))
+	req = addQueryAuthContext(req, "admin")
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	var result map[string]interface{}
+	json.Unmarshal(rec.Body.Bytes(), &result)
+	first := result["results"].(map[string]interface{})["0"].(map[string]interface{})
+	if data := first["data"].([]interface{}); len(data) != 2 {
+		t.Errorf("Expected 2 rows with row cap, got %d", len(data))
+	}
+	if first["truncated"] != true {
+		t.Error("Expected truncated to be true")
+	}
+}
+
+func TestQueryHandler_Batch_Rejections(t *testing.T) {
+	handler, _, cleanup := setupQueryHandler(t)
+	defer cleanup()
+
+	tests := []struct {
+		name       string
+		body       string
+		role       string
+		wantStatus int
+	}{
+		{"write statement", `{"queries": [{"sql": "SELECT 1"}, {"sql": "DELETE FROM test_query"}]}`, "admin", http.StatusBadRequest},
+		{"internal table", `{"queries": [{"sql": "SELECT * FROM api_keys"}]}`, "admin", http.StatusForbidden},
+		{"empty batch", `{"queries": []}`, "admin", http.StatusBadRequest},
+		{"duplicate names", `{"queries": [{"name": "a", "sql": "SELECT 1"}, {"name": "a", "sql": "SELECT 2"}]}`, "admin", http.StatusBadRequest},
+		{"invalid JSON", `{"queries":`, "admin", http.StatusBadRequest},
+		{"no query permission", `{"queries": [{"sql": "SELECT 1"}]}`, "reader", http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("POST", "/duckdb/query/batch", bytes.NewBufferString(tt.body))
+			req = addQueryAuthContext(req, tt.role)
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+
+			if rec.Code != tt.wantStatus {
+				t.Errorf("Expected status %d, got %d: %s", tt.wantStatus, rec.Code, rec.Body.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,323 @@
+package handlers
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+
+	"github.com/tobilg/caddy-duckdb-module/auth"
+	"github.com/tobilg/caddy-duckdb-module/database"
+	"github.com/tobilg/caddy-duckdb-module/formats"
+	"go.uber.org/zap"
+)
+
+// maxBulkUpdateItems is the maximum number of items accepted in a single bulk update.
+const maxBulkUpdateItems = 1000
+
+// maxBulkInsertRows is the maximum number of rows accepted in a single bulk insert.
+const maxBulkInsertRows = 10000
+
+// isJSONArray reports whether the next non-whitespace byte of body starts a
+// JSON array. The byte is left unread.
+func isJSONArray(body *bufio.Reader) bool {
+	for {
+		b, err := body.ReadByte()
+		if err != nil {
+			return false
+		}
+		switch b {
+		case ' ', '\t', '\r', '\n':
+			continue
+		}
+		body.UnreadByte()
+		return b == '['
+	}
+}
+
+// handleBulkInsert handles POST /api/{table} with an array body, which inserts
+// every row in a single transaction:
+//
+//	[
+//	  {"id": 1, "name": "Alice"},
+//	  {"id": 2, "name": "Bob", "email": null}
+//	]
+//
+// Each row is normalized like a single insert: omitted columns are NULL and
+// "__DEFAULT__" values get the column default. Either every row is inserted or
+// none is. The caller has checked CREATE permission.
+func (h *CRUDHandler) handleBulkInsert(w http.ResponseWriter, r *http.Request, tableName string, body *bufio.Reader) {
+	requestID := auth.GetRequestIDFromContext(r.Context())
+
+	var rows []map[string]interface{}
+	if err := json.NewDecoder(body).Decode(&rows); err != nil {
+		h.sendErrorWithRequest(w, r, "Invalid JSON in request body (expected an array of objects)", http.StatusBadRequest)
+		return
+	}
+	if len(rows) == 0 {
+		h.sendErrorWithRequest(w, r, "At least one row is required", http.StatusBadRequest)
+		return
+	}
+	if len(rows) > maxBulkInsertRows {
+		h.sendErrorWithRequest(w, r, fmt.Sprintf("Too many rows in bulk insert: %d (maximum %d)", len(rows), maxBulkInsertRows), http.StatusBadRequest)
+		return
+	}
+
+	// Validate every row before inserting any of them. The columns of all rows
+	// are checked against the schema together.
+	known := make(map[string]interface{})
+	for i := range rows {
+		if err := h.checkColumnCount(len(rows[i])); err != nil {
+			h.sendErrorWithRequest(w, r, fmt.Sprintf("Row %d: too many columns: %s", i, err.Error()), http.StatusBadRequest)
+			return
+		}
+		rows[i] = formats.MapInputKeys(rows[i], h.jsonKeyCase)
+		columns := make([]string, 0, len(rows[i]))
+		for col := range rows[i] {
+			if err := SanitizeColumnName(col); err != nil {
+				h.sendErrorWithRequest(w, r, fmt.Sprintf("Row %d: invalid column name '%s': %s", i, col, err.Error()), http.StatusBadRequest)
+				return
+			}
+			columns = append(columns, col)
+			known[col] = nil
+		}
+		if err := h.checkNotDerived(tableName, columns); err != nil {
+			h.sendErrorWithRequest(w, r, fmt.Sprintf("Row %d: invalid column: %s", i, err.Error()), http.StatusBadRequest)
+			return
+		}
+	}
+
+	if err := h.dropUnknownColumns(w, r, tableName, known); err != nil {
+		var unknownErr *unknownColumnsError
+		if errors.As(err, &unknownErr) {
+			h.sendErrorWithRequest(w, r, fmt.Sprintf("Invalid column: %s", err.Error()), http.StatusBadRequest)
+			return
+		}
+		h.logger.Error("Failed to check columns", zap.Error(err), zap.String("table", tableName), zap.String("request_id", requestID))
+		h.sendDetailedErrorWithRequest(w, r, "Failed to insert data", err, http.StatusInternalServerError)
+		return
+	}
+
+	for i, row := range rows {
+		for col := range row {
+			if _, ok := known[col]; !ok {
+				delete(row, col)
+			}
+		}
+		if len(row) == 0 {
+			h.sendErrorWithRequest(w, r, fmt.Sprintf("Row %d contains no known columns", i), http.StatusBadRequest)
+			return
+		}
+
+		// Apply table validation rules; defaulted columns have no value to validate
+		defaulted := takeDefaults(row)
+		if verr := h.validateRow(tableName, row, defaulted); verr != nil {
+			h.sendValidationErrorWithRequest(w, r, &ValidationError{Rule: verr.Rule, Message: fmt.Sprintf("row %d: %s", i, verr.Message), Violations: verr.Violations})
+			return
+		}
+		for _, col := range defaulted {
+			row[col] = database.Default
+		}
+	}
+
+	stopDB := ServerTimingFromContext(r.Context()).Start(TimingDB)
+	result, err := h.dbMgr.BatchInsert(tableName, rows)
+	stopDB()
+	if err != nil {
+		h.logger.Error("Failed to insert data", zap.Error(err), zap.String("table", tableName), zap.String("request_id", requestID))
+		message := "Failed to insert data"
+		var batchErr *database.InsertBatchError
+		if errors.As(err, &batchErr) {
+			message = fmt.Sprintf("Failed to insert data: row %d failed, no rows were inserted", batchErr.Index)
+			err = batchErr.Err
+		}
+		h.sendDetailedErrorWithRequest(w, r, message, err, http.StatusInternalServerError)
+		return
+	}
+
+	h.changes.Bump(tableName)
+	h.sendSuccessWithRequest(w, r, result.RowsAffected, http.StatusCreated)
+}
+
+// BulkUpdateItem is a single item of a bulk update: the SET values for the rows
+// whose columns equal the WHERE values.
+type BulkUpdateItem struct {
+	Where map[string]interface{} `json:"where"`
+	Set   map[string]interface{} `json:"set"`
+}
+
+// handleBulkUpdate handles PUT /api/{table}/bulk, which updates rows to
+// different values by key in a single transaction. Request body format:
+//
+//	[
+//	  {"where": {"id": 1}, "set": {"status": "active"}},
+//	  {"where": {"id": 2}, "set": {"status": "inactive", "score": 7}}
+//	]
+//
+// Either every item is applied or none is. The response reports the total and
+// per-item rows affected.
+func (h *CRUDHandler) handleBulkUpdate(w http.ResponseWriter, r *http.Request, tableName string) {
+	requestID := auth.GetRequestIDFromContext(r.Context())
+
+	// Check authorization
+	role := auth.GetRoleFromContext(r.Context())
+	stopAuth := ServerTimingFromContext(r.Context()).Start(TimingAuth)
+	allowed, err := h.authorizer.CheckPermission(role, tableName, auth.OperationUpdate)
+	stopAuth()
+	if err != nil {
+		h.logger.Error("Failed to check permission", zap.Error(err), zap.String("request_id", requestID))
+		h.sendErrorWithRequest(w, r, "Failed to check permission", http.StatusInternalServerError)
+		return
+	}
+	if !allowed {
+		h.sendErrorWithRequest(w, r, "Forbidden: insufficient permissions for UPDATE operation", http.StatusForbidden)
+		return
+	}
+
+	defer r.Body.Close()
+
+	var items []BulkUpdateItem
+	if err := json.NewDecoder(r.Body).Decode(&items); err != nil {
+		h.sendErrorWithRequest(w, r, "Invalid JSON in request body (expected an array of {where, set} items)", http.StatusBadRequest)
+		return
+	}
+	if len(items) == 0 {
+		h.sendErrorWithRequest(w, r, "At least one update is required", http.StatusBadRequest)
+		return
+	}
+	if len(items) > maxBulkUpdateItems {
+		h.sendErrorWithRequest(w, r, fmt.Sprintf("Too many updates in bulk request: %d (maximum %d)", len(items), maxBulkUpdateItems), http.StatusBadRequest)
+		return
+	}
+
+	// Validate every item before executing any of them. The SET columns of all
+	// items are checked against the schema together.
+	setColumns := make(map[string]interface{})
+	filters := make([][]database.Filter, len(items))
+	for i := range items {
+		item := &items[i]
+		if err := h.checkColumnCount(len(item.Set)); err != nil {
+			h.sendErrorWithRequest(w, r, fmt.Sprintf("Update %d: too many columns: %s", i, err.Error()), http.StatusBadRequest)
+			return
+		}
+		item.Set = formats.MapInputKeys(item.Set, h.jsonKeyCase)
+		item.Where = formats.MapInputKeys(item.Where, h.jsonKeyCase)
+		if len(item.Where) == 0 {
+			h.sendErrorWithRequest(w, r, fmt.Sprintf("Update %d: WHERE clause is required", i), http.StatusBadRequest)
+			return
+		}
+		if len(item.Set) == 0 {
+			h.sendErrorWithRequest(w, r, fmt.Sprintf("Update %d: SET clause is required", i), http.StatusBadRequest)
+			return
+		}
+
+		// Sorted so items with the same WHERE columns share a prepared statement
+		whereColumns := make([]string, 0, len(item.Where))
+		for col := range item.Where {
+			if err := SanitizeColumnName(col); err != nil {
+				h.sendErrorWithRequest(w, r, fmt.Sprintf("Update %d: invalid WHERE column '%s': %s", i, col, err.Error()), http.StatusBadRequest)
+				return
+			}
+			whereColumns = append(whereColumns, col)
+		}
+		sort.Strings(whereColumns)
+		for _, col := range whereColumns {
+			filters[i] = append(filters[i], database.Filter{Column: col, Operator: "eq", Value: item.Where[col]})
+		}
+		if err := h.checkFilterable(tableName, filters[i]); err != nil {
+			h.sendErrorWithRequest(w, r, fmt.Sprintf("Update %d: invalid WHERE clause: %s", i, err.Error()), http.StatusBadRequest)
+			return
+		}
+		if err := h.checkNotDerived(tableName, whereColumns); err != nil {
+			h.sendErrorWithRequest(w, r, fmt.Sprintf("Update %d: invalid WHERE clause: %s", i, err.Error()), http.StatusBadRequest)
+			return
+		}
+
+		columns := make([]string, 0, len(item.Set))
+		for col := range item.Set {
+			if err := SanitizeColumnName(col); err != nil {
+				h.sendErrorWithRequest(w, r, fmt.Sprintf("Update %d: invalid SET column '%s': %s", i, col, err.Error()), http.StatusBadRequest)
+				return
+			}
+			columns = append(columns, col)
+			setColumns[col] = nil
+		}
+		if err := h.checkNotDerived(tableName, columns); err != nil {
+			h.sendErrorWithRequest(w, r, fmt.Sprintf("Update %d: invalid SET clause: %s", i, err.Error()), http.StatusBadRequest)
+			return
+		}
+	}
+
+	// Unknown SET columns may be dropped; unknown WHERE columns are always rejected
+	// by the database so an update is never widened
+	if err := h.dropUnknownColumns(w, r, tableName, setColumns); err != nil {
+		var unknownErr *unknownColumnsError
+		if errors.As(err, &unknownErr) {
+			h.sendErrorWithRequest(w, r, fmt.Sprintf("Invalid SET clause: %s", err.Error()), http.StatusBadRequest)
+			return
+		}
+		h.logger.Error("Failed to check columns", zap.Error(err), zap.String("table", tableName), zap.String("request_id", requestID))
+		h.sendDetailedErrorWithRequest(w, r, "Failed to update data", err, http.StatusInternalServerError)
+		return
+	}
+
+	softDeleteCol := h.softDeleteColumn(tableName)
+	updates := make([]database.UpdateItem, len(items))
+	for i, item := range items {
+		for col := range item.Set {
+			if _, ok := setColumns[col]; !ok {
+				delete(item.Set, col)
+			}
+		}
+		if len(item.Set) == 0 {
+			h.sendErrorWithRequest(w, r, fmt.Sprintf("Update %d: SET clause contains no known columns", i), http.StatusBadRequest)
+			return
+		}
+		if verr := h.validateSet(tableName, item.Set); verr != nil {
+			h.sendValidationErrorWithRequest(w, r, verr)
+			return
+		}
+
+		// Soft-deleted rows must be restored before they can be updated
+		if softDeleteCol != "" {
+			filters[i] = append(filters[i], database.Filter{Column: softDeleteCol, Operator: "is_null"})
+		}
+		updates[i] = database.UpdateItem{Set: item.Set, Filters: filters[i]}
+	}
+
+	stopDB := ServerTimingFromContext(r.Context()).Start(TimingDB)
+	affected, err := h.dbMgr.UpdateBatch(tableName, updates)
+	stopDB()
+	if err != nil {
+		h.logger.Error("Failed to update data", zap.Error(err), zap.String("table", tableName), zap.String("request_id", requestID))
+		message := "Failed to update data"
+		var batchErr *database.UpdateBatchError
+		if errors.As(err, &batchErr) {
+			message = fmt.Sprintf("Failed to update data: update %d failed, no updates were applied", batchErr.Index)
+			err = batchErr.Err
+		}
+		h.sendDetailedErrorWithRequest(w, r, message, err, http.StatusInternalServerError)
+		return
+	}
+
+	var total int64
+	results := make([]map[string]interface{}, len(affected))
+	for i, n := range affected {
+		total += n
+		results[i] = map[string]interface{}{"rows_affected": n}
+	}
+	if total > 0 {
+		h.changes.Bump(tableName)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success":       true,
+		"rows_affected": total,
+		"results":       results,
+		"request_id":    auth.GetRequestIDFromContext(r.Context()),
+	})
+}
@@ -0,0 +1,223 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestCRUDHandler_BulkUpdate(t *testing.T) {
+	handler, mgr, cleanup := setupTestHandler(t)
+	defer cleanup()
+
+	body := bytes.NewBufferString(`[
+		{"where": {"id": 1}, "set": {"age": 41}},
+		{"where": {"id": 2}, "set": {"age": 52, "email": "bob@new.example.com"}},
+		{"where": {"id": 3}, "set": {"age": 63}},
+		{"where": {"id": 99}, "set": {"age": 1}}
+	]`)
+	req := httptest.NewRequest("PUT", "/duckdb/api/test_users/bulk", body)
+	req.Header.Set("Content-Type", "application/json")
+	req = addAuthContext(req, "admin")
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	var result struct {
+		RowsAffected int64 `json:"rows_affected"`
+		Results      []struct {
+			RowsAffected int64 `json:"rows_affected"`
+		} `json:"results"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &result); err != nil {
+		t.Fatalf("Failed to parse response: %v", err)
+	}
+	if result.RowsAffected != 3 {
+		t.Errorf("Expected 3 rows affected in total, got %d", result.RowsAffected)
+	}
+	if len(result.Results) != 4 || result.Results[0].RowsAffected != 1 || result.Results[3].RowsAffected != 0 {
+		t.Errorf("Unexpected per-item results: %+v", result.Results)
+	}
+
+	var ages, email string
+	if err := mgr.QueryRowScanMain("SELECT string_agg(age::VARCHAR, ',' ORDER BY id) FROM test_users", []interface{}{&ages}); err != nil {
+		t.Fatalf("Failed to query ages: %v", err)
+	}
+	if ages != "41,52,63" {
+		t.Errorf("Expected ages 41,52,63, got %s", ages)
+	}
+	if err := mgr.QueryRowScanMain("SELECT email FROM test_users WHERE id = 2", []interface{}{&email}); err != nil {
+		t.Fatalf("Failed to query email: %v", err)
+	}
+	if email != "bob@new.example.com" {
+		t.Errorf("Expected updated email, got %s", email)
+	}
+}
+
+func TestCRUDHandler_BulkUpdate_Atomic(t *testing.T) {
+	handler, mgr, cleanup := setupTestHandler(t)
+	defer cleanup()
+
+	// The second item fails in the database (age is an INTEGER), so the first is rolled back
+	body := bytes.NewBufferString(`[
+		{"where": {"id": 1}, "set": {"age": 41}},
+		{"where": {"id": 2}, "set": {"age": "not a number"}}
+	]`)
+	req := httptest.NewRequest("PUT", "/duckdb/api/test_users/bulk", body)
+	req = addAuthContext(req, "admin")
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	if rec.Code != http.StatusInternalServerError {
+		t.Fatalf("Expected status 500, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if !strings.Contains(rec.Body.String(), "update 1 failed") {
+		t.Errorf("Expected the failing item to be reported, got %s", rec.Body.String())
+	}
+
+	var age int
+	if err := mgr.QueryRowScanMain("SELECT age FROM test_users WHERE id = 1", []interface{}{&age}); err != nil {
+		t.Fatalf("Failed to query age: %v", err)
+	}
+	if age != 30 {
+		t.Errorf("Expected no update to be applied, got age %d", age)
+	}
+}
+
+func TestCRUDHandler_BulkUpdate_Invalid(t *testing.T) {
+	handler, _, cleanup := setupTestHandler(t)
+	defer cleanup()
+
+	tests := []struct {
+		name    string
+		method  string
+		role    string
+		body    string
+		status  int
+		message string
+	}{
+		{"wrong method", "POST", "admin", `[]`, http.StatusMethodNotAllowed, "Method not allowed"},
+		{"not an array", "PUT", "admin", `{"where": {"id": 1}, "set": {"age": 1}}`, http.StatusBadRequest, "Invalid JSON"},
+		{"empty", "PUT", "admin", `[]`, http.StatusBadRequest, "At least one update"},
+		{"missing where", "PUT", "admin", `[{"set": {"age": 1}}]`, http.StatusBadRequest, "Update 0: WHERE clause is required"},
+		{"missing set", "PUT", "admin", `[{"where": {"id": 1}, "set": {"age": 1}}, {"where": {"id": 2}}]`, http.StatusBadRequest, "Update 1: SET clause is required"},
+		{"unknown column", "PUT", "admin", `[{"where": {"id": 1}, "set": {"salary": 1}}]`, http.StatusBadRequest, "unknown column(s) 'salary'"},
+		{"forbidden", "PUT", "reader", `[{"where": {"id": 1}, "set": {"age": 1}}]`, http.StatusForbidden, "insufficient permissions"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, "/duckdb/api/test_users/bulk", bytes.NewBufferString(tt.body))
+			req = addAuthContext(req, tt.role)
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+			if rec.Code != tt.status {
+				t.Fatalf("Expected status %d, got %d: %s", tt.status, rec.Code, rec.Body.String())
+			}
+			if !strings.Contains(rec.Body.String(), tt.message) {
+				t.Errorf("Expected message containing %q, got %s", tt.message, rec.Body.String())
+			}
+		})
+	}
+}
+
+func TestCRUDHandler_BulkInsert(t *testing.T) {
+	handler, mgr, cleanup := setupTestHandler(t)
+	defer cleanup()
+
+	body := bytes.NewBufferString(` [
+		{"id": 4, "name": "Dave", "email": "dave@example.com", "age": 40},
+		{"id": 5, "name": "Eve"},
+		{"id": 6, "name": "Frank", "age": 22}
+	]`)
+	req := httptest.NewRequest("POST", "/duckdb/api/test_users", body)
+	req.Header.Set("Content-Type", "application/json")
+	req = addAuthContext(req, "admin")
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("Expected status 201, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	var result struct {
+		RowsAffected int64 `json:"rows_affected"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &result); err != nil {
+		t.Fatalf("Failed to parse response: %v", err)
+	}
+	if result.RowsAffected != 3 {
+		t.Errorf("Expected 3 rows affected in total, got %d", result.RowsAffected)
+	}
+
+	// Omitted nullable columns are NULL, like in a single insert
+	var count, nullEmails int
+	if err := mgr.QueryRowScanMain("SELECT count(*), count(*) FILTER (WHERE email IS NULL) FROM test_users WHERE id > 3", []interface{}{&count, &nullEmails}); err != nil {
+		t.Fatalf("Failed to query rows: %v", err)
+	}
+	if count != 3 || nullEmails != 2 {
+		t.Errorf("Expected 3 inserted rows with 2 NULL emails, got %d and %d", count, nullEmails)
+	}
+}
+
+func TestCRUDHandler_BulkInsert_Atomic(t *testing.T) {
+	handler, mgr, cleanup := setupTestHandler(t)
+	defer cleanup()
+
+	// The second row duplicates a primary key, so the first is rolled back
+	body := bytes.NewBufferString(`[{"id": 4, "name": "Dave"}, {"id": 1, "name": "Duplicate"}]`)
+	req := httptest.NewRequest("POST", "/duckdb/api/test_users", body)
+	req = addAuthContext(req, "admin")
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	if rec.Code != http.StatusInternalServerError {
+		t.Fatalf("Expected status 500, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if !strings.Contains(rec.Body.String(), "row 1 failed") {
+		t.Errorf("Expected the failing row to be reported, got %s", rec.Body.String())
+	}
+
+	var count int
+	if err := mgr.QueryRowScanMain("SELECT count(*) FROM test_users", []interface{}{&count}); err != nil {
+		t.Fatalf("Failed to count rows: %v", err)
+	}
+	if count != 3 {
+		t.Errorf("Expected no row to be inserted, got %d rows", count)
+	}
+}
+
+func TestCRUDHandler_BulkInsert_Invalid(t *testing.T) {
+	handler, _, cleanup := setupTestHandler(t)
+	defer cleanup()
+
+	tests := []struct {
+		name    string
+		role    string
+		body    string
+		status  int
+		message string
+	}{
+		{"empty", "admin", `[]`, http.StatusBadRequest, "At least one row"},
+		{"not objects", "admin", `[1, 2]`, http.StatusBadRequest, "expected an array of objects"},
+		{"invalid column", "admin", `[{"id": 4}, {"bad name": 1}]`, http.StatusBadRequest, "Row 1: invalid column name"},
+		{"unknown column", "admin", `[{"id": 4, "salary": 1}]`, http.StatusBadRequest, "unknown column(s) 'salary'"},
+		{"empty row", "admin", `[{"id": 4}, {}]`, http.StatusBadRequest, "Row 1 contains no known columns"},
+		{"forbidden", "reader", `[{"id": 4}]`, http.StatusForbidden, "insufficient permissions"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("POST", "/duckdb/api/test_users", bytes.NewBufferString(tt.body))
+			req = addAuthContext(req, tt.role)
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+			if rec.Code != tt.status {
+				t.Fatalf("Expected status %d, got %d: %s", tt.status, rec.Code, rec.Body.String())
+			}
+			if !strings.Contains(rec.Body.String(), tt.message) {
+				t.Errorf("Expected message containing %q, got %s", tt.message, rec.Body.String())
+			}
+		})
+	}
+}
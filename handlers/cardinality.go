@@ -0,0 +1,121 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/tobilg/caddy-duckdb-module/auth"
+	"github.com/tobilg/caddy-duckdb-module/database"
+	"go.uber.org/zap"
+)
+
+// handleCardinality counts the distinct values of a column, e.g.
+// GET /duckdb/api/visits/cardinality?column=visitor
+// The count is a fast approx_count_distinct estimate unless exact=true asks for
+// the precise (and slower) COUNT(DISTINCT). Requires READ permission. Rows can be
+// narrowed with the usual filter parameter.
+func (h *CRUDHandler) handleCardinality(w http.ResponseWriter, r *http.Request, tableName string) {
+	requestID := auth.GetRequestIDFromContext(r.Context())
+
+	// Check authorization
+	role := auth.GetRoleFromContext(r.Context())
+	stopAuth := ServerTimingFromContext(r.Context()).Start(TimingAuth)
+	allowed, err := h.authorizer.CheckPermission(role, tableName, auth.OperationRead)
+	stopAuth()
+	if err != nil {
+		h.logger.Error("Failed to check permission", zap.Error(err), zap.String("request_id", requestID))
+		h.sendErrorWithRequest(w, r, "Failed to check permission", http.StatusInternalServerError)
+		return
+	}
+	if !allowed {
+		h.sendErrorWithRequest(w, r, "Forbidden: insufficient permissions for READ operation", http.StatusForbidden)
+		return
+	}
+
+	query := r.URL.Query()
+	column := strings.TrimSpace(query.Get("column"))
+	if column == "" {
+		h.sendErrorWithRequest(w, r, "Invalid cardinality: column is required", http.StatusBadRequest)
+		return
+	}
+	if err := SanitizeColumnName(column); err != nil {
+		h.sendErrorWithRequest(w, r, fmt.Sprintf("Invalid column '%s': %s", column, err.Error()), http.StatusBadRequest)
+		return
+	}
+	exact := false
+	if v := query.Get("exact"); v != "" {
+		switch strings.ToLower(v) {
+		case "true", "1":
+			exact = true
+		case "false", "0":
+		default:
+			h.sendErrorWithRequest(w, r, fmt.Sprintf("Invalid exact value '%s': expected true or false", v), http.StatusBadRequest)
+			return
+		}
+	}
+
+	filters, err := ParseFilters(r)
+	if err != nil {
+		h.sendErrorWithRequest(w, r, fmt.Sprintf("Invalid filters: %s", err.Error()), http.StatusBadRequest)
+		return
+	}
+	for _, f := range filters {
+		if err := SanitizeColumnName(f.Column); err != nil {
+			h.sendErrorWithRequest(w, r, fmt.Sprintf("Invalid filter column '%s': %s", f.Column, err.Error()), http.StatusBadRequest)
+			return
+		}
+	}
+	if err := h.checkFilterable(tableName, filters); err != nil {
+		h.sendErrorWithRequest(w, r, fmt.Sprintf("Invalid filter: %s", err.Error()), http.StatusBadRequest)
+		return
+	}
+	if !h.rejectUnknownColumns(w, r, tableName, h.tables[tableName].DerivedColumns(), "filter", filterColumns(filters)) {
+		return
+	}
+
+	denied, ok := h.deniedColumns(w, r, tableName)
+	if !ok || !h.rejectDeniedColumns(w, r, denied, filterColumns(filters), []string{column}) {
+		return
+	}
+
+	// Counting a column's values reveals about as much as grouping by it
+	if err := h.checkGroupable(tableName, []string{column}); err != nil {
+		h.sendErrorWithRequest(w, r, fmt.Sprintf("Invalid cardinality: %s", err.Error()), http.StatusBadRequest)
+		return
+	}
+
+	// Reject unknown columns up front instead of surfacing a binder error
+	if unknown, err := h.unknownGroupingColumns(tableName, []string{column}, nil); err != nil {
+		h.logger.Error("Failed to check columns", zap.Error(err), zap.String("table", tableName), zap.String("request_id", requestID))
+		h.sendDetailedErrorWithRequest(w, r, "Failed to count distinct values", err, http.StatusInternalServerError)
+		return
+	} else if len(unknown) > 0 {
+		h.sendErrorWithRequest(w, r, fmt.Sprintf("Invalid cardinality: unknown column '%s'", column), http.StatusBadRequest)
+		return
+	}
+
+	// Hide soft-deleted rows
+	if col := h.readSoftDeleteColumn(r, tableName); col != "" {
+		filters = append(filters, database.Filter{Column: col, Operator: "is_null"})
+	}
+
+	stopDB := ServerTimingFromContext(r.Context()).Start(TimingDB)
+	count, err := h.dbMgr.DistinctCountContext(r.Context(), tableName, h.tables[tableName].DerivedColumns(), column, filters, exact)
+	stopDB()
+	if err != nil {
+		h.logger.Error("Failed to count distinct values", zap.Error(err), zap.String("table", tableName), zap.String("request_id", requestID))
+		h.sendDetailedErrorWithRequest(w, r, "Failed to count distinct values", err, http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"table":       tableName,
+		"column":      column,
+		"distinct":    count,
+		"approximate": !exact,
+	})
+}
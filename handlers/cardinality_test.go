@@ -0,0 +1,90 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestCRUDHandler_Cardinality(t *testing.T) {
+	handler, mgr, cleanup := setupTestHandler(t)
+	defer cleanup()
+	if _, err := mgr.ExecMain(`INSERT INTO test_users VALUES (4, 'Alice', 'alice2@example.com', 41), (5, NULL, 'nobody@example.com', 30)`); err != nil {
+		t.Fatalf("Failed to insert rows: %v", err)
+	}
+
+	get := func(query string) (int64, bool) {
+		t.Helper()
+		req := httptest.NewRequest("GET", "/duckdb/api/test_users/cardinality?"+query, nil)
+		req = addAuthContext(req, "reader")
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("Expected status 200 for %s, got %d: %s", query, rec.Code, rec.Body.String())
+		}
+		var result struct {
+			Column      string `json:"column"`
+			Distinct    int64  `json:"distinct"`
+			Approximate bool   `json:"approximate"`
+		}
+		if err := json.Unmarshal(rec.Body.Bytes(), &result); err != nil {
+			t.Fatalf("Failed to parse response: %v", err)
+		}
+		return result.Distinct, result.Approximate
+	}
+
+	// Alice, Bob, Charlie; NULL names are not counted
+	exact, approximate := get("column=name&exact=true")
+	if exact != 3 || approximate {
+		t.Errorf("Expected an exact count of 3, got %d (approximate: %v)", exact, approximate)
+	}
+	approx, approximate := get("column=name")
+	if !approximate {
+		t.Error("Expected the default count to be approximate")
+	}
+	if approx != exact {
+		t.Errorf("Expected the estimate to match %d on a small dataset, got %d", exact, approx)
+	}
+
+	// Filters apply before counting
+	if n, _ := get("column=age&exact=true&filter=age:gte:30"); n != 3 {
+		t.Errorf("Expected 3 distinct ages >= 30, got %d", n)
+	}
+}
+
+func TestCRUDHandler_CardinalityRejections(t *testing.T) {
+	handler, mgr, cleanup := setupTestHandler(t)
+	defer cleanup()
+	if _, err := mgr.ExecAuth(`INSERT INTO roles (role_name, description) VALUES ('nobody', 'no permissions')`); err != nil {
+		t.Fatalf("Failed to create role: %v", err)
+	}
+	handler.SetTableConfigs(map[string]*TableConfig{"test_users": {Filterable: []string{"name", "age"}}})
+
+	tests := []struct {
+		name       string
+		method     string
+		query      string
+		role       string
+		wantStatus int
+	}{
+		{"missing column", "GET", "", "reader", http.StatusBadRequest},
+		{"invalid column", "GET", "column=name;drop", "reader", http.StatusBadRequest},
+		{"unknown column", "GET", "column=nickname", "reader", http.StatusBadRequest},
+		{"column not filterable", "GET", "column=email", "reader", http.StatusBadRequest},
+		{"invalid exact", "GET", "column=name&exact=maybe", "reader", http.StatusBadRequest},
+		{"no read permission", "GET", "column=name", "nobody", http.StatusForbidden},
+		{"wrong method", "POST", "column=name", "admin", http.StatusMethodNotAllowed},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, "/duckdb/api/test_users/cardinality?"+tt.query, nil)
+			req = addAuthContext(req, tt.role)
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+			if rec.Code != tt.wantStatus {
+				t.Errorf("Expected status %d, got %d: %s", tt.wantStatus, rec.Code, rec.Body.String())
+			}
+		})
+	}
+}
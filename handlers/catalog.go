@@ -0,0 +1,135 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/tobilg/caddy-duckdb-module/auth"
+	"github.com/tobilg/caddy-duckdb-module/database"
+	"go.uber.org/zap"
+)
+
+// CatalogHandler serves GET /duckdb/schema, which describes the tables and
+// views of the main database that the caller's role can read, so that client
+// generators and admin UIs can discover them without raw SQL.
+type CatalogHandler struct {
+	dbMgr       *database.Manager
+	authorizer  *auth.Authorizer
+	logger      *zap.Logger
+	errorDetail string
+}
+
+// catalogColumn is a column in a schema response.
+type catalogColumn struct {
+	Name     string `json:"name"`
+	Type     string `json:"type"`
+	Nullable bool   `json:"nullable"`
+}
+
+// catalogTable is a table or view in a schema response.
+type catalogTable struct {
+	Name    string          `json:"name"`
+	Type    string          `json:"type"`
+	Columns []catalogColumn `json:"columns"`
+}
+
+// NewCatalogHandler creates a new schema handler.
+func NewCatalogHandler(dbMgr *database.Manager, authorizer *auth.Authorizer, logger *zap.Logger) *CatalogHandler {
+	return &CatalogHandler{
+		dbMgr:      dbMgr,
+		authorizer: authorizer,
+		logger:     logger,
+	}
+}
+
+// SetErrorDetail sets how much of a failure is described in error responses
+// (see ErrorDetailFull, ErrorDetailSafe, ErrorDetailMinimal).
+func (h *CatalogHandler) SetErrorDetail(level string) {
+	h.errorDetail = level
+}
+
+// ServeHTTP handles GET /duckdb/schema, listing every readable table with its
+// columns, and GET /duckdb/schema/{table}, describing a single table. Internal
+// tables and tables the role cannot read are left out; a role that can read
+// no table at all is refused.
+func (h *CatalogHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	requestID := auth.GetRequestIDFromContext(r.Context())
+
+	if r.Method != http.MethodGet {
+		writeError(w, r, h.errorDetail, "Method not allowed. Use GET.", nil, http.StatusMethodNotAllowed)
+		return
+	}
+	tableName := strings.Trim(strings.TrimPrefix(r.URL.Path, "/duckdb/schema"), "/")
+	if strings.Contains(tableName, "/") {
+		writeError(w, r, h.errorDetail, "Invalid path: expected /schema or /schema/{table}", nil, http.StatusBadRequest)
+		return
+	}
+	if tableName != "" {
+		if err := SanitizeTableName(tableName); err != nil {
+			writeError(w, r, h.errorDetail, err.Error(), nil, http.StatusBadRequest)
+			return
+		}
+	}
+
+	schemas, err := h.dbMgr.TableSchemas(r.Context())
+	if err != nil {
+		h.logger.Error("Failed to list table schemas", zap.Error(err), zap.String("request_id", requestID))
+		writeError(w, r, h.errorDetail, "Failed to list tables", err, http.StatusInternalServerError)
+		return
+	}
+	tables, err := h.readableTables(auth.GetRoleFromContext(r.Context()), schemas)
+	if err != nil {
+		h.logger.Error("Failed to check permission", zap.Error(err), zap.String("request_id", requestID))
+		writeError(w, r, h.errorDetail, "Failed to check permission", nil, http.StatusInternalServerError)
+		return
+	}
+	if len(tables) == 0 {
+		writeError(w, r, h.errorDetail, "Forbidden: READ permission on at least one table is required", nil, http.StatusForbidden)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if tableName == "" {
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(map[string]interface{}{"tables": tables})
+		return
+	}
+	for _, table := range tables {
+		if strings.EqualFold(table.Name, tableName) {
+			w.WriteHeader(http.StatusOK)
+			json.NewEncoder(w).Encode(table)
+			return
+		}
+	}
+	// Unreadable and internal tables are indistinguishable from missing ones
+	writeError(w, r, h.errorDetail, "Table not found", nil, http.StatusNotFound)
+}
+
+// readableTables returns the non-internal tables of schemas on which role has
+// READ permission.
+func (h *CatalogHandler) readableTables(role string, schemas []database.TableSchema) ([]catalogTable, error) {
+	tables := make([]catalogTable, 0, len(schemas))
+	for _, schema := range schemas {
+		if auth.IsInternalTable(schema.Name) {
+			continue
+		}
+		allowed, err := h.authorizer.CheckPermission(role, schema.Name, auth.OperationRead)
+		if err != nil {
+			return nil, err
+		}
+		if !allowed {
+			continue
+		}
+
+		table := catalogTable{Name: schema.Name, Type: "table", Columns: make([]catalogColumn, len(schema.Columns))}
+		if schema.Type == "VIEW" {
+			table.Type = "view"
+		}
+		for i, col := range schema.Columns {
+			table.Columns[i] = catalogColumn{Name: col.Name, Type: col.Type, Nullable: col.Nullable}
+		}
+		tables = append(tables, table)
+	}
+	return tables, nil
+}
@@ -0,0 +1,142 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"go.uber.org/zap"
+)
+
+func TestCatalogHandler(t *testing.T) {
+	crud, mgr, cleanup := setupTestHandler(t)
+	defer cleanup()
+	handler := NewCatalogHandler(mgr, crud.authorizer, zap.NewNop())
+
+	// An internal table name in the main database must stay hidden
+	for _, stmt := range []string{
+		`CREATE TABLE api_keys (key VARCHAR)`,
+		`CREATE VIEW adults AS SELECT id, name FROM test_users WHERE age >= 30`,
+		`CREATE TABLE secrets (id INTEGER NOT NULL)`,
+	} {
+		if _, err := mgr.ExecMain(stmt); err != nil {
+			t.Fatalf("Failed to run %q: %v", stmt, err)
+		}
+	}
+
+	// A role that can only read test_users
+	if _, err := mgr.ExecAuth(`INSERT INTO roles (role_name, description) VALUES ('users_only', 'reads test_users')`); err != nil {
+		t.Fatalf("Failed to create role: %v", err)
+	}
+	if _, err := mgr.ExecAuth(`INSERT INTO permissions (id, role_name, table_name, can_create, can_read, can_update, can_delete, can_query)
+		VALUES (nextval('permissions_id_seq'), 'users_only', 'test_users', false, true, false, false, false)`); err != nil {
+		t.Fatalf("Failed to grant permission: %v", err)
+	}
+	if _, err := mgr.ExecAuth(`INSERT INTO roles (role_name, description) VALUES ('nobody', 'no permissions')`); err != nil {
+		t.Fatalf("Failed to create role: %v", err)
+	}
+
+	get := func(path, role string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("GET", path, nil)
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, addAuthContext(req, role))
+		return rec
+	}
+	tableNames := func(rec *httptest.ResponseRecorder) map[string]catalogTable {
+		var body struct {
+			Tables []catalogTable `json:"tables"`
+		}
+		if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+			t.Fatalf("Failed to parse response: %v", err)
+		}
+		tables := make(map[string]catalogTable)
+		for _, table := range body.Tables {
+			tables[table.Name] = table
+		}
+		return tables
+	}
+
+	t.Run("listing", func(t *testing.T) {
+		rec := get("/duckdb/schema", "admin")
+		if rec.Code != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d: %s", rec.Code, rec.Body.String())
+		}
+		tables := tableNames(rec)
+		if _, ok := tables["api_keys"]; ok {
+			t.Error("Expected internal table api_keys to be hidden")
+		}
+		for _, name := range []string{"test_users", "adults", "secrets"} {
+			if _, ok := tables[name]; !ok {
+				t.Errorf("Expected table %s in listing, got %v", name, tables)
+			}
+		}
+		if tables["adults"].Type != "view" || tables["test_users"].Type != "table" {
+			t.Errorf("Expected table types view and table, got %q and %q", tables["adults"].Type, tables["test_users"].Type)
+		}
+	})
+
+	t.Run("filtered by role", func(t *testing.T) {
+		rec := get("/duckdb/schema", "users_only")
+		if rec.Code != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d: %s", rec.Code, rec.Body.String())
+		}
+		tables := tableNames(rec)
+		if len(tables) != 1 {
+			t.Errorf("Expected only test_users, got %v", tables)
+		}
+		if rec := get("/duckdb/schema/secrets", "users_only"); rec.Code != http.StatusNotFound {
+			t.Errorf("Expected status 404 for an unreadable table, got %d", rec.Code)
+		}
+	})
+
+	t.Run("detail", func(t *testing.T) {
+		rec := get("/duckdb/schema/secrets", "admin")
+		if rec.Code != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d: %s", rec.Code, rec.Body.String())
+		}
+		var table catalogTable
+		if err := json.Unmarshal(rec.Body.Bytes(), &table); err != nil {
+			t.Fatalf("Failed to parse response: %v", err)
+		}
+		want := catalogColumn{Name: "id", Type: "INTEGER", Nullable: false}
+		if table.Name != "secrets" || len(table.Columns) != 1 || table.Columns[0] != want {
+			t.Errorf("Expected secrets with column %+v, got %+v", want, table)
+		}
+
+		rec = get("/duckdb/schema/test_users", "admin")
+		if err := json.Unmarshal(rec.Body.Bytes(), &table); err != nil {
+			t.Fatalf("Failed to parse response: %v", err)
+		}
+		if len(table.Columns) != 4 || table.Columns[1].Name != "name" || !table.Columns[1].Nullable {
+			t.Errorf("Expected 4 columns in ordinal order, got %+v", table.Columns)
+		}
+	})
+
+	t.Run("hidden and missing tables", func(t *testing.T) {
+		if rec := get("/duckdb/schema/api_keys", "admin"); rec.Code != http.StatusNotFound {
+			t.Errorf("Expected status 404 for an internal table, got %d", rec.Code)
+		}
+		if rec := get("/duckdb/schema/missing", "admin"); rec.Code != http.StatusNotFound {
+			t.Errorf("Expected status 404 for a missing table, got %d", rec.Code)
+		}
+		if rec := get("/duckdb/schema/bad-name", "admin"); rec.Code != http.StatusBadRequest {
+			t.Errorf("Expected status 400 for an invalid table name, got %d", rec.Code)
+		}
+	})
+
+	t.Run("no readable tables", func(t *testing.T) {
+		if rec := get("/duckdb/schema", "nobody"); rec.Code != http.StatusForbidden {
+			t.Errorf("Expected status 403, got %d", rec.Code)
+		}
+	})
+
+	t.Run("method not allowed", func(t *testing.T) {
+		req := httptest.NewRequest("POST", "/duckdb/schema", nil)
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, addAuthContext(req, "admin"))
+		if rec.Code != http.StatusMethodNotAllowed {
+			t.Errorf("Expected status 405, got %d", rec.Code)
+		}
+	})
+}
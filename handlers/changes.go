@@ -0,0 +1,284 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/tobilg/caddy-duckdb-module/auth"
+	"github.com/tobilg/caddy-duckdb-module/database"
+	"github.com/tobilg/caddy-duckdb-module/formats"
+	"go.uber.org/zap"
+)
+
+const (
+	// DefaultChangesTimeout is how long a changes long-poll waits when no timeout is given.
+	DefaultChangesTimeout = 30 * time.Second
+	// MaxChangesTimeout bounds the wait of a changes long-poll.
+	MaxChangesTimeout = 60 * time.Second
+
+	// maxChangeHistory is the number of versions per table whose change time is kept
+	// for returning changed rows.
+	maxChangeHistory = 1024
+)
+
+// tableChanges holds the change counter of a single table.
+type tableChanges struct {
+	version uint64
+	// times[i] is the time version base+i was reached
+	base  uint64
+	times []time.Time
+	// notify is closed and replaced whenever the version advances
+	notify chan struct{}
+}
+
+// changeTracker keeps a per-table change counter that is advanced by writes made
+// through the table API, and lets readers wait for the counter to advance.
+// Counters live in memory and start at 0 when the server starts.
+type changeTracker struct {
+	mu      sync.Mutex
+	started time.Time
+	tables  map[string]*tableChanges
+}
+
+// newChangeTracker creates an empty change tracker.
+func newChangeTracker() *changeTracker {
+	return &changeTracker{
+		started: time.Now(),
+		tables:  make(map[string]*tableChanges),
+	}
+}
+
+// table returns the change state of a table, creating it if needed. Must be called with mu held.
+func (t *changeTracker) table(name string) *tableChanges {
+	tc, ok := t.tables[name]
+	if !ok {
+		tc = &tableChanges{times: []time.Time{t.started}, notify: make(chan struct{})}
+		t.tables[name] = tc
+	}
+	return tc
+}
+
+// Version returns the current change counter of a table.
+func (t *changeTracker) Version(table string) uint64 {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.table(table).version
+}
+
+// Bump advances the change counter of a table and wakes up waiting readers.
+func (t *changeTracker) Bump(table string) uint64 {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	tc := t.table(table)
+	tc.version++
+	tc.times = append(tc.times, time.Now())
+	if len(tc.times) > maxChangeHistory {
+		drop := len(tc.times) - maxChangeHistory
+		tc.times = tc.times[drop:]
+		tc.base += uint64(drop)
+	}
+	close(tc.notify)
+	tc.notify = make(chan struct{})
+	return tc.version
+}
+
+// ChangedAt returns the time a table reached the given version.
+// Returns false if the version is unknown (in the future or no longer in the history).
+func (t *changeTracker) ChangedAt(table string, version uint64) (time.Time, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	tc := t.table(table)
+	if version < tc.base || version > tc.version {
+		return time.Time{}, false
+	}
+	return tc.times[version-tc.base], true
+}
+
+// Wait blocks until the table's counter differs from since or the context is done.
+// It returns the current version and whether it advanced past since. A since value
+// ahead of the counter (e.g. after a server restart) returns immediately.
+func (t *changeTracker) Wait(ctx context.Context, table string, since uint64) (uint64, bool) {
+	for {
+		t.mu.Lock()
+		tc := t.table(table)
+		version, notify := tc.version, tc.notify
+		t.mu.Unlock()
+
+		if version != since {
+			return version, version > since
+		}
+
+		select {
+		case <-notify:
+		case <-ctx.Done():
+			return version, false
+		}
+	}
+}
+
+// parseChangesTimeout parses the timeout query parameter (seconds) of a changes request.
+func parseChangesTimeout(r *http.Request) (time.Duration, error) {
+	value := r.URL.Query().Get("timeout")
+	if value == "" {
+		return DefaultChangesTimeout, nil
+	}
+	seconds, err := strconv.Atoi(value)
+	if err != nil || seconds < 0 {
+		return 0, fmt.Errorf("timeout must be a non-negative number of seconds")
+	}
+	timeout := time.Duration(seconds) * time.Second
+	if timeout > MaxChangesTimeout {
+		timeout = MaxChangesTimeout
+	}
+	return timeout, nil
+}
+
+// handleChanges long-polls for changes to a table.
+// Request format: GET /duckdb/api/{table}/changes?since=<version>&timeout=<seconds>
+//
+// The request blocks until the table's change counter advances past since or the
+// timeout (default 30s, max 60s) expires, then returns the current version. If the
+// table has a change_column configured and rows=true is given, the rows whose change
+// column is newer than the time of the since version are included. Pending polls
+// count against the per-key stream limit (max_streams_per_key).
+func (h *CRUDHandler) handleChanges(w http.ResponseWriter, r *http.Request, tableName string) {
+	requestID := auth.GetRequestIDFromContext(r.Context())
+
+	// Check authorization
+	role := auth.GetRoleFromContext(r.Context())
+	allowed, err := h.authorizer.CheckPermission(role, tableName, auth.OperationRead)
+	if err != nil {
+		h.logger.Error("Failed to check permission", zap.Error(err), zap.String("request_id", requestID))
+		h.sendErrorWithRequest(w, r, "Failed to check permission", http.StatusInternalServerError)
+		return
+	}
+	if !allowed {
+		h.sendErrorWithRequest(w, r, "Forbidden: insufficient permissions for READ operation", http.StatusForbidden)
+		return
+	}
+
+	var since uint64
+	if value := r.URL.Query().Get("since"); value != "" {
+		if since, err = strconv.ParseUint(value, 10, 64); err != nil {
+			h.sendErrorWithRequest(w, r, "Invalid since parameter: must be a non-negative integer version", http.StatusBadRequest)
+			return
+		}
+	}
+	timeout, err := parseChangesTimeout(r)
+	if err != nil {
+		h.sendErrorWithRequest(w, r, fmt.Sprintf("Invalid timeout parameter: %s", err.Error()), http.StatusBadRequest)
+		return
+	}
+	includeRows := r.URL.Query().Get("rows") == "true" || r.URL.Query().Get("rows") == "1"
+
+	var changeColumn string
+	if cfg := h.tables[tableName]; cfg != nil {
+		changeColumn = cfg.ChangeColumn
+	}
+	if includeRows && changeColumn == "" {
+		h.sendErrorWithRequest(w, r, fmt.Sprintf("Change tracking column is not configured for table '%s'", tableName), http.StatusBadRequest)
+		return
+	}
+
+	// Changed rows leave out the columns the role may not read
+	denied, ok := h.deniedColumns(w, r, tableName)
+	if !ok {
+		return
+	}
+
+	release, ok := h.streams.Acquire(r.Context())
+	if !ok {
+		h.sendErrorWithRequest(w, r, fmt.Sprintf("Too many concurrent streams for this API key (limit %d)", h.streams.Max()), http.StatusTooManyRequests)
+		return
+	}
+	defer release()
+
+	ctx, cancel := context.WithTimeout(r.Context(), timeout)
+	defer cancel()
+	version, changed := h.changes.Wait(ctx, tableName, since)
+
+	response := map[string]interface{}{
+		"table":   tableName,
+		"version": version,
+		"changed": changed,
+	}
+
+	// A since value ahead of the counter means the counter was reset; clients should re-read the table
+	if version < since {
+		response["resync"] = true
+	} else if changed && includeRows {
+		sinceTime, ok := h.changes.ChangedAt(tableName, since)
+		if !ok {
+			response["resync"] = true
+		} else {
+			filters := []database.Filter{{Column: changeColumn, Operator: "gt", Value: sinceTime}}
+			sorts := []database.Sort{{Column: changeColumn, Direction: "asc"}}
+			rows, err := h.dbMgr.SelectContext(r.Context(), tableName, nil, filters, nil, nil, sorts, h.absoluteMaxRows, 0)
+			if err != nil {
+				h.logger.Error("Failed to query changed rows", zap.Error(err), zap.String("table", tableName), zap.String("request_id", requestID))
+				h.sendDetailedErrorWithRequest(w, r, "Failed to query changed rows", err, http.StatusInternalServerError)
+				return
+			}
+			defer rows.Close()
+
+			_, data, _, err := formats.ScanRows(rows, 0)
+			if err != nil {
+				h.logger.Error("Failed to read changed rows", zap.Error(err), zap.String("request_id", requestID))
+				h.sendErrorWithRequest(w, r, "Failed to read changed rows", http.StatusInternalServerError)
+				return
+			}
+			dropDeniedColumns(data, denied)
+			formats.ConvertDecimals(data, h.decimalAsNumber)
+			response["rows"] = data
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(response)
+}
+
+// MaxModifiedHeader carries the largest change column value of a
+// modified_since read, to be sent as the next modified_since.
+const MaxModifiedHeader = "X-Max-Modified"
+
+// modifiedSinceColumn returns the change column used by modified_since reads of
+// the table. It must be configured and exist in the table.
+func (h *CRUDHandler) modifiedSinceColumn(tableName string) (string, int, error) {
+	var column string
+	if cfg := h.tables[tableName]; cfg != nil {
+		column = cfg.ChangeColumn
+	}
+	if column == "" {
+		return "", http.StatusBadRequest, fmt.Errorf("change tracking column is not configured for table '%s'", tableName)
+	}
+	columns, err := h.dbMgr.TableColumns(tableName)
+	if err != nil {
+		return "", http.StatusInternalServerError, fmt.Errorf("failed to get table columns: %v", err)
+	}
+	if !containsColumn(columns, column) {
+		return "", http.StatusBadRequest, fmt.Errorf("change tracking column '%s' does not exist in table '%s'", column, tableName)
+	}
+	return column, 0, nil
+}
+
+// formatMaxModified formats the value of MaxModifiedHeader. An empty page
+// keeps the requested modified_since.
+func formatMaxModified(max interface{}, since time.Time) string {
+	switch v := max.(type) {
+	case nil:
+		return since.UTC().Format(time.RFC3339Nano)
+	case time.Time:
+		return v.UTC().Format(time.RFC3339Nano)
+	default:
+		return fmt.Sprint(v)
+	}
+}
@@ -0,0 +1,315 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/tobilg/caddy-duckdb-module/auth"
+)
+
+func TestChangeTracker_WaitAndBump(t *testing.T) {
+	tracker := newChangeTracker()
+
+	if v := tracker.Version("users"); v != 0 {
+		t.Fatalf("Expected initial version 0, got %d", v)
+	}
+
+	// A bump unblocks a pending wait
+	done := make(chan uint64)
+	go func() {
+		v, _ := tracker.Wait(context.Background(), "users", 0)
+		done <- v
+	}()
+	time.Sleep(20 * time.Millisecond)
+	tracker.Bump("users")
+
+	select {
+	case v := <-done:
+		if v != 1 {
+			t.Errorf("Expected version 1, got %d", v)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("Wait was not unblocked by Bump")
+	}
+
+	// Wait times out without changes
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if v, changed := tracker.Wait(ctx, "users", 1); changed || v != 1 {
+		t.Errorf("Expected unchanged version 1 after timeout, got %d (changed=%v)", v, changed)
+	}
+
+	// A since value ahead of the counter returns immediately
+	if v, changed := tracker.Wait(context.Background(), "users", 5); changed || v != 1 {
+		t.Errorf("Expected immediate return with version 1, got %d (changed=%v)", v, changed)
+	}
+
+	// Counters are per table
+	if v := tracker.Version("orders"); v != 0 {
+		t.Errorf("Expected version 0 for another table, got %d", v)
+	}
+}
+
+func TestChangeTracker_ChangedAt(t *testing.T) {
+	tracker := newChangeTracker()
+	for i := 0; i < maxChangeHistory+10; i++ {
+		tracker.Bump("users")
+	}
+
+	if _, ok := tracker.ChangedAt("users", 0); ok {
+		t.Error("Expected pruned version to be unknown")
+	}
+	if _, ok := tracker.ChangedAt("users", maxChangeHistory+10); !ok {
+		t.Error("Expected current version to be known")
+	}
+	if _, ok := tracker.ChangedAt("users", maxChangeHistory+11); ok {
+		t.Error("Expected future version to be unknown")
+	}
+}
+
+func TestCRUDHandler_ChangesLongPoll(t *testing.T) {
+	handler, mgr, cleanup := setupTestHandler(t)
+	defer cleanup()
+
+	if _, err := mgr.ExecMain(`CREATE TABLE events (id INTEGER, updated_at TIMESTAMP)`); err != nil {
+		t.Fatalf("Failed to create table: %v", err)
+	}
+	handler.SetTableConfigs(map[string]*TableConfig{"events": {ChangeColumn: "updated_at"}})
+
+	type pollResult struct {
+		code int
+		body map[string]interface{}
+	}
+	poll := func(target string) pollResult {
+		req := httptest.NewRequest("GET", target, nil)
+		req = addAuthContext(req, "reader")
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		var body map[string]interface{}
+		json.Unmarshal(rec.Body.Bytes(), &body)
+		return pollResult{rec.Code, body}
+	}
+
+	// A concurrent write unblocks a pending long-poll
+	results := make(chan pollResult)
+	go func() {
+		results <- poll("/duckdb/api/events/changes?since=0&timeout=10&rows=true")
+	}()
+	time.Sleep(50 * time.Millisecond)
+
+	req := httptest.NewRequest("POST", "/duckdb/api/events", bytes.NewBufferString(`{"id": 1, "updated_at": "2999-01-01T00:00:00"}`))
+	req = addAuthContext(req, "admin")
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("Expected status 201, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	select {
+	case res := <-results:
+		if res.code != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d: %v", res.code, res.body)
+		}
+		if res.body["version"] != float64(1) || res.body["changed"] != true {
+			t.Errorf("Expected version 1 and changed, got %v", res.body)
+		}
+		rows, _ := res.body["rows"].([]interface{})
+		if len(rows) != 1 {
+			t.Errorf("Expected 1 changed row, got %v", res.body["rows"])
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Long-poll was not unblocked by the write")
+	}
+
+	// No change before the timeout
+	res := poll("/duckdb/api/events/changes?since=1&timeout=0")
+	if res.code != http.StatusOK || res.body["changed"] != false || res.body["version"] != float64(1) {
+		t.Errorf("Expected unchanged version 1, got %d: %v", res.code, res.body)
+	}
+}
+
+func TestCRUDHandler_ChangesRejections(t *testing.T) {
+	handler, _, cleanup := setupTestHandler(t)
+	defer cleanup()
+
+	tests := []struct {
+		name       string
+		method     string
+		target     string
+		role       string
+		wantStatus int
+	}{
+		{"invalid since", "GET", "/duckdb/api/test_users/changes?since=abc", "admin", http.StatusBadRequest},
+		{"invalid timeout", "GET", "/duckdb/api/test_users/changes?timeout=-1", "admin", http.StatusBadRequest},
+		{"rows without change column", "GET", "/duckdb/api/test_users/changes?rows=true&timeout=0", "admin", http.StatusBadRequest},
+		{"wrong method", "POST", "/duckdb/api/test_users/changes", "admin", http.StatusMethodNotAllowed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.target, nil)
+			req = addAuthContext(req, tt.role)
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+			if rec.Code != tt.wantStatus {
+				t.Errorf("Expected status %d, got %d: %s", tt.wantStatus, rec.Code, rec.Body.String())
+			}
+		})
+	}
+}
+
+func TestCRUDHandler_ChangesStreamLimit(t *testing.T) {
+	handler, _, cleanup := setupTestHandler(t)
+	defer cleanup()
+
+	streams := auth.NewStreamLimiter(2)
+	handler.SetStreamLimiter(streams)
+
+	key := &auth.APIKey{Key: "stream-key", RoleName: "reader"}
+	poll := func() int {
+		req := httptest.NewRequest("GET", "/duckdb/api/test_users/changes?since=0&timeout=10", nil)
+		req = addAuthContext(req, "reader")
+		req = req.WithContext(auth.SetContextValues(req.Context(), key, "reader"))
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec.Code
+	}
+
+	// Open as many long-polls as the limit allows
+	codes := make(chan int, 2)
+	for i := 0; i < 2; i++ {
+		go func() { codes <- poll() }()
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for streams.Active("stream-key") < 2 {
+		if time.Now().After(deadline) {
+			t.Fatalf("Expected 2 open streams, got %d", streams.Active("stream-key"))
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	// Excess streams for the same key are rejected
+	for i := 0; i < 3; i++ {
+		if code := poll(); code != http.StatusTooManyRequests {
+			t.Errorf("Expected status 429 for excess stream, got %d", code)
+		}
+	}
+
+	// A write completes the open polls and releases their slots
+	handler.changes.Bump("test_users")
+	for i := 0; i < 2; i++ {
+		select {
+		case code := <-codes:
+			if code != http.StatusOK {
+				t.Errorf("Expected status 200 for open stream, got %d", code)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatal("Long-poll was not unblocked")
+		}
+	}
+	if n := streams.Active("stream-key"); n != 0 {
+		t.Errorf("Expected all streams to be released, got %d", n)
+	}
+}
+
+func TestCRUDHandler_ModifiedSince(t *testing.T) {
+	handler, mgr, cleanup := setupTestHandler(t)
+	defer cleanup()
+
+	if _, err := mgr.ExecMain(`CREATE TABLE events (id INTEGER, updated_at TIMESTAMP)`); err != nil {
+		t.Fatalf("Failed to create table: %v", err)
+	}
+	handler.SetTableConfigs(map[string]*TableConfig{
+		"events":     {ChangeColumn: "updated_at"},
+		"test_users": {ChangeColumn: "missing"},
+	})
+
+	pull := func(since string) ([]int, string) {
+		t.Helper()
+		req := httptest.NewRequest("GET", "/duckdb/api/events?modified_since="+since, nil)
+		req = addAuthContext(req, "reader")
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d: %s", rec.Code, rec.Body.String())
+		}
+		var result struct {
+			Data []struct {
+				ID int `json:"id"`
+			} `json:"data"`
+		}
+		if err := json.Unmarshal(rec.Body.Bytes(), &result); err != nil {
+			t.Fatalf("Failed to parse response: %v", err)
+		}
+		ids := make([]int, len(result.Data))
+		for i, row := range result.Data {
+			ids[i] = row.ID
+		}
+		return ids, rec.Header().Get(MaxModifiedHeader)
+	}
+
+	// First write, then a full pull
+	if _, err := mgr.ExecMain(`INSERT INTO events VALUES (1, '2025-01-02 10:00:00'), (2, '2025-01-01 10:00:00.5')`); err != nil {
+		t.Fatalf("Failed to insert rows: %v", err)
+	}
+	ids, next := pull("2000-01-01T00:00:00Z")
+	if len(ids) != 2 || ids[0] != 2 || ids[1] != 1 {
+		t.Errorf("Expected rows [2 1] oldest first, got %v", ids)
+	}
+	if next != "2025-01-02T10:00:00Z" {
+		t.Errorf("Expected %s 2025-01-02T10:00:00Z, got %q", MaxModifiedHeader, next)
+	}
+
+	// Second write: the next pull returns only the changed rows
+	if _, err := mgr.ExecMain(`UPDATE events SET updated_at = '2025-01-03 08:00:00' WHERE id = 2`); err != nil {
+		t.Fatalf("Failed to update row: %v", err)
+	}
+	if _, err := mgr.ExecMain(`INSERT INTO events VALUES (3, '2025-01-03 09:00:00')`); err != nil {
+		t.Fatalf("Failed to insert row: %v", err)
+	}
+	ids, next = pull(next)
+	if len(ids) != 2 || ids[0] != 2 || ids[1] != 3 {
+		t.Errorf("Expected rows [2 3], got %v", ids)
+	}
+	if next != "2025-01-03T09:00:00Z" {
+		t.Errorf("Expected %s 2025-01-03T09:00:00Z, got %q", MaxModifiedHeader, next)
+	}
+
+	// Nothing changed since: no rows, same timestamp
+	ids, again := pull(next)
+	if len(ids) != 0 || again != next {
+		t.Errorf("Expected no rows and %s %s, got %v and %q", MaxModifiedHeader, next, ids, again)
+	}
+
+	rejections := []struct {
+		name   string
+		target string
+	}{
+		{"invalid timestamp", "/duckdb/api/events?modified_since=yesterday"},
+		{"missing change column", "/duckdb/api/test_users?modified_since=2025-01-01T00:00:00Z"},
+	}
+	for _, tt := range rejections {
+		req := httptest.NewRequest("GET", tt.target, nil)
+		req = addAuthContext(req, "reader")
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		if rec.Code != http.StatusBadRequest {
+			t.Errorf("%s: expected status 400, got %d: %s", tt.name, rec.Code, rec.Body.String())
+		}
+	}
+
+	// A table without a change column
+	handler.SetTableConfigs(nil)
+	req := httptest.NewRequest("GET", "/duckdb/api/events?modified_since=2025-01-01T00:00:00Z", nil)
+	req = addAuthContext(req, "reader")
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400 without change_column, got %d: %s", rec.Code, rec.Body.String())
+	}
+}
@@ -0,0 +1,109 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"golang.org/x/sync/singleflight"
+)
+
+// SetCoalesceReads enables coalescing of identical concurrent JSON table reads:
+// while a read is in flight, identical reads by the same role wait for it and
+// are answered with a copy of its response instead of querying DuckDB again.
+func (h *CRUDHandler) SetCoalesceReads(enabled bool) {
+	h.coalesce = nil
+	if enabled {
+		h.coalesce = &coalescer{}
+	}
+}
+
+// SetCoalesceReads enables coalescing of identical concurrent JSON SELECT
+// queries, see CRUDHandler.SetCoalesceReads.
+func (h *QueryHandler) SetCoalesceReads(enabled bool) {
+	h.coalesce = nil
+	if enabled {
+		h.coalesce = &coalescer{}
+	}
+}
+
+// coalescer executes identical concurrent reads once and shares the buffered
+// response. Only fully buffered (non-streaming) responses can be shared.
+type coalescer struct {
+	group singleflight.Group
+}
+
+// bufferedResponse is an http.ResponseWriter that records a response so it
+// can be replayed to every coalesced caller.
+type bufferedResponse struct {
+	header http.Header
+	status int
+	body   bytes.Buffer
+}
+
+func (b *bufferedResponse) Header() http.Header {
+	return b.header
+}
+
+func (b *bufferedResponse) WriteHeader(status int) {
+	if b.status == 0 {
+		b.status = status
+	}
+}
+
+func (b *bufferedResponse) Write(p []byte) (int, error) {
+	if b.status == 0 {
+		b.status = http.StatusOK
+	}
+	return b.body.Write(p)
+}
+
+// serve runs fn once for concurrent requests with the same key and writes a
+// copy of its response to each of them. The shared execution is detached from
+// the cancellation of the request that started it, so a disconnecting client
+// does not fail the others. Error responses are not shared: callers that
+// joined a failed execution run fn themselves, so each error carries its own
+// request ID.
+func (c *coalescer) serve(w http.ResponseWriter, r *http.Request, key string, fn func(http.ResponseWriter, *http.Request)) {
+	executed := false
+	v, _, _ := c.group.Do(key, func() (interface{}, error) {
+		executed = true
+		resp := &bufferedResponse{header: make(http.Header)}
+		fn(resp, r.WithContext(context.WithoutCancel(r.Context())))
+		return resp, nil
+	})
+	resp := v.(*bufferedResponse)
+	if !executed && resp.status >= http.StatusBadRequest {
+		fn(w, r)
+		return
+	}
+
+	for name, values := range resp.header {
+		w.Header()[name] = append([]string(nil), values...)
+	}
+	if resp.status == 0 {
+		resp.status = http.StatusOK
+	}
+	w.WriteHeader(resp.status)
+	w.Write(resp.body.Bytes())
+}
+
+// coalesceKey joins the parts identifying a read. NUL cannot occur in a URL
+// path, an encoded query string, or a role name, so keys are unambiguous.
+func coalesceKey(parts ...string) string {
+	return strings.Join(parts, "\x00")
+}
+
+// queryCoalesceKey identifies a SELECT query by role, SQL, and parameters.
+// Only surrounding whitespace and trailing semicolons are normalized, since
+// whitespace inside string literals is significant.
+func queryCoalesceKey(role, sqlQuery string, params []interface{}) (string, bool) {
+	encoded, err := json.Marshal(params)
+	if err != nil {
+		return "", false
+	}
+	normalized := strings.TrimRight(strings.TrimSpace(sqlQuery), "; \t\r\n")
+	return coalesceKey("query", role, normalized, string(encoded)), true
+}
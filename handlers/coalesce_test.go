@@ -0,0 +1,202 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestCoalescer_Serve(t *testing.T) {
+	var c coalescer
+	var executions int32
+	release := make(chan struct{})
+	fn := func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&executions, 1)
+		<-release
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(`{"n":1}`))
+	}
+
+	const callers = 20
+	recs := make([]*httptest.ResponseRecorder, callers)
+	var wg sync.WaitGroup
+	for i := range recs {
+		recs[i] = httptest.NewRecorder()
+		wg.Add(1)
+		go func(rec *httptest.ResponseRecorder) {
+			defer wg.Done()
+			c.serve(rec, httptest.NewRequest("GET", "/", nil), "key", fn)
+		}(recs[i])
+	}
+	// Let every caller join the in-flight execution before it completes
+	time.Sleep(100 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	if n := atomic.LoadInt32(&executions); n != 1 {
+		t.Errorf("Expected 1 execution, got %d", n)
+	}
+	for i, rec := range recs {
+		if rec.Code != http.StatusOK || rec.Body.String() != `{"n":1}` || rec.Header().Get("Content-Type") != "application/json" {
+			t.Errorf("Caller %d: unexpected response %d %v %s", i, rec.Code, rec.Header(), rec.Body.String())
+		}
+	}
+
+	// Each caller gets its own copy of the headers
+	recs[0].Header().Set("Content-Type", "text/plain")
+	if recs[1].Header().Get("Content-Type") != "application/json" {
+		t.Error("Expected headers not to be shared between callers")
+	}
+}
+
+func TestCoalescer_ErrorsNotShared(t *testing.T) {
+	var c coalescer
+	var executions int32
+	release := make(chan struct{})
+	fn := func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&executions, 1) == 1 {
+			<-release
+		}
+		w.WriteHeader(http.StatusInternalServerError)
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			rec := httptest.NewRecorder()
+			c.serve(rec, httptest.NewRequest("GET", "/", nil), "key", fn)
+			if rec.Code != http.StatusInternalServerError {
+				t.Errorf("Expected status 500, got %d", rec.Code)
+			}
+		}()
+	}
+	time.Sleep(100 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	if n := atomic.LoadInt32(&executions); n != 5 {
+		t.Errorf("Expected every caller to execute after a failure, got %d executions", n)
+	}
+}
+
+func TestQueryHandler_CoalesceReads(t *testing.T) {
+	handler, mgr, cleanup := setupQueryHandler(t)
+	defer cleanup()
+	handler.SetCoalesceReads(true)
+
+	// Every execution draws from the sequence, so its value counts executions.
+	// The cross join keeps the query in flight while the other requests arrive.
+	if _, err := mgr.ExecMain("CREATE SEQUENCE executions"); err != nil {
+		t.Fatalf("Failed to create sequence: %v", err)
+	}
+	query := `{"sql": "SELECT nextval('executions') AS n, (SELECT count(*) FROM range(20000) a, range(20000) b) AS c"}`
+
+	const callers = 20
+	start := make(chan struct{})
+	bodies := make([]string, callers)
+	var wg sync.WaitGroup
+	for i := range bodies {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			<-start
+			req := httptest.NewRequest("POST", "/duckdb/query", bytes.NewBufferString(query))
+			req = addAuthContext(req, "admin")
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+			if rec.Code != http.StatusOK {
+				t.Errorf("Expected status 200, got %d: %s", rec.Code, rec.Body.String())
+			}
+			bodies[i] = rec.Body.String()
+		}(i)
+	}
+	close(start)
+	wg.Wait()
+
+	var executions int64
+	if err := mgr.QueryRowScanMain("SELECT currval('executions')", []interface{}{&executions}); err != nil {
+		t.Fatalf("Failed to read sequence: %v", err)
+	}
+	if executions != 1 {
+		t.Errorf("Expected the query to be executed once, got %d executions", executions)
+	}
+	for i, body := range bodies {
+		if body != bodies[0] {
+			t.Errorf("Caller %d got a different response: %s", i, body)
+		}
+	}
+
+	// Without coalescing, every request executes the query
+	handler.SetCoalesceReads(false)
+	req := httptest.NewRequest("POST", "/duckdb/query", bytes.NewBufferString(query))
+	req = addAuthContext(req, "admin")
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	var result struct {
+		Data []struct {
+			N int64 `json:"n"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &result); err != nil {
+		t.Fatalf("Failed to parse response: %v", err)
+	}
+	if len(result.Data) != 1 || result.Data[0].N != 2 {
+		t.Errorf("Expected a second execution, got %s", rec.Body.String())
+	}
+}
+
+func TestCRUDHandler_CoalesceReads(t *testing.T) {
+	handler, _, cleanup := setupTestHandler(t)
+	defer cleanup()
+	handler.SetCoalesceReads(true)
+
+	// Same read with reordered parameters, and a read by another role
+	tests := []struct {
+		path string
+		role string
+	}{
+		{"/duckdb/api/test_users?sort=age:desc&limit=2", "admin"},
+		{"/duckdb/api/test_users?limit=2&sort=age:desc", "admin"},
+		{"/duckdb/api/test_users?limit=2&sort=age:desc", "reader"},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest("GET", tt.path, nil)
+		req = addAuthContext(req, tt.role)
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("Expected status 200 for %s as %s, got %d: %s", tt.path, tt.role, rec.Code, rec.Body.String())
+		}
+		var result struct {
+			Data []map[string]interface{} `json:"data"`
+		}
+		if err := json.Unmarshal(rec.Body.Bytes(), &result); err != nil {
+			t.Fatalf("Failed to parse response: %v", err)
+		}
+		if len(result.Data) != 2 || result.Data[0]["name"] != "Charlie" {
+			t.Errorf("Unexpected data for %s as %s: %s", tt.path, tt.role, rec.Body.String())
+		}
+	}
+}
+
+func TestQueryCoalesceKey(t *testing.T) {
+	k1, _ := queryCoalesceKey("admin", "SELECT 1;", nil)
+	k2, _ := queryCoalesceKey("admin", "  SELECT 1 ", nil)
+	k3, _ := queryCoalesceKey("admin", "SELECT  1", nil)
+	k4, _ := queryCoalesceKey("admin", "SELECT 1", []interface{}{1})
+	k5, _ := queryCoalesceKey("reader", "SELECT 1", nil)
+	if k1 != k2 {
+		t.Error("Expected surrounding whitespace and semicolons to be ignored")
+	}
+	if k1 == k3 || k1 == k4 || k1 == k5 {
+		t.Error("Expected inner whitespace, parameters, and role to distinguish queries")
+	}
+}
@@ -0,0 +1,117 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/tobilg/caddy-duckdb-module/auth"
+	"github.com/tobilg/caddy-duckdb-module/database"
+	"go.uber.org/zap"
+)
+
+// deniedColumns returns the columns of the table the request's role may not
+// read, including the derived columns computed from them. It sends 500 and
+// returns false if they cannot be looked up.
+func (h *CRUDHandler) deniedColumns(w http.ResponseWriter, r *http.Request, tableName string) ([]string, bool) {
+	stopAuth := ServerTimingFromContext(r.Context()).Start(TimingAuth)
+	denied, err := h.authorizer.DeniedColumns(auth.GetRoleFromContext(r.Context()), tableName)
+	stopAuth()
+	if err != nil {
+		h.logger.Error("Failed to check column permissions", zap.Error(err), zap.String("table", tableName), zap.String("request_id", auth.GetRequestIDFromContext(r.Context())))
+		h.sendErrorWithRequest(w, r, "Failed to check permission", http.StatusInternalServerError)
+		return nil, false
+	}
+	if len(denied) > 0 {
+		_, name := database.SplitTableName(tableName)
+		columns := denied
+		for _, d := range h.tables[tableName].DerivedColumns() {
+			if mayReadColumns(d.Expression, name, columns) {
+				denied = append(denied, d.Name)
+			}
+		}
+	}
+	return denied, true
+}
+
+// mayReadColumns reports whether a derived column expression on table may read
+// any of columns: it names one of them or the whole row (the table), selects
+// columns by pattern with COLUMNS, or cannot be tokenized.
+func mayReadColumns(expression, table string, columns []string) bool {
+	tokens, ok := tokenizeSQL(expression)
+	if !ok {
+		return true
+	}
+	for _, tok := range tokens {
+		if strings.EqualFold(tok, table) || strings.EqualFold(tok, "COLUMNS") || containsColumn(columns, tok) {
+			return true
+		}
+	}
+	return false
+}
+
+// rejectDeniedColumns sends 403 naming the referenced columns (selected,
+// filtered, sorted, grouped, or aggregated) that are denied, since reading by
+// a column reveals its values. Columns are named as configured, whatever case
+// the request used. It returns false if a response was sent.
+func (h *CRUDHandler) rejectDeniedColumns(w http.ResponseWriter, r *http.Request, denied []string, columns ...[]string) bool {
+	var forbidden []string
+	for _, list := range columns {
+		for _, col := range list {
+			for _, d := range denied {
+				if strings.EqualFold(d, col) && !containsColumn(forbidden, d) {
+					forbidden = append(forbidden, d)
+				}
+			}
+		}
+	}
+	if len(forbidden) > 0 {
+		h.sendErrorWithRequest(w, r, fmt.Sprintf("Forbidden: insufficient permissions to read column(s) '%s'", strings.Join(forbidden, "', '")), http.StatusForbidden)
+		return false
+	}
+	return true
+}
+
+// readableColumns returns the table's columns and derived columns without the
+// denied ones, to select in place of SELECT *.
+func (h *CRUDHandler) readableColumns(tableName string, derived []database.DerivedColumn, denied []string) ([]string, error) {
+	all, err := h.dbMgr.TableColumns(tableName)
+	if err != nil {
+		return nil, err
+	}
+	for _, d := range derived {
+		all = append(all, d.Name)
+	}
+	columns := make([]string, 0, len(all))
+	for _, col := range all {
+		if !containsColumn(denied, col) {
+			columns = append(columns, col)
+		}
+	}
+	return columns, nil
+}
+
+// aggregateColumns returns the columns the aggregates compute over.
+func aggregateColumns(aggregates []database.Aggregate) []string {
+	columns := make([]string, 0, len(aggregates))
+	for _, a := range aggregates {
+		if a.Column != "*" {
+			columns = append(columns, a.Column)
+		}
+	}
+	return columns
+}
+
+// dropDeniedColumns removes the denied columns from rows read with SELECT *.
+func dropDeniedColumns(data []map[string]interface{}, denied []string) {
+	if len(denied) == 0 {
+		return
+	}
+	for _, row := range data {
+		for col := range row {
+			if containsColumn(denied, col) {
+				delete(row, col)
+			}
+		}
+	}
+}
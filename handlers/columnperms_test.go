@@ -0,0 +1,197 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/tobilg/caddy-duckdb-module/auth"
+	"github.com/tobilg/caddy-duckdb-module/database"
+)
+
+func TestMayReadColumns(t *testing.T) {
+	tests := []struct {
+		expression string
+		want       bool
+	}{
+		{"upper(name)", false},
+		{"age * 2", false},
+		{"'email'", false},
+		{"name || ' <' || email || '>'", true},
+		{"split_part(EMAIL, '@', 2)", true},
+		{"to_json(test_users)", true},
+		{"COLUMNS('e.*')", true},
+		{`"email"`, true},
+	}
+	for _, tt := range tests {
+		if got := mayReadColumns(tt.expression, "test_users", []string{"email"}); got != tt.want {
+			t.Errorf("mayReadColumns(%q) = %v, want %v", tt.expression, got, tt.want)
+		}
+	}
+}
+
+func TestCRUDHandler_Read_DeniedDerivedColumns(t *testing.T) {
+	handler, _, cleanup := setupTestHandler(t)
+	defer cleanup()
+	handler.SetTableConfigs(map[string]*TableConfig{
+		"test_users": {Derived: []database.DerivedColumn{
+			{Name: "contact", Expression: "name || ' <' || email || '>'"},
+			{Name: "label", Expression: "upper(name)"},
+		}},
+	})
+	if err := handler.authorizer.CreatePermission(auth.Permission{RoleName: "reader", TableName: "test_users", CanRead: true}); err != nil {
+		t.Fatalf("Failed to create permission: %v", err)
+	}
+	if err := handler.authorizer.SetDeniedColumns("reader", "test_users", []string{"email"}); err != nil {
+		t.Fatalf("Failed to set denied columns: %v", err)
+	}
+
+	read := func(query string) *httptest.ResponseRecorder {
+		req := addAuthContext(httptest.NewRequest("GET", "/duckdb/api/test_users"+query, nil), "reader")
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec
+	}
+
+	// SELECT * leaves out derived columns computed from the denied column
+	rec := read("")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if body := rec.Body.String(); strings.Contains(body, "@example.com") || strings.Contains(body, "contact") || !strings.Contains(body, "ALICE") {
+		t.Errorf("Expected label without contact or email, got %s", body)
+	}
+
+	// and referencing them is forbidden
+	for _, query := range []string{"?select=contact", "?filter=contact:like:%25example%25", "?sort=contact:asc"} {
+		if rec := read(query); rec.Code != http.StatusForbidden || !strings.Contains(rec.Body.String(), "'contact'") {
+			t.Errorf("Expected status 403 naming contact for %s, got %d: %s", query, rec.Code, rec.Body.String())
+		}
+	}
+	if rec := read("?select=id,label"); rec.Code != http.StatusOK {
+		t.Errorf("Expected select of an allowed derived column to succeed, got %d: %s", rec.Code, rec.Body.String())
+	}
+}
+
+func TestCRUDHandler_Read_DeniedColumns(t *testing.T) {
+	handler, _, cleanup := setupTestHandler(t)
+	defer cleanup()
+
+	if err := handler.authorizer.CreatePermission(auth.Permission{RoleName: "reader", TableName: "test_users", CanRead: true}); err != nil {
+		t.Fatalf("Failed to create permission: %v", err)
+	}
+	if err := handler.authorizer.SetDeniedColumns("reader", "test_users", []string{"email"}); err != nil {
+		t.Fatalf("Failed to set denied columns: %v", err)
+	}
+
+	read := func(role, query, accept string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("GET", "/duckdb/api/test_users"+query, nil)
+		if accept != "" {
+			req.Header.Set("Accept", accept)
+		}
+		req = addAuthContext(req, role)
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec
+	}
+	rows := func(rec *httptest.ResponseRecorder) []interface{} {
+		t.Helper()
+		var body map[string]interface{}
+		if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+			t.Fatalf("Failed to parse response: %v", err)
+		}
+		return body["data"].([]interface{})
+	}
+
+	// SELECT * leaves out the denied column for the restricted role only
+	rec := read("reader", "", "")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	data := rows(rec)
+	if len(data) != 3 {
+		t.Fatalf("Expected 3 rows, got %d", len(data))
+	}
+	for _, row := range data {
+		r := row.(map[string]interface{})
+		if _, ok := r["email"]; ok {
+			t.Errorf("Expected email to be absent, got %v", r)
+		}
+		if r["name"] == nil || r["age"] == nil || r["id"] == nil {
+			t.Errorf("Expected the other columns, got %v", r)
+		}
+	}
+	if strings.Contains(rec.Body.String(), "@example.com") {
+		t.Errorf("Expected no email values in the response, got %s", rec.Body.String())
+	}
+	if row := rows(read("admin", "", ""))[0].(map[string]interface{}); row["email"] == nil {
+		t.Errorf("Expected admin to read email, got %v", row)
+	}
+
+	// Other formats leave it out too
+	rec = read("reader", "", "text/csv")
+	if rec.Code != http.StatusOK || strings.Contains(rec.Body.String(), "email") {
+		t.Errorf("Expected CSV without email, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	// Allowed columns can still be selected explicitly
+	if rec := read("reader", "?select=id,name", ""); rec.Code != http.StatusOK || len(rows(rec)) != 3 {
+		t.Errorf("Expected select of allowed columns to succeed, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	// Catalog-qualified names of the same table are restricted alike
+	for _, query := range []string{"?catalog=memory", "?catalog=MEMORY"} {
+		if rec := read("reader", query, ""); rec.Code != http.StatusOK || strings.Contains(rec.Body.String(), "@example.com") {
+			t.Errorf("Expected %s to leave out email, got %d: %s", query, rec.Code, rec.Body.String())
+		}
+	}
+	req := addAuthContext(httptest.NewRequest("GET", "/duckdb/api/memory.test_users", nil), "reader")
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK || strings.Contains(rec.Body.String(), "@example.com") {
+		t.Errorf("Expected memory.test_users to leave out email, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	// Referencing the denied column in any way is forbidden
+	forbidden := []string{
+		"?select=id,email",
+		"?select=EMAIL",
+		"?filter=email:like:alice%25",
+		"?sort=email:asc",
+		"?group_by=email&agg=count:*",
+		"?summary=count:email",
+		"?key_by=email",
+	}
+	for _, query := range forbidden {
+		rec := read("reader", query, "")
+		if rec.Code != http.StatusForbidden {
+			t.Errorf("Expected status 403 for %s, got %d: %s", query, rec.Code, rec.Body.String())
+			continue
+		}
+		if !strings.Contains(rec.Body.String(), "'email'") {
+			t.Errorf("Expected the denied column to be named for %s, got %s", query, rec.Body.String())
+		}
+	}
+
+	// Row hashes leave out the denied column, so they do not change with it
+	hashes := func(role string) []interface{} {
+		t.Helper()
+		var result []interface{}
+		for _, row := range rows(read(role, "?include_hash=true&sort=id:asc", "")) {
+			result = append(result, row.(map[string]interface{})[database.RowHashColumn])
+		}
+		return result
+	}
+	readerBefore, adminBefore := hashes("reader"), hashes("admin")
+	if _, err := handler.dbMgr.ExecMain("UPDATE test_users SET email = 'changed@example.org' WHERE id = 1"); err != nil {
+		t.Fatalf("Failed to update email: %v", err)
+	}
+	if readerAfter := hashes("reader"); readerAfter[0] != readerBefore[0] {
+		t.Errorf("Expected the reader's row hash to ignore email, got %v then %v", readerBefore[0], readerAfter[0])
+	}
+	if adminAfter := hashes("admin"); adminAfter[0] == adminBefore[0] {
+		t.Errorf("Expected the admin's row hash to change with email, got %v", adminAfter[0])
+	}
+}
@@ -1,11 +1,17 @@
 package handlers
 
 import (
+	"bufio"
 	"database/sql"
+	"encoding/csv"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
+	"sort"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/tobilg/caddy-duckdb-module/auth"
 	"github.com/tobilg/caddy-duckdb-module/database"
@@ -19,6 +25,26 @@ type CRUDHandler struct {
 	authorizer      *auth.Authorizer
 	maxRowsPerPage  int
 	absoluteMaxRows int
+	rowLimitPolicy  string
+	tables          map[string]*TableConfig
+	csvCharset      string
+	rejectCharset   bool
+	autoCreate      bool
+	jsonKeyCase     string
+	maxColumns      int
+	downloadTTL     time.Duration
+	errorDetail     string
+	debugSQL        *DebugSQLConfig
+	pagination      *PaginationPolicy
+	responseShape   string
+	decimalAsNumber bool
+	arrowOpts       formats.ArrowOptions
+	parquetOpts     formats.ParquetOptions
+	indexAdvisor    *IndexAdvisor
+	coalesce        *coalescer
+	maintenance     func() bool
+	changes         *changeTracker
+	streams         *auth.StreamLimiter
 	logger          *zap.Logger
 }
 
@@ -29,12 +55,317 @@ func NewCRUDHandler(dbMgr *database.Manager, authorizer *auth.Authorizer, maxRow
 		authorizer:      authorizer,
 		maxRowsPerPage:  maxRowsPerPage,
 		absoluteMaxRows: absoluteMaxRows,
+		changes:         newChangeTracker(),
 		logger:          logger,
 	}
 }
 
+// SetTableConfigs sets the per-table configuration (soft delete, etc.).
+func (h *CRUDHandler) SetTableConfigs(tables map[string]*TableConfig) {
+	h.tables = tables
+}
+
+// SetCSVCharset sets the default charset for CSV responses and whether requests
+// for unsupported charsets (via Accept-Charset) are rejected with 406.
+func (h *CRUDHandler) SetCSVCharset(charset string, rejectUnsupported bool) {
+	h.csvCharset = charset
+	h.rejectCharset = rejectUnsupported
+}
+
+// SetArrowDictionaryThreshold dictionary-encodes string columns of Arrow
+// responses with at most threshold distinct values (0 disables it).
+func (h *CRUDHandler) SetArrowDictionaryThreshold(threshold int) {
+	h.arrowOpts.DictionaryThreshold = threshold
+}
+
+// SetArrowBatchSize sets the number of rows per record batch of Arrow
+// responses (0 uses formats.DefaultArrowBatchSize).
+func (h *CRUDHandler) SetArrowBatchSize(size int) {
+	h.arrowOpts.BatchSize = size
+}
+
+// SetParquetRowGroupSize sets the number of rows per row group of Parquet
+// responses (0 uses formats.DefaultParquetRowGroupSize).
+func (h *CRUDHandler) SetParquetRowGroupSize(size int) {
+	h.parquetOpts.RowGroupSize = size
+}
+
+// SetAutoCreateTables enables creating missing tables on the first POST,
+// with a schema inferred from the request body.
+func (h *CRUDHandler) SetAutoCreateTables(enabled bool) {
+	h.autoCreate = enabled
+}
+
+// SetJSONKeyCase sets the key casing for JSON output and request bodies.
+// With formats.KeyCaseCamel, snake_case column names are written as camelCase
+// and camelCase keys in create/update bodies are mapped back to column names.
+func (h *CRUDHandler) SetJSONKeyCase(keyCase string) {
+	h.jsonKeyCase = keyCase
+}
+
+// SetMaxColumns caps the number of columns accepted in a create or update body.
+// Zero disables the limit.
+func (h *CRUDHandler) SetMaxColumns(max int) {
+	h.maxColumns = max
+}
+
+// checkColumnCount rejects bodies with more columns than the configured limit.
+// It runs before any per-column work so oversized bodies are cheap to refuse.
+func (h *CRUDHandler) checkColumnCount(count int) error {
+	if h.maxColumns > 0 && count > h.maxColumns {
+		return fmt.Errorf("request body has %d columns (limit %d)", count, h.maxColumns)
+	}
+	return nil
+}
+
+// SetErrorDetail sets how much of a database error is included in error
+// responses (ErrorDetailFull, ErrorDetailSafe, or ErrorDetailMinimal).
+func (h *CRUDHandler) SetErrorDetail(level string) {
+	h.errorDetail = level
+}
+
+// SetStreamLimiter sets the limiter capping concurrent change long-polls per API key.
+func (h *CRUDHandler) SetStreamLimiter(streams *auth.StreamLimiter) {
+	h.streams = streams
+}
+
+// softDeleteColumn returns the soft-delete column configured for a table, or "" if none.
+func (h *CRUDHandler) softDeleteColumn(tableName string) string {
+	if cfg, ok := h.tables[tableName]; ok && cfg != nil {
+		return cfg.SoftDeleteColumn
+	}
+	return ""
+}
+
+// readSoftDeleteColumn returns the soft-delete column whose soft-deleted rows a
+// read hides, or "" if the table has none or the read asks for them with
+// include_deleted=true.
+func (h *CRUDHandler) readSoftDeleteColumn(r *http.Request, tableName string) string {
+	if ParseIncludeDeleted(r) {
+		return ""
+	}
+	return h.softDeleteColumn(tableName)
+}
+
+// checkFilterable returns an error if a filter references a column outside the table's filterable allowlist.
+func (h *CRUDHandler) checkFilterable(tableName string, filters []database.Filter) error {
+	cfg := h.tables[tableName]
+	for _, f := range filters {
+		if !cfg.IsFilterable(f.Column) {
+			return fmt.Errorf("column '%s' cannot be used in filters for table '%s'", f.Column, tableName)
+		}
+	}
+	return nil
+}
+
+// checkSortable returns an error if a sort references a column outside the table's sortable allowlist.
+func (h *CRUDHandler) checkSortable(tableName string, sorts []database.Sort) error {
+	cfg := h.tables[tableName]
+	for _, s := range sorts {
+		if !cfg.IsSortable(s.Column) {
+			return fmt.Errorf("column '%s' cannot be used for sorting table '%s'", s.Column, tableName)
+		}
+	}
+	return nil
+}
+
+// checkWindow returns an error if a window partitions by a column outside the table's
+// filterable allowlist or orders by a column outside its sortable allowlist.
+func (h *CRUDHandler) checkWindow(tableName string, window *database.Window) error {
+	if window == nil {
+		return nil
+	}
+	cfg := h.tables[tableName]
+	for _, col := range window.Partition {
+		if !cfg.IsFilterable(col) {
+			return fmt.Errorf("column '%s' cannot be used to partition table '%s'", col, tableName)
+		}
+	}
+	return h.checkSortable(tableName, window.Order)
+}
+
+// checkNotDerived returns an error if a column is one of the table's derived columns.
+// Derived columns exist only in reads, so writes and write filters cannot reference them.
+func (h *CRUDHandler) checkNotDerived(tableName string, columns []string) error {
+	cfg := h.tables[tableName]
+	for _, col := range columns {
+		if cfg.IsDerived(col) {
+			return fmt.Errorf("column '%s' is derived and only available in reads", col)
+		}
+	}
+	return nil
+}
+
+// DefaultValueSentinel is the value that sets a column to its DEFAULT in a
+// create body, e.g. {"name": "x", "created_at": "__DEFAULT__"}. Omitted columns
+// are set to NULL instead.
+const DefaultValueSentinel = "__DEFAULT__"
+
+// takeDefaults removes the columns set to DefaultValueSentinel from data and
+// returns their names in sorted order.
+func takeDefaults(data map[string]interface{}) []string {
+	var defaulted []string
+	for col, val := range data {
+		if s, ok := val.(string); ok && s == DefaultValueSentinel {
+			defaulted = append(defaulted, col)
+			delete(data, col)
+		}
+	}
+	sort.Strings(defaulted)
+	return defaulted
+}
+
+// unknownReadColumns returns the columns that are neither in the table nor one
+// of its derived columns. The table's columns come from the schema cache.
+func (h *CRUDHandler) unknownReadColumns(tableName string, columns []string, derived []database.DerivedColumn) ([]string, error) {
+	isDerived := make(map[string]bool, len(derived))
+	for _, d := range derived {
+		isDerived[strings.ToLower(d.Name)] = true
+	}
+	var physical []string
+	for _, col := range columns {
+		if !isDerived[strings.ToLower(col)] {
+			physical = append(physical, col)
+		}
+	}
+	if len(physical) == 0 {
+		return nil, nil
+	}
+	return h.dbMgr.UnknownColumns(tableName, physical)
+}
+
+// unknownSummaryColumns returns the aggregate columns that are neither in the
+// table nor one of its derived columns.
+func (h *CRUDHandler) unknownSummaryColumns(tableName string, aggregates []database.Aggregate) ([]string, error) {
+	cfg := h.tables[tableName]
+	columns := make([]string, 0, len(aggregates))
+	for _, a := range aggregates {
+		if !cfg.IsDerived(a.Column) {
+			columns = append(columns, a.Column)
+		}
+	}
+	if len(columns) == 0 {
+		return nil, nil
+	}
+	return h.dbMgr.UnknownColumns(tableName, columns)
+}
+
+// rejectUnknownColumns sends 400 naming the columns of a read parameter (kind,
+// e.g. "filter") that do not exist in the table, so a typo gets a clear error
+// instead of a database binder error. It returns false if a response was sent.
+func (h *CRUDHandler) rejectUnknownColumns(w http.ResponseWriter, r *http.Request, tableName string, derived []database.DerivedColumn, kind string, columns []string) bool {
+	if len(columns) == 0 {
+		return true
+	}
+	unknown, err := h.unknownReadColumns(tableName, columns, derived)
+	if err != nil {
+		h.logger.Error("Failed to check columns", zap.Error(err), zap.String("table", tableName), zap.String("request_id", auth.GetRequestIDFromContext(r.Context())))
+		h.sendDetailedErrorWithRequest(w, r, "Failed to get table schema", err, http.StatusInternalServerError)
+		return false
+	}
+	if len(unknown) > 0 {
+		h.sendErrorWithRequest(w, r, fmt.Sprintf("Invalid %s: unknown column(s) '%s'", kind, strings.Join(unknown, "', '")), http.StatusBadRequest)
+		return false
+	}
+	return true
+}
+
+// sortColumns returns the column names referenced by the sorts.
+func sortColumns(sorts []database.Sort) []string {
+	columns := make([]string, len(sorts))
+	for i, s := range sorts {
+		columns[i] = s.Column
+	}
+	return columns
+}
+
+// windowColumns returns the column names a window partitions and orders by.
+func windowColumns(window *database.Window) []string {
+	if window == nil {
+		return nil
+	}
+	return append(append([]string{}, window.Partition...), sortColumns(window.Order)...)
+}
+
+// filterColumns returns the column names referenced by the filters.
+func filterColumns(filters []database.Filter) []string {
+	columns := make([]string, len(filters))
+	for i, f := range filters {
+		columns[i] = f.Column
+	}
+	return columns
+}
+
+// dropUnknownColumns checks the keys of data against the table's columns.
+// Unknown columns are rejected with an error unless ignore_unknown=true is set,
+// in which case they are removed from data, logged at debug level, and reported
+// in the X-Ignored-Columns response header.
+func (h *CRUDHandler) dropUnknownColumns(w http.ResponseWriter, r *http.Request, tableName string, data map[string]interface{}) error {
+	columns := make([]string, 0, len(data))
+	for col := range data {
+		columns = append(columns, col)
+	}
+	unknown, err := h.dbMgr.UnknownColumns(tableName, columns)
+	if err != nil {
+		return fmt.Errorf("failed to get table schema: %w", err)
+	}
+	if len(unknown) == 0 {
+		return nil
+	}
+	if !ParseIgnoreUnknown(r) {
+		return &unknownColumnsError{columns: unknown}
+	}
+
+	for _, col := range unknown {
+		delete(data, col)
+	}
+	h.logger.Debug("Ignoring unknown columns",
+		zap.String("table", tableName),
+		zap.Strings("columns", unknown),
+		zap.String("request_id", auth.GetRequestIDFromContext(r.Context())),
+	)
+	w.Header().Set("X-Ignored-Columns", strings.Join(unknown, ","))
+	return nil
+}
+
+// unknownColumnsError reports columns that do not exist in the table.
+type unknownColumnsError struct {
+	columns []string
+}
+
+func (e *unknownColumnsError) Error() string {
+	return fmt.Sprintf("unknown column(s) '%s' (use ignore_unknown=true to drop them)", strings.Join(e.columns, "', '"))
+}
+
+// validateRow applies the table's validation rules and JSON Schema to a row
+// to be inserted. The defaulted columns may be missing from the row.
+func (h *CRUDHandler) validateRow(tableName string, data map[string]interface{}, defaulted []string) *ValidationError {
+	cfg, ok := h.tables[tableName]
+	if !ok || cfg == nil {
+		return nil
+	}
+	if verr := cfg.validateSchema(data, func(column string) bool { return containsColumn(defaulted, column) }); verr != nil {
+		return verr
+	}
+	return validateValues(cfg.validators, data)
+}
+
+// validateSet applies the table's validation rules and JSON Schema to the SET
+// values of an update. Required properties of the schema may be missing.
+func (h *CRUDHandler) validateSet(tableName string, set map[string]interface{}) *ValidationError {
+	cfg, ok := h.tables[tableName]
+	if !ok || cfg == nil {
+		return nil
+	}
+	if verr := cfg.validateSchema(set, func(string) bool { return true }); verr != nil {
+		return verr
+	}
+	return validateValues(cfg.validators, set)
+}
+
 // ServeHTTP handles HTTP requests for CRUD operations.
 func (h *CRUDHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	r = withQueryTag(r)
 	requestID := auth.GetRequestIDFromContext(r.Context())
 
 	// Extract table name from path: /duckdb/api/{table}
@@ -44,38 +375,71 @@ func (h *CRUDHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Sanitize table name
-	if err := SanitizeTableName(tableName); err != nil {
+	// Sanitize table name and qualify it with the requested catalog
+	tableName, err := ResolveTableName(r, tableName, h.dbMgr)
+	if errors.Is(err, errCatalogLookup) {
+		h.logger.Error("Failed to resolve table", zap.Error(err), zap.String("request_id", requestID))
+		h.sendDetailedErrorWithRequest(w, r, "Failed to resolve table", err, http.StatusInternalServerError)
+		return
+	}
+	if err != nil {
 		h.sendErrorWithRequest(w, r, err.Error(), http.StatusBadRequest)
 		return
 	}
 
 	// Block access to internal auth tables
-	if auth.IsInternalTable(tableName) {
+	if _, name := database.SplitTableName(tableName); auth.IsInternalTable(name) {
 		h.sendErrorWithRequest(w, r, "Access to internal tables is forbidden", http.StatusForbidden)
 		return
 	}
 
+	// Writes are refused while maintenance mode is on
+	if inMaintenance(h.maintenance) && isWriteRequest(r) {
+		h.sendErrorWithRequest(w, r, maintenanceModeMessage, http.StatusServiceUnavailable)
+		return
+	}
+
 	// Check if table exists
 	exists, err := h.dbMgr.TableExists(tableName)
 	if err != nil {
 		h.logger.Error("Failed to check table existence", zap.Error(err), zap.String("request_id", requestID))
-		h.sendErrorWithRequest(w, r, "Failed to check table existence", http.StatusInternalServerError)
+		h.sendDetailedErrorWithRequest(w, r, "Failed to check table existence", err, http.StatusInternalServerError)
 		return
 	}
 	if !exists {
+		// Optionally create the table from the first inserted row
+		if h.autoCreate && r.Method == http.MethodPost && ExtractActionFromPath(r.URL.Path) == "" {
+			h.handleAutoCreate(w, r, tableName)
+			return
+		}
 		h.sendErrorWithRequest(w, r, fmt.Sprintf("Table '%s' does not exist", tableName), http.StatusNotFound)
 		return
 	}
 
+	// Route table sub-resources: /duckdb/api/{table}/{action}
+	if action := ExtractActionFromPath(r.URL.Path); action != "" {
+		h.handleAction(w, r, tableName, action)
+		return
+	}
+
 	// Route based on HTTP method
 	switch r.Method {
 	case http.MethodPost:
 		h.handleCreate(w, r, tableName)
 	case http.MethodGet:
+		// Identical concurrent JSON reads share one execution; debug output is per request
+		if h.coalesce != nil && GetAcceptFormat(r) == "json" && !h.debugSQL.allows(r) {
+			key := coalesceKey("read", auth.GetRoleFromContext(r.Context()), tableName, r.URL.Path, r.URL.Query().Encode())
+			h.coalesce.serve(w, r, key, func(w http.ResponseWriter, r *http.Request) {
+				h.handleRead(w, r, tableName)
+			})
+			return
+		}
 		h.handleRead(w, r, tableName)
 	case http.MethodPut:
 		h.handleUpdate(w, r, tableName)
+	case http.MethodPatch:
+		h.handleUpsert(w, r, tableName)
 	case http.MethodDelete:
 		h.handleDelete(w, r, tableName)
 	default:
@@ -84,12 +448,15 @@ func (h *CRUDHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 }
 
 // handleCreate handles INSERT operations.
+// A JSON array body inserts all of its rows in one transaction (see handleBulkInsert).
 func (h *CRUDHandler) handleCreate(w http.ResponseWriter, r *http.Request, tableName string) {
 	requestID := auth.GetRequestIDFromContext(r.Context())
 
 	// Check authorization
 	role := auth.GetRoleFromContext(r.Context())
+	stopAuth := ServerTimingFromContext(r.Context()).Start(TimingAuth)
 	allowed, err := h.authorizer.CheckPermission(role, tableName, auth.OperationCreate)
+	stopAuth()
 	if err != nil {
 		h.logger.Error("Failed to check permission", zap.Error(err), zap.String("request_id", requestID))
 		h.sendErrorWithRequest(w, r, "Failed to check permission", http.StatusInternalServerError)
@@ -103,11 +470,28 @@ func (h *CRUDHandler) handleCreate(w http.ResponseWriter, r *http.Request, table
 	// Parse request body using streaming decoder for better performance
 	defer r.Body.Close()
 
+	// An array of objects is inserted in a single transaction
+	body := bufio.NewReader(r.Body)
+	dryRun := ParseDryRun(r)
+	if isJSONArray(body) {
+		if dryRun {
+			h.sendErrorWithRequest(w, r, "dry_run is not supported for bulk inserts", http.StatusBadRequest)
+			return
+		}
+		h.handleBulkInsert(w, r, tableName, body)
+		return
+	}
+
 	var data map[string]interface{}
-	if err := json.NewDecoder(r.Body).Decode(&data); err != nil {
+	if err := json.NewDecoder(body).Decode(&data); err != nil {
 		h.sendErrorWithRequest(w, r, "Invalid JSON in request body", http.StatusBadRequest)
 		return
 	}
+	if err := h.checkColumnCount(len(data)); err != nil {
+		h.sendErrorWithRequest(w, r, fmt.Sprintf("Too many columns: %s", err.Error()), http.StatusBadRequest)
+		return
+	}
+	data = formats.MapInputKeys(data, h.jsonKeyCase)
 
 	// Validate column names
 	for col := range data {
@@ -117,24 +501,207 @@ func (h *CRUDHandler) handleCreate(w http.ResponseWriter, r *http.Request, table
 		}
 	}
 
+	columns := make([]string, 0, len(data))
+	for col := range data {
+		columns = append(columns, col)
+	}
+	if err := h.checkNotDerived(tableName, columns); err != nil {
+		h.sendErrorWithRequest(w, r, fmt.Sprintf("Invalid column: %s", err.Error()), http.StatusBadRequest)
+		return
+	}
+
+	if err := h.dropUnknownColumns(w, r, tableName, data); err != nil {
+		var unknownErr *unknownColumnsError
+		if errors.As(err, &unknownErr) {
+			if dryRun {
+				h.sendCreateDryRunResult(w, r, []string{fmt.Sprintf("Invalid column: %s", err.Error())})
+				return
+			}
+			h.sendErrorWithRequest(w, r, fmt.Sprintf("Invalid column: %s", err.Error()), http.StatusBadRequest)
+			return
+		}
+		h.logger.Error("Failed to check columns", zap.Error(err), zap.String("table", tableName), zap.String("request_id", requestID))
+		h.sendDetailedErrorWithRequest(w, r, "Failed to insert data", err, http.StatusInternalServerError)
+		return
+	}
+	if len(data) == 0 {
+		h.sendErrorWithRequest(w, r, "Request body contains no known columns", http.StatusBadRequest)
+		return
+	}
+
+	// Apply table validation rules; defaulted columns have no value to validate
+	defaulted := takeDefaults(data)
+	if verr := h.validateRow(tableName, data, defaulted); verr != nil {
+		if dryRun {
+			h.sendCreateDryRunResult(w, r, validationProblems(verr))
+			return
+		}
+		h.sendValidationErrorWithRequest(w, r, verr)
+		return
+	}
+	for _, col := range defaulted {
+		data[col] = database.Default
+	}
+
+	// A dry run executes the insert and rolls it back, so the database checks
+	// types and constraints without the row being stored
+	if dryRun {
+		stopDB := ServerTimingFromContext(r.Context()).Start(TimingDB)
+		err := h.dbMgr.InsertDryRun(tableName, data)
+		stopDB()
+		if err == nil {
+			h.sendCreateDryRunResult(w, r, nil)
+			return
+		}
+		switch category := CategorizeError(err); category {
+		case ErrorCategoryConstraint, ErrorCategoryConversion:
+			problem := fmt.Sprintf("Insert would fail (%s)", category)
+			if h.errorDetail != ErrorDetailSafe && h.errorDetail != ErrorDetailMinimal {
+				problem += ": " + err.Error()
+			}
+			h.sendCreateDryRunResult(w, r, []string{problem})
+		default:
+			h.logger.Error("Failed to dry-run insert", zap.Error(err), zap.String("table", tableName), zap.String("request_id", requestID))
+			h.sendDetailedErrorWithRequest(w, r, "Failed to insert data", err, http.StatusInternalServerError)
+		}
+		return
+	}
+
 	// Execute insert
+	stopDB := ServerTimingFromContext(r.Context()).Start(TimingDB)
 	result, err := h.dbMgr.Insert(tableName, data)
+	stopDB()
 	if err != nil {
 		h.logger.Error("Failed to insert data", zap.Error(err), zap.String("table", tableName), zap.String("request_id", requestID))
-		h.sendErrorWithRequest(w, r, fmt.Sprintf("Failed to insert data: %s", err.Error()), http.StatusInternalServerError)
+		h.sendDetailedErrorWithRequest(w, r, "Failed to insert data", err, http.StatusInternalServerError)
 		return
 	}
 
+	h.changes.Bump(tableName)
+	h.sendSuccessWithRequest(w, r, result.RowsAffected, http.StatusCreated)
+}
+
+// handleAutoCreate creates a missing table from the JSON body's value types and inserts the row.
+// Only used when auto_create_tables is enabled. Requires create permission on all tables ('*').
+func (h *CRUDHandler) handleAutoCreate(w http.ResponseWriter, r *http.Request, tableName string) {
+	requestID := auth.GetRequestIDFromContext(r.Context())
+
+	// Check authorization: creating tables needs the '*' grant, and the insert
+	// the table's own, as for inserts into existing tables
+	role := auth.GetRoleFromContext(r.Context())
+	allowed, err := h.authorizer.CheckPermission(role, "*", auth.OperationCreate)
+	if err == nil && allowed {
+		allowed, err = h.authorizer.CheckPermission(role, tableName, auth.OperationCreate)
+	}
+	if err != nil {
+		h.logger.Error("Failed to check permission", zap.Error(err), zap.String("request_id", requestID))
+		h.sendErrorWithRequest(w, r, "Failed to check permission", http.StatusInternalServerError)
+		return
+	}
+	if !allowed {
+		h.sendErrorWithRequest(w, r, fmt.Sprintf("Table '%s' does not exist and creating tables requires CREATE permission on all tables ('*') and on the table", tableName), http.StatusForbidden)
+		return
+	}
+
+	defer r.Body.Close()
+
+	var data map[string]interface{}
+	if err := json.NewDecoder(r.Body).Decode(&data); err != nil {
+		h.sendErrorWithRequest(w, r, "Invalid JSON in request body", http.StatusBadRequest)
+		return
+	}
+	if err := h.checkColumnCount(len(data)); err != nil {
+		h.sendErrorWithRequest(w, r, fmt.Sprintf("Too many columns: %s", err.Error()), http.StatusBadRequest)
+		return
+	}
+	data = formats.MapInputKeys(data, h.jsonKeyCase)
+	if len(data) == 0 {
+		h.sendErrorWithRequest(w, r, "At least one column is required to create a table", http.StatusBadRequest)
+		return
+	}
+	if defaulted := takeDefaults(data); len(defaulted) > 0 {
+		h.sendErrorWithRequest(w, r, fmt.Sprintf("Column '%s' cannot use %s: table '%s' does not exist yet", defaulted[0], DefaultValueSentinel, tableName), http.StatusBadRequest)
+		return
+	}
+
+	// Validate column names
+	for col := range data {
+		if err := SanitizeColumnName(col); err != nil {
+			h.sendErrorWithRequest(w, r, fmt.Sprintf("Invalid column name '%s': %s", col, err.Error()), http.StatusBadRequest)
+			return
+		}
+	}
+
+	columns := make([]string, 0, len(data))
+	for col := range data {
+		columns = append(columns, col)
+	}
+	if err := h.checkNotDerived(tableName, columns); err != nil {
+		h.sendErrorWithRequest(w, r, fmt.Sprintf("Invalid column: %s", err.Error()), http.StatusBadRequest)
+		return
+	}
+
+	// Apply table validation rules
+	if verr := h.validateRow(tableName, data, nil); verr != nil {
+		h.sendValidationErrorWithRequest(w, r, verr)
+		return
+	}
+
+	// The table must really be new: a name DuckDB resolves to an existing table
+	// would otherwise insert past that table's own checks
+	if err := h.dbMgr.CreateTableFromRow(tableName, data); errors.Is(err, database.ErrTableExists) {
+		h.sendErrorWithRequest(w, r, fmt.Sprintf("Table '%s' already exists", tableName), http.StatusConflict)
+		return
+	} else if err != nil {
+		h.logger.Error("Failed to auto-create table", zap.Error(err), zap.String("table", tableName), zap.String("request_id", requestID))
+		h.sendDetailedErrorWithRequest(w, r, "Failed to create table", err, http.StatusInternalServerError)
+		return
+	}
+
+	h.logger.Warn("Auto-created table from insert",
+		zap.String("table", tableName),
+		zap.String("role", role),
+		zap.String("request_id", requestID),
+	)
+
+	// Objects and arrays are stored in JSON columns as serialized text
+	for col, val := range data {
+		switch val.(type) {
+		case map[string]interface{}, []interface{}:
+			encoded, err := json.Marshal(val)
+			if err != nil {
+				h.sendErrorWithRequest(w, r, fmt.Sprintf("Invalid value for column '%s': %s", col, err.Error()), http.StatusBadRequest)
+				return
+			}
+			data[col] = string(encoded)
+		}
+	}
+
+	result, err := h.dbMgr.Insert(tableName, data)
+	if err != nil {
+		h.logger.Error("Failed to insert data", zap.Error(err), zap.String("table", tableName), zap.String("request_id", requestID))
+		h.sendDetailedErrorWithRequest(w, r, "Failed to insert data", err, http.StatusInternalServerError)
+		return
+	}
+
+	h.changes.Bump(tableName)
 	h.sendSuccessWithRequest(w, r, result.RowsAffected, http.StatusCreated)
 }
 
 // handleRead handles SELECT operations.
 func (h *CRUDHandler) handleRead(w http.ResponseWriter, r *http.Request, tableName string) {
+	if IsGroupedRead(r) {
+		h.handleGroupedRead(w, r, tableName)
+		return
+	}
+
 	requestID := auth.GetRequestIDFromContext(r.Context())
 
 	// Check authorization
 	role := auth.GetRoleFromContext(r.Context())
+	stopAuth := ServerTimingFromContext(r.Context()).Start(TimingAuth)
 	allowed, err := h.authorizer.CheckPermission(role, tableName, auth.OperationRead)
+	stopAuth()
 	if err != nil {
 		h.logger.Error("Failed to check permission", zap.Error(err), zap.String("request_id", requestID))
 		h.sendErrorWithRequest(w, r, "Failed to check permission", http.StatusInternalServerError)
@@ -147,62 +714,383 @@ func (h *CRUDHandler) handleRead(w http.ResponseWriter, r *http.Request, tableNa
 
 	// Parse pagination
 	limit, offset, page, paginationRequested := ParsePagination(r, h.maxRowsPerPage, h.absoluteMaxRows)
+	if err := h.checkPagination(tableName, page, offset); err != nil {
+		h.sendErrorWithRequest(w, r, fmt.Sprintf("Invalid pagination: %s", err.Error()), http.StatusBadRequest)
+		return
+	}
+
+	// Keyset (cursor) pagination always reads a page, maxRowsPerPage by default
+	keyset, err := ParseKeyset(r)
+	if err != nil {
+		h.sendErrorWithRequest(w, r, fmt.Sprintf("Invalid cursor: %s", err.Error()), http.StatusBadRequest)
+		return
+	}
+	if keyset != nil {
+		if err := SanitizeColumnName(keyset.Column); err != nil {
+			h.sendErrorWithRequest(w, r, fmt.Sprintf("Invalid cursor column '%s': %s", keyset.Column, err.Error()), http.StatusBadRequest)
+			return
+		}
+		if !paginationRequested {
+			limit, page, paginationRequested = h.maxRowsPerPage, 1, true
+		}
+	}
 
 	// Apply safety limit if pagination not requested and absoluteMaxRows is configured
 	safetyLimit := limit
 	if !paginationRequested && h.absoluteMaxRows > 0 {
 		safetyLimit = h.absoluteMaxRows
 	}
-
-	// Parse filters
-	filters, err := ParseFilters(r)
-	if err != nil {
-		h.sendErrorWithRequest(w, r, fmt.Sprintf("Invalid filters: %s", err.Error()), http.StatusBadRequest)
+
+	// Parse filters
+	filters, err := ParseFilters(r)
+	if err != nil {
+		h.sendErrorWithRequest(w, r, fmt.Sprintf("Invalid filters: %s", err.Error()), http.StatusBadRequest)
+		return
+	}
+
+	// Validate filter column names
+	for _, f := range filters {
+		if err := SanitizeColumnName(f.Column); err != nil {
+			h.sendErrorWithRequest(w, r, fmt.Sprintf("Invalid filter column '%s': %s", f.Column, err.Error()), http.StatusBadRequest)
+			return
+		}
+	}
+
+	// Parse sorts
+	sorts, err := ParseSorts(r)
+	if err != nil {
+		h.sendErrorWithRequest(w, r, fmt.Sprintf("Invalid sort: %s", err.Error()), http.StatusBadRequest)
+		return
+	}
+
+	// Validate sort column names
+	for _, s := range sorts {
+		if err := SanitizeColumnName(s.Column); err != nil {
+			h.sendErrorWithRequest(w, r, fmt.Sprintf("Invalid sort column '%s': %s", s.Column, err.Error()), http.StatusBadRequest)
+			return
+		}
+	}
+
+	// Enforce the table's filterable/sortable allowlists
+	if err := h.checkFilterable(tableName, filters); err != nil {
+		h.sendErrorWithRequest(w, r, fmt.Sprintf("Invalid filter: %s", err.Error()), http.StatusBadRequest)
+		return
+	}
+	if err := h.checkSortable(tableName, sorts); err != nil {
+		h.sendErrorWithRequest(w, r, fmt.Sprintf("Invalid sort: %s", err.Error()), http.StatusBadRequest)
+		return
+	}
+	if keyset != nil {
+		if err := h.checkFilterable(tableName, keyset.Filters(nil)); err != nil {
+			h.sendErrorWithRequest(w, r, fmt.Sprintf("Invalid cursor: %s", err.Error()), http.StatusBadRequest)
+			return
+		}
+		if err := h.checkSortable(tableName, keyset.Sorts()); err != nil {
+			h.sendErrorWithRequest(w, r, fmt.Sprintf("Invalid cursor: %s", err.Error()), http.StatusBadRequest)
+			return
+		}
+	}
+
+	// Parse the optional window (QUALIFY) restriction
+	window, err := ParseWindow(r)
+	if err != nil {
+		h.sendErrorWithRequest(w, r, fmt.Sprintf("Invalid window: %s", err.Error()), http.StatusBadRequest)
+		return
+	}
+	if err := h.checkWindow(tableName, window); err != nil {
+		h.sendErrorWithRequest(w, r, fmt.Sprintf("Invalid window: %s", err.Error()), http.StatusBadRequest)
+		return
+	}
+
+	// Parse the optional random sample
+	sample, err := ParseSample(r)
+	if err != nil {
+		h.sendErrorWithRequest(w, r, fmt.Sprintf("Invalid sample: %s", err.Error()), http.StatusBadRequest)
+		return
+	}
+
+	// Incremental reads return the rows changed after modified_since, oldest first
+	modifiedSince, err := ParseModifiedSince(r)
+	if err != nil {
+		h.sendErrorWithRequest(w, r, fmt.Sprintf("Invalid modified_since: %s", err.Error()), http.StatusBadRequest)
+		return
+	}
+	var changeColumn string
+	if modifiedSince != nil {
+		var status int
+		if changeColumn, status, err = h.modifiedSinceColumn(tableName); err != nil {
+			h.sendErrorWithRequest(w, r, fmt.Sprintf("Invalid modified_since: %s", err.Error()), status)
+			return
+		}
+		filters = append(filters, database.Filter{Column: changeColumn, Operator: "gt", Value: *modifiedSince})
+		sorts = append([]database.Sort{{Column: changeColumn, Direction: "asc"}}, sorts...)
+	}
+
+	// Keyset reads filter and order by their key, which windows, samples, and
+	// modified_since would change
+	if keyset != nil && (window != nil || sample != nil || modifiedSince != nil) {
+		h.sendErrorWithRequest(w, r, "Invalid cursor: after and cursor cannot be combined with window, sample, or modified_since", http.StatusBadRequest)
+		return
+	}
+
+	// Hide soft-deleted rows unless include_deleted=true
+	requestedFilters := filters
+	if col := h.readSoftDeleteColumn(r, tableName); col != "" {
+		filters = append(filters, database.Filter{Column: col, Operator: "is_null"})
+	}
+
+	// Read the page after the keyset position
+	keysetFilters := filters
+	if keyset != nil {
+		filters = keyset.Filters(filters)
+		sorts = keyset.Sorts()
+	}
+
+	// Determine response format
+	format := GetAcceptFormat(r)
+	var csvOpts formats.CSVOptions
+	if format == "csv" {
+		var ok bool
+		if csvOpts.Charset, ok = NegotiateCSVCharset(r, h.csvCharset, h.rejectCharset); !ok {
+			h.sendErrorWithRequest(w, r, fmt.Sprintf("None of the requested charsets are supported: %s", r.Header.Get("Accept-Charset")), http.StatusNotAcceptable)
+			return
+		}
+		if csvOpts.Delimiter, csvOpts.QuoteAll, err = ParseCSVDialect(r); err != nil {
+			h.sendErrorWithRequest(w, r, fmt.Sprintf("Invalid CSV options: %s", err.Error()), http.StatusBadRequest)
+			return
+		}
+	}
+	parquetOpts := h.parquetOpts
+	if format == "parquet" {
+		if parquetOpts.Compression, err = ParseParquetCompression(r); err != nil {
+			h.sendErrorWithRequest(w, r, fmt.Sprintf("Invalid compression: %s", err.Error()), http.StatusBadRequest)
+			return
+		}
+	}
+
+	// Key the JSON data object by a column instead of returning an array
+	keyBy, keyByLastWins, err := ParseKeyBy(r)
+	if err != nil {
+		h.sendErrorWithRequest(w, r, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if keyBy != "" && format != "json" {
+		h.sendErrorWithRequest(w, r, "key_by is only supported for JSON responses", http.StatusBadRequest)
+		return
+	}
+
+	// Return only the selected columns
+	columns, err := ParseSelect(r)
+	if err != nil {
+		h.sendErrorWithRequest(w, r, fmt.Sprintf("Invalid select: %s", err.Error()), http.StatusBadRequest)
+		return
+	}
+
+	// Aggregates over all matching rows, returned alongside the page
+	aggregates, err := ParseSummary(r)
+	if err != nil {
+		h.sendErrorWithRequest(w, r, fmt.Sprintf("Invalid summary: %s", err.Error()), http.StatusBadRequest)
+		return
+	}
+	if aggregates != nil && format != "json" {
+		h.sendErrorWithRequest(w, r, "summary is only supported for JSON responses", http.StatusBadRequest)
+		return
+	}
+
+	// Return the query plan instead of the rows
+	explain := ParseExplain(r)
+	if explain && format != "json" {
+		h.sendErrorWithRequest(w, r, "explain is only supported for JSON responses", http.StatusBadRequest)
+		return
+	}
+
+	// Columns the role may not read cannot be referenced and are left out of SELECT *
+	denied, ok := h.deniedColumns(w, r, tableName)
+	if !ok {
+		return
+	}
+
+	// Execute query with safety limit
+	derived := h.tables[tableName].DerivedColumns()
+
+	// Add the per-row hash column if requested; denied columns are not hashed,
+	// since the hash would tell which rows share their values
+	if ParseIncludeHash(r) {
+		hashColumns := h.tables[tableName].HashColumnList()
+		if len(hashColumns) == 0 {
+			if hashColumns, err = h.dbMgr.TableColumns(tableName); err != nil {
+				h.logger.Error("Failed to get table columns", zap.Error(err), zap.String("table", tableName), zap.String("request_id", requestID))
+				h.sendDetailedErrorWithRequest(w, r, "Failed to get table columns", err, http.StatusInternalServerError)
+				return
+			}
+		}
+		if len(denied) > 0 {
+			readable := make([]string, 0, len(hashColumns))
+			for _, col := range hashColumns {
+				if !containsColumn(denied, col) {
+					readable = append(readable, col)
+				}
+			}
+			hashColumns = readable
+		}
+		if len(hashColumns) == 0 {
+			h.sendErrorWithRequest(w, r, "Forbidden: insufficient permissions to read the hashed columns", http.StatusForbidden)
+			return
+		}
+		hash := database.DerivedColumn{Name: database.RowHashColumn, Expression: database.RowHashExpression(hashColumns)}
+		derived = append(append([]database.DerivedColumn{}, derived...), hash)
+		if len(columns) > 0 && !containsColumn(columns, database.RowHashColumn) {
+			columns = append(columns, database.RowHashColumn)
+		}
+	}
+	if unknown, err := h.unknownReadColumns(tableName, columns, derived); err != nil {
+		h.logger.Error("Failed to check columns", zap.Error(err), zap.String("table", tableName), zap.String("request_id", requestID))
+		h.sendDetailedErrorWithRequest(w, r, "Failed to query data", err, http.StatusInternalServerError)
+		return
+	} else if len(unknown) > 0 {
+		h.sendErrorWithRequest(w, r, fmt.Sprintf("Invalid select: unknown column(s) '%s'", strings.Join(unknown, "', '")), http.StatusBadRequest)
+		return
+	}
+	if !h.rejectUnknownColumns(w, r, tableName, derived, "filter", filterColumns(filters)) ||
+		!h.rejectUnknownColumns(w, r, tableName, derived, "sort", sortColumns(sorts)) ||
+		!h.rejectUnknownColumns(w, r, tableName, derived, "window", windowColumns(window)) {
+		return
+	}
+	if unknown, err := h.unknownSummaryColumns(tableName, aggregates); err != nil {
+		h.logger.Error("Failed to check columns", zap.Error(err), zap.String("table", tableName), zap.String("request_id", requestID))
+		h.sendDetailedErrorWithRequest(w, r, "Failed to query data", err, http.StatusInternalServerError)
+		return
+	} else if len(unknown) > 0 {
+		h.sendErrorWithRequest(w, r, fmt.Sprintf("Invalid summary: unknown column(s) '%s'", strings.Join(unknown, "', '")), http.StatusBadRequest)
 		return
 	}
 
-	// Validate filter column names
-	for _, f := range filters {
-		if err := SanitizeColumnName(f.Column); err != nil {
-			h.sendErrorWithRequest(w, r, fmt.Sprintf("Invalid filter column '%s': %s", f.Column, err.Error()), http.StatusBadRequest)
+	if len(denied) > 0 {
+		var keyColumns []string
+		if keyset != nil {
+			keyColumns = append(keyColumns, keyset.Column)
+		}
+		if keyBy != "" {
+			keyColumns = append(keyColumns, keyBy)
+		}
+		if !h.rejectDeniedColumns(w, r, denied, columns, filterColumns(requestedFilters), sortColumns(sorts), windowColumns(window), aggregateColumns(aggregates), keyColumns) {
 			return
 		}
+		if len(columns) == 0 {
+			if columns, err = h.readableColumns(tableName, derived, denied); err != nil {
+				h.logger.Error("Failed to get table columns", zap.Error(err), zap.String("table", tableName), zap.String("request_id", requestID))
+				h.sendDetailedErrorWithRequest(w, r, "Failed to get table columns", err, http.StatusInternalServerError)
+				return
+			}
+		}
 	}
-
-	// Parse sorts
-	sorts, err := ParseSorts(r)
-	if err != nil {
-		h.sendErrorWithRequest(w, r, fmt.Sprintf("Invalid sort: %s", err.Error()), http.StatusBadRequest)
+	projection := columns
+	if format == "csv" {
+		if projection, err = h.wktProjection(r.Context(), tableName, columns, derived); err != nil {
+			h.logger.Error("Failed to check geometry columns", zap.Error(err), zap.String("table", tableName), zap.String("request_id", requestID))
+			h.sendDetailedErrorWithRequest(w, r, "Failed to query data", err, http.StatusInternalServerError)
+			return
+		}
+	}
+	if explain {
+		stopDB := ServerTimingFromContext(r.Context()).Start(TimingDB)
+		plan, err := h.dbMgr.ExplainSelectContext(r.Context(), tableName, derived, projection, filters, window, sample, sorts, safetyLimit, offset)
+		stopDB()
+		if err != nil {
+			h.logger.Error("Failed to explain query", zap.Error(err), zap.String("table", tableName), zap.String("request_id", requestID))
+			h.sendDetailedErrorWithRequest(w, r, "Failed to explain query", err, http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(map[string]interface{}{"explain": plan})
 		return
 	}
 
-	// Validate sort column names
-	for _, s := range sorts {
-		if err := SanitizeColumnName(s.Column); err != nil {
-			h.sendErrorWithRequest(w, r, fmt.Sprintf("Invalid sort column '%s': %s", s.Column, err.Error()), http.StatusBadRequest)
+	queryStart := time.Now()
+	stopDB := ServerTimingFromContext(r.Context()).Start(TimingDB)
+
+	// Get total count for pagination first, so reads over the row limit fail
+	// before the rows are queried; keyset pages report a cursor instead
+	var totalRows int64
+	var counted bool
+	if keyset == nil {
+		totalRows, err = h.dbMgr.CountContext(r.Context(), tableName, derived, filters, window, sample)
+		if err != nil {
+			h.logger.Error("Failed to count rows", zap.Error(err), zap.String("request_id", requestID))
+			// Continue without count
+			totalRows = 0
+		} else {
+			counted = true
+		}
+		if h.rowLimitExceeded(paginationRequested, totalRows) {
+			stopDB()
+			h.sendRowLimitError(w, r, totalRows)
 			return
 		}
 	}
 
-	// Execute query with safety limit
-	rows, err := h.dbMgr.Select(tableName, filters, sorts, safetyLimit, offset)
+	// JSON results are scanned up front anyway, so they can come from the result cache
+	var rows *sql.Rows
+	var cached *database.ResultSet
+	if format == "json" && h.dbMgr.ResultCacheEnabled() {
+		cached, err = h.dbMgr.SelectResultContext(r.Context(), tableName, derived, projection, filters, window, sample, sorts, safetyLimit, offset)
+	} else {
+		rows, err = h.dbMgr.SelectColumnsContext(r.Context(), tableName, derived, projection, filters, window, sample, sorts, safetyLimit, offset)
+	}
 	if err != nil {
+		stopDB()
 		h.logger.Error("Failed to query data", zap.Error(err), zap.String("table", tableName), zap.String("request_id", requestID))
-		h.sendErrorWithRequest(w, r, fmt.Sprintf("Failed to query data: %s", err.Error()), http.StatusInternalServerError)
+		h.sendDetailedErrorWithRequest(w, r, "Failed to query data", err, http.StatusInternalServerError)
 		return
 	}
-	defer rows.Close()
+	if rows != nil {
+		defer rows.Close()
+	}
+	h.indexAdvisor.Record(tableName, requestedFilters, sorts, derived)
 
-	// Get total count for pagination
-	totalRows, err := h.dbMgr.Count(tableName, filters)
-	if err != nil {
-		h.logger.Error("Failed to count rows", zap.Error(err), zap.String("request_id", requestID))
-		// Continue without count
-		totalRows = 0
+	var nextCursor string
+	if keyset != nil {
+		next, more, err := h.dbMgr.NextKeyContext(r.Context(), tableName, derived, keysetFilters, keyset, limit)
+		if err != nil {
+			stopDB()
+			h.logger.Error("Failed to query next cursor", zap.Error(err), zap.String("table", tableName), zap.String("request_id", requestID))
+			h.sendDetailedErrorWithRequest(w, r, "Failed to query data", err, http.StatusInternalServerError)
+			return
+		}
+		if more {
+			nextCursor = encodeCursor(keyset.Column, next)
+			w.Header().Set(NextCursorHeader, nextCursor)
+		}
 	}
+	stopDB()
 
-	// Determine response format
-	format := GetAcceptFormat(r)
+	// Report the newest change on the page as the next modified_since
+	if modifiedSince != nil {
+		stopDB = ServerTimingFromContext(r.Context()).Start(TimingDB)
+		maxModified, err := h.dbMgr.PageMaxContext(r.Context(), tableName, derived, filters, window, sample, sorts, safetyLimit, offset, changeColumn)
+		stopDB()
+		if err != nil {
+			h.logger.Error("Failed to query max change time", zap.Error(err), zap.String("table", tableName), zap.String("request_id", requestID))
+			h.sendDetailedErrorWithRequest(w, r, "Failed to query data", err, http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set(MaxModifiedHeader, formatMaxModified(maxModified, *modifiedSince))
+	}
+
+	// Compute the summary over the full filtered set
+	var summary map[string]map[string]interface{}
+	if aggregates != nil {
+		stopDB = ServerTimingFromContext(r.Context()).Start(TimingDB)
+		summary, err = h.dbMgr.SummaryContext(r.Context(), tableName, derived, filters, window, sample, aggregates)
+		stopDB()
+		if err != nil {
+			// Usually a type error, e.g. sum over a VARCHAR column
+			h.logger.Warn("Failed to compute summary", zap.Error(err), zap.String("table", tableName), zap.String("request_id", requestID))
+			h.sendDetailedErrorWithRequest(w, r, "Failed to compute summary", err, http.StatusBadRequest)
+			return
+		}
+	}
 
 	// Build links config if requested
 	var linksConfig *formats.LinksConfig
@@ -214,10 +1102,59 @@ func (h *CRUDHandler) handleRead(w http.ResponseWriter, r *http.Request, tableNa
 		}
 	}
 
+	// Keyset pages link to the previous page by a cursor before this one
+	if linksConfig != nil && keyset != nil {
+		linksConfig.KeysetColumn = keyset.Column
+		if keyset.After != nil {
+			stopDB = ServerTimingFromContext(r.Context()).Start(TimingDB)
+			prev, first, err := h.dbMgr.PrevKeyContext(r.Context(), tableName, derived, keysetFilters, keyset, limit)
+			stopDB()
+			if err != nil {
+				h.logger.Error("Failed to query previous cursor", zap.Error(err), zap.String("table", tableName), zap.String("request_id", requestID))
+				h.sendDetailedErrorWithRequest(w, r, "Failed to query data", err, http.StatusInternalServerError)
+				return
+			}
+			linksConfig.HasPrev = true
+			if !first {
+				linksConfig.PrevCursor = encodeCursor(keyset.Column, prev)
+			}
+		}
+	}
+
 	// Format response
-	if err := h.formatResponse(w, rows, format, page, limit, totalRows, paginationRequested, safetyLimit, linksConfig); err != nil {
-		h.logger.Error("Failed to format response", zap.Error(err), zap.String("request_id", requestID))
-		h.sendErrorWithRequest(w, r, "Failed to format response", http.StatusInternalServerError)
+	jsonOpts := formats.JSONOptions{KeyCase: h.jsonKeyCase, KeyBy: keyBy, KeyByLastWins: keyByLastWins, Summary: summary, Shape: h.responseShapeFor(tableName), DecimalAsNumber: h.decimalAsNumber, Keyset: keyset != nil, NextCursor: nextCursor}
+	if format == "json" && h.debugSQL.allows(r) {
+		debugFilters := h.debugSQL.redactFilters(filters)
+		selectStmt, _ := database.SelectColumnsStatement(tableName, derived, columns, debugFilters, window, sample, sorts, safetyLimit, offset)
+		countStmt, _ := database.CountStatement(tableName, derived, debugFilters, window, sample)
+		jsonOpts.Debug = debugObject(selectStmt, countStmt)
+	}
+	// Streamed pages have a known size only if the matching rows were counted;
+	// a sample is drawn anew by each query, so its count may not match the page
+	rowsRead := int64(-1)
+	if cached != nil {
+		rowsRead = int64(len(cached.Rows))
+	} else if counted && sample == nil {
+		rowsRead = pageRows(totalRows, safetyLimit, offset)
+	}
+	setQueryStats(w, time.Since(queryStart), rowsRead)
+
+	defer ServerTimingFromContext(r.Context()).Start(TimingSer)()
+	if cached != nil {
+		err = formats.WriteJSONDataWithOptions(w, cached.Columns, cached.Maps(), page, limit, totalRows, paginationRequested, safetyLimit, linksConfig, jsonOpts)
+	} else {
+		err = h.formatResponse(w, rows, format, csvOpts, parquetOpts, page, limit, totalRows, paginationRequested, safetyLimit, linksConfig, jsonOpts)
+	}
+	if err != nil {
+		switch {
+		case errors.Is(err, formats.ErrUnknownKeyColumn):
+			h.sendErrorWithRequest(w, r, fmt.Sprintf("Invalid key_by: %s", err.Error()), http.StatusBadRequest)
+		case errors.Is(err, formats.ErrDuplicateKey):
+			h.sendErrorWithRequest(w, r, fmt.Sprintf("Cannot key results: %s (use key_by_duplicates=last to keep the last row)", err.Error()), http.StatusConflict)
+		default:
+			h.logger.Error("Failed to format response", zap.Error(err), zap.String("request_id", requestID))
+			h.sendErrorWithRequest(w, r, "Failed to format response", http.StatusInternalServerError)
+		}
 	}
 }
 
@@ -229,7 +1166,8 @@ type UpdateRequestFilter struct {
 }
 
 // handleUpdate handles UPDATE operations.
-// WHERE clause supports all filter operators: eq, ne, gt, gte, lt, lte, like, in
+// Supports dry_run=true parameter to preview affected rows without updating.
+// WHERE clause supports all filter operators (see ParseFilters)
 // Request body format:
 //
 //	{
@@ -241,7 +1179,9 @@ func (h *CRUDHandler) handleUpdate(w http.ResponseWriter, r *http.Request, table
 
 	// Check authorization
 	role := auth.GetRoleFromContext(r.Context())
+	stopAuth := ServerTimingFromContext(r.Context()).Start(TimingAuth)
 	allowed, err := h.authorizer.CheckPermission(role, tableName, auth.OperationUpdate)
+	stopAuth()
 	if err != nil {
 		h.logger.Error("Failed to check permission", zap.Error(err), zap.String("request_id", requestID))
 		h.sendErrorWithRequest(w, r, "Failed to check permission", http.StatusInternalServerError)
@@ -263,6 +1203,16 @@ func (h *CRUDHandler) handleUpdate(w http.ResponseWriter, r *http.Request, table
 		h.sendErrorWithRequest(w, r, "Invalid JSON in request body", http.StatusBadRequest)
 		return
 	}
+	if err := h.checkColumnCount(len(req.Set)); err != nil {
+		h.sendErrorWithRequest(w, r, fmt.Sprintf("Too many columns: %s", err.Error()), http.StatusBadRequest)
+		return
+	}
+	req.Set = formats.MapInputKeys(req.Set, h.jsonKeyCase)
+	if h.jsonKeyCase == formats.KeyCaseCamel {
+		for i := range req.Where {
+			req.Where[i].Column = formats.ToSnakeCase(req.Where[i].Column)
+		}
+	}
 
 	// Validate WHERE clause is provided
 	if len(req.Where) == 0 {
@@ -276,12 +1226,6 @@ func (h *CRUDHandler) handleUpdate(w http.ResponseWriter, r *http.Request, table
 		return
 	}
 
-	// Valid operators
-	validOperators := map[string]bool{
-		"eq": true, "ne": true, "gt": true, "gte": true,
-		"lt": true, "lte": true, "like": true, "in": true,
-	}
-
 	// Convert request filters to database.Filter and validate
 	filters := make([]database.Filter, 0, len(req.Where))
 	for _, f := range req.Where {
@@ -292,10 +1236,16 @@ func (h *CRUDHandler) handleUpdate(w http.ResponseWriter, r *http.Request, table
 		}
 
 		// Validate operator
-		if !validOperators[f.Operator] {
-			h.sendErrorWithRequest(w, r, fmt.Sprintf("Invalid operator '%s': supported operators are eq, ne, gt, gte, lt, lte, like, in", f.Operator), http.StatusBadRequest)
+		if !filterOperators[f.Operator] {
+			h.sendErrorWithRequest(w, r, fmt.Sprintf("Invalid operator '%s': supported operators are %s", f.Operator, supportedOperators), http.StatusBadRequest)
 			return
 		}
+		if f.Operator == "between" {
+			if _, _, ok := database.BetweenBounds(f.Value); !ok {
+				h.sendErrorWithRequest(w, r, fmt.Sprintf("Invalid between bounds for '%s': value must be an array of two bounds", f.Column), http.StatusBadRequest)
+				return
+			}
+		}
 
 		filters = append(filters, database.Filter{
 			Column:   f.Column,
@@ -304,34 +1254,104 @@ func (h *CRUDHandler) handleUpdate(w http.ResponseWriter, r *http.Request, table
 		})
 	}
 
+	// Enforce the table's filterable allowlist
+	if err := h.checkFilterable(tableName, filters); err != nil {
+		h.sendErrorWithRequest(w, r, fmt.Sprintf("Invalid WHERE clause: %s", err.Error()), http.StatusBadRequest)
+		return
+	}
+
+	if err := h.checkNotDerived(tableName, filterColumns(filters)); err != nil {
+		h.sendErrorWithRequest(w, r, fmt.Sprintf("Invalid WHERE clause: %s", err.Error()), http.StatusBadRequest)
+		return
+	}
+
 	// Validate SET column names
+	setColumns := make([]string, 0, len(req.Set))
 	for col := range req.Set {
 		if err := SanitizeColumnName(col); err != nil {
 			h.sendErrorWithRequest(w, r, fmt.Sprintf("Invalid SET column '%s': %s", col, err.Error()), http.StatusBadRequest)
 			return
 		}
+		setColumns = append(setColumns, col)
+	}
+	if err := h.checkNotDerived(tableName, setColumns); err != nil {
+		h.sendErrorWithRequest(w, r, fmt.Sprintf("Invalid SET clause: %s", err.Error()), http.StatusBadRequest)
+		return
+	}
+
+	// Unknown SET columns may be dropped; unknown WHERE columns are always rejected
+	// by the database so an update is never widened
+	if err := h.dropUnknownColumns(w, r, tableName, req.Set); err != nil {
+		var unknownErr *unknownColumnsError
+		if errors.As(err, &unknownErr) {
+			h.sendErrorWithRequest(w, r, fmt.Sprintf("Invalid SET clause: %s", err.Error()), http.StatusBadRequest)
+			return
+		}
+		h.logger.Error("Failed to check columns", zap.Error(err), zap.String("table", tableName), zap.String("request_id", requestID))
+		h.sendDetailedErrorWithRequest(w, r, "Failed to update data", err, http.StatusInternalServerError)
+		return
+	}
+	if len(req.Set) == 0 {
+		h.sendErrorWithRequest(w, r, "SET clause contains no known columns", http.StatusBadRequest)
+		return
+	}
+
+	// Apply table validation rules
+	if verr := h.validateSet(tableName, req.Set); verr != nil {
+		h.sendValidationErrorWithRequest(w, r, verr)
+		return
+	}
+
+	// Soft-deleted rows must be restored before they can be updated
+	if col := h.softDeleteColumn(tableName); col != "" {
+		filters = append(filters, database.Filter{Column: col, Operator: "is_null"})
+	}
+
+	// Dry run: count the rows that would be updated without modifying them
+	if ParseDryRun(r) {
+		count, err := h.dbMgr.CountWithFilters(tableName, filters)
+		if err != nil {
+			h.logger.Error("Failed to count rows for dry run", zap.Error(err), zap.String("table", tableName), zap.String("request_id", requestID))
+			h.sendDetailedErrorWithRequest(w, r, "Failed to count rows", err, http.StatusInternalServerError)
+			return
+		}
+		h.sendDryRunResultWithRequest(w, r, count, filters)
+		return
 	}
 
 	// Execute update with filters
+	stopDB := ServerTimingFromContext(r.Context()).Start(TimingDB)
 	result, err := h.dbMgr.UpdateWithFilters(tableName, req.Set, filters)
+	stopDB()
 	if err != nil {
 		h.logger.Error("Failed to update data", zap.Error(err), zap.String("table", tableName), zap.String("request_id", requestID))
-		h.sendErrorWithRequest(w, r, fmt.Sprintf("Failed to update data: %s", err.Error()), http.StatusInternalServerError)
+		h.sendDetailedErrorWithRequest(w, r, "Failed to update data", err, http.StatusInternalServerError)
 		return
 	}
 
-	h.sendSuccessWithRequest(w, r, result.RowsAffected, http.StatusOK)
+	if result.RowsAffected > 0 {
+		h.changes.Bump(tableName)
+	}
+	var debug map[string]interface{}
+	if h.debugSQL.allows(r) {
+		debug = debugObject(database.UpdateStatement(tableName, h.debugSQL.redactSet(req.Set), h.debugSQL.redactFilters(filters)))
+	}
+	h.sendSuccessWithDebug(w, r, result.RowsAffected, http.StatusOK, debug)
 }
 
 // handleDelete handles DELETE operations.
 // Supports dry_run=true parameter to preview affected rows without deleting.
-// WHERE clause supports all filter operators: eq, ne, gt, gte, lt, lte, like, in
+// soft_delete=true is only accepted for tables with soft_delete configured,
+// which always soft-delete.
+// WHERE clause supports all filter operators (see ParseFilters)
 func (h *CRUDHandler) handleDelete(w http.ResponseWriter, r *http.Request, tableName string) {
 	requestID := auth.GetRequestIDFromContext(r.Context())
 
 	// Check authorization
 	role := auth.GetRoleFromContext(r.Context())
+	stopAuth := ServerTimingFromContext(r.Context()).Start(TimingAuth)
 	allowed, err := h.authorizer.CheckPermission(role, tableName, auth.OperationDelete)
+	stopAuth()
 	if err != nil {
 		h.logger.Error("Failed to check permission", zap.Error(err), zap.String("request_id", requestID))
 		h.sendErrorWithRequest(w, r, "Failed to check permission", http.StatusInternalServerError)
@@ -362,81 +1382,335 @@ func (h *CRUDHandler) handleDelete(w http.ResponseWriter, r *http.Request, table
 		}
 	}
 
+	// Enforce the table's filterable allowlist
+	if err := h.checkFilterable(tableName, filters); err != nil {
+		h.sendErrorWithRequest(w, r, fmt.Sprintf("Invalid where clause: %s", err.Error()), http.StatusBadRequest)
+		return
+	}
+	if err := h.checkNotDerived(tableName, filterColumns(filters)); err != nil {
+		h.sendErrorWithRequest(w, r, fmt.Sprintf("Invalid where clause: %s", err.Error()), http.StatusBadRequest)
+		return
+	}
+
 	// Check for dry_run parameter
 	dryRun := ParseDryRun(r)
+	softDeleteCol := h.softDeleteColumn(tableName)
+
+	// Reads only hide the soft-deleted rows of tables with soft_delete
+	// configured, so other tables cannot be soft-deleted per request
+	if softDeleteCol == "" && ParseSoftDelete(r) {
+		h.sendErrorWithRequest(w, r, fmt.Sprintf("soft_delete=true requires soft_delete to be configured for table '%s'", tableName), http.StatusBadRequest)
+		return
+	}
 
 	if dryRun {
 		// Dry run: just count affected rows without deleting
-		count, err := h.dbMgr.CountWithFilters(tableName, filters)
+		countFilters := filters
+		if softDeleteCol != "" {
+			countFilters = append(countFilters, database.Filter{Column: softDeleteCol, Operator: "is_null"})
+		}
+		count, err := h.dbMgr.CountWithFilters(tableName, countFilters)
 		if err != nil {
 			h.logger.Error("Failed to count rows for dry run", zap.Error(err), zap.String("table", tableName), zap.String("request_id", requestID))
-			h.sendErrorWithRequest(w, r, fmt.Sprintf("Failed to count rows: %s", err.Error()), http.StatusInternalServerError)
+			h.sendDetailedErrorWithRequest(w, r, "Failed to count rows", err, http.StatusInternalServerError)
 			return
 		}
-		h.sendDryRunResultWithRequest(w, r, count)
+		h.sendDryRunResultWithRequest(w, r, count, countFilters)
 		return
 	}
 
-	// Execute delete with filters
-	result, err := h.dbMgr.DeleteWithFilters(tableName, filters)
+	// Execute delete with filters (soft delete if configured for this table)
+	var result *database.DeleteResult
+	stopDB := ServerTimingFromContext(r.Context()).Start(TimingDB)
+	if softDeleteCol != "" {
+		result, err = h.dbMgr.SoftDeleteWithFilters(tableName, softDeleteCol, filters)
+	} else {
+		result, err = h.dbMgr.DeleteWithFilters(tableName, filters)
+	}
+	stopDB()
 	if err != nil {
 		h.logger.Error("Failed to delete data", zap.Error(err), zap.String("table", tableName), zap.String("request_id", requestID))
-		h.sendErrorWithRequest(w, r, fmt.Sprintf("Failed to delete data: %s", err.Error()), http.StatusInternalServerError)
+		h.sendDetailedErrorWithRequest(w, r, "Failed to delete data", err, http.StatusInternalServerError)
+		return
+	}
+
+	if result.RowsAffected > 0 {
+		h.changes.Bump(tableName)
+	}
+	var debug map[string]interface{}
+	if h.debugSQL.allows(r) {
+		debugFilters := h.debugSQL.redactFilters(filters)
+		if softDeleteCol != "" {
+			debug = debugObject(database.SoftDeleteStatement(tableName, softDeleteCol, debugFilters))
+		} else {
+			debug = debugObject(database.DeleteStatement(tableName, debugFilters))
+		}
+	}
+	h.sendSuccessWithDebug(w, r, result.RowsAffected, http.StatusOK, debug)
+}
+
+// handleAction routes requests for table sub-resources.
+func (h *CRUDHandler) handleAction(w http.ResponseWriter, r *http.Request, tableName, action string) {
+	switch action {
+	case "restore":
+		if r.Method != http.MethodPost {
+			h.sendErrorWithRequest(w, r, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		h.handleRestore(w, r, tableName)
+	case "purge":
+		if r.Method != http.MethodDelete {
+			h.sendErrorWithRequest(w, r, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		h.handlePurge(w, r, tableName)
+	case "changes":
+		if r.Method != http.MethodGet {
+			h.sendErrorWithRequest(w, r, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		h.handleChanges(w, r, tableName)
+	case "bulk":
+		if r.Method != http.MethodPut {
+			h.sendErrorWithRequest(w, r, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		h.handleBulkUpdate(w, r, tableName)
+	case "timeseries":
+		if r.Method != http.MethodGet {
+			h.sendErrorWithRequest(w, r, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		h.handleTimeSeries(w, r, tableName)
+	case "cardinality":
+		if r.Method != http.MethodGet {
+			h.sendErrorWithRequest(w, r, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		h.handleCardinality(w, r, tableName)
+	case "download-token":
+		if r.Method != http.MethodPost {
+			h.sendErrorWithRequest(w, r, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		h.handleDownloadToken(w, r, tableName)
+	default:
+		h.sendErrorWithRequest(w, r, fmt.Sprintf("Unknown table action '%s'", action), http.StatusNotFound)
+	}
+}
+
+// handleRestore clears the soft-delete column for matching soft-deleted rows.
+// Requires UPDATE permission and a soft_delete column configured for the table.
+// WHERE clause is passed as ?where=column:operator:value, same as DELETE.
+func (h *CRUDHandler) handleRestore(w http.ResponseWriter, r *http.Request, tableName string) {
+	requestID := auth.GetRequestIDFromContext(r.Context())
+
+	// Check authorization
+	role := auth.GetRoleFromContext(r.Context())
+	stopAuth := ServerTimingFromContext(r.Context()).Start(TimingAuth)
+	allowed, err := h.authorizer.CheckPermission(role, tableName, auth.OperationUpdate)
+	stopAuth()
+	if err != nil {
+		h.logger.Error("Failed to check permission", zap.Error(err), zap.String("request_id", requestID))
+		h.sendErrorWithRequest(w, r, "Failed to check permission", http.StatusInternalServerError)
+		return
+	}
+	if !allowed {
+		h.sendErrorWithRequest(w, r, "Forbidden: insufficient permissions for UPDATE operation", http.StatusForbidden)
+		return
+	}
+
+	softDeleteCol := h.softDeleteColumn(tableName)
+	if softDeleteCol == "" {
+		h.sendErrorWithRequest(w, r, fmt.Sprintf("Soft delete is not configured for table '%s'", tableName), http.StatusBadRequest)
+		return
+	}
+
+	filters, err := ParseWhereClause(r)
+	if err != nil {
+		h.sendErrorWithRequest(w, r, fmt.Sprintf("Invalid where clause: %s", err.Error()), http.StatusBadRequest)
+		return
+	}
+	if len(filters) == 0 {
+		h.sendErrorWithRequest(w, r, "WHERE clause is required for restore operation (use ?where=column:operator:value)", http.StatusBadRequest)
+		return
+	}
+
+	// Validate column names
+	for _, f := range filters {
+		if err := SanitizeColumnName(f.Column); err != nil {
+			h.sendErrorWithRequest(w, r, fmt.Sprintf("Invalid WHERE column '%s': %s", f.Column, err.Error()), http.StatusBadRequest)
+			return
+		}
+	}
+
+	// Enforce the table's filterable allowlist
+	if err := h.checkFilterable(tableName, filters); err != nil {
+		h.sendErrorWithRequest(w, r, fmt.Sprintf("Invalid where clause: %s", err.Error()), http.StatusBadRequest)
+		return
+	}
+	if err := h.checkNotDerived(tableName, filterColumns(filters)); err != nil {
+		h.sendErrorWithRequest(w, r, fmt.Sprintf("Invalid where clause: %s", err.Error()), http.StatusBadRequest)
+		return
+	}
+
+	result, err := h.dbMgr.RestoreWithFilters(tableName, softDeleteCol, filters)
+	if err != nil {
+		h.logger.Error("Failed to restore data", zap.Error(err), zap.String("table", tableName), zap.String("request_id", requestID))
+		h.sendDetailedErrorWithRequest(w, r, "Failed to restore data", err, http.StatusInternalServerError)
+		return
+	}
+
+	if result.RowsAffected > 0 {
+		h.changes.Bump(tableName)
+	}
+	h.sendSuccessWithRequest(w, r, result.RowsAffected, http.StatusOK)
+}
+
+// handlePurge permanently deletes rows that were soft-deleted before ?before=.
+// Requires DELETE permission and a soft_delete column configured for the table.
+// The before parameter accepts RFC 3339 timestamps or dates (YYYY-MM-DD).
+func (h *CRUDHandler) handlePurge(w http.ResponseWriter, r *http.Request, tableName string) {
+	requestID := auth.GetRequestIDFromContext(r.Context())
+
+	// Check authorization
+	role := auth.GetRoleFromContext(r.Context())
+	stopAuth := ServerTimingFromContext(r.Context()).Start(TimingAuth)
+	allowed, err := h.authorizer.CheckPermission(role, tableName, auth.OperationDelete)
+	stopAuth()
+	if err != nil {
+		h.logger.Error("Failed to check permission", zap.Error(err), zap.String("request_id", requestID))
+		h.sendErrorWithRequest(w, r, "Failed to check permission", http.StatusInternalServerError)
+		return
+	}
+	if !allowed {
+		h.sendErrorWithRequest(w, r, "Forbidden: insufficient permissions for DELETE operation", http.StatusForbidden)
+		return
+	}
+
+	softDeleteCol := h.softDeleteColumn(tableName)
+	if softDeleteCol == "" {
+		h.sendErrorWithRequest(w, r, fmt.Sprintf("Soft delete is not configured for table '%s'", tableName), http.StatusBadRequest)
+		return
+	}
+
+	beforeStr := r.URL.Query().Get("before")
+	if beforeStr == "" {
+		h.sendErrorWithRequest(w, r, "before parameter is required for purge operation (use ?before=YYYY-MM-DD)", http.StatusBadRequest)
+		return
+	}
+	before, err := ParseTimestamp(beforeStr)
+	if err != nil {
+		h.sendErrorWithRequest(w, r, fmt.Sprintf("Invalid before parameter: %s", err.Error()), http.StatusBadRequest)
+		return
+	}
+
+	result, err := h.dbMgr.PurgeSoftDeleted(tableName, softDeleteCol, before)
+	if err != nil {
+		h.logger.Error("Failed to purge data", zap.Error(err), zap.String("table", tableName), zap.String("request_id", requestID))
+		h.sendDetailedErrorWithRequest(w, r, "Failed to purge data", err, http.StatusInternalServerError)
 		return
 	}
 
+	h.logger.Info("Purged soft-deleted rows",
+		zap.String("table", tableName),
+		zap.Time("before", before),
+		zap.Int64("rows_affected", result.RowsAffected),
+		zap.String("request_id", requestID),
+	)
+
+	if result.RowsAffected > 0 {
+		h.changes.Bump(tableName)
+	}
 	h.sendSuccessWithRequest(w, r, result.RowsAffected, http.StatusOK)
 }
 
 // formatResponse formats the query result based on the requested format.
-func (h *CRUDHandler) formatResponse(w http.ResponseWriter, rows *sql.Rows, format string, page, limit int, totalRows int64, paginationRequested bool, safetyLimit int, linksConfig *formats.LinksConfig) error {
+func (h *CRUDHandler) formatResponse(w http.ResponseWriter, rows *sql.Rows, format string, csvOpts formats.CSVOptions, parquetOpts formats.ParquetOptions, page, limit int, totalRows int64, paginationRequested bool, safetyLimit int, linksConfig *formats.LinksConfig, jsonOpts formats.JSONOptions) error {
 	switch format {
 	case "csv":
-		return formats.WriteCSV(w, rows)
+		return formats.WriteCSVWithOptions(w, rows, csvOpts)
 	case "json":
-		return formats.WriteJSON(w, rows, page, limit, totalRows, paginationRequested, safetyLimit, linksConfig)
+		return formats.WriteJSONWithOptions(w, rows, page, limit, totalRows, paginationRequested, safetyLimit, linksConfig, jsonOpts)
 	case "parquet":
-		return formats.WriteParquet(w, rows)
+		return formats.WriteParquetWithOptions(w, rows, parquetOpts)
 	case "arrow":
-		return formats.WriteArrowIPC(w, rows)
+		return formats.WriteArrowIPCWithOptions(w, rows, h.arrowOpts)
+	case "ndjson":
+		return formats.WriteNDJSONWithOptions(w, rows, jsonOpts)
 	default:
-		return formats.WriteJSON(w, rows, page, limit, totalRows, paginationRequested, safetyLimit, linksConfig)
+		return formats.WriteJSONWithOptions(w, rows, page, limit, totalRows, paginationRequested, safetyLimit, linksConfig, jsonOpts)
 	}
 }
 
 // sendErrorWithRequest sends an error response.
-// The request ID is available in the X-Request-ID response header.
+// The body includes the request ID, as does the X-Request-ID response header.
 func (h *CRUDHandler) sendErrorWithRequest(w http.ResponseWriter, r *http.Request, message string, statusCode int) {
+	writeError(w, r, h.errorDetail, message, nil, statusCode)
+}
+
+// sendDetailedErrorWithRequest sends an error response for a failed database
+// operation. err is only included in the message at full error detail, so the
+// caller must log it.
+func (h *CRUDHandler) sendDetailedErrorWithRequest(w http.ResponseWriter, r *http.Request, message string, err error, statusCode int) {
+	writeError(w, r, h.errorDetail, message, err, statusCode)
+}
+
+// sendValidationErrorWithRequest sends a 422 response describing the violated
+// rule, or listing the JSON Schema violations.
+func (h *CRUDHandler) sendValidationErrorWithRequest(w http.ResponseWriter, r *http.Request, verr *ValidationError) {
+	if h.errorDetail == ErrorDetailMinimal {
+		h.sendErrorWithRequest(w, r, "", http.StatusUnprocessableEntity)
+		return
+	}
+	response := map[string]interface{}{
+		"error":      http.StatusText(http.StatusUnprocessableEntity),
+		"message":    fmt.Sprintf("Validation failed: %s", verr.Message),
+		"code":       http.StatusUnprocessableEntity,
+		"request_id": auth.GetRequestIDFromContext(r.Context()),
+	}
+	if verr.Violations != nil {
+		response["errors"] = verr.Violations
+	} else {
+		response["rule"] = verr.Rule
+	}
 	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(statusCode)
-	json.NewEncoder(w).Encode(map[string]interface{}{
-		"error":   http.StatusText(statusCode),
-		"message": message,
-		"code":    statusCode,
-	})
+	w.WriteHeader(http.StatusUnprocessableEntity)
+	json.NewEncoder(w).Encode(response)
 }
 
 // sendError sends an error response (without request context).
 // Deprecated: Use sendErrorWithRequest when request is available.
 func (h *CRUDHandler) sendError(w http.ResponseWriter, message string, statusCode int) {
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(statusCode)
-	json.NewEncoder(w).Encode(map[string]interface{}{
-		"error":   http.StatusText(statusCode),
-		"message": message,
-		"code":    statusCode,
-	})
+	writeError(w, nil, h.errorDetail, message, nil, statusCode)
 }
 
 // sendSuccessWithRequest sends a success response.
-// The request ID is available in the X-Request-ID response header.
+// The body includes the request ID, as does the X-Request-ID response header.
 func (h *CRUDHandler) sendSuccessWithRequest(w http.ResponseWriter, r *http.Request, rowsAffected int64, statusCode int) {
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(statusCode)
-	json.NewEncoder(w).Encode(map[string]interface{}{
+	h.sendSuccessWithDebug(w, r, rowsAffected, statusCode, nil)
+}
+
+// sendSuccessWithDebug is like sendSuccessWithRequest but includes the debug
+// object (see DebugSQLConfig) when non-nil. Clients that prefer a minimal
+// response (see ParseReturnMinimal) get 204 No Content instead.
+func (h *CRUDHandler) sendSuccessWithDebug(w http.ResponseWriter, r *http.Request, rowsAffected int64, statusCode int, debug map[string]interface{}) {
+	if ParseReturnMinimal(r) {
+		w.Header().Set("Preference-Applied", "return=minimal")
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+	response := map[string]interface{}{
 		"success":       true,
 		"rows_affected": rowsAffected,
-	})
+		"request_id":    auth.GetRequestIDFromContext(r.Context()),
+	}
+	if debug != nil {
+		response["debug"] = debug
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+	json.NewEncoder(w).Encode(response)
 }
 
 // sendSuccess sends a success response (without request context).
@@ -451,16 +1725,73 @@ func (h *CRUDHandler) sendSuccess(w http.ResponseWriter, rowsAffected int64, sta
 }
 
 // sendDryRunResultWithRequest sends a dry run result response.
-// The request ID is available in the X-Request-ID response header.
-func (h *CRUDHandler) sendDryRunResultWithRequest(w http.ResponseWriter, r *http.Request, affectedRows int64) {
+// The body includes the request ID and the parameterized WHERE clause that would be
+// applied, so clients can verify exactly what would be affected. Values are returned
+// separately as params and never interpolated into the clause.
+// Honors the Accept header: text/csv returns a single CSV row, text/plain the bare count.
+func (h *CRUDHandler) sendDryRunResultWithRequest(w http.ResponseWriter, r *http.Request, affectedRows int64, filters []database.Filter) {
+	requestID := auth.GetRequestIDFromContext(r.Context())
+	where, params := database.DescribeFilters(filters)
+
+	accept := r.Header.Get("Accept")
+	switch {
+	case strings.Contains(accept, "text/csv"):
+		w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+		w.WriteHeader(http.StatusOK)
+		csvWriter := csv.NewWriter(w)
+		csvWriter.Write([]string{"dry_run", "affected_rows", "request_id", "where"})
+		csvWriter.Write([]string{"true", strconv.FormatInt(affectedRows, 10), requestID, where})
+		csvWriter.Flush()
+	case strings.Contains(accept, "text/plain"):
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		w.WriteHeader(http.StatusOK)
+		fmt.Fprintf(w, "%d\n", affectedRows)
+	default:
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"dry_run":       true,
+			"affected_rows": affectedRows,
+			"request_id":    requestID,
+			"where":         where,
+			"params":        params,
+		})
+	}
+}
+
+// sendCreateDryRunResult sends the result of a create dry run: would_succeed is
+// true if there are no problems.
+func (h *CRUDHandler) sendCreateDryRunResult(w http.ResponseWriter, r *http.Request, problems []string) {
+	if problems == nil {
+		problems = []string{}
+	}
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
 	json.NewEncoder(w).Encode(map[string]interface{}{
 		"dry_run":       true,
-		"affected_rows": affectedRows,
+		"would_succeed": len(problems) == 0,
+		"errors":        problems,
+		"request_id":    auth.GetRequestIDFromContext(r.Context()),
 	})
 }
 
+// validationProblems describes a validation error for a dry run: one entry per
+// JSON Schema violation, or the message of the violated rule.
+func validationProblems(verr *ValidationError) []string {
+	if verr.Violations == nil {
+		return []string{fmt.Sprintf("Validation failed: %s", verr.Message)}
+	}
+	problems := make([]string, 0, len(verr.Violations))
+	for _, v := range verr.Violations {
+		if v.Path == "" {
+			problems = append(problems, v.Message)
+		} else {
+			problems = append(problems, fmt.Sprintf("%s: %s", v.Path, v.Message))
+		}
+	}
+	return problems
+}
+
 // sendDryRunResult sends a dry run result response (without request context).
 // Deprecated: Use sendDryRunResultWithRequest when request is available.
 func (h *CRUDHandler) sendDryRunResult(w http.ResponseWriter, affectedRows int64) {
@@ -472,7 +1803,8 @@ func (h *CRUDHandler) sendDryRunResult(w http.ResponseWriter, affectedRows int64
 	})
 }
 
-// ExtractTableFromPath extracts the table name from the request path.
+// ExtractTableFromPath extracts the table name from the request path. A
+// trailing slash is ignored.
 func ExtractTableFromPath(path string) string {
 	parts := strings.Split(strings.Trim(path, "/"), "/")
 	if len(parts) >= 3 && parts[0] == "duckdb" && parts[1] == "api" {
@@ -3,14 +3,20 @@ package handlers
 import (
 	"bytes"
 	"context"
+	"database/sql"
 	"encoding/json"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
+	"strings"
 	"testing"
 	"time"
 
+	"github.com/apache/arrow/go/v18/parquet/compress"
 	"github.com/tobilg/caddy-duckdb-module/auth"
 	"github.com/tobilg/caddy-duckdb-module/database"
+	"github.com/tobilg/caddy-duckdb-module/formats"
 	"go.uber.org/zap"
 )
 
@@ -159,6 +165,69 @@ func TestCRUDHandler_Read_WithFilters(t *testing.T) {
 	}
 }
 
+func TestCRUDHandler_Read_Between(t *testing.T) {
+	handler, _, cleanup := setupTestHandler(t)
+	defer cleanup()
+
+	req := httptest.NewRequest("GET", "/duckdb/api/test_users?filter=age:between:25|30,name:ne:Bob", nil)
+	req = addAuthContext(req, "admin")
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var result map[string]interface{}
+	json.Unmarshal(rec.Body.Bytes(), &result)
+	data := result["data"].([]interface{})
+	if len(data) != 1 || data[0].(map[string]interface{})["name"] != "Alice" {
+		t.Errorf("Expected only Alice, got %v", data)
+	}
+
+	req = httptest.NewRequest("GET", "/duckdb/api/test_users?filter=age:between:25", nil)
+	req = addAuthContext(req, "admin")
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	if rec.Code != http.StatusBadRequest || !strings.Contains(rec.Body.String(), "between") {
+		t.Errorf("Expected 400 for malformed bounds, got %d: %s", rec.Code, rec.Body.String())
+	}
+}
+
+func TestCRUDHandler_Read_NullAndNegatedFilters(t *testing.T) {
+	handler, _, cleanup := setupTestHandler(t)
+	defer cleanup()
+
+	tests := []struct {
+		query    string
+		status   int
+		expected int
+	}{
+		{"filter=email:is_not_null,name:not_in:Bob|Charlie", http.StatusOK, 1},
+		{"filter=email:is_null", http.StatusOK, 0},
+		{"filter=name:notlike:%25li%25", http.StatusOK, 1},
+		{"filter=email:is_null:alice@example.com", http.StatusBadRequest, 0},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest("GET", "/duckdb/api/test_users?"+tt.query, nil)
+		req = addAuthContext(req, "admin")
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+
+		if rec.Code != tt.status {
+			t.Errorf("%s: expected status %d, got %d: %s", tt.query, tt.status, rec.Code, rec.Body.String())
+			continue
+		}
+		if rec.Code != http.StatusOK {
+			continue
+		}
+		var result map[string]interface{}
+		json.Unmarshal(rec.Body.Bytes(), &result)
+		if data, _ := result["data"].([]interface{}); len(data) != tt.expected {
+			t.Errorf("%s: expected %d rows, got %d", tt.query, tt.expected, len(data))
+		}
+	}
+}
+
 func TestCRUDHandler_Read_WithSorting(t *testing.T) {
 	handler, _, cleanup := setupTestHandler(t)
 	defer cleanup()
@@ -259,6 +328,43 @@ func TestCRUDHandler_Update(t *testing.T) {
 	}
 }
 
+func TestCRUDHandler_Update_Between(t *testing.T) {
+	handler, _, cleanup := setupTestHandler(t)
+	defer cleanup()
+
+	tests := []struct {
+		name     string
+		where    string
+		expected int
+	}{
+		{"inclusive bounds", `[{"column": "age", "op": "between", "value": [25, 30]}]`, http.StatusOK},
+		{"single bound", `[{"column": "age", "op": "between", "value": 25}]`, http.StatusBadRequest},
+		{"three bounds", `[{"column": "age", "op": "between", "value": [25, 30, 35]}]`, http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			body := bytes.NewBufferString(`{"where": ` + tt.where + `, "set": {"email": "range@example.com"}}`)
+			req := httptest.NewRequest("PUT", "/duckdb/api/test_users", body)
+			req = addAuthContext(req, "admin")
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+
+			if rec.Code != tt.expected {
+				t.Fatalf("Expected status %d, got %d: %s", tt.expected, rec.Code, rec.Body.String())
+			}
+			if rec.Code != http.StatusOK {
+				return
+			}
+			var result map[string]interface{}
+			json.Unmarshal(rec.Body.Bytes(), &result)
+			// Alice (30) and Bob (25)
+			if result["rows_affected"] != float64(2) {
+				t.Errorf("Expected 2 rows affected, got %v", result["rows_affected"])
+			}
+		})
+	}
+}
+
 func TestCRUDHandler_Update_MissingWhere(t *testing.T) {
 	handler, _, cleanup := setupTestHandler(t)
 	defer cleanup()
@@ -319,6 +425,75 @@ func TestCRUDHandler_Delete(t *testing.T) {
 	}
 }
 
+func TestCRUDHandler_ReturnMinimal(t *testing.T) {
+	handler, mgr, cleanup := setupTestHandler(t)
+	defer cleanup()
+
+	tests := []struct {
+		name       string
+		method     string
+		url        string
+		body       string
+		prefer     string
+		wantStatus int
+	}{
+		{"create", "POST", "/duckdb/api/test_users", `{"id": 4, "name": "David"}`, "return=minimal", http.StatusNoContent},
+		{"update", "PUT", "/duckdb/api/test_users", `{"where": [{"column": "id", "op": "eq", "value": 4}], "set": {"age": 28}}`, "respond-async, return=minimal", http.StatusNoContent},
+		{"delete via no_content", "DELETE", "/duckdb/api/test_users?where=id:eq:4&no_content=true", "", "", http.StatusNoContent},
+		{"create with representation", "POST", "/duckdb/api/test_users", `{"id": 5, "name": "Eve"}`, "return=representation", http.StatusCreated},
+		{"delete without preference", "DELETE", "/duckdb/api/test_users?where=id:eq:5", "", "", http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.url, bytes.NewBufferString(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			if tt.prefer != "" {
+				req.Header.Set("Prefer", tt.prefer)
+			}
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, addAuthContext(req, "admin"))
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("Expected status %d, got %d: %s", tt.wantStatus, rec.Code, rec.Body.String())
+			}
+			if tt.wantStatus == http.StatusNoContent {
+				if rec.Body.Len() != 0 {
+					t.Errorf("Expected an empty body, got %q", rec.Body.String())
+				}
+				if got := rec.Header().Get("Preference-Applied"); got != "return=minimal" {
+					t.Errorf("Expected Preference-Applied: return=minimal, got %q", got)
+				}
+				return
+			}
+			var result map[string]interface{}
+			if err := json.Unmarshal(rec.Body.Bytes(), &result); err != nil {
+				t.Fatalf("Failed to parse response: %v", err)
+			}
+			if result["success"] != true || result["rows_affected"] != float64(1) {
+				t.Errorf("Expected the JSON success body, got %v", result)
+			}
+		})
+	}
+
+	// The minimal mutations were applied
+	var count int
+	if err := mgr.QueryRowScanMainContext(context.Background(), "SELECT COUNT(*) FROM test_users WHERE id >= 4", []interface{}{&count}); err != nil {
+		t.Fatalf("Failed to count rows: %v", err)
+	}
+	if count != 0 {
+		t.Errorf("Expected the created rows to be deleted again, got %d", count)
+	}
+
+	// Errors keep their JSON body
+	req := httptest.NewRequest("DELETE", "/duckdb/api/test_users", nil)
+	req.Header.Set("Prefer", "return=minimal")
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, addAuthContext(req, "admin"))
+	if rec.Code != http.StatusBadRequest || rec.Body.Len() == 0 {
+		t.Errorf("Expected a 400 error body, got %d: %q", rec.Code, rec.Body.String())
+	}
+}
+
 func TestCRUDHandler_Delete_DryRun(t *testing.T) {
 	handler, _, cleanup := setupTestHandler(t)
 	defer cleanup()
@@ -342,6 +517,173 @@ func TestCRUDHandler_Delete_DryRun(t *testing.T) {
 	if result["affected_rows"].(float64) != 2 { // Alice and Charlie
 		t.Errorf("Expected 2 affected rows, got %v", result["affected_rows"])
 	}
+	if result["request_id"] != "test-request-id" {
+		t.Errorf("Expected request_id 'test-request-id', got %v", result["request_id"])
+	}
+	if result["where"] != "age >= $1" {
+		t.Errorf("Expected where 'age >= $1', got %v", result["where"])
+	}
+	if params, ok := result["params"].([]interface{}); !ok || len(params) != 1 || params[0] != "30" {
+		t.Errorf("Expected params [\"30\"], got %v", result["params"])
+	}
+}
+
+func TestCRUDHandler_Delete_DryRun_CSV(t *testing.T) {
+	handler, _, cleanup := setupTestHandler(t)
+	defer cleanup()
+
+	req := httptest.NewRequest("DELETE", "/duckdb/api/test_users?where=age:gte:30&dry_run=true", nil)
+	req.Header.Set("Accept", "text/csv")
+	req = addAuthContext(req, "admin")
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", rec.Code)
+	}
+	if ct := rec.Header().Get("Content-Type"); ct != "text/csv; charset=utf-8" {
+		t.Errorf("Expected Content-Type 'text/csv; charset=utf-8', got '%s'", ct)
+	}
+	expected := "dry_run,affected_rows,request_id,where\ntrue,2,test-request-id,age >= $1\n"
+	if rec.Body.String() != expected {
+		t.Errorf("Expected body %q, got %q", expected, rec.Body.String())
+	}
+}
+
+func TestCRUDHandler_Update_DryRun(t *testing.T) {
+	handler, mgr, cleanup := setupTestHandler(t)
+	defer cleanup()
+
+	body := `{"where": [{"column": "age", "op": "lt", "value": 32}], "set": {"name": "Changed"}}`
+	req := httptest.NewRequest("PUT", "/duckdb/api/test_users?dry_run=true", bytes.NewBufferString(body))
+	req = addAuthContext(req, "admin")
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	var result map[string]interface{}
+	json.Unmarshal(rec.Body.Bytes(), &result)
+
+	if result["dry_run"] != true {
+		t.Error("Expected dry_run to be true")
+	}
+	if result["affected_rows"].(float64) != 2 { // Alice and Bob
+		t.Errorf("Expected 2 affected rows, got %v", result["affected_rows"])
+	}
+	if result["request_id"] != "test-request-id" {
+		t.Errorf("Expected request_id 'test-request-id', got %v", result["request_id"])
+	}
+	if result["where"] != "age < $1" {
+		t.Errorf("Expected where 'age < $1', got %v", result["where"])
+	}
+
+	// Nothing should have been modified
+	var count int64
+	if err := mgr.QueryRowScanMain("SELECT COUNT(*) FROM test_users WHERE name = 'Changed'", []interface{}{&count}); err != nil {
+		t.Fatalf("Failed to count rows: %v", err)
+	}
+	if count != 0 {
+		t.Errorf("Expected no rows updated by dry run, got %d", count)
+	}
+}
+
+func TestCRUDHandler_Create_DryRun(t *testing.T) {
+	handler, mgr, cleanup := setupTestHandler(t)
+	defer cleanup()
+
+	dryRun := func(body string) (int, map[string]interface{}) {
+		req := httptest.NewRequest("POST", "/duckdb/api/test_users?dry_run=true", bytes.NewBufferString(body))
+		req = addAuthContext(req, "admin")
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		var result map[string]interface{}
+		json.Unmarshal(rec.Body.Bytes(), &result)
+		return rec.Code, result
+	}
+	countUsers := func() int64 {
+		var count int64
+		if err := mgr.QueryRowScanMain("SELECT COUNT(*) FROM test_users", []interface{}{&count}); err != nil {
+			t.Fatalf("Failed to count rows: %v", err)
+		}
+		return count
+	}
+
+	// A valid row would be inserted
+	code, result := dryRun(`{"id": 4, "name": "Dave", "email": "dave@example.com", "age": 40}`)
+	if code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %v", code, result)
+	}
+	if result["dry_run"] != true || result["would_succeed"] != true {
+		t.Errorf("Expected the insert to succeed, got %v", result)
+	}
+	if problems, _ := result["errors"].([]interface{}); len(problems) != 0 {
+		t.Errorf("Expected no errors, got %v", result["errors"])
+	}
+	if result["request_id"] != "test-request-id" {
+		t.Errorf("Expected request_id 'test-request-id', got %v", result["request_id"])
+	}
+
+	// A duplicate primary key would fail
+	code, result = dryRun(`{"id": 1, "name": "Alice again"}`)
+	if code != http.StatusOK || result["would_succeed"] != false {
+		t.Fatalf("Expected a failed dry run, got %d: %v", code, result)
+	}
+	problems, _ := result["errors"].([]interface{})
+	if len(problems) != 1 || !strings.Contains(problems[0].(string), "constraint") {
+		t.Errorf("Expected a constraint error, got %v", result["errors"])
+	}
+
+	// So would a value of the wrong type and an unknown column
+	if _, result = dryRun(`{"id": 5, "age": "forty"}`); result["would_succeed"] != false {
+		t.Errorf("Expected a conversion error to fail the dry run, got %v", result)
+	}
+	if _, result = dryRun(`{"id": 5, "nickname": "Ed"}`); result["would_succeed"] != false {
+		t.Errorf("Expected an unknown column to fail the dry run, got %v", result)
+	}
+
+	// Nothing was inserted
+	if count := countUsers(); count != 3 {
+		t.Errorf("Expected 3 rows after dry runs, got %d", count)
+	}
+
+	// Bulk inserts cannot be dry-run
+	if code, _ := dryRun(`[{"id": 6}]`); code != http.StatusBadRequest {
+		t.Errorf("Expected 400 for a bulk dry run, got %d", code)
+	}
+	if count := countUsers(); count != 3 {
+		t.Errorf("Expected 3 rows after the bulk dry run, got %d", count)
+	}
+}
+
+func TestCRUDHandler_Create_DryRun_ValidationRules(t *testing.T) {
+	handler, _, cleanup := setupTestHandler(t)
+	defer cleanup()
+
+	cfg := &TableConfig{Rules: []ValidationRule{{Column: "age", Rule: RuleMin, Values: []string{"18"}}}}
+	if err := cfg.Provision(); err != nil {
+		t.Fatalf("Provision failed: %v", err)
+	}
+	handler.SetTableConfigs(map[string]*TableConfig{"test_users": cfg})
+
+	req := httptest.NewRequest("POST", "/duckdb/api/test_users?dry_run=true", bytes.NewBufferString(`{"id": 4, "age": 12}`))
+	req = addAuthContext(req, "admin")
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	var result map[string]interface{}
+	json.Unmarshal(rec.Body.Bytes(), &result)
+	if rec.Code != http.StatusOK || result["would_succeed"] != false {
+		t.Fatalf("Expected a failed dry run, got %d: %s", rec.Code, rec.Body.String())
+	}
+	problems, _ := result["errors"].([]interface{})
+	if len(problems) != 1 || !strings.Contains(problems[0].(string), "Validation failed") {
+		t.Errorf("Expected the rule violation, got %v", result["errors"])
+	}
 }
 
 func TestCRUDHandler_Delete_MissingWhere(t *testing.T) {
@@ -393,6 +735,42 @@ func TestCRUDHandler_InternalTableForbidden(t *testing.T) {
 	}
 }
 
+func TestCRUDHandler_DefaultTableAccess(t *testing.T) {
+	handler, _, cleanup := setupTestHandler(t)
+	defer cleanup()
+
+	// A permissive role without any permission of its own
+	if err := handler.authorizer.CreateRole("dev", "Development"); err != nil {
+		t.Fatalf("Failed to create role: %v", err)
+	}
+	if err := handler.authorizer.SetRoleDefaultAccess("dev", auth.DefaultAccessRead); err != nil {
+		t.Fatalf("Failed to set default access: %v", err)
+	}
+
+	req := addAuthContext(httptest.NewRequest("GET", "/duckdb/api/test_users", nil), "dev")
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Errorf("Expected status 200 reading an unconfigured table, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	req = httptest.NewRequest("POST", "/duckdb/api/test_users", bytes.NewBufferString(`{"id": 4, "name": "Dana"}`))
+	req.Header.Set("Content-Type", "application/json")
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, addAuthContext(req, "dev"))
+	if rec.Code != http.StatusForbidden {
+		t.Errorf("Expected status 403 writing with read default access, got %d", rec.Code)
+	}
+
+	// Internal tables stay off limits
+	req = addAuthContext(httptest.NewRequest("GET", "/duckdb/api/api_keys", nil), "dev")
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	if rec.Code != http.StatusForbidden {
+		t.Errorf("Expected status 403 for an internal table, got %d", rec.Code)
+	}
+}
+
 func TestCRUDHandler_InvalidTableName(t *testing.T) {
 	handler, _, cleanup := setupTestHandler(t)
 	defer cleanup()
@@ -423,7 +801,8 @@ func TestCRUDHandler_MethodNotAllowed(t *testing.T) {
 	handler, _, cleanup := setupTestHandler(t)
 	defer cleanup()
 
-	req := httptest.NewRequest("PATCH", "/duckdb/api/test_users", nil)
+	// PATCH is an upsert, so use a method the table API does not support
+	req := httptest.NewRequest("TRACE", "/duckdb/api/test_users", nil)
 	req = addAuthContext(req, "admin")
 
 	rec := httptest.NewRecorder()
@@ -464,86 +843,1409 @@ func TestCRUDHandler_Read_CSVFormat(t *testing.T) {
 		t.Errorf("Expected status 200, got %d", rec.Code)
 	}
 
-	if ct := rec.Header().Get("Content-Type"); ct != "text/csv" {
-		t.Errorf("Expected Content-Type 'text/csv', got '%s'", ct)
+	if ct := rec.Header().Get("Content-Type"); ct != "text/csv; charset=utf-8" {
+		t.Errorf("Expected Content-Type 'text/csv; charset=utf-8', got '%s'", ct)
 	}
 }
 
-func TestCRUDHandler_Read_InvalidFilter(t *testing.T) {
+func TestCRUDHandler_Read_SafetyLimit(t *testing.T) {
 	handler, _, cleanup := setupTestHandler(t)
 	defer cleanup()
 
-	req := httptest.NewRequest("GET", "/duckdb/api/test_users?filter=invalid_format", nil)
-	req = addAuthContext(req, "admin")
+	read := func(target string) map[string]interface{} {
+		req := httptest.NewRequest("GET", target, nil)
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, addAuthContext(req, "admin"))
+		if rec.Code != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d: %s", rec.Code, rec.Body.String())
+		}
+		var body map[string]interface{}
+		if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+			t.Fatalf("Failed to parse response: %v", err)
+		}
+		return body
+	}
 
-	rec := httptest.NewRecorder()
-	handler.ServeHTTP(rec, req)
+	// The table has 3 rows, more than the safety limit
+	handler.absoluteMaxRows = 2
+	body := read("/duckdb/api/test_users")
+	if truncated, _ := body["truncated"].(bool); !truncated {
+		t.Error("Expected truncated to be true")
+	}
+	if total, _ := body["total_available"].(float64); total != 3 {
+		t.Errorf("Expected total_available 3, got %v", body["total_available"])
+	}
+	if message, _ := body["message"].(string); !strings.Contains(message, "2 rows") {
+		t.Errorf("Expected message naming the safety limit, got %q", message)
+	}
+	if data := body["data"].([]interface{}); len(data) != 2 {
+		t.Errorf("Expected 2 rows, got %d", len(data))
+	}
 
-	if rec.Code != http.StatusBadRequest {
-		t.Errorf("Expected status 400, got %d", rec.Code)
+	// A table that fits the safety limit is not truncated
+	handler.absoluteMaxRows = 3
+	body = read("/duckdb/api/test_users")
+	if _, ok := body["truncated"]; ok {
+		t.Error("Expected no truncated field when all rows fit")
+	}
+
+	// Requested pagination reports pages instead
+	body = read("/duckdb/api/test_users?limit=2")
+	pagination := body["pagination"].(map[string]interface{})
+	if pagination["total_pages"].(float64) != 2 || pagination["has_next"] != true {
+		t.Errorf("Expected total_pages 2 and has_next true, got %v", pagination)
+	}
+	body = read("/duckdb/api/test_users?limit=2&page=2")
+	if pagination := body["pagination"].(map[string]interface{}); pagination["has_next"] != false {
+		t.Errorf("Expected has_next false on the last page, got %v", pagination)
 	}
 }
 
-func TestCRUDHandler_Read_InvalidSort(t *testing.T) {
+func TestCRUDHandler_Read_CSVDialect(t *testing.T) {
 	handler, _, cleanup := setupTestHandler(t)
 	defer cleanup()
 
-	req := httptest.NewRequest("GET", "/duckdb/api/test_users?sort=name:invalid", nil)
-	req = addAuthContext(req, "admin")
+	read := func(query string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("GET", "/duckdb/api/test_users?select=id,name&sort=id:asc&limit=2", nil)
+		req.URL.RawQuery += "&" + query
+		req.Header.Set("Accept", "text/csv")
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, addAuthContext(req, "admin"))
+		return rec
+	}
 
-	rec := httptest.NewRecorder()
-	handler.ServeHTTP(rec, req)
+	rec := read("delimiter=;&quote_all=true")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if want := "\"id\";\"name\"\n\"1\";\"Alice\"\n\"2\";\"Bob\"\n"; rec.Body.String() != want {
+		t.Errorf("Expected %q, got %q", want, rec.Body.String())
+	}
+
+	rec = read("delimiter=tab")
+	if want := "id\tname\n1\tAlice\n2\tBob\n"; rec.Body.String() != want {
+		t.Errorf("Expected %q, got %q", want, rec.Body.String())
+	}
 
+	rec = read("delimiter=colon")
 	if rec.Code != http.StatusBadRequest {
-		t.Errorf("Expected status 400, got %d", rec.Code)
+		t.Errorf("Expected status 400 for unsupported delimiter, got %d", rec.Code)
 	}
 }
 
-func TestCRUDHandler_Update_InvalidOperator(t *testing.T) {
+func TestCRUDHandler_Read_Explain(t *testing.T) {
 	handler, _, cleanup := setupTestHandler(t)
 	defer cleanup()
 
-	body := bytes.NewBufferString(`{
-		"where": [{"column": "id", "op": "invalid", "value": 1}],
-		"set": {"age": 31}
-	}`)
-	req := httptest.NewRequest("PUT", "/duckdb/api/test_users", body)
-	req.Header.Set("Content-Type", "application/json")
-	req = addAuthContext(req, "admin")
+	read := func(query, accept string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("GET", "/duckdb/api/test_users?filter=age:gt:26&sort=name:asc&"+query, nil)
+		if accept != "" {
+			req.Header.Set("Accept", accept)
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, addAuthContext(req, "reader"))
+		return rec
+	}
 
-	rec := httptest.NewRecorder()
-	handler.ServeHTTP(rec, req)
+	rec := read("explain=true", "")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var result map[string]interface{}
+	if err := json.Unmarshal(rec.Body.Bytes(), &result); err != nil {
+		t.Fatalf("Failed to parse response: %v", err)
+	}
+	if plan, ok := result["explain"].([]interface{}); !ok || len(plan) == 0 {
+		t.Errorf("Expected a query plan, got %v", result["explain"])
+	}
+	if _, ok := result["data"]; ok {
+		t.Error("Expected no data rows alongside the plan")
+	}
+
+	// Normal reads are unaffected
+	rec = read("explain=false", "")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	result = nil
+	if err := json.Unmarshal(rec.Body.Bytes(), &result); err != nil {
+		t.Fatalf("Failed to parse response: %v", err)
+	}
+	if data, ok := result["data"].([]interface{}); !ok || len(data) != 2 {
+		t.Errorf("Expected 2 rows, got %v", result["data"])
+	}
+	if _, ok := result["explain"]; ok {
+		t.Error("Expected no plan in a normal read")
+	}
 
+	rec = read("explain=true", "text/csv")
 	if rec.Code != http.StatusBadRequest {
-		t.Errorf("Expected status 400 for invalid operator, got %d", rec.Code)
+		t.Errorf("Expected status 400 for a CSV explain, got %d", rec.Code)
 	}
 }
 
-func TestCRUDHandler_Create_InvalidColumnName(t *testing.T) {
+func TestCRUDHandler_Read_ParquetCompression(t *testing.T) {
 	handler, _, cleanup := setupTestHandler(t)
 	defer cleanup()
 
-	body := bytes.NewBufferString(`{"id": 4, "name;DROP TABLE test_users;--": "David"}`)
-	req := httptest.NewRequest("POST", "/duckdb/api/test_users", body)
-	req.Header.Set("Content-Type", "application/json")
-	req = addAuthContext(req, "admin")
+	read := func(target string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("GET", target, nil)
+		req.Header.Set("Accept", "application/parquet")
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, addAuthContext(req, "admin"))
+		return rec
+	}
 
-	rec := httptest.NewRecorder()
-	handler.ServeHTTP(rec, req)
+	for _, tt := range []struct {
+		query string
+		codec compress.Compression
+	}{
+		{"", compress.Codecs.Snappy},
+		{"?compression=zstd", compress.Codecs.Zstd},
+		{"?compression=gzip", compress.Codecs.Gzip},
+		{"?compression=none", compress.Codecs.Uncompressed},
+	} {
+		rec := read("/duckdb/api/test_users" + tt.query)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("%q: expected status 200, got %d: %s", tt.query, rec.Code, rec.Body.String())
+		}
+		if codec := firstColumnCodec(t, rec.Body.Bytes()); codec != tt.codec {
+			t.Errorf("%q: expected %s compression, got %s", tt.query, tt.codec, codec)
+		}
+	}
 
-	if rec.Code != http.StatusBadRequest {
-		t.Errorf("Expected status 400 for invalid column name, got %d", rec.Code)
+	if rec := read("/duckdb/api/test_users?compression=lzma"); rec.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400 for an unknown codec, got %d", rec.Code)
 	}
 }
 
-func TestExtractTableFromPath(t *testing.T) {
-	tests := []struct {
-		path     string
-		expected string
-	}{
+func TestCRUDHandler_Read_NDJSONFormat(t *testing.T) {
+	handler, _, cleanup := setupTestHandler(t)
+	defer cleanup()
+
+	req := httptest.NewRequest("GET", "/duckdb/api/test_users?sort=id:asc", nil)
+	req.Header.Set("Accept", "application/x-ndjson")
+	req = addAuthContext(req, "admin")
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if ct := rec.Header().Get("Content-Type"); ct != formats.NDJSONContentType {
+		t.Errorf("Expected Content-Type %q, got %q", formats.NDJSONContentType, ct)
+	}
+
+	lines := strings.Split(strings.TrimSpace(rec.Body.String()), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("Expected 3 lines, got %d: %q", len(lines), rec.Body.String())
+	}
+	for i, name := range []string{"Alice", "Bob", "Charlie"} {
+		var record map[string]interface{}
+		if err := json.Unmarshal([]byte(lines[i]), &record); err != nil {
+			t.Fatalf("Failed to parse line %d: %v", i, err)
+		}
+		if record["name"] != name {
+			t.Errorf("Expected line %d to be %s, got %v", i, name, record)
+		}
+	}
+}
+
+func TestCRUDHandler_Read_Select(t *testing.T) {
+	handler, _, cleanup := setupTestHandler(t)
+	defer cleanup()
+
+	get := func(target, accept string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("GET", target, nil)
+		if accept != "" {
+			req.Header.Set("Accept", accept)
+		}
+		req = addAuthContext(req, "admin")
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec
+	}
+
+	rec := get("/duckdb/api/test_users?select=name,id&sort=age:desc", "")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var result map[string]interface{}
+	if err := json.Unmarshal(rec.Body.Bytes(), &result); err != nil {
+		t.Fatalf("Failed to parse response: %v", err)
+	}
+	data := result["data"].([]interface{})
+	if len(data) != 3 {
+		t.Fatalf("Expected 3 rows, got %d", len(data))
+	}
+	for _, row := range data {
+		if fields := row.(map[string]interface{}); len(fields) != 2 || fields["id"] == nil || fields["name"] == nil {
+			t.Errorf("Expected only id and name, got %v", fields)
+		}
+	}
+	// Sorting by a column that is not selected still applies
+	if first := data[0].(map[string]interface{}); first["name"] != "Charlie" {
+		t.Errorf("Expected Charlie first, got %v", first)
+	}
+
+	rec = get("/duckdb/api/test_users?select=name,id&sort=id:asc", "text/csv")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if header := strings.SplitN(rec.Body.String(), "\n", 2)[0]; strings.TrimSpace(header) != "name,id" {
+		t.Errorf("Expected CSV header 'name,id', got %q", header)
+	}
+
+	for _, target := range []string{
+		"/duckdb/api/test_users?select=id,name%3BDROP",
+		"/duckdb/api/test_users?select=id,,name",
+		"/duckdb/api/test_users?select=id,id",
+		"/duckdb/api/test_users?select=id,missing",
+	} {
+		if rec := get(target, ""); rec.Code != http.StatusBadRequest {
+			t.Errorf("Expected status 400 for %s, got %d: %s", target, rec.Code, rec.Body.String())
+		}
+	}
+}
+
+func TestCRUDHandler_Read_InvalidFilter(t *testing.T) {
+	handler, _, cleanup := setupTestHandler(t)
+	defer cleanup()
+
+	req := httptest.NewRequest("GET", "/duckdb/api/test_users?filter=invalid_format", nil)
+	req = addAuthContext(req, "admin")
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400, got %d", rec.Code)
+	}
+}
+
+func TestCRUDHandler_Read_InvalidSort(t *testing.T) {
+	handler, _, cleanup := setupTestHandler(t)
+	defer cleanup()
+
+	req := httptest.NewRequest("GET", "/duckdb/api/test_users?sort=name:invalid", nil)
+	req = addAuthContext(req, "admin")
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400, got %d", rec.Code)
+	}
+}
+
+func TestCRUDHandler_Read_UnknownColumns(t *testing.T) {
+	handler, _, cleanup := setupTestHandler(t)
+	defer cleanup()
+
+	tests := []struct {
+		name    string
+		query   string
+		status  int
+		message string
+	}{
+		{"known filter", "filter=age:gt:26", http.StatusOK, ""},
+		{"known sort", "sort=NAME:desc", http.StatusOK, ""},
+		{"unknown filter", "filter=nickname:eq:Al", http.StatusBadRequest, "Invalid filter: unknown column(s) 'nickname'"},
+		{"unknown sort", "sort=age:asc,height:desc", http.StatusBadRequest, "Invalid sort: unknown column(s) 'height'"},
+		{"unknown window column", "window=row_number:partition=team&qualify=<=1", http.StatusBadRequest, "Invalid window: unknown column(s) 'team'"},
+		{"unknown cursor column", "after=nickname:Al", http.StatusBadRequest, "unknown column(s) 'nickname'"},
+		{"unknown grouped filter", "group_by=age&agg=count:*&filter=nickname:eq:Al", http.StatusBadRequest, "Invalid filter: unknown column(s) 'nickname'"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "/duckdb/api/test_users?"+tt.query, nil)
+			req = addAuthContext(req, "admin")
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+
+			if rec.Code != tt.status {
+				t.Fatalf("Expected status %d, got %d: %s", tt.status, rec.Code, rec.Body.String())
+			}
+			if tt.message != "" && !strings.Contains(rec.Body.String(), tt.message) {
+				t.Errorf("Expected %q in response, got %s", tt.message, rec.Body.String())
+			}
+		})
+	}
+}
+
+func TestCRUDHandler_Update_InvalidOperator(t *testing.T) {
+	handler, _, cleanup := setupTestHandler(t)
+	defer cleanup()
+
+	body := bytes.NewBufferString(`{
+		"where": [{"column": "id", "op": "invalid", "value": 1}],
+		"set": {"age": 31}
+	}`)
+	req := httptest.NewRequest("PUT", "/duckdb/api/test_users", body)
+	req.Header.Set("Content-Type", "application/json")
+	req = addAuthContext(req, "admin")
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400 for invalid operator, got %d", rec.Code)
+	}
+}
+
+func TestCRUDHandler_Create_InvalidColumnName(t *testing.T) {
+	handler, _, cleanup := setupTestHandler(t)
+	defer cleanup()
+
+	body := bytes.NewBufferString(`{"id": 4, "name;DROP TABLE test_users;--": "David"}`)
+	req := httptest.NewRequest("POST", "/duckdb/api/test_users", body)
+	req.Header.Set("Content-Type", "application/json")
+	req = addAuthContext(req, "admin")
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400 for invalid column name, got %d", rec.Code)
+	}
+}
+
+func TestCRUDHandler_MaxColumns(t *testing.T) {
+	handler, mgr, cleanup := setupTestHandler(t)
+	defer cleanup()
+	handler.SetMaxColumns(10)
+
+	do := func(method, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, "/duckdb/api/test_users?ignore_unknown=true", bytes.NewBufferString(body))
+		req = addAuthContext(req, "admin")
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec
+	}
+
+	// Mostly non-existent columns: rejected by the count alone, before the
+	// unknown-column lookup would have dropped them
+	columns := make([]string, 0, 50)
+	for i := 0; i < 50; i++ {
+		columns = append(columns, fmt.Sprintf(`"col_%d": %d`, i, i))
+	}
+	wide := "{" + strings.Join(columns, ", ") + "}"
+
+	rec := do("POST", wide)
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("Expected status 400 for too many columns, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if !strings.Contains(rec.Body.String(), "Too many columns") || rec.Header().Get("X-Ignored-Columns") != "" {
+		t.Errorf("Expected column count error before column checks, got %s", rec.Body.String())
+	}
+	if rec := do("PUT", `{"where": [{"column": "id", "op": "eq", "value": 1}], "set": `+wide+`}`); rec.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400 for too many SET columns, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	var count int
+	if err := mgr.QueryRowMain("SELECT COUNT(*) FROM test_users").Scan(&count); err != nil || count != 3 {
+		t.Errorf("Expected table to be unchanged, got %d rows (err: %v)", count, err)
+	}
+
+	// Bodies within the limit are unaffected
+	if rec := do("POST", `{"id": 4, "name": "David", "email": "david@example.com", "age": 40}`); rec.Code != http.StatusCreated {
+		t.Errorf("Expected status 201 within the limit, got %d: %s", rec.Code, rec.Body.String())
+	}
+}
+
+func TestCRUDHandler_UnknownColumns(t *testing.T) {
+	handler, mgr, cleanup := setupTestHandler(t)
+	defer cleanup()
+
+	do := func(method, target, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, target, bytes.NewBufferString(body))
+		req = addAuthContext(req, "admin")
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec
+	}
+
+	createBody := `{"id": 4, "name": "David", "nickname": "Dave"}`
+	updateBody := `{"where": [{"column": "id", "op": "eq", "value": 1}], "set": {"age": 31, "nickname": "Al"}}`
+
+	// Strict by default: unknown columns are rejected
+	if rec := do("POST", "/duckdb/api/test_users", createBody); rec.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400 for unknown create column, got %d: %s", rec.Code, rec.Body.String())
+	} else if !strings.Contains(rec.Body.String(), "nickname") {
+		t.Errorf("Expected error to name the unknown column: %s", rec.Body.String())
+	}
+	if rec := do("PUT", "/duckdb/api/test_users", updateBody); rec.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400 for unknown SET column, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	// ignore_unknown=true drops unknown columns and reports them
+	rec := do("POST", "/duckdb/api/test_users?ignore_unknown=true", createBody)
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("Expected status 201, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if got := rec.Header().Get("X-Ignored-Columns"); got != "nickname" {
+		t.Errorf("Expected X-Ignored-Columns 'nickname', got %q", got)
+	}
+
+	rec = do("PUT", "/duckdb/api/test_users?ignore_unknown=true", updateBody)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if got := rec.Header().Get("X-Ignored-Columns"); got != "nickname" {
+		t.Errorf("Expected X-Ignored-Columns 'nickname', got %q", got)
+	}
+
+	var age int
+	if err := mgr.QueryRowScanMain("SELECT age FROM test_users WHERE id = 1", []interface{}{&age}); err != nil {
+		t.Fatalf("Failed to read row: %v", err)
+	}
+	if age != 31 {
+		t.Errorf("Expected age 31 after update, got %d", age)
+	}
+
+	// A body with only unknown columns is still rejected
+	if rec := do("PUT", "/duckdb/api/test_users?ignore_unknown=true", `{"where": [{"column": "id", "op": "eq", "value": 1}], "set": {"nickname": "Al"}}`); rec.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400 when no known SET columns remain, got %d", rec.Code)
+	}
+}
+
+// enableSoftDelete adds a deleted_at column to test_users and configures soft delete for it
+func enableSoftDelete(t *testing.T, handler *CRUDHandler, mgr *database.Manager) {
+	if _, err := mgr.ExecMain(`ALTER TABLE test_users ADD COLUMN deleted_at TIMESTAMP`); err != nil {
+		t.Fatalf("Failed to add deleted_at column: %v", err)
+	}
+	handler.SetTableConfigs(map[string]*TableConfig{
+		"test_users": {SoftDeleteColumn: "deleted_at"},
+	})
+}
+
+// readTestUsers reads all visible rows from test_users and returns them
+func readTestUsers(t *testing.T, handler *CRUDHandler) []interface{} {
+	req := httptest.NewRequest("GET", "/duckdb/api/test_users", nil)
+	req = addAuthContext(req, "admin")
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	var result map[string]interface{}
+	if err := json.Unmarshal(rec.Body.Bytes(), &result); err != nil {
+		t.Fatalf("Failed to parse response: %v", err)
+	}
+	return result["data"].([]interface{})
+}
+
+func TestCRUDHandler_SoftDelete_MainCatalogQualified(t *testing.T) {
+	handler, mgr, cleanup := setupTestHandler(t)
+	defer cleanup()
+	enableSoftDelete(t, handler, mgr)
+
+	// Naming the main catalog must not bypass the table's configuration
+	for i, target := range []string{"/duckdb/api/memory.test_users?where=id:eq:1", "/duckdb/api/test_users?catalog=memory&where=id:eq:2"} {
+		req := addAuthContext(httptest.NewRequest("DELETE", target, nil), "admin")
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d: %s", rec.Code, rec.Body.String())
+		}
+
+		var count int
+		if err := mgr.QueryRowScanMain("SELECT COUNT(*) FROM test_users", []interface{}{&count}); err != nil {
+			t.Fatalf("Failed to count rows: %v", err)
+		}
+		if count != 3 {
+			t.Errorf("%s: expected a soft delete to keep all 3 rows, got %d", target, count)
+		}
+		if data := readTestUsers(t, handler); len(data) != 2-i {
+			t.Errorf("%s: expected %d visible rows, got %d", target, 2-i, len(data))
+		}
+	}
+}
+
+func TestCRUDHandler_SoftDelete_RestoreLifecycle(t *testing.T) {
+	handler, mgr, cleanup := setupTestHandler(t)
+	defer cleanup()
+	enableSoftDelete(t, handler, mgr)
+
+	// Soft-delete Alice
+	req := httptest.NewRequest("DELETE", "/duckdb/api/test_users?where=id:eq:1", nil)
+	req = addAuthContext(req, "admin")
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	// Row must still exist in the table
+	var count int
+	if err := mgr.QueryRowScanMain("SELECT COUNT(*) FROM test_users WHERE deleted_at IS NOT NULL", []interface{}{&count}); err != nil {
+		t.Fatalf("Failed to count rows: %v", err)
+	}
+	if count != 1 {
+		t.Errorf("Expected 1 soft-deleted row, got %d", count)
+	}
+
+	// Soft-deleted rows are excluded from reads
+	if data := readTestUsers(t, handler); len(data) != 2 {
+		t.Errorf("Expected 2 visible rows after soft delete, got %d", len(data))
+	}
+
+	// Restore Alice
+	req = httptest.NewRequest("POST", "/duckdb/api/test_users/restore?where=id:eq:1", nil)
+	req = addAuthContext(req, "admin")
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	var result map[string]interface{}
+	json.Unmarshal(rec.Body.Bytes(), &result)
+	if result["rows_affected"].(float64) != 1 {
+		t.Errorf("Expected 1 row restored, got %v", result["rows_affected"])
+	}
+
+	// Restored rows reappear in reads
+	if data := readTestUsers(t, handler); len(data) != 3 {
+		t.Errorf("Expected 3 visible rows after restore, got %d", len(data))
+	}
+}
+
+func TestCRUDHandler_SoftDelete_Purge(t *testing.T) {
+	handler, mgr, cleanup := setupTestHandler(t)
+	defer cleanup()
+	enableSoftDelete(t, handler, mgr)
+
+	req := httptest.NewRequest("DELETE", "/duckdb/api/test_users?where=age:gte:30", nil)
+	req = addAuthContext(req, "admin")
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	// Nothing was soft-deleted before 2000
+	req = httptest.NewRequest("DELETE", "/duckdb/api/test_users/purge?before=2000-01-01", nil)
+	req = addAuthContext(req, "admin")
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	var result map[string]interface{}
+	json.Unmarshal(rec.Body.Bytes(), &result)
+	if rec.Code != http.StatusOK || result["rows_affected"].(float64) != 0 {
+		t.Errorf("Expected 0 rows purged, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	// Purge everything soft-deleted before a future date
+	before := time.Now().Add(24 * time.Hour).UTC().Format(time.RFC3339)
+	req = httptest.NewRequest("DELETE", "/duckdb/api/test_users/purge?before="+before, nil)
+	req = addAuthContext(req, "admin")
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	json.Unmarshal(rec.Body.Bytes(), &result)
+	if rec.Code != http.StatusOK || result["rows_affected"].(float64) != 2 {
+		t.Errorf("Expected 2 rows purged, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	var count int
+	if err := mgr.QueryRowScanMain("SELECT COUNT(*) FROM test_users", []interface{}{&count}); err != nil {
+		t.Fatalf("Failed to count rows: %v", err)
+	}
+	if count != 1 {
+		t.Errorf("Expected 1 row left after purge, got %d", count)
+	}
+}
+
+func TestCRUDHandler_SoftDelete_IncludeDeleted(t *testing.T) {
+	handler, mgr, cleanup := setupTestHandler(t)
+	defer cleanup()
+	enableSoftDelete(t, handler, mgr)
+
+	do := func(method, target string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, target, nil)
+		req = addAuthContext(req, "admin")
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("%s %s: expected status 200, got %d: %s", method, target, rec.Code, rec.Body.String())
+		}
+		return rec
+	}
+	do("DELETE", "/duckdb/api/test_users?where=id:eq:1")
+
+	// Excluded by default
+	if data := readTestUsers(t, handler); len(data) != 2 {
+		t.Errorf("Expected 2 visible rows, got %d", len(data))
+	}
+
+	// Included on request, with the deletion timestamp
+	var result struct {
+		Data []map[string]interface{} `json:"data"`
+	}
+	rec := do("GET", "/duckdb/api/test_users?include_deleted=true&sort=id:asc")
+	if err := json.Unmarshal(rec.Body.Bytes(), &result); err != nil {
+		t.Fatalf("Failed to parse response: %v", err)
+	}
+	if len(result.Data) != 3 || result.Data[0]["deleted_at"] == nil || result.Data[1]["deleted_at"] != nil {
+		t.Errorf("Expected all 3 rows with Alice soft-deleted, got %v", result.Data)
+	}
+
+	var distinct struct {
+		Distinct int64 `json:"distinct"`
+	}
+	rec = do("GET", "/duckdb/api/test_users/cardinality?column=name&exact=true&include_deleted=true")
+	if err := json.Unmarshal(rec.Body.Bytes(), &distinct); err != nil || distinct.Distinct != 3 {
+		t.Errorf("Expected 3 distinct names including deleted rows, got %s", rec.Body.String())
+	}
+}
+
+func TestCRUDHandler_SoftDelete_Parameter(t *testing.T) {
+	handler, mgr, cleanup := setupTestHandler(t)
+	defer cleanup()
+
+	del := func() *httptest.ResponseRecorder {
+		req := httptest.NewRequest("DELETE", "/duckdb/api/test_users?where=id:eq:1&soft_delete=true", nil)
+		req = addAuthContext(req, "admin")
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec
+	}
+
+	// A deleted_at column is not enough: reads would still return the rows
+	if _, err := mgr.ExecMain(`ALTER TABLE test_users ADD COLUMN deleted_at TIMESTAMP`); err != nil {
+		t.Fatalf("Failed to add deleted_at column: %v", err)
+	}
+	if rec := del(); rec.Code != http.StatusBadRequest || !strings.Contains(rec.Body.String(), "soft_delete to be configured") {
+		t.Fatalf("Expected 400 without soft_delete configured, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var deleted int
+	if err := mgr.QueryRowScanMain("SELECT COUNT(deleted_at) FROM test_users", []interface{}{&deleted}); err != nil || deleted != 0 {
+		t.Fatalf("Expected no soft-deleted rows, got %d (%v)", deleted, err)
+	}
+
+	// Tables with soft_delete configured accept it, and hide the row
+	handler.SetTableConfigs(map[string]*TableConfig{
+		"test_users": {SoftDeleteColumn: "deleted_at"},
+	})
+	if rec := del(); rec.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	var total int
+	if err := mgr.QueryRowScanMain("SELECT COUNT(*), COUNT(deleted_at) FROM test_users", []interface{}{&total, &deleted}); err != nil {
+		t.Fatalf("Failed to count rows: %v", err)
+	}
+	if total != 3 || deleted != 1 {
+		t.Errorf("Expected 3 rows with 1 soft-deleted, got %d rows with %d soft-deleted", total, deleted)
+	}
+	if data := readTestUsers(t, handler); len(data) != 2 {
+		t.Errorf("Expected 2 visible rows, got %d", len(data))
+	}
+}
+
+func TestCRUDHandler_Restore_NotConfigured(t *testing.T) {
+	handler, _, cleanup := setupTestHandler(t)
+	defer cleanup()
+
+	req := httptest.NewRequest("POST", "/duckdb/api/test_users/restore?where=id:eq:1", nil)
+	req = addAuthContext(req, "admin")
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400 when soft delete is not configured, got %d", rec.Code)
+	}
+}
+
+func TestCRUDHandler_Restore_RequiresUpdatePermission(t *testing.T) {
+	handler, mgr, cleanup := setupTestHandler(t)
+	defer cleanup()
+	enableSoftDelete(t, handler, mgr)
+
+	req := httptest.NewRequest("POST", "/duckdb/api/test_users/restore?where=id:eq:1", nil)
+	req = addAuthContext(req, "reader")
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusForbidden {
+		t.Errorf("Expected status 403 for reader role, got %d", rec.Code)
+	}
+}
+
+func TestCRUDHandler_UnknownAction(t *testing.T) {
+	handler, _, cleanup := setupTestHandler(t)
+	defer cleanup()
+
+	req := httptest.NewRequest("POST", "/duckdb/api/test_users/unknown", nil)
+	req = addAuthContext(req, "admin")
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("Expected status 404 for unknown action, got %d", rec.Code)
+	}
+}
+
+// enableValidation configures validation rules for test_users
+func TestCRUDHandler_FilterSortAllowlist(t *testing.T) {
+	handler, _, cleanup := setupTestHandler(t)
+	defer cleanup()
+	handler.SetTableConfigs(map[string]*TableConfig{
+		"test_users": {Filterable: []string{"id", "name"}, Sortable: []string{"name"}},
+	})
+
+	tests := []struct {
+		name       string
+		method     string
+		target     string
+		body       string
+		wantStatus int
+	}{
+		{"allowed filter", "GET", "/duckdb/api/test_users?filter=name:eq:Alice", "", http.StatusOK},
+		{"allowed sort", "GET", "/duckdb/api/test_users?sort=name:desc", "", http.StatusOK},
+		{"disallowed filter", "GET", "/duckdb/api/test_users?filter=email:like:%25example%25", "", http.StatusBadRequest},
+		{"disallowed sort", "GET", "/duckdb/api/test_users?sort=age:asc", "", http.StatusBadRequest},
+		{"disallowed update where", "PUT", "/duckdb/api/test_users", `{"where": [{"column": "age", "op": "gt", "value": 1}], "set": {"name": "X"}}`, http.StatusBadRequest},
+		{"disallowed delete where", "DELETE", "/duckdb/api/test_users?where=age:gt:1", "", http.StatusBadRequest},
+		{"allowed delete where", "DELETE", "/duckdb/api/test_users?where=id:eq:3", "", http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.target, bytes.NewBufferString(tt.body))
+			req = addAuthContext(req, "admin")
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+
+			if rec.Code != tt.wantStatus {
+				t.Errorf("Expected status %d, got %d: %s", tt.wantStatus, rec.Code, rec.Body.String())
+			}
+		})
+	}
+}
+
+func TestCRUDHandler_WindowTopNPerGroup(t *testing.T) {
+	handler, mgr, cleanup := setupTestHandler(t)
+	defer cleanup()
+
+	if _, err := mgr.ExecMain(`
+		CREATE TABLE products AS SELECT * FROM (VALUES
+			(1, 'books', 10.0), (2, 'books', 25.0), (3, 'books', 15.0),
+			(4, 'games', 60.0), (5, 'games', 40.0), (6, 'toys', 5.0)
+		) AS t(id, category, price)
+	`); err != nil {
+		t.Fatalf("Failed to create table: %v", err)
+	}
+
+	read := func(query url.Values) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("GET", "/duckdb/api/products?"+query.Encode(), nil)
+		req = addAuthContext(req, "reader")
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec
+	}
+
+	// Two most expensive products per category
+	rec := read(url.Values{
+		"window":  {"row_number:partition=category:order=price desc"},
+		"qualify": {"<=2"},
+		"sort":    {"id:asc"},
+	})
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var result map[string]interface{}
+	if err := json.Unmarshal(rec.Body.Bytes(), &result); err != nil {
+		t.Fatalf("Failed to parse response: %v", err)
+	}
+	data := result["data"].([]interface{})
+	var ids []float64
+	for _, row := range data {
+		ids = append(ids, row.(map[string]interface{})["id"].(float64))
+	}
+	want := []float64{2, 3, 4, 5, 6}
+	if len(ids) != len(want) {
+		t.Fatalf("Expected ids %v, got %v", want, ids)
+	}
+	for i := range want {
+		if ids[i] != want[i] {
+			t.Fatalf("Expected ids %v, got %v", want, ids)
+		}
+	}
+
+	// Raw SQL in window parameters is rejected
+	rec = read(url.Values{"window": {"row_number:order=price; DROP TABLE products"}, "qualify": {"<=2"}})
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400 for invalid order column, got %d", rec.Code)
+	}
+
+	// Window columns honor the filterable/sortable allowlists
+	handler.SetTableConfigs(map[string]*TableConfig{"products": {Filterable: []string{"id"}, Sortable: []string{"price"}}})
+	rec = read(url.Values{"window": {"rank:partition=category:order=price desc"}, "qualify": {"=1"}})
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400 for non-filterable partition column, got %d", rec.Code)
+	}
+}
+
+func TestCRUDHandler_Catalog(t *testing.T) {
+	handler, mgr, cleanup := setupTestHandler(t)
+	defer cleanup()
+
+	// A same-named table in an attached catalog holds different rows
+	for _, stmt := range []string{
+		`ATTACH ':memory:' AS archive`,
+		`CREATE TABLE archive.test_users (id INTEGER, name VARCHAR, email VARCHAR, age INTEGER)`,
+		`INSERT INTO archive.test_users VALUES (10, 'Zoe', 'zoe@example.com', 50)`,
+	} {
+		if _, err := mgr.ExecMain(stmt); err != nil {
+			t.Fatalf("Failed to run %q: %v", stmt, err)
+		}
+	}
+
+	do := func(method, target, role, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, target, bytes.NewBufferString(body))
+		req = addAuthContext(req, role)
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec
+	}
+	names := func(rec *httptest.ResponseRecorder) []string {
+		var result map[string]interface{}
+		json.Unmarshal(rec.Body.Bytes(), &result)
+		var names []string
+		for _, row := range result["data"].([]interface{}) {
+			names = append(names, row.(map[string]interface{})["name"].(string))
+		}
+		return names
+	}
+
+	// Reads are scoped to the requested catalog
+	rec := do("GET", "/duckdb/api/test_users?catalog=archive", "admin", "")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if got := names(rec); len(got) != 1 || got[0] != "Zoe" {
+		t.Errorf("Expected only the archive row, got %v", got)
+	}
+	rec = do("GET", "/duckdb/api/archive.test_users", "admin", "")
+	if got := names(rec); len(got) != 1 || got[0] != "Zoe" {
+		t.Errorf("Expected only the archive row via qualified path, got %v", got)
+	}
+	rec = do("GET", "/duckdb/api/test_users", "admin", "")
+	if got := names(rec); len(got) != 3 {
+		t.Errorf("Expected the 3 main rows without a catalog, got %v", got)
+	}
+
+	// Writes are scoped too
+	rec = do("POST", "/duckdb/api/test_users?catalog=archive", "admin", `{"id": 11, "name": "Yan", "age": 40}`)
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("Expected status 201, got %d: %s", rec.Code, rec.Body.String())
+	}
+	rec = do("DELETE", "/duckdb/api/test_users?catalog=archive&where=id:eq:10", "admin", "")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var mainCount, archiveCount int
+	mgr.QueryRowScanMain("SELECT COUNT(*) FROM test_users", []interface{}{&mainCount})
+	mgr.QueryRowScanMain("SELECT COUNT(*) FROM archive.test_users", []interface{}{&archiveCount})
+	if mainCount != 3 || archiveCount != 1 {
+		t.Errorf("Expected 3 main and 1 archive rows, got %d and %d", mainCount, archiveCount)
+	}
+
+	// Tables missing from the catalog are not found; catalogs that are not attached are rejected
+	if rec := do("GET", "/duckdb/api/missing_table?catalog=archive", "admin", ""); rec.Code != http.StatusNotFound {
+		t.Errorf("Expected status 404 for a table missing from the catalog, got %d", rec.Code)
+	}
+	if rec := do("GET", "/duckdb/api/test_users?catalog=missing", "admin", ""); rec.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400 for unknown catalog, got %d", rec.Code)
+	}
+
+	// Permissions are keyed on catalog.table
+	authorizer := auth.NewAuthorizer(mgr.AuthDB())
+	if err := authorizer.CreateRole("archivist", "Reads the archive"); err != nil {
+		t.Fatalf("Failed to create role: %v", err)
+	}
+	if err := authorizer.CreatePermission(auth.Permission{RoleName: "archivist", TableName: "archive.test_users", CanRead: true}); err != nil {
+		t.Fatalf("Failed to create permission: %v", err)
+	}
+	if rec := do("GET", "/duckdb/api/test_users?catalog=archive", "archivist", ""); rec.Code != http.StatusOK {
+		t.Errorf("Expected status 200 for catalog permission, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if rec := do("GET", "/duckdb/api/test_users", "archivist", ""); rec.Code != http.StatusForbidden {
+		t.Errorf("Expected status 403 for the main table, got %d", rec.Code)
+	}
+	if rec := do("GET", "/duckdb/api/ARCHIVE.test_users", "archivist", ""); rec.Code != http.StatusOK {
+		t.Errorf("Expected the catalog to match case-insensitively, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	// Other names DuckDB resolves to the main table, like the main schema, do not reach it
+	for _, target := range []string{"/duckdb/api/main.test_users", "/duckdb/api/test_users?catalog=main", "/duckdb/api/system.test_users"} {
+		if rec := do("GET", target, "archivist", ""); rec.Code != http.StatusBadRequest || strings.Contains(rec.Body.String(), "Alice") {
+			t.Errorf("Expected status 400 for %s, got %d: %s", target, rec.Code, rec.Body.String())
+		}
+	}
+}
+
+func TestCRUDHandler_DerivedColumns(t *testing.T) {
+	handler, _, cleanup := setupTestHandler(t)
+	defer cleanup()
+	handler.SetTableConfigs(map[string]*TableConfig{
+		"test_users": {Derived: []database.DerivedColumn{
+			{Name: "display_name", Expression: "CONCAT(name, ' (', age, ')')"},
+		}},
+	})
+
+	req := httptest.NewRequest("GET", "/duckdb/api/test_users?filter=display_name:like:%25(3%25&sort=display_name:desc", nil)
+	req = addAuthContext(req, "admin")
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	var result map[string]interface{}
+	if err := json.Unmarshal(rec.Body.Bytes(), &result); err != nil {
+		t.Fatalf("Failed to parse response: %v", err)
+	}
+	data := result["data"].([]interface{})
+	if len(data) != 2 {
+		t.Fatalf("Expected 2 rows with age in the 30s, got %d", len(data))
+	}
+	first := data[0].(map[string]interface{})
+	if first["display_name"] != "Charlie (35)" {
+		t.Errorf("Expected 'Charlie (35)' first, got %v", first["display_name"])
+	}
+	if first["name"] != "Charlie" {
+		t.Errorf("Expected regular columns alongside derived ones, got %v", first)
+	}
+
+	// Derived columns cannot be written or used to target writes
+	writes := []struct {
+		name   string
+		method string
+		target string
+		body   string
+	}{
+		{"create", "POST", "/duckdb/api/test_users", `{"id": 9, "display_name": "X"}`},
+		{"update set", "PUT", "/duckdb/api/test_users", `{"where": [{"column": "id", "op": "eq", "value": 1}], "set": {"display_name": "X"}}`},
+		{"update where", "PUT", "/duckdb/api/test_users", `{"where": [{"column": "display_name", "op": "eq", "value": "X"}], "set": {"name": "X"}}`},
+		{"delete where", "DELETE", "/duckdb/api/test_users?where=display_name:eq:X", ""},
+	}
+	for _, tt := range writes {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.target, bytes.NewBufferString(tt.body))
+			req = addAuthContext(req, "admin")
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+			if rec.Code != http.StatusBadRequest {
+				t.Errorf("Expected status 400, got %d: %s", rec.Code, rec.Body.String())
+			}
+		})
+	}
+}
+
+func TestCRUDHandler_JSONKeyCaseCamel(t *testing.T) {
+	handler, mgr, cleanup := setupTestHandler(t)
+	defer cleanup()
+	handler.SetJSONKeyCase(formats.KeyCaseCamel)
+
+	if _, err := mgr.ExecMain(`CREATE TABLE user_profiles (user_id INTEGER, first_name VARCHAR, address_2 VARCHAR)`); err != nil {
+		t.Fatalf("Failed to create table: %v", err)
+	}
+
+	do := func(method, target, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, target, bytes.NewBufferString(body))
+		req = addAuthContext(req, "admin")
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec
+	}
+
+	// camelCase keys on insert map back to snake_case columns
+	rec := do("POST", "/duckdb/api/user_profiles", `{"userId": 1, "firstName": "Ada", "address_2": "Flat 1"}`)
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("Expected status 201, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	// camelCase keys on update (SET and WHERE)
+	rec = do("PUT", "/duckdb/api/user_profiles", `{"where": [{"column": "userId", "op": "eq", "value": 1}], "set": {"firstName": "Grace"}}`)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	var firstName string
+	if err := mgr.QueryRowScanMain("SELECT first_name FROM user_profiles WHERE user_id = 1", []interface{}{&firstName}); err != nil {
+		t.Fatalf("Failed to read row: %v", err)
+	}
+	if firstName != "Grace" {
+		t.Errorf("Expected first_name 'Grace', got '%s'", firstName)
+	}
+
+	// JSON output uses camelCase keys
+	rec = do("GET", "/duckdb/api/user_profiles", "")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var result map[string]interface{}
+	if err := json.Unmarshal(rec.Body.Bytes(), &result); err != nil {
+		t.Fatalf("Failed to parse response: %v", err)
+	}
+	row := result["data"].([]interface{})[0].(map[string]interface{})
+	if row["userId"] != float64(1) || row["firstName"] != "Grace" {
+		t.Errorf("Expected camelCase keys, got %v", row)
+	}
+	if row["address_2"] != "Flat 1" {
+		t.Errorf("Expected non-round-trippable column name unchanged, got %v", row)
+	}
+}
+
+func TestCRUDHandler_Read_KeyBy(t *testing.T) {
+	handler, _, cleanup := setupTestHandler(t)
+	defer cleanup()
+
+	get := func(target string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("GET", target, nil)
+		req = addAuthContext(req, "admin")
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec
+	}
+
+	rec := get("/duckdb/api/test_users?key_by=id")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var result map[string]interface{}
+	if err := json.Unmarshal(rec.Body.Bytes(), &result); err != nil {
+		t.Fatalf("Failed to parse response: %v", err)
+	}
+	data, ok := result["data"].(map[string]interface{})
+	if !ok {
+		t.Fatalf("Expected data to be an object, got %T", result["data"])
+	}
+	if data["1"].(map[string]interface{})["name"] != "Alice" {
+		t.Errorf("Expected key '1' to be Alice, got %v", data["1"])
+	}
+
+	// Duplicate keys are rejected unless last-wins is requested
+	if _, err := handler.dbMgr.ExecMain(`INSERT INTO test_users VALUES (4, 'Alice', 'alice2@example.com', 40)`); err != nil {
+		t.Fatalf("Failed to insert duplicate name: %v", err)
+	}
+	if rec := get("/duckdb/api/test_users?key_by=name"); rec.Code != http.StatusConflict {
+		t.Errorf("Expected status 409 for duplicate keys, got %d: %s", rec.Code, rec.Body.String())
+	}
+	rec = get("/duckdb/api/test_users?key_by=name&key_by_duplicates=last&sort=id:asc")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	json.Unmarshal(rec.Body.Bytes(), &result)
+	data = result["data"].(map[string]interface{})
+	if len(data) != 3 || data["Alice"].(map[string]interface{})["id"] != float64(4) {
+		t.Errorf("Expected last Alice (id 4) to win, got %v", data)
+	}
+
+	// Unknown column and non-JSON formats
+	if rec := get("/duckdb/api/test_users?key_by=missing"); rec.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400 for unknown key column, got %d", rec.Code)
+	}
+	req := httptest.NewRequest("GET", "/duckdb/api/test_users?key_by=id", nil)
+	req.Header.Set("Accept", "text/csv")
+	req = addAuthContext(req, "admin")
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400 for key_by with CSV, got %d", rec.Code)
+	}
+}
+
+func TestCRUDHandler_Read_IncludeHash(t *testing.T) {
+	handler, _, cleanup := setupTestHandler(t)
+	defer cleanup()
+	handler.SetTableConfigs(map[string]*TableConfig{
+		"test_users": {HashColumns: []string{"name", "email", "age"}},
+	})
+
+	hashes := func() map[float64]string {
+		req := httptest.NewRequest("GET", "/duckdb/api/test_users?include_hash=true", nil)
+		req = addAuthContext(req, "admin")
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d: %s", rec.Code, rec.Body.String())
+		}
+		var result map[string]interface{}
+		if err := json.Unmarshal(rec.Body.Bytes(), &result); err != nil {
+			t.Fatalf("Failed to parse response: %v", err)
+		}
+		out := make(map[float64]string)
+		for _, row := range result["data"].([]interface{}) {
+			r := row.(map[string]interface{})
+			hash, ok := r["_row_hash"].(string)
+			if !ok || hash == "" {
+				t.Fatalf("Expected _row_hash in row, got %v", r)
+			}
+			out[r["id"].(float64)] = hash
+		}
+		return out
+	}
+
+	// A row with the same hashed values but a different id hashes identically
+	if _, err := handler.dbMgr.ExecMain(`INSERT INTO test_users VALUES (4, 'Alice', 'alice@example.com', 30)`); err != nil {
+		t.Fatalf("Failed to insert duplicate row: %v", err)
+	}
+	before := hashes()
+	if before[1] != before[4] {
+		t.Errorf("Expected identical rows to have identical hashes, got %s and %s", before[1], before[4])
+	}
+	if before[1] == before[2] {
+		t.Error("Expected different rows to have different hashes")
+	}
+
+	// Updating a row changes its hash
+	if _, err := handler.dbMgr.ExecMain(`UPDATE test_users SET age = 31 WHERE id = 1`); err != nil {
+		t.Fatalf("Failed to update row: %v", err)
+	}
+	after := hashes()
+	if after[1] == before[1] {
+		t.Error("Expected changed row to have a different hash")
+	}
+	if after[2] != before[2] {
+		t.Error("Expected unchanged row to keep its hash")
+	}
+
+	// Without include_hash the column is absent
+	req := httptest.NewRequest("GET", "/duckdb/api/test_users", nil)
+	req = addAuthContext(req, "admin")
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	if strings.Contains(rec.Body.String(), "_row_hash") {
+		t.Error("Expected no _row_hash without include_hash")
+	}
+}
+
+func enableValidation(t *testing.T, handler *CRUDHandler) {
+	cfg := &TableConfig{Rules: []ValidationRule{
+		{Column: "age", Rule: RuleMin, Values: []string{"0"}},
+		{Column: "age", Rule: RuleMax, Values: []string{"150"}},
+		{Column: "name", Rule: RuleEnum, Values: []string{"Alice", "Bob", "Charlie", "Dave"}},
+		{Column: "email", Rule: RuleRegex, Values: []string{`^[^@]+@[^@]+$`}},
+	}}
+	if err := cfg.Provision(); err != nil {
+		t.Fatalf("Failed to provision table config: %v", err)
+	}
+	handler.SetTableConfigs(map[string]*TableConfig{"test_users": cfg})
+}
+
+func TestCRUDHandler_AutoCreateTable(t *testing.T) {
+	handler, mgr, cleanup := setupTestHandler(t)
+	defer cleanup()
+	handler.SetAutoCreateTables(true)
+
+	post := func(body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("POST", "/duckdb/api/events", bytes.NewBufferString(body))
+		req = addAuthContext(req, "admin")
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec
+	}
+
+	// First insert creates the table
+	rec := post(`{"id": 1, "kind": "click", "score": 0.5, "ok": true, "meta": {"x": 1}}`)
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("Expected status 201, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	// Second insert uses the created table
+	rec = post(`{"id": 2, "kind": "view", "score": 1.25, "ok": false}`)
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("Expected status 201 for second insert, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	var count int64
+	if err := mgr.QueryRowScanMain("SELECT COUNT(*) FROM events", []interface{}{&count}); err != nil {
+		t.Fatalf("Failed to count rows: %v", err)
+	}
+	if count != 2 {
+		t.Errorf("Expected 2 rows in auto-created table, got %d", count)
+	}
+
+	var metaType string
+	if err := mgr.QueryRowScanMain("SELECT data_type FROM information_schema.columns WHERE table_name = 'events' AND column_name = 'meta'", []interface{}{&metaType}); err != nil {
+		t.Fatalf("Failed to read column type: %v", err)
+	}
+	if metaType != "JSON" {
+		t.Errorf("Expected meta column of type JSON, got %s", metaType)
+	}
+}
+
+func TestCRUDHandler_AutoCreateTable_Gated(t *testing.T) {
+	handler, _, cleanup := setupTestHandler(t)
+	defer cleanup()
+
+	body := `{"id": 1}`
+
+	// Disabled by default
+	req := httptest.NewRequest("POST", "/duckdb/api/events", bytes.NewBufferString(body))
+	req = addAuthContext(req, "admin")
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("Expected status 404 when disabled, got %d", rec.Code)
+	}
+
+	// Requires create permission on '*'
+	handler.SetAutoCreateTables(true)
+	req = httptest.NewRequest("POST", "/duckdb/api/events", bytes.NewBufferString(body))
+	req = addAuthContext(req, "reader")
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	if rec.Code != http.StatusForbidden {
+		t.Errorf("Expected status 403 for reader, got %d", rec.Code)
+	}
+
+	// The table's own grant must allow the insert too
+	if err := handler.authorizer.CreateRole("creator", "Creates tables"); err != nil {
+		t.Fatalf("Failed to create role: %v", err)
+	}
+	for _, perm := range []auth.Permission{
+		{RoleName: "creator", TableName: "*", CanCreate: true},
+		{RoleName: "creator", TableName: "events", CanRead: true},
+	} {
+		if err := handler.authorizer.CreatePermission(perm); err != nil {
+			t.Fatalf("Failed to create permission: %v", err)
+		}
+	}
+	req = httptest.NewRequest("POST", "/duckdb/api/events", bytes.NewBufferString(body))
+	req = addAuthContext(req, "creator")
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	if rec.Code != http.StatusForbidden {
+		t.Errorf("Expected status 403 without create permission on the table, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	// Reads of missing tables still return 404
+	req = httptest.NewRequest("GET", "/duckdb/api/events", nil)
+	req = addAuthContext(req, "admin")
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("Expected status 404 for GET, got %d", rec.Code)
+	}
+}
+
+func TestCRUDHandler_AutoCreateTable_Existing(t *testing.T) {
+	handler, mgr, cleanup := setupTestHandler(t)
+	defer cleanup()
+	handler.SetAutoCreateTables(true)
+
+	// A name that resolves to an existing table is not created or written
+	req := httptest.NewRequest("POST", "/duckdb/api/test_users", bytes.NewBufferString(`{"id": 9, "name": "Mallory"}`))
+	req = addAuthContext(req, "admin")
+	rec := httptest.NewRecorder()
+	handler.handleAutoCreate(rec, req, "test_users")
+	if rec.Code != http.StatusConflict {
+		t.Errorf("Expected status 409, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var count int
+	if err := mgr.QueryRowScanMain("SELECT COUNT(*) FROM test_users", []interface{}{&count}); err != nil {
+		t.Fatalf("Failed to count rows: %v", err)
+	}
+	if count != 3 {
+		t.Errorf("Expected the existing table to keep 3 rows, got %d", count)
+	}
+}
+
+func TestCRUDHandler_Create_ValidationRules(t *testing.T) {
+	handler, _, cleanup := setupTestHandler(t)
+	defer cleanup()
+	enableValidation(t, handler)
+
+	tests := []struct {
+		name       string
+		body       string
+		wantStatus int
+		wantRule   string
+	}{
+		{"valid row", `{"id": 4, "name": "Dave", "email": "dave@example.com", "age": 40}`, http.StatusCreated, ""},
+		{"below min", `{"id": 5, "name": "Dave", "age": -1}`, http.StatusUnprocessableEntity, RuleMin},
+		{"above max", `{"id": 6, "name": "Dave", "age": 200}`, http.StatusUnprocessableEntity, RuleMax},
+		{"not in enum", `{"id": 7, "name": "Eve", "age": 20}`, http.StatusUnprocessableEntity, RuleEnum},
+		{"regex mismatch", `{"id": 8, "name": "Dave", "email": "invalid"}`, http.StatusUnprocessableEntity, RuleRegex},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("POST", "/duckdb/api/test_users", bytes.NewBufferString(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			req = addAuthContext(req, "admin")
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("Expected status %d, got %d: %s", tt.wantStatus, rec.Code, rec.Body.String())
+			}
+			if tt.wantRule == "" {
+				return
+			}
+
+			var result map[string]interface{}
+			json.Unmarshal(rec.Body.Bytes(), &result)
+			rule, ok := result["rule"].(map[string]interface{})
+			if !ok {
+				t.Fatalf("Expected 'rule' object in response: %s", rec.Body.String())
+			}
+			if rule["rule"] != tt.wantRule {
+				t.Errorf("Expected violated rule %s, got %v", tt.wantRule, rule["rule"])
+			}
+		})
+	}
+}
+
+func TestCRUDHandler_Update_ValidationRules(t *testing.T) {
+	handler, _, cleanup := setupTestHandler(t)
+	defer cleanup()
+	enableValidation(t, handler)
+
+	body := `{"where": [{"column": "id", "op": "eq", "value": 1}], "set": {"age": -5}}`
+	req := httptest.NewRequest("PUT", "/duckdb/api/test_users", bytes.NewBufferString(body))
+	req.Header.Set("Content-Type", "application/json")
+	req = addAuthContext(req, "admin")
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusUnprocessableEntity {
+		t.Errorf("Expected status 422, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	body = `{"where": [{"column": "id", "op": "eq", "value": 1}], "set": {"age": 31}}`
+	req = httptest.NewRequest("PUT", "/duckdb/api/test_users", bytes.NewBufferString(body))
+	req.Header.Set("Content-Type", "application/json")
+	req = addAuthContext(req, "admin")
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Errorf("Expected status 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+}
+
+func TestExtractTableFromPath(t *testing.T) {
+	tests := []struct {
+		path     string
+		expected string
+	}{
 		{"/duckdb/api/users", "users"},
 		{"/duckdb/api/user_data", "user_data"},
 		{"duckdb/api/users", "users"},
+		{"/duckdb/api/users/", "users"},
 		{"/duckdb/api/", ""},
 		{"/api/users", ""},
 		{"/duckdb/users", ""},
@@ -599,3 +2301,235 @@ func BenchmarkCRUDHandler_Read(b *testing.B) {
 		handler.ServeHTTP(rec, req)
 	}
 }
+
+func TestCRUDHandler_Read_Summary(t *testing.T) {
+	handler, _, cleanup := setupTestHandler(t)
+	defer cleanup()
+
+	// Ages of the matching rows are 30 and 35; the page only holds one of them
+	query := url.Values{
+		"filter":  {"age:gte:30"},
+		"sort":    {"age:asc"},
+		"limit":   {"1"},
+		"page":    {"1"},
+		"summary": {"sum:age,avg:age,max:age,count:email"},
+	}
+	req := httptest.NewRequest("GET", "/duckdb/api/test_users?"+query.Encode(), nil)
+	req = addAuthContext(req, "admin")
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	var result struct {
+		Data    []map[string]interface{}          `json:"data"`
+		Summary map[string]map[string]interface{} `json:"summary"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &result); err != nil {
+		t.Fatalf("Failed to parse response: %v", err)
+	}
+	if len(result.Data) != 1 {
+		t.Fatalf("Expected a page of 1 row, got %d", len(result.Data))
+	}
+	age := result.Summary["age"]
+	if age["sum"] != float64(65) || age["avg"] != 32.5 || age["max"] != float64(35) {
+		t.Errorf("Expected summary over all matching rows, got %v", age)
+	}
+	if result.Summary["email"]["count"] != float64(2) {
+		t.Errorf("Expected count of 2, got %v", result.Summary["email"])
+	}
+
+	tests := []struct {
+		name    string
+		query   string
+		accept  string
+		message string
+	}{
+		{"unknown function", "summary=median:age", "", "unsupported summary function"},
+		{"unknown column", "summary=sum:salary", "", "unknown column(s) 'salary'"},
+		{"non-numeric column", "summary=sum:name", "", "Failed to compute summary"},
+		{"csv format", "summary=sum:age", "text/csv", "only supported for JSON"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "/duckdb/api/test_users?"+tt.query, nil)
+			if tt.accept != "" {
+				req.Header.Set("Accept", tt.accept)
+			}
+			req = addAuthContext(req, "admin")
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+			if rec.Code != http.StatusBadRequest {
+				t.Fatalf("Expected status 400, got %d: %s", rec.Code, rec.Body.String())
+			}
+			if !strings.Contains(rec.Body.String(), tt.message) {
+				t.Errorf("Expected message containing %q, got %s", tt.message, rec.Body.String())
+			}
+		})
+	}
+}
+
+func TestCRUDHandler_Read_Sample(t *testing.T) {
+	handler, mgr, cleanup := setupTestHandler(t)
+	defer cleanup()
+	if _, err := mgr.ExecMain("INSERT INTO test_users SELECT i, 'user' || i, NULL, i % 100 FROM range(4, 1004) t(i)"); err != nil {
+		t.Fatalf("Failed to insert test data: %v", err)
+	}
+
+	read := func(query string) (string, int) {
+		t.Helper()
+		req := httptest.NewRequest("GET", "/duckdb/api/test_users?"+query, nil)
+		req = addAuthContext(req, "admin")
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("Expected status 200 for %s, got %d: %s", query, rec.Code, rec.Body.String())
+		}
+		var result struct {
+			Data []map[string]interface{} `json:"data"`
+		}
+		if err := json.Unmarshal(rec.Body.Bytes(), &result); err != nil {
+			t.Fatalf("Failed to parse response: %v", err)
+		}
+		ids := make([]string, len(result.Data))
+		for i, row := range result.Data {
+			ids[i] = fmt.Sprint(row["id"])
+		}
+		return strings.Join(ids, ","), len(result.Data)
+	}
+
+	first, n := read("sample=10%25&seed=42&sort=id:asc")
+	if n == 0 || n >= 1003 {
+		t.Fatalf("Expected a sample of the rows, got %d rows", n)
+	}
+	if again, _ := read("sample=10%25&seed=42&sort=id:asc"); again != first {
+		t.Errorf("Expected the same seed to return the same rows, got %s and %s", first, again)
+	}
+	if other, _ := read("sample=10%25&seed=7&sort=id:asc"); other == first {
+		t.Errorf("Expected a different seed to return different rows, got %s", other)
+	}
+	if _, n := read("sample=25&seed=1"); n != 25 {
+		t.Errorf("Expected a sample of 25 rows, got %d", n)
+	}
+
+	req := httptest.NewRequest("GET", "/duckdb/api/test_users?sample=10%25&seed=x", nil)
+	req = addAuthContext(req, "admin")
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	if rec.Code != http.StatusBadRequest || !strings.Contains(rec.Body.String(), "Invalid sample: invalid seed") {
+		t.Errorf("Expected status 400 for an invalid seed, got %d: %s", rec.Code, rec.Body.String())
+	}
+}
+
+func TestCRUDHandler_Create_DefaultValue(t *testing.T) {
+	handler, mgr, cleanup := setupTestHandler(t)
+	defer cleanup()
+
+	if _, err := mgr.ExecMain(`CREATE TABLE tasks (id INTEGER, title VARCHAR, status VARCHAR DEFAULT 'open')`); err != nil {
+		t.Fatalf("Failed to create table: %v", err)
+	}
+	handler.SetTableConfigs(map[string]*TableConfig{
+		"tasks": {Rules: []ValidationRule{{Column: "status", Rule: "enum", Values: []string{"open", "done"}}}},
+	})
+	if err := handler.tables["tasks"].Provision(); err != nil {
+		t.Fatalf("Failed to provision table config: %v", err)
+	}
+
+	create := func(body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("POST", "/duckdb/api/tasks", bytes.NewBufferString(body))
+		req = addAuthContext(req, "admin")
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec
+	}
+
+	// The sentinel is not checked against the enum rule
+	if rec := create(`{"id": 1, "title": "a", "status": "__DEFAULT__"}`); rec.Code != http.StatusCreated {
+		t.Fatalf("Expected status 201, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if rec := create(`{"id": 2, "title": "b"}`); rec.Code != http.StatusCreated {
+		t.Fatalf("Expected status 201, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	var defaulted, omitted sql.NullString
+	if err := mgr.QueryRowScanMain("SELECT status FROM tasks WHERE id = 1", []interface{}{&defaulted}); err != nil {
+		t.Fatalf("Failed to read row: %v", err)
+	}
+	if err := mgr.QueryRowScanMain("SELECT status FROM tasks WHERE id = 2", []interface{}{&omitted}); err != nil {
+		t.Fatalf("Failed to read row: %v", err)
+	}
+	if defaulted.String != "open" {
+		t.Errorf("Expected the column default 'open', got %v", defaulted)
+	}
+	if omitted.Valid {
+		t.Errorf("Expected omitted column to be NULL, got %q", omitted.String)
+	}
+
+	// Unknown columns are still rejected
+	if rec := create(`{"id": 3, "state": "__DEFAULT__"}`); rec.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400 for unknown defaulted column, got %d: %s", rec.Code, rec.Body.String())
+	}
+}
+
+func TestCRUDHandler_Read_ResultCache(t *testing.T) {
+	mgr, err := database.NewManagerForTesting(database.Config{
+		MainDBPath:    ":memory:",
+		AuthDBPath:    ":memory:",
+		Threads:       1,
+		AccessMode:    "read_write",
+		QueryTimeout:  30 * time.Second,
+		QueryCacheTTL: time.Minute,
+		Logger:        zap.NewNop(),
+	})
+	if err != nil {
+		t.Fatalf("Failed to create manager: %v", err)
+	}
+	defer mgr.Close()
+	if _, err := mgr.ExecMain("CREATE TABLE test_users (id INTEGER PRIMARY KEY, name VARCHAR, email VARCHAR, age INTEGER)"); err != nil {
+		t.Fatalf("Failed to create test table: %v", err)
+	}
+	if _, err := mgr.ExecMain("INSERT INTO test_users VALUES (1, 'Alice', 'alice@example.com', 30)"); err != nil {
+		t.Fatalf("Failed to insert test data: %v", err)
+	}
+	handler := NewCRUDHandler(mgr, auth.NewAuthorizer(mgr.AuthDB()), 100, 10000, zap.NewNop())
+
+	read := func() map[string]interface{} {
+		t.Helper()
+		req := addAuthContext(httptest.NewRequest("GET", "/duckdb/api/test_users?limit=10&page=1&sort=id", nil), "admin")
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d: %s", rec.Code, rec.Body.String())
+		}
+		var result map[string]interface{}
+		json.Unmarshal(rec.Body.Bytes(), &result)
+		return result
+	}
+
+	first := read()
+	second := read()
+	if len(second["data"].([]interface{})) != 1 || second["data"].([]interface{})[0].(map[string]interface{})["name"] != "Alice" {
+		t.Errorf("Expected the cached read to return Alice, got %v", second["data"])
+	}
+	if first["pagination"].(map[string]interface{})["total_rows"] != second["pagination"].(map[string]interface{})["total_rows"] {
+		t.Errorf("Expected the same total_rows, got %v and %v", first["pagination"], second["pagination"])
+	}
+
+	body := bytes.NewBufferString(`{"id": 2, "name": "Bob", "email": "bob@example.com", "age": 25}`)
+	req := httptest.NewRequest("POST", "/duckdb/api/test_users", body)
+	req.Header.Set("Content-Type", "application/json")
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, addAuthContext(req, "admin"))
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("Expected status 201, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	third := read()
+	if n := len(third["data"].([]interface{})); n != 2 {
+		t.Errorf("Expected 2 rows after the insert, got %d", n)
+	}
+	if total := third["pagination"].(map[string]interface{})["total_rows"]; total != float64(2) {
+		t.Errorf("Expected total_rows 2 after the insert, got %v", total)
+	}
+}
@@ -0,0 +1,112 @@
+package handlers
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/tobilg/caddy-duckdb-module/auth"
+	"github.com/tobilg/caddy-duckdb-module/database"
+)
+
+// RedactedValue replaces the bound values of redacted columns in debug output.
+const RedactedValue = "[REDACTED]"
+
+// DebugSQLConfig configures the debug_sql request parameter, which adds the
+// generated SQL and its bound parameter values to read, update, delete, and
+// query responses. Without a config, or for roles not listed, the parameter is
+// ignored.
+type DebugSQLConfig struct {
+	// Roles are the roles allowed to request debug_sql=true.
+	Roles []string `json:"roles,omitempty"`
+
+	// Redact lists columns whose bound values are replaced with RedactedValue.
+	// Raw /query parameters are not tied to a column, so they are all redacted
+	// when any column is listed.
+	Redact []string `json:"redact,omitempty"`
+}
+
+// SetDebugSQL enables the debug_sql parameter for the configured roles.
+func (h *CRUDHandler) SetDebugSQL(cfg *DebugSQLConfig) {
+	h.debugSQL = cfg
+}
+
+// SetDebugSQL enables the debug_sql parameter for the configured roles.
+func (h *QueryHandler) SetDebugSQL(cfg *DebugSQLConfig) {
+	h.debugSQL = cfg
+}
+
+// allows reports whether r requested debug_sql and its role may see it.
+func (c *DebugSQLConfig) allows(r *http.Request) bool {
+	if c == nil || !ParseDebugSQL(r) {
+		return false
+	}
+	role := auth.GetRoleFromContext(r.Context())
+	for _, allowed := range c.Roles {
+		if allowed == role {
+			return true
+		}
+	}
+	return false
+}
+
+// redacts reports whether the values of column must be redacted
+// (case-insensitive, like DuckDB identifiers).
+func (c *DebugSQLConfig) redacts(column string) bool {
+	for _, col := range c.Redact {
+		if strings.EqualFold(col, column) {
+			return true
+		}
+	}
+	return false
+}
+
+// redactFilters returns a copy of filters with redacted columns' values replaced.
+func (c *DebugSQLConfig) redactFilters(filters []database.Filter) []database.Filter {
+	redacted := make([]database.Filter, len(filters))
+	for i, f := range filters {
+		if f.Value != nil && c.redacts(f.Column) {
+			if _, _, ok := database.BetweenBounds(f.Value); ok {
+				f.Value = []interface{}{RedactedValue, RedactedValue}
+			} else {
+				f.Value = RedactedValue
+			}
+		}
+		redacted[i] = f
+	}
+	return redacted
+}
+
+// redactSet returns a copy of set with redacted columns' values replaced.
+func (c *DebugSQLConfig) redactSet(set map[string]interface{}) map[string]interface{} {
+	redacted := make(map[string]interface{}, len(set))
+	for col, value := range set {
+		if c.redacts(col) {
+			value = RedactedValue
+		}
+		redacted[col] = value
+	}
+	return redacted
+}
+
+// redactParams returns the positional parameters of a raw query, all of them
+// redacted if any column is configured for redaction.
+func (c *DebugSQLConfig) redactParams(params []interface{}) []interface{} {
+	if len(c.Redact) == 0 {
+		return params
+	}
+	redacted := make([]interface{}, len(params))
+	for i := range redacted {
+		redacted[i] = RedactedValue
+	}
+	return redacted
+}
+
+// debugObject builds the debug object of a response from the executed statements.
+func debugObject(statements ...database.Statement) map[string]interface{} {
+	for i := range statements {
+		if statements[i].Params == nil {
+			statements[i].Params = []interface{}{}
+		}
+	}
+	return map[string]interface{}{"queries": statements}
+}
@@ -0,0 +1,185 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// debugResponse is the part of a response carrying debug_sql output.
+type debugResponse struct {
+	Debug *struct {
+		Queries []struct {
+			SQL    string        `json:"sql"`
+			Params []interface{} `json:"params"`
+		} `json:"queries"`
+	} `json:"debug"`
+}
+
+func decodeDebug(t *testing.T, rec *httptest.ResponseRecorder) debugResponse {
+	t.Helper()
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var result debugResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &result); err != nil {
+		t.Fatalf("Failed to parse response: %v", err)
+	}
+	return result
+}
+
+func TestCRUDHandler_DebugSQL_Read(t *testing.T) {
+	handler, _, cleanup := setupTestHandler(t)
+	defer cleanup()
+	handler.SetDebugSQL(&DebugSQLConfig{Roles: []string{"admin"}, Redact: []string{"email"}})
+
+	req := httptest.NewRequest("GET", "/duckdb/api/test_users?filter=email:eq:alice@example.com,age:gt:20&debug_sql=true", nil)
+	req = addAuthContext(req, "admin")
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	result := decodeDebug(t, rec)
+	if result.Debug == nil || len(result.Debug.Queries) != 2 {
+		t.Fatalf("Expected the select and count statements, got %s", rec.Body.String())
+	}
+	selectStmt := result.Debug.Queries[0]
+	if selectStmt.SQL != "SELECT * FROM test_users WHERE email = $1 AND age > $2 LIMIT 10000" {
+		t.Errorf("Unexpected select SQL: %s", selectStmt.SQL)
+	}
+	if len(selectStmt.Params) != 2 || selectStmt.Params[0] != RedactedValue || selectStmt.Params[1] != "20" {
+		t.Errorf("Expected the email value to be redacted, got %v", selectStmt.Params)
+	}
+	if countStmt := result.Debug.Queries[1]; countStmt.SQL != "SELECT COUNT(*) FROM test_users WHERE email = $1 AND age > $2" {
+		t.Errorf("Unexpected count SQL: %s", countStmt.SQL)
+	}
+
+	// Omitted without the parameter, and for roles without the capability
+	tests := []struct {
+		name  string
+		query string
+		role  string
+	}{
+		{"parameter not set", "", "admin"},
+		{"role not granted", "?debug_sql=true", "reader"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "/duckdb/api/test_users"+tt.query, nil)
+			req = addAuthContext(req, tt.role)
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+			if result := decodeDebug(t, rec); result.Debug != nil {
+				t.Errorf("Expected no debug object, got %s", rec.Body.String())
+			}
+		})
+	}
+}
+
+func TestCRUDHandler_DebugSQL_Disabled(t *testing.T) {
+	handler, _, cleanup := setupTestHandler(t)
+	defer cleanup()
+
+	req := httptest.NewRequest("GET", "/duckdb/api/test_users?debug_sql=true", nil)
+	req = addAuthContext(req, "admin")
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	if result := decodeDebug(t, rec); result.Debug != nil {
+		t.Errorf("Expected debug_sql to be ignored when not configured, got %s", rec.Body.String())
+	}
+}
+
+func TestCRUDHandler_DebugSQL_UpdateDelete(t *testing.T) {
+	handler, _, cleanup := setupTestHandler(t)
+	defer cleanup()
+	handler.SetDebugSQL(&DebugSQLConfig{Roles: []string{"admin"}, Redact: []string{"email"}})
+
+	body := bytes.NewBufferString(`{
+		"where": [{"column": "id", "op": "eq", "value": 1}],
+		"set": {"email": "alice@new.example.com", "age": 31}
+	}`)
+	req := httptest.NewRequest("PUT", "/duckdb/api/test_users?debug_sql=true", body)
+	req.Header.Set("Content-Type", "application/json")
+	req = addAuthContext(req, "admin")
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	result := decodeDebug(t, rec)
+	if result.Debug == nil || len(result.Debug.Queries) != 1 {
+		t.Fatalf("Expected the update statement, got %s", rec.Body.String())
+	}
+	update := result.Debug.Queries[0]
+	if update.SQL != "UPDATE test_users SET age = $1, email = $2 WHERE id = $3" {
+		t.Errorf("Unexpected update SQL: %s", update.SQL)
+	}
+	if len(update.Params) != 3 || update.Params[0] != float64(31) || update.Params[1] != RedactedValue || update.Params[2] != float64(1) {
+		t.Errorf("Expected the email value to be redacted, got %v", update.Params)
+	}
+
+	req = httptest.NewRequest("DELETE", "/duckdb/api/test_users?where=id:eq:2&debug_sql=true", nil)
+	req = addAuthContext(req, "admin")
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	result = decodeDebug(t, rec)
+	if result.Debug == nil || len(result.Debug.Queries) != 1 || result.Debug.Queries[0].SQL != "DELETE FROM test_users WHERE id = $1" {
+		t.Errorf("Expected the delete statement, got %s", rec.Body.String())
+	}
+
+	// The capability is per role
+	req = httptest.NewRequest("DELETE", "/duckdb/api/test_users?where=id:eq:3&debug_sql=true", nil)
+	req = addAuthContext(req, "editor")
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	if result := decodeDebug(t, rec); result.Debug != nil {
+		t.Errorf("Expected no debug object for a role without the capability, got %s", rec.Body.String())
+	}
+}
+
+func TestQueryHandler_DebugSQL(t *testing.T) {
+	handler, _, cleanup := setupQueryHandler(t)
+	defer cleanup()
+	handler.SetDebugSQL(&DebugSQLConfig{Roles: []string{"admin"}})
+
+	body := bytes.NewBufferString(`{"sql": "SELECT name FROM test_query WHERE id = $1", "params": [2]}`)
+	req := httptest.NewRequest("POST", "/duckdb/query?debug_sql=true", body)
+	req = addQueryAuthContext(req, "admin")
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	result := decodeDebug(t, rec)
+	if result.Debug == nil || len(result.Debug.Queries) != 1 {
+		t.Fatalf("Expected the query statement, got %s", rec.Body.String())
+	}
+	stmt := result.Debug.Queries[0]
+	if stmt.SQL != "SELECT name FROM test_query WHERE id = $1" || len(stmt.Params) != 1 || stmt.Params[0] != float64(2) {
+		t.Errorf("Unexpected debug statement: %+v", stmt)
+	}
+
+	// Raw parameters are all redacted once any column is redacted
+	handler.SetDebugSQL(&DebugSQLConfig{Roles: []string{"admin"}, Redact: []string{"secret"}})
+	body = bytes.NewBufferString(`{"sql": "UPDATE test_query SET value = $1 WHERE id = $2", "params": [1.5, 2]}`)
+	req = httptest.NewRequest("POST", "/duckdb/query?debug_sql=true", body)
+	req = addQueryAuthContext(req, "admin")
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	result = decodeDebug(t, rec)
+	if result.Debug == nil || len(result.Debug.Queries) != 1 {
+		t.Fatalf("Expected the DML statement, got %s", rec.Body.String())
+	}
+	if params := result.Debug.Queries[0].Params; len(params) != 2 || params[0] != RedactedValue || params[1] != RedactedValue {
+		t.Errorf("Expected all params to be redacted, got %v", params)
+	}
+
+	// Omitted without the parameter
+	body = bytes.NewBufferString(`{"sql": "SELECT 1"}`)
+	req = httptest.NewRequest("POST", "/duckdb/query", body)
+	req = addQueryAuthContext(req, "admin")
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	if result := decodeDebug(t, rec); result.Debug != nil {
+		t.Errorf("Expected no debug object, got %s", rec.Body.String())
+	}
+}
@@ -0,0 +1,19 @@
+package handlers
+
+// SetDecimalAsString sets whether DECIMAL values are written to JSON responses
+// as strings (true) or as numbers with the exact digits (false).
+func (h *CRUDHandler) SetDecimalAsString(enabled bool) {
+	h.decimalAsNumber = !enabled
+}
+
+// SetDecimalAsString sets whether DECIMAL values are written to JSON responses
+// as strings (true) or as numbers with the exact digits (false).
+func (h *QueryHandler) SetDecimalAsString(enabled bool) {
+	h.decimalAsNumber = !enabled
+}
+
+// SetDecimalAsString sets whether the record schemas of tables=true document
+// DECIMAL columns as strings (true) or numbers (false), matching the handlers.
+func (h *OpenAPIHandler) SetDecimalAsString(enabled bool) {
+	h.decimalAsNumber = !enabled
+}
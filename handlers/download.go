@@ -0,0 +1,179 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/tobilg/caddy-duckdb-module/auth"
+	"go.uber.org/zap"
+)
+
+// downloadFormats maps the formats a download token can be minted for to the
+// Accept header that selects them and the file extension of the download.
+var downloadFormats = map[string]struct{ accept, ext string }{
+	"json":    {"application/json", "json"},
+	"csv":     {"text/csv", "csv"},
+	"parquet": {"application/parquet", "parquet"},
+	"arrow":   {"application/vnd.apache.arrow.stream", "arrow"},
+	"ndjson":  {"application/x-ndjson", "ndjson"},
+}
+
+// SetDownloadTokenTTL enables single-use download tokens, minted via
+// POST /api/{table}/download-token. ttl is the default and maximum lifetime
+// of a token; zero disables the endpoint.
+func (h *CRUDHandler) SetDownloadTokenTTL(ttl time.Duration) {
+	h.downloadTTL = ttl
+}
+
+// handleDownloadToken mints a single-use token for reading the table with the
+// request's query parameters (filter, sort, limit, ...). The token is redeemed
+// without an API key at GET /download/{token}. Requires READ permission.
+func (h *CRUDHandler) handleDownloadToken(w http.ResponseWriter, r *http.Request, tableName string) {
+	requestID := auth.GetRequestIDFromContext(r.Context())
+
+	if h.downloadTTL <= 0 {
+		h.sendErrorWithRequest(w, r, "Download tokens are not enabled", http.StatusNotFound)
+		return
+	}
+
+	// Check authorization
+	role := auth.GetRoleFromContext(r.Context())
+	allowed, err := h.authorizer.CheckPermission(role, tableName, auth.OperationRead)
+	if err != nil {
+		h.logger.Error("Failed to check permission", zap.Error(err), zap.String("request_id", requestID))
+		h.sendErrorWithRequest(w, r, "Failed to check permission", http.StatusInternalServerError)
+		return
+	}
+	if !allowed {
+		h.sendErrorWithRequest(w, r, "Forbidden: insufficient permissions for READ operation", http.StatusForbidden)
+		return
+	}
+
+	// Optional body: {"format": "csv", "expires_in": 60}
+	defer r.Body.Close()
+	var req struct {
+		Format    string `json:"format"`
+		ExpiresIn int    `json:"expires_in"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && err != io.EOF {
+		h.sendErrorWithRequest(w, r, "Invalid JSON in request body", http.StatusBadRequest)
+		return
+	}
+	if req.Format == "" {
+		req.Format = "json"
+	}
+	req.Format = strings.ToLower(req.Format)
+	if _, ok := downloadFormats[req.Format]; !ok {
+		formats := slices.Sorted(maps.Keys(downloadFormats))
+		h.sendErrorWithRequest(w, r, fmt.Sprintf("Invalid format '%s' (must be one of: %s)", req.Format, strings.Join(formats, ", ")), http.StatusBadRequest)
+		return
+	}
+	if req.ExpiresIn < 0 {
+		h.sendErrorWithRequest(w, r, "expires_in must be >= 0", http.StatusBadRequest)
+		return
+	}
+	ttl := h.downloadTTL
+	if expiresIn := time.Duration(req.ExpiresIn) * time.Second; expiresIn > 0 && expiresIn < ttl {
+		ttl = expiresIn
+	}
+
+	// The table name is already qualified with the catalog
+	query := r.URL.Query()
+	query.Del("catalog")
+
+	grant := auth.DownloadGrant{
+		RoleName:  role,
+		TableName: tableName,
+		Query:     query.Encode(),
+		Format:    req.Format,
+		ExpiresAt: time.Now().Add(ttl),
+	}
+	token, err := h.authorizer.CreateDownloadToken(grant)
+	if err != nil {
+		h.logger.Error("Failed to create download token", zap.Error(err), zap.String("table", tableName), zap.String("request_id", requestID))
+		h.sendErrorWithRequest(w, r, "Failed to create download token", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"token":      token,
+		"url":        "/duckdb/download/" + token,
+		"expires_at": grant.ExpiresAt.UTC().Format(time.RFC3339),
+	})
+}
+
+// DownloadHandler redeems single-use download tokens. It does not require an
+// API key: the token itself authorizes exactly one read, which is served by the
+// CRUD handler with the permissions of the role that minted the token.
+type DownloadHandler struct {
+	authorizer *auth.Authorizer
+	crud       *CRUDHandler
+	logger     *zap.Logger
+}
+
+// NewDownloadHandler creates a new download handler.
+func NewDownloadHandler(authorizer *auth.Authorizer, crud *CRUDHandler, logger *zap.Logger) *DownloadHandler {
+	return &DownloadHandler{
+		authorizer: authorizer,
+		crud:       crud,
+		logger:     logger,
+	}
+}
+
+// ServeHTTP handles GET /duckdb/download/{token}.
+func (h *DownloadHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	requestID := auth.GetRequestIDFromContext(r.Context())
+
+	if r.Method != http.MethodGet {
+		h.crud.sendErrorWithRequest(w, r, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	token := strings.TrimPrefix(r.URL.Path, "/duckdb/download/")
+	if token == "" || strings.Contains(token, "/") {
+		h.crud.sendErrorWithRequest(w, r, "Invalid path: download token required", http.StatusBadRequest)
+		return
+	}
+
+	grant, err := h.authorizer.RedeemDownloadToken(token)
+	switch {
+	case errors.Is(err, auth.ErrDownloadTokenNotFound):
+		h.crud.sendErrorWithRequest(w, r, "Download token not found", http.StatusNotFound)
+		return
+	case errors.Is(err, auth.ErrDownloadTokenGone):
+		h.crud.sendErrorWithRequest(w, r, "Download token has expired or was already used", http.StatusGone)
+		return
+	case err != nil:
+		h.logger.Error("Failed to redeem download token", zap.Error(err), zap.String("request_id", requestID))
+		h.crud.sendErrorWithRequest(w, r, "Failed to redeem download token", http.StatusInternalServerError)
+		return
+	}
+
+	// Replay the granted read as the minting role
+	format := downloadFormats[grant.Format]
+	ctx := auth.SetContextValues(r.Context(), nil, grant.RoleName)
+	read := r.Clone(ctx)
+	read.URL.Path = "/duckdb/api/" + grant.TableName
+	read.URL.RawPath = ""
+	read.URL.RawQuery = grant.Query
+	read.Header.Set("Accept", format.accept)
+
+	h.logger.Info("Download token redeemed",
+		zap.String("table", grant.TableName),
+		zap.String("role", grant.RoleName),
+		zap.String("format", grant.Format),
+		zap.String("request_id", requestID),
+	)
+
+	filename := grant.TableName[strings.LastIndex(grant.TableName, ".")+1:]
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s.%s"`, filename, format.ext))
+	h.crud.ServeHTTP(w, read)
+}
@@ -0,0 +1,102 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+func TestDownloadToken_MintAndRedeem(t *testing.T) {
+	handler, _, cleanup := setupTestHandler(t)
+	defer cleanup()
+	if err := handler.authorizer.InitDownloadTokens(); err != nil {
+		t.Fatalf("InitDownloadTokens failed: %v", err)
+	}
+	handler.SetDownloadTokenTTL(time.Minute)
+	downloads := NewDownloadHandler(handler.authorizer, handler, zap.NewNop())
+
+	// Mint a token for a filtered, sorted CSV export
+	req := httptest.NewRequest("POST", "/duckdb/api/test_users/download-token?filter=age:gt:26&sort=id:asc", bytes.NewBufferString(`{"format": "csv"}`))
+	req = addAuthContext(req, "reader")
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("Expected status 201, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var minted struct {
+		Token     string `json:"token"`
+		URL       string `json:"url"`
+		ExpiresAt string `json:"expires_at"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &minted); err != nil {
+		t.Fatalf("Failed to parse response: %v", err)
+	}
+	if minted.Token == "" || minted.URL != "/duckdb/download/"+minted.Token || minted.ExpiresAt == "" {
+		t.Fatalf("Unexpected mint response: %s", rec.Body.String())
+	}
+
+	// Redeem without an API key
+	redeem := func() *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		downloads.ServeHTTP(rec, httptest.NewRequest("GET", minted.URL, nil))
+		return rec
+	}
+	rec = redeem()
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if !strings.HasPrefix(rec.Header().Get("Content-Type"), "text/csv") {
+		t.Errorf("Expected CSV download, got %s", rec.Header().Get("Content-Type"))
+	}
+	if got := rec.Header().Get("Content-Disposition"); got != `attachment; filename="test_users.csv"` {
+		t.Errorf("Unexpected Content-Disposition: %s", got)
+	}
+	body := rec.Body.String()
+	if !strings.Contains(body, "Alice") || !strings.Contains(body, "Charlie") || strings.Contains(body, "Bob") {
+		t.Errorf("Expected the filtered rows in the download, got %s", body)
+	}
+
+	// Tokens are single-use
+	if rec := redeem(); rec.Code != http.StatusGone {
+		t.Errorf("Expected status 410 on second redemption, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	rec = httptest.NewRecorder()
+	downloads.ServeHTTP(rec, httptest.NewRequest("GET", "/duckdb/download/not-a-token", nil))
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("Expected status 404 for unknown token, got %d", rec.Code)
+	}
+}
+
+func TestDownloadToken_Mint_Errors(t *testing.T) {
+	handler, _, cleanup := setupTestHandler(t)
+	defer cleanup()
+
+	mint := func(body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("POST", "/duckdb/api/test_users/download-token", bytes.NewBufferString(body))
+		req = addAuthContext(req, "reader")
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec
+	}
+
+	// Disabled by default
+	if rec := mint(""); rec.Code != http.StatusNotFound {
+		t.Errorf("Expected status 404 while disabled, got %d", rec.Code)
+	}
+
+	handler.authorizer.InitDownloadTokens()
+	handler.SetDownloadTokenTTL(time.Minute)
+	if rec := mint(""); rec.Code != http.StatusCreated {
+		t.Errorf("Expected status 201 without a body, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if rec := mint(`{"format": "xml"}`); rec.Code != http.StatusBadRequest || !strings.Contains(rec.Body.String(), "arrow, csv, json, ndjson, parquet") {
+		t.Errorf("Expected status 400 listing the formats for unknown format, got %d: %s", rec.Code, rec.Body.String())
+	}
+}
@@ -0,0 +1,219 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/tobilg/caddy-duckdb-module/auth"
+	"github.com/tobilg/caddy-duckdb-module/database"
+)
+
+// Error detail levels control how much of a failure is described in error
+// responses. Handlers log the underlying error with the request ID at every level.
+const (
+	// ErrorDetailFull includes database errors in responses (the default, for development).
+	ErrorDetailFull = "full"
+	// ErrorDetailSafe replaces database errors with a generic message and the request ID.
+	// Messages about invalid requests (bad filters, unknown columns) are kept.
+	ErrorDetailSafe = "safe"
+	// ErrorDetailMinimal responds with the status text and the request ID only.
+	ErrorDetailMinimal = "minimal"
+)
+
+// IsValidErrorDetail reports whether level is a supported error detail level.
+// An empty level means ErrorDetailFull.
+func IsValidErrorDetail(level string) bool {
+	switch level {
+	case "", ErrorDetailFull, ErrorDetailSafe, ErrorDetailMinimal:
+		return true
+	}
+	return false
+}
+
+// writeError writes a JSON error response at the given detail level. message
+// describes the failure to the client; err, if not nil, is the underlying
+// (database) error, which is appended to the message only at ErrorDetailFull.
+// The body includes the request ID at every level so that the logged error can
+// be found. A database.ErrDatabaseBusy error becomes 503 with a Retry-After
+// header regardless of statusCode.
+func writeError(w http.ResponseWriter, r *http.Request, level, message string, err error, statusCode int) {
+	if errors.Is(err, database.ErrDatabaseBusy) {
+		message = "Database busy"
+		statusCode = http.StatusServiceUnavailable
+		w.Header().Set("Retry-After", "1")
+	}
+	response := map[string]interface{}{
+		"error": http.StatusText(statusCode),
+		"code":  statusCode,
+	}
+	switch level {
+	case ErrorDetailSafe:
+		response["message"] = message
+	case ErrorDetailMinimal:
+		response["message"] = http.StatusText(statusCode)
+	default:
+		if err != nil {
+			message += ": " + err.Error()
+		}
+		response["message"] = message
+	}
+	if r != nil {
+		response["request_id"] = auth.GetRequestIDFromContext(r.Context())
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+	json.NewEncoder(w).Encode(response)
+}
+
+// ErrorCategory classifies a DuckDB error by the type prefix of its message
+// (e.g. "Parser Error: ...").
+type ErrorCategory string
+
+const (
+	// ErrorCategorySyntax covers malformed SQL (Parser Error, Syntax Error).
+	ErrorCategorySyntax ErrorCategory = "syntax"
+	// ErrorCategoryCatalog covers references to missing tables, functions, etc.
+	ErrorCategoryCatalog ErrorCategory = "catalog"
+	// ErrorCategoryBinder covers missing columns and type mismatches during binding.
+	ErrorCategoryBinder ErrorCategory = "binder"
+	// ErrorCategoryConstraint covers primary key, unique, not null and check violations.
+	ErrorCategoryConstraint ErrorCategory = "constraint"
+	// ErrorCategoryConversion covers invalid casts and out-of-range values.
+	ErrorCategoryConversion ErrorCategory = "conversion"
+	// ErrorCategoryOther covers everything else.
+	ErrorCategoryOther ErrorCategory = "other"
+)
+
+// errorCategoryPrefixes maps DuckDB error type prefixes to categories.
+var errorCategoryPrefixes = map[string]ErrorCategory{
+	"Parser Error":        ErrorCategorySyntax,
+	"Syntax Error":        ErrorCategorySyntax,
+	"Catalog Error":       ErrorCategoryCatalog,
+	"Binder Error":        ErrorCategoryBinder,
+	"Constraint Error":    ErrorCategoryConstraint,
+	"Conversion Error":    ErrorCategoryConversion,
+	"Invalid Input Error": ErrorCategoryConversion,
+	"Out of Range Error":  ErrorCategoryConversion,
+}
+
+// CategorizeError classifies a DuckDB error. Errors wrapped by the database
+// layer (e.g. "failed to execute insert: ...") are classified by the error
+// they wrap.
+func CategorizeError(err error) ErrorCategory {
+	if err == nil {
+		return ErrorCategoryOther
+	}
+	for inner := errors.Unwrap(err); inner != nil; inner = errors.Unwrap(inner) {
+		err = inner
+	}
+	msg := err.Error()
+	if idx := strings.Index(msg, ": "); idx != -1 {
+		if category, ok := errorCategoryPrefixes[msg[:idx]]; ok {
+			return category
+		}
+	}
+	return ErrorCategoryOther
+}
+
+// QueryErrorDetail holds structured information parsed from a DuckDB error message.
+type QueryErrorDetail struct {
+	// Message is the first line of the error without its type prefix.
+	Message string
+	// Position is the 1-based character offset of the error in the query (0 if unknown).
+	Position int
+	// Line and Column locate the error in the query (1-based, 0 if unknown).
+	Line   int
+	Column int
+	// Hint holds suggestions such as `Did you mean "users"?` (empty if none).
+	Hint string
+}
+
+// ParseQueryError extracts the message, position and hint from a DuckDB error.
+// DuckDB reports the error location as a context line followed by a caret:
+//
+//	Parser Error: syntax error at or near "FORM"
+//
+//	LINE 1: SELECT * FORM users
+//	                 ^
+//
+// Any other trailing lines (e.g. "Did you mean ...?") are returned as the hint.
+func ParseQueryError(err error, query string) QueryErrorDetail {
+	lines := strings.Split(err.Error(), "\n")
+
+	detail := QueryErrorDetail{Message: lines[0]}
+	if idx := strings.Index(detail.Message, ": "); idx != -1 {
+		if _, ok := errorCategoryPrefixes[detail.Message[:idx]]; ok {
+			detail.Message = detail.Message[idx+2:]
+		}
+	}
+
+	var hints []string
+	for i := 1; i < len(lines); i++ {
+		line := lines[i]
+		if strings.HasPrefix(line, "LINE ") {
+			if i+1 < len(lines) {
+				detail.Line, detail.Column = parseErrorLocation(line, lines[i+1], query)
+				i++
+			}
+			continue
+		}
+		if trimmed := strings.TrimSpace(line); trimmed != "" {
+			hints = append(hints, trimmed)
+		}
+	}
+	detail.Hint = strings.Join(hints, " ")
+
+	if detail.Line > 0 && detail.Column > 0 {
+		queryLines := strings.Split(query, "\n")
+		if detail.Line <= len(queryLines) {
+			offset := 0
+			for _, l := range queryLines[:detail.Line-1] {
+				offset += len([]rune(l)) + 1
+			}
+			detail.Position = offset + detail.Column
+		}
+	}
+
+	return detail
+}
+
+// parseErrorLocation computes the 1-based line and column from a DuckDB
+// "LINE n: ..." context line and the caret line beneath it.
+func parseErrorLocation(contextLine, caretLine, query string) (int, int) {
+	colon := strings.Index(contextLine, ": ")
+	if colon == -1 {
+		return 0, 0
+	}
+	lineNo, err := strconv.Atoi(strings.TrimPrefix(contextLine[:colon], "LINE "))
+	if err != nil || lineNo < 1 {
+		return 0, 0
+	}
+
+	caret := strings.Index(caretLine, "^")
+	prefixLen := colon + 2
+	if caret < prefixLen {
+		return lineNo, 0
+	}
+	column := caret - prefixLen + 1
+
+	// Long lines are shown as a window starting with "..."; locate it in the query
+	snippet := contextLine[prefixLen:]
+	if strings.HasPrefix(snippet, "...") {
+		queryLines := strings.Split(query, "\n")
+		if lineNo > len(queryLines) {
+			return lineNo, 0
+		}
+		visible := strings.TrimSuffix(strings.TrimPrefix(snippet, "..."), "...")
+		start := strings.Index(queryLines[lineNo-1], visible)
+		if start == -1 {
+			return lineNo, 0
+		}
+		column = start + column - len("...")
+	}
+
+	return lineNo, column
+}
@@ -0,0 +1,287 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/tobilg/caddy-duckdb-module/auth"
+	"github.com/tobilg/caddy-duckdb-module/database"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestCategorizeError(t *testing.T) {
+	tests := []struct {
+		msg  string
+		want ErrorCategory
+	}{
+		{`Parser Error: syntax error at or near "FORM"`, ErrorCategorySyntax},
+		{`Catalog Error: Table with name usrs does not exist!`, ErrorCategoryCatalog},
+		{`Binder Error: Referenced column "nam" not found in FROM clause!`, ErrorCategoryBinder},
+		{`Constraint Error: Duplicate key "id: 1" violates primary key constraint.`, ErrorCategoryConstraint},
+		{`Conversion Error: Could not convert string 'abc' to INT32`, ErrorCategoryConversion},
+		{`IO Error: No files found`, ErrorCategoryOther},
+		{`something went wrong`, ErrorCategoryOther},
+	}
+
+	for _, tt := range tests {
+		if got := CategorizeError(errors.New(tt.msg)); got != tt.want {
+			t.Errorf("CategorizeError(%q) = %s, want %s", tt.msg, got, tt.want)
+		}
+	}
+	wrapped := fmt.Errorf("failed to execute insert: %w", errors.New(`Constraint Error: Duplicate key "id: 1" violates primary key constraint.`))
+	if got := CategorizeError(wrapped); got != ErrorCategoryConstraint {
+		t.Errorf("CategorizeError(wrapped) = %s, want %s", got, ErrorCategoryConstraint)
+	}
+	if got := CategorizeError(nil); got != ErrorCategoryOther {
+		t.Errorf("CategorizeError(nil) = %s, want %s", got, ErrorCategoryOther)
+	}
+}
+
+func TestParseQueryError(t *testing.T) {
+	t.Run("syntax error with position", func(t *testing.T) {
+		query := "SELECT * FORM users"
+		err := errors.New("Parser Error: syntax error at or near \"FORM\"\n\nLINE 1: SELECT * FORM users\n                 ^")
+		detail := ParseQueryError(err, query)
+
+		if detail.Message != `syntax error at or near "FORM"` {
+			t.Errorf("Unexpected message: %q", detail.Message)
+		}
+		if detail.Line != 1 || detail.Column != 10 || detail.Position != 10 {
+			t.Errorf("Expected line 1, column 10, position 10; got %d, %d, %d", detail.Line, detail.Column, detail.Position)
+		}
+		if detail.Hint != "" {
+			t.Errorf("Expected no hint, got %q", detail.Hint)
+		}
+	})
+
+	t.Run("hint and multi-line query", func(t *testing.T) {
+		query := "SELECT *\nFROM usrs"
+		err := errors.New("Catalog Error: Table with name usrs does not exist!\nDid you mean \"users\"?\n\nLINE 2: FROM usrs\n             ^")
+		detail := ParseQueryError(err, query)
+
+		if detail.Hint != `Did you mean "users"?` {
+			t.Errorf("Unexpected hint: %q", detail.Hint)
+		}
+		if detail.Line != 2 || detail.Column != 6 || detail.Position != 15 {
+			t.Errorf("Expected line 2, column 6, position 15; got %d, %d, %d", detail.Line, detail.Column, detail.Position)
+		}
+	})
+
+	t.Run("truncated context line", func(t *testing.T) {
+		query := "SELECT aaaaaaaaaa, bbbbbbbbbb, cccccccccc FORM t"
+		err := errors.New("Parser Error: syntax error at or near \"FORM\"\n\nLINE 1: ...bbbbbbbbb, cccccccccc FORM t\n                                 ^")
+		detail := ParseQueryError(err, query)
+
+		if detail.Column != 43 || detail.Position != 43 {
+			t.Errorf("Expected column 43 and position 43, got %d and %d", detail.Column, detail.Position)
+		}
+	})
+
+	t.Run("no location", func(t *testing.T) {
+		detail := ParseQueryError(errors.New("IO Error: disk full"), "SELECT 1")
+		if detail.Message != "IO Error: disk full" || detail.Position != 0 {
+			t.Errorf("Unexpected detail: %+v", detail)
+		}
+	})
+}
+
+func TestCRUDHandler_ErrorDetail(t *testing.T) {
+	handler, _, cleanup := setupTestHandler(t)
+	defer cleanup()
+
+	// Inserting an existing primary key fails with a DuckDB constraint error
+	insertDuplicate := func(level string) (map[string]interface{}, *observer.ObservedLogs) {
+		core, logs := observer.New(zapcore.ErrorLevel)
+		handler.logger = zap.New(core)
+		handler.SetErrorDetail(level)
+
+		req := httptest.NewRequest("POST", "/duckdb/api/test_users", bytes.NewBufferString(`{"id": 1, "name": "Dup"}`))
+		req = addAuthContext(req, "admin")
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		if rec.Code != http.StatusInternalServerError {
+			t.Fatalf("Expected status 500, got %d: %s", rec.Code, rec.Body.String())
+		}
+		var body map[string]interface{}
+		if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+			t.Fatalf("Failed to parse response: %v", err)
+		}
+		return body, logs
+	}
+
+	body, _ := insertDuplicate(ErrorDetailFull)
+	if msg, _ := body["message"].(string); !strings.Contains(msg, "Constraint Error") {
+		t.Errorf("Expected database error in message at full detail, got %q", msg)
+	}
+	if body["request_id"] != "test-request-id" {
+		t.Errorf("Expected request_id in the body at full detail, got %v", body["request_id"])
+	}
+
+	body, logs := insertDuplicate(ErrorDetailSafe)
+	if body["message"] != "Failed to insert data" {
+		t.Errorf("Expected generic message at safe detail, got %q", body["message"])
+	}
+	if body["request_id"] != "test-request-id" {
+		t.Errorf("Expected request_id in the body, got %v", body["request_id"])
+	}
+	entries := logs.FilterField(zap.String("request_id", "test-request-id")).All()
+	if len(entries) == 0 {
+		t.Fatal("Expected the failure to be logged with the request ID")
+	}
+	if logged := entries[0].ContextMap()["error"]; !strings.Contains(logged.(string), "Constraint Error") {
+		t.Errorf("Expected database error in the log, got %v", logged)
+	}
+
+	body, _ = insertDuplicate(ErrorDetailMinimal)
+	if body["message"] != "Internal Server Error" || body["request_id"] != "test-request-id" {
+		t.Errorf("Expected status text and request_id at minimal detail, got %v", body)
+	}
+
+	// Messages about invalid requests are kept at safe detail
+	handler.SetErrorDetail(ErrorDetailSafe)
+	req := httptest.NewRequest("GET", "/duckdb/api/test_users?sort=age:sideways", nil)
+	req = addAuthContext(req, "admin")
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	if rec.Code != http.StatusBadRequest || !strings.Contains(rec.Body.String(), "invalid sort direction") {
+		t.Errorf("Expected sort validation message at safe detail, got %d: %s", rec.Code, rec.Body.String())
+	}
+}
+
+func TestCRUDHandler_RequestIDInBody(t *testing.T) {
+	handler, _, cleanup := setupTestHandler(t)
+	defer cleanup()
+
+	tests := []struct {
+		name       string
+		method     string
+		target     string
+		body       string
+		wantStatus int
+	}{
+		{"error", "GET", "/duckdb/api/test_users?filter=age:sideways:1", "", http.StatusBadRequest},
+		{"create", "POST", "/duckdb/api/test_users", `{"id": 9, "name": "Dana"}`, http.StatusCreated},
+		{"update", "PUT", "/duckdb/api/test_users", `{"where": [{"column": "id", "op": "eq", "value": 9}], "set": {"age": 40}}`, http.StatusOK},
+		{"dry run", "DELETE", "/duckdb/api/test_users?where=id:eq:9&dry_run=true", "", http.StatusOK},
+		{"bulk", "PUT", "/duckdb/api/test_users/bulk", `[{"where": {"id": 9}, "set": {"age": 41}}]`, http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.target, bytes.NewBufferString(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			req = addAuthContext(req, "admin")
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("Expected status %d, got %d: %s", tt.wantStatus, rec.Code, rec.Body.String())
+			}
+			var body map[string]interface{}
+			if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+				t.Fatalf("Failed to parse response: %v", err)
+			}
+			if body["request_id"] != "test-request-id" {
+				t.Errorf("Expected request_id in the body, got %v", body["request_id"])
+			}
+		})
+	}
+}
+
+func TestQueryHandler_ErrorDetail(t *testing.T) {
+	handler, _, cleanup := setupQueryHandler(t)
+	defer cleanup()
+	handler.SetErrorDetail(ErrorDetailSafe)
+
+	req := httptest.NewRequest("POST", "/duckdb/query", bytes.NewBufferString(`{"sql": "SELECT * FORM secret_table"}`))
+	req = addQueryAuthContext(req, "admin")
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("Expected status 400, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var body map[string]interface{}
+	if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+		t.Fatalf("Failed to parse response: %v", err)
+	}
+	if body["message"] != "Query execution failed" {
+		t.Errorf("Expected generic message, got %q", body["message"])
+	}
+	if strings.Contains(rec.Body.String(), "secret_table") {
+		t.Errorf("Expected query fragments to be withheld, got %s", rec.Body.String())
+	}
+	if _, ok := body["position"]; ok {
+		t.Error("Expected no error position at safe detail")
+	}
+}
+
+func TestCRUDHandler_DatabaseBusy(t *testing.T) {
+	mgr, err := database.NewManagerForTesting(database.Config{
+		MainDBPath:     ":memory:",
+		AuthDBPath:     ":memory:",
+		Threads:        1,
+		AccessMode:     "read_write",
+		QueryTimeout:   30 * time.Second,
+		AcquireTimeout: 50 * time.Millisecond,
+		Logger:         zap.NewNop(),
+	})
+	if err != nil {
+		t.Fatalf("Failed to create manager: %v", err)
+	}
+	defer mgr.Close()
+	if _, err := mgr.ExecMain(`CREATE TABLE test_users (id INTEGER PRIMARY KEY, name VARCHAR)`); err != nil {
+		t.Fatalf("Failed to create test table: %v", err)
+	}
+	handler := NewCRUDHandler(mgr, auth.NewAuthorizer(mgr.AuthDB()), 100, 10000, zap.NewNop())
+
+	serve := func(method, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, "/duckdb/api/test_users", bytes.NewBufferString(body))
+		req.Header.Set("Content-Type", "application/json")
+		req = addAuthContext(req, "admin")
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec
+	}
+
+	if rec := serve("GET", ""); rec.Code != http.StatusOK {
+		t.Fatalf("Expected status 200 before exhausting the pool, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	// Hold the only connection of the main pool
+	mgr.MainDB().SetMaxOpenConns(1)
+	held, err := mgr.MainDB().Conn(context.Background())
+	if err != nil {
+		t.Fatalf("Failed to take connection: %v", err)
+	}
+	defer held.Close()
+
+	for _, method := range []string{"GET", "POST"} {
+		start := time.Now()
+		rec := serve(method, `{"id": 1, "name": "Alice"}`)
+		if elapsed := time.Since(start); elapsed > 5*time.Second {
+			t.Errorf("%s: expected a fast failure, took %v", method, elapsed)
+		}
+		if rec.Code != http.StatusServiceUnavailable {
+			t.Fatalf("%s: expected status 503, got %d: %s", method, rec.Code, rec.Body.String())
+		}
+		if got := rec.Header().Get("Retry-After"); got != "1" {
+			t.Errorf("%s: expected Retry-After 1, got %q", method, got)
+		}
+		var body map[string]interface{}
+		if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+			t.Fatalf("Failed to parse response: %v", err)
+		}
+		if msg, _ := body["message"].(string); !strings.HasPrefix(msg, "Database busy") {
+			t.Errorf("%s: expected busy message, got %q", method, msg)
+		}
+	}
+}
@@ -0,0 +1,29 @@
+package handlers
+
+import (
+	"context"
+
+	"github.com/tobilg/caddy-duckdb-module/database"
+)
+
+// wktProjection returns the columns to select for a CSV read, with the table's
+// geometry columns converted to WKT text so GIS tools can import them. Without
+// geometry columns the selected columns are returned unchanged; with them, no
+// columns expands to the table's columns followed by its derived columns.
+func (h *CRUDHandler) wktProjection(ctx context.Context, tableName string, columns []string, derived []database.DerivedColumn) ([]string, error) {
+	geometry, err := h.dbMgr.GeometryColumns(ctx, tableName)
+	if err != nil || len(geometry) == 0 {
+		return columns, err
+	}
+	if len(columns) == 0 {
+		tableColumns, err := h.dbMgr.TableColumns(tableName)
+		if err != nil {
+			return nil, err
+		}
+		columns = append(columns, tableColumns...)
+		for _, d := range derived {
+			columns = append(columns, d.Name)
+		}
+	}
+	return database.WKTProjection(columns, geometry), nil
+}
@@ -0,0 +1,58 @@
+package handlers
+
+import (
+	"encoding/csv"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestCRUDHandler_Read_CSVGeometryAsWKT(t *testing.T) {
+	handler, mgr, cleanup := setupTestHandler(t)
+	defer cleanup()
+
+	if _, err := mgr.ExecMain("INSTALL spatial; LOAD spatial"); err != nil {
+		t.Skipf("spatial extension not available: %v", err)
+	}
+	if _, err := mgr.ExecMain(`
+		CREATE TABLE places (id INTEGER, name VARCHAR, location GEOMETRY);
+		INSERT INTO places VALUES (1, 'Origin', ST_Point(0, 0)), (2, 'Corner', ST_Point(1.5, 2));
+	`); err != nil {
+		t.Fatalf("Failed to create table: %v", err)
+	}
+
+	read := func(target string) [][]string {
+		t.Helper()
+		req := httptest.NewRequest("GET", target, nil)
+		req.Header.Set("Accept", "text/csv")
+		req = addAuthContext(req, "admin")
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d: %s", rec.Code, rec.Body.String())
+		}
+		records, err := csv.NewReader(strings.NewReader(rec.Body.String())).ReadAll()
+		if err != nil {
+			t.Fatalf("Failed to parse CSV: %v", err)
+		}
+		return records
+	}
+
+	records := read("/duckdb/api/places?sort=id:asc")
+	if got := strings.Join(records[0], ","); got != "id,name,location" {
+		t.Errorf("Expected header id,name,location, got %s", got)
+	}
+	if len(records) != 3 || records[1][2] != "POINT (0 0)" || records[2][2] != "POINT (1.5 2)" {
+		t.Errorf("Expected WKT locations, got %v", records)
+	}
+
+	// Selected columns keep their order
+	records = read("/duckdb/api/places?select=location,id&sort=id:asc")
+	if got := strings.Join(records[0], ","); got != "location,id" {
+		t.Errorf("Expected header location,id, got %s", got)
+	}
+	if records[2][0] != "POINT (1.5 2)" {
+		t.Errorf("Expected WKT location, got %v", records[2])
+	}
+}
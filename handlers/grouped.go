@@ -0,0 +1,155 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/tobilg/caddy-duckdb-module/auth"
+	"github.com/tobilg/caddy-duckdb-module/database"
+	"github.com/tobilg/caddy-duckdb-module/formats"
+	"go.uber.org/zap"
+)
+
+// checkGroupable checks that the table allows grouping by the columns. Grouping
+// by a column exposes its values like a filter would, so the table's filterable
+// allowlist applies.
+func (h *CRUDHandler) checkGroupable(tableName string, columns []string) error {
+	cfg := h.tables[tableName]
+	for _, col := range columns {
+		if !cfg.IsFilterable(col) {
+			return fmt.Errorf("column '%s' cannot be used to group table '%s'", col, tableName)
+		}
+	}
+	return nil
+}
+
+// unknownGroupingColumns returns the group and aggregate columns that are neither
+// in the table nor one of its derived columns.
+func (h *CRUDHandler) unknownGroupingColumns(tableName string, groupBy []string, aggregates []database.Aggregate) ([]string, error) {
+	cfg := h.tables[tableName]
+	columns := append([]string{}, groupBy...)
+	for _, a := range aggregates {
+		if a.Column != "*" {
+			columns = append(columns, a.Column)
+		}
+	}
+	var physical []string
+	for _, col := range columns {
+		if !cfg.IsDerived(col) {
+			physical = append(physical, col)
+		}
+	}
+	if len(physical) == 0 {
+		return nil, nil
+	}
+	return h.dbMgr.UnknownColumns(tableName, physical)
+}
+
+// handleGroupedRead returns aggregates per group instead of rows, e.g.
+// GET /duckdb/api/sales?group_by=region&agg=sum:amount,count:*
+// Requires READ permission only, unlike the equivalent raw SQL query. Rows can be
+// narrowed with the usual filter parameter; the number of returned groups is
+// capped by absolute_max_rows.
+func (h *CRUDHandler) handleGroupedRead(w http.ResponseWriter, r *http.Request, tableName string) {
+	requestID := auth.GetRequestIDFromContext(r.Context())
+
+	// Check authorization
+	role := auth.GetRoleFromContext(r.Context())
+	stopAuth := ServerTimingFromContext(r.Context()).Start(TimingAuth)
+	allowed, err := h.authorizer.CheckPermission(role, tableName, auth.OperationRead)
+	stopAuth()
+	if err != nil {
+		h.logger.Error("Failed to check permission", zap.Error(err), zap.String("request_id", requestID))
+		h.sendErrorWithRequest(w, r, "Failed to check permission", http.StatusInternalServerError)
+		return
+	}
+	if !allowed {
+		h.sendErrorWithRequest(w, r, "Forbidden: insufficient permissions for READ operation", http.StatusForbidden)
+		return
+	}
+
+	grouped, err := ParseGroupedRead(r)
+	if err != nil {
+		h.sendErrorWithRequest(w, r, fmt.Sprintf("Invalid aggregation: %s", err.Error()), http.StatusBadRequest)
+		return
+	}
+
+	filters, err := ParseFilters(r)
+	if err != nil {
+		h.sendErrorWithRequest(w, r, fmt.Sprintf("Invalid filters: %s", err.Error()), http.StatusBadRequest)
+		return
+	}
+	for _, f := range filters {
+		if err := SanitizeColumnName(f.Column); err != nil {
+			h.sendErrorWithRequest(w, r, fmt.Sprintf("Invalid filter column '%s': %s", f.Column, err.Error()), http.StatusBadRequest)
+			return
+		}
+	}
+	if err := h.checkFilterable(tableName, filters); err != nil {
+		h.sendErrorWithRequest(w, r, fmt.Sprintf("Invalid filter: %s", err.Error()), http.StatusBadRequest)
+		return
+	}
+	if !h.rejectUnknownColumns(w, r, tableName, h.tables[tableName].DerivedColumns(), "filter", filterColumns(filters)) {
+		return
+	}
+
+	denied, ok := h.deniedColumns(w, r, tableName)
+	if !ok || !h.rejectDeniedColumns(w, r, denied, filterColumns(filters), grouped.GroupBy, aggregateColumns(grouped.Aggregates)) {
+		return
+	}
+
+	if err := h.checkGroupable(tableName, grouped.GroupBy); err != nil {
+		h.sendErrorWithRequest(w, r, fmt.Sprintf("Invalid aggregation: %s", err.Error()), http.StatusBadRequest)
+		return
+	}
+
+	// Reject unknown columns up front instead of surfacing a binder error
+	if unknown, err := h.unknownGroupingColumns(tableName, grouped.GroupBy, grouped.Aggregates); err != nil {
+		h.logger.Error("Failed to check columns", zap.Error(err), zap.String("table", tableName), zap.String("request_id", requestID))
+		h.sendDetailedErrorWithRequest(w, r, "Failed to compute aggregates", err, http.StatusInternalServerError)
+		return
+	} else if len(unknown) > 0 {
+		h.sendErrorWithRequest(w, r, fmt.Sprintf("Invalid aggregation: unknown column(s) '%s'", strings.Join(unknown, "', '")), http.StatusBadRequest)
+		return
+	}
+
+	// Hide soft-deleted rows
+	if col := h.readSoftDeleteColumn(r, tableName); col != "" {
+		filters = append(filters, database.Filter{Column: col, Operator: "is_null"})
+	}
+
+	stopDB := ServerTimingFromContext(r.Context()).Start(TimingDB)
+	rows, err := h.dbMgr.GroupedContext(r.Context(), tableName, h.tables[tableName].DerivedColumns(), filters, grouped, h.absoluteMaxRows)
+	if err != nil {
+		stopDB()
+		// Usually a type error, e.g. summing a VARCHAR column
+		h.logger.Warn("Failed to compute aggregates", zap.Error(err), zap.String("table", tableName), zap.String("request_id", requestID))
+		h.sendDetailedErrorWithRequest(w, r, "Failed to compute aggregates", err, http.StatusBadRequest)
+		return
+	}
+	defer rows.Close()
+
+	_, data, _, err := formats.ScanRows(rows, 0)
+	stopDB()
+	if err != nil {
+		h.logger.Error("Failed to read aggregates", zap.Error(err), zap.String("request_id", requestID))
+		h.sendDetailedErrorWithRequest(w, r, "Failed to compute aggregates", err, http.StatusBadRequest)
+		return
+	}
+	formats.ConvertDecimals(data, h.decimalAsNumber)
+
+	groupBy := grouped.GroupBy
+	if groupBy == nil {
+		groupBy = []string{}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"table":    tableName,
+		"group_by": groupBy,
+		"groups":   len(data),
+		"data":     data,
+	})
+}
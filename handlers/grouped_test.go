@@ -0,0 +1,126 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// setupSalesTable creates a small sales table with three regions.
+func setupSalesTable(t *testing.T) (*CRUDHandler, func()) {
+	t.Helper()
+	handler, mgr, cleanup := setupTestHandler(t)
+	if _, err := mgr.ExecMain(`CREATE TABLE sales (region VARCHAR, product VARCHAR, amount DOUBLE)`); err != nil {
+		t.Fatalf("Failed to create table: %v", err)
+	}
+	if _, err := mgr.ExecMain(`
+		INSERT INTO sales VALUES
+			('west', 'a', 10), ('west', 'b', 30), ('east', 'a', 5),
+			('east', 'a', 15), ('north', 'b', 1)
+	`); err != nil {
+		t.Fatalf("Failed to insert rows: %v", err)
+	}
+	return handler, cleanup
+}
+
+func TestCRUDHandler_GroupedRead(t *testing.T) {
+	handler, cleanup := setupSalesTable(t)
+	defer cleanup()
+
+	get := func(query string) []map[string]interface{} {
+		t.Helper()
+		req := httptest.NewRequest("GET", "/duckdb/api/sales?"+query, nil)
+		req = addAuthContext(req, "reader")
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("Expected status 200 for %s, got %d: %s", query, rec.Code, rec.Body.String())
+		}
+		var result struct {
+			Table   string                   `json:"table"`
+			GroupBy []string                 `json:"group_by"`
+			Groups  int                      `json:"groups"`
+			Data    []map[string]interface{} `json:"data"`
+		}
+		if err := json.Unmarshal(rec.Body.Bytes(), &result); err != nil {
+			t.Fatalf("Failed to parse response: %v", err)
+		}
+		if result.Table != "sales" || result.Groups != len(result.Data) {
+			t.Errorf("Unexpected response: %s", rec.Body.String())
+		}
+		return result.Data
+	}
+
+	// The reader role has READ but not query permission
+	data := get("group_by=region&agg=sum:amount,count:*")
+	if len(data) != 3 {
+		t.Fatalf("Expected 3 groups, got %v", data)
+	}
+	if first := data[0]; first["region"] != "east" || first["sum_amount"] != float64(20) || first["count"] != float64(2) || len(first) != 3 {
+		t.Errorf("Unexpected first group: %v", first)
+	}
+
+	// agg defaults to count:*; filters apply before grouping
+	data = get("group_by=region,product&filter=amount:gt:2")
+	if len(data) != 3 || data[0]["count"] != float64(2) {
+		t.Errorf("Expected 3 region/product groups, got %v", data)
+	}
+
+	// List aggregates return each group's values as a sorted array
+	data = get("group_by=region&agg=array_agg:amount,list:product")
+	if len(data) != 3 {
+		t.Fatalf("Expected 3 groups, got %v", data)
+	}
+	east, _ := json.Marshal(data[0])
+	if string(east) != `{"array_agg_amount":[5,15],"list_product":["a","a"],"region":"east"}` {
+		t.Errorf("Unexpected east group: %s", east)
+	}
+	west, _ := json.Marshal(data[2])
+	if string(west) != `{"array_agg_amount":[10,30],"list_product":["a","b"],"region":"west"}` {
+		t.Errorf("Unexpected west group: %s", west)
+	}
+
+	// Without group_by the aggregates cover all rows
+	data = get("agg=min:amount,max:amount")
+	if len(data) != 1 || data[0]["min_amount"] != float64(1) || data[0]["max_amount"] != float64(30) {
+		t.Errorf("Expected one row of totals, got %v", data)
+	}
+}
+
+func TestCRUDHandler_GroupedReadRejections(t *testing.T) {
+	handler, cleanup := setupSalesTable(t)
+	defer cleanup()
+	handler.SetTableConfigs(map[string]*TableConfig{"sales": {Filterable: []string{"region", "amount"}}})
+
+	tests := []struct {
+		name       string
+		query      string
+		role       string
+		wantStatus int
+	}{
+		{"unknown function", "group_by=region&agg=median:amount", "reader", http.StatusBadRequest},
+		{"star without count", "group_by=region&agg=sum:*", "reader", http.StatusBadRequest},
+		{"star in list", "group_by=region&agg=array_agg:*", "reader", http.StatusBadRequest},
+		{"unknown list column", "group_by=region&agg=array_agg:price", "reader", http.StatusBadRequest},
+		{"malformed aggregate", "group_by=region&agg=amount", "reader", http.StatusBadRequest},
+		{"invalid group column", "group_by=region%3BDROP&agg=count:*", "reader", http.StatusBadRequest},
+		{"unknown group column", "group_by=country", "reader", http.StatusBadRequest},
+		{"unknown aggregate column", "group_by=region&agg=sum:price", "reader", http.StatusBadRequest},
+		{"group by not filterable", "group_by=product", "reader", http.StatusBadRequest},
+		{"duplicate result column", "group_by=region,region", "reader", http.StatusBadRequest},
+		{"type error", "agg=sum:region", "reader", http.StatusBadRequest},
+		{"no read permission", "group_by=region", "unknown_role", http.StatusForbidden},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "/duckdb/api/sales?"+tt.query, nil)
+			req = addAuthContext(req, tt.role)
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+			if rec.Code != tt.wantStatus {
+				t.Errorf("Expected status %d, got %d: %s", tt.wantStatus, rec.Code, rec.Body.String())
+			}
+		})
+	}
+}
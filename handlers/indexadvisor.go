@@ -0,0 +1,243 @@
+package handlers
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/tobilg/caddy-duckdb-module/database"
+	"go.uber.org/zap"
+)
+
+// Defaults for IndexAdvisorConfig.
+const (
+	defaultIndexMinUses    = 100
+	defaultMaxAutoIndexes  = 10
+	maxIndexAdvisorColumns = 10000
+)
+
+// IndexAdvisorConfig configures the index advisor, which counts the columns
+// table reads filter and sort on and recommends ART indexes for frequently
+// used columns that have none.
+type IndexAdvisorConfig struct {
+	// Enabled turns on usage tracking and recommendations.
+	Enabled bool `json:"enabled,omitempty"`
+
+	// MinUses is the number of reads filtering or sorting on a column after
+	// which an index on it is recommended. Default is 100.
+	MinUses int `json:"min_uses,omitempty"`
+
+	// AutoCreate creates recommended indexes instead of only reporting them.
+	// Default is false.
+	AutoCreate bool `json:"auto_create,omitempty"`
+
+	// MaxAutoIndexes bounds the number of indexes created automatically.
+	// Default is 10.
+	MaxAutoIndexes int `json:"max_auto_indexes,omitempty"`
+}
+
+// Validate checks the thresholds.
+func (c *IndexAdvisorConfig) Validate() error {
+	if c.MinUses < 0 {
+		return fmt.Errorf("index_advisor min_uses must be >= 0")
+	}
+	if c.MaxAutoIndexes < 0 {
+		return fmt.Errorf("index_advisor max_auto_indexes must be >= 0")
+	}
+	return nil
+}
+
+// IndexRecommendation is a column that reads frequently filter or sort on and
+// that has no index.
+type IndexRecommendation struct {
+	Table      string `json:"table"`
+	Column     string `json:"column"`
+	FilterUses int64  `json:"filter_uses"`
+	SortUses   int64  `json:"sort_uses"`
+	SQL        string `json:"sql"`
+}
+
+// columnUsage counts the reads that filtered or sorted on a column.
+type columnUsage struct {
+	filters int64
+	sorts   int64
+}
+
+func (u *columnUsage) total() int64 {
+	return u.filters + u.sorts
+}
+
+// IndexAdvisor tracks filter and sort column usage of table reads.
+type IndexAdvisor struct {
+	dbMgr          *database.Manager
+	minUses        int64
+	autoCreate     bool
+	maxAutoIndexes int
+	logger         *zap.Logger
+
+	mu      sync.Mutex
+	usage   map[string]map[string]*columnUsage // table -> lowercased column
+	columns int
+	created int
+}
+
+// NewIndexAdvisor creates an index advisor. Zero thresholds in cfg are
+// replaced by their defaults.
+func NewIndexAdvisor(dbMgr *database.Manager, cfg IndexAdvisorConfig, logger *zap.Logger) *IndexAdvisor {
+	if cfg.MinUses == 0 {
+		cfg.MinUses = defaultIndexMinUses
+	}
+	if cfg.MaxAutoIndexes == 0 {
+		cfg.MaxAutoIndexes = defaultMaxAutoIndexes
+	}
+	return &IndexAdvisor{
+		dbMgr:          dbMgr,
+		minUses:        int64(cfg.MinUses),
+		autoCreate:     cfg.AutoCreate,
+		maxAutoIndexes: cfg.MaxAutoIndexes,
+		logger:         logger,
+		usage:          make(map[string]map[string]*columnUsage),
+	}
+}
+
+// SetIndexAdvisor enables tracking of the filter and sort columns of table reads.
+func (h *CRUDHandler) SetIndexAdvisor(advisor *IndexAdvisor) {
+	h.indexAdvisor = advisor
+}
+
+// Record counts one read of a table filtering and sorting on the given
+// columns. Derived columns cannot be indexed and are not counted. When a
+// column reaches the MinUses threshold, the recommendation is logged and, with
+// AutoCreate, the index is created in the background. Record does nothing on
+// a nil advisor.
+func (a *IndexAdvisor) Record(table string, filters []database.Filter, sorts []database.Sort, derived []database.DerivedColumn) {
+	if a == nil || (len(filters) == 0 && len(sorts) == 0) {
+		return
+	}
+	skip := make(map[string]bool, len(derived))
+	for _, d := range derived {
+		skip[strings.ToLower(d.Name)] = true
+	}
+
+	// Each column counts once per read, even if filtered on several times
+	filtered := make(map[string]bool, len(filters))
+	for _, f := range filters {
+		filtered[strings.ToLower(f.Column)] = true
+	}
+	sorted := make(map[string]bool, len(sorts))
+	for _, s := range sorts {
+		sorted[strings.ToLower(s.Column)] = true
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	columns := a.usage[table]
+	if columns == nil {
+		columns = make(map[string]*columnUsage)
+		a.usage[table] = columns
+	}
+	count := func(column string, filter bool) {
+		if skip[column] {
+			return
+		}
+		u := columns[column]
+		if u == nil {
+			// Bound memory if clients filter on many distinct (unknown) columns
+			if a.columns >= maxIndexAdvisorColumns {
+				return
+			}
+			u = &columnUsage{}
+			columns[column] = u
+			a.columns++
+		}
+		if filter {
+			u.filters++
+		} else {
+			u.sorts++
+		}
+		if u.total() == a.minUses {
+			a.thresholdReached(table, column)
+		}
+	}
+	for column := range filtered {
+		count(column, true)
+	}
+	for column := range sorted {
+		count(column, false)
+	}
+}
+
+// thresholdReached logs the recommendation and, if enabled and within the
+// MaxAutoIndexes bound, creates the index. Called with a.mu held.
+func (a *IndexAdvisor) thresholdReached(table, column string) {
+	a.logger.Info("Index recommended",
+		zap.String("table", table),
+		zap.String("column", column),
+		zap.Int64("uses", a.minUses),
+	)
+	if !a.autoCreate || a.created >= a.maxAutoIndexes {
+		return
+	}
+	a.created++
+	go func() {
+		indexed, err := a.dbMgr.IndexedColumns(table)
+		if err != nil {
+			a.logger.Warn("Failed to look up indexes", zap.Error(err), zap.String("table", table))
+			return
+		}
+		if indexed[column] {
+			return
+		}
+		if err := a.dbMgr.CreateIndex(table, column); err != nil {
+			a.logger.Warn("Failed to create recommended index", zap.Error(err), zap.String("table", table), zap.String("column", column))
+		}
+	}()
+}
+
+// Recommendations returns the columns that reached the MinUses threshold and
+// have no index, most used first.
+func (a *IndexAdvisor) Recommendations() ([]IndexRecommendation, error) {
+	a.mu.Lock()
+	candidates := make(map[string][]IndexRecommendation)
+	for table, columns := range a.usage {
+		for column, u := range columns {
+			if u.total() < a.minUses {
+				continue
+			}
+			candidates[table] = append(candidates[table], IndexRecommendation{
+				Table:      table,
+				Column:     column,
+				FilterUses: u.filters,
+				SortUses:   u.sorts,
+				SQL:        fmt.Sprintf("CREATE INDEX %s ON %s (%s)", database.IndexName(table, column), table, column),
+			})
+		}
+	}
+	a.mu.Unlock()
+
+	recommendations := []IndexRecommendation{}
+	for table, columns := range candidates {
+		indexed, err := a.dbMgr.IndexedColumns(table)
+		if err != nil {
+			return nil, fmt.Errorf("failed to look up indexes of table '%s': %w", table, err)
+		}
+		for _, rec := range columns {
+			if !indexed[rec.Column] {
+				recommendations = append(recommendations, rec)
+			}
+		}
+	}
+	sort.Slice(recommendations, func(i, j int) bool {
+		ti := recommendations[i].FilterUses + recommendations[i].SortUses
+		tj := recommendations[j].FilterUses + recommendations[j].SortUses
+		if ti != tj {
+			return ti > tj
+		}
+		if recommendations[i].Table != recommendations[j].Table {
+			return recommendations[i].Table < recommendations[j].Table
+		}
+		return recommendations[i].Column < recommendations[j].Column
+	})
+	return recommendations, nil
+}
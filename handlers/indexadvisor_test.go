@@ -0,0 +1,113 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+func TestIndexAdvisor_Recommendations(t *testing.T) {
+	handler, mgr, cleanup := setupTestHandler(t)
+	defer cleanup()
+	advisor := NewIndexAdvisor(mgr, IndexAdvisorConfig{Enabled: true, MinUses: 3}, zap.NewNop())
+	handler.SetIndexAdvisor(advisor)
+
+	read := func(query string) {
+		req := httptest.NewRequest("GET", "/duckdb/api/test_users"+query, nil)
+		req = addAuthContext(req, "admin")
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d: %s", rec.Code, rec.Body.String())
+		}
+	}
+
+	// email: 4 filtered reads; age: 2 filters and 1 sort; name: 2 sorts
+	read("?filter=email:eq:alice@example.com")
+	read("?filter=email:ne:x")
+	read("?filter=email:like:%25example%25,email:ne:x&sort=name:asc")
+	read("?filter=email:eq:bob@example.com,age:gt:20&sort=name:desc")
+	read("?filter=age:lt:60&sort=age:asc")
+	read("")
+
+	recommendations, err := advisor.Recommendations()
+	if err != nil {
+		t.Fatalf("Recommendations failed: %v", err)
+	}
+	if len(recommendations) != 2 {
+		t.Fatalf("Expected 2 recommendations, got %+v", recommendations)
+	}
+	email := recommendations[0]
+	if email.Table != "test_users" || email.Column != "email" || email.FilterUses != 4 || email.SortUses != 0 {
+		t.Errorf("Unexpected email recommendation: %+v", email)
+	}
+	if email.SQL != "CREATE INDEX idx_test_users_email ON test_users (email)" {
+		t.Errorf("Unexpected SQL: %s", email.SQL)
+	}
+	if age := recommendations[1]; age.Column != "age" || age.FilterUses != 2 || age.SortUses != 1 {
+		t.Errorf("Unexpected age recommendation: %+v", age)
+	}
+
+	// Indexed columns are no longer recommended
+	if err := mgr.CreateIndex("test_users", "email"); err != nil {
+		t.Fatalf("CreateIndex failed: %v", err)
+	}
+	recommendations, err = advisor.Recommendations()
+	if err != nil {
+		t.Fatalf("Recommendations failed: %v", err)
+	}
+	if len(recommendations) != 1 || recommendations[0].Column != "age" {
+		t.Errorf("Expected only age to be recommended, got %+v", recommendations)
+	}
+}
+
+func TestIndexAdvisor_AutoCreate(t *testing.T) {
+	handler, mgr, cleanup := setupTestHandler(t)
+	defer cleanup()
+	advisor := NewIndexAdvisor(mgr, IndexAdvisorConfig{Enabled: true, MinUses: 2, AutoCreate: true, MaxAutoIndexes: 1}, zap.NewNop())
+	handler.SetIndexAdvisor(advisor)
+
+	for _, query := range []string{"?filter=email:ne:x,age:gt:1", "?filter=email:ne:y,age:gt:2"} {
+		req := httptest.NewRequest("GET", "/duckdb/api/test_users"+query, nil)
+		req = addAuthContext(req, "admin")
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d: %s", rec.Code, rec.Body.String())
+		}
+	}
+
+	// Indexes are created in the background; only one is allowed
+	deadline := time.Now().Add(5 * time.Second)
+	var indexed map[string]bool
+	for time.Now().Before(deadline) {
+		var err error
+		if indexed, err = mgr.IndexedColumns("test_users"); err != nil {
+			t.Fatalf("IndexedColumns failed: %v", err)
+		}
+		if indexed["email"] || indexed["age"] {
+			break
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+	time.Sleep(100 * time.Millisecond)
+	indexed, _ = mgr.IndexedColumns("test_users")
+	if indexed["email"] == indexed["age"] {
+		t.Errorf("Expected exactly one of email and age to be indexed, got %v", indexed)
+	}
+}
+
+func TestIndexAdvisor_NilAndValidate(t *testing.T) {
+	var advisor *IndexAdvisor
+	advisor.Record("test_users", nil, nil, nil) // must not panic
+
+	if err := (&IndexAdvisorConfig{MinUses: -1}).Validate(); err == nil {
+		t.Error("Expected error for negative min_uses")
+	}
+	if err := (&IndexAdvisorConfig{MaxAutoIndexes: -1}).Validate(); err == nil {
+		t.Error("Expected error for negative max_auto_indexes")
+	}
+}
@@ -0,0 +1,89 @@
+package handlers
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+
+	"github.com/tobilg/caddy-duckdb-module/database"
+)
+
+// MetricsContentType is the content type of the Prometheus text exposition format.
+const MetricsContentType = "text/plain; version=0.0.4; charset=utf-8"
+
+// ServeMetrics writes the database metrics in the Prometheus text exposition
+// format. The caller decides whether the request is authorized.
+func ServeMetrics(w http.ResponseWriter, r *http.Request, metrics database.Metrics) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		w.Header().Set("Allow", "GET, HEAD")
+		writeError(w, r, "warn", "Method not allowed", nil, http.StatusMethodNotAllowed)
+		return
+	}
+	w.Header().Set("Content-Type", MetricsContentType)
+	w.WriteHeader(http.StatusOK)
+	if r.Method == http.MethodHead {
+		return
+	}
+	WriteMetrics(w, metrics)
+}
+
+// WriteMetrics writes metrics in the Prometheus text exposition format.
+func WriteMetrics(w io.Writer, metrics database.Metrics) {
+	pools := metrics.PoolNames()
+	poolGauge := func(name, help string, value func(string) float64) {
+		writeMetricHeader(w, name, help, "gauge")
+		for _, pool := range pools {
+			fmt.Fprintf(w, "%s{pool=%q} %s\n", name, pool, formatMetricValue(value(pool)))
+		}
+	}
+	poolCounter := func(name, help string, value func(string) float64) {
+		writeMetricHeader(w, name, help, "counter")
+		for _, pool := range pools {
+			fmt.Fprintf(w, "%s{pool=%q} %s\n", name, pool, formatMetricValue(value(pool)))
+		}
+	}
+
+	poolGauge("duckdb_pool_max_open_connections", "Maximum number of open connections of the pool.", func(p string) float64 {
+		return float64(metrics.Pools[p].MaxOpenConnections)
+	})
+	poolGauge("duckdb_pool_open_connections", "Number of established connections, in use and idle.", func(p string) float64 {
+		return float64(metrics.Pools[p].OpenConnections)
+	})
+	poolGauge("duckdb_pool_in_use_connections", "Number of connections currently in use.", func(p string) float64 {
+		return float64(metrics.Pools[p].InUse)
+	})
+	poolGauge("duckdb_pool_idle_connections", "Number of idle connections.", func(p string) float64 {
+		return float64(metrics.Pools[p].Idle)
+	})
+	poolCounter("duckdb_pool_wait_count_total", "Total number of connections waited for.", func(p string) float64 {
+		return float64(metrics.Pools[p].WaitCount)
+	})
+	poolCounter("duckdb_pool_wait_duration_seconds_total", "Total time blocked waiting for a new connection.", func(p string) float64 {
+		return metrics.Pools[p].WaitDuration.Seconds()
+	})
+
+	types := database.QueryTypes()
+	writeMetricHeader(w, "duckdb_queries_total", "Queries run on the main database by type.", "counter")
+	for _, t := range types {
+		fmt.Fprintf(w, "duckdb_queries_total{type=%q} %d\n", t, metrics.Queries[t])
+	}
+	writeMetricHeader(w, "duckdb_query_errors_total", "Queries on the main database that failed, by type.", "counter")
+	for _, t := range types {
+		fmt.Fprintf(w, "duckdb_query_errors_total{type=%q} %d\n", t, metrics.Errors[t])
+	}
+	writeMetricHeader(w, "duckdb_labeled_requests_total", "Authenticated requests by their X-Query-Label header.", "counter")
+	for _, label := range metrics.LabelNames() {
+		fmt.Fprintf(w, "duckdb_labeled_requests_total{label=%q} %d\n", label, metrics.Labels[label])
+	}
+}
+
+// writeMetricHeader writes the HELP and TYPE lines of a metric family.
+func writeMetricHeader(w io.Writer, name, help, kind string) {
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n", name, help, name, kind)
+}
+
+// formatMetricValue formats a sample value, without a fraction for whole numbers.
+func formatMetricValue(v float64) string {
+	return strconv.FormatFloat(v, 'f', -1, 64)
+}
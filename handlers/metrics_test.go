@@ -0,0 +1,90 @@
+package handlers
+
+import (
+	"database/sql"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/tobilg/caddy-duckdb-module/database"
+)
+
+func TestWriteMetrics(t *testing.T) {
+	metrics := database.Metrics{
+		Pools: map[string]sql.DBStats{
+			"main": {MaxOpenConnections: 4, OpenConnections: 3, InUse: 1, Idle: 2, WaitCount: 5, WaitDuration: 1500 * time.Millisecond},
+			"auth": {OpenConnections: 1, Idle: 1},
+		},
+		Queries: map[string]int64{database.QuerySelect: 7, database.QueryInsert: 2},
+		Errors:  map[string]int64{database.QuerySelect: 1},
+		Labels:  map[string]int64{"team-analytics": 4},
+	}
+
+	var b strings.Builder
+	WriteMetrics(&b, metrics)
+	out := b.String()
+
+	for _, line := range []string{
+		"# HELP duckdb_pool_open_connections Number of established connections, in use and idle.",
+		"# TYPE duckdb_pool_open_connections gauge",
+		`duckdb_pool_open_connections{pool="main"} 3`,
+		`duckdb_pool_open_connections{pool="auth"} 1`,
+		`duckdb_pool_in_use_connections{pool="main"} 1`,
+		`duckdb_pool_idle_connections{pool="main"} 2`,
+		`duckdb_pool_max_open_connections{pool="main"} 4`,
+		"# TYPE duckdb_pool_wait_count_total counter",
+		`duckdb_pool_wait_count_total{pool="main"} 5`,
+		`duckdb_pool_wait_duration_seconds_total{pool="main"} 1.5`,
+		"# TYPE duckdb_queries_total counter",
+		`duckdb_queries_total{type="select"} 7`,
+		`duckdb_queries_total{type="insert"} 2`,
+		`duckdb_queries_total{type="raw"} 0`,
+		`duckdb_query_errors_total{type="select"} 1`,
+		`duckdb_query_errors_total{type="delete"} 0`,
+		"# TYPE duckdb_labeled_requests_total counter",
+		`duckdb_labeled_requests_total{label="team-analytics"} 4`,
+	} {
+		if !strings.Contains(out, line+"\n") {
+			t.Errorf("Expected line %q in:\n%s", line, out)
+		}
+	}
+
+	// Every line is a comment or a sample
+	for _, line := range strings.Split(strings.TrimSuffix(out, "\n"), "\n") {
+		if strings.HasPrefix(line, "# HELP ") || strings.HasPrefix(line, "# TYPE ") {
+			continue
+		}
+		if name, _, ok := strings.Cut(line, "{"); !ok || !strings.HasPrefix(name, "duckdb_") || !strings.Contains(line, "} ") {
+			t.Errorf("Malformed sample line %q", line)
+		}
+	}
+
+	// Pools are listed in a stable order
+	if strings.Index(out, `{pool="auth"}`) > strings.Index(out, `{pool="main"}`) {
+		t.Error("Expected pools in sorted order")
+	}
+}
+
+func TestServeMetrics(t *testing.T) {
+	metrics := database.Metrics{Pools: map[string]sql.DBStats{"main": {}}}
+
+	rec := httptest.NewRecorder()
+	ServeMetrics(rec, httptest.NewRequest("GET", "/duckdb/metrics", nil), metrics)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", rec.Code)
+	}
+	if ct := rec.Header().Get("Content-Type"); ct != MetricsContentType {
+		t.Errorf("Expected content type %q, got %q", MetricsContentType, ct)
+	}
+	if !strings.Contains(rec.Body.String(), "duckdb_queries_total") {
+		t.Errorf("Expected metrics in body, got %s", rec.Body.String())
+	}
+
+	rec = httptest.NewRecorder()
+	ServeMetrics(rec, httptest.NewRequest("POST", "/duckdb/metrics", nil), metrics)
+	if rec.Code != http.StatusMethodNotAllowed {
+		t.Errorf("Expected status 405 for POST, got %d", rec.Code)
+	}
+}
@@ -3,16 +3,35 @@ package handlers
 import (
 	"encoding/json"
 	"net/http"
+	"strings"
+
+	"github.com/tobilg/caddy-duckdb-module/auth"
+	"github.com/tobilg/caddy-duckdb-module/database"
+	"gopkg.in/yaml.v3"
 )
 
+// YAMLContentType is the content type of the YAML OpenAPI specification.
+const YAMLContentType = "application/yaml"
+
 // OpenAPIHandler serves the OpenAPI specification.
-type OpenAPIHandler struct{}
+type OpenAPIHandler struct {
+	apiKeyHeader    string
+	dbMgr           *database.Manager
+	authorizer      *auth.Authorizer
+	decimalAsNumber bool
+}
 
 // NewOpenAPIHandler creates a new OpenAPI handler.
 func NewOpenAPIHandler() *OpenAPIHandler {
 	return &OpenAPIHandler{}
 }
 
+// SetAPIKeyHeader sets the request header the spec documents for the API key
+// (see auth.Middleware.SetAPIKeyHeader). Empty means auth.DefaultAPIKeyHeader.
+func (h *OpenAPIHandler) SetAPIKeyHeader(name string) {
+	h.apiKeyHeader = name
+}
+
 // ServeHTTP handles HTTP requests for the OpenAPI specification.
 func (h *OpenAPIHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodGet {
@@ -28,11 +47,72 @@ func (h *OpenAPIHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 
 	spec := h.generateOpenAPISpec()
 
+	// Describe the actual tables the authenticated role can access
+	if ParseOpenAPITables(r) {
+		if h.dbMgr == nil || h.authorizer == nil {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(map[string]interface{}{
+				"error":   "Bad Request",
+				"message": "Table paths are not available",
+				"code":    400,
+			})
+			return
+		}
+		if err := h.addTablePaths(r.Context(), spec, auth.GetRoleFromContext(r.Context())); err != nil {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusInternalServerError)
+			json.NewEncoder(w).Encode(map[string]interface{}{
+				"error":   "Internal Server Error",
+				"message": "Failed to describe tables",
+				"code":    500,
+			})
+			return
+		}
+	}
+
+	if wantsYAML(r) {
+		out, err := yaml.Marshal(spec)
+		if err != nil {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusInternalServerError)
+			json.NewEncoder(w).Encode(map[string]interface{}{
+				"error":   "Internal Server Error",
+				"message": "Failed to encode OpenAPI specification as YAML",
+				"code":    500,
+			})
+			return
+		}
+		w.Header().Set("Content-Type", YAMLContentType)
+		w.WriteHeader(http.StatusOK)
+		w.Write(out)
+		return
+	}
+
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
 	json.NewEncoder(w).Encode(spec)
 }
 
+// wantsYAML reports whether the request asks for the YAML specification: the
+// openapi.yaml path, format=yaml, or a YAML media type in the Accept header.
+func wantsYAML(r *http.Request) bool {
+	if strings.HasSuffix(r.URL.Path, ".yaml") {
+		return true
+	}
+	if format := strings.ToLower(r.URL.Query().Get("format")); format != "" {
+		return format == "yaml"
+	}
+	for _, part := range strings.Split(r.Header.Get("Accept"), ",") {
+		mediaType, _, _ := strings.Cut(part, ";")
+		switch strings.ToLower(strings.TrimSpace(mediaType)) {
+		case YAMLContentType, "application/x-yaml", "text/yaml":
+			return true
+		}
+	}
+	return false
+}
+
 // generateOpenAPISpec generates the OpenAPI 3.0 specification.
 func (h *OpenAPIHandler) generateOpenAPISpec() map[string]interface{} {
 	return map[string]interface{}{
@@ -82,8 +162,29 @@ func (h *OpenAPIHandler) generatePaths() map[string]interface{} {
 			"get": map[string]interface{}{
 				"tags":        []string{"OpenAPI"},
 				"summary":     "Get OpenAPI specification",
-				"description": "Returns the OpenAPI 3.0 specification for this API",
+				"description": "Returns the OpenAPI 3.0 specification for this API. Send Accept: application/yaml or format=yaml for YAML. With tables=true (requires an API key), adds a path and record schema for each table the key's role can access.",
 				"operationId": "getOpenAPISpec",
+				"parameters": []map[string]interface{}{
+					{
+						"name":        "tables",
+						"in":          "query",
+						"description": "Add a concrete /api/<table> path per accessible table, with its columns as the record schema. Requires an API key.",
+						"schema": map[string]interface{}{
+							"type":    "boolean",
+							"default": false,
+						},
+					},
+					{
+						"name":        "format",
+						"in":          "query",
+						"description": "Serialization of the specification",
+						"schema": map[string]interface{}{
+							"type":    "string",
+							"enum":    []string{"json", "yaml"},
+							"default": "json",
+						},
+					},
+				},
 				"responses": map[string]interface{}{
 					"200": map[string]interface{}{
 						"description": "OpenAPI specification",
@@ -93,34 +194,91 @@ func (h *OpenAPIHandler) generatePaths() map[string]interface{} {
 									"type": "object",
 								},
 							},
+							YAMLContentType: map[string]interface{}{
+								"schema": map[string]interface{}{
+									"type": "object",
+								},
+							},
 						},
 					},
 				},
 			},
 		},
-		"/api/{table}": map[string]interface{}{
-			"get":    h.generateReadOperation(),
-			"post":   h.generateCreateOperation(),
-			"put":    h.generateUpdateOperation(),
-			"delete": h.generateDeleteOperation(),
-			"parameters": []map[string]interface{}{
-				{
-					"name":        "table",
-					"in":          "path",
-					"required":    true,
-					"description": "Name of the database table",
-					"schema": map[string]interface{}{
-						"type": "string",
+		"/openapi.yaml": map[string]interface{}{
+			"get": map[string]interface{}{
+				"tags":        []string{"OpenAPI"},
+				"summary":     "Get OpenAPI specification as YAML",
+				"description": "Returns the OpenAPI 3.0 specification for this API as YAML",
+				"operationId": "getOpenAPISpecYAML",
+				"responses": map[string]interface{}{
+					"200": map[string]interface{}{
+						"description": "OpenAPI specification",
+						"content": map[string]interface{}{
+							YAMLContentType: map[string]interface{}{
+								"schema": map[string]interface{}{
+									"type": "object",
+								},
+							},
+						},
 					},
 				},
 			},
 		},
+		"/api/{table}": map[string]interface{}{
+			"get":        h.generateReadOperation(),
+			"post":       h.generateCreateOperation(),
+			"put":        h.generateUpdateOperation(),
+			"patch":      h.generateUpsertOperation(),
+			"delete":     h.generateDeleteOperation(),
+			"parameters": []map[string]interface{}{tablePathParameter(), catalogQueryParameter()},
+		},
+		"/api/{table}/bulk": map[string]interface{}{
+			"put":        h.generateBulkUpdateOperation(),
+			"parameters": []map[string]interface{}{tablePathParameter(), catalogQueryParameter()},
+		},
+		"/api/{table}/restore": map[string]interface{}{
+			"post":       h.generateRestoreOperation(),
+			"parameters": []map[string]interface{}{tablePathParameter(), catalogQueryParameter()},
+		},
+		"/api/{table}/purge": map[string]interface{}{
+			"delete":     h.generatePurgeOperation(),
+			"parameters": []map[string]interface{}{tablePathParameter(), catalogQueryParameter()},
+		},
+		"/api/{table}/changes": map[string]interface{}{
+			"get":        h.generateChangesOperation(),
+			"parameters": []map[string]interface{}{tablePathParameter(), catalogQueryParameter()},
+		},
+		"/api/{table}/timeseries": map[string]interface{}{
+			"get":        h.generateTimeSeriesOperation(),
+			"parameters": []map[string]interface{}{tablePathParameter(), catalogQueryParameter()},
+		},
+		"/api/{table}/cardinality": map[string]interface{}{
+			"get":        h.generateCardinalityOperation(),
+			"parameters": []map[string]interface{}{tablePathParameter(), catalogQueryParameter()},
+		},
+		"/api/{table}/download-token": map[string]interface{}{
+			"post":       h.generateDownloadTokenOperation(),
+			"parameters": []map[string]interface{}{tablePathParameter(), catalogQueryParameter()},
+		},
+		"/download/{token}": map[string]interface{}{
+			"get": h.generateDownloadOperation(),
+		},
+		"/transaction": map[string]interface{}{
+			"post":       h.generateTransactionOperation(),
+			"parameters": []map[string]interface{}{catalogQueryParameter()},
+		},
 		"/query": map[string]interface{}{
 			"post": h.generateQueryPostOperation(),
 		},
 		"/query/{sql}/result.{format}": map[string]interface{}{
 			"get": h.generateQueryGetOperation(),
 		},
+		"/query/batch": map[string]interface{}{
+			"post": h.generateQueryBatchOperation(),
+		},
+		"/query/sse": map[string]interface{}{
+			"get": h.generateQuerySSEOperation(),
+		},
 	}
 }
 
@@ -156,10 +314,27 @@ func (h *OpenAPIHandler) generateReadOperation() map[string]interface{} {
 					"default": 100,
 				},
 			},
+			{
+				"name":        "after",
+				"in":          "query",
+				"description": "Keyset (cursor) pagination: read rows in ascending order of this unique column, from the first row (column) or after a key (column:value). The next_cursor of the response (also in the X-Next-Cursor header) continues via the cursor parameter. Cannot be combined with page or sort.",
+				"schema": map[string]interface{}{
+					"type": "string",
+				},
+				"example": "id",
+			},
+			{
+				"name":        "cursor",
+				"in":          "query",
+				"description": "Opaque next_cursor of the previous keyset page",
+				"schema": map[string]interface{}{
+					"type": "string",
+				},
+			},
 			{
 				"name":        "filter",
 				"in":          "query",
-				"description": "Filter conditions in format: column:operator:value (comma-separated for multiple). Operators: eq, ne, gt, gte, lt, lte, like, in",
+				"description": "Filter conditions in format: column:operator:value (comma-separated for multiple). Operators: eq, ne, gt, gte, lt, lte, like, notlike, in, not_in, between, is_null, is_not_null (the null operators take no value, e.g. deleted_at:is_null)",
 				"schema": map[string]interface{}{
 					"type": "string",
 				},
@@ -183,6 +358,147 @@ func (h *OpenAPIHandler) generateReadOperation() map[string]interface{} {
 					"default": false,
 				},
 			},
+			{
+				"name":        "key_by",
+				"in":          "query",
+				"description": "Return data as an object keyed by this column's value instead of an array (JSON only)",
+				"schema": map[string]interface{}{
+					"type": "string",
+				},
+				"example": "id",
+			},
+			{
+				"name":        "key_by_duplicates",
+				"in":          "query",
+				"description": "How duplicate key_by values are handled: error (409) or last (keep the last row)",
+				"schema": map[string]interface{}{
+					"type":    "string",
+					"enum":    []string{"error", "last"},
+					"default": "error",
+				},
+			},
+			{
+				"name":        "window",
+				"in":          "query",
+				"description": "Ranking window function for top-N-per-group reads: function[:partition=col1|col2][:order=col desc|col2]. Functions: row_number, rank, dense_rank, percent_rank, cume_dist. Requires qualify.",
+				"schema": map[string]interface{}{
+					"type": "string",
+				},
+				"example": "row_number:partition=category:order=price desc",
+			},
+			{
+				"name":        "qualify",
+				"in":          "query",
+				"description": "Condition on the window function value: an operator (=, !=, <, <=, >, >=) followed by a number",
+				"schema": map[string]interface{}{
+					"type": "string",
+				},
+				"example": "<=3",
+			},
+			{
+				"name":        "sample",
+				"in":          "query",
+				"description": "Read from a random sample of the table, taken before filters: a percentage (10%) or a row count (100)",
+				"schema": map[string]interface{}{
+					"type": "string",
+				},
+				"example": "10%",
+			},
+			{
+				"name":        "sample_method",
+				"in":          "query",
+				"description": "Sampling method. Defaults to reservoir when a seed is given; row counts require reservoir.",
+				"schema": map[string]interface{}{
+					"type": "string",
+					"enum": []string{"reservoir", "bernoulli", "system"},
+				},
+			},
+			{
+				"name":        "seed",
+				"in":          "query",
+				"description": "Seed for a reproducible sample: the same seed returns the same rows for the same data. Requires sample.",
+				"schema": map[string]interface{}{
+					"type":    "integer",
+					"minimum": 0,
+				},
+				"example": 42,
+			},
+			{
+				"name":        "summary",
+				"in":          "query",
+				"description": "Aggregates over all matching rows (not just the page), returned in a summary object: function:column,... Functions: sum, avg, min, max, count. JSON only.",
+				"schema": map[string]interface{}{
+					"type": "string",
+				},
+				"example": "sum:amount,avg:price",
+			},
+			{
+				"name":        "group_by",
+				"in":          "query",
+				"description": "Comma-separated columns to group by. Returns one row of aggregates (see agg) per group instead of the table's rows.",
+				"schema": map[string]interface{}{
+					"type": "string",
+				},
+				"example": "region",
+			},
+			{
+				"name":        "agg",
+				"in":          "query",
+				"description": "Aggregates per group as function:column,... Functions: sum, avg, min, max, count (count:* counts rows), and array_agg or list, which return the group's values as an array. Defaults to count:*. Only READ permission is required.",
+				"schema": map[string]interface{}{
+					"type": "string",
+				},
+				"example": "sum:amount,count:*",
+			},
+			{
+				"name":        "select",
+				"in":          "query",
+				"description": "Comma-separated list of the columns to return, in order. Defaults to all columns.",
+				"schema": map[string]interface{}{
+					"type": "string",
+				},
+				"example": "id,name,email",
+			},
+			{
+				"name":        "modified_since",
+				"in":          "query",
+				"description": "Return only rows whose change_column is later than this RFC 3339 timestamp, oldest first. The X-Max-Modified response header holds the value for the next request.",
+				"schema": map[string]interface{}{
+					"type":   "string",
+					"format": "date-time",
+				},
+			},
+			debugSQLQueryParameter(),
+			parquetCompressionQueryParameter(),
+			csvDelimiterQueryParameter(),
+			csvQuoteAllQueryParameter(),
+			{
+				"name":        "include_hash",
+				"in":          "query",
+				"description": "Add a _row_hash column with an MD5 hash of each row (or the table's hash_columns) for change detection",
+				"schema": map[string]interface{}{
+					"type":    "boolean",
+					"default": false,
+				},
+			},
+			{
+				"name":        "explain",
+				"in":          "query",
+				"description": "Return the DuckDB query plan of the read as {\"explain\": plan} instead of rows. JSON responses only.",
+				"schema": map[string]interface{}{
+					"type":    "boolean",
+					"default": false,
+				},
+			},
+			{
+				"name":        "include_deleted",
+				"in":          "query",
+				"description": "Include soft-deleted rows of a table with soft_delete configured",
+				"schema": map[string]interface{}{
+					"type":    "boolean",
+					"default": false,
+				},
+			},
 		},
 		"responses": map[string]interface{}{
 			"200": map[string]interface{}{
@@ -210,6 +526,11 @@ func (h *OpenAPIHandler) generateReadOperation() map[string]interface{} {
 							"format": "binary",
 						},
 					},
+					"application/x-ndjson": map[string]interface{}{
+						"schema": map[string]interface{}{
+							"type": "string",
+						},
+					},
 				},
 			},
 			"400": map[string]interface{}{
@@ -258,28 +579,56 @@ func (h *OpenAPIHandler) generateReadOperation() map[string]interface{} {
 
 // generateCreateOperation generates the POST operation spec.
 func (h *OpenAPIHandler) generateCreateOperation() map[string]interface{} {
+	recordSchema := map[string]interface{}{
+		"type": "object",
+		"additionalProperties": map[string]interface{}{
+			"oneOf": []map[string]interface{}{
+				{"type": "string"},
+				{"type": "number"},
+				{"type": "boolean"},
+				{"type": "null"},
+			},
+		},
+	}
 	return map[string]interface{}{
 		"tags":        []string{"CRUD"},
 		"summary":     "Create a new record",
-		"description": "Inserts a new record into the specified table",
+		"description": "Inserts a new record into the specified table. An array of records is inserted in a single transaction. Use dry_run=true to check whether a single record would be inserted without inserting it.",
 		"operationId": "createRecord",
 		"security": []map[string]interface{}{
 			{"ApiKeyAuth": []string{}},
 		},
+		"parameters": []map[string]interface{}{
+			{
+				"name":        "dry_run",
+				"in":          "query",
+				"description": "If true, validates the record and executes the insert in a rolled-back transaction, reporting whether it would succeed. Not supported for arrays.",
+				"schema": map[string]interface{}{
+					"type":    "boolean",
+					"default": false,
+				},
+			},
+			{
+				"name":        "ignore_unknown",
+				"in":          "query",
+				"description": "If true, columns that do not exist in the table are dropped instead of rejected and listed in the X-Ignored-Columns response header",
+				"schema": map[string]interface{}{
+					"type":    "boolean",
+					"default": false,
+				},
+			},
+			preferHeaderParameter(),
+			noContentQueryParameter(),
+		},
 		"requestBody": map[string]interface{}{
 			"required":    true,
-			"description": "Record data as key-value pairs",
+			"description": "Record data as key-value pairs, or an array of up to 10000 records. Omitted columns are set to NULL; the value \"__DEFAULT__\" uses the column's DEFAULT.",
 			"content": map[string]interface{}{
 				"application/json": map[string]interface{}{
 					"schema": map[string]interface{}{
-						"type": "object",
-						"additionalProperties": map[string]interface{}{
-							"oneOf": []map[string]interface{}{
-								{"type": "string"},
-								{"type": "number"},
-								{"type": "boolean"},
-								{"type": "null"},
-							},
+						"oneOf": []map[string]interface{}{
+							recordSchema,
+							{"type": "array", "items": recordSchema},
 						},
 					},
 					"example": map[string]interface{}{
@@ -291,6 +640,16 @@ func (h *OpenAPIHandler) generateCreateOperation() map[string]interface{} {
 			},
 		},
 		"responses": map[string]interface{}{
+			"200": map[string]interface{}{
+				"description": "Dry run result",
+				"content": map[string]interface{}{
+					"application/json": map[string]interface{}{
+						"schema": map[string]interface{}{
+							"$ref": "#/components/schemas/CreateDryRunResponse",
+						},
+					},
+				},
+			},
 			"201": map[string]interface{}{
 				"description": "Record created successfully",
 				"content": map[string]interface{}{
@@ -301,6 +660,7 @@ func (h *OpenAPIHandler) generateCreateOperation() map[string]interface{} {
 					},
 				},
 			},
+			"204": noContentResponse(),
 			"400": map[string]interface{}{
 				"description": "Bad request",
 				"content": map[string]interface{}{
@@ -321,159 +681,1095 @@ func (h *OpenAPIHandler) generateCreateOperation() map[string]interface{} {
 					},
 				},
 			},
-			"403": map[string]interface{}{
-				"description": "Forbidden",
-				"content": map[string]interface{}{
-					"application/json": map[string]interface{}{
-						"schema": map[string]interface{}{
-							"$ref": "#/components/schemas/ErrorResponse",
-						},
-					},
-				},
+			"403": map[string]interface{}{
+				"description": "Forbidden",
+				"content": map[string]interface{}{
+					"application/json": map[string]interface{}{
+						"schema": map[string]interface{}{
+							"$ref": "#/components/schemas/ErrorResponse",
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+// generateUpdateOperation generates the PUT operation spec.
+func (h *OpenAPIHandler) generateUpdateOperation() map[string]interface{} {
+	return map[string]interface{}{
+		"tags":        []string{"CRUD"},
+		"summary":     "Update records",
+		"description": "Updates records matching the WHERE clause. Use dry_run=true to preview affected rows without updating.",
+		"operationId": "updateRecords",
+		"security": []map[string]interface{}{
+			{"ApiKeyAuth": []string{}},
+		},
+		"parameters": []map[string]interface{}{
+			{
+				"name":        "dry_run",
+				"in":          "query",
+				"description": "If true, returns affected row count without actually updating",
+				"schema": map[string]interface{}{
+					"type":    "boolean",
+					"default": false,
+				},
+			},
+			debugSQLQueryParameter(),
+			{
+				"name":        "ignore_unknown",
+				"in":          "query",
+				"description": "If true, columns that do not exist in the table are dropped instead of rejected and listed in the X-Ignored-Columns response header",
+				"schema": map[string]interface{}{
+					"type":    "boolean",
+					"default": false,
+				},
+			},
+			preferHeaderParameter(),
+			noContentQueryParameter(),
+		},
+		"requestBody": map[string]interface{}{
+			"required":    true,
+			"description": "Update specification with WHERE conditions and SET values",
+			"content": map[string]interface{}{
+				"application/json": map[string]interface{}{
+					"schema": map[string]interface{}{
+						"$ref": "#/components/schemas/UpdateRequest",
+					},
+				},
+			},
+		},
+		"responses": map[string]interface{}{
+			"200": map[string]interface{}{
+				"description": "Records updated successfully (or dry run result)",
+				"content": map[string]interface{}{
+					"application/json": map[string]interface{}{
+						"schema": map[string]interface{}{
+							"oneOf": []map[string]interface{}{
+								{"$ref": "#/components/schemas/SuccessResponse"},
+								{"$ref": "#/components/schemas/DryRunResponse"},
+							},
+						},
+					},
+				},
+			},
+			"204": noContentResponse(),
+			"400": map[string]interface{}{
+				"description": "Bad request",
+				"content": map[string]interface{}{
+					"application/json": map[string]interface{}{
+						"schema": map[string]interface{}{
+							"$ref": "#/components/schemas/ErrorResponse",
+						},
+					},
+				},
+			},
+			"401": map[string]interface{}{
+				"description": "Unauthorized",
+				"content": map[string]interface{}{
+					"application/json": map[string]interface{}{
+						"schema": map[string]interface{}{
+							"$ref": "#/components/schemas/ErrorResponse",
+						},
+					},
+				},
+			},
+			"403": map[string]interface{}{
+				"description": "Forbidden",
+				"content": map[string]interface{}{
+					"application/json": map[string]interface{}{
+						"schema": map[string]interface{}{
+							"$ref": "#/components/schemas/ErrorResponse",
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+// generateUpsertOperation generates the PATCH operation spec.
+func (h *OpenAPIHandler) generateUpsertOperation() map[string]interface{} {
+	return map[string]interface{}{
+		"tags":        []string{"CRUD"},
+		"summary":     "Insert or update records",
+		"description": "Inserts rows, updating the existing row instead when a row conflicts on the primary key or the unique constraint named in conflict (INSERT ... ON CONFLICT DO UPDATE). Only the columns present in a row are written. All rows are written in one transaction. Requires CREATE and UPDATE permission.",
+		"operationId": "upsertRecords",
+		"security": []map[string]interface{}{
+			{"ApiKeyAuth": []string{}},
+		},
+		"parameters": []map[string]interface{}{
+			{
+				"name":        "ignore_unknown",
+				"in":          "query",
+				"description": "If true, columns that do not exist in the table are dropped instead of rejected and listed in the X-Ignored-Columns response header",
+				"schema": map[string]interface{}{
+					"type":    "boolean",
+					"default": false,
+				},
+			},
+		},
+		"requestBody": map[string]interface{}{
+			"required":    true,
+			"description": "Rows to insert or update and the conflict target",
+			"content": map[string]interface{}{
+				"application/json": map[string]interface{}{
+					"schema": map[string]interface{}{
+						"$ref": "#/components/schemas/UpsertRequest",
+					},
+				},
+			},
+		},
+		"responses": map[string]interface{}{
+			"200": map[string]interface{}{
+				"description": "Records inserted or updated successfully",
+				"content": map[string]interface{}{
+					"application/json": map[string]interface{}{
+						"schema": map[string]interface{}{
+							"$ref": "#/components/schemas/SuccessResponse",
+						},
+					},
+				},
+			},
+			"400": map[string]interface{}{
+				"description": "Bad request (e.g. conflict columns that are not a key of the table)",
+				"content": map[string]interface{}{
+					"application/json": map[string]interface{}{
+						"schema": map[string]interface{}{
+							"$ref": "#/components/schemas/ErrorResponse",
+						},
+					},
+				},
+			},
+			"401": map[string]interface{}{
+				"description": "Unauthorized",
+				"content": map[string]interface{}{
+					"application/json": map[string]interface{}{
+						"schema": map[string]interface{}{
+							"$ref": "#/components/schemas/ErrorResponse",
+						},
+					},
+				},
+			},
+			"403": map[string]interface{}{
+				"description": "Forbidden",
+				"content": map[string]interface{}{
+					"application/json": map[string]interface{}{
+						"schema": map[string]interface{}{
+							"$ref": "#/components/schemas/ErrorResponse",
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+// generateBulkUpdateOperation generates the PUT /api/{table}/bulk operation spec.
+func (h *OpenAPIHandler) generateBulkUpdateOperation() map[string]interface{} {
+	return map[string]interface{}{
+		"tags":        []string{"CRUD"},
+		"summary":     "Update records to different values by key",
+		"description": "Applies each item's SET values to the rows whose columns equal its WHERE values, in a single transaction. If any item fails, no update is applied. Requires update permission.",
+		"operationId": "bulkUpdateRecords",
+		"security": []map[string]interface{}{
+			{"ApiKeyAuth": []string{}},
+		},
+		"parameters": []map[string]interface{}{
+			{
+				"name":        "ignore_unknown",
+				"in":          "query",
+				"description": "If true, SET columns that do not exist in the table are dropped instead of rejected and listed in the X-Ignored-Columns response header",
+				"schema": map[string]interface{}{
+					"type":    "boolean",
+					"default": false,
+				},
+			},
+		},
+		"requestBody": map[string]interface{}{
+			"required":    true,
+			"description": "Updates to apply in order (at most 1000)",
+			"content": map[string]interface{}{
+				"application/json": map[string]interface{}{
+					"schema": map[string]interface{}{
+						"type": "array",
+						"items": map[string]interface{}{
+							"type":     "object",
+							"required": []string{"where", "set"},
+							"properties": map[string]interface{}{
+								"where": map[string]interface{}{
+									"type":                 "object",
+									"description":          "Column values identifying the rows to update (equality)",
+									"additionalProperties": true,
+									"example":              map[string]interface{}{"id": 1},
+								},
+								"set": map[string]interface{}{
+									"type":                 "object",
+									"description":          "Column values to set",
+									"additionalProperties": true,
+									"example":              map[string]interface{}{"status": "active"},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+		"responses": map[string]interface{}{
+			"200": map[string]interface{}{
+				"description": "All updates applied",
+				"content": map[string]interface{}{
+					"application/json": map[string]interface{}{
+						"schema": map[string]interface{}{
+							"type":     "object",
+							"required": []string{"success", "rows_affected", "results"},
+							"properties": map[string]interface{}{
+								"success": map[string]interface{}{
+									"type":    "boolean",
+									"example": true,
+								},
+								"rows_affected": map[string]interface{}{
+									"type":        "integer",
+									"description": "Total number of rows updated",
+									"example":     2,
+								},
+								"results": map[string]interface{}{
+									"type":        "array",
+									"description": "Rows updated by each item, in request order",
+									"items": map[string]interface{}{
+										"type": "object",
+										"properties": map[string]interface{}{
+											"rows_affected": map[string]interface{}{
+												"type":    "integer",
+												"example": 1,
+											},
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+			"400": errorResponseRef("Bad request"),
+			"401": errorResponseRef("Unauthorized"),
+			"403": errorResponseRef("Forbidden"),
+			"500": errorResponseRef("An update failed; no updates were applied"),
+		},
+	}
+}
+
+// generateDeleteOperation generates the DELETE operation spec.
+func (h *OpenAPIHandler) generateDeleteOperation() map[string]interface{} {
+	return map[string]interface{}{
+		"tags":        []string{"CRUD"},
+		"summary":     "Delete records",
+		"description": "Deletes records matching the WHERE clause. Use dry_run=true to preview affected rows without deleting.",
+		"operationId": "deleteRecords",
+		"security": []map[string]interface{}{
+			{"ApiKeyAuth": []string{}},
+		},
+		"parameters": []map[string]interface{}{
+			{
+				"name":        "where",
+				"in":          "query",
+				"required":    true,
+				"description": "WHERE conditions in format: column:operator:value (comma-separated for multiple). Operators: eq, ne, gt, gte, lt, lte, like, notlike, in, not_in, between, is_null, is_not_null (the null operators take no value, e.g. deleted_at:is_null)",
+				"schema": map[string]interface{}{
+					"type": "string",
+				},
+				"example": "status:eq:inactive",
+			},
+			{
+				"name":        "dry_run",
+				"in":          "query",
+				"description": "If true, returns affected row count without actually deleting",
+				"schema": map[string]interface{}{
+					"type":    "boolean",
+					"default": false,
+				},
+			},
+			{
+				"name":        "soft_delete",
+				"in":          "query",
+				"description": "Tables with soft_delete configured always soft-delete; on other tables, true is rejected with 400.",
+				"schema": map[string]interface{}{
+					"type":    "boolean",
+					"default": false,
+				},
+			},
+			debugSQLQueryParameter(),
+			preferHeaderParameter(),
+			noContentQueryParameter(),
+		},
+		"responses": map[string]interface{}{
+			"200": map[string]interface{}{
+				"description": "Records deleted successfully (or dry run result)",
+				"content": map[string]interface{}{
+					"application/json": map[string]interface{}{
+						"schema": map[string]interface{}{
+							"oneOf": []map[string]interface{}{
+								{"$ref": "#/components/schemas/SuccessResponse"},
+								{"$ref": "#/components/schemas/DryRunResponse"},
+							},
+						},
+					},
+				},
+			},
+			"204": noContentResponse(),
+			"400": map[string]interface{}{
+				"description": "Bad request",
+				"content": map[string]interface{}{
+					"application/json": map[string]interface{}{
+						"schema": map[string]interface{}{
+							"$ref": "#/components/schemas/ErrorResponse",
+						},
+					},
+				},
+			},
+			"401": map[string]interface{}{
+				"description": "Unauthorized",
+				"content": map[string]interface{}{
+					"application/json": map[string]interface{}{
+						"schema": map[string]interface{}{
+							"$ref": "#/components/schemas/ErrorResponse",
+						},
+					},
+				},
+			},
+			"403": map[string]interface{}{
+				"description": "Forbidden",
+				"content": map[string]interface{}{
+					"application/json": map[string]interface{}{
+						"schema": map[string]interface{}{
+							"$ref": "#/components/schemas/ErrorResponse",
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+// generateRestoreOperation generates the POST /api/{table}/restore operation spec.
+func (h *OpenAPIHandler) generateRestoreOperation() map[string]interface{} {
+	return map[string]interface{}{
+		"tags":        []string{"CRUD"},
+		"summary":     "Restore soft-deleted records",
+		"description": "Clears the soft-delete column for soft-deleted records matching the WHERE clause. Requires soft_delete to be configured for the table and update permission.",
+		"operationId": "restoreRecords",
+		"security": []map[string]interface{}{
+			{"ApiKeyAuth": []string{}},
+		},
+		"parameters": []map[string]interface{}{
+			{
+				"name":        "where",
+				"in":          "query",
+				"required":    true,
+				"description": "WHERE conditions in format: column:operator:value (comma-separated for multiple). Operators: eq, ne, gt, gte, lt, lte, like, notlike, in, not_in, between, is_null, is_not_null (the null operators take no value, e.g. deleted_at:is_null)",
+				"schema": map[string]interface{}{
+					"type": "string",
+				},
+				"example": "id:eq:1",
+			},
+		},
+		"responses": map[string]interface{}{
+			"200": successResponseRef("Records restored successfully"),
+			"400": errorResponseRef("Bad request or soft delete not configured"),
+			"401": errorResponseRef("Unauthorized"),
+			"403": errorResponseRef("Forbidden"),
+		},
+	}
+}
+
+// generatePurgeOperation generates the DELETE /api/{table}/purge operation spec.
+func (h *OpenAPIHandler) generatePurgeOperation() map[string]interface{} {
+	return map[string]interface{}{
+		"tags":        []string{"CRUD"},
+		"summary":     "Purge soft-deleted records",
+		"description": "Permanently deletes records that were soft-deleted before the given date. Requires soft_delete to be configured for the table and delete permission.",
+		"operationId": "purgeRecords",
+		"security": []map[string]interface{}{
+			{"ApiKeyAuth": []string{}},
+		},
+		"parameters": []map[string]interface{}{
+			{
+				"name":        "before",
+				"in":          "query",
+				"required":    true,
+				"description": "Purge records soft-deleted before this date (YYYY-MM-DD) or RFC 3339 timestamp",
+				"schema": map[string]interface{}{
+					"type": "string",
+				},
+				"example": "2025-01-01",
+			},
+		},
+		"responses": map[string]interface{}{
+			"200": successResponseRef("Records purged successfully"),
+			"400": errorResponseRef("Bad request or soft delete not configured"),
+			"401": errorResponseRef("Unauthorized"),
+			"403": errorResponseRef("Forbidden"),
+		},
+	}
+}
+
+// generateChangesOperation generates the GET /api/{table}/changes operation spec.
+func (h *OpenAPIHandler) generateChangesOperation() map[string]interface{} {
+	return map[string]interface{}{
+		"tags":        []string{"CRUD"},
+		"summary":     "Long-poll for table changes",
+		"description": "Blocks until the table's change counter advances past `since` or the timeout expires, then returns the current version. The counter is advanced by writes through the table API and resets when the server restarts (reported as resync). Requires read permission.",
+		"operationId": "pollChanges",
+		"security": []map[string]interface{}{
+			{"ApiKeyAuth": []string{}},
+		},
+		"parameters": []map[string]interface{}{
+			{
+				"name":        "since",
+				"in":          "query",
+				"required":    false,
+				"description": "Last version seen by the client (default: 0)",
+				"schema": map[string]interface{}{
+					"type":    "integer",
+					"minimum": 0,
+				},
+			},
+			{
+				"name":        "timeout",
+				"in":          "query",
+				"required":    false,
+				"description": "Maximum wait in seconds (default: 30, max: 60)",
+				"schema": map[string]interface{}{
+					"type":    "integer",
+					"minimum": 0,
+					"maximum": 60,
+				},
+			},
+			{
+				"name":        "rows",
+				"in":          "query",
+				"required":    false,
+				"description": "Include rows whose change_column is newer than the since version. Requires change_column to be configured for the table.",
+				"schema": map[string]interface{}{
+					"type": "boolean",
+				},
+			},
+		},
+		"responses": map[string]interface{}{
+			"200": map[string]interface{}{
+				"description": "Current change version",
+				"content": map[string]interface{}{
+					"application/json": map[string]interface{}{
+						"schema": map[string]interface{}{
+							"type": "object",
+							"properties": map[string]interface{}{
+								"table":   map[string]interface{}{"type": "string"},
+								"version": map[string]interface{}{"type": "integer"},
+								"changed": map[string]interface{}{"type": "boolean"},
+								"resync": map[string]interface{}{
+									"type":        "boolean",
+									"description": "The since version is unknown; re-read the table",
+								},
+								"rows": map[string]interface{}{
+									"type":  "array",
+									"items": map[string]interface{}{"type": "object"},
+								},
+							},
+						},
+					},
+				},
+			},
+			"400": errorResponseRef("Bad request"),
+			"401": errorResponseRef("Unauthorized"),
+			"403": errorResponseRef("Forbidden"),
+			"429": errorResponseRef("Too many concurrent streams for this API key"),
+		},
+	}
+}
+
+// generateTimeSeriesOperation generates the GET /api/{table}/timeseries operation spec.
+func (h *OpenAPIHandler) generateTimeSeriesOperation() map[string]interface{} {
+	return map[string]interface{}{
+		"tags":        []string{"CRUD"},
+		"summary":     "Aggregate rows into time buckets",
+		"description": "Groups the table's rows by time_bucket(interval, time_column) and the group_by columns, and returns the aggregates of each bucket ordered by bucket. Requires read permission.",
+		"operationId": "readTimeSeries",
+		"security": []map[string]interface{}{
+			{"ApiKeyAuth": []string{}},
+		},
+		"parameters": []map[string]interface{}{
+			{
+				"name":        "time_column",
+				"in":          "query",
+				"required":    true,
+				"description": "TIMESTAMP or DATE column to bucket",
+				"schema": map[string]interface{}{
+					"type": "string",
+				},
+				"example": "ts",
+			},
+			{
+				"name":        "interval",
+				"in":          "query",
+				"required":    true,
+				"description": "Bucket width: a count followed by s, m, h, d, w, mo, or y",
+				"schema": map[string]interface{}{
+					"type":    "string",
+					"pattern": "^[0-9]+(s|m|h|d|w|mo|y)$",
+				},
+				"example": "1h",
+			},
+			{
+				"name":        "aggregate",
+				"in":          "query",
+				"description": "Aggregates per bucket as function:column (comma-separated). Functions: sum, avg, min, max, count, array_agg, list; count also accepts *. Results are named count for count:* and function_column otherwise. Default: count:*",
+				"schema": map[string]interface{}{
+					"type": "string",
+				},
+				"example": "count:*,avg:latency",
+			},
+			{
+				"name":        "group_by",
+				"in":          "query",
+				"description": "Additional grouping columns (comma-separated)",
+				"schema": map[string]interface{}{
+					"type": "string",
+				},
+				"example": "status",
+			},
+			{
+				"name":        "filter",
+				"in":          "query",
+				"description": "Filter conditions applied before bucketing, in the same format as reads",
+				"schema": map[string]interface{}{
+					"type": "string",
+				},
+			},
+		},
+		"responses": map[string]interface{}{
+			"200": map[string]interface{}{
+				"description": "Aggregates per bucket",
+				"content": map[string]interface{}{
+					"application/json": map[string]interface{}{
+						"schema": map[string]interface{}{
+							"type": "object",
+							"properties": map[string]interface{}{
+								"table":       map[string]interface{}{"type": "string"},
+								"time_column": map[string]interface{}{"type": "string"},
+								"interval":    map[string]interface{}{"type": "string"},
+								"buckets":     map[string]interface{}{"type": "integer"},
+								"data": map[string]interface{}{
+									"type":  "array",
+									"items": map[string]interface{}{"type": "object"},
+								},
+							},
+						},
+					},
+				},
+			},
+			"400": errorResponseRef("Bad request"),
+			"401": errorResponseRef("Unauthorized"),
+			"403": errorResponseRef("Forbidden"),
+		},
+	}
+}
+
+// generateCardinalityOperation generates the GET /api/{table}/cardinality operation spec.
+func (h *OpenAPIHandler) generateCardinalityOperation() map[string]interface{} {
+	return map[string]interface{}{
+		"tags":        []string{"CRUD"},
+		"summary":     "Count the distinct values of a column",
+		"description": "Estimates the number of distinct non-NULL values of a column with approx_count_distinct, or counts them precisely with exact=true. Requires read permission.",
+		"operationId": "readCardinality",
+		"security": []map[string]interface{}{
+			{"ApiKeyAuth": []string{}},
+		},
+		"parameters": []map[string]interface{}{
+			{
+				"name":        "column",
+				"in":          "query",
+				"required":    true,
+				"description": "Column whose distinct values are counted",
+				"schema": map[string]interface{}{
+					"type": "string",
+				},
+				"example": "visitor",
+			},
+			{
+				"name":        "exact",
+				"in":          "query",
+				"description": "Count precisely with COUNT(DISTINCT) instead of estimating. Slower on large tables.",
+				"schema": map[string]interface{}{
+					"type":    "boolean",
+					"default": false,
+				},
+			},
+			{
+				"name":        "filter",
+				"in":          "query",
+				"description": "Filter conditions applied before counting, in the same format as reads",
+				"schema": map[string]interface{}{
+					"type": "string",
+				},
+			},
+		},
+		"responses": map[string]interface{}{
+			"200": map[string]interface{}{
+				"description": "Distinct value count",
+				"content": map[string]interface{}{
+					"application/json": map[string]interface{}{
+						"schema": map[string]interface{}{
+							"type": "object",
+							"properties": map[string]interface{}{
+								"table":       map[string]interface{}{"type": "string"},
+								"column":      map[string]interface{}{"type": "string"},
+								"distinct":    map[string]interface{}{"type": "integer"},
+								"approximate": map[string]interface{}{"type": "boolean"},
+							},
+						},
+					},
+				},
+			},
+			"400": errorResponseRef("Bad request"),
+			"401": errorResponseRef("Unauthorized"),
+			"403": errorResponseRef("Forbidden"),
+		},
+	}
+}
+
+// generateDownloadTokenOperation generates the POST /api/{table}/download-token operation spec.
+func (h *OpenAPIHandler) generateDownloadTokenOperation() map[string]interface{} {
+	return map[string]interface{}{
+		"tags":        []string{"CRUD"},
+		"summary":     "Mint a single-use download token",
+		"description": "Creates a short-lived token that redeems a read of the table exactly once via GET /download/{token}, without an API key. The read uses this request's query parameters (filter, sort, limit, ...) and runs with the caller's role. Requires read permission and download_token_ttl to be configured.",
+		"operationId": "createDownloadToken",
+		"security": []map[string]interface{}{
+			{"ApiKeyAuth": []string{}},
+		},
+		"requestBody": map[string]interface{}{
+			"required": false,
+			"content": map[string]interface{}{
+				"application/json": map[string]interface{}{
+					"schema": map[string]interface{}{
+						"type": "object",
+						"properties": map[string]interface{}{
+							"format": map[string]interface{}{
+								"type":    "string",
+								"enum":    []string{"json", "csv", "parquet", "arrow", "ndjson"},
+								"default": "json",
+							},
+							"expires_in": map[string]interface{}{
+								"type":        "integer",
+								"minimum":     0,
+								"description": "Token lifetime in seconds, capped at download_token_ttl (default: download_token_ttl)",
+							},
+						},
+					},
+				},
+			},
+		},
+		"responses": map[string]interface{}{
+			"201": map[string]interface{}{
+				"description": "Token created",
+				"content": map[string]interface{}{
+					"application/json": map[string]interface{}{
+						"schema": map[string]interface{}{
+							"type": "object",
+							"properties": map[string]interface{}{
+								"token":      map[string]interface{}{"type": "string"},
+								"url":        map[string]interface{}{"type": "string"},
+								"expires_at": map[string]interface{}{"type": "string", "format": "date-time"},
+							},
+						},
+					},
+				},
+			},
+			"400": errorResponseRef("Bad request"),
+			"401": errorResponseRef("Unauthorized"),
+			"403": errorResponseRef("Forbidden"),
+			"404": errorResponseRef("Download tokens are not enabled"),
+		},
+	}
+}
+
+// generateDownloadOperation generates the GET /download/{token} operation spec.
+func (h *OpenAPIHandler) generateDownloadOperation() map[string]interface{} {
+	return map[string]interface{}{
+		"tags":        []string{"CRUD"},
+		"summary":     "Redeem a download token",
+		"description": "Streams the read bound to the token as an attachment in the token's format. No API key is required; each token works once.",
+		"operationId": "redeemDownloadToken",
+		"parameters": []map[string]interface{}{
+			{
+				"name":     "token",
+				"in":       "path",
+				"required": true,
+				"schema": map[string]interface{}{
+					"type": "string",
+				},
+			},
+		},
+		"responses": map[string]interface{}{
+			"200": map[string]interface{}{
+				"description": "The exported rows",
 			},
+			"404": errorResponseRef("Unknown token"),
+			"410": errorResponseRef("Token expired or already used"),
 		},
 	}
 }
 
-// generateUpdateOperation generates the PUT operation spec.
-func (h *OpenAPIHandler) generateUpdateOperation() map[string]interface{} {
+// generateTransactionOperation generates the POST /transaction operation spec.
+func (h *OpenAPIHandler) generateTransactionOperation() map[string]interface{} {
 	return map[string]interface{}{
 		"tags":        []string{"CRUD"},
-		"summary":     "Update records",
-		"description": "Updates records matching the WHERE clause",
-		"operationId": "updateRecords",
+		"summary":     "Apply writes to several tables in one transaction",
+		"description": "Applies an ordered array of inserts, updates, and deletes in a single transaction. Every operation is validated and checked against the role's permission for its table before any of them runs; if one fails, none is applied. Raw SQL is not accepted.",
+		"operationId": "runTransaction",
 		"security": []map[string]interface{}{
 			{"ApiKeyAuth": []string{}},
 		},
 		"requestBody": map[string]interface{}{
-			"required":    true,
-			"description": "Update specification with WHERE conditions and SET values",
+			"required": true,
 			"content": map[string]interface{}{
 				"application/json": map[string]interface{}{
 					"schema": map[string]interface{}{
-						"$ref": "#/components/schemas/UpdateRequest",
+						"type":     "array",
+						"minItems": 1,
+						"maxItems": maxTransactionOperations,
+						"items": map[string]interface{}{
+							"type":                 "object",
+							"required":             []string{"op", "table"},
+							"additionalProperties": false,
+							"properties": map[string]interface{}{
+								"op": map[string]interface{}{
+									"type": "string",
+									"enum": []string{"insert", "update", "delete"},
+								},
+								"table": map[string]interface{}{
+									"type": "string",
+								},
+								"data": map[string]interface{}{
+									"type":        "object",
+									"description": "Row to insert (insert only)",
+								},
+								"where": map[string]interface{}{
+									"type":        "array",
+									"description": "Conditions selecting the rows to update or delete, in the format of an update's where",
+									"items":       map[string]interface{}{"type": "object"},
+								},
+								"set": map[string]interface{}{
+									"type":        "object",
+									"description": "Values to set (update only)",
+								},
+							},
+						},
+					},
+					"example": []map[string]interface{}{
+						{"op": "insert", "table": "orders", "data": map[string]interface{}{"id": 1, "user_id": 7}},
+						{"op": "update", "table": "users", "where": []map[string]interface{}{{"column": "id", "op": "eq", "value": 7}}, "set": map[string]interface{}{"order_count": 3}},
 					},
 				},
 			},
 		},
 		"responses": map[string]interface{}{
 			"200": map[string]interface{}{
-				"description": "Records updated successfully",
-				"content": map[string]interface{}{
-					"application/json": map[string]interface{}{
-						"schema": map[string]interface{}{
-							"$ref": "#/components/schemas/SuccessResponse",
-						},
-					},
-				},
-			},
-			"400": map[string]interface{}{
-				"description": "Bad request",
+				"description": "All operations were applied",
 				"content": map[string]interface{}{
 					"application/json": map[string]interface{}{
 						"schema": map[string]interface{}{
-							"$ref": "#/components/schemas/ErrorResponse",
+							"type": "object",
+							"properties": map[string]interface{}{
+								"success":       map[string]interface{}{"type": "boolean"},
+								"rows_affected": map[string]interface{}{"type": "integer"},
+								"request_id":    map[string]interface{}{"type": "string"},
+								"results": map[string]interface{}{
+									"type": "array",
+									"items": map[string]interface{}{
+										"type": "object",
+										"properties": map[string]interface{}{
+											"op":            map[string]interface{}{"type": "string"},
+											"table":         map[string]interface{}{"type": "string"},
+											"rows_affected": map[string]interface{}{"type": "integer"},
+										},
+									},
+								},
+							},
 						},
 					},
 				},
 			},
-			"401": map[string]interface{}{
-				"description": "Unauthorized",
-				"content": map[string]interface{}{
-					"application/json": map[string]interface{}{
-						"schema": map[string]interface{}{
-							"$ref": "#/components/schemas/ErrorResponse",
+			"400": errorResponseRef("Bad request"),
+			"401": errorResponseRef("Unauthorized"),
+			"403": errorResponseRef("Forbidden"),
+			"404": errorResponseRef("Table not found"),
+			"500": errorResponseRef("An operation failed; no changes were applied"),
+		},
+	}
+}
+
+// generateQueryBatchOperation generates the POST /query/batch operation spec.
+func (h *OpenAPIHandler) generateQueryBatchOperation() map[string]interface{} {
+	return map[string]interface{}{
+		"tags":        []string{"Query"},
+		"summary":     "Execute a batch of read-only queries",
+		"description": "Executes read-only SQL statements in order and returns all result sets, keyed by query name or index. The row safety limit applies to each statement. Requires can_query permission.",
+		"operationId": "executeQueryBatch",
+		"security": []map[string]interface{}{
+			{"ApiKeyAuth": []string{}},
+		},
+		"requestBody": map[string]interface{}{
+			"required": true,
+			"content": map[string]interface{}{
+				"application/json": map[string]interface{}{
+					"schema": map[string]interface{}{
+						"type":     "object",
+						"required": []string{"queries"},
+						"properties": map[string]interface{}{
+							"queries": map[string]interface{}{
+								"type":     "array",
+								"maxItems": maxBatchQueries,
+								"items": map[string]interface{}{
+									"type":     "object",
+									"required": []string{"sql"},
+									"properties": map[string]interface{}{
+										"name": map[string]interface{}{
+											"type":        "string",
+											"description": "Optional key for the result set (defaults to the query index)",
+										},
+										"sql": map[string]interface{}{
+											"type":        "string",
+											"description": "Read-only SQL statement",
+										},
+										"params": map[string]interface{}{
+											"type":        "array",
+											"description": "Query parameters",
+											"items":       map[string]interface{}{},
+										},
+									},
+								},
+							},
 						},
 					},
 				},
 			},
-			"403": map[string]interface{}{
-				"description": "Forbidden",
+		},
+		"responses": map[string]interface{}{
+			"200": map[string]interface{}{
+				"description": "All queries executed successfully",
 				"content": map[string]interface{}{
 					"application/json": map[string]interface{}{
 						"schema": map[string]interface{}{
-							"$ref": "#/components/schemas/ErrorResponse",
+							"type": "object",
+							"properties": map[string]interface{}{
+								"results": map[string]interface{}{
+									"type": "object",
+									"additionalProperties": map[string]interface{}{
+										"$ref": "#/components/schemas/QueryResponse",
+									},
+								},
+							},
 						},
 					},
 				},
 			},
+			"400": errorResponseRef("Bad request or non read-only statement"),
+			"401": errorResponseRef("Unauthorized"),
+			"403": errorResponseRef("Forbidden"),
+			"429": errorResponseRef("Too many concurrent streams for this API key"),
 		},
 	}
 }
 
-// generateDeleteOperation generates the DELETE operation spec.
-func (h *OpenAPIHandler) generateDeleteOperation() map[string]interface{} {
+// generateQuerySSEOperation generates the GET /query/sse operation spec.
+func (h *OpenAPIHandler) generateQuerySSEOperation() map[string]interface{} {
 	return map[string]interface{}{
-		"tags":        []string{"CRUD"},
-		"summary":     "Delete records",
-		"description": "Deletes records matching the WHERE clause. Use dry_run=true to preview affected rows without deleting.",
-		"operationId": "deleteRecords",
+		"tags":        []string{"Query"},
+		"summary":     "Stream query results as server-sent events",
+		"description": "Executes a read-only SQL query and streams each row as an SSE `data:` event (a JSON object). The stream ends with `event: done` carrying `rows` and `execution_time_ms`, or `event: error` if the query fails mid-stream. Requires can_query permission.",
+		"operationId": "streamQuery",
 		"security": []map[string]interface{}{
 			{"ApiKeyAuth": []string{}},
 		},
 		"parameters": []map[string]interface{}{
 			{
-				"name":        "where",
+				"name":        "sql",
 				"in":          "query",
 				"required":    true,
-				"description": "WHERE conditions in format: column:operator:value (comma-separated for multiple). Operators: eq, ne, gt, gte, lt, lte, like, in",
+				"description": "Read-only SQL query",
 				"schema": map[string]interface{}{
 					"type": "string",
 				},
-				"example": "status:eq:inactive",
-			},
-			{
-				"name":        "dry_run",
-				"in":          "query",
-				"description": "If true, returns affected row count without actually deleting",
-				"schema": map[string]interface{}{
-					"type":    "boolean",
-					"default": false,
-				},
+				"example": "SELECT * FROM users",
 			},
 		},
 		"responses": map[string]interface{}{
 			"200": map[string]interface{}{
-				"description": "Records deleted successfully (or dry run result)",
-				"content": map[string]interface{}{
-					"application/json": map[string]interface{}{
-						"schema": map[string]interface{}{
-							"oneOf": []map[string]interface{}{
-								{"$ref": "#/components/schemas/SuccessResponse"},
-								{"$ref": "#/components/schemas/DryRunResponse"},
-							},
-						},
-					},
-				},
-			},
-			"400": map[string]interface{}{
-				"description": "Bad request",
+				"description": "Event stream of result rows",
 				"content": map[string]interface{}{
-					"application/json": map[string]interface{}{
+					"text/event-stream": map[string]interface{}{
 						"schema": map[string]interface{}{
-							"$ref": "#/components/schemas/ErrorResponse",
+							"type": "string",
 						},
 					},
 				},
 			},
-			"401": map[string]interface{}{
-				"description": "Unauthorized",
-				"content": map[string]interface{}{
-					"application/json": map[string]interface{}{
-						"schema": map[string]interface{}{
-							"$ref": "#/components/schemas/ErrorResponse",
-						},
-					},
+			"400": errorResponseRef("Bad request or non read-only statement"),
+			"401": errorResponseRef("Unauthorized"),
+			"403": errorResponseRef("Forbidden"),
+		},
+	}
+}
+
+// tablePathParameter returns the {table} path parameter spec.
+func tablePathParameter() map[string]interface{} {
+	return map[string]interface{}{
+		"name":        "table",
+		"in":          "path",
+		"required":    true,
+		"description": "Name of the database table, optionally qualified with an attached catalog (catalog.table)",
+		"schema": map[string]interface{}{
+			"type": "string",
+		},
+	}
+}
+
+// catalogQueryParameter returns the catalog query parameter spec.
+func catalogQueryParameter() map[string]interface{} {
+	return map[string]interface{}{
+		"name":        "catalog",
+		"in":          "query",
+		"description": "Attached catalog (database) containing the table. Permissions apply to catalog.table.",
+		"schema": map[string]interface{}{
+			"type": "string",
+		},
+	}
+}
+
+// debugSQLQueryParameter returns the debug_sql query parameter spec.
+func debugSQLQueryParameter() map[string]interface{} {
+	return map[string]interface{}{
+		"name":        "debug_sql",
+		"in":          "query",
+		"description": "If true, adds the generated SQL and bound parameters to the response in a debug object. Only honored for roles listed in the debug_sql config; sensitive values are redacted.",
+		"schema": map[string]interface{}{
+			"type":    "boolean",
+			"default": false,
+		},
+	}
+}
+
+// preferHeaderParameter returns the spec of the Prefer header of mutations.
+func preferHeaderParameter() map[string]interface{} {
+	return map[string]interface{}{
+		"name":        "Prefer",
+		"in":          "header",
+		"description": "return=minimal answers a successful mutation with 204 No Content instead of a JSON body",
+		"schema": map[string]interface{}{
+			"type": "string",
+			"enum": []string{"return=minimal"},
+		},
+	}
+}
+
+// noContentQueryParameter returns the spec of the no_content query parameter.
+func noContentQueryParameter() map[string]interface{} {
+	return map[string]interface{}{
+		"name":        "no_content",
+		"in":          "query",
+		"description": "If true, a successful mutation returns 204 No Content instead of a JSON body, like Prefer: return=minimal",
+		"schema": map[string]interface{}{
+			"type":    "boolean",
+			"default": false,
+		},
+	}
+}
+
+// noContentResponse returns the spec of the 204 response to minimal mutations.
+func noContentResponse() map[string]interface{} {
+	return map[string]interface{}{
+		"description": "Success without a body (Prefer: return=minimal or no_content=true)",
+	}
+}
+
+// parquetCompressionQueryParameter returns the spec of the compression query parameter.
+func parquetCompressionQueryParameter() map[string]interface{} {
+	return map[string]interface{}{
+		"name":        "compression",
+		"in":          "query",
+		"description": "Compression codec of Parquet responses. Can also be requested with an Accept header parameter, e.g. application/parquet; compression=zstd. Ignored for other formats.",
+		"schema": map[string]interface{}{
+			"type":    "string",
+			"enum":    []string{"zstd", "snappy", "gzip", "none"},
+			"default": "snappy",
+		},
+	}
+}
+
+// querySelectQueryParameter returns the spec of the select query parameter of
+// raw SQL queries.
+func querySelectQueryParameter() map[string]interface{} {
+	return map[string]interface{}{
+		"name":        "select",
+		"in":          "query",
+		"description": "Comma-separated subset of the query's result columns to return, in order. Only for SELECT and WITH queries; unknown columns return 400.",
+		"schema": map[string]interface{}{
+			"type": "string",
+		},
+		"example": "id,name",
+	}
+}
+
+// csvDelimiterQueryParameter returns the spec of the delimiter query parameter.
+func csvDelimiterQueryParameter() map[string]interface{} {
+	return map[string]interface{}{
+		"name":        "delimiter",
+		"in":          "query",
+		"description": "Field delimiter of CSV responses, as a name or the character itself (e.g. %3B for a semicolon). Ignored for other formats.",
+		"schema": map[string]interface{}{
+			"type":    "string",
+			"enum":    []string{"comma", "semicolon", "tab", "pipe", ",", ";", "\t", "|"},
+			"default": "comma",
+		},
+	}
+}
+
+// csvQuoteAllQueryParameter returns the spec of the quote_all query parameter.
+func csvQuoteAllQueryParameter() map[string]interface{} {
+	return map[string]interface{}{
+		"name":        "quote_all",
+		"in":          "query",
+		"description": "Quote every field of CSV responses, not only those containing the delimiter, a quote, or a line break. Ignored for other formats.",
+		"schema": map[string]interface{}{
+			"type":    "boolean",
+			"default": false,
+		},
+	}
+}
+
+// successResponseRef returns a JSON response spec referencing SuccessResponse.
+func successResponseRef(description string) map[string]interface{} {
+	return map[string]interface{}{
+		"description": description,
+		"content": map[string]interface{}{
+			"application/json": map[string]interface{}{
+				"schema": map[string]interface{}{
+					"$ref": "#/components/schemas/SuccessResponse",
 				},
 			},
-			"403": map[string]interface{}{
-				"description": "Forbidden",
-				"content": map[string]interface{}{
-					"application/json": map[string]interface{}{
-						"schema": map[string]interface{}{
-							"$ref": "#/components/schemas/ErrorResponse",
-						},
-					},
+		},
+	}
+}
+
+// errorResponseRef returns a JSON response spec referencing ErrorResponse.
+func errorResponseRef(description string) map[string]interface{} {
+	return map[string]interface{}{
+		"description": description,
+		"content": map[string]interface{}{
+			"application/json": map[string]interface{}{
+				"schema": map[string]interface{}{
+					"$ref": "#/components/schemas/ErrorResponse",
 				},
 			},
 		},
@@ -490,6 +1786,7 @@ func (h *OpenAPIHandler) generateQueryPostOperation() map[string]interface{} {
 		"security": []map[string]interface{}{
 			{"ApiKeyAuth": []string{}},
 		},
+		"parameters": []map[string]interface{}{debugSQLQueryParameter(), querySelectQueryParameter(), parquetCompressionQueryParameter(), csvDelimiterQueryParameter(), csvQuoteAllQueryParameter()},
 		"requestBody": map[string]interface{}{
 			"required":    true,
 			"description": "SQL query and optional parameters",
@@ -527,14 +1824,19 @@ func (h *OpenAPIHandler) generateQueryPostOperation() map[string]interface{} {
 							"format": "binary",
 						},
 					},
+					"application/x-ndjson": map[string]interface{}{
+						"schema": map[string]interface{}{
+							"type": "string",
+						},
+					},
 				},
 			},
 			"400": map[string]interface{}{
-				"description": "Bad request",
+				"description": "Bad request or SQL syntax error",
 				"content": map[string]interface{}{
 					"application/json": map[string]interface{}{
 						"schema": map[string]interface{}{
-							"$ref": "#/components/schemas/ErrorResponse",
+							"$ref": "#/components/schemas/QueryErrorResponse",
 						},
 					},
 				},
@@ -590,9 +1892,13 @@ func (h *OpenAPIHandler) generateQueryGetOperation() map[string]interface{} {
 				"description": "Response format",
 				"schema": map[string]interface{}{
 					"type": "string",
-					"enum": []string{"json", "csv", "parquet", "arrow"},
+					"enum": []string{"json", "csv", "parquet", "arrow", "ndjson"},
 				},
 			},
+			querySelectQueryParameter(),
+			parquetCompressionQueryParameter(),
+			csvDelimiterQueryParameter(),
+			csvQuoteAllQueryParameter(),
 		},
 		"responses": map[string]interface{}{
 			"200": map[string]interface{}{
@@ -620,6 +1926,11 @@ func (h *OpenAPIHandler) generateQueryGetOperation() map[string]interface{} {
 							"format": "binary",
 						},
 					},
+					"application/x-ndjson": map[string]interface{}{
+						"schema": map[string]interface{}{
+							"type": "string",
+						},
+					},
 				},
 			},
 			"400": map[string]interface{}{
@@ -670,12 +1981,7 @@ func (h *OpenAPIHandler) generateQueryGetOperation() map[string]interface{} {
 func (h *OpenAPIHandler) generateComponents() map[string]interface{} {
 	return map[string]interface{}{
 		"securitySchemes": map[string]interface{}{
-			"ApiKeyAuth": map[string]interface{}{
-				"type":        "apiKey",
-				"in":          "header",
-				"name":        "X-API-Key",
-				"description": "API key for authentication. Create keys in the auth database.",
-			},
+			"ApiKeyAuth": h.apiKeySecurityScheme(),
 		},
 		"schemas": map[string]interface{}{
 			"ErrorResponse": map[string]interface{}{
@@ -724,9 +2030,71 @@ func (h *OpenAPIHandler) generateComponents() map[string]interface{} {
 					},
 				},
 			},
+			"QueryErrorResponse": map[string]interface{}{
+				"description": "Error response; SQL syntax errors include the error location and hint",
+				"allOf": []map[string]interface{}{
+					{"$ref": "#/components/schemas/ErrorResponse"},
+					{
+						"type": "object",
+						"properties": map[string]interface{}{
+							"category": map[string]interface{}{
+								"type":        "string",
+								"description": "Error category (syntax)",
+								"example":     "syntax",
+							},
+							"position": map[string]interface{}{
+								"type":        "integer",
+								"description": "1-based character offset of the error in the query",
+								"example":     10,
+							},
+							"line": map[string]interface{}{
+								"type":        "integer",
+								"description": "1-based line of the error in the query",
+								"example":     1,
+							},
+							"column": map[string]interface{}{
+								"type":        "integer",
+								"description": "1-based column of the error in the query",
+								"example":     10,
+							},
+							"hint": map[string]interface{}{
+								"type":        "string",
+								"description": "Suggestion from DuckDB, if any",
+								"example":     "Did you mean \"users\"?",
+							},
+						},
+					},
+				},
+			},
+			"CreateDryRunResponse": map[string]interface{}{
+				"type":     "object",
+				"required": []string{"dry_run", "would_succeed", "errors", "request_id"},
+				"properties": map[string]interface{}{
+					"dry_run": map[string]interface{}{
+						"type":    "boolean",
+						"example": true,
+					},
+					"would_succeed": map[string]interface{}{
+						"type":        "boolean",
+						"description": "Whether the record would be inserted",
+						"example":     false,
+					},
+					"errors": map[string]interface{}{
+						"type":        "array",
+						"description": "Problems that would make the insert fail: unknown columns, validation rule and JSON Schema violations, and type or constraint errors reported by the database",
+						"items":       map[string]interface{}{"type": "string"},
+						"example":     []string{"Insert would fail (constraint): Duplicate key \"id: 1\" violates primary key constraint"},
+					},
+					"request_id": map[string]interface{}{
+						"type":        "string",
+						"description": "Unique request identifier for tracing",
+						"example":     "550e8400-e29b-41d4-a716-446655440000",
+					},
+				},
+			},
 			"DryRunResponse": map[string]interface{}{
 				"type":     "object",
-				"required": []string{"dry_run", "affected_rows", "request_id"},
+				"required": []string{"dry_run", "affected_rows", "request_id", "where"},
 				"properties": map[string]interface{}{
 					"dry_run": map[string]interface{}{
 						"type":    "boolean",
@@ -742,6 +2110,17 @@ func (h *OpenAPIHandler) generateComponents() map[string]interface{} {
 						"description": "Unique request identifier for tracing",
 						"example":     "550e8400-e29b-41d4-a716-446655440000",
 					},
+					"where": map[string]interface{}{
+						"type":        "string",
+						"description": "Parameterized WHERE clause that would be applied (values are in params)",
+						"example":     "status = $1 AND deleted_at IS NULL",
+					},
+					"params": map[string]interface{}{
+						"type":        "array",
+						"description": "Values bound to the WHERE clause placeholders, in order",
+						"items":       map[string]interface{}{},
+						"example":     []interface{}{"inactive"},
+					},
 				},
 			},
 			"ReadResponse": map[string]interface{}{
@@ -804,6 +2183,11 @@ func (h *OpenAPIHandler) generateComponents() map[string]interface{} {
 						"description": "Total number of pages",
 						"example":     3,
 					},
+					"has_next": map[string]interface{}{
+						"type":        "boolean",
+						"description": "Whether a page follows the current one",
+						"example":     true,
+					},
 				},
 			},
 			"HATEOASLinks": map[string]interface{}{
@@ -866,6 +2250,33 @@ func (h *OpenAPIHandler) generateComponents() map[string]interface{} {
 					},
 				},
 			},
+			"UpsertRequest": map[string]interface{}{
+				"type":     "object",
+				"required": []string{"data"},
+				"properties": map[string]interface{}{
+					"data": map[string]interface{}{
+						"description": "A row object or an array of row objects",
+						"oneOf": []map[string]interface{}{
+							{"type": "object", "additionalProperties": true},
+							{"type": "array", "items": map[string]interface{}{"type": "object", "additionalProperties": true}},
+						},
+					},
+					"conflict": map[string]interface{}{
+						"type":        "array",
+						"description": "Columns of the primary key or unique constraint to match existing rows on. Defaults to the primary key.",
+						"items": map[string]interface{}{
+							"type": "string",
+						},
+					},
+				},
+				"example": map[string]interface{}{
+					"data": []map[string]interface{}{
+						{"id": 1, "status": "active"},
+						{"id": 2, "status": "new"},
+					},
+					"conflict": []string{"id"},
+				},
+			},
 			"FilterCondition": map[string]interface{}{
 				"type":     "object",
 				"required": []string{"column", "op", "value"},
@@ -968,3 +2379,25 @@ func (h *OpenAPIHandler) generateComponents() map[string]interface{} {
 		},
 	}
 }
+
+// apiKeySecurityScheme describes how the API key is sent: as a bearer token
+// when the configured header is Authorization, otherwise in the named header.
+func (h *OpenAPIHandler) apiKeySecurityScheme() map[string]interface{} {
+	header := h.apiKeyHeader
+	if header == "" {
+		header = auth.DefaultAPIKeyHeader
+	}
+	if strings.EqualFold(header, "Authorization") {
+		return map[string]interface{}{
+			"type":        "http",
+			"scheme":      "bearer",
+			"description": "API key sent as a bearer token. Create keys in the auth database.",
+		}
+	}
+	return map[string]interface{}{
+		"type":        "apiKey",
+		"in":          "header",
+		"name":        header,
+		"description": "API key for authentication. Create keys in the auth database.",
+	}
+}
@@ -0,0 +1,186 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"strings"
+
+	"github.com/tobilg/caddy-duckdb-module/auth"
+	"github.com/tobilg/caddy-duckdb-module/database"
+)
+
+// SetTableSource lets the handler describe the database's actual tables for
+// requests with tables=true (see ParseOpenAPITables). Without it such requests
+// are rejected.
+func (h *OpenAPIHandler) SetTableSource(dbMgr *database.Manager, authorizer *auth.Authorizer) {
+	h.dbMgr = dbMgr
+	h.authorizer = authorizer
+}
+
+// ParseOpenAPITables checks if the tables parameter is set to true. When true,
+// the OpenAPI specification adds a concrete path per table the role can access.
+func ParseOpenAPITables(r *http.Request) bool {
+	tables := r.URL.Query().Get("tables")
+	return tables == "true" || tables == "1"
+}
+
+// tableOperation is a CRUD operation documented on a concrete table path.
+type tableOperation struct {
+	method    string
+	operation auth.Operation
+	generate  func() map[string]interface{}
+	// also is a second operation the role needs, if any
+	also auth.Operation
+}
+
+// addTablePaths adds a /api/<table> path for every table and view of the main
+// database on which the role has at least one CRUD permission, with only the
+// permitted operations, and a <table>Record schema for its columns.
+func (h *OpenAPIHandler) addTablePaths(ctx context.Context, spec map[string]interface{}, role string) error {
+	tables, err := h.dbMgr.TableSchemas(ctx)
+	if err != nil {
+		return err
+	}
+
+	paths := spec["paths"].(map[string]interface{})
+	schemas := spec["components"].(map[string]interface{})["schemas"].(map[string]interface{})
+	operations := []tableOperation{
+		{"get", auth.OperationRead, h.generateReadOperation, ""},
+		{"post", auth.OperationCreate, h.generateCreateOperation, ""},
+		{"put", auth.OperationUpdate, h.generateUpdateOperation, ""},
+		{"patch", auth.OperationCreate, h.generateUpsertOperation, auth.OperationUpdate},
+		{"delete", auth.OperationDelete, h.generateDeleteOperation, ""},
+	}
+
+	for _, table := range tables {
+		if auth.IsInternalTable(table.Name) {
+			continue
+		}
+		schemaName := table.Name + "Record"
+		recordRef := map[string]interface{}{"$ref": "#/components/schemas/" + schemaName}
+
+		item := make(map[string]interface{})
+		for _, op := range operations {
+			allowed, err := h.authorizer.CheckPermission(role, table.Name, op.operation)
+			if err != nil {
+				return err
+			}
+			if allowed && op.also != "" {
+				if allowed, err = h.authorizer.CheckPermission(role, table.Name, op.also); err != nil {
+					return err
+				}
+			}
+			if !allowed {
+				continue
+			}
+			operation := op.generate()
+			operation["tags"] = []string{table.Name}
+			operation["operationId"] = operation["operationId"].(string) + "_" + table.Name
+			switch op.method {
+			case "get":
+				withReadRecords(operation, recordRef)
+			case "post":
+				withCreateRecords(operation, recordRef)
+			}
+			item[op.method] = operation
+		}
+		if len(item) == 0 {
+			continue
+		}
+
+		paths["/api/"+table.Name] = item
+		schemas[schemaName] = recordSchema(table.Columns, h.decimalAsNumber)
+	}
+	return nil
+}
+
+// withReadRecords documents the rows of a read response as the table's records.
+func withReadRecords(operation, recordRef map[string]interface{}) {
+	content := operation["responses"].(map[string]interface{})["200"].(map[string]interface{})["content"].(map[string]interface{})
+	content["application/json"] = map[string]interface{}{
+		"schema": map[string]interface{}{
+			"allOf": []map[string]interface{}{
+				{"$ref": "#/components/schemas/ReadResponse"},
+				{
+					"type": "object",
+					"properties": map[string]interface{}{
+						"data": map[string]interface{}{
+							"type":  "array",
+							"items": recordRef,
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+// withCreateRecords documents the body of a create request as one or more of the table's records.
+func withCreateRecords(operation, recordRef map[string]interface{}) {
+	content := operation["requestBody"].(map[string]interface{})["content"].(map[string]interface{})
+	content["application/json"] = map[string]interface{}{
+		"schema": map[string]interface{}{
+			"oneOf": []map[string]interface{}{
+				recordRef,
+				{"type": "array", "items": recordRef},
+			},
+		},
+	}
+}
+
+// recordSchema returns the object schema of a table's rows.
+func recordSchema(columns []database.ColumnSchema, decimalAsNumber bool) map[string]interface{} {
+	properties := make(map[string]interface{}, len(columns))
+	for _, col := range columns {
+		schema := columnTypeSchema(col.Type, decimalAsNumber)
+		if col.Nullable {
+			schema["nullable"] = true
+		}
+		properties[col.Name] = schema
+	}
+	return map[string]interface{}{
+		"type":       "object",
+		"properties": properties,
+	}
+}
+
+// columnTypeSchema maps a DuckDB column type to the JSON schema of its values in
+// JSON responses. Types without a JSON counterpart (JSON, STRUCT, MAP, UNION)
+// allow any value. DECIMAL values are strings unless decimalAsNumber is set.
+func columnTypeSchema(dataType string, decimalAsNumber bool) map[string]interface{} {
+	dataType = strings.ToUpper(strings.TrimSpace(dataType))
+	if strings.HasSuffix(dataType, "[]") {
+		return map[string]interface{}{
+			"type":  "array",
+			"items": columnTypeSchema(strings.TrimSuffix(dataType, "[]"), decimalAsNumber),
+		}
+	}
+	switch {
+	case dataType == "BOOLEAN":
+		return map[string]interface{}{"type": "boolean"}
+	case dataType == "TINYINT", dataType == "SMALLINT", dataType == "INTEGER", dataType == "UTINYINT", dataType == "USMALLINT":
+		return map[string]interface{}{"type": "integer", "format": "int32"}
+	case dataType == "BIGINT", dataType == "UINTEGER", dataType == "UBIGINT":
+		return map[string]interface{}{"type": "integer", "format": "int64"}
+	case dataType == "HUGEINT", dataType == "UHUGEINT":
+		return map[string]interface{}{"type": "integer"}
+	case dataType == "FLOAT":
+		return map[string]interface{}{"type": "number", "format": "float"}
+	case dataType == "DOUBLE":
+		return map[string]interface{}{"type": "number", "format": "double"}
+	case strings.HasPrefix(dataType, "DECIMAL") && decimalAsNumber:
+		return map[string]interface{}{"type": "number"}
+	case strings.HasPrefix(dataType, "DECIMAL"):
+		return map[string]interface{}{"type": "string", "format": "decimal"}
+	case dataType == "DATE":
+		return map[string]interface{}{"type": "string", "format": "date"}
+	case strings.HasPrefix(dataType, "TIMESTAMP"):
+		return map[string]interface{}{"type": "string", "format": "date-time"}
+	case dataType == "UUID":
+		return map[string]interface{}{"type": "string", "format": "uuid"}
+	case strings.HasPrefix(dataType, "VARCHAR"), strings.HasPrefix(dataType, "ENUM"),
+		dataType == "TIME", dataType == "INTERVAL", dataType == "BLOB":
+		return map[string]interface{}{"type": "string"}
+	}
+	return map[string]interface{}{}
+}
@@ -0,0 +1,151 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/tobilg/caddy-duckdb-module/auth"
+)
+
+// tableSpec requests the OpenAPI specification with table paths as the role.
+func tableSpec(t *testing.T, handler *OpenAPIHandler, role string) map[string]interface{} {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodGet, "/openapi.json?tables=true", nil)
+	req = addAuthContext(req, role)
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var spec map[string]interface{}
+	if err := json.Unmarshal(rec.Body.Bytes(), &spec); err != nil {
+		t.Fatalf("Failed to decode spec: %v", err)
+	}
+	return spec
+}
+
+func TestOpenAPIHandler_TablePaths(t *testing.T) {
+	_, mgr, cleanup := setupTestHandler(t)
+	defer cleanup()
+	if _, err := mgr.ExecMain(`CREATE TABLE orders (id BIGINT NOT NULL, total DECIMAL(10, 2), placed_at TIMESTAMP, tags VARCHAR[])`); err != nil {
+		t.Fatalf("Failed to create table: %v", err)
+	}
+
+	handler := NewOpenAPIHandler()
+	handler.SetTableSource(mgr, auth.NewAuthorizer(mgr.AuthDB()))
+
+	spec := tableSpec(t, handler, "admin")
+	paths := spec["paths"].(map[string]interface{})
+	for _, path := range []string{"/api/test_users", "/api/orders", "/api/{table}"} {
+		if _, ok := paths[path]; !ok {
+			t.Errorf("Expected path %s", path)
+		}
+	}
+	users := paths["/api/test_users"].(map[string]interface{})
+	for _, method := range []string{"get", "post", "put", "delete"} {
+		if _, ok := users[method]; !ok {
+			t.Errorf("Expected admin to have %s on /api/test_users", method)
+		}
+	}
+	if id := users["get"].(map[string]interface{})["operationId"]; id != "readRecords_test_users" {
+		t.Errorf("Expected a table-specific operationId, got %v", id)
+	}
+
+	// Column schemas are reflected in the record schema
+	schemas := spec["components"].(map[string]interface{})["schemas"].(map[string]interface{})
+	orders, ok := schemas["ordersRecord"].(map[string]interface{})
+	if !ok {
+		t.Fatal("Expected an ordersRecord schema")
+	}
+	props := orders["properties"].(map[string]interface{})
+	id := props["id"].(map[string]interface{})
+	if id["type"] != "integer" || id["format"] != "int64" || id["nullable"] != nil {
+		t.Errorf("Unexpected id schema: %v", id)
+	}
+	if total := props["total"].(map[string]interface{}); total["type"] != "string" || total["nullable"] != true {
+		t.Errorf("Unexpected total schema: %v", total)
+	}
+	if placed := props["placed_at"].(map[string]interface{}); placed["format"] != "date-time" {
+		t.Errorf("Unexpected placed_at schema: %v", placed)
+	}
+	if tags := props["tags"].(map[string]interface{}); tags["type"] != "array" || tags["items"].(map[string]interface{})["type"] != "string" {
+		t.Errorf("Unexpected tags schema: %v", tags)
+	}
+
+	// Reads reference the record schema
+	read, _ := json.Marshal(users["get"])
+	if !strings.Contains(string(read), `"#/components/schemas/test_usersRecord"`) {
+		t.Errorf("Expected the read response to reference test_usersRecord: %s", read)
+	}
+
+	// The plain specification has no table paths
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/openapi.json", nil))
+	var plain map[string]interface{}
+	json.Unmarshal(rec.Body.Bytes(), &plain)
+	if _, ok := plain["paths"].(map[string]interface{})["/api/test_users"]; ok {
+		t.Error("Expected no table paths without tables=true")
+	}
+}
+
+func TestOpenAPIHandler_TablePathsByRole(t *testing.T) {
+	_, mgr, cleanup := setupTestHandler(t)
+	defer cleanup()
+
+	handler := NewOpenAPIHandler()
+	handler.SetTableSource(mgr, auth.NewAuthorizer(mgr.AuthDB()))
+
+	// The reader role only sees the read operation
+	paths := tableSpec(t, handler, "reader")["paths"].(map[string]interface{})
+	users, ok := paths["/api/test_users"].(map[string]interface{})
+	if !ok {
+		t.Fatal("Expected /api/test_users for reader")
+	}
+	if len(users) != 1 || users["get"] == nil {
+		t.Errorf("Expected only get for reader, got %v", users)
+	}
+
+	// A role without permissions sees no tables
+	paths = tableSpec(t, handler, "nobody")["paths"].(map[string]interface{})
+	if _, ok := paths["/api/test_users"]; ok {
+		t.Error("Expected no table paths for a role without permissions")
+	}
+}
+
+func TestOpenAPIHandler_TablePathsUnavailable(t *testing.T) {
+	handler := NewOpenAPIHandler()
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/openapi.json?tables=true", nil))
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400 without a table source, got %d", rec.Code)
+	}
+}
+
+func TestColumnTypeSchema(t *testing.T) {
+	tests := map[string]string{
+		"BOOLEAN":                  "boolean",
+		"INTEGER":                  "integer",
+		"UBIGINT":                  "integer",
+		"DOUBLE":                   "number",
+		"DECIMAL(18,3)":            "string",
+		"VARCHAR":                  "string",
+		"DATE":                     "string",
+		"TIMESTAMP WITH TIME ZONE": "string",
+		"UUID":                     "string",
+		"INTEGER[]":                "array",
+	}
+	for dataType, want := range tests {
+		if got := columnTypeSchema(dataType, false)["type"]; got != want {
+			t.Errorf("columnTypeSchema(%q) type = %v, want %s", dataType, got, want)
+		}
+	}
+	if got := columnTypeSchema("DECIMAL(18,3)", true)["type"]; got != "number" {
+		t.Errorf("Expected DECIMAL as number with decimalAsNumber, got %v", got)
+	}
+	if schema := columnTypeSchema("STRUCT(a INTEGER)", false); len(schema) != 0 {
+		t.Errorf("Expected STRUCT to allow any value, got %v", schema)
+	}
+}
@@ -4,7 +4,10 @@ import (
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
+	"reflect"
 	"testing"
+
+	"gopkg.in/yaml.v3"
 )
 
 func TestNewOpenAPIHandler(t *testing.T) {
@@ -206,7 +209,7 @@ func TestOpenAPIHandler_Spec_Paths(t *testing.T) {
 	}
 
 	// Verify expected paths exist
-	expectedPaths := []string{"/openapi.json", "/api/{table}", "/query", "/query/{sql}/result.{format}"}
+	expectedPaths := []string{"/openapi.json", "/openapi.yaml", "/api/{table}", "/api/{table}/restore", "/api/{table}/purge", "/api/{table}/changes", "/api/{table}/timeseries", "/api/{table}/cardinality", "/api/{table}/download-token", "/download/{token}", "/transaction", "/query", "/query/{sql}/result.{format}", "/query/batch", "/query/sse"}
 	for _, path := range expectedPaths {
 		if _, ok := paths[path]; !ok {
 			t.Errorf("Expected path '%s' not found", path)
@@ -264,7 +267,7 @@ func TestOpenAPIHandler_Spec_CRUDOperations(t *testing.T) {
 		}
 	}
 
-	expectedParams := []string{"page", "limit", "filter", "sort", "links"}
+	expectedParams := []string{"page", "limit", "filter", "sort", "links", "debug_sql"}
 	for _, name := range expectedParams {
 		if !paramNames[name] {
 			t.Errorf("Expected parameter '%s' in GET /api/{table}", name)
@@ -344,6 +347,32 @@ func TestOpenAPIHandler_Spec_Components(t *testing.T) {
 	}
 }
 
+func TestOpenAPIHandler_Spec_APIKeyHeader(t *testing.T) {
+	scheme := func(header string) map[string]interface{} {
+		handler := NewOpenAPIHandler()
+		handler.SetAPIKeyHeader(header)
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/openapi.json", nil))
+
+		var spec struct {
+			Components struct {
+				SecuritySchemes map[string]map[string]interface{} `json:"securitySchemes"`
+			} `json:"components"`
+		}
+		if err := json.NewDecoder(rec.Body).Decode(&spec); err != nil {
+			t.Fatalf("Failed to decode response: %v", err)
+		}
+		return spec.Components.SecuritySchemes["ApiKeyAuth"]
+	}
+
+	if s := scheme("X-Gateway-Key"); s["type"] != "apiKey" || s["in"] != "header" || s["name"] != "X-Gateway-Key" {
+		t.Errorf("Expected an apiKey scheme for X-Gateway-Key, got %v", s)
+	}
+	if s := scheme("Authorization"); s["type"] != "http" || s["scheme"] != "bearer" || s["name"] != nil {
+		t.Errorf("Expected a bearer scheme for Authorization, got %v", s)
+	}
+}
+
 func TestOpenAPIHandler_Spec_Schemas(t *testing.T) {
 	handler := NewOpenAPIHandler()
 
@@ -368,6 +397,7 @@ func TestOpenAPIHandler_Spec_Schemas(t *testing.T) {
 		"ErrorResponse",
 		"SuccessResponse",
 		"DryRunResponse",
+		"CreateDryRunResponse",
 		"ReadResponse",
 		"Pagination",
 		"HATEOASLinks",
@@ -549,3 +579,57 @@ func BenchmarkOpenAPIHandler_ServeHTTP(b *testing.B) {
 		handler.ServeHTTP(rec, req)
 	}
 }
+
+func TestOpenAPIHandler_ServeHTTP_YAML(t *testing.T) {
+	handler := NewOpenAPIHandler()
+
+	get := func(target, accept string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, target, nil)
+		if accept != "" {
+			req.Header.Set("Accept", accept)
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec
+	}
+
+	var jsonSpec interface{}
+	if err := json.Unmarshal(get("/openapi.json", "").Body.Bytes(), &jsonSpec); err != nil {
+		t.Fatalf("Failed to decode JSON spec: %v", err)
+	}
+
+	for _, tc := range []struct{ target, accept string }{
+		{"/openapi.yaml", ""},
+		{"/openapi.json?format=yaml", ""},
+		{"/openapi.json", "application/yaml"},
+		{"/openapi.json", "text/html, application/x-yaml;q=0.9"},
+	} {
+		rec := get(tc.target, tc.accept)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("%s (Accept %q): expected status 200, got %d", tc.target, tc.accept, rec.Code)
+		}
+		if ct := rec.Header().Get("Content-Type"); ct != YAMLContentType {
+			t.Errorf("%s (Accept %q): expected Content-Type %s, got %s", tc.target, tc.accept, YAMLContentType, ct)
+		}
+
+		// Round-trip the YAML through JSON so numbers compare like the JSON spec's
+		var yamlSpec interface{}
+		if err := yaml.Unmarshal(rec.Body.Bytes(), &yamlSpec); err != nil {
+			t.Fatalf("Failed to decode YAML spec: %v", err)
+		}
+		data, err := json.Marshal(yamlSpec)
+		if err != nil {
+			t.Fatalf("Failed to re-encode YAML spec: %v", err)
+		}
+		var roundTripped interface{}
+		json.Unmarshal(data, &roundTripped)
+		if !reflect.DeepEqual(roundTripped, jsonSpec) {
+			t.Errorf("%s (Accept %q): YAML spec does not match the JSON spec", tc.target, tc.accept)
+		}
+	}
+
+	// format=json wins over the Accept header
+	if ct := get("/openapi.json?format=json", "application/yaml").Header().Get("Content-Type"); ct != "application/json" {
+		t.Errorf("Expected format=json to return JSON, got %s", ct)
+	}
+}
@@ -0,0 +1,94 @@
+package handlers
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// Pagination styles for PaginationPolicy.
+const (
+	// PaginationStylePage allows page/limit pagination to any depth (default).
+	PaginationStylePage = "page"
+	// PaginationStyleCursor only allows page/limit up to MaxOffset; deeper reads
+	// must page by filtering past the last row seen (keyset pagination).
+	PaginationStyleCursor = "cursor"
+)
+
+// PaginationPolicy restricts how deep clients may page with page/limit. Each
+// page is read with an OFFSET, which DuckDB serves by scanning and discarding
+// every skipped row, so deep pages of large tables are expensive.
+type PaginationPolicy struct {
+	// Style is PaginationStylePage or PaginationStyleCursor. Default is page.
+	Style string `json:"style,omitempty"`
+
+	// MaxOffset is the largest OFFSET (rows skipped) page/limit may produce on a
+	// cursor-style table. Default is 0: only the first page.
+	MaxOffset int `json:"max_offset,omitempty"`
+}
+
+// Validate checks the style and offset limit.
+func (p *PaginationPolicy) Validate() error {
+	switch strings.ToLower(p.Style) {
+	case "", PaginationStylePage, PaginationStyleCursor:
+	default:
+		return fmt.Errorf("invalid pagination style: %s (must be 'page' or 'cursor')", p.Style)
+	}
+	if p.MaxOffset < 0 {
+		return fmt.Errorf("pagination max_offset must be >= 0")
+	}
+	return nil
+}
+
+// SetPaginationPolicy sets the pagination policy for tables without their own.
+func (h *CRUDHandler) SetPaginationPolicy(policy *PaginationPolicy) {
+	h.pagination = policy
+}
+
+// paginationPolicy returns the table's pagination policy, falling back to the
+// global one. Nil means page/limit is unrestricted.
+func (h *CRUDHandler) paginationPolicy(tableName string) *PaginationPolicy {
+	if cfg := h.tables[tableName]; cfg != nil && cfg.Pagination != nil {
+		return cfg.Pagination
+	}
+	return h.pagination
+}
+
+// checkPagination returns an error if page/limit would skip more rows than the
+// table's pagination policy allows.
+func (h *CRUDHandler) checkPagination(tableName string, page, offset int) error {
+	policy := h.paginationPolicy(tableName)
+	if policy == nil || !strings.EqualFold(policy.Style, PaginationStyleCursor) || offset <= policy.MaxOffset {
+		return nil
+	}
+	return fmt.Errorf("page %d exceeds the maximum offset of %d rows for table '%s'; use cursor pagination instead: read by a unique column with after=<column> and continue with the returned cursor (e.g. after=id&limit=100, then cursor=<next_cursor>)", page, policy.MaxOffset, tableName)
+}
+
+// NextCursorHeader carries the cursor of the next page of a keyset paginated
+// read, to be sent as the next request's cursor parameter. It is omitted on
+// the last page.
+const NextCursorHeader = "X-Next-Cursor"
+
+// pageCursor is the decoded form of an opaque next_cursor.
+type pageCursor struct {
+	Column string `json:"c"`
+	After  string `json:"a"`
+}
+
+// encodeCursor returns the opaque cursor of the page after the row whose
+// column has the key after.
+func encodeCursor(column, after string) string {
+	data, _ := json.Marshal(pageCursor{Column: column, After: after})
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// decodeCursor parses a cursor returned by encodeCursor.
+func decodeCursor(cursor string) (pageCursor, error) {
+	var c pageCursor
+	data, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil || json.Unmarshal(data, &c) != nil || c.Column == "" {
+		return pageCursor{}, fmt.Errorf("malformed cursor: %s", cursor)
+	}
+	return c, nil
+}
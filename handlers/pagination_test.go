@@ -0,0 +1,314 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestPaginationPolicy_Validate(t *testing.T) {
+	valid := []PaginationPolicy{{}, {Style: "page"}, {Style: "cursor", MaxOffset: 1000}}
+	for _, p := range valid {
+		if err := p.Validate(); err != nil {
+			t.Errorf("Expected %+v to be valid, got %v", p, err)
+		}
+	}
+	invalid := []PaginationPolicy{{Style: "offset"}, {Style: "cursor", MaxOffset: -1}}
+	for _, p := range invalid {
+		if err := p.Validate(); err == nil {
+			t.Errorf("Expected %+v to be invalid", p)
+		}
+	}
+}
+
+func TestCRUDHandler_Read_CursorOnlyTable(t *testing.T) {
+	handler, _, cleanup := setupTestHandler(t)
+	defer cleanup()
+	handler.SetTableConfigs(map[string]*TableConfig{
+		"test_users": {Pagination: &PaginationPolicy{Style: PaginationStyleCursor, MaxOffset: 1}},
+	})
+
+	tests := []struct {
+		name   string
+		query  string
+		status int
+	}{
+		{"no pagination", "", http.StatusOK},
+		{"first page", "?limit=1&page=1", http.StatusOK},
+		{"offset at threshold", "?limit=1&page=2", http.StatusOK},
+		{"offset beyond threshold", "?limit=1&page=3", http.StatusBadRequest},
+		{"deep page", "?limit=1&page=5000", http.StatusBadRequest},
+		{"cursor via filter", "?limit=1&page=1&sort=id:asc&filter=id:gt:2", http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "/duckdb/api/test_users"+tt.query, nil)
+			req = addAuthContext(req, "admin")
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+			if rec.Code != tt.status {
+				t.Fatalf("Expected status %d, got %d: %s", tt.status, rec.Code, rec.Body.String())
+			}
+			if tt.status == http.StatusBadRequest && !strings.Contains(rec.Body.String(), "use cursor pagination") {
+				t.Errorf("Expected the error to suggest cursor pagination, got %s", rec.Body.String())
+			}
+		})
+	}
+}
+
+func TestCRUDHandler_Read_GlobalPaginationPolicy(t *testing.T) {
+	handler, mgr, cleanup := setupTestHandler(t)
+	defer cleanup()
+	if _, err := mgr.ExecMain(`CREATE TABLE test_small (id INTEGER)`); err != nil {
+		t.Fatalf("Failed to create table: %v", err)
+	}
+	if _, err := mgr.ExecMain(`INSERT INTO test_small VALUES (1), (2), (3)`); err != nil {
+		t.Fatalf("Failed to insert rows: %v", err)
+	}
+
+	// Cursor-only by default, but test_small allows page pagination
+	handler.SetPaginationPolicy(&PaginationPolicy{Style: PaginationStyleCursor})
+	handler.SetTableConfigs(map[string]*TableConfig{
+		"test_small": {Pagination: &PaginationPolicy{Style: PaginationStylePage}},
+	})
+
+	req := httptest.NewRequest("GET", "/duckdb/api/test_users?limit=1&page=2", nil)
+	req = addAuthContext(req, "admin")
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("Expected the global cursor policy to reject page 2, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	req = httptest.NewRequest("GET", "/duckdb/api/test_small?limit=1&page=3", nil)
+	req = addAuthContext(req, "admin")
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Errorf("Expected the table policy to allow page 3, got %d: %s", rec.Code, rec.Body.String())
+	}
+}
+
+func TestCursorRoundTrip(t *testing.T) {
+	cursor := encodeCursor("created_at", "2024-01-15 10:30:00")
+	decoded, err := decodeCursor(cursor)
+	if err != nil {
+		t.Fatalf("decodeCursor failed: %v", err)
+	}
+	if decoded.Column != "created_at" || decoded.After != "2024-01-15 10:30:00" {
+		t.Errorf("Unexpected decoded cursor: %+v", decoded)
+	}
+	for _, bad := range []string{"not base64!", "bm90IGpzb24", encodeCursor("", "1")} {
+		if _, err := decodeCursor(bad); err == nil {
+			t.Errorf("Expected error for malformed cursor %q", bad)
+		}
+	}
+}
+
+func TestCRUDHandler_Read_KeysetPagination(t *testing.T) {
+	handler, mgr, cleanup := setupTestHandler(t)
+	defer cleanup()
+	if _, err := mgr.ExecMain(`INSERT INTO test_users VALUES
+		(4, 'Dave', 'dave@example.com', 40),
+		(5, 'Eve', 'eve@example.com', 22),
+		(6, 'Frank', 'frank@example.com', 51),
+		(7, 'Grace', 'grace@example.com', 28)`); err != nil {
+		t.Fatalf("Failed to insert rows: %v", err)
+	}
+
+	read := func(query string) (*httptest.ResponseRecorder, map[string]interface{}) {
+		req := httptest.NewRequest("GET", "/duckdb/api/test_users?"+query, nil)
+		req = addAuthContext(req, "admin")
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		var body map[string]interface{}
+		json.Unmarshal(rec.Body.Bytes(), &body)
+		return rec, body
+	}
+
+	// Walk all pages via cursors: no row is skipped or repeated
+	var ids []float64
+	query := "after=id&limit=3"
+	pages := 0
+	for {
+		rec, body := read(query)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d: %s", rec.Code, rec.Body.String())
+		}
+		pages++
+		for _, row := range body["data"].([]interface{}) {
+			ids = append(ids, row.(map[string]interface{})["id"].(float64))
+		}
+		pagination := body["pagination"].(map[string]interface{})
+		if pagination["limit"] != float64(3) {
+			t.Errorf("Expected limit 3, got %v", pagination["limit"])
+		}
+		next, _ := pagination["next_cursor"].(string)
+		if rec.Header().Get(NextCursorHeader) != next {
+			t.Errorf("Expected %s header %q, got %q", NextCursorHeader, next, rec.Header().Get(NextCursorHeader))
+		}
+		if next == "" {
+			break
+		}
+		if pages > 5 {
+			t.Fatal("Cursor pagination did not terminate")
+		}
+		query = "limit=3&cursor=" + url.QueryEscape(next)
+	}
+	if pages != 3 {
+		t.Errorf("Expected 3 pages, got %d", pages)
+	}
+	if len(ids) != 7 {
+		t.Fatalf("Expected 7 rows across all pages, got %v", ids)
+	}
+	for i, id := range ids {
+		if id != float64(i+1) {
+			t.Fatalf("Expected ids 1-7 in order, got %v", ids)
+		}
+	}
+
+	// An explicit key, combined with a filter
+	rec, body := read("after=id:3&filter=age:lt:45&limit=10")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	data := body["data"].([]interface{})
+	if len(data) != 3 || data[0].(map[string]interface{})["name"] != "Dave" {
+		t.Errorf("Expected Dave, Eve, and Grace, got %v", data)
+	}
+	if body["pagination"].(map[string]interface{})["next_cursor"] != nil {
+		t.Errorf("Expected no next cursor on the last page, got %v", body["pagination"])
+	}
+
+	// Without limit, pages hold max_rows_per_page rows
+	if rec, _ := read("after=name"); rec.Code != http.StatusOK {
+		t.Errorf("Expected status 200 without limit, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	invalid := []string{
+		"after=id&cursor=abc",
+		"after=id&page=2",
+		"after=id&sort=name:asc",
+		"cursor=not-a-cursor",
+		"after=bad%3Bcolumn",
+		"after=id&sample=50%25",
+	}
+	for _, query := range invalid {
+		if rec, _ := read(query); rec.Code != http.StatusBadRequest {
+			t.Errorf("Expected status 400 for %s, got %d: %s", query, rec.Code, rec.Body.String())
+		}
+	}
+}
+
+func TestCRUDHandler_Read_KeysetLinks(t *testing.T) {
+	handler, mgr, cleanup := setupTestHandler(t)
+	defer cleanup()
+	if _, err := mgr.ExecMain(`INSERT INTO test_users VALUES
+		(4, 'Dave', 'dave@example.com', 40),
+		(5, 'Eve', 'eve@example.com', 22)`); err != nil {
+		t.Fatalf("Failed to insert rows: %v", err)
+	}
+
+	// follow reads a link and returns the page's ids and links
+	follow := func(link string) ([]float64, map[string]interface{}) {
+		t.Helper()
+		req := httptest.NewRequest("GET", link, nil)
+		req = addAuthContext(req, "admin")
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("Expected status 200 for %s, got %d: %s", link, rec.Code, rec.Body.String())
+		}
+		var body map[string]interface{}
+		if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+			t.Fatalf("Failed to parse response: %v", err)
+		}
+		var ids []float64
+		for _, row := range body["data"].([]interface{}) {
+			ids = append(ids, row.(map[string]interface{})["id"].(float64))
+		}
+		links, ok := body["_links"].(map[string]interface{})
+		if !ok {
+			t.Fatalf("Expected _links for %s, got %v", link, body)
+		}
+		return ids, links
+	}
+
+	// Walk forward by next links: pages [1 2] [3 4] [5], without overlap
+	var pages [][]float64
+	var pageLinks []map[string]interface{}
+	link := "/duckdb/api/test_users?after=id&limit=2&links=true"
+	for link != "" {
+		if len(pages) > 5 {
+			t.Fatal("Following next links did not terminate")
+		}
+		ids, links := follow(link)
+		pages = append(pages, ids)
+		pageLinks = append(pageLinks, links)
+		link, _ = links["next"].(string)
+		if link != "" {
+			next, err := url.Parse(link)
+			if err != nil {
+				t.Fatalf("Failed to parse next link: %v", err)
+			}
+			if _, err := decodeCursor(next.Query().Get("cursor")); err != nil {
+				t.Errorf("Expected next link to carry a valid cursor, got %s: %v", link, err)
+			}
+		}
+	}
+	want := [][]float64{{1, 2}, {3, 4}, {5}}
+	if fmt.Sprint(pages) != fmt.Sprint(want) {
+		t.Fatalf("Expected pages %v, got %v", want, pages)
+	}
+	for i, links := range pageLinks {
+		if links["first"] != "/duckdb/api/test_users?after=id&limit=2&links=true" {
+			t.Errorf("Page %d: expected first link to the first page, got %v", i+1, links["first"])
+		}
+		if _, ok := links["last"]; ok {
+			t.Errorf("Page %d: expected no last link, got %v", i+1, links)
+		}
+	}
+	if _, ok := pageLinks[0]["prev"]; ok {
+		t.Errorf("Expected no prev link on the first page, got %v", pageLinks[0])
+	}
+
+	// prev links lead back to the preceding page
+	for i := len(pages) - 1; i > 0; i-- {
+		prev, ok := pageLinks[i]["prev"].(string)
+		if !ok {
+			t.Fatalf("Page %d: expected a prev link, got %v", i+1, pageLinks[i])
+		}
+		ids, _ := follow(prev)
+		if fmt.Sprint(ids) != fmt.Sprint(pages[i-1]) {
+			t.Errorf("Page %d: expected prev to return %v, got %v", i+1, pages[i-1], ids)
+		}
+	}
+
+	// self repeats the current page
+	ids, _ := follow(pageLinks[1]["self"].(string))
+	if fmt.Sprint(ids) != fmt.Sprint(pages[1]) {
+		t.Errorf("Expected self to return %v, got %v", pages[1], ids)
+	}
+}
+
+func TestCRUDHandler_Read_KeysetSortable(t *testing.T) {
+	handler, _, cleanup := setupTestHandler(t)
+	defer cleanup()
+	handler.SetTableConfigs(map[string]*TableConfig{
+		"test_users": {Sortable: []string{"id"}},
+	})
+
+	for query, status := range map[string]int{"after=id": http.StatusOK, "after=email": http.StatusBadRequest} {
+		req := httptest.NewRequest("GET", "/duckdb/api/test_users?"+query, nil)
+		req = addAuthContext(req, "admin")
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		if rec.Code != status {
+			t.Errorf("Expected status %d for %s, got %d: %s", status, query, rec.Code, rec.Body.String())
+		}
+	}
+}
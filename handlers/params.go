@@ -1,13 +1,17 @@
 package handlers
 
 import (
+	"errors"
 	"fmt"
 	"net/http"
 	"net/url"
+	"sort"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/tobilg/caddy-duckdb-module/database"
+	"github.com/tobilg/caddy-duckdb-module/formats"
 )
 
 // ParsePagination parses pagination parameters from the request.
@@ -54,9 +58,57 @@ func ParsePagination(r *http.Request, maxRowsPerPage int, absoluteMaxRows int) (
 	return limit, offset, page, paginationRequested
 }
 
+// ParseKeyset parses keyset (cursor) pagination parameters from the request.
+// Format: after=column starts at the first row in ascending column order,
+// after=column:value after the given key, and cursor=<next_cursor> continues
+// where the previous page ended. Returns nil if none is given. Keyset reads
+// define their own order and position, so they cannot be combined with sort
+// or page.
+func ParseKeyset(r *http.Request) (*database.Keyset, error) {
+	query := r.URL.Query()
+	after, cursor := query.Get("after"), query.Get("cursor")
+	if after == "" && cursor == "" {
+		return nil, nil
+	}
+	if after != "" && cursor != "" {
+		return nil, fmt.Errorf("after and cursor cannot be combined")
+	}
+	if query.Get("sort") != "" || query.Get("page") != "" {
+		return nil, fmt.Errorf("after and cursor cannot be combined with sort or page; rows are read in ascending key order")
+	}
+
+	if cursor != "" {
+		c, err := decodeCursor(cursor)
+		if err != nil {
+			return nil, err
+		}
+		return &database.Keyset{Column: c.Column, After: c.After}, nil
+	}
+	column, value, hasValue := strings.Cut(after, ":")
+	keyset := &database.Keyset{Column: strings.TrimSpace(column)}
+	if keyset.Column == "" {
+		return nil, fmt.Errorf("invalid after: %s (expected column or column:value)", after)
+	}
+	if hasValue {
+		keyset.After = value
+	}
+	return keyset, nil
+}
+
+// filterOperators are the operators accepted in filter and where conditions.
+var filterOperators = map[string]bool{
+	"eq": true, "ne": true, "gt": true, "gte": true,
+	"lt": true, "lte": true, "like": true, "notlike": true,
+	"in": true, "not_in": true, "between": true,
+	"is_null": true, "is_not_null": true,
+}
+
+// supportedOperators lists filterOperators for error messages.
+const supportedOperators = "eq, ne, gt, gte, lt, lte, like, notlike, in, not_in, between, is_null, is_not_null"
+
 // ParseFilters parses filter parameters from the request.
 // Format: filter=column:operator:value,column2:operator2:value2
-// Example: filter=age:gt:18,status:eq:active
+// Example: filter=age:gt:18,status:eq:active,deleted_at:is_null
 func ParseFilters(r *http.Request) ([]database.Filter, error) {
 	filterStr := r.URL.Query().Get("filter")
 	if filterStr == "" {
@@ -68,6 +120,9 @@ func ParseFilters(r *http.Request) ([]database.Filter, error) {
 
 	for _, part := range filterParts {
 		components := strings.SplitN(part, ":", 3)
+		if len(components) == 2 && database.IsNullOperator(strings.TrimSpace(components[1])) {
+			components = append(components, "")
+		}
 		if len(components) != 3 {
 			return nil, fmt.Errorf("invalid filter format: %s (expected column:operator:value)", part)
 		}
@@ -77,21 +132,13 @@ func ParseFilters(r *http.Request) ([]database.Filter, error) {
 		value := components[2]
 
 		// Validate operator
-		validOperators := map[string]bool{
-			"eq": true, "ne": true, "gt": true, "gte": true,
-			"lt": true, "lte": true, "like": true, "in": true,
-		}
-		if !validOperators[operator] {
+		if !filterOperators[operator] {
 			return nil, fmt.Errorf("invalid operator: %s", operator)
 		}
 
-		// Parse value based on operator
-		var parsedValue interface{}
-		if operator == "in" {
-			// For IN operator, split by pipe
-			parsedValue = strings.Split(value, "|")
-		} else {
-			parsedValue = value
+		parsedValue, err := parseFilterValue(operator, value)
+		if err != nil {
+			return nil, err
 		}
 
 		filters = append(filters, database.Filter{
@@ -104,6 +151,29 @@ func ParseFilters(r *http.Request) ([]database.Filter, error) {
 	return filters, nil
 }
 
+// parseFilterValue parses the value of a filter or where condition. The in
+// and not_in operators take a pipe-separated list, between exactly two
+// pipe-separated bounds (e.g. 18|65), and is_null and is_not_null no value.
+func parseFilterValue(operator, value string) (interface{}, error) {
+	switch operator {
+	case "in", "not_in":
+		return strings.Split(value, "|"), nil
+	case "is_null", "is_not_null":
+		if value != "" {
+			return nil, fmt.Errorf("operator %s takes no value: %s", operator, value)
+		}
+		return nil, nil
+	case "between":
+		bounds := strings.Split(value, "|")
+		if len(bounds) != 2 || bounds[0] == "" || bounds[1] == "" {
+			return nil, fmt.Errorf("invalid between bounds: %s (expected low|high)", value)
+		}
+		return bounds, nil
+	default:
+		return value, nil
+	}
+}
+
 // ParseSorts parses sort parameters from the request.
 // Format: sort=column:direction,column2:direction2
 // Example: sort=created_at:desc,name:asc
@@ -142,7 +212,7 @@ func ParseSorts(r *http.Request) ([]database.Sort, error) {
 // ParseWhereClause parses WHERE clause from query parameters.
 // Format: where=column:operator:value,column2:operator2:value2
 // Example: where=id:eq:123,status:ne:deleted
-// Supports all the same operators as filter: eq, ne, gt, gte, lt, lte, like, in
+// Supports all the same operators as filter.
 func ParseWhereClause(r *http.Request) ([]database.Filter, error) {
 	whereStr := r.URL.Query().Get("where")
 	if whereStr == "" {
@@ -154,6 +224,9 @@ func ParseWhereClause(r *http.Request) ([]database.Filter, error) {
 
 	for _, part := range whereParts {
 		components := strings.SplitN(part, ":", 3)
+		if len(components) == 2 && database.IsNullOperator(strings.TrimSpace(components[1])) {
+			components = append(components, "")
+		}
 		if len(components) != 3 {
 			return nil, fmt.Errorf("invalid where format: %s (expected column:operator:value)", part)
 		}
@@ -163,21 +236,13 @@ func ParseWhereClause(r *http.Request) ([]database.Filter, error) {
 		value := components[2]
 
 		// Validate operator - same operators as filter
-		validOperators := map[string]bool{
-			"eq": true, "ne": true, "gt": true, "gte": true,
-			"lt": true, "lte": true, "like": true, "in": true,
-		}
-		if !validOperators[operator] {
-			return nil, fmt.Errorf("invalid operator in where clause: %s (supported: eq, ne, gt, gte, lt, lte, like, in)", operator)
+		if !filterOperators[operator] {
+			return nil, fmt.Errorf("invalid operator in where clause: %s (supported: %s)", operator, supportedOperators)
 		}
 
-		// Parse value based on operator
-		var parsedValue interface{}
-		if operator == "in" {
-			// For IN operator, split by pipe
-			parsedValue = strings.Split(value, "|")
-		} else {
-			parsedValue = value
+		parsedValue, err := parseFilterValue(operator, value)
+		if err != nil {
+			return nil, err
 		}
 
 		filters = append(filters, database.Filter{
@@ -197,6 +262,106 @@ func ParseDryRun(r *http.Request) bool {
 	return dryRun == "true" || dryRun == "1"
 }
 
+// ParseSoftDelete checks if soft_delete parameter is set to true. Tables with
+// soft_delete configured always soft-delete; DELETE operations on other tables
+// reject it, since their reads would not hide the rows.
+func ParseSoftDelete(r *http.Request) bool {
+	softDelete := r.URL.Query().Get("soft_delete")
+	return softDelete == "true" || softDelete == "1"
+}
+
+// ParseIncludeDeleted checks if include_deleted parameter is set to true.
+// When true, reads of a table with soft_delete configured include soft-deleted rows.
+func ParseIncludeDeleted(r *http.Request) bool {
+	include := r.URL.Query().Get("include_deleted")
+	return include == "true" || include == "1"
+}
+
+// ParseDebugSQL checks if debug_sql parameter is set to true.
+// It is only honored for roles permitted by the DebugSQLConfig.
+func ParseDebugSQL(r *http.Request) bool {
+	debug := r.URL.Query().Get("debug_sql")
+	return debug == "true" || debug == "1"
+}
+
+// ParseIgnoreUnknown checks if ignore_unknown parameter is set to true.
+// When true, create and update requests drop columns that do not exist in the
+// table instead of rejecting the request.
+func ParseIgnoreUnknown(r *http.Request) bool {
+	ignore := r.URL.Query().Get("ignore_unknown")
+	return ignore == "true" || ignore == "1"
+}
+
+// ParseIncludeHash checks if include_hash parameter is set to true.
+// When true, reads add a per-row hash column for change detection.
+func ParseIncludeHash(r *http.Request) bool {
+	includeHash := r.URL.Query().Get("include_hash")
+	return includeHash == "true" || includeHash == "1"
+}
+
+// ParseReturnMinimal reports whether the client asked for an empty response to a
+// successful mutation, with a Prefer: return=minimal header (RFC 7240) or the
+// no_content=true parameter.
+func ParseReturnMinimal(r *http.Request) bool {
+	if noContent := r.URL.Query().Get("no_content"); noContent == "true" || noContent == "1" {
+		return true
+	}
+	for _, header := range r.Header.Values("Prefer") {
+		for _, pref := range strings.Split(header, ",") {
+			// Preferences may carry parameters, e.g. return=minimal; foo=bar
+			token, _, _ := strings.Cut(pref, ";")
+			if strings.EqualFold(strings.ReplaceAll(strings.TrimSpace(token), " ", ""), "return=minimal") {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// ParseExplain checks if explain parameter is set to true.
+// When true, reads return the query plan instead of rows.
+func ParseExplain(r *http.Request) bool {
+	explain := r.URL.Query().Get("explain")
+	return explain == "true" || explain == "1"
+}
+
+// ParseSelect parses the select parameter, a comma-separated list of the columns
+// to return. Returns nil if the parameter is not set.
+func ParseSelect(r *http.Request) ([]string, error) {
+	value := strings.TrimSpace(r.URL.Query().Get("select"))
+	if value == "" {
+		return nil, nil
+	}
+	var columns []string
+	seen := make(map[string]bool)
+	for _, col := range strings.Split(value, ",") {
+		col = strings.TrimSpace(col)
+		if err := SanitizeColumnName(col); err != nil {
+			return nil, fmt.Errorf("invalid column '%s': %v", col, err)
+		}
+		if seen[strings.ToLower(col)] {
+			return nil, fmt.Errorf("duplicate column '%s'", col)
+		}
+		seen[strings.ToLower(col)] = true
+		columns = append(columns, col)
+	}
+	return columns, nil
+}
+
+// ParseModifiedSince parses the modified_since parameter, an RFC 3339 timestamp.
+// Returns nil if the parameter is not set.
+func ParseModifiedSince(r *http.Request) (*time.Time, error) {
+	value := strings.TrimSpace(r.URL.Query().Get("modified_since"))
+	if value == "" {
+		return nil, nil
+	}
+	t, err := time.Parse(time.RFC3339Nano, value)
+	if err != nil {
+		return nil, fmt.Errorf("%s is not an RFC 3339 timestamp", value)
+	}
+	return &t, nil
+}
+
 // ParseLinks checks if links parameter is set to true.
 // When true, HATEOAS navigation links are included in paginated responses.
 func ParseLinks(r *http.Request) bool {
@@ -204,6 +369,317 @@ func ParseLinks(r *http.Request) bool {
 	return links == "true" || links == "1"
 }
 
+// ParseKeyBy parses the key_by and key_by_duplicates parameters.
+// key_by names the column whose values key the JSON data object; key_by_duplicates
+// is "error" (default) to reject duplicate keys or "last" to keep the last row.
+func ParseKeyBy(r *http.Request) (keyBy string, lastWins bool, err error) {
+	keyBy = r.URL.Query().Get("key_by")
+	switch duplicates := r.URL.Query().Get("key_by_duplicates"); duplicates {
+	case "", "error":
+	case "last":
+		lastWins = true
+	default:
+		return "", false, fmt.Errorf("invalid key_by_duplicates: %s (must be 'error' or 'last')", duplicates)
+	}
+	if keyBy != "" {
+		if err := SanitizeColumnName(keyBy); err != nil {
+			return "", false, fmt.Errorf("invalid key_by column '%s': %v", keyBy, err)
+		}
+	}
+	return keyBy, lastWins, nil
+}
+
+// ParseWindow parses the window and qualify parameters into a QUALIFY condition.
+// Format: window=function[:partition=col1|col2][:order=col1 desc|col2]&qualify=<op><number>
+// Example: window=row_number:partition=category:order=price desc&qualify=<=3
+// Allowed functions are row_number, rank, dense_rank, percent_rank, and cume_dist;
+// allowed operators are =, !=, <, <=, >, and >=. Returns nil if window is not set.
+func ParseWindow(r *http.Request) (*database.Window, error) {
+	windowStr := r.URL.Query().Get("window")
+	qualifyStr := strings.TrimSpace(r.URL.Query().Get("qualify"))
+	if windowStr == "" {
+		if qualifyStr != "" {
+			return nil, fmt.Errorf("qualify requires a window parameter")
+		}
+		return nil, nil
+	}
+	if qualifyStr == "" {
+		return nil, fmt.Errorf("window requires a qualify parameter (e.g. qualify=<=3)")
+	}
+
+	parts := strings.Split(windowStr, ":")
+	window := &database.Window{Function: strings.ToLower(strings.TrimSpace(parts[0]))}
+	if !database.IsWindowFunction(window.Function) {
+		return nil, fmt.Errorf("unsupported window function: %s (must be row_number, rank, dense_rank, percent_rank, or cume_dist)", parts[0])
+	}
+
+	for _, part := range parts[1:] {
+		key, value, ok := strings.Cut(part, "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid window option: %s (expected partition=... or order=...)", part)
+		}
+		switch strings.TrimSpace(key) {
+		case "partition":
+			for _, col := range strings.Split(value, "|") {
+				col = strings.TrimSpace(col)
+				if err := SanitizeColumnName(col); err != nil {
+					return nil, fmt.Errorf("invalid partition column '%s': %v", col, err)
+				}
+				window.Partition = append(window.Partition, col)
+			}
+		case "order":
+			for _, item := range strings.Split(value, "|") {
+				fields := strings.Fields(item)
+				if len(fields) == 0 || len(fields) > 2 {
+					return nil, fmt.Errorf("invalid window order: %s (expected column [asc|desc])", item)
+				}
+				if err := SanitizeColumnName(fields[0]); err != nil {
+					return nil, fmt.Errorf("invalid order column '%s': %v", fields[0], err)
+				}
+				direction := "asc"
+				if len(fields) == 2 {
+					direction = strings.ToLower(fields[1])
+					if direction != "asc" && direction != "desc" {
+						return nil, fmt.Errorf("invalid sort direction: %s (must be 'asc' or 'desc')", fields[1])
+					}
+				}
+				window.Order = append(window.Order, database.Sort{Column: fields[0], Direction: direction})
+			}
+		default:
+			return nil, fmt.Errorf("unknown window option: %s (expected partition or order)", key)
+		}
+	}
+
+	// Two-character operators first so that "<=3" is not read as "<" and "=3"
+	for _, op := range []string{"<=", ">=", "!=", "<", ">", "="} {
+		if strings.HasPrefix(qualifyStr, op) {
+			window.Operator = op
+			break
+		}
+	}
+	if window.Operator == "" {
+		return nil, fmt.Errorf("invalid qualify: %s (expected an operator =, !=, <, <=, >, >= followed by a number)", qualifyStr)
+	}
+	valueStr := strings.TrimSpace(strings.TrimPrefix(qualifyStr, window.Operator))
+	if n, err := strconv.ParseInt(valueStr, 10, 64); err == nil {
+		window.Value = n
+	} else if f, err := strconv.ParseFloat(valueStr, 64); err == nil {
+		window.Value = f
+	} else {
+		return nil, fmt.Errorf("invalid qualify value: %s (must be a number)", valueStr)
+	}
+
+	return window, nil
+}
+
+// ParseSample parses the sample, sample_method, and seed parameters into a
+// random sample that the read is taken from.
+// Format: sample=<percent>% or sample=<rows>, sample_method=reservoir|bernoulli|system, seed=<integer>
+// Example: sample=10%&seed=42 reads a reproducible 10% reservoir sample.
+// Returns nil if sample is not set.
+func ParseSample(r *http.Request) (*database.Sample, error) {
+	query := r.URL.Query()
+	sampleStr := strings.TrimSpace(query.Get("sample"))
+	methodStr := strings.TrimSpace(query.Get("sample_method"))
+	seedStr := strings.TrimSpace(query.Get("seed"))
+	if sampleStr == "" {
+		if methodStr != "" || seedStr != "" {
+			return nil, fmt.Errorf("sample_method and seed require a sample parameter")
+		}
+		return nil, nil
+	}
+
+	sample := &database.Sample{Method: strings.ToLower(methodStr)}
+	if percentStr, ok := strings.CutSuffix(sampleStr, "%"); ok {
+		percent, err := strconv.ParseFloat(strings.TrimSpace(percentStr), 64)
+		if err != nil {
+			return nil, fmt.Errorf("%s is neither a percentage such as 10%% nor a row count", sampleStr)
+		}
+		sample.Percent = percent
+	} else {
+		rows, err := strconv.Atoi(sampleStr)
+		if err != nil {
+			return nil, fmt.Errorf("%s is neither a percentage such as 10%% nor a row count", sampleStr)
+		}
+		sample.Rows = rows
+	}
+	if seedStr != "" {
+		seed, err := strconv.ParseInt(seedStr, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid seed: %s (must be an integer)", seedStr)
+		}
+		sample.Seed = &seed
+	}
+	if err := sample.Validate(); err != nil {
+		return nil, err
+	}
+	return sample, nil
+}
+
+// ParseSummary parses the summary parameter into aggregates computed over all
+// rows matching the read, not just the returned page.
+// Format: summary=function:column,function2:column2
+// Example: summary=sum:amount,avg:price
+// Allowed functions are sum, avg, min, max, and count. Returns nil if summary is not set.
+func ParseSummary(r *http.Request) ([]database.Aggregate, error) {
+	summaryStr := r.URL.Query().Get("summary")
+	if summaryStr == "" {
+		return nil, nil
+	}
+
+	parts := strings.Split(summaryStr, ",")
+	aggregates := make([]database.Aggregate, 0, len(parts))
+	seen := make(map[database.Aggregate]bool, len(parts))
+	for _, part := range parts {
+		fn, column, ok := strings.Cut(part, ":")
+		if !ok {
+			return nil, fmt.Errorf("invalid summary: %s (expected function:column)", part)
+		}
+		fn = strings.ToLower(strings.TrimSpace(fn))
+		column = strings.TrimSpace(column)
+		if !database.IsSummaryFunction(fn) {
+			return nil, fmt.Errorf("unsupported summary function: %s (must be sum, avg, min, max, or count)", fn)
+		}
+		if err := SanitizeColumnName(column); err != nil {
+			return nil, fmt.Errorf("invalid summary column '%s': %v", column, err)
+		}
+		agg := database.Aggregate{Function: fn, Column: column}
+		if seen[agg] {
+			continue
+		}
+		seen[agg] = true
+		aggregates = append(aggregates, agg)
+	}
+
+	return aggregates, nil
+}
+
+// ParseTimeSeries parses the parameters of a time series request:
+// time_column, interval (e.g. 1h), aggregate as function:column,... (count may
+// use *), and an optional comma-separated group_by.
+func ParseTimeSeries(r *http.Request) (*database.TimeSeries, error) {
+	query := r.URL.Query()
+	ts := &database.TimeSeries{
+		TimeColumn: strings.TrimSpace(query.Get("time_column")),
+		Interval:   strings.TrimSpace(query.Get("interval")),
+	}
+	if ts.TimeColumn == "" {
+		return nil, fmt.Errorf("time_column is required")
+	}
+	if err := SanitizeColumnName(ts.TimeColumn); err != nil {
+		return nil, fmt.Errorf("invalid time_column '%s': %v", ts.TimeColumn, err)
+	}
+	if ts.Interval == "" {
+		return nil, fmt.Errorf("interval is required")
+	}
+	if _, err := database.ParseInterval(ts.Interval); err != nil {
+		return nil, err
+	}
+
+	var err error
+	if ts.Aggregates, err = parseAggregates(query.Get("aggregate")); err != nil {
+		return nil, err
+	}
+	if ts.GroupBy, err = parseGroupBy(query.Get("group_by")); err != nil {
+		return nil, err
+	}
+
+	if err := ts.Validate(); err != nil {
+		return nil, err
+	}
+	return ts, nil
+}
+
+// IsGroupedRead reports whether a read asks for grouped aggregates (group_by or agg).
+func IsGroupedRead(r *http.Request) bool {
+	query := r.URL.Query()
+	return query.Has("group_by") || query.Has("agg")
+}
+
+// ParseGroupedRead parses the parameters of a grouped read: an optional
+// comma-separated group_by and agg as function:column,... (count may use *).
+// Example: group_by=region&agg=sum:amount,count:*
+// Allowed functions are sum, avg, min, max, count, and the list aggregates
+// array_agg and list, which return each group's values as an array; agg
+// defaults to count:*.
+func ParseGroupedRead(r *http.Request) (*database.GroupedRead, error) {
+	query := r.URL.Query()
+	g := &database.GroupedRead{}
+	var err error
+	if g.Aggregates, err = parseAggregates(query.Get("agg")); err != nil {
+		return nil, err
+	}
+	if g.GroupBy, err = parseGroupBy(query.Get("group_by")); err != nil {
+		return nil, err
+	}
+	if err := g.Validate(); err != nil {
+		return nil, err
+	}
+	return g, nil
+}
+
+// parseAggregates parses a comma-separated list of function:column aggregates,
+// where count may use *. Repeated aggregates are dropped; an empty list means count:*.
+func parseAggregates(value string) ([]database.Aggregate, error) {
+	if value == "" {
+		value = "count:*"
+	}
+	var aggregates []database.Aggregate
+	seen := make(map[database.Aggregate]bool)
+	for _, part := range strings.Split(value, ",") {
+		fn, column, ok := strings.Cut(part, ":")
+		if !ok {
+			return nil, fmt.Errorf("invalid aggregate: %s (expected function:column)", part)
+		}
+		fn = strings.ToLower(strings.TrimSpace(fn))
+		column = strings.TrimSpace(column)
+		if !database.IsGroupingFunction(fn) {
+			return nil, fmt.Errorf("unsupported aggregate function: %s (must be sum, avg, min, max, count, array_agg, or list)", fn)
+		}
+		if column == "*" {
+			if fn != "count" {
+				return nil, fmt.Errorf("invalid aggregate: %s (only count accepts *)", part)
+			}
+		} else if err := SanitizeColumnName(column); err != nil {
+			return nil, fmt.Errorf("invalid aggregate column '%s': %v", column, err)
+		}
+		agg := database.Aggregate{Function: fn, Column: column}
+		if !seen[agg] {
+			seen[agg] = true
+			aggregates = append(aggregates, agg)
+		}
+	}
+	return aggregates, nil
+}
+
+// parseGroupBy parses a comma-separated list of group_by columns.
+func parseGroupBy(value string) ([]string, error) {
+	if value == "" {
+		return nil, nil
+	}
+	var columns []string
+	for _, col := range strings.Split(value, ",") {
+		col = strings.TrimSpace(col)
+		if err := SanitizeColumnName(col); err != nil {
+			return nil, fmt.Errorf("invalid group_by column '%s': %v", col, err)
+		}
+		columns = append(columns, col)
+	}
+	return columns, nil
+}
+
+// ParseTimestamp parses a timestamp query parameter.
+// Accepts RFC 3339 timestamps (2024-01-15T10:30:00Z) or plain dates (2024-01-15, interpreted as UTC midnight).
+func ParseTimestamp(value string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	if t, err := time.Parse("2006-01-02", value); err == nil {
+		return t, nil
+	}
+	return time.Time{}, fmt.Errorf("invalid timestamp: %s (expected RFC 3339 or YYYY-MM-DD)", value)
+}
+
 // GetAcceptFormat returns the preferred response format based on Accept header.
 func GetAcceptFormat(r *http.Request) string {
 	accept := r.Header.Get("Accept")
@@ -218,11 +694,132 @@ func GetAcceptFormat(r *http.Request) string {
 	if strings.Contains(accept, "application/vnd.apache.arrow") {
 		return "arrow"
 	}
+	if strings.Contains(accept, formats.NDJSONContentType) {
+		return "ndjson"
+	}
 
 	// Default to JSON
 	return "json"
 }
 
+// ParseParquetCompression returns the compression codec requested for a Parquet
+// response, from the compression query parameter or, without it, a compression
+// parameter of the Accept header (application/parquet; compression=zstd).
+// Empty means the default codec. Unknown codecs are an error.
+func ParseParquetCompression(r *http.Request) (string, error) {
+	compression := r.URL.Query().Get("compression")
+	if compression == "" {
+		for _, part := range strings.Split(r.Header.Get("Accept"), ",") {
+			fields := strings.Split(part, ";")
+			if strings.TrimSpace(fields[0]) != "application/parquet" {
+				continue
+			}
+			for _, param := range fields[1:] {
+				if name, value, ok := strings.Cut(strings.TrimSpace(param), "="); ok && name == "compression" {
+					compression = strings.Trim(value, `"`)
+				}
+			}
+		}
+	}
+	if compression == "" {
+		return "", nil
+	}
+	compression = strings.ToLower(compression)
+	if _, ok := formats.ParquetCompressionCodecs[compression]; !ok {
+		return "", fmt.Errorf("unsupported compression '%s' (must be zstd, snappy, gzip, or none)", compression)
+	}
+	return compression, nil
+}
+
+// ParseCSVDialect returns the delimiter and quoting requested for a CSV response
+// by the delimiter and quote_all query parameters. The delimiter is a character
+// or name from formats.CSVDelimiters (comma, semicolon, tab, pipe); 0 means the
+// default comma. Other delimiters are an error.
+func ParseCSVDialect(r *http.Request) (rune, bool, error) {
+	query := r.URL.Query()
+	quoteAll := query.Get("quote_all") == "true" || query.Get("quote_all") == "1"
+
+	// Go's query parser drops pairs containing a literal ';', so ?delimiter=;
+	// is looked up in the raw query
+	delimiter, found := query.Get("delimiter"), query.Has("delimiter")
+	if !found {
+		for _, pair := range strings.Split(r.URL.RawQuery, "&") {
+			if value, ok := strings.CutPrefix(pair, "delimiter="); ok {
+				if unescaped, err := url.QueryUnescape(value); err == nil {
+					delimiter, found = unescaped, true
+				}
+			}
+		}
+	}
+	if !found || delimiter == "" {
+		return 0, quoteAll, nil
+	}
+	comma, ok := formats.CSVDelimiters[strings.ToLower(delimiter)]
+	if !ok {
+		return 0, false, fmt.Errorf("unsupported delimiter '%s' (must be comma, semicolon, tab, or pipe)", delimiter)
+	}
+	return comma, quoteAll, nil
+}
+
+// NegotiateCSVCharset picks the charset for a CSV response from the Accept-Charset header.
+// Charsets are tried in order of preference (q-values); "*" selects the default charset.
+// Without an Accept-Charset header the default charset is used.
+// If no requested charset is supported, UTF-8 is used unless rejectUnsupported is set,
+// in which case false is returned and the caller should respond with 406 Not Acceptable.
+func NegotiateCSVCharset(r *http.Request, defaultCharset string, rejectUnsupported bool) (string, bool) {
+	if defaultCharset == "" {
+		defaultCharset = formats.DefaultCharset
+	}
+
+	header := r.Header.Get("Accept-Charset")
+	if strings.TrimSpace(header) == "" {
+		return defaultCharset, true
+	}
+
+	type candidate struct {
+		name string
+		q    float64
+	}
+	var candidates []candidate
+	for _, part := range strings.Split(header, ",") {
+		fields := strings.Split(part, ";")
+		name := strings.TrimSpace(fields[0])
+		if name == "" {
+			continue
+		}
+		q := 1.0
+		for _, param := range fields[1:] {
+			param = strings.TrimSpace(param)
+			if strings.HasPrefix(param, "q=") {
+				if v, err := strconv.ParseFloat(param[2:], 64); err == nil {
+					q = v
+				}
+			}
+		}
+		if q <= 0 {
+			continue
+		}
+		candidates = append(candidates, candidate{name: name, q: q})
+	}
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].q > candidates[j].q
+	})
+
+	for _, c := range candidates {
+		if c.name == "*" {
+			return defaultCharset, true
+		}
+		if charset, ok := formats.NormalizeCharset(c.name); ok {
+			return charset, true
+		}
+	}
+
+	if rejectUnsupported {
+		return "", false
+	}
+	return formats.DefaultCharset, true
+}
+
 // SanitizeTableName validates and sanitizes table names to prevent SQL injection.
 func SanitizeTableName(tableName string) error {
 	if tableName == "" {
@@ -239,6 +836,75 @@ func SanitizeTableName(tableName string) error {
 	return nil
 }
 
+// SanitizeQualifiedTableName validates a table name that may be qualified with a
+// catalog ("catalog.table"). Each part must be a valid table name.
+func SanitizeQualifiedTableName(tableName string) error {
+	parts := strings.Split(tableName, ".")
+	if len(parts) > 2 {
+		return fmt.Errorf("invalid table name: expected table or catalog.table")
+	}
+	for _, part := range parts {
+		if err := SanitizeTableName(part); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// errCatalogLookup marks ResolveTableName errors from looking up a catalog,
+// as opposed to invalid names.
+var errCatalogLookup = errors.New("failed to resolve table")
+
+// CatalogResolver looks up the catalogs a table name may be qualified with.
+// *database.Manager implements it.
+type CatalogResolver interface {
+	// MainCatalog returns the catalog name of the main database.
+	MainCatalog() string
+	// AttachedCatalog returns the attached catalog name refers to, as DuckDB
+	// spells it, or "" if there is none.
+	AttachedCatalog(name string) (string, error)
+}
+
+// ResolveTableName returns the table targeted by a table API request, qualified
+// with its catalog ("catalog.table") when the path names one (/duckdb/api/catalog.table)
+// or the catalog query parameter is set. The qualified name is used for SQL and
+// for permission checks, so permissions on catalog.table are separate from table.
+// The qualifier must name an attached catalog; anything else DuckDB could resolve
+// it to, like the main schema, is rejected, so every name of a table is its one
+// canonical name. The catalog is spelled as attached, and a table of the main
+// database is returned unqualified, so it gets the same permissions, table
+// configuration, and write routing however it is named.
+func ResolveTableName(r *http.Request, tableName string, catalogs CatalogResolver) (string, error) {
+	if err := SanitizeQualifiedTableName(tableName); err != nil {
+		return "", err
+	}
+	catalog := r.URL.Query().Get("catalog")
+	if catalog != "" {
+		if strings.Contains(tableName, ".") {
+			return "", fmt.Errorf("catalog parameter cannot be combined with a catalog-qualified table name")
+		}
+		if err := SanitizeTableName(catalog); err != nil {
+			return "", fmt.Errorf("invalid catalog: %v", err)
+		}
+		tableName = catalog + "." + tableName
+	}
+	catalog, name := database.SplitTableName(tableName)
+	if catalog == "" {
+		return name, nil
+	}
+	attached, err := catalogs.AttachedCatalog(catalog)
+	if err != nil {
+		return "", fmt.Errorf("%w: %w", errCatalogLookup, err)
+	}
+	if attached == "" {
+		return "", fmt.Errorf("unknown catalog '%s'", catalog)
+	}
+	if attached == catalogs.MainCatalog() {
+		return name, nil
+	}
+	return attached + "." + name, nil
+}
+
 // SanitizeColumnName validates and sanitizes column names to prevent SQL injection.
 func SanitizeColumnName(columnName string) error {
 	if columnName == "" {
@@ -305,10 +971,11 @@ func ParseGETQueryPath(path string) (string, string, error) {
 		"csv":     true,
 		"arrow":   true,
 		"parquet": true,
+		"ndjson":  true,
 	}
 
 	if !validFormats[format] {
-		return "", "", fmt.Errorf("invalid format: %s (must be json, csv, arrow, or parquet)", format)
+		return "", "", fmt.Errorf("invalid format: %s (must be json, csv, arrow, parquet, or ndjson)", format)
 	}
 
 	return decodedSQL, format, nil
@@ -3,7 +3,12 @@ package handlers
 import (
 	"net/http"
 	"net/http/httptest"
+	"net/url"
+	"strings"
 	"testing"
+	"time"
+
+	"github.com/tobilg/caddy-duckdb-module/database"
 )
 
 func TestParsePagination(t *testing.T) {
@@ -130,6 +135,50 @@ func TestParsePagination(t *testing.T) {
 	}
 }
 
+func TestParseKeyset(t *testing.T) {
+	tests := []struct {
+		name      string
+		query     string
+		wantNil   bool
+		wantCol   string
+		wantAfter interface{}
+		wantErr   bool
+	}{
+		{"none", "limit=10", true, "", nil, false},
+		{"first page", "after=id", false, "id", nil, false},
+		{"after key", "after=id:42", false, "id", "42", false},
+		{"key with colons", "after=created_at:2024-01-15T10:30:00", false, "created_at", "2024-01-15T10:30:00", false},
+		{"cursor", "cursor=" + encodeCursor("id", "7"), false, "id", "7", false},
+		{"missing column", "after=:5", false, "", nil, true},
+		{"malformed cursor", "cursor=abc", false, "", nil, true},
+		{"after and cursor", "after=id&cursor=" + encodeCursor("id", "7"), false, "", nil, true},
+		{"with sort", "after=id&sort=id:asc", false, "", nil, true},
+		{"with page", "after=id&page=2", false, "", nil, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "/?"+tt.query, nil)
+			got, err := ParseKeyset(req)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseKeyset() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if tt.wantNil {
+				if got != nil {
+					t.Errorf("Expected no keyset, got %+v", got)
+				}
+				return
+			}
+			if got == nil || got.Column != tt.wantCol || got.After != tt.wantAfter {
+				t.Errorf("ParseKeyset() = %+v, want column %q after %v", got, tt.wantCol, tt.wantAfter)
+			}
+		})
+	}
+}
+
 func TestParseFilters(t *testing.T) {
 	tests := []struct {
 		name       string
@@ -237,6 +286,81 @@ func TestParseFilters(t *testing.T) {
 				}
 			},
 		},
+		{
+			name:      "between operator",
+			query:     "filter=age:between:18|65",
+			wantCount: 1,
+			wantErr:   false,
+			checkFirst: func(t *testing.T, column, operator string, value interface{}) {
+				bounds, ok := value.([]string)
+				if operator != "between" || !ok || len(bounds) != 2 || bounds[0] != "18" || bounds[1] != "65" {
+					t.Errorf("expected between bounds [18 65], got %s %v", operator, value)
+				}
+			},
+		},
+		{
+			name:      "between without pipe",
+			query:     "filter=age:between:18",
+			wantCount: 0,
+			wantErr:   true,
+		},
+		{
+			name:      "between with three bounds",
+			query:     "filter=age:between:18|30|65",
+			wantCount: 0,
+			wantErr:   true,
+		},
+		{
+			name:      "between with empty bound",
+			query:     "filter=age:between:18|",
+			wantCount: 0,
+			wantErr:   true,
+		},
+		{
+			name:      "is_null without value",
+			query:     "filter=deleted_at:is_null,age:gt:18",
+			wantCount: 2,
+			wantErr:   false,
+			checkFirst: func(t *testing.T, column, operator string, value interface{}) {
+				if column != "deleted_at" || operator != "is_null" || value != nil {
+					t.Errorf("expected deleted_at is_null without value, got %s %s %v", column, operator, value)
+				}
+			},
+		},
+		{
+			name:      "is_not_null with empty value",
+			query:     "filter=deleted_at:is_not_null:",
+			wantCount: 1,
+			wantErr:   false,
+		},
+		{
+			name:      "is_null with value",
+			query:     "filter=deleted_at:is_null:2024-01-01",
+			wantCount: 0,
+			wantErr:   true,
+		},
+		{
+			name:      "not_in operator",
+			query:     "filter=status:not_in:archived|spam",
+			wantCount: 1,
+			wantErr:   false,
+			checkFirst: func(t *testing.T, column, operator string, value interface{}) {
+				if values, ok := value.([]string); !ok || len(values) != 2 {
+					t.Errorf("expected two not_in values, got %v", value)
+				}
+			},
+		},
+		{
+			name:      "notlike operator",
+			query:     "filter=name:notlike:test%25",
+			wantCount: 1,
+			wantErr:   false,
+			checkFirst: func(t *testing.T, column, operator string, value interface{}) {
+				if operator != "notlike" || value != "test%" {
+					t.Errorf("expected notlike test%%, got %s %v", operator, value)
+				}
+			},
+		},
 		{
 			name:      "invalid format - missing parts",
 			query:     "filter=name:eq",
@@ -431,6 +555,35 @@ func TestParseWhereClause(t *testing.T) {
 			wantCount: 0,
 			wantErr:   true,
 		},
+		{
+			name:      "between operator",
+			query:     "where=age:between:18|65",
+			wantCount: 1,
+			wantErr:   false,
+			checkFirst: func(t *testing.T, column, operator string, value interface{}) {
+				if bounds, ok := value.([]string); !ok || len(bounds) != 2 {
+					t.Errorf("expected two between bounds, got %v", value)
+				}
+			},
+		},
+		{
+			name:      "is_not_null without value",
+			query:     "where=deleted_at:is_not_null",
+			wantCount: 1,
+			wantErr:   false,
+		},
+		{
+			name:      "is_null with value",
+			query:     "where=deleted_at:is_null:x",
+			wantCount: 0,
+			wantErr:   true,
+		},
+		{
+			name:      "malformed between bounds",
+			query:     "where=age:between:18|30|65",
+			wantCount: 0,
+			wantErr:   true,
+		},
 		{
 			name:      "invalid operator in where",
 			query:     "where=name:contains:test",
@@ -484,6 +637,248 @@ func TestParseDryRun(t *testing.T) {
 	}
 }
 
+func TestParseDebugSQL(t *testing.T) {
+	tests := []struct {
+		query string
+		want  bool
+	}{
+		{"", false},
+		{"debug_sql=true", true},
+		{"debug_sql=1", true},
+		{"debug_sql=false", false},
+		{"debug_sql=yes", false},
+	}
+
+	for _, tt := range tests {
+		req := httptest.NewRequest("GET", "/?"+tt.query, nil)
+		if got := ParseDebugSQL(req); got != tt.want {
+			t.Errorf("ParseDebugSQL(%q) = %v, want %v", tt.query, got, tt.want)
+		}
+	}
+}
+
+func TestParseKeyBy(t *testing.T) {
+	tests := []struct {
+		name         string
+		query        string
+		wantKeyBy    string
+		wantLastWins bool
+		wantErr      bool
+	}{
+		{"no key_by param", "", "", false, false},
+		{"key_by", "key_by=id", "id", false, false},
+		{"duplicates error", "key_by=id&key_by_duplicates=error", "id", false, false},
+		{"duplicates last", "key_by=id&key_by_duplicates=last", "id", true, false},
+		{"invalid duplicates", "key_by=id&key_by_duplicates=first", "", false, true},
+		{"invalid column", "key_by=id%3Bdrop", "", false, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "/?"+tt.query, nil)
+			keyBy, lastWins, err := ParseKeyBy(req)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseKeyBy() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if keyBy != tt.wantKeyBy || lastWins != tt.wantLastWins {
+				t.Errorf("ParseKeyBy() = (%q, %v), want (%q, %v)", keyBy, lastWins, tt.wantKeyBy, tt.wantLastWins)
+			}
+		})
+	}
+}
+
+func TestParseWindow(t *testing.T) {
+	t.Run("top n per group", func(t *testing.T) {
+		query := url.Values{
+			"window":  {"row_number:partition=category|region:order=price desc|id"},
+			"qualify": {"<=3"},
+		}
+		req := httptest.NewRequest("GET", "/?"+query.Encode(), nil)
+		window, err := ParseWindow(req)
+		if err != nil {
+			t.Fatalf("ParseWindow() error = %v", err)
+		}
+		if window.Function != "row_number" || window.Operator != "<=" || window.Value != int64(3) {
+			t.Errorf("Unexpected window: %+v", window)
+		}
+		if len(window.Partition) != 2 || window.Partition[0] != "category" || window.Partition[1] != "region" {
+			t.Errorf("Unexpected partition: %v", window.Partition)
+		}
+		if len(window.Order) != 2 || window.Order[0] != (database.Sort{Column: "price", Direction: "desc"}) || window.Order[1] != (database.Sort{Column: "id", Direction: "asc"}) {
+			t.Errorf("Unexpected order: %v", window.Order)
+		}
+	})
+
+	t.Run("no window", func(t *testing.T) {
+		window, err := ParseWindow(httptest.NewRequest("GET", "/", nil))
+		if err != nil || window != nil {
+			t.Errorf("ParseWindow() = (%v, %v), want (nil, nil)", window, err)
+		}
+	})
+
+	tests := []struct {
+		name    string
+		window  string
+		qualify string
+	}{
+		{"qualify without window", "", "<=3"},
+		{"window without qualify", "row_number", ""},
+		{"unsupported function", "sum:order=price", "<=3"},
+		{"unknown option", "rank:frame=rows", "<=3"},
+		{"invalid partition column", "rank:partition=a;drop", "<=3"},
+		{"invalid order direction", "rank:order=price up", "<=3"},
+		{"invalid operator", "rank:order=price", "~3"},
+		{"non-numeric value", "rank:order=price", "<=abc"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			query := url.Values{}
+			if tt.window != "" {
+				query.Set("window", tt.window)
+			}
+			if tt.qualify != "" {
+				query.Set("qualify", tt.qualify)
+			}
+			req := httptest.NewRequest("GET", "/?"+query.Encode(), nil)
+			if _, err := ParseWindow(req); err == nil {
+				t.Error("Expected error")
+			}
+		})
+	}
+}
+
+func TestParseSelect(t *testing.T) {
+	columns, err := ParseSelect(httptest.NewRequest("GET", "/?select=id,%20name,email", nil))
+	if err != nil {
+		t.Fatalf("ParseSelect() error = %v", err)
+	}
+	if len(columns) != 3 || columns[0] != "id" || columns[1] != "name" || columns[2] != "email" {
+		t.Errorf("Unexpected columns: %v", columns)
+	}
+
+	if columns, err := ParseSelect(httptest.NewRequest("GET", "/", nil)); err != nil || columns != nil {
+		t.Errorf("ParseSelect() = (%v, %v), want (nil, nil)", columns, err)
+	}
+	for _, value := range []string{"id,", "id,ID", "id;DROP", "*"} {
+		if _, err := ParseSelect(httptest.NewRequest("GET", "/?select="+url.QueryEscape(value), nil)); err == nil {
+			t.Errorf("Expected select=%s to be rejected", value)
+		}
+	}
+}
+
+func TestParseGroupedRead(t *testing.T) {
+	req := httptest.NewRequest("GET", "/?group_by=region,%20product&agg=SUM:amount,count:*,sum:amount", nil)
+	if !IsGroupedRead(req) {
+		t.Fatal("Expected a grouped read")
+	}
+	g, err := ParseGroupedRead(req)
+	if err != nil {
+		t.Fatalf("ParseGroupedRead() error = %v", err)
+	}
+	if len(g.GroupBy) != 2 || g.GroupBy[1] != "product" {
+		t.Errorf("Unexpected group columns: %v", g.GroupBy)
+	}
+	if len(g.Aggregates) != 2 || g.Aggregates[0] != (database.Aggregate{Function: "sum", Column: "amount"}) || g.Aggregates[1].Column != "*" {
+		t.Errorf("Unexpected aggregates: %v", g.Aggregates)
+	}
+
+	// agg defaults to count:*
+	g, err = ParseGroupedRead(httptest.NewRequest("GET", "/?group_by=region", nil))
+	if err != nil || len(g.Aggregates) != 1 || g.Aggregates[0].Function != "count" {
+		t.Errorf("ParseGroupedRead() = (%v, %v), want count:*", g, err)
+	}
+
+	if IsGroupedRead(httptest.NewRequest("GET", "/?filter=region:eq:west", nil)) {
+		t.Error("Expected a plain read not to be grouped")
+	}
+	for _, query := range []string{"agg=avg:*", "agg=stddev:amount", "agg=sum", "group_by=a,,b", "group_by=count"} {
+		if _, err := ParseGroupedRead(httptest.NewRequest("GET", "/?"+query, nil)); err == nil {
+			t.Errorf("Expected %s to be rejected", query)
+		}
+	}
+}
+
+func TestParseModifiedSince(t *testing.T) {
+	since, err := ParseModifiedSince(httptest.NewRequest("GET", "/?modified_since=2025-01-02T10:00:00.5%2B01:00", nil))
+	if err != nil {
+		t.Fatalf("ParseModifiedSince() error = %v", err)
+	}
+	if want := time.Date(2025, 1, 2, 9, 0, 0, 500000000, time.UTC); !since.Equal(want) {
+		t.Errorf("ParseModifiedSince() = %v, want %v", since, want)
+	}
+
+	if since, err := ParseModifiedSince(httptest.NewRequest("GET", "/", nil)); err != nil || since != nil {
+		t.Errorf("ParseModifiedSince() = (%v, %v), want (nil, nil)", since, err)
+	}
+	for _, value := range []string{"2025-01-02", "yesterday", "1735812000"} {
+		if _, err := ParseModifiedSince(httptest.NewRequest("GET", "/?modified_since="+value, nil)); err == nil {
+			t.Errorf("Expected modified_since=%s to be rejected", value)
+		}
+	}
+}
+
+func TestParseSample(t *testing.T) {
+	sample, err := ParseSample(httptest.NewRequest("GET", "/?sample=10%25&seed=42", nil))
+	if err != nil {
+		t.Fatalf("ParseSample() error = %v", err)
+	}
+	if sample.Percent != 10 || sample.Rows != 0 || sample.Seed == nil || *sample.Seed != 42 {
+		t.Errorf("Unexpected sample: %+v", sample)
+	}
+
+	sample, err = ParseSample(httptest.NewRequest("GET", "/?sample=100&sample_method=Reservoir", nil))
+	if err != nil {
+		t.Fatalf("ParseSample() error = %v", err)
+	}
+	if sample.Rows != 100 || sample.Method != "reservoir" || sample.Seed != nil {
+		t.Errorf("Unexpected sample: %+v", sample)
+	}
+
+	if sample, err := ParseSample(httptest.NewRequest("GET", "/", nil)); err != nil || sample != nil {
+		t.Errorf("ParseSample() = (%v, %v), want (nil, nil)", sample, err)
+	}
+
+	invalid := []string{
+		"seed=42",
+		"sample_method=system",
+		"sample=ten%25",
+		"sample=0%25",
+		"sample=1.5",
+		"sample=10%25&seed=abc",
+		"sample=10%25&seed=4.2",
+		"sample=10%25&sample_method=random",
+		"sample=100&sample_method=bernoulli",
+	}
+	for _, query := range invalid {
+		if _, err := ParseSample(httptest.NewRequest("GET", "/?"+query, nil)); err == nil {
+			t.Errorf("Expected error for %s", query)
+		}
+	}
+}
+
+func TestParseSummary(t *testing.T) {
+	req := httptest.NewRequest("GET", "/?summary="+url.QueryEscape("sum:amount, AVG:price,sum:amount"), nil)
+	aggregates, err := ParseSummary(req)
+	if err != nil {
+		t.Fatalf("ParseSummary() error = %v", err)
+	}
+	want := []database.Aggregate{{Function: "sum", Column: "amount"}, {Function: "avg", Column: "price"}}
+	if len(aggregates) != len(want) || aggregates[0] != want[0] || aggregates[1] != want[1] {
+		t.Errorf("ParseSummary() = %v, want %v (duplicates dropped)", aggregates, want)
+	}
+
+	if aggregates, err := ParseSummary(httptest.NewRequest("GET", "/", nil)); err != nil || aggregates != nil {
+		t.Errorf("ParseSummary() = (%v, %v), want (nil, nil)", aggregates, err)
+	}
+
+	for _, summary := range []string{"sum", "median:price", "array_agg:price", "sum:a;drop", "sum:"} {
+		req := httptest.NewRequest("GET", "/?summary="+url.QueryEscape(summary), nil)
+		if _, err := ParseSummary(req); err == nil {
+			t.Errorf("Expected error for summary=%q", summary)
+		}
+	}
+}
+
 func TestParseLinks(t *testing.T) {
 	tests := []struct {
 		name  string
@@ -507,6 +902,44 @@ func TestParseLinks(t *testing.T) {
 	}
 }
 
+func TestParseReturnMinimal(t *testing.T) {
+	tests := []struct {
+		query  string
+		prefer []string
+		want   bool
+	}{
+		{"", nil, false},
+		{"no_content=true", nil, true},
+		{"no_content=1", nil, true},
+		{"no_content=false", nil, false},
+		{"", []string{"return=minimal"}, true},
+		{"", []string{"Return=Minimal"}, true},
+		{"", []string{"return = minimal"}, true},
+		{"", []string{"respond-async, return=minimal; foo=bar"}, true},
+		{"", []string{"respond-async", "return=minimal"}, true},
+		{"", []string{"return=representation"}, false},
+		{"", []string{"wait=10"}, false},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest("DELETE", "/?"+tt.query, nil)
+		for _, value := range tt.prefer {
+			req.Header.Add("Prefer", value)
+		}
+		if got := ParseReturnMinimal(req); got != tt.want {
+			t.Errorf("ParseReturnMinimal(%q, %q) = %v, want %v", tt.query, tt.prefer, got, tt.want)
+		}
+	}
+}
+
+func TestParseExplain(t *testing.T) {
+	for query, want := range map[string]bool{"": false, "explain=true": true, "explain=1": true, "explain=false": false, "explain=yes": false} {
+		req := httptest.NewRequest("GET", "/?"+query, nil)
+		if got := ParseExplain(req); got != want {
+			t.Errorf("ParseExplain(%q) = %v, want %v", query, got, want)
+		}
+	}
+}
+
 func TestGetAcceptFormat(t *testing.T) {
 	tests := []struct {
 		name   string
@@ -518,6 +951,7 @@ func TestGetAcceptFormat(t *testing.T) {
 		{"text/csv", "text/csv", "csv"},
 		{"application/parquet", "application/parquet", "parquet"},
 		{"application/vnd.apache.arrow", "application/vnd.apache.arrow.stream", "arrow"},
+		{"application/x-ndjson", "application/x-ndjson", "ndjson"},
 		{"text/html defaults to json", "text/html", "json"},
 		{"*/* defaults to json", "*/*", "json"},
 		{"csv with charset", "text/csv; charset=utf-8", "csv"},
@@ -536,6 +970,167 @@ func TestGetAcceptFormat(t *testing.T) {
 	}
 }
 
+func TestParseParquetCompression(t *testing.T) {
+	tests := []struct {
+		name    string
+		query   string
+		accept  string
+		want    string
+		wantErr bool
+	}{
+		{"none requested", "", "application/parquet", "", false},
+		{"query parameter", "compression=zstd", "", "zstd", false},
+		{"query parameter is case-insensitive", "compression=GZIP", "", "gzip", false},
+		{"uncompressed", "compression=none", "", "none", false},
+		{"accept parameter", "", "application/parquet; compression=zstd", "zstd", false},
+		{"quoted accept parameter", "", `application/json, application/parquet;q=0.9;compression="snappy"`, "snappy", false},
+		{"query parameter wins", "compression=gzip", "application/parquet; compression=zstd", "gzip", false},
+		{"other media type ignored", "", "text/csv; compression=zstd", "", false},
+		{"unknown codec", "compression=lzo", "", "", true},
+		{"unknown accept codec", "", "application/parquet; compression=brotli", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "/?"+tt.query, nil)
+			if tt.accept != "" {
+				req.Header.Set("Accept", tt.accept)
+			}
+			got, err := ParseParquetCompression(req)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseParquetCompression() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("ParseParquetCompression() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseCSVDialect(t *testing.T) {
+	tests := []struct {
+		name         string
+		query        string
+		wantComma    rune
+		wantQuoteAll bool
+		wantErr      bool
+	}{
+		{"none requested", "", 0, false, false},
+		{"semicolon by name", "delimiter=semicolon", ';', false, false},
+		{"literal semicolon", "delimiter=;", ';', false, false},
+		{"escaped semicolon", "delimiter=%3B", ';', false, false},
+		{"tab by name", "delimiter=TAB", '\t', false, false},
+		{"escaped tab", "delimiter=%09", '\t', false, false},
+		{"pipe", "delimiter=|", '|', false, false},
+		{"comma", "delimiter=,", ',', false, false},
+		{"quote all", "quote_all=true", 0, true, false},
+		{"both", "delimiter=pipe&quote_all=1", '|', true, false},
+		{"unsupported delimiter", "delimiter=:", 0, false, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "/", nil)
+			req.URL.RawQuery = tt.query
+			comma, quoteAll, err := ParseCSVDialect(req)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseCSVDialect() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if comma != tt.wantComma || quoteAll != tt.wantQuoteAll {
+				t.Errorf("ParseCSVDialect() = %q, %v, want %q, %v", comma, quoteAll, tt.wantComma, tt.wantQuoteAll)
+			}
+		})
+	}
+}
+
+func TestNegotiateCSVCharset(t *testing.T) {
+	tests := []struct {
+		name          string
+		acceptCharset string
+		defaultCS     string
+		reject        bool
+		want          string
+		wantOK        bool
+	}{
+		{"no header uses utf-8", "", "", false, "utf-8", true},
+		{"no header uses configured default", "", "windows-1252", false, "windows-1252", true},
+		{"supported charset", "windows-1252", "", false, "windows-1252", true},
+		{"alias is normalized", "latin1", "", false, "iso-8859-1", true},
+		{"q-values pick preferred", "utf-8;q=0.5, cp1252;q=0.9", "", false, "windows-1252", true},
+		{"unsupported skipped", "shift_jis, iso-8859-15;q=0.8", "", false, "iso-8859-15", true},
+		{"wildcard uses default", "*", "iso-8859-1", false, "iso-8859-1", true},
+		{"q=0 excluded", "windows-1252;q=0", "", false, "utf-8", true},
+		{"unsupported falls back to utf-8", "shift_jis", "windows-1252", false, "utf-8", true},
+		{"unsupported rejected", "shift_jis", "", true, "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "/", nil)
+			if tt.acceptCharset != "" {
+				req.Header.Set("Accept-Charset", tt.acceptCharset)
+			}
+			got, ok := NegotiateCSVCharset(req, tt.defaultCS, tt.reject)
+			if got != tt.want || ok != tt.wantOK {
+				t.Errorf("NegotiateCSVCharset() = %q, %v; want %q, %v", got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
+
+// testCatalogs resolves the catalogs memory (the main database) and archive.
+type testCatalogs struct{}
+
+func (testCatalogs) MainCatalog() string { return "memory" }
+
+func (testCatalogs) AttachedCatalog(name string) (string, error) {
+	for _, catalog := range []string{"memory", "archive"} {
+		if strings.EqualFold(catalog, name) {
+			return catalog, nil
+		}
+	}
+	return "", nil
+}
+
+func TestResolveTableName(t *testing.T) {
+	tests := []struct {
+		name      string
+		tableName string
+		query     string
+		want      string
+		wantErr   bool
+	}{
+		{"unqualified", "users", "", "users", false},
+		{"catalog parameter", "users", "catalog=archive", "archive.users", false},
+		{"qualified path", "archive.users", "", "archive.users", false},
+		{"catalog and qualified path", "archive.users", "catalog=other", "", true},
+		{"invalid catalog", "users", "catalog=a%3Bb", "", true},
+		{"too many parts", "a.b.users", "", "", true},
+		{"empty catalog part", ".users", "", "", true},
+		{"main catalog parameter", "users", "catalog=memory", "users", false},
+		{"main catalog path", "memory.users", "", "users", false},
+		{"main catalog case-insensitive", "MEMORY.users", "", "users", false},
+		{"catalog spelled as attached", "Archive.users", "", "archive.users", false},
+		{"unknown catalog", "missing.users", "", "", true},
+		{"unknown catalog parameter", "users", "catalog=missing", "", true},
+		{"main schema", "main.users", "", "", true},
+		{"main schema parameter", "users", "catalog=main", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "/?"+tt.query, nil)
+			got, err := ResolveTableName(req, tt.tableName, testCatalogs{})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ResolveTableName() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("ResolveTableName() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
 func TestSanitizeTableName(t *testing.T) {
 	tests := []struct {
 		name      string
@@ -631,6 +1226,13 @@ func TestParseGETQueryPath(t *testing.T) {
 			wantFormat: "arrow",
 			wantErr:    false,
 		},
+		{
+			name:       "valid NDJSON path",
+			path:       "/duckdb/query/SELECT%20*%20FROM%20data/result.ndjson",
+			wantSQL:    "SELECT * FROM data",
+			wantFormat: "ndjson",
+			wantErr:    false,
+		},
 		{
 			name:    "invalid prefix",
 			path:    "/api/query/SELECT%20*%20FROM%20users/result.json",
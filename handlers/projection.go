@@ -0,0 +1,60 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// projectionPrefix opens the subquery that projects a raw query onto a subset
+// of its columns. It ends in a newline so that the submitted query starts at
+// the beginning of a line and error columns stay valid.
+const projectionPrefix = "SELECT * FROM (\n"
+
+// isProjectableQuery reports whether a raw query can be wrapped in a subquery
+// to project its result (select=...). SHOW, DESCRIBE, and EXPLAIN cannot.
+func isProjectableQuery(sql string) bool {
+	trimmed := strings.TrimSpace(strings.ToUpper(sql))
+	return strings.HasPrefix(trimmed, "SELECT") || strings.HasPrefix(trimmed, "WITH")
+}
+
+// projectionSource returns the query with trailing semicolons removed, ready to
+// be embedded in a subquery. The closing parenthesis goes on its own line so a
+// trailing line comment cannot swallow it.
+func projectionSource(sql string) string {
+	return strings.TrimRight(strings.TrimSpace(sql), ";") + "\n)"
+}
+
+// resultColumns returns the output columns of a raw SELECT without reading its
+// rows. An error is the query's own error, with positions relative to
+// projectionPrefix + query.
+func (h *QueryHandler) resultColumns(ctx context.Context, sql string, params []interface{}) ([]string, error) {
+	rows, err := h.dbMgr.QueryMainContext(ctx, projectionPrefix+projectionSource(sql)+" AS q LIMIT 0", params...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	return rows.Columns()
+}
+
+// projectQuery wraps a raw SELECT so that it returns only the selected columns,
+// in the requested order. Selected columns are matched case-insensitively,
+// like DuckDB identifiers, against the query's result columns; unknown ones
+// are an error.
+func projectQuery(sql string, resultColumns, selected []string) (string, error) {
+	quoted := make([]string, len(selected))
+	for i, col := range selected {
+		match := ""
+		for _, result := range resultColumns {
+			if strings.EqualFold(result, col) {
+				match = result
+				break
+			}
+		}
+		if match == "" {
+			return "", fmt.Errorf("unknown column '%s' (the query returns: %s)", col, strings.Join(resultColumns, ", "))
+		}
+		quoted[i] = `"` + match + `"`
+	}
+	return "SELECT " + strings.Join(quoted, ", ") + " FROM (\n" + projectionSource(sql) + " AS q", nil
+}
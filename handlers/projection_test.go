@@ -0,0 +1,107 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestProjectQuery(t *testing.T) {
+	got, err := projectQuery("SELECT * FROM t;", []string{"id", "Name", "value"}, []string{"name", "id"})
+	if err != nil {
+		t.Fatalf("projectQuery failed: %v", err)
+	}
+	want := "SELECT \"Name\", \"id\" FROM (\nSELECT * FROM t\n) AS q"
+	if got != want {
+		t.Errorf("projectQuery() = %q, want %q", got, want)
+	}
+
+	if _, err := projectQuery("SELECT * FROM t", []string{"id"}, []string{"missing"}); err == nil {
+		t.Error("Expected error for an unknown column")
+	}
+}
+
+func TestQueryHandler_SelectProjection(t *testing.T) {
+	handler, _, cleanup := setupQueryHandler(t)
+	defer cleanup()
+
+	query := func(target, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("POST", target, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, addQueryAuthContext(req, "admin"))
+		return rec
+	}
+
+	// A parameterized query ending in a line comment, projected in the requested order
+	rec := query("/duckdb/query?select=name,id", `{"sql": "SELECT * FROM test_query WHERE id >= $1 ORDER BY id -- all columns", "params": [2]}`)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var result struct {
+		Data []map[string]interface{} `json:"data"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &result); err != nil {
+		t.Fatalf("Failed to parse response: %v", err)
+	}
+	if len(result.Data) != 2 {
+		t.Fatalf("Expected 2 rows, got %d", len(result.Data))
+	}
+	for _, row := range result.Data {
+		if len(row) != 2 || row["name"] == nil || row["id"] == nil {
+			t.Errorf("Expected only name and id, got %v", row)
+		}
+	}
+	if result.Data[0]["name"] != "Bob" {
+		t.Errorf("Expected Bob first, got %v", result.Data[0]["name"])
+	}
+
+	// CSV keeps the requested column order
+	sql := url.QueryEscape("SELECT * FROM test_query ORDER BY id")
+	req := httptest.NewRequest("GET", "/duckdb/query/"+sql+"/result.csv?select=value,name", nil)
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, addQueryAuthContext(req, "admin"))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if header, _, _ := strings.Cut(rec.Body.String(), "\n"); header != "value,name" {
+		t.Errorf("Expected CSV header 'value,name', got %q", header)
+	}
+
+	// Columns outside the query's result are rejected
+	rec = query("/duckdb/query?select=id,email", `{"sql": "SELECT id, name FROM test_query"}`)
+	if rec.Code != http.StatusBadRequest || !strings.Contains(rec.Body.String(), "unknown column 'email'") {
+		t.Errorf("Expected 400 naming the unknown column, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	// Statements that cannot be wrapped are rejected
+	rec = query("/duckdb/query?select=name", `{"sql": "SHOW TABLES"}`)
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400 for SHOW with select, got %d", rec.Code)
+	}
+}
+
+func TestQueryHandler_SelectProjection_SyntaxErrorPosition(t *testing.T) {
+	handler, _, cleanup := setupQueryHandler(t)
+	defer cleanup()
+
+	req := httptest.NewRequest("POST", "/duckdb/query?select=id", strings.NewReader(`{"sql": "SELECT id,, name FROM test_query"}`))
+	req.Header.Set("Content-Type", "application/json")
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, addQueryAuthContext(req, "admin"))
+
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("Expected status 400, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var result map[string]interface{}
+	if err := json.Unmarshal(rec.Body.Bytes(), &result); err != nil {
+		t.Fatalf("Failed to parse response: %v", err)
+	}
+	// The location refers to the submitted query, not the projection wrapper
+	if result["line"] != float64(1) || result["column"] != float64(11) {
+		t.Errorf("Expected line 1 column 11, got line %v column %v", result["line"], result["column"])
+	}
+}
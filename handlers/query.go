@@ -17,9 +17,22 @@ import (
 
 // QueryHandler handles raw SQL query execution.
 type QueryHandler struct {
-	dbMgr      *database.Manager
-	authorizer *auth.Authorizer
-	logger     *zap.Logger
+	dbMgr           *database.Manager
+	authorizer      *auth.Authorizer
+	absoluteMaxRows int
+	csvCharset      string
+	rejectCharset   bool
+	errorDetail     string
+	debugSQL        *DebugSQLConfig
+	responseShape   string
+	decimalAsNumber bool
+	arrowOpts       formats.ArrowOptions
+	parquetOpts     formats.ParquetOptions
+	validateTables  bool
+	coalesce        *coalescer
+	maintenance     func() bool
+	streams         *auth.StreamLimiter
+	logger          *zap.Logger
 }
 
 // NewQueryHandler creates a new query handler.
@@ -31,14 +44,59 @@ func NewQueryHandler(dbMgr *database.Manager, authorizer *auth.Authorizer, logge
 	}
 }
 
+// SetAbsoluteMaxRows sets the per-statement row cap for batch queries (0 disables the cap).
+func (h *QueryHandler) SetAbsoluteMaxRows(absoluteMaxRows int) {
+	h.absoluteMaxRows = absoluteMaxRows
+}
+
+// SetCSVCharset sets the default charset for CSV responses and whether requests
+// for unsupported charsets (via Accept-Charset) are rejected with 406.
+func (h *QueryHandler) SetCSVCharset(charset string, rejectUnsupported bool) {
+	h.csvCharset = charset
+	h.rejectCharset = rejectUnsupported
+}
+
+// SetArrowDictionaryThreshold dictionary-encodes string columns of Arrow
+// responses with at most threshold distinct values (0 disables it).
+func (h *QueryHandler) SetArrowDictionaryThreshold(threshold int) {
+	h.arrowOpts.DictionaryThreshold = threshold
+}
+
+// SetArrowBatchSize sets the number of rows per record batch of Arrow
+// responses (0 uses formats.DefaultArrowBatchSize).
+func (h *QueryHandler) SetArrowBatchSize(size int) {
+	h.arrowOpts.BatchSize = size
+}
+
+// SetParquetRowGroupSize sets the number of rows per row group of Parquet
+// responses (0 uses formats.DefaultParquetRowGroupSize).
+func (h *QueryHandler) SetParquetRowGroupSize(size int) {
+	h.parquetOpts.RowGroupSize = size
+}
+
+// SetErrorDetail sets how much of a database error is included in error
+// responses (ErrorDetailFull, ErrorDetailSafe, or ErrorDetailMinimal).
+func (h *QueryHandler) SetErrorDetail(level string) {
+	h.errorDetail = level
+}
+
+// SetStreamLimiter sets the limiter capping concurrent SSE streams per API key.
+func (h *QueryHandler) SetStreamLimiter(streams *auth.StreamLimiter) {
+	h.streams = streams
+}
+
 // ServeHTTP handles HTTP requests for raw SQL queries.
 // Supports both POST (with JSON body) and GET (with URL-encoded SQL in path).
 func (h *QueryHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	r = withQueryTag(r)
 	requestID := auth.GetRequestIDFromContext(r.Context())
 
 	// Check authorization for raw SQL queries
 	role := auth.GetRoleFromContext(r.Context())
+	timing := ServerTimingFromContext(r.Context())
+	stopAuth := timing.Start(TimingAuth)
 	allowed, err := h.authorizer.CheckPermission(role, "*", auth.OperationQuery)
+	stopAuth()
 	if err != nil {
 		h.logger.Error("Failed to check permission", zap.Error(err), zap.String("request_id", requestID))
 		h.sendErrorWithRequest(w, r, "Failed to check permission", http.StatusInternalServerError)
@@ -49,6 +107,16 @@ func (h *QueryHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// Route sub-endpoints: /duckdb/query/batch, /duckdb/query/sse
+	switch strings.TrimSuffix(r.URL.Path, "/") {
+	case "/duckdb/query/batch":
+		h.handleBatch(w, r)
+		return
+	case "/duckdb/query/sse":
+		h.handleSSE(w, r)
+		return
+	}
+
 	var sqlQuery string
 	var params []interface{}
 	var format string
@@ -101,36 +169,86 @@ func (h *QueryHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// Report tables that do not exist before executing, suggesting the closest match
+	if h.validateTables && h.isSelectQuery(sqlQuery) {
+		if err := h.checkTables(r.Context(), sqlQuery); err != nil {
+			h.sendErrorWithRequest(w, r, fmt.Sprintf("Invalid query: %s", err.Error()), http.StatusBadRequest)
+			return
+		}
+	}
+
+	// Negotiate the output charset and dialect for CSV results
+	var csvOpts formats.CSVOptions
+	if format == "csv" {
+		var ok bool
+		if csvOpts.Charset, ok = NegotiateCSVCharset(r, h.csvCharset, h.rejectCharset); !ok {
+			h.sendErrorWithRequest(w, r, fmt.Sprintf("None of the requested charsets are supported: %s", r.Header.Get("Accept-Charset")), http.StatusNotAcceptable)
+			return
+		}
+		var err error
+		if csvOpts.Delimiter, csvOpts.QuoteAll, err = ParseCSVDialect(r); err != nil {
+			h.sendErrorWithRequest(w, r, fmt.Sprintf("Invalid CSV options: %s", err.Error()), http.StatusBadRequest)
+			return
+		}
+	}
+	parquetOpts := h.parquetOpts
+	if format == "parquet" {
+		var err error
+		if parquetOpts.Compression, err = ParseParquetCompression(r); err != nil {
+			h.sendErrorWithRequest(w, r, fmt.Sprintf("Invalid compression: %s", err.Error()), http.StatusBadRequest)
+			return
+		}
+	}
+
 	// Log the query (be careful with sensitive data in production)
 	h.logger.Info("Executing query",
 		zap.String("role", role),
 		zap.String("method", r.Method),
 		zap.String("sql", sqlQuery),
 		zap.String("format", format),
+		zap.String("label", auth.GetQueryLabelFromContext(r.Context())),
 		zap.String("request_id", requestID),
 	)
 
-	// Execute query with read-write separation for optimal performance
-	// Read-only queries (SELECT) don't use transactions, while write queries use ExecMain
-	startTime := time.Now()
-
-	if h.isSelectQuery(sqlQuery) {
-		// Read-only query - use QueryMain for better concurrency (no transaction overhead)
-		rows, err := h.dbMgr.QueryMain(sqlQuery, params...)
-		_ = time.Since(startTime) // execution time tracked but not used in response
-
+	// Project the result onto the columns requested with select=
+	selected, err := ParseSelect(r)
+	if err != nil {
+		h.sendErrorWithRequest(w, r, fmt.Sprintf("Invalid select parameter: %s", err.Error()), http.StatusBadRequest)
+		return
+	}
+	if selected != nil {
+		if !isProjectableQuery(sqlQuery) {
+			h.sendErrorWithRequest(w, r, "select is only supported for SELECT and WITH queries", http.StatusBadRequest)
+			return
+		}
+		resultColumns, err := h.resultColumns(r.Context(), sqlQuery, params)
 		if err != nil {
 			h.logger.Error("Failed to execute query", zap.Error(err), zap.String("sql", sqlQuery), zap.String("request_id", requestID))
-			h.sendErrorWithRequest(w, r, fmt.Sprintf("Query execution failed: %s", err.Error()), http.StatusInternalServerError)
+			h.sendWrappedQueryErrorWithRequest(w, r, "Query execution failed", err, sqlQuery, projectionPrefix)
+			return
+		}
+		if sqlQuery, err = projectQuery(sqlQuery, resultColumns, selected); err != nil {
+			h.sendErrorWithRequest(w, r, fmt.Sprintf("Invalid select parameter: %s", err.Error()), http.StatusBadRequest)
 			return
 		}
-		defer rows.Close()
+	}
+
+	var debug map[string]interface{}
+	if h.debugSQL.allows(r) {
+		debug = debugObject(database.Statement{SQL: sqlQuery, Params: h.debugSQL.redactParams(params)})
+	}
 
-		// Format and return results (same format as /api endpoint)
-		if err := h.formatQueryResponse(w, rows, format); err != nil {
-			h.logger.Error("Failed to format response", zap.Error(err), zap.String("request_id", requestID))
-			h.sendErrorWithRequest(w, r, "Failed to format response", http.StatusInternalServerError)
+	if h.isSelectQuery(sqlQuery) {
+		// Identical concurrent JSON queries share one execution; debug output is per request
+		if h.coalesce != nil && format == "json" && debug == nil {
+			if key, ok := queryCoalesceKey(role, sqlQuery, params); ok {
+				h.coalesce.serve(w, r, key, func(w http.ResponseWriter, r *http.Request) {
+					h.executeSelect(w, r, sqlQuery, params, format, csvOpts, parquetOpts, nil)
+				})
+				return
+			}
 		}
+		h.executeSelect(w, r, sqlQuery, params, format, csvOpts, parquetOpts, debug)
 	} else {
 		// Write query (INSERT, UPDATE, DELETE, CREATE, etc.)
 		// Only allowed for POST requests to prevent accidental modifications via GET
@@ -138,63 +256,191 @@ func (h *QueryHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 			h.sendErrorWithRequest(w, r, "GET requests can only execute read-only queries (SELECT, SHOW, DESCRIBE, EXPLAIN)", http.StatusMethodNotAllowed)
 			return
 		}
+		if inMaintenance(h.maintenance) {
+			h.sendErrorWithRequest(w, r, maintenanceModeMessage, http.StatusServiceUnavailable)
+			return
+		}
 
 		// Use ExecMain for write queries
-		result, err := h.dbMgr.ExecMain(sqlQuery, params...)
+		startTime := time.Now()
+		result, err := h.dbMgr.ExecPreparedContext(r.Context(), sqlQuery, params...)
 		executionTime := time.Since(startTime)
+		timing.Add(TimingDB, executionTime)
 
 		if err != nil {
 			h.logger.Error("Failed to execute DML query", zap.Error(err), zap.String("sql", sqlQuery), zap.String("request_id", requestID))
-			h.sendErrorWithRequest(w, r, fmt.Sprintf("Query execution failed: %s", err.Error()), http.StatusInternalServerError)
+			h.sendQueryErrorWithRequest(w, r, "Query execution failed", err, sqlQuery)
 			return
 		}
 
 		rowsAffected, _ := result.RowsAffected()
-		h.sendDMLResponseWithRequest(w, r, rowsAffected, executionTime)
+		setQueryStats(w, executionTime, -1)
+		h.sendDMLResponseWithRequest(w, r, rowsAffected, executionTime, debug)
+	}
+}
+
+// executeSelect runs a read-only query and writes its formatted result.
+// Read-only queries use QueryMain for better concurrency (no transaction overhead).
+func (h *QueryHandler) executeSelect(w http.ResponseWriter, r *http.Request, sqlQuery string, params []interface{}, format string, csvOpts formats.CSVOptions, parquetOpts formats.ParquetOptions, debug map[string]interface{}) {
+	requestID := auth.GetRequestIDFromContext(r.Context())
+	timing := ServerTimingFromContext(r.Context())
+
+	startTime := time.Now()
+	rows, err := h.dbMgr.QueryPreparedContext(r.Context(), sqlQuery, params...)
+	timing.Add(TimingDB, time.Since(startTime))
+
+	if err != nil {
+		h.logger.Error("Failed to execute query", zap.Error(err), zap.String("sql", sqlQuery), zap.String("request_id", requestID))
+		h.sendQueryErrorWithRequest(w, r, "Query execution failed", err, sqlQuery)
+		return
+	}
+	defer rows.Close()
+
+	// Format and return results (same format as /api endpoint)
+	defer timing.Start(TimingSer)()
+	if err := h.formatQueryResponse(w, rows, format, csvOpts, parquetOpts, debug, startTime); err != nil {
+		h.logger.Error("Failed to format response", zap.Error(err), zap.String("request_id", requestID))
+		h.sendErrorWithRequest(w, r, "Failed to format response", http.StatusInternalServerError)
 	}
 }
 
 // formatQueryResponse formats the query result.
 // Uses the same JSON format as the CRUD /api endpoint for consistency.
-func (h *QueryHandler) formatQueryResponse(w http.ResponseWriter, rows *sql.Rows, format string) error {
+// debug is included in JSON responses when non-nil. The query statistics
+// headers report the time since queryStart.
+func (h *QueryHandler) formatQueryResponse(w http.ResponseWriter, rows *sql.Rows, format string, csvOpts formats.CSVOptions, parquetOpts formats.ParquetOptions, debug map[string]interface{}, queryStart time.Time) error {
+	// Streamed formats write rows as they are read, so their row count is unknown
+	switch format {
+	case "csv", "parquet", "arrow", "ndjson":
+		setQueryStats(w, time.Since(queryStart), -1)
+	}
+
 	switch format {
 	case "csv":
-		return formats.WriteCSV(w, rows)
-	case "json":
-		// Use same format as /api endpoint: data as array of objects, no pagination
-		return formats.WriteJSON(w, rows, 1, 0, 0, false, 0, nil)
+		return formats.WriteCSVWithOptions(w, rows, csvOpts)
 	case "parquet":
-		return formats.WriteParquet(w, rows)
+		return formats.WriteParquetWithOptions(w, rows, parquetOpts)
 	case "arrow":
-		return formats.WriteArrowIPC(w, rows)
+		return formats.WriteArrowIPCWithOptions(w, rows, h.arrowOpts)
+	case "ndjson":
+		return formats.WriteNDJSONWithOptions(w, rows, formats.JSONOptions{DecimalAsNumber: h.decimalAsNumber})
 	default:
-		// Use same format as /api endpoint: data as array of objects, no pagination
-		return formats.WriteJSON(w, rows, 1, 0, 0, false, 0, nil)
+		return h.writeQueryJSON(w, rows, debug, queryStart)
 	}
 }
 
+// writeQueryJSON writes a query result in the same format as the /api endpoint:
+// data as an array of objects, without pagination. The rows are scanned before
+// anything is written, so the statistics headers include the row count.
+func (h *QueryHandler) writeQueryJSON(w http.ResponseWriter, rows *sql.Rows, debug map[string]interface{}, queryStart time.Time) error {
+	columns, data, _, err := formats.ScanRows(rows, 0)
+	if err != nil {
+		return err
+	}
+	setQueryStats(w, time.Since(queryStart), int64(len(data)))
+	return formats.WriteJSONDataWithOptions(w, columns, data, 1, 0, 0, false, 0, nil, formats.JSONOptions{Debug: debug, Shape: h.responseShape, DecimalAsNumber: h.decimalAsNumber})
+}
+
 // sendDMLResponseWithRequest sends a response for DML queries.
-// The request ID is available in the X-Request-ID response header.
-func (h *QueryHandler) sendDMLResponseWithRequest(w http.ResponseWriter, r *http.Request, rowsAffected int64, executionTime time.Duration) {
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusOK)
-	json.NewEncoder(w).Encode(map[string]interface{}{
+// The body includes the request ID, as does the X-Request-ID response header.
+// debug is included when non-nil.
+func (h *QueryHandler) sendDMLResponseWithRequest(w http.ResponseWriter, r *http.Request, rowsAffected int64, executionTime time.Duration, debug map[string]interface{}) {
+	response := map[string]interface{}{
 		"success":           true,
 		"rows_affected":     rowsAffected,
 		"execution_time_ms": executionTime.Milliseconds(),
-	})
+		"request_id":        auth.GetRequestIDFromContext(r.Context()),
+	}
+	if debug != nil {
+		response["debug"] = debug
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(response)
 }
 
 // sendErrorWithRequest sends an error response.
-// The request ID is available in the X-Request-ID response header.
+// The body includes the request ID, as does the X-Request-ID response header.
 func (h *QueryHandler) sendErrorWithRequest(w http.ResponseWriter, r *http.Request, message string, statusCode int) {
+	writeError(w, r, h.errorDetail, message, nil, statusCode)
+}
+
+// sendDetailedErrorWithRequest sends an error response for a failed database
+// operation. err is only included in the message at full error detail, so the
+// caller must log it.
+func (h *QueryHandler) sendDetailedErrorWithRequest(w http.ResponseWriter, r *http.Request, message string, err error, statusCode int) {
+	writeError(w, r, h.errorDetail, message, err, statusCode)
+}
+
+// sendQueryErrorWithRequest sends an error response for a failed query.
+// Syntax errors are client errors: they return 400 with the DuckDB message, the
+// error position in the query and any hint as structured fields, so query editors
+// can highlight the problem. All other errors return 500. Below full error
+// detail, the DuckDB message and location are left out.
+func (h *QueryHandler) sendQueryErrorWithRequest(w http.ResponseWriter, r *http.Request, prefix string, err error, query string) {
+	h.sendWrappedQueryErrorWithRequest(w, r, prefix, err, query, "")
+}
+
+// sendWrappedQueryErrorWithRequest is like sendQueryErrorWithRequest for a query
+// that was executed with wrap (ending in a newline) in front of it, as when
+// probing the columns for select=. Error locations are shifted past it.
+func (h *QueryHandler) sendWrappedQueryErrorWithRequest(w http.ResponseWriter, r *http.Request, prefix string, err error, query, wrap string) {
+	if CategorizeError(err) != ErrorCategorySyntax {
+		h.sendDetailedErrorWithRequest(w, r, prefix, err, http.StatusInternalServerError)
+		return
+	}
+	if h.errorDetail == ErrorDetailSafe || h.errorDetail == ErrorDetailMinimal {
+		h.sendDetailedErrorWithRequest(w, r, prefix, err, http.StatusBadRequest)
+		return
+	}
+
+	detail := ParseQueryError(err, query)
+	if tag := h.dbMgr.QueryTagPrefix(r.Context()) + wrap; tag != "" {
+		// Error locations refer to the executed query; shift them past the tag comment
+		// and wrapper
+		detail = ParseQueryError(err, tag+query)
+		tagLines := strings.Count(tag, "\n")
+		if detail.Line > tagLines {
+			detail.Line -= tagLines
+			detail.Position -= len([]rune(tag))
+		} else {
+			detail.Line, detail.Column, detail.Position = 0, 0, 0
+		}
+	}
+	response := map[string]interface{}{
+		"error":      http.StatusText(http.StatusBadRequest),
+		"message":    fmt.Sprintf("%s: %s", prefix, detail.Message),
+		"code":       http.StatusBadRequest,
+		"category":   string(ErrorCategorySyntax),
+		"request_id": auth.GetRequestIDFromContext(r.Context()),
+	}
+	if detail.Position > 0 {
+		response["position"] = detail.Position
+	}
+	if detail.Line > 0 && detail.Column > 0 {
+		response["line"] = detail.Line
+		response["column"] = detail.Column
+	}
+	if detail.Hint != "" {
+		response["hint"] = detail.Hint
+	}
+
 	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(statusCode)
-	json.NewEncoder(w).Encode(map[string]interface{}{
-		"error":   http.StatusText(statusCode),
-		"message": message,
-		"code":    statusCode,
-	})
+	w.WriteHeader(http.StatusBadRequest)
+	json.NewEncoder(w).Encode(response)
+}
+
+// withQueryTag attaches the request ID, role, API key namespace, and query label
+// to the request context so that queries executed with it are tagged when query
+// tagging is enabled.
+func withQueryTag(r *http.Request) *http.Request {
+	tag := database.QueryTag{
+		RequestID: auth.GetRequestIDFromContext(r.Context()),
+		Role:      auth.GetRoleFromContext(r.Context()),
+		Namespace: auth.GetNamespaceFromContext(r.Context()),
+		Label:     auth.GetQueryLabelFromContext(r.Context()),
+	}
+	return r.WithContext(database.WithQueryTag(r.Context(), tag))
 }
 
 // isSelectQuery checks if the SQL query is a SELECT query.
@@ -219,6 +465,7 @@ var (
 		regexp.MustCompile(`\bapi_keys\b`),
 		regexp.MustCompile(`\broles\b`),
 		regexp.MustCompile(`\bpermissions\b`),
+		regexp.MustCompile(`\bdownload_tokens\b`),
 	}
 )
 
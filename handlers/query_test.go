@@ -11,6 +11,8 @@ import (
 	"testing"
 	"time"
 
+	"github.com/apache/arrow/go/v18/parquet/compress"
+	"github.com/apache/arrow/go/v18/parquet/file"
 	"github.com/tobilg/caddy-duckdb-module/auth"
 	"github.com/tobilg/caddy-duckdb-module/database"
 	"go.uber.org/zap"
@@ -77,6 +79,56 @@ func addQueryAuthContext(r *http.Request, role string) *http.Request {
 	return r.WithContext(ctx)
 }
 
+func TestQueryHandler_QueryTagging(t *testing.T) {
+	mgr, err := database.NewManagerForTesting(database.Config{
+		MainDBPath:   ":memory:",
+		AuthDBPath:   ":memory:",
+		Threads:      1,
+		AccessMode:   "read_write",
+		QueryTimeout: 30 * time.Second,
+		QueryTagging: true,
+		Logger:       zap.NewNop(),
+	})
+	if err != nil {
+		t.Fatalf("Failed to create manager: %v", err)
+	}
+	defer mgr.Close()
+	handler := NewQueryHandler(mgr, auth.NewAuthorizer(mgr.AuthDB()), zap.NewNop())
+
+	post := func(sql string) *httptest.ResponseRecorder {
+		body, _ := json.Marshal(map[string]string{"sql": sql})
+		req := httptest.NewRequest("POST", "/duckdb/query", bytes.NewReader(body))
+		req = addQueryAuthContext(req, "admin")
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec
+	}
+
+	rec := post("SELECT current_query() AS q")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var result map[string]interface{}
+	if err := json.Unmarshal(rec.Body.Bytes(), &result); err != nil {
+		t.Fatalf("Failed to parse response: %v", err)
+	}
+	query := result["data"].([]interface{})[0].(map[string]interface{})["q"].(string)
+	if !strings.HasPrefix(query, "/* req=test-request-id role=admin */\n") {
+		t.Errorf("Expected query to be tagged, got %q", query)
+	}
+
+	// Syntax error positions refer to the client's query, not the tagged one
+	rec = post("SELECT 1,, 2")
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("Expected status 400, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var errResult map[string]interface{}
+	json.Unmarshal(rec.Body.Bytes(), &errResult)
+	if errResult["line"] != float64(1) || errResult["position"] != float64(10) {
+		t.Errorf("Expected line 1 and position 10, got %v", errResult)
+	}
+}
+
 func TestNewQueryHandler(t *testing.T) {
 	handler, _, cleanup := setupQueryHandler(t)
 	defer cleanup()
@@ -272,8 +324,42 @@ func TestQueryHandler_POST_InvalidSQL(t *testing.T) {
 	rec := httptest.NewRecorder()
 	handler.ServeHTTP(rec, req)
 
-	if rec.Code != http.StatusInternalServerError {
-		t.Errorf("Expected status 500, got %d", rec.Code)
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400 for syntax error, got %d", rec.Code)
+	}
+}
+
+func TestQueryHandler_SyntaxErrorPosition(t *testing.T) {
+	handler, _, cleanup := setupQueryHandler(t)
+	defer cleanup()
+
+	body := `{"sql": "SELECT id,, name FROM test_query"}`
+	req := httptest.NewRequest("POST", "/duckdb/query", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	req = addQueryAuthContext(req, "admin")
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("Expected status 400, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	var result map[string]interface{}
+	if err := json.Unmarshal(rec.Body.Bytes(), &result); err != nil {
+		t.Fatalf("Failed to parse response: %v", err)
+	}
+	if result["category"] != "syntax" {
+		t.Errorf("Expected category 'syntax', got %v", result["category"])
+	}
+	if msg, _ := result["message"].(string); !strings.Contains(msg, "syntax error") {
+		t.Errorf("Expected DuckDB syntax error message, got %v", result["message"])
+	}
+	if result["position"] != float64(11) { // the second comma is character 11
+		t.Errorf("Expected position 11, got %v", result["position"])
+	}
+	if result["line"] != float64(1) || result["column"] != float64(11) {
+		t.Errorf("Expected line 1 column 11, got line %v column %v", result["line"], result["column"])
 	}
 }
 
@@ -332,6 +418,40 @@ func TestQueryHandler_GET_CSVFormat(t *testing.T) {
 	}
 }
 
+func TestQueryHandler_GET_NDJSONFormat(t *testing.T) {
+	handler, _, cleanup := setupQueryHandler(t)
+	defer cleanup()
+
+	sql := url.QueryEscape("SELECT id, name FROM test_query ORDER BY id")
+	req := httptest.NewRequest("GET", "/duckdb/query/"+sql+"/result.ndjson", nil)
+	req = addQueryAuthContext(req, "admin")
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if ct := rec.Header().Get("Content-Type"); ct != "application/x-ndjson" {
+		t.Errorf("Expected Content-Type application/x-ndjson, got %s", ct)
+	}
+
+	lines := strings.Split(strings.TrimSpace(rec.Body.String()), "\n")
+	var first map[string]interface{}
+	if err := json.Unmarshal([]byte(lines[0]), &first); err != nil {
+		t.Fatalf("Failed to parse first line: %v", err)
+	}
+	if first["name"] != "Alice" {
+		t.Errorf("Expected first row Alice, got %v", first)
+	}
+	for i, line := range lines {
+		var record map[string]interface{}
+		if err := json.Unmarshal([]byte(line), &record); err != nil {
+			t.Errorf("Line %d is not a JSON object: %q", i, line)
+		}
+	}
+}
+
 func TestQueryHandler_GET_DMLQuery_NotAllowed(t *testing.T) {
 	handler, _, cleanup := setupQueryHandler(t)
 	defer cleanup()
@@ -534,6 +654,63 @@ func TestQueryHandler_AcceptHeader_CSV(t *testing.T) {
 	}
 }
 
+func TestQueryHandler_AcceptCharset_Windows1252(t *testing.T) {
+	handler, _, cleanup := setupQueryHandler(t)
+	defer cleanup()
+
+	body := `{"sql": "SELECT 'Müller' AS name"}`
+	req := httptest.NewRequest("POST", "/duckdb/query", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "text/csv")
+	req.Header.Set("Accept-Charset", "windows-1252")
+	req = addQueryAuthContext(req, "admin")
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if ct := rec.Header().Get("Content-Type"); ct != "text/csv; charset=windows-1252" {
+		t.Errorf("Expected Content-Type 'text/csv; charset=windows-1252', got '%s'", ct)
+	}
+	// ü is a single 0xFC byte in windows-1252
+	if got := rec.Body.String(); got != "name\nM\xfcller\n" {
+		t.Errorf("Expected windows-1252 encoded body, got %q", got)
+	}
+}
+
+func TestQueryHandler_AcceptCharset_Unsupported(t *testing.T) {
+	handler, _, cleanup := setupQueryHandler(t)
+	defer cleanup()
+
+	body := `{"sql": "SELECT 1 AS n"}`
+	newRequest := func() *http.Request {
+		req := httptest.NewRequest("POST", "/duckdb/query", strings.NewReader(body))
+		req.Header.Set("Accept", "text/csv")
+		req.Header.Set("Accept-Charset", "shift_jis")
+		return addQueryAuthContext(req, "admin")
+	}
+
+	// Falls back to UTF-8 by default
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, newRequest())
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if ct := rec.Header().Get("Content-Type"); ct != "text/csv; charset=utf-8" {
+		t.Errorf("Expected Content-Type 'text/csv; charset=utf-8', got '%s'", ct)
+	}
+
+	// Rejected with 406 when configured
+	handler.SetCSVCharset("utf-8", true)
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, newRequest())
+	if rec.Code != http.StatusNotAcceptable {
+		t.Errorf("Expected status 406, got %d: %s", rec.Code, rec.Body.String())
+	}
+}
+
 func TestQueryHandler_AcceptHeader_Parquet(t *testing.T) {
 	handler, _, cleanup := setupQueryHandler(t)
 	defer cleanup()
@@ -557,6 +734,49 @@ func TestQueryHandler_AcceptHeader_Parquet(t *testing.T) {
 	}
 }
 
+func TestQueryHandler_ParquetCompression(t *testing.T) {
+	handler, _, cleanup := setupQueryHandler(t)
+	defer cleanup()
+
+	query := func(accept string) *httptest.ResponseRecorder {
+		body := `{"sql": "SELECT * FROM test_query ORDER BY id"}`
+		req := httptest.NewRequest("POST", "/duckdb/query", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Accept", accept)
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, addQueryAuthContext(req, "admin"))
+		return rec
+	}
+
+	rec := query("application/parquet; compression=zstd")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if codec := firstColumnCodec(t, rec.Body.Bytes()); codec != compress.Codecs.Zstd {
+		t.Errorf("Expected ZSTD compression, got %s", codec)
+	}
+
+	rec = query("application/parquet; compression=lzo")
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400 for an unknown codec, got %d", rec.Code)
+	}
+}
+
+// firstColumnCodec returns the compression codec of the first column chunk of a Parquet file.
+func firstColumnCodec(t *testing.T, data []byte) compress.Compression {
+	t.Helper()
+	reader, err := file.NewParquetReader(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("Failed to read Parquet: %v", err)
+	}
+	defer reader.Close()
+	chunk, err := reader.MetaData().RowGroup(0).ColumnChunk(0)
+	if err != nil {
+		t.Fatalf("Failed to read column chunk metadata: %v", err)
+	}
+	return chunk.Compression()
+}
+
 func TestQueryHandler_AcceptHeader_Arrow(t *testing.T) {
 	handler, _, cleanup := setupQueryHandler(t)
 	defer cleanup()
@@ -0,0 +1,40 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// Query statistics response headers. They are set on reads of every format, so
+// clients can log performance even when the body (CSV, Parquet, Arrow) cannot
+// carry it. Neither names tables, columns, or SQL.
+const (
+	// QueryTimeHeader holds the time in milliseconds spent running the request's
+	// queries before the response was written.
+	QueryTimeHeader = "X-Query-Time-Ms"
+	// RowsReadHeader holds the number of rows in the response. It is only set
+	// when the number is known before the body is written.
+	RowsReadHeader = "X-Rows-Read"
+)
+
+// setQueryStats sets the query statistics headers. rows < 0 leaves out RowsReadHeader.
+func setQueryStats(w http.ResponseWriter, queryTime time.Duration, rows int64) {
+	w.Header().Set(QueryTimeHeader, strconv.FormatFloat(float64(queryTime.Microseconds())/1000, 'f', 2, 64))
+	if rows >= 0 {
+		w.Header().Set(RowsReadHeader, strconv.FormatInt(rows, 10))
+	}
+}
+
+// pageRows returns the number of rows a read of limit rows starting at offset
+// returns when total rows match.
+func pageRows(total int64, limit, offset int) int64 {
+	rows := total - int64(offset)
+	if rows < 0 {
+		return 0
+	}
+	if limit > 0 && rows > int64(limit) {
+		return int64(limit)
+	}
+	return rows
+}
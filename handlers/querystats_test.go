@@ -0,0 +1,105 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestPageRows(t *testing.T) {
+	tests := []struct {
+		total         int64
+		limit, offset int
+		want          int64
+	}{
+		{total: 3, limit: 0, offset: 0, want: 3},
+		{total: 3, limit: 2, offset: 0, want: 2},
+		{total: 3, limit: 2, offset: 2, want: 1},
+		{total: 3, limit: 2, offset: 4, want: 0},
+		{total: 0, limit: 10, offset: 0, want: 0},
+	}
+	for _, tt := range tests {
+		if got := pageRows(tt.total, tt.limit, tt.offset); got != tt.want {
+			t.Errorf("pageRows(%d, %d, %d) = %d, want %d", tt.total, tt.limit, tt.offset, got, tt.want)
+		}
+	}
+}
+
+// assertQueryTime checks that the response reports a non-negative query time.
+func assertQueryTime(t *testing.T, rec *httptest.ResponseRecorder) {
+	t.Helper()
+	value := rec.Header().Get(QueryTimeHeader)
+	ms, err := strconv.ParseFloat(value, 64)
+	if err != nil || ms < 0 {
+		t.Errorf("Expected a query time in %s, got %q", QueryTimeHeader, value)
+	}
+}
+
+func TestCRUDHandler_Read_QueryStatsCSV(t *testing.T) {
+	handler, _, cleanup := setupTestHandler(t)
+	defer cleanup()
+
+	tests := []struct {
+		query string
+		want  string
+	}{
+		{query: "", want: "3"},
+		{query: "?limit=2&page=1", want: "2"},
+		{query: "?limit=2&page=2", want: "1"},
+		{query: "?filter=age:gt:100", want: "0"},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest("GET", "/duckdb/api/test_users"+tt.query, nil)
+		req.Header.Set("Accept", "text/csv")
+		req = addAuthContext(req, "admin")
+
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+
+		if rec.Code != http.StatusOK {
+			t.Fatalf("%q: expected status 200, got %d: %s", tt.query, rec.Code, rec.Body.String())
+		}
+		assertQueryTime(t, rec)
+		if got := rec.Header().Get(RowsReadHeader); got != tt.want {
+			t.Errorf("%q: expected %s %s, got %q", tt.query, RowsReadHeader, tt.want, got)
+		}
+	}
+}
+
+func TestQueryHandler_QueryStats(t *testing.T) {
+	handler, _, cleanup := setupQueryHandler(t)
+	defer cleanup()
+
+	send := func(body, accept string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("POST", "/duckdb/query", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		if accept != "" {
+			req.Header.Set("Accept", accept)
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, addQueryAuthContext(req, "admin"))
+		if rec.Code != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d: %s", rec.Code, rec.Body.String())
+		}
+		return rec
+	}
+
+	// JSON is scanned before it is written, so it reports the row count
+	rec := send(`{"sql": "SELECT * FROM test_query ORDER BY id"}`, "")
+	assertQueryTime(t, rec)
+	if got := rec.Header().Get(RowsReadHeader); got != "3" {
+		t.Errorf("Expected %s 3, got %q", RowsReadHeader, got)
+	}
+
+	// CSV is streamed: only the time is known up front
+	rec = send(`{"sql": "SELECT * FROM test_query ORDER BY id"}`, "text/csv")
+	assertQueryTime(t, rec)
+	if got := rec.Header().Get(RowsReadHeader); got != "" {
+		t.Errorf("Expected no %s for a streamed CSV result, got %q", RowsReadHeader, got)
+	}
+
+	rec = send(`{"sql": "UPDATE test_query SET value = 0 WHERE id = 1"}`, "")
+	assertQueryTime(t, rec)
+}
@@ -0,0 +1,33 @@
+package handlers
+
+import "net/http"
+
+// maintenanceModeMessage is the error message of writes refused in maintenance mode.
+const maintenanceModeMessage = "Maintenance mode: writes are temporarily disabled"
+
+// SetMaintenanceMode sets the check for runtime maintenance mode. While active
+// reports true, write requests are rejected with 503; reads are unaffected.
+func (h *CRUDHandler) SetMaintenanceMode(active func() bool) {
+	h.maintenance = active
+}
+
+// SetMaintenanceMode sets the check for runtime maintenance mode. While active
+// reports true, write queries are rejected with 503; SELECT queries are unaffected.
+func (h *QueryHandler) SetMaintenanceMode(active func() bool) {
+	h.maintenance = active
+}
+
+// inMaintenance reports whether the maintenance mode check is set and active.
+func inMaintenance(active func() bool) bool {
+	return active != nil && active()
+}
+
+// isWriteRequest reports whether a table API request modifies data. Creating a
+// download link is a POST but only grants a read.
+func isWriteRequest(r *http.Request) bool {
+	switch r.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return false
+	}
+	return ExtractActionFromPath(r.URL.Path) != "download-token"
+}
@@ -0,0 +1,63 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/tobilg/caddy-duckdb-module/auth"
+)
+
+// Row limit policies decide what a read without pagination returns when the
+// result has more rows than absolute_max_rows.
+const (
+	// RowLimitTruncate returns the first absolute_max_rows rows and flags the
+	// response with "truncated": true (the default).
+	RowLimitTruncate = "truncate"
+	// RowLimitError fails the read with 400, telling the client to paginate.
+	RowLimitError = "error"
+)
+
+// IsValidRowLimitPolicy reports whether policy is a supported row limit
+// policy. An empty policy means RowLimitTruncate.
+func IsValidRowLimitPolicy(policy string) bool {
+	switch policy {
+	case "", RowLimitTruncate, RowLimitError:
+		return true
+	}
+	return false
+}
+
+// SetRowLimitPolicy sets what reads exceeding absolute_max_rows return
+// (RowLimitTruncate or RowLimitError).
+func (h *CRUDHandler) SetRowLimitPolicy(policy string) {
+	h.rowLimitPolicy = policy
+}
+
+// rowLimitExceeded reports whether a read without pagination of totalRows
+// matching rows must fail under the row limit policy.
+func (h *CRUDHandler) rowLimitExceeded(paginationRequested bool, totalRows int64) bool {
+	return h.rowLimitPolicy == RowLimitError && !paginationRequested &&
+		h.absoluteMaxRows > 0 && totalRows > int64(h.absoluteMaxRows)
+}
+
+// sendRowLimitError sends a 400 response for a read exceeding absolute_max_rows,
+// with the limit, the number of matching rows, and how to paginate.
+func (h *CRUDHandler) sendRowLimitError(w http.ResponseWriter, r *http.Request, totalRows int64) {
+	message := fmt.Sprintf("Result too large: %d rows match, more than the limit of %d. Use pagination (?limit=X&page=Y) to read them.", totalRows, h.absoluteMaxRows)
+	if h.errorDetail == ErrorDetailMinimal {
+		h.sendErrorWithRequest(w, r, message, http.StatusBadRequest)
+		return
+	}
+	response := map[string]interface{}{
+		"error":           http.StatusText(http.StatusBadRequest),
+		"message":         message,
+		"code":            http.StatusBadRequest,
+		"limit":           h.absoluteMaxRows,
+		"total_available": totalRows,
+		"request_id":      auth.GetRequestIDFromContext(r.Context()),
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusBadRequest)
+	json.NewEncoder(w).Encode(response)
+}
@@ -0,0 +1,88 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/tobilg/caddy-duckdb-module/database"
+)
+
+func TestCRUDHandler_Read_RowLimitPolicy(t *testing.T) {
+	handler, mgr, cleanup := setupTestHandler(t)
+	defer cleanup()
+	handler.absoluteMaxRows = 2 // test_users has 3 rows
+
+	read := func(query string) (*httptest.ResponseRecorder, map[string]interface{}) {
+		req := httptest.NewRequest("GET", "/duckdb/api/test_users"+query, nil)
+		req = addAuthContext(req, "admin")
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		var body map[string]interface{}
+		json.Unmarshal(rec.Body.Bytes(), &body)
+		return rec, body
+	}
+
+	// truncate (the default) returns the first rows and flags the response
+	for _, policy := range []string{"", RowLimitTruncate} {
+		handler.SetRowLimitPolicy(policy)
+		rec, body := read("")
+		if rec.Code != http.StatusOK {
+			t.Fatalf("Policy %q: expected status 200, got %d: %s", policy, rec.Code, rec.Body.String())
+		}
+		if data := body["data"].([]interface{}); len(data) != 2 {
+			t.Errorf("Policy %q: expected 2 rows, got %d", policy, len(data))
+		}
+		if body["truncated"] != true || body["total_available"] != float64(3) {
+			t.Errorf("Policy %q: expected a truncated response with 3 rows available, got %v", policy, body)
+		}
+	}
+
+	// error fails the read and asks the client to paginate, without running
+	// the query for the rows: only the count
+	queries := func() int64 { return mgr.Metrics().Queries[database.QuerySelect] }
+	before := queries()
+	read("")
+	truncated := queries() - before
+	handler.SetRowLimitPolicy(RowLimitError)
+	before = queries()
+	rec, body := read("")
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("Expected status 400, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if ran := queries() - before; ran != truncated-1 {
+		t.Errorf("Expected the rejected read to skip the select (%d queries), ran %d", truncated-1, ran)
+	}
+	if body["total_available"] != float64(3) || body["limit"] != float64(2) {
+		t.Errorf("Expected the limit and total available rows, got %v", body)
+	}
+	if msg, _ := body["message"].(string); !strings.Contains(msg, "Result too large") || !strings.Contains(msg, "pagination") {
+		t.Errorf("Expected pagination guidance, got %q", msg)
+	}
+	if body["request_id"] != "test-request-id" {
+		t.Errorf("Expected request_id in the body, got %v", body["request_id"])
+	}
+	if _, ok := body["data"]; ok {
+		t.Error("Expected no data in the error response")
+	}
+
+	// Reads within the limit and paginated reads are unaffected
+	if rec, body := read("?filter=age:gt:26"); rec.Code != http.StatusOK || len(body["data"].([]interface{})) != 2 {
+		t.Errorf("Expected 2 rows within the limit, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if rec, _ := read("?limit=2&page=2"); rec.Code != http.StatusOK {
+		t.Errorf("Expected paginated read to succeed, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	// Other formats fail the same way
+	req := httptest.NewRequest("GET", "/duckdb/api/test_users", nil)
+	req.Header.Set("Accept", "text/csv")
+	req = addAuthContext(req, "admin")
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400 for CSV, got %d: %s", rec.Code, rec.Body.String())
+	}
+}
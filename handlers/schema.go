@@ -0,0 +1,101 @@
+package handlers
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/santhosh-tekuri/jsonschema/v6"
+	"github.com/santhosh-tekuri/jsonschema/v6/kind"
+	"golang.org/x/text/language"
+	"golang.org/x/text/message"
+)
+
+// schemaPrinter renders schema validation messages.
+var schemaPrinter = message.NewPrinter(language.English)
+
+// SchemaViolation is a single JSON Schema validation failure of a request body.
+type SchemaViolation struct {
+	// Path is the JSON pointer of the offending value, e.g. "/age" ("" for the body itself).
+	Path string `json:"path"`
+
+	// Message describes the failure, e.g. "missing property 'name'".
+	Message string `json:"message"`
+}
+
+// compileJSONSchema loads and compiles the JSON Schema file at path.
+func compileJSONSchema(path string) (*jsonschema.Schema, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	doc, err := jsonschema.UnmarshalJSON(f)
+	if err != nil {
+		return nil, fmt.Errorf("invalid JSON: %v", err)
+	}
+	compiler := jsonschema.NewCompiler()
+	if err := compiler.AddResource(path, doc); err != nil {
+		return nil, err
+	}
+	return compiler.Compile(path)
+}
+
+// validateSchema validates a create or update body against the table's JSON
+// Schema. Required properties for which optional returns true may be missing:
+// columns set to their default on create, and every column on update.
+// Returns nil if the table has no schema or the body is valid.
+func (c *TableConfig) validateSchema(data map[string]interface{}, optional func(column string) bool) *ValidationError {
+	if c == nil || c.schema == nil {
+		return nil
+	}
+	err := c.schema.Validate(data)
+	if err == nil {
+		return nil
+	}
+	verr, ok := err.(*jsonschema.ValidationError)
+	if !ok {
+		return &ValidationError{Message: err.Error()}
+	}
+
+	violations := schemaViolations(verr, optional, nil)
+	if len(violations) == 0 {
+		return nil
+	}
+	first := violations[0]
+	message := first.Message
+	if first.Path != "" {
+		message = fmt.Sprintf("%s: %s", strings.TrimPrefix(first.Path, "/"), first.Message)
+	}
+	return &ValidationError{Message: message, Violations: violations}
+}
+
+// schemaViolations flattens the leaf errors of a schema validation error.
+func schemaViolations(verr *jsonschema.ValidationError, optional func(string) bool, violations []SchemaViolation) []SchemaViolation {
+	if len(verr.Causes) > 0 {
+		for _, cause := range verr.Causes {
+			violations = schemaViolations(cause, optional, violations)
+		}
+		return violations
+	}
+
+	errKind := verr.ErrorKind
+	if required, ok := errKind.(*kind.Required); ok {
+		missing := make([]string, 0, len(required.Missing))
+		for _, column := range required.Missing {
+			if !optional(column) {
+				missing = append(missing, column)
+			}
+		}
+		if len(missing) == 0 {
+			return violations
+		}
+		errKind = &kind.Required{Missing: missing}
+	}
+	path := ""
+	if len(verr.InstanceLocation) > 0 {
+		path = "/" + strings.Join(verr.InstanceLocation, "/")
+	}
+	return append(violations, SchemaViolation{Path: path, Message: errKind.LocalizedString(schemaPrinter)})
+}
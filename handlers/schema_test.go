@@ -0,0 +1,134 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+const testUserSchema = `{
+	"type": "object",
+	"required": ["id", "name"],
+	"properties": {
+		"id": {"type": "integer"},
+		"name": {"type": "string", "minLength": 1},
+		"email": {"type": "string"},
+		"age": {"type": "integer", "minimum": 0}
+	},
+	"additionalProperties": false
+}`
+
+// enableSchema provisions testUserSchema for the test_users table.
+func enableSchema(t *testing.T, handler *CRUDHandler) {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "users.json")
+	if err := os.WriteFile(path, []byte(testUserSchema), 0o644); err != nil {
+		t.Fatalf("Failed to write schema: %v", err)
+	}
+	cfg := &TableConfig{JSONSchema: path}
+	if err := cfg.Provision(); err != nil {
+		t.Fatalf("Failed to provision schema: %v", err)
+	}
+	handler.SetTableConfigs(map[string]*TableConfig{"test_users": cfg})
+}
+
+func TestTableConfig_Provision_JSONSchema(t *testing.T) {
+	dir := t.TempDir()
+	invalid := filepath.Join(dir, "invalid.json")
+	os.WriteFile(invalid, []byte(`{"type": 5}`), 0o644)
+	malformed := filepath.Join(dir, "malformed.json")
+	os.WriteFile(malformed, []byte(`{"type":`), 0o644)
+
+	for _, path := range []string{filepath.Join(dir, "missing.json"), invalid, malformed} {
+		cfg := &TableConfig{JSONSchema: path}
+		if err := cfg.Provision(); err == nil {
+			t.Errorf("Expected provisioning %s to fail", filepath.Base(path))
+		}
+	}
+}
+
+func TestCRUDHandler_Create_JSONSchema(t *testing.T) {
+	handler, _, cleanup := setupTestHandler(t)
+	defer cleanup()
+	enableSchema(t, handler)
+
+	tests := []struct {
+		name       string
+		body       string
+		wantStatus int
+		wantPaths  []string
+	}{
+		{"valid row", `{"id": 4, "name": "Dave", "age": 40}`, http.StatusCreated, nil},
+		{"missing required", `{"id": 5, "email": "eve@example.com"}`, http.StatusUnprocessableEntity, []string{""}},
+		{"wrong types", `{"id": "6", "name": "Eve", "age": -1}`, http.StatusUnprocessableEntity, []string{"/id", "/age"}},
+		{"default for required", `{"id": 7, "name": "__DEFAULT__"}`, http.StatusCreated, nil},
+		{"bulk row", `[{"id": 8, "name": "Frank"}, {"id": 9}]`, http.StatusUnprocessableEntity, []string{""}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("POST", "/duckdb/api/test_users", bytes.NewBufferString(tt.body))
+			req = addAuthContext(req, "admin")
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("Expected status %d, got %d: %s", tt.wantStatus, rec.Code, rec.Body.String())
+			}
+			if tt.wantPaths == nil {
+				return
+			}
+
+			var result struct {
+				Message string            `json:"message"`
+				Errors  []SchemaViolation `json:"errors"`
+			}
+			if err := json.Unmarshal(rec.Body.Bytes(), &result); err != nil {
+				t.Fatalf("Failed to parse response: %v", err)
+			}
+			paths := make(map[string]bool, len(result.Errors))
+			for _, v := range result.Errors {
+				paths[v.Path] = true
+			}
+			for _, path := range tt.wantPaths {
+				if !paths[path] {
+					t.Errorf("Expected a violation at '%s', got %v", path, result.Errors)
+				}
+			}
+		})
+	}
+}
+
+func TestCRUDHandler_Update_JSONSchema(t *testing.T) {
+	handler, _, cleanup := setupTestHandler(t)
+	defer cleanup()
+	enableSchema(t, handler)
+
+	tests := []struct {
+		name       string
+		set        string
+		wantStatus int
+	}{
+		// Required properties may be omitted from an update
+		{"partial set", `{"age": 31}`, http.StatusOK},
+		{"wrong type", `{"age": "old"}`, http.StatusUnprocessableEntity},
+		{"empty name", `{"name": ""}`, http.StatusUnprocessableEntity},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			body := `{"where": [{"column": "id", "op": "eq", "value": 1}], "set": ` + tt.set + `}`
+			req := httptest.NewRequest("PUT", "/duckdb/api/test_users", bytes.NewBufferString(body))
+			req = addAuthContext(req, "admin")
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+
+			if rec.Code != tt.wantStatus {
+				t.Errorf("Expected status %d, got %d: %s", tt.wantStatus, rec.Code, rec.Body.String())
+			}
+		})
+	}
+}
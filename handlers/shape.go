@@ -0,0 +1,21 @@
+package handlers
+
+// SetResponseShape sets the JSON envelope shape of table reads for tables
+// without their own (see formats.ShapeResult).
+func (h *CRUDHandler) SetResponseShape(shape string) {
+	h.responseShape = shape
+}
+
+// SetResponseShape sets the JSON envelope shape of SELECT query results.
+func (h *QueryHandler) SetResponseShape(shape string) {
+	h.responseShape = shape
+}
+
+// responseShapeFor returns the table's response shape, falling back to the
+// global one.
+func (h *CRUDHandler) responseShapeFor(tableName string) string {
+	if cfg := h.tables[tableName]; cfg != nil && cfg.ResponseShape != "" {
+		return cfg.ResponseShape
+	}
+	return h.responseShape
+}
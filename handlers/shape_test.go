@@ -0,0 +1,103 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/tobilg/caddy-duckdb-module/formats"
+)
+
+func decodeShape(t *testing.T, rec *httptest.ResponseRecorder) map[string]interface{} {
+	t.Helper()
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var result map[string]interface{}
+	if err := json.Unmarshal(rec.Body.Bytes(), &result); err != nil {
+		t.Fatalf("Failed to parse response: %v", err)
+	}
+	return result
+}
+
+func TestCRUDHandler_ResponseShape(t *testing.T) {
+	handler, mgr, cleanup := setupTestHandler(t)
+	defer cleanup()
+	if _, err := mgr.ExecMain(`CREATE TABLE test_legacy (id INTEGER)`); err != nil {
+		t.Fatalf("Failed to create table: %v", err)
+	}
+	handler.SetResponseShape(formats.ShapeResult)
+	handler.SetTableConfigs(map[string]*TableConfig{
+		"test_legacy": {ResponseShape: formats.ShapeItems},
+	})
+
+	// The global shape wraps the envelope
+	req := httptest.NewRequest("GET", "/duckdb/api/test_users?limit=2&page=1", nil)
+	req = addAuthContext(req, "admin")
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	result := decodeShape(t, rec)
+	inner, ok := result["result"].(map[string]interface{})
+	if !ok {
+		t.Fatalf("Expected a result envelope, got %s", rec.Body.String())
+	}
+	if data, ok := inner["data"].([]interface{}); !ok || len(data) != 2 {
+		t.Errorf("Expected 2 rows in result.data, got %v", inner["data"])
+	}
+	if _, ok := inner["pagination"]; !ok {
+		t.Errorf("Expected pagination inside the result envelope, got %s", rec.Body.String())
+	}
+
+	// The table's shape replaces the global one
+	req = httptest.NewRequest("GET", "/duckdb/api/test_legacy", nil)
+	req = addAuthContext(req, "admin")
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	result = decodeShape(t, rec)
+	if _, ok := result["items"].([]interface{}); !ok {
+		t.Errorf("Expected data renamed to items, got %s", rec.Body.String())
+	}
+	if _, ok := result["data"]; ok {
+		t.Errorf("Expected no data member, got %s", rec.Body.String())
+	}
+}
+
+func TestCRUDHandler_ResponseShape_Default(t *testing.T) {
+	handler, _, cleanup := setupTestHandler(t)
+	defer cleanup()
+
+	req := httptest.NewRequest("GET", "/duckdb/api/test_users", nil)
+	req = addAuthContext(req, "admin")
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	if result := decodeShape(t, rec); result["data"] == nil {
+		t.Errorf("Expected the default shape, got %s", rec.Body.String())
+	}
+}
+
+func TestQueryHandler_ResponseShape(t *testing.T) {
+	handler, _, cleanup := setupQueryHandler(t)
+	defer cleanup()
+	handler.SetResponseShape(formats.ShapeItems)
+
+	body := bytes.NewBufferString(`{"sql": "SELECT 1 AS n"}`)
+	req := httptest.NewRequest("POST", "/duckdb/query", body)
+	req = addQueryAuthContext(req, "admin")
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	result := decodeShape(t, rec)
+	if items, ok := result["items"].([]interface{}); !ok || len(items) != 1 {
+		t.Errorf("Expected one row in items, got %s", rec.Body.String())
+	}
+}
+
+func TestTableConfig_ValidateResponseShape(t *testing.T) {
+	if err := (&TableConfig{ResponseShape: formats.ShapeResult}).Validate(); err != nil {
+		t.Errorf("Expected result shape to be valid, got %v", err)
+	}
+	if err := (&TableConfig{ResponseShape: "envelope"}).Validate(); err == nil {
+		t.Error("Expected an unknown shape to be rejected")
+	}
+}
@@ -0,0 +1,152 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/tobilg/caddy-duckdb-module/auth"
+	"github.com/tobilg/caddy-duckdb-module/formats"
+	"go.uber.org/zap"
+)
+
+// handleSSE streams the results of a read-only query as server-sent events.
+// Request format: GET /duckdb/query/sse?sql=SELECT+...
+//
+// Each row is sent as a `data:` event containing a JSON object and flushed immediately.
+// The stream ends with an `event: done` carrying the row count and execution_time_ms,
+// or an `event: error` if the query fails mid-stream. The query is cancelled when the
+// client disconnects. Each API key may hold at most max_streams_per_key streams open;
+// further requests are rejected with 429.
+func (h *QueryHandler) handleSSE(w http.ResponseWriter, r *http.Request) {
+	requestID := auth.GetRequestIDFromContext(r.Context())
+
+	if r.Method != http.MethodGet {
+		h.sendErrorWithRequest(w, r, "Method not allowed. Use GET to stream query results.", http.StatusMethodNotAllowed)
+		return
+	}
+
+	sqlQuery := r.URL.Query().Get("sql")
+	if sqlQuery == "" {
+		h.sendErrorWithRequest(w, r, "SQL query is required (use ?sql=...)", http.StatusBadRequest)
+		return
+	}
+	if !h.isSelectQuery(sqlQuery) {
+		h.sendErrorWithRequest(w, r, "Only read-only queries (SELECT, WITH, SHOW, DESCRIBE, EXPLAIN) can be streamed", http.StatusBadRequest)
+		return
+	}
+	if h.containsInternalTables(sqlQuery) {
+		h.sendErrorWithRequest(w, r, "Access to internal auth tables is forbidden", http.StatusForbidden)
+		return
+	}
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		h.sendErrorWithRequest(w, r, "Streaming is not supported by this server", http.StatusInternalServerError)
+		return
+	}
+
+	release, ok := h.streams.Acquire(r.Context())
+	if !ok {
+		h.sendErrorWithRequest(w, r, fmt.Sprintf("Too many concurrent streams for this API key (limit %d)", h.streams.Max()), http.StatusTooManyRequests)
+		return
+	}
+	defer release()
+
+	h.logger.Info("Streaming query",
+		zap.String("role", auth.GetRoleFromContext(r.Context())),
+		zap.String("sql", sqlQuery),
+		zap.String("label", auth.GetQueryLabelFromContext(r.Context())),
+		zap.String("request_id", requestID),
+	)
+
+	startTime := time.Now()
+	ctx := r.Context()
+
+	rows, err := h.dbMgr.QueryMainContext(ctx, sqlQuery)
+	if err != nil {
+		h.logger.Error("Failed to execute query", zap.Error(err), zap.String("sql", sqlQuery), zap.String("request_id", requestID))
+		h.sendQueryErrorWithRequest(w, r, "Query execution failed", err, sqlQuery)
+		return
+	}
+	defer rows.Close()
+
+	columns, err := rows.Columns()
+	if err != nil {
+		h.logger.Error("Failed to get columns", zap.Error(err), zap.String("request_id", requestID))
+		h.sendErrorWithRequest(w, r, "Failed to read query results", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	rowCount := 0
+	truncated := false
+	for rows.Next() {
+		if ctx.Err() != nil {
+			h.logger.Info("Client disconnected during stream", zap.Int("rows_sent", rowCount), zap.String("request_id", requestID))
+			return
+		}
+		if h.absoluteMaxRows > 0 && rowCount >= h.absoluteMaxRows {
+			truncated = true
+			break
+		}
+
+		row, err := formats.ScanRowMap(rows, columns)
+		if err != nil {
+			h.writeSSEEvent(w, flusher, "error", map[string]interface{}{"message": err.Error()})
+			return
+		}
+		formats.ConvertDecimals([]map[string]interface{}{row}, h.decimalAsNumber)
+		if err := h.writeSSEEvent(w, flusher, "", row); err != nil {
+			// Client went away
+			return
+		}
+		rowCount++
+	}
+
+	if err := rows.Err(); err != nil {
+		if ctx.Err() != nil {
+			h.logger.Info("Client disconnected during stream", zap.Int("rows_sent", rowCount), zap.String("request_id", requestID))
+			return
+		}
+		h.logger.Error("Error iterating rows", zap.Error(err), zap.String("request_id", requestID))
+		h.writeSSEEvent(w, flusher, "error", map[string]interface{}{"message": err.Error()})
+		return
+	}
+
+	done := map[string]interface{}{
+		"rows":              rowCount,
+		"execution_time_ms": time.Since(startTime).Milliseconds(),
+	}
+	if truncated {
+		done["truncated"] = true
+		done["message"] = fmt.Sprintf("Results limited to %d rows by safety limit.", h.absoluteMaxRows)
+	}
+	h.writeSSEEvent(w, flusher, "done", done)
+}
+
+// writeSSEEvent writes a single server-sent event with a JSON payload and flushes it.
+// An empty event name produces a default (message) event.
+func (h *QueryHandler) writeSSEEvent(w http.ResponseWriter, flusher http.Flusher, event string, payload interface{}) error {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	if event != "" {
+		if _, err := fmt.Fprintf(w, "event: %s\n", event); err != nil {
+			return err
+		}
+	}
+	if _, err := fmt.Fprintf(w, "data: %s\n\n", data); err != nil {
+		return err
+	}
+	flusher.Flush()
+	return nil
+}
@@ -0,0 +1,194 @@
+package handlers
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/tobilg/caddy-duckdb-module/auth"
+)
+
+// sseEvent is a parsed server-sent event.
+type sseEvent struct {
+	event string
+	data  string
+}
+
+// parseSSE splits an SSE stream into events.
+func parseSSE(t *testing.T, body string) []sseEvent {
+	t.Helper()
+	var events []sseEvent
+	var current sseEvent
+	scanner := bufio.NewScanner(strings.NewReader(body))
+	for scanner.Scan() {
+		line := scanner.Text()
+		switch {
+		case line == "":
+			events = append(events, current)
+			current = sseEvent{}
+		case strings.HasPrefix(line, "event: "):
+			current.event = strings.TrimPrefix(line, "event: ")
+		case strings.HasPrefix(line, "data: "):
+			current.data = strings.TrimPrefix(line, "data: ")
+		default:
+			t.Fatalf("Unexpected SSE line: %q", line)
+		}
+	}
+	return events
+}
+
+func TestQueryHandler_SSE_StreamsRows(t *testing.T) {
+	handler, _, cleanup := setupQueryHandler(t)
+	defer cleanup()
+
+	sql := url.QueryEscape("SELECT * FROM test_query ORDER BY id")
+	req := httptest.NewRequest("GET", "/duckdb/query/sse?sql="+sql, nil)
+	req = addQueryAuthContext(req, "admin")
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if ct := rec.Header().Get("Content-Type"); ct != "text/event-stream" {
+		t.Errorf("Expected Content-Type 'text/event-stream', got '%s'", ct)
+	}
+
+	events := parseSSE(t, rec.Body.String())
+	if len(events) != 4 {
+		t.Fatalf("Expected 3 row events and 1 done event, got %d: %s", len(events), rec.Body.String())
+	}
+
+	for i, ev := range events[:3] {
+		if ev.event != "" {
+			t.Errorf("Expected default event for row %d, got '%s'", i, ev.event)
+		}
+		var row map[string]interface{}
+		if err := json.Unmarshal([]byte(ev.data), &row); err != nil {
+			t.Fatalf("Failed to parse row event: %v", err)
+		}
+		if row["id"].(float64) != float64(i+1) {
+			t.Errorf("Expected id %d, got %v", i+1, row["id"])
+		}
+	}
+
+	done := events[3]
+	if done.event != "done" {
+		t.Fatalf("Expected final 'done' event, got '%s'", done.event)
+	}
+	var summary map[string]interface{}
+	if err := json.Unmarshal([]byte(done.data), &summary); err != nil {
+		t.Fatalf("Failed to parse done event: %v", err)
+	}
+	if summary["rows"].(float64) != 3 {
+		t.Errorf("Expected rows 3, got %v", summary["rows"])
+	}
+	if _, ok := summary["execution_time_ms"]; !ok {
+		t.Error("Expected execution_time_ms in done event")
+	}
+}
+
+func TestQueryHandler_SSE_RowCap(t *testing.T) {
+	handler, _, cleanup := setupQueryHandler(t)
+	defer cleanup()
+	handler.SetAbsoluteMaxRows(2)
+
+	req := httptest.NewRequest("GET", "/duckdb/query/sse?sql="+url.QueryEscape("SELECT * FROM test_query"), nil)
+	req = addQueryAuthContext(req, "admin")
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	events := parseSSE(t, rec.Body.String())
+	if len(events) != 3 {
+		t.Fatalf("Expected 2 row events and 1 done event, got %d", len(events))
+	}
+	if !strings.Contains(events[2].data, `"truncated":true`) {
+		t.Errorf("Expected truncated in done event, got %s", events[2].data)
+	}
+}
+
+func TestQueryHandler_SSE_Rejections(t *testing.T) {
+	handler, _, cleanup := setupQueryHandler(t)
+	defer cleanup()
+
+	tests := []struct {
+		name       string
+		method     string
+		sql        string
+		role       string
+		wantStatus int
+	}{
+		{"missing sql", "GET", "", "admin", http.StatusBadRequest},
+		{"write statement", "GET", "DELETE FROM test_query", "admin", http.StatusBadRequest},
+		{"internal table", "GET", "SELECT * FROM api_keys", "admin", http.StatusForbidden},
+		{"wrong method", "POST", "SELECT 1", "admin", http.StatusMethodNotAllowed},
+		{"no query permission", "GET", "SELECT 1", "reader", http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			target := "/duckdb/query/sse"
+			if tt.sql != "" {
+				target += "?sql=" + url.QueryEscape(tt.sql)
+			}
+			req := httptest.NewRequest(tt.method, target, nil)
+			req = addQueryAuthContext(req, tt.role)
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+
+			if rec.Code != tt.wantStatus {
+				t.Errorf("Expected status %d, got %d: %s", tt.wantStatus, rec.Code, rec.Body.String())
+			}
+		})
+	}
+}
+
+func TestQueryHandler_SSE_StreamLimit(t *testing.T) {
+	handler, _, cleanup := setupQueryHandler(t)
+	defer cleanup()
+
+	streams := auth.NewStreamLimiter(1)
+	handler.SetStreamLimiter(streams)
+
+	key := &auth.APIKey{Key: "stream-key", RoleName: "admin"}
+	stream := func() *httptest.ResponseRecorder {
+		sql := url.QueryEscape("SELECT * FROM test_query ORDER BY id")
+		req := httptest.NewRequest("GET", "/duckdb/query/sse?sql="+sql, nil)
+		req = addQueryAuthContext(req, "admin")
+		req = req.WithContext(auth.SetContextValues(req.Context(), key, "admin"))
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec
+	}
+
+	// Hold the key's only slot open
+	ctx := auth.SetContextValues(context.Background(), key, "admin")
+	release, ok := streams.Acquire(ctx)
+	if !ok {
+		t.Fatal("Expected to acquire a stream slot")
+	}
+
+	rec := stream()
+	if rec.Code != http.StatusTooManyRequests {
+		t.Fatalf("Expected status 429, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var body map[string]interface{}
+	json.Unmarshal(rec.Body.Bytes(), &body)
+	if body["code"] != float64(http.StatusTooManyRequests) {
+		t.Errorf("Expected error code 429 in body, got %v", body)
+	}
+
+	// Once released, streaming works again and the slot is freed afterwards
+	release()
+	if rec := stream(); rec.Code != http.StatusOK {
+		t.Errorf("Expected status 200 after release, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if n := streams.Active("stream-key"); n != 0 {
+		t.Errorf("Expected stream slot to be released, got %d", n)
+	}
+}
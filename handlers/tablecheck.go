@@ -0,0 +1,240 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// SetValidateTables enables checking that the tables referenced by a SELECT
+// query exist before it is executed, so a typo'd table name is answered with a
+// 400 naming the closest existing table instead of a database error.
+func (h *QueryHandler) SetValidateTables(enabled bool) {
+	h.validateTables = enabled
+}
+
+// unknownTableError reports a table referenced by a query that does not exist.
+type unknownTableError struct {
+	Table      string
+	Suggestion string
+}
+
+func (e *unknownTableError) Error() string {
+	if e.Suggestion != "" {
+		return fmt.Sprintf("unknown table '%s' (did you mean '%s'?)", e.Table, e.Suggestion)
+	}
+	return fmt.Sprintf("unknown table '%s'", e.Table)
+}
+
+// checkTables returns an *unknownTableError for the first table referenced by
+// the query that does not exist. The check is best-effort: queries whose table
+// references cannot be extracted reliably, and catalog lookup failures, are
+// passed through for the database to report.
+func (h *QueryHandler) checkTables(ctx context.Context, sql string) error {
+	tables, ok := referencedTables(sql)
+	if !ok || len(tables) == 0 {
+		return nil
+	}
+	names, err := h.dbMgr.RelationNames(ctx)
+	if err != nil {
+		return nil
+	}
+	existing := make(map[string]bool, len(names))
+	for _, name := range names {
+		existing[name] = true
+	}
+	for _, table := range tables {
+		if !existing[table] {
+			return &unknownTableError{Table: table, Suggestion: closestName(table, names)}
+		}
+	}
+	return nil
+}
+
+// referencedTables extracts the unqualified table names that a SELECT (or
+// WITH ... SELECT) query reads from: the first relation after each FROM and
+// JOIN of the query and its subqueries, lowercased, excluding CTE names.
+// Qualified names, table functions, file paths, and comma-joined relations
+// after the first are skipped. ok is false when the query is not a SELECT or
+// cannot be tokenized unambiguously (quoted identifiers, dollar-quoted strings,
+// unbalanced parentheses), in which case nothing should be checked.
+func referencedTables(sql string) (tables []string, ok bool) {
+	tokens, ok := tokenizeSQL(stripSQLComments(sql))
+	if !ok || len(tokens) == 0 {
+		return nil, false
+	}
+	if first := strings.ToUpper(tokens[0]); first != "SELECT" && first != "WITH" {
+		return nil, false
+	}
+
+	// CTE names: <name> [(<columns>)] AS [NOT] [MATERIALIZED] (
+	ctes := make(map[string]bool)
+	for i := 1; i < len(tokens); i++ {
+		if !strings.EqualFold(tokens[i], "AS") {
+			continue
+		}
+		j := i + 1
+		for j < len(tokens) && (strings.EqualFold(tokens[j], "NOT") || strings.EqualFold(tokens[j], "MATERIALIZED")) {
+			j++
+		}
+		if j >= len(tokens) || tokens[j] != "(" {
+			continue
+		}
+		name := i - 1
+		if tokens[name] == ")" {
+			for name > 0 && tokens[name] != "(" {
+				name--
+			}
+			name--
+		}
+		if name >= 0 && isSQLIdentifier(tokens[name]) {
+			ctes[strings.ToLower(tokens[name])] = true
+		}
+	}
+
+	// Each open parenthesis records whether it starts a subquery, where FROM
+	// begins a clause, or an expression such as EXTRACT(year FROM ts)
+	var subquery []bool
+	seen := make(map[string]bool)
+	for i, tok := range tokens {
+		upper := strings.ToUpper(tok)
+		switch {
+		case tok == "(":
+			next := ""
+			if i+1 < len(tokens) {
+				next = strings.ToUpper(tokens[i+1])
+			}
+			subquery = append(subquery, next == "SELECT" || next == "WITH" || next == "FROM")
+		case tok == ")":
+			if len(subquery) == 0 {
+				return nil, false
+			}
+			subquery = subquery[:len(subquery)-1]
+		case upper == "FROM" || upper == "JOIN":
+			if len(subquery) > 0 && !subquery[len(subquery)-1] {
+				continue
+			}
+			// IS [NOT] DISTINCT FROM compares values
+			if i > 0 && strings.EqualFold(tokens[i-1], "DISTINCT") {
+				continue
+			}
+			if i+1 >= len(tokens) || !isSQLIdentifier(tokens[i+1]) || sqlRelationKeywords[strings.ToUpper(tokens[i+1])] {
+				continue
+			}
+			if i+2 < len(tokens) && (tokens[i+2] == "." || tokens[i+2] == "(") {
+				continue
+			}
+			name := strings.ToLower(tokens[i+1])
+			if !ctes[name] && !seen[name] {
+				seen[name] = true
+				tables = append(tables, name)
+			}
+		}
+	}
+	if len(subquery) != 0 {
+		return nil, false
+	}
+	return tables, true
+}
+
+// sqlRelationKeywords are keywords that may follow FROM or JOIN in place of a table name.
+var sqlRelationKeywords = map[string]bool{
+	"LATERAL": true, "UNNEST": true, "VALUES": true, "SELECT": true, "WITH": true,
+}
+
+// tokenizeSQL splits a comment-free query into identifiers and keywords,
+// numbers, single-character symbols, and string literals (each replaced by a
+// single "'" token). ok is false for quoted identifiers and dollar-quoted
+// strings, which the caller does not try to interpret.
+func tokenizeSQL(sql string) (tokens []string, ok bool) {
+	runes := []rune(sql)
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		switch {
+		case unicode.IsSpace(r):
+			i++
+		case r == '"':
+			return nil, false
+		case r == '$' && i+1 < len(runes) && !unicode.IsDigit(runes[i+1]):
+			return nil, false
+		case r == '\'':
+			// String literal, with '' as an escaped quote
+			i++
+			for {
+				if i >= len(runes) {
+					return nil, false
+				}
+				if runes[i] == '\'' {
+					if i+1 < len(runes) && runes[i+1] == '\'' {
+						i += 2
+						continue
+					}
+					i++
+					break
+				}
+				i++
+			}
+			tokens = append(tokens, "'")
+		case r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r) || r == '$':
+			start := i
+			i++
+			for i < len(runes) && (runes[i] == '_' || runes[i] == '$' || unicode.IsLetter(runes[i]) || unicode.IsDigit(runes[i])) {
+				i++
+			}
+			tokens = append(tokens, string(runes[start:i]))
+		default:
+			tokens = append(tokens, string(r))
+			i++
+		}
+	}
+	return tokens, true
+}
+
+// isSQLIdentifier reports whether the token is an unquoted identifier.
+func isSQLIdentifier(tok string) bool {
+	for i, r := range tok {
+		if !(r == '_' || unicode.IsLetter(r) || (i > 0 && (unicode.IsDigit(r) || r == '$'))) {
+			return false
+		}
+	}
+	return tok != ""
+}
+
+// closestName returns the name with the smallest edit distance to target, or
+// "" if none is close enough to be a likely typo.
+func closestName(target string, names []string) string {
+	maxDistance := len(target) / 3
+	if maxDistance < 2 {
+		maxDistance = 2
+	}
+	best, bestDistance := "", maxDistance+1
+	for _, name := range names {
+		if d := editDistance(target, name); d < bestDistance {
+			best, bestDistance = name, d
+		}
+	}
+	return best
+}
+
+// editDistance returns the Levenshtein distance between a and b.
+func editDistance(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	prev := make([]int, len(rb)+1)
+	curr := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		curr[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+	return prev[len(rb)]
+}
@@ -0,0 +1,123 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestReferencedTables(t *testing.T) {
+	tests := []struct {
+		name   string
+		sql    string
+		tables []string
+		ok     bool
+	}{
+		{"simple", "SELECT * FROM users", []string{"users"}, true},
+		{"case folded", "select * from Users u join ORDERS o on u.id = o.user_id", []string{"users", "orders"}, true},
+		{"subquery", "SELECT * FROM (SELECT id FROM events) e WHERE id IN (SELECT user_id FROM orders)", []string{"events", "orders"}, true},
+		{"cte excluded", "WITH recent AS (SELECT * FROM events) SELECT * FROM recent", []string{"events"}, true},
+		{"cte with columns", "WITH RECURSIVE r(n) AS (SELECT 1 UNION ALL SELECT n + 1 FROM r WHERE n < 3) SELECT * FROM r", nil, true},
+		{"expression from", "SELECT EXTRACT(year FROM created_at), substring(name FROM 1 FOR 2) FROM users", []string{"users"}, true},
+		{"distinct from", "SELECT * FROM users WHERE a IS NOT DISTINCT FROM b", []string{"users"}, true},
+		{"qualified skipped", "SELECT * FROM main.users JOIN other.orders ON true", nil, true},
+		{"table function skipped", "SELECT * FROM read_parquet('data.parquet') JOIN range(10) ON true", nil, true},
+		{"file skipped", "SELECT * FROM 'data.csv'", nil, true},
+		{"string contents ignored", "SELECT 'from nowhere' FROM users", []string{"users"}, true},
+		{"comment ignored", "SELECT * /* FROM nowhere */ FROM users -- JOIN missing", []string{"users"}, true},
+		{"no tables", "SELECT 1", nil, true},
+		{"quoted identifier", `SELECT * FROM "Users"`, nil, false},
+		{"dollar quoted", "SELECT $$from x$$", nil, false},
+		{"unbalanced", "SELECT (1 FROM users", nil, false},
+		{"not a select", "SHOW TABLES", nil, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tables, ok := referencedTables(tt.sql)
+			if ok != tt.ok || !reflect.DeepEqual(tables, tt.tables) {
+				t.Errorf("referencedTables(%q) = %v, %v; expected %v, %v", tt.sql, tables, ok, tt.tables, tt.ok)
+			}
+		})
+	}
+}
+
+func TestClosestName(t *testing.T) {
+	names := []string{"orders", "test_query", "users"}
+	tests := []struct {
+		target   string
+		expected string
+	}{
+		{"usres", "users"},
+		{"user", "users"},
+		{"test_qurey", "test_query"},
+		{"invoices", ""},
+	}
+	for _, tt := range tests {
+		if got := closestName(tt.target, names); got != tt.expected {
+			t.Errorf("closestName(%q) = %q, expected %q", tt.target, got, tt.expected)
+		}
+	}
+}
+
+func TestQueryHandler_ValidateTables(t *testing.T) {
+	handler, _, cleanup := setupQueryHandler(t)
+	defer cleanup()
+	handler.SetValidateTables(true)
+
+	body := bytes.NewBufferString(`{"sql": "SELECT * FROM test_qurey WHERE id = 1"}`)
+	req := httptest.NewRequest("POST", "/duckdb/query", body)
+	req = addQueryAuthContext(req, "admin")
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("Expected status 400, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if !strings.Contains(rec.Body.String(), "unknown table 'test_qurey' (did you mean 'test_query'?)") {
+		t.Errorf("Expected a suggestion for the typo, got %s", rec.Body.String())
+	}
+
+	// GET queries are checked too
+	path := "/duckdb/query/" + url.QueryEscape("SELECT * FROM nothing_like_it") + "/result.json"
+	req = httptest.NewRequest("GET", path, nil)
+	req = addQueryAuthContext(req, "admin")
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	if rec.Code != http.StatusBadRequest || !strings.Contains(rec.Body.String(), "unknown table 'nothing_like_it'") {
+		t.Errorf("Expected unknown table error, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	// Existing tables, CTEs, and queries that cannot be parsed reliably run as usual
+	for _, sql := range []string{
+		"SELECT * FROM TEST_QUERY",
+		"WITH q AS (SELECT * FROM test_query) SELECT COUNT(*) FROM q",
+		`SELECT * FROM "test_query"`,
+	} {
+		body, _ := json.Marshal(map[string]string{"sql": sql})
+		req := httptest.NewRequest("POST", "/duckdb/query", bytes.NewReader(body))
+		req = addQueryAuthContext(req, "admin")
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		if rec.Code != http.StatusOK {
+			t.Errorf("Expected %q to succeed, got %d: %s", sql, rec.Code, rec.Body.String())
+		}
+	}
+}
+
+func TestQueryHandler_ValidateTables_Disabled(t *testing.T) {
+	handler, _, cleanup := setupQueryHandler(t)
+	defer cleanup()
+
+	body := bytes.NewBufferString(`{"sql": "SELECT * FROM test_qurey"}`)
+	req := httptest.NewRequest("POST", "/duckdb/query", body)
+	req = addQueryAuthContext(req, "admin")
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	if strings.Contains(rec.Body.String(), "did you mean") {
+		t.Errorf("Expected no pre-validation when disabled, got %s", rec.Body.String())
+	}
+}
@@ -0,0 +1,195 @@
+package handlers
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/santhosh-tekuri/jsonschema/v6"
+	"github.com/tobilg/caddy-duckdb-module/database"
+	"github.com/tobilg/caddy-duckdb-module/formats"
+)
+
+// DefaultSoftDeleteColumn is the column used for soft deletes when none is configured.
+const DefaultSoftDeleteColumn = "deleted_at"
+
+// TableConfig holds per-table behavior configured via the `table` Caddyfile block.
+type TableConfig struct {
+	// SoftDeleteColumn is the nullable timestamp column used to mark rows as deleted.
+	// When set, DELETE requests stamp this column instead of removing rows,
+	// reads exclude rows where it is not NULL, and the restore and purge
+	// endpoints become available for the table.
+	SoftDeleteColumn string `json:"soft_delete_column,omitempty"`
+
+	// Rules are per-column validation rules (min, max, enum, regex) enforced
+	// on create and update requests. Violations are rejected with 422.
+	Rules []ValidationRule `json:"rules,omitempty"`
+
+	// Filterable lists the columns that may be used in filters and WHERE clauses
+	// (read, update, delete, restore). Empty means all columns are allowed.
+	Filterable []string `json:"filterable,omitempty"`
+
+	// Sortable lists the columns that may be used in sorts. Empty means all columns are allowed.
+	Sortable []string `json:"sortable,omitempty"`
+
+	// Derived are computed columns (name + SQL expression) added to the projection
+	// of reads. They can be filtered and sorted by name like regular columns but
+	// are not writable.
+	Derived []database.DerivedColumn `json:"derived,omitempty"`
+
+	// ChangeColumn is a timestamp column (e.g. updated_at) that records when a row
+	// last changed. When set, the changes long-poll endpoint can return changed rows.
+	ChangeColumn string `json:"change_column,omitempty"`
+
+	// HashColumns are the columns covered by the row hash returned with
+	// include_hash=true. Empty means all columns of the table.
+	HashColumns []string `json:"hash_columns,omitempty"`
+
+	// Pagination overrides the global pagination policy for this table,
+	// e.g. to require cursor pagination on a large table.
+	Pagination *PaginationPolicy `json:"pagination,omitempty"`
+
+	// ResponseShape overrides the global JSON envelope shape of reads for this
+	// table (see formats.ShapeResult).
+	ResponseShape string `json:"response_shape,omitempty"`
+
+	// JSONSchema is the path of a JSON Schema file that create and update
+	// bodies must match (after key case conversion). Violations are rejected
+	// with 422. Updates may omit required properties.
+	JSONSchema string `json:"json_schema,omitempty"`
+
+	validators []*columnValidator
+	schema     *jsonschema.Schema
+}
+
+// Provision compiles the validation rules into validators and the JSON
+// Schema, if any. Must be called before the configuration is used by a handler.
+func (c *TableConfig) Provision() error {
+	c.validators = make([]*columnValidator, 0, len(c.Rules))
+	for _, rule := range c.Rules {
+		v, err := compileValidationRule(rule)
+		if err != nil {
+			return fmt.Errorf("invalid rule '%s': %v", rule, err)
+		}
+		c.validators = append(c.validators, v)
+	}
+	c.schema = nil
+	if c.JSONSchema != "" {
+		schema, err := compileJSONSchema(c.JSONSchema)
+		if err != nil {
+			return fmt.Errorf("invalid json_schema '%s': %v", c.JSONSchema, err)
+		}
+		c.schema = schema
+	}
+	return nil
+}
+
+// Validate checks that the table configuration only references safe identifiers.
+func (c *TableConfig) Validate() error {
+	if c.SoftDeleteColumn != "" {
+		if err := SanitizeColumnName(c.SoftDeleteColumn); err != nil {
+			return fmt.Errorf("invalid soft_delete column '%s': %v", c.SoftDeleteColumn, err)
+		}
+	}
+	if c.ChangeColumn != "" {
+		if err := SanitizeColumnName(c.ChangeColumn); err != nil {
+			return fmt.Errorf("invalid change_column '%s': %v", c.ChangeColumn, err)
+		}
+	}
+	for _, col := range c.Filterable {
+		if err := SanitizeColumnName(col); err != nil {
+			return fmt.Errorf("invalid filterable column '%s': %v", col, err)
+		}
+	}
+	for _, col := range c.Sortable {
+		if err := SanitizeColumnName(col); err != nil {
+			return fmt.Errorf("invalid sortable column '%s': %v", col, err)
+		}
+	}
+	for _, col := range c.HashColumns {
+		if err := SanitizeColumnName(col); err != nil {
+			return fmt.Errorf("invalid hash column '%s': %v", col, err)
+		}
+	}
+	if c.Pagination != nil {
+		if err := c.Pagination.Validate(); err != nil {
+			return err
+		}
+	}
+	if !formats.IsValidShape(c.ResponseShape) {
+		return fmt.Errorf("invalid response_shape: %s (must be 'default', 'result' or 'items')", c.ResponseShape)
+	}
+	seen := make(map[string]bool, len(c.Derived))
+	for _, d := range c.Derived {
+		if err := SanitizeColumnName(d.Name); err != nil {
+			return fmt.Errorf("invalid derived column '%s': %v", d.Name, err)
+		}
+		if seen[strings.ToLower(d.Name)] {
+			return fmt.Errorf("duplicate derived column '%s'", d.Name)
+		}
+		seen[strings.ToLower(d.Name)] = true
+		if strings.TrimSpace(d.Expression) == "" {
+			return fmt.Errorf("derived column '%s' must have an expression", d.Name)
+		}
+		if strings.Contains(d.Expression, ";") {
+			return fmt.Errorf("derived column '%s' expression must not contain ';'", d.Name)
+		}
+	}
+	return nil
+}
+
+// DerivedColumns returns the table's derived columns (nil-safe).
+func (c *TableConfig) DerivedColumns() []database.DerivedColumn {
+	if c == nil {
+		return nil
+	}
+	return c.Derived
+}
+
+// HashColumnList returns the columns covered by the row hash (nil-safe).
+// Empty means all columns of the table.
+func (c *TableConfig) HashColumnList() []string {
+	if c == nil {
+		return nil
+	}
+	return c.HashColumns
+}
+
+// IsDerived reports whether the column is one of the table's derived columns.
+func (c *TableConfig) IsDerived(column string) bool {
+	for _, d := range c.DerivedColumns() {
+		if strings.EqualFold(d.Name, column) {
+			return true
+		}
+	}
+	return false
+}
+
+// IsFilterable reports whether filters may reference the column.
+func (c *TableConfig) IsFilterable(column string) bool {
+	return c == nil || len(c.Filterable) == 0 || containsColumn(c.Filterable, column)
+}
+
+// IsSortable reports whether sorts may reference the column.
+func (c *TableConfig) IsSortable(column string) bool {
+	return c == nil || len(c.Sortable) == 0 || containsColumn(c.Sortable, column)
+}
+
+// containsColumn reports whether column is in the list (case-insensitive, like DuckDB identifiers).
+func containsColumn(columns []string, column string) bool {
+	for _, c := range columns {
+		if strings.EqualFold(c, column) {
+			return true
+		}
+	}
+	return false
+}
+
+// ExtractActionFromPath extracts the table sub-resource from the request path.
+// Expects paths like /duckdb/api/{table}/{action}; returns "" for plain table paths.
+func ExtractActionFromPath(path string) string {
+	parts := strings.Split(strings.Trim(path, "/"), "/")
+	if len(parts) >= 4 && parts[0] == "duckdb" && parts[1] == "api" {
+		return parts[3]
+	}
+	return ""
+}
@@ -0,0 +1,115 @@
+package handlers
+
+import (
+	"testing"
+
+	"github.com/tobilg/caddy-duckdb-module/database"
+)
+
+func TestTableConfig_Validate(t *testing.T) {
+	valid := &TableConfig{SoftDeleteColumn: "deleted_at"}
+	if err := valid.Validate(); err != nil {
+		t.Errorf("Expected valid config, got error: %v", err)
+	}
+
+	invalid := &TableConfig{SoftDeleteColumn: "deleted_at; DROP TABLE users"}
+	if err := invalid.Validate(); err == nil {
+		t.Error("Expected error for invalid soft_delete column")
+	}
+
+	if err := (&TableConfig{ChangeColumn: "updated at"}).Validate(); err == nil {
+		t.Error("Expected error for invalid change_column")
+	}
+}
+
+func TestTableConfig_ValidateAllowlists(t *testing.T) {
+	valid := &TableConfig{Filterable: []string{"id", "status"}, Sortable: []string{"created_at"}}
+	if err := valid.Validate(); err != nil {
+		t.Errorf("Expected valid config, got error: %v", err)
+	}
+
+	if err := (&TableConfig{Filterable: []string{"id; --"}}).Validate(); err == nil {
+		t.Error("Expected error for invalid filterable column")
+	}
+	if err := (&TableConfig{Sortable: []string{"a b"}}).Validate(); err == nil {
+		t.Error("Expected error for invalid sortable column")
+	}
+	if err := (&TableConfig{HashColumns: []string{"name, 1"}}).Validate(); err == nil {
+		t.Error("Expected error for invalid hash column")
+	}
+}
+
+func TestTableConfig_ValidateDerived(t *testing.T) {
+	valid := &TableConfig{Derived: []database.DerivedColumn{{Name: "full_name", Expression: "CONCAT(first, ' ', last)"}}}
+	if err := valid.Validate(); err != nil {
+		t.Errorf("Expected valid config, got error: %v", err)
+	}
+	if !valid.IsDerived("FULL_NAME") || valid.IsDerived("first") {
+		t.Error("Expected only full_name to be derived (case-insensitive)")
+	}
+
+	invalid := []database.DerivedColumn{
+		{Name: "full name", Expression: "first"},
+		{Name: "full_name", Expression: "  "},
+		{Name: "full_name", Expression: "first; DROP TABLE users"},
+	}
+	for _, d := range invalid {
+		if err := (&TableConfig{Derived: []database.DerivedColumn{d}}).Validate(); err == nil {
+			t.Errorf("Expected error for derived column %+v", d)
+		}
+	}
+
+	duplicate := &TableConfig{Derived: []database.DerivedColumn{
+		{Name: "x", Expression: "1"},
+		{Name: "X", Expression: "2"},
+	}}
+	if err := duplicate.Validate(); err == nil {
+		t.Error("Expected error for duplicate derived columns")
+	}
+}
+
+func TestTableConfig_IsFilterableSortable(t *testing.T) {
+	var unconfigured *TableConfig
+	if !unconfigured.IsFilterable("anything") || !unconfigured.IsSortable("anything") {
+		t.Error("Expected all columns allowed without a table config")
+	}
+
+	open := &TableConfig{}
+	if !open.IsFilterable("email") || !open.IsSortable("email") {
+		t.Error("Expected all columns allowed with empty allowlists")
+	}
+
+	restricted := &TableConfig{Filterable: []string{"id", "Status"}, Sortable: []string{"created_at"}}
+	if !restricted.IsFilterable("id") || !restricted.IsFilterable("status") {
+		t.Error("Expected allowlisted columns to be filterable (case-insensitive)")
+	}
+	if restricted.IsFilterable("email") {
+		t.Error("Expected email not to be filterable")
+	}
+	if !restricted.IsSortable("created_at") || restricted.IsSortable("id") {
+		t.Error("Expected only created_at to be sortable")
+	}
+}
+
+func TestExtractActionFromPath(t *testing.T) {
+	tests := []struct {
+		path     string
+		expected string
+	}{
+		{"/duckdb/api/users", ""},
+		{"/duckdb/api/users/", ""},
+		{"/duckdb/api/users/restore", "restore"},
+		{"/duckdb/api/users/restore/", "restore"},
+		{"/duckdb/api/users/purge", "purge"},
+		{"/other/api/users/restore", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			result := ExtractActionFromPath(tt.path)
+			if result != tt.expected {
+				t.Errorf("ExtractActionFromPath(%q) = %q, want %q", tt.path, result, tt.expected)
+			}
+		})
+	}
+}
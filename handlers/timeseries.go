@@ -0,0 +1,117 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/tobilg/caddy-duckdb-module/auth"
+	"github.com/tobilg/caddy-duckdb-module/database"
+	"github.com/tobilg/caddy-duckdb-module/formats"
+	"go.uber.org/zap"
+)
+
+// handleTimeSeries aggregates the table's rows per time bucket, e.g.
+// GET /duckdb/api/requests/timeseries?time_column=ts&interval=1h&aggregate=count:*&group_by=status
+// Requires READ permission. Rows can be narrowed with the usual filter parameter;
+// the number of returned buckets is capped by absolute_max_rows.
+func (h *CRUDHandler) handleTimeSeries(w http.ResponseWriter, r *http.Request, tableName string) {
+	requestID := auth.GetRequestIDFromContext(r.Context())
+
+	// Check authorization
+	role := auth.GetRoleFromContext(r.Context())
+	stopAuth := ServerTimingFromContext(r.Context()).Start(TimingAuth)
+	allowed, err := h.authorizer.CheckPermission(role, tableName, auth.OperationRead)
+	stopAuth()
+	if err != nil {
+		h.logger.Error("Failed to check permission", zap.Error(err), zap.String("request_id", requestID))
+		h.sendErrorWithRequest(w, r, "Failed to check permission", http.StatusInternalServerError)
+		return
+	}
+	if !allowed {
+		h.sendErrorWithRequest(w, r, "Forbidden: insufficient permissions for READ operation", http.StatusForbidden)
+		return
+	}
+
+	ts, err := ParseTimeSeries(r)
+	if err != nil {
+		h.sendErrorWithRequest(w, r, fmt.Sprintf("Invalid time series: %s", err.Error()), http.StatusBadRequest)
+		return
+	}
+
+	filters, err := ParseFilters(r)
+	if err != nil {
+		h.sendErrorWithRequest(w, r, fmt.Sprintf("Invalid filters: %s", err.Error()), http.StatusBadRequest)
+		return
+	}
+	for _, f := range filters {
+		if err := SanitizeColumnName(f.Column); err != nil {
+			h.sendErrorWithRequest(w, r, fmt.Sprintf("Invalid filter column '%s': %s", f.Column, err.Error()), http.StatusBadRequest)
+			return
+		}
+	}
+	if err := h.checkFilterable(tableName, filters); err != nil {
+		h.sendErrorWithRequest(w, r, fmt.Sprintf("Invalid filter: %s", err.Error()), http.StatusBadRequest)
+		return
+	}
+	if !h.rejectUnknownColumns(w, r, tableName, h.tables[tableName].DerivedColumns(), "filter", filterColumns(filters)) {
+		return
+	}
+
+	// Bucketing and grouping by a column exposes its values like a filter would
+	groupBy := append([]string{ts.TimeColumn}, ts.GroupBy...)
+	denied, ok := h.deniedColumns(w, r, tableName)
+	if !ok || !h.rejectDeniedColumns(w, r, denied, filterColumns(filters), groupBy, aggregateColumns(ts.Aggregates)) {
+		return
+	}
+	if err := h.checkGroupable(tableName, groupBy); err != nil {
+		h.sendErrorWithRequest(w, r, fmt.Sprintf("Invalid time series: %s", err.Error()), http.StatusBadRequest)
+		return
+	}
+
+	// Reject unknown columns up front instead of surfacing a binder error
+	if unknown, err := h.unknownGroupingColumns(tableName, groupBy, ts.Aggregates); err != nil {
+		h.logger.Error("Failed to check columns", zap.Error(err), zap.String("table", tableName), zap.String("request_id", requestID))
+		h.sendDetailedErrorWithRequest(w, r, "Failed to compute time series", err, http.StatusInternalServerError)
+		return
+	} else if len(unknown) > 0 {
+		h.sendErrorWithRequest(w, r, fmt.Sprintf("Invalid time series: unknown column(s) '%s'", strings.Join(unknown, "', '")), http.StatusBadRequest)
+		return
+	}
+
+	// Hide soft-deleted rows
+	if col := h.readSoftDeleteColumn(r, tableName); col != "" {
+		filters = append(filters, database.Filter{Column: col, Operator: "is_null"})
+	}
+
+	stopDB := ServerTimingFromContext(r.Context()).Start(TimingDB)
+	rows, err := h.dbMgr.TimeSeriesContext(r.Context(), tableName, h.tables[tableName].DerivedColumns(), filters, ts, h.absoluteMaxRows)
+	if err != nil {
+		stopDB()
+		// Usually a type error, e.g. bucketing a VARCHAR column or averaging text
+		h.logger.Warn("Failed to compute time series", zap.Error(err), zap.String("table", tableName), zap.String("request_id", requestID))
+		h.sendDetailedErrorWithRequest(w, r, "Failed to compute time series", err, http.StatusBadRequest)
+		return
+	}
+	defer rows.Close()
+
+	_, data, _, err := formats.ScanRows(rows, 0)
+	stopDB()
+	if err != nil {
+		h.logger.Error("Failed to read time series", zap.Error(err), zap.String("request_id", requestID))
+		h.sendDetailedErrorWithRequest(w, r, "Failed to compute time series", err, http.StatusBadRequest)
+		return
+	}
+	formats.ConvertDecimals(data, h.decimalAsNumber)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"table":       tableName,
+		"time_column": ts.TimeColumn,
+		"interval":    ts.Interval,
+		"buckets":     len(data),
+		"data":        data,
+	})
+}
@@ -0,0 +1,120 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// setupTimeSeriesTable creates a small request log spanning three hours.
+func setupTimeSeriesTable(t *testing.T) (*CRUDHandler, func()) {
+	t.Helper()
+	handler, mgr, cleanup := setupTestHandler(t)
+	if _, err := mgr.ExecMain(`CREATE TABLE requests (ts TIMESTAMP, status INTEGER, path VARCHAR, latency DOUBLE)`); err != nil {
+		t.Fatalf("Failed to create table: %v", err)
+	}
+	if _, err := mgr.ExecMain(`
+		INSERT INTO requests VALUES
+			('2024-06-01 10:05:00', 200, '/a', 10), ('2024-06-01 10:20:00', 200, '/b', 20),
+			('2024-06-01 10:45:00', 500, '/a', 90), ('2024-06-01 11:10:00', 200, '/a', 30),
+			('2024-06-01 12:30:00', 404, '/c', 5), ('2024-06-01 12:31:00', 200, '/c', 15)
+	`); err != nil {
+		t.Fatalf("Failed to insert rows: %v", err)
+	}
+	return handler, cleanup
+}
+
+func TestCRUDHandler_TimeSeries(t *testing.T) {
+	handler, cleanup := setupTimeSeriesTable(t)
+	defer cleanup()
+
+	get := func(query string) []map[string]interface{} {
+		t.Helper()
+		req := httptest.NewRequest("GET", "/duckdb/api/requests/timeseries?"+query, nil)
+		req = addAuthContext(req, "reader")
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("Expected status 200 for %s, got %d: %s", query, rec.Code, rec.Body.String())
+		}
+		var result struct {
+			Buckets int                      `json:"buckets"`
+			Data    []map[string]interface{} `json:"data"`
+		}
+		if err := json.Unmarshal(rec.Body.Bytes(), &result); err != nil {
+			t.Fatalf("Failed to parse response: %v", err)
+		}
+		if result.Buckets != len(result.Data) {
+			t.Errorf("Expected buckets %d to match the data, got %d", len(result.Data), result.Buckets)
+		}
+		return result.Data
+	}
+
+	// Hourly counts (count:* is the default aggregate)
+	data := get("time_column=ts&interval=1h")
+	wantCounts := []float64{3, 1, 2}
+	if len(data) != len(wantCounts) {
+		t.Fatalf("Expected %d buckets, got %v", len(wantCounts), data)
+	}
+	for i, want := range wantCounts {
+		if data[i]["count"] != want {
+			t.Errorf("Bucket %d: expected count %v, got %v", i, want, data[i]["count"])
+		}
+	}
+	if _, ok := data[0]["bucket"].(string); !ok {
+		t.Errorf("Expected a bucket timestamp, got %v", data[0]["bucket"])
+	}
+
+	// Grouped by status, with another aggregate
+	data = get("time_column=ts&interval=1h&aggregate=count:*,avg:latency&group_by=status")
+	if len(data) != 5 {
+		t.Fatalf("Expected 5 bucket/status groups, got %v", data)
+	}
+	if data[0]["status"] != float64(200) || data[0]["count"] != float64(2) || data[0]["avg_latency"] != float64(15) {
+		t.Errorf("Unexpected first group: %v", data[0])
+	}
+
+	// Filters apply before bucketing; a wider interval merges buckets
+	data = get("time_column=ts&interval=1d&filter=path:eq:/a")
+	if len(data) != 1 || data[0]["count"] != float64(3) {
+		t.Errorf("Expected one daily bucket of 3 rows, got %v", data)
+	}
+}
+
+func TestCRUDHandler_TimeSeriesRejections(t *testing.T) {
+	handler, cleanup := setupTimeSeriesTable(t)
+	defer cleanup()
+	handler.SetTableConfigs(map[string]*TableConfig{"requests": {Filterable: []string{"ts", "status"}}})
+
+	tests := []struct {
+		name       string
+		method     string
+		query      string
+		role       string
+		wantStatus int
+	}{
+		{"missing time column", "GET", "interval=1h", "reader", http.StatusBadRequest},
+		{"missing interval", "GET", "time_column=ts", "reader", http.StatusBadRequest},
+		{"malformed interval", "GET", "time_column=ts&interval=1hour", "reader", http.StatusBadRequest},
+		{"injected interval", "GET", "time_column=ts&interval=1h'--", "reader", http.StatusBadRequest},
+		{"unknown function", "GET", "time_column=ts&interval=1h&aggregate=median:latency", "reader", http.StatusBadRequest},
+		{"star without count", "GET", "time_column=ts&interval=1h&aggregate=sum:*", "reader", http.StatusBadRequest},
+		{"unknown time column", "GET", "time_column=created&interval=1h", "reader", http.StatusBadRequest},
+		{"unknown aggregate column", "GET", "time_column=ts&interval=1h&aggregate=avg:duration", "reader", http.StatusBadRequest},
+		{"non-time column", "GET", "time_column=status&interval=1h", "reader", http.StatusBadRequest},
+		{"group by not filterable", "GET", "time_column=ts&interval=1h&group_by=path", "reader", http.StatusBadRequest},
+		{"wrong method", "POST", "time_column=ts&interval=1h", "admin", http.StatusMethodNotAllowed},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, "/duckdb/api/requests/timeseries?"+tt.query, nil)
+			req = addAuthContext(req, tt.role)
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+			if rec.Code != tt.wantStatus {
+				t.Errorf("Expected status %d, got %d: %s", tt.wantStatus, rec.Code, rec.Body.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,157 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Server-Timing metric names for the phases of a request.
+const (
+	TimingAuth  = "auth"  // API key authentication and permission checks
+	TimingDB    = "db"    // query execution
+	TimingSer   = "ser"   // result serialization
+	TimingTotal = "total" // whole request
+)
+
+// ServerTiming collects the durations of request phases for the Server-Timing
+// response header. All methods are safe to call on a nil *ServerTiming, which
+// records nothing, so handlers can instrument phases unconditionally.
+type ServerTiming struct {
+	start time.Time
+
+	mu      sync.Mutex
+	names   []string
+	metrics map[string]time.Duration
+}
+
+// NewServerTiming creates a collector whose total is measured from now.
+func NewServerTiming() *ServerTiming {
+	return &ServerTiming{start: time.Now(), metrics: make(map[string]time.Duration)}
+}
+
+type serverTimingKey struct{}
+
+// WithServerTiming returns a context carrying the collector.
+func WithServerTiming(ctx context.Context, timing *ServerTiming) context.Context {
+	return context.WithValue(ctx, serverTimingKey{}, timing)
+}
+
+// ServerTimingFromContext returns the collector stored in the context, or nil
+// if Server-Timing is disabled.
+func ServerTimingFromContext(ctx context.Context) *ServerTiming {
+	timing, _ := ctx.Value(serverTimingKey{}).(*ServerTiming)
+	return timing
+}
+
+// Add adds d to the named phase. Repeated phases (e.g. a select and a count)
+// are summed.
+func (t *ServerTiming) Add(name string, d time.Duration) {
+	if t == nil {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if _, ok := t.metrics[name]; !ok {
+		t.names = append(t.names, name)
+	}
+	t.metrics[name] += d
+}
+
+// Duration returns the time recorded for the named phase so far.
+func (t *ServerTiming) Duration(name string) time.Duration {
+	if t == nil {
+		return 0
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.metrics[name]
+}
+
+// Start starts timing the named phase and returns a function that stops it.
+func (t *ServerTiming) Start(name string) func() {
+	if t == nil {
+		return func() {}
+	}
+	start := time.Now()
+	return func() { t.Add(name, time.Since(start)) }
+}
+
+// Header renders the recorded phases followed by the total elapsed time, e.g.
+// "auth;dur=0.41, db;dur=45.02, total;dur=50.13". Durations are in milliseconds.
+func (t *ServerTiming) Header() string {
+	if t == nil {
+		return ""
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	parts := make([]string, 0, len(t.names)+1)
+	for _, name := range t.names {
+		parts = append(parts, formatTimingMetric(name, t.metrics[name]))
+	}
+	parts = append(parts, formatTimingMetric(TimingTotal, time.Since(t.start)))
+	return strings.Join(parts, ", ")
+}
+
+func formatTimingMetric(name string, d time.Duration) string {
+	return fmt.Sprintf("%s;dur=%.2f", name, float64(d.Microseconds())/1000)
+}
+
+// ServerTimingWriter sets the Server-Timing header when the response headers are
+// written. Serialization happens after that point, so Finish sends the complete
+// breakdown (including "ser" and the final total) as a trailer as well.
+type ServerTimingWriter struct {
+	http.ResponseWriter
+	timing      *ServerTiming
+	wroteHeader bool
+}
+
+// NewServerTimingWriter wraps w to report the phases recorded in timing.
+func NewServerTimingWriter(w http.ResponseWriter, timing *ServerTiming) *ServerTimingWriter {
+	return &ServerTimingWriter{ResponseWriter: w, timing: timing}
+}
+
+// WriteHeader sets the Server-Timing header and forwards the status code.
+func (s *ServerTimingWriter) WriteHeader(code int) {
+	if !s.wroteHeader {
+		s.wroteHeader = true
+		s.Header().Set("Server-Timing", s.timing.Header())
+	}
+	s.ResponseWriter.WriteHeader(code)
+}
+
+// Write writes an implicit 200 header if needed and forwards the data.
+func (s *ServerTimingWriter) Write(b []byte) (int, error) {
+	if !s.wroteHeader {
+		s.WriteHeader(http.StatusOK)
+	}
+	return s.ResponseWriter.Write(b)
+}
+
+// Flush forwards flushes so streaming responses (SSE) keep working.
+func (s *ServerTimingWriter) Flush() {
+	if !s.wroteHeader {
+		s.WriteHeader(http.StatusOK)
+	}
+	if f, ok := s.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Unwrap returns the wrapped writer for http.ResponseController.
+func (s *ServerTimingWriter) Unwrap() http.ResponseWriter {
+	return s.ResponseWriter
+}
+
+// Finish is called once the handler has returned. If nothing was written it sets
+// the header; otherwise it sends the complete timings as a trailer.
+func (s *ServerTimingWriter) Finish() {
+	if !s.wroteHeader {
+		s.Header().Set("Server-Timing", s.timing.Header())
+		return
+	}
+	s.Header().Set(http.TrailerPrefix+"Server-Timing", s.timing.Header())
+}
@@ -0,0 +1,100 @@
+package handlers
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// parseServerTiming parses a Server-Timing header into metric durations.
+func parseServerTiming(t *testing.T, header string) map[string]float64 {
+	t.Helper()
+	metrics := make(map[string]float64)
+	for _, entry := range strings.Split(header, ",") {
+		name, params, ok := strings.Cut(strings.TrimSpace(entry), ";")
+		if !ok || !strings.HasPrefix(params, "dur=") {
+			t.Fatalf("Malformed Server-Timing entry %q in %q", entry, header)
+		}
+		dur, err := strconv.ParseFloat(strings.TrimPrefix(params, "dur="), 64)
+		if err != nil || dur < 0 {
+			t.Fatalf("Invalid duration in Server-Timing entry %q: %v", entry, err)
+		}
+		metrics[name] = dur
+	}
+	return metrics
+}
+
+func TestServerTiming_Header(t *testing.T) {
+	timing := NewServerTiming()
+	timing.Add(TimingDB, 40*time.Millisecond)
+	timing.Add(TimingAuth, 1500*time.Microsecond)
+	timing.Add(TimingDB, 5*time.Millisecond)
+
+	header := timing.Header()
+	if !strings.HasPrefix(header, "db;dur=45.00, auth;dur=1.50, total;dur=") {
+		t.Errorf("Expected phases in recording order followed by total, got %q", header)
+	}
+	if metrics := parseServerTiming(t, header); len(metrics) != 3 {
+		t.Errorf("Expected 3 metrics, got %v", metrics)
+	}
+
+	// A nil collector records nothing and never panics
+	var disabled *ServerTiming
+	disabled.Start(TimingDB)()
+	disabled.Add(TimingAuth, time.Second)
+	if disabled.Header() != "" {
+		t.Error("Expected empty header from nil collector")
+	}
+}
+
+func TestServerTimingWriter(t *testing.T) {
+	timing := NewServerTiming()
+	rec := httptest.NewRecorder()
+	w := NewServerTimingWriter(rec, timing)
+
+	timing.Add(TimingDB, 2*time.Millisecond)
+	w.Write([]byte("hello"))
+	timing.Add(TimingSer, time.Millisecond)
+	w.Finish()
+
+	res := rec.Result()
+	header := parseServerTiming(t, res.Header.Get("Server-Timing"))
+	if _, ok := header[TimingSer]; ok {
+		t.Error("Expected header to only contain phases recorded before the body was written")
+	}
+	if _, ok := header[TimingDB]; !ok {
+		t.Error("Expected db phase in header")
+	}
+	trailer := parseServerTiming(t, res.Trailer.Get("Server-Timing"))
+	if _, ok := trailer[TimingSer]; !ok {
+		t.Errorf("Expected ser phase in trailer, got %v", trailer)
+	}
+}
+
+func TestQueryHandler_ServerTiming(t *testing.T) {
+	handler, _, cleanup := setupQueryHandler(t)
+	defer cleanup()
+
+	timing := NewServerTiming()
+	req := httptest.NewRequest("POST", "/duckdb/query", bytes.NewBufferString(`{"sql": "SELECT 42 AS answer"}`))
+	req = addQueryAuthContext(req, "admin")
+	req = req.WithContext(WithServerTiming(req.Context(), timing))
+	rec := httptest.NewRecorder()
+	w := NewServerTimingWriter(rec, timing)
+	handler.ServeHTTP(w, req)
+	w.Finish()
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	trailer := parseServerTiming(t, rec.Result().Trailer.Get("Server-Timing"))
+	for _, name := range []string{TimingAuth, TimingDB, TimingSer, TimingTotal} {
+		if _, ok := trailer[name]; !ok {
+			t.Errorf("Expected %s phase in Server-Timing, got %v", name, trailer)
+		}
+	}
+}
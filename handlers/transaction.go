@@ -0,0 +1,308 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/tobilg/caddy-duckdb-module/auth"
+	"github.com/tobilg/caddy-duckdb-module/database"
+	"github.com/tobilg/caddy-duckdb-module/formats"
+	"go.uber.org/zap"
+)
+
+// maxTransactionOperations is the maximum number of operations accepted in a single transaction.
+const maxTransactionOperations = 1000
+
+// TransactionOperation is a single operation of a transaction request body.
+// Inserts take data; updates take where and set; deletes take where. The where
+// conditions have the same format as the body of an update.
+type TransactionOperation struct {
+	Op    string                 `json:"op"`
+	Table string                 `json:"table"`
+	Data  map[string]interface{} `json:"data,omitempty"`
+	Where []UpdateRequestFilter  `json:"where,omitempty"`
+	Set   map[string]interface{} `json:"set,omitempty"`
+}
+
+// transactionOperations maps the operations of a transaction to the permission they need.
+var transactionOperations = map[string]auth.Operation{
+	database.TxInsert: auth.OperationCreate,
+	database.TxUpdate: auth.OperationUpdate,
+	database.TxDelete: auth.OperationDelete,
+}
+
+// ServeTransaction handles POST /duckdb/transaction, which applies an ordered
+// array of inserts, updates, and deletes, possibly on several tables, in a
+// single transaction:
+//
+//	[
+//	  {"op": "insert", "table": "orders", "data": {"id": 1, "user_id": 7}},
+//	  {"op": "update", "table": "users", "where": [{"column": "id", "op": "eq", "value": 7}], "set": {"orders": 3}},
+//	  {"op": "delete", "table": "carts", "where": [{"column": "user_id", "op": "eq", "value": 7}]}
+//	]
+//
+// Every operation is validated and checked against the role's permissions
+// before any of them runs. Either every operation is applied or none is. Raw
+// SQL is not accepted; use the query endpoint for that.
+func (h *CRUDHandler) ServeTransaction(w http.ResponseWriter, r *http.Request) {
+	r = withQueryTag(r)
+	requestID := auth.GetRequestIDFromContext(r.Context())
+
+	if r.Method != http.MethodPost {
+		h.sendErrorWithRequest(w, r, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	// Writes are refused while maintenance mode is on
+	if inMaintenance(h.maintenance) {
+		h.sendErrorWithRequest(w, r, maintenanceModeMessage, http.StatusServiceUnavailable)
+		return
+	}
+
+	defer r.Body.Close()
+	var ops []TransactionOperation
+	decoder := json.NewDecoder(r.Body)
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&ops); err != nil {
+		h.sendErrorWithRequest(w, r, "Invalid JSON in request body (expected an array of operations with op, table, data, where, and set)", http.StatusBadRequest)
+		return
+	}
+	if len(ops) == 0 {
+		h.sendErrorWithRequest(w, r, "At least one operation is required", http.StatusBadRequest)
+		return
+	}
+	if len(ops) > maxTransactionOperations {
+		h.sendErrorWithRequest(w, r, fmt.Sprintf("Too many operations in transaction: %d (maximum %d)", len(ops), maxTransactionOperations), http.StatusBadRequest)
+		return
+	}
+
+	// Validate and authorize every operation before running any of them
+	txOps := make([]database.TxOperation, len(ops))
+	for i, op := range ops {
+		txOp, status, err := h.transactionOperation(w, r, op)
+		if err != nil {
+			var verr *ValidationError
+			if errors.As(err, &verr) {
+				h.sendValidationErrorWithRequest(w, r, &ValidationError{Rule: verr.Rule, Message: fmt.Sprintf("operation %d: %s", i, verr.Message), Violations: verr.Violations})
+				return
+			}
+			if status == http.StatusInternalServerError {
+				h.logger.Error("Failed to prepare transaction", zap.Error(err), zap.Int("operation", i), zap.String("request_id", requestID))
+				h.sendDetailedErrorWithRequest(w, r, fmt.Sprintf("Operation %d: failed to prepare operation", i), err, status)
+				return
+			}
+			h.sendErrorWithRequest(w, r, fmt.Sprintf("Operation %d: %s", i, err.Error()), status)
+			return
+		}
+		txOps[i] = txOp
+	}
+
+	stopDB := ServerTimingFromContext(r.Context()).Start(TimingDB)
+	affected, err := h.dbMgr.RunTransaction(txOps)
+	stopDB()
+	if err != nil {
+		h.logger.Error("Failed to run transaction", zap.Error(err), zap.String("request_id", requestID))
+		message := "Transaction failed, no changes were applied"
+		var txErr *database.TransactionError
+		if errors.As(err, &txErr) {
+			message = fmt.Sprintf("Transaction failed: operation %d failed, no changes were applied", txErr.Index)
+			err = txErr.Err
+		}
+		h.sendDetailedErrorWithRequest(w, r, message, err, http.StatusInternalServerError)
+		return
+	}
+
+	var total int64
+	results := make([]map[string]interface{}, len(txOps))
+	for i, op := range txOps {
+		if affected[i] > 0 {
+			h.changes.Bump(op.Table)
+		}
+		total += affected[i]
+		results[i] = map[string]interface{}{
+			"op":            op.Op,
+			"table":         op.Table,
+			"rows_affected": affected[i],
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success":       true,
+		"rows_affected": total,
+		"results":       results,
+		"request_id":    requestID,
+	})
+}
+
+// transactionOperation validates an operation of a transaction like the
+// equivalent single request would be, and checks the role's permission for it.
+// It returns the HTTP status for the error.
+func (h *CRUDHandler) transactionOperation(w http.ResponseWriter, r *http.Request, op TransactionOperation) (database.TxOperation, int, error) {
+	operation, ok := transactionOperations[strings.ToLower(op.Op)]
+	if !ok {
+		if strings.EqualFold(op.Op, "sql") || strings.EqualFold(op.Op, "query") {
+			return database.TxOperation{}, http.StatusBadRequest, fmt.Errorf("raw SQL is not supported in transactions")
+		}
+		return database.TxOperation{}, http.StatusBadRequest, fmt.Errorf("invalid op '%s': supported operations are insert, update, delete", op.Op)
+	}
+	txOp := database.TxOperation{Op: strings.ToLower(op.Op)}
+
+	// Sanitize the table name and qualify it with the requested catalog
+	tableName, err := ResolveTableName(r, op.Table, h.dbMgr)
+	if errors.Is(err, errCatalogLookup) {
+		return txOp, http.StatusInternalServerError, err
+	}
+	if err != nil {
+		return txOp, http.StatusBadRequest, err
+	}
+	if _, name := database.SplitTableName(tableName); auth.IsInternalTable(name) {
+		return txOp, http.StatusForbidden, fmt.Errorf("access to internal tables is forbidden")
+	}
+	txOp.Table = tableName
+
+	role := auth.GetRoleFromContext(r.Context())
+	stopAuth := ServerTimingFromContext(r.Context()).Start(TimingAuth)
+	allowed, err := h.authorizer.CheckPermission(role, tableName, operation)
+	stopAuth()
+	if err != nil {
+		return txOp, http.StatusInternalServerError, fmt.Errorf("failed to check permission: %w", err)
+	}
+	if !allowed {
+		return txOp, http.StatusForbidden, fmt.Errorf("forbidden: insufficient permissions for %s operation on table '%s'", strings.ToUpper(txOp.Op), tableName)
+	}
+
+	exists, err := h.dbMgr.TableExists(tableName)
+	if err != nil {
+		return txOp, http.StatusInternalServerError, fmt.Errorf("failed to check table existence: %w", err)
+	}
+	if !exists {
+		return txOp, http.StatusNotFound, fmt.Errorf("table '%s' does not exist", tableName)
+	}
+
+	switch txOp.Op {
+	case database.TxInsert:
+		if len(op.Where) > 0 || len(op.Set) > 0 {
+			return txOp, http.StatusBadRequest, fmt.Errorf("insert takes data, not where or set")
+		}
+		if len(op.Data) == 0 {
+			return txOp, http.StatusBadRequest, fmt.Errorf("data is required for insert")
+		}
+		var status int
+		if txOp.Data, status, err = h.transactionColumns(w, r, tableName, op.Data); err != nil {
+			return txOp, status, err
+		}
+
+		// Apply table validation rules; defaulted columns have no value to validate
+		defaulted := takeDefaults(txOp.Data)
+		if verr := h.validateRow(tableName, txOp.Data, defaulted); verr != nil {
+			return txOp, http.StatusUnprocessableEntity, verr
+		}
+		for _, col := range defaulted {
+			txOp.Data[col] = database.Default
+		}
+		return txOp, 0, nil
+	case database.TxUpdate:
+		if len(op.Data) > 0 {
+			return txOp, http.StatusBadRequest, fmt.Errorf("update takes where and set, not data")
+		}
+		if len(op.Set) == 0 {
+			return txOp, http.StatusBadRequest, fmt.Errorf("set is required for update")
+		}
+		if txOp.Filters, err = h.transactionFilters(tableName, op.Where); err != nil {
+			return txOp, http.StatusBadRequest, err
+		}
+		var status int
+		if txOp.Set, status, err = h.transactionColumns(w, r, tableName, op.Set); err != nil {
+			return txOp, status, err
+		}
+		if verr := h.validateSet(tableName, txOp.Set); verr != nil {
+			return txOp, http.StatusUnprocessableEntity, verr
+		}
+
+		// Soft-deleted rows must be restored before they can be updated
+		if col := h.softDeleteColumn(tableName); col != "" {
+			txOp.Filters = append(txOp.Filters, database.Filter{Column: col, Operator: "is_null"})
+		}
+		return txOp, 0, nil
+	default:
+		if len(op.Data) > 0 || len(op.Set) > 0 {
+			return txOp, http.StatusBadRequest, fmt.Errorf("delete takes where, not data or set")
+		}
+		if txOp.Filters, err = h.transactionFilters(tableName, op.Where); err != nil {
+			return txOp, http.StatusBadRequest, err
+		}
+		// Soft delete if configured for this table
+		txOp.SoftDeleteColumn = h.softDeleteColumn(tableName)
+		return txOp, 0, nil
+	}
+}
+
+// transactionColumns validates the columns of the data of an insert or the SET
+// values of an update. Unknown columns are rejected unless ignore_unknown=true.
+// It returns the HTTP status for the error.
+func (h *CRUDHandler) transactionColumns(w http.ResponseWriter, r *http.Request, tableName string, values map[string]interface{}) (map[string]interface{}, int, error) {
+	if err := h.checkColumnCount(len(values)); err != nil {
+		return nil, http.StatusBadRequest, fmt.Errorf("too many columns: %w", err)
+	}
+	values = formats.MapInputKeys(values, h.jsonKeyCase)
+	columns := make([]string, 0, len(values))
+	for col := range values {
+		if err := SanitizeColumnName(col); err != nil {
+			return nil, http.StatusBadRequest, fmt.Errorf("invalid column name '%s': %w", col, err)
+		}
+		columns = append(columns, col)
+	}
+	if err := h.checkNotDerived(tableName, columns); err != nil {
+		return nil, http.StatusBadRequest, fmt.Errorf("invalid column: %w", err)
+	}
+	if err := h.dropUnknownColumns(w, r, tableName, values); err != nil {
+		var unknownErr *unknownColumnsError
+		if errors.As(err, &unknownErr) {
+			return nil, http.StatusBadRequest, fmt.Errorf("invalid column: %w", err)
+		}
+		return nil, http.StatusInternalServerError, err
+	}
+	if len(values) == 0 {
+		return nil, http.StatusBadRequest, fmt.Errorf("no known columns")
+	}
+	return values, 0, nil
+}
+
+// transactionFilters converts and validates the where conditions of an update
+// or delete. At least one condition is required.
+func (h *CRUDHandler) transactionFilters(tableName string, where []UpdateRequestFilter) ([]database.Filter, error) {
+	if len(where) == 0 {
+		return nil, fmt.Errorf("where is required for update and delete")
+	}
+	filters := make([]database.Filter, 0, len(where))
+	for _, f := range where {
+		column := f.Column
+		if h.jsonKeyCase == formats.KeyCaseCamel {
+			column = formats.ToSnakeCase(column)
+		}
+		if err := SanitizeColumnName(column); err != nil {
+			return nil, fmt.Errorf("invalid where column '%s': %w", column, err)
+		}
+		if !filterOperators[f.Operator] {
+			return nil, fmt.Errorf("invalid operator '%s': supported operators are %s", f.Operator, supportedOperators)
+		}
+		if f.Operator == "between" {
+			if _, _, ok := database.BetweenBounds(f.Value); !ok {
+				return nil, fmt.Errorf("invalid between bounds for '%s': value must be an array of two bounds", column)
+			}
+		}
+		filters = append(filters, database.Filter{Column: column, Operator: f.Operator, Value: f.Value})
+	}
+	if err := h.checkFilterable(tableName, filters); err != nil {
+		return nil, fmt.Errorf("invalid where clause: %w", err)
+	}
+	if err := h.checkNotDerived(tableName, filterColumns(filters)); err != nil {
+		return nil, fmt.Errorf("invalid where clause: %w", err)
+	}
+	return filters, nil
+}
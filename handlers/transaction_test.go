@@ -0,0 +1,189 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/tobilg/caddy-duckdb-module/database"
+)
+
+// setupTransactionHandler adds an orders table next to test_users.
+func setupTransactionHandler(t *testing.T) (*CRUDHandler, *database.Manager, func()) {
+	t.Helper()
+	handler, mgr, cleanup := setupTestHandler(t)
+	if _, err := mgr.ExecMain(`CREATE TABLE orders (id INTEGER PRIMARY KEY, user_id INTEGER, total DOUBLE)`); err != nil {
+		cleanup()
+		t.Fatalf("Failed to create table: %v", err)
+	}
+	return handler, mgr, cleanup
+}
+
+func postTransaction(handler *CRUDHandler, role, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest("POST", "/duckdb/transaction", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	rec := httptest.NewRecorder()
+	handler.ServeTransaction(rec, addAuthContext(req, role))
+	return rec
+}
+
+func countTransactionRows(t *testing.T, mgr *database.Manager, query string) int {
+	t.Helper()
+	var count int
+	if err := mgr.QueryRowScanMain(query, []interface{}{&count}); err != nil {
+		t.Fatalf("Failed to count rows: %v", err)
+	}
+	return count
+}
+
+func TestCRUDHandler_Transaction(t *testing.T) {
+	handler, mgr, cleanup := setupTransactionHandler(t)
+	defer cleanup()
+
+	rec := postTransaction(handler, "admin", `[
+		{"op": "insert", "table": "test_users", "data": {"id": 4, "name": "David", "age": 28}},
+		{"op": "insert", "table": "orders", "data": {"id": 1, "user_id": 4, "total": 12.5}},
+		{"op": "update", "table": "test_users", "where": [{"column": "age", "op": "gte", "value": 30}], "set": {"email": "senior@example.com"}},
+		{"op": "delete", "table": "test_users", "where": [{"column": "name", "op": "eq", "value": "Bob"}]}
+	]`)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	var result struct {
+		Success      bool  `json:"success"`
+		RowsAffected int64 `json:"rows_affected"`
+		Results      []struct {
+			Op           string `json:"op"`
+			Table        string `json:"table"`
+			RowsAffected int64  `json:"rows_affected"`
+		} `json:"results"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &result); err != nil {
+		t.Fatalf("Failed to parse response: %v", err)
+	}
+	if !result.Success || result.RowsAffected != 5 {
+		t.Errorf("Expected success with 5 rows affected, got %+v", result)
+	}
+	want := []int64{1, 1, 2, 1}
+	if len(result.Results) != len(want) {
+		t.Fatalf("Expected %d results, got %+v", len(want), result.Results)
+	}
+	for i, n := range want {
+		if result.Results[i].RowsAffected != n {
+			t.Errorf("Operation %d: expected %d rows affected, got %d", i, n, result.Results[i].RowsAffected)
+		}
+	}
+	if result.Results[1].Op != "insert" || result.Results[1].Table != "orders" {
+		t.Errorf("Unexpected result for operation 1: %+v", result.Results[1])
+	}
+
+	if n := countTransactionRows(t, mgr, `SELECT COUNT(*) FROM test_users`); n != 3 {
+		t.Errorf("Expected 3 users, got %d", n)
+	}
+	if n := countTransactionRows(t, mgr, `SELECT COUNT(*) FROM orders`); n != 1 {
+		t.Errorf("Expected 1 order, got %d", n)
+	}
+}
+
+func TestCRUDHandler_Transaction_Rollback(t *testing.T) {
+	handler, mgr, cleanup := setupTransactionHandler(t)
+	defer cleanup()
+
+	// The third operation violates the primary key of orders
+	rec := postTransaction(handler, "admin", `[
+		{"op": "insert", "table": "orders", "data": {"id": 1, "user_id": 1, "total": 5}},
+		{"op": "update", "table": "test_users", "where": [{"column": "id", "op": "eq", "value": 1}], "set": {"age": 99}},
+		{"op": "insert", "table": "orders", "data": {"id": 1, "user_id": 2, "total": 7}}
+	]`)
+	if rec.Code != http.StatusInternalServerError {
+		t.Fatalf("Expected status 500, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if !strings.Contains(rec.Body.String(), "operation 2 failed") {
+		t.Errorf("Expected the error to name operation 2, got %s", rec.Body.String())
+	}
+
+	if n := countTransactionRows(t, mgr, `SELECT COUNT(*) FROM orders`); n != 0 {
+		t.Errorf("Expected no orders after rollback, got %d", n)
+	}
+	if n := countTransactionRows(t, mgr, `SELECT COUNT(*) FROM test_users WHERE age = 99`); n != 0 {
+		t.Error("Expected the update to be rolled back")
+	}
+}
+
+func TestCRUDHandler_Transaction_PermissionDenied(t *testing.T) {
+	handler, mgr, cleanup := setupTransactionHandler(t)
+	defer cleanup()
+
+	// A role that can write test_users but only read orders
+	if _, err := mgr.ExecAuth(`INSERT INTO roles (role_name, description) VALUES ('users_writer', 'writes test_users')`); err != nil {
+		t.Fatalf("Failed to create role: %v", err)
+	}
+	if _, err := mgr.ExecAuth(`INSERT INTO permissions (id, role_name, table_name, can_create, can_read, can_update, can_delete, can_query)
+		VALUES (nextval('permissions_id_seq'), 'users_writer', 'test_users', true, true, true, true, false),
+		       (nextval('permissions_id_seq'), 'users_writer', 'orders', false, true, false, false, false)`); err != nil {
+		t.Fatalf("Failed to grant permissions: %v", err)
+	}
+
+	rec := postTransaction(handler, "users_writer", `[
+		{"op": "insert", "table": "test_users", "data": {"id": 4, "name": "David"}},
+		{"op": "insert", "table": "orders", "data": {"id": 1, "user_id": 4, "total": 5}}
+	]`)
+	if rec.Code != http.StatusForbidden {
+		t.Fatalf("Expected status 403, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if !strings.Contains(rec.Body.String(), "Operation 1") {
+		t.Errorf("Expected the error to name operation 1, got %s", rec.Body.String())
+	}
+
+	// Nothing ran, including the permitted operation
+	if n := countTransactionRows(t, mgr, `SELECT COUNT(*) FROM test_users WHERE id = 4`); n != 0 {
+		t.Error("Expected the permitted insert not to run")
+	}
+}
+
+func TestCRUDHandler_Transaction_Rejections(t *testing.T) {
+	handler, mgr, cleanup := setupTransactionHandler(t)
+	defer cleanup()
+
+	tests := []struct {
+		name       string
+		body       string
+		wantStatus int
+	}{
+		{"not an array", `{"op": "insert", "table": "orders", "data": {"id": 1}}`, http.StatusBadRequest},
+		{"empty", `[]`, http.StatusBadRequest},
+		{"raw SQL op", `[{"op": "sql", "table": "orders"}]`, http.StatusBadRequest},
+		{"raw SQL field", `[{"op": "insert", "table": "orders", "sql": "DROP TABLE orders"}]`, http.StatusBadRequest},
+		{"unknown op", `[{"op": "merge", "table": "orders"}]`, http.StatusBadRequest},
+		{"invalid table", `[{"op": "insert", "table": "orders; DROP TABLE orders", "data": {"id": 1}}]`, http.StatusBadRequest},
+		{"missing table", `[{"op": "insert", "table": "missing", "data": {"id": 1}}]`, http.StatusNotFound},
+		{"internal table", `[{"op": "delete", "table": "api_keys", "where": [{"column": "key", "op": "ne", "value": ""}]}]`, http.StatusForbidden},
+		{"insert without data", `[{"op": "insert", "table": "orders"}]`, http.StatusBadRequest},
+		{"unknown column", `[{"op": "insert", "table": "orders", "data": {"id": 1, "nope": 2}}]`, http.StatusBadRequest},
+		{"update without where", `[{"op": "update", "table": "orders", "set": {"total": 1}}]`, http.StatusBadRequest},
+		{"delete without where", `[{"op": "delete", "table": "orders"}]`, http.StatusBadRequest},
+		{"invalid operator", `[{"op": "delete", "table": "orders", "where": [{"column": "id", "op": "like_regex", "value": 1}]}]`, http.StatusBadRequest},
+		{"delete with set", `[{"op": "delete", "table": "orders", "where": [{"column": "id", "op": "eq", "value": 1}], "set": {"total": 1}}]`, http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := postTransaction(handler, "admin", tt.body)
+			if rec.Code != tt.wantStatus {
+				t.Errorf("Expected status %d, got %d: %s", tt.wantStatus, rec.Code, rec.Body.String())
+			}
+		})
+	}
+	if n := countTransactionRows(t, mgr, `SELECT COUNT(*) FROM orders`); n != 0 {
+		t.Errorf("Expected rejected transactions to apply nothing, got %d orders", n)
+	}
+
+	req := httptest.NewRequest("GET", "/duckdb/transaction", nil)
+	rec := httptest.NewRecorder()
+	handler.ServeTransaction(rec, addAuthContext(req, "admin"))
+	if rec.Code != http.StatusMethodNotAllowed {
+		t.Errorf("Expected status 405 for GET, got %d", rec.Code)
+	}
+}
@@ -0,0 +1,223 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/tobilg/caddy-duckdb-module/auth"
+	"github.com/tobilg/caddy-duckdb-module/database"
+	"github.com/tobilg/caddy-duckdb-module/formats"
+	"go.uber.org/zap"
+)
+
+// handleUpsert handles PATCH /api/{table}, which inserts rows or updates the
+// existing rows they conflict with (INSERT ... ON CONFLICT DO UPDATE) in a
+// single transaction. Request body format:
+//
+//	{
+//	  "data": [{"id": 1, "status": "active"}, {"id": 2, "status": "new"}],
+//	  "conflict": ["id"]
+//	}
+//
+// data is a row object or an array of rows. conflict names the primary key or
+// unique constraint to match on and defaults to the table's primary key. Only
+// the columns present in a row are written. Requires CREATE and UPDATE
+// permission.
+func (h *CRUDHandler) handleUpsert(w http.ResponseWriter, r *http.Request, tableName string) {
+	requestID := auth.GetRequestIDFromContext(r.Context())
+
+	// Check authorization: an upsert may insert or update any row
+	role := auth.GetRoleFromContext(r.Context())
+	stopAuth := ServerTimingFromContext(r.Context()).Start(TimingAuth)
+	for _, op := range []auth.Operation{auth.OperationCreate, auth.OperationUpdate} {
+		allowed, err := h.authorizer.CheckPermission(role, tableName, op)
+		if err != nil {
+			stopAuth()
+			h.logger.Error("Failed to check permission", zap.Error(err), zap.String("request_id", requestID))
+			h.sendErrorWithRequest(w, r, "Failed to check permission", http.StatusInternalServerError)
+			return
+		}
+		if !allowed {
+			stopAuth()
+			h.sendErrorWithRequest(w, r, "Forbidden: insufficient permissions for UPSERT operation (requires CREATE and UPDATE)", http.StatusForbidden)
+			return
+		}
+	}
+	stopAuth()
+
+	defer r.Body.Close()
+
+	var req struct {
+		Data     json.RawMessage `json:"data"`
+		Conflict []string        `json:"conflict"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.sendErrorWithRequest(w, r, "Invalid JSON in request body", http.StatusBadRequest)
+		return
+	}
+	var rows []map[string]interface{}
+	if data := bytes.TrimSpace(req.Data); len(data) > 0 && data[0] == '[' {
+		if err := json.Unmarshal(data, &rows); err != nil {
+			h.sendErrorWithRequest(w, r, "Invalid data: expected a row object or an array of row objects", http.StatusBadRequest)
+			return
+		}
+	} else {
+		var row map[string]interface{}
+		if err := json.Unmarshal(data, &row); err != nil || row == nil {
+			h.sendErrorWithRequest(w, r, "Invalid data: expected a row object or an array of row objects", http.StatusBadRequest)
+			return
+		}
+		rows = []map[string]interface{}{row}
+	}
+	if len(rows) == 0 {
+		h.sendErrorWithRequest(w, r, "At least one row is required", http.StatusBadRequest)
+		return
+	}
+	if len(rows) > maxBulkInsertRows {
+		h.sendErrorWithRequest(w, r, fmt.Sprintf("Too many rows in upsert: %d (maximum %d)", len(rows), maxBulkInsertRows), http.StatusBadRequest)
+		return
+	}
+
+	// Validate the column names of every row, then check them against the schema together
+	known := make(map[string]interface{})
+	for i := range rows {
+		if err := h.checkColumnCount(len(rows[i])); err != nil {
+			h.sendErrorWithRequest(w, r, fmt.Sprintf("Row %d: too many columns: %s", i, err.Error()), http.StatusBadRequest)
+			return
+		}
+		rows[i] = formats.MapInputKeys(rows[i], h.jsonKeyCase)
+		columns := make([]string, 0, len(rows[i]))
+		for col := range rows[i] {
+			if err := SanitizeColumnName(col); err != nil {
+				h.sendErrorWithRequest(w, r, fmt.Sprintf("Row %d: invalid column name '%s': %s", i, col, err.Error()), http.StatusBadRequest)
+				return
+			}
+			columns = append(columns, col)
+			known[col] = nil
+		}
+		if err := h.checkNotDerived(tableName, columns); err != nil {
+			h.sendErrorWithRequest(w, r, fmt.Sprintf("Row %d: invalid column: %s", i, err.Error()), http.StatusBadRequest)
+			return
+		}
+	}
+	if err := h.dropUnknownColumns(w, r, tableName, known); err != nil {
+		var unknownErr *unknownColumnsError
+		if errors.As(err, &unknownErr) {
+			h.sendErrorWithRequest(w, r, fmt.Sprintf("Invalid column: %s", err.Error()), http.StatusBadRequest)
+			return
+		}
+		h.logger.Error("Failed to check columns", zap.Error(err), zap.String("table", tableName), zap.String("request_id", requestID))
+		h.sendDetailedErrorWithRequest(w, r, "Failed to upsert data", err, http.StatusInternalServerError)
+		return
+	}
+
+	// Resolve the conflict target against the table's keys
+	conflict, status, err := h.upsertConflictColumns(tableName, req.Conflict)
+	if err != nil {
+		if status == http.StatusInternalServerError {
+			h.logger.Error("Failed to get table keys", zap.Error(err), zap.String("table", tableName), zap.String("request_id", requestID))
+			h.sendDetailedErrorWithRequest(w, r, "Failed to upsert data", err, status)
+			return
+		}
+		h.sendErrorWithRequest(w, r, fmt.Sprintf("Invalid conflict: %s", err.Error()), status)
+		return
+	}
+
+	for i, row := range rows {
+		for col := range row {
+			if _, ok := known[col]; !ok {
+				delete(row, col)
+			}
+		}
+		if len(row) == 0 {
+			h.sendErrorWithRequest(w, r, fmt.Sprintf("Row %d contains no known columns", i), http.StatusBadRequest)
+			return
+		}
+
+		// Apply table validation rules; defaulted columns have no value to validate
+		defaulted := takeDefaults(row)
+		for _, col := range conflict {
+			if _, ok := row[col]; !ok {
+				h.sendErrorWithRequest(w, r, fmt.Sprintf("Row %d: missing conflict column '%s'", i, col), http.StatusBadRequest)
+				return
+			}
+		}
+		if verr := h.validateRow(tableName, row, defaulted); verr != nil {
+			h.sendValidationErrorWithRequest(w, r, &ValidationError{Rule: verr.Rule, Message: fmt.Sprintf("row %d: %s", i, verr.Message), Violations: verr.Violations})
+			return
+		}
+		for _, col := range defaulted {
+			row[col] = database.Default
+		}
+	}
+
+	stopDB := ServerTimingFromContext(r.Context()).Start(TimingDB)
+	result, err := h.dbMgr.Upsert(tableName, rows, conflict)
+	stopDB()
+	if err != nil {
+		h.logger.Error("Failed to upsert data", zap.Error(err), zap.String("table", tableName), zap.String("request_id", requestID))
+		message := "Failed to upsert data"
+		var batchErr *database.InsertBatchError
+		if errors.As(err, &batchErr) {
+			message = fmt.Sprintf("Failed to upsert data: row %d failed, no rows were written", batchErr.Index)
+			err = batchErr.Err
+		}
+		h.sendDetailedErrorWithRequest(w, r, message, err, http.StatusInternalServerError)
+		return
+	}
+
+	h.changes.Bump(tableName)
+	h.sendSuccessWithRequest(w, r, result.RowsAffected, http.StatusOK)
+}
+
+// upsertConflictColumns returns the conflict target of an upsert: the
+// requested columns, which must be exactly the columns of the table's primary
+// key or of a unique constraint, or the primary key when none are requested.
+// The status is the HTTP status for the error.
+func (h *CRUDHandler) upsertConflictColumns(tableName string, requested []string) ([]string, int, error) {
+	if h.jsonKeyCase == formats.KeyCaseCamel {
+		mapped := make([]string, len(requested))
+		for i, col := range requested {
+			mapped[i] = formats.ToSnakeCase(col)
+		}
+		requested = mapped
+	}
+	for _, col := range requested {
+		if err := SanitizeColumnName(col); err != nil {
+			return nil, http.StatusBadRequest, fmt.Errorf("invalid column name '%s': %s", col, err.Error())
+		}
+	}
+
+	keys, err := h.dbMgr.UniqueKeys(tableName)
+	if err != nil {
+		return nil, http.StatusInternalServerError, err
+	}
+	if len(requested) == 0 {
+		for _, key := range keys {
+			if key.Primary {
+				return key.Columns, http.StatusOK, nil
+			}
+		}
+		return nil, http.StatusBadRequest, fmt.Errorf("table '%s' has no primary key; name the columns of a unique constraint in conflict", tableName)
+	}
+
+	want := sortedColumns(requested)
+	for _, key := range keys {
+		if sortedColumns(key.Columns) == want {
+			return key.Columns, http.StatusOK, nil
+		}
+	}
+	return nil, http.StatusBadRequest, fmt.Errorf("columns '%s' are not the primary key or a unique constraint of table '%s'", strings.Join(requested, "', '"), tableName)
+}
+
+// sortedColumns returns a comparable form of a set of column names.
+func sortedColumns(columns []string) string {
+	sorted := append([]string{}, columns...)
+	sort.Strings(sorted)
+	return strings.Join(sorted, ",")
+}
@@ -0,0 +1,151 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// upsert sends a PATCH /api/test_users request with the given body and role.
+func upsert(t *testing.T, handler *CRUDHandler, role, body string) *httptest.ResponseRecorder {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodPatch, "/duckdb/api/test_users", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	req = addAuthContext(req, role)
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestCRUDHandler_Upsert(t *testing.T) {
+	handler, mgr, cleanup := setupTestHandler(t)
+	defer cleanup()
+
+	// Insert path: a new key inserts the row, with the primary key inferred
+	rec := upsert(t, handler, "editor", `{"data": {"id": 4, "name": "Dave", "email": "dave@example.com", "age": 40}}`)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var result map[string]interface{}
+	json.Unmarshal(rec.Body.Bytes(), &result)
+	if result["rows_affected"] != float64(1) {
+		t.Errorf("Expected 1 row affected, got %v", result["rows_affected"])
+	}
+	var name string
+	if err := mgr.QueryRowScanMain("SELECT name FROM test_users WHERE id = 4", []interface{}{&name}); err != nil || name != "Dave" {
+		t.Errorf("Expected Dave to be inserted, got %q (%v)", name, err)
+	}
+
+	// Update path: existing keys are updated, only the given columns change
+	rec = upsert(t, handler, "editor", `{"data": [{"id": 1, "age": 31}, {"id": 5, "name": "Eve", "age": 22}], "conflict": ["id"]}`)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var email string
+	var age int
+	if err := mgr.QueryRowScanMain("SELECT name, email, age FROM test_users WHERE id = 1", []interface{}{&name, &email, &age}); err != nil {
+		t.Fatalf("Failed to read row: %v", err)
+	}
+	if name != "Alice" || email != "alice@example.com" || age != 31 {
+		t.Errorf("Expected only Alice's age to change, got %s, %s, %d", name, email, age)
+	}
+	var count int
+	if err := mgr.QueryRowScanMain("SELECT COUNT(*) FROM test_users", []interface{}{&count}); err != nil || count != 5 {
+		t.Errorf("Expected 5 rows, got %d (%v)", count, err)
+	}
+
+	// Repeating the same upsert is idempotent
+	rec = upsert(t, handler, "editor", `{"data": {"id": 4, "name": "Dave", "email": "dave@example.com", "age": 40}}`)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if err := mgr.QueryRowScanMain("SELECT COUNT(*) FROM test_users", []interface{}{&count}); err != nil || count != 5 {
+		t.Errorf("Expected still 5 rows, got %d (%v)", count, err)
+	}
+}
+
+func TestCRUDHandler_Upsert_ConflictColumns(t *testing.T) {
+	handler, mgr, cleanup := setupTestHandler(t)
+	defer cleanup()
+	if _, err := mgr.ExecMain(`CREATE TABLE test_accounts (id INTEGER PRIMARY KEY, email VARCHAR UNIQUE, name VARCHAR)`); err != nil {
+		t.Fatalf("Failed to create table: %v", err)
+	}
+	if _, err := mgr.ExecMain(`INSERT INTO test_accounts VALUES (1, 'a@example.com', 'A')`); err != nil {
+		t.Fatalf("Failed to insert row: %v", err)
+	}
+	if _, err := mgr.ExecMain(`CREATE TABLE test_log (message VARCHAR)`); err != nil {
+		t.Fatalf("Failed to create table: %v", err)
+	}
+
+	serve := func(table, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPatch, "/duckdb/api/"+table, strings.NewReader(body))
+		req = addAuthContext(req, "admin")
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec
+	}
+
+	// A unique constraint can be the conflict target
+	rec := serve("test_accounts", `{"data": {"id": 1, "email": "a@example.com", "name": "Renamed"}, "conflict": ["email"]}`)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected status 200 for a unique conflict target, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var name string
+	if err := mgr.QueryRowScanMain("SELECT name FROM test_accounts WHERE id = 1", []interface{}{&name}); err != nil || name != "Renamed" {
+		t.Errorf("Expected the row to be updated, got %q (%v)", name, err)
+	}
+
+	tests := []struct {
+		name  string
+		table string
+		body  string
+		want  string
+	}{
+		{"not a key", "test_accounts", `{"data": {"id": 2, "name": "B"}, "conflict": ["name"]}`, "not the primary key or a unique constraint"},
+		{"invalid column", "test_accounts", `{"data": {"id": 2}, "conflict": ["id;drop"]}`, "invalid column name"},
+		{"missing conflict column", "test_accounts", `{"data": {"name": "B"}}`, "missing conflict column 'id'"},
+		{"no primary key", "test_log", `{"data": {"message": "hi"}}`, "has no primary key"},
+		{"no data", "test_accounts", `{"conflict": ["id"]}`, "Invalid data"},
+		{"empty array", "test_accounts", `{"data": []}`, "At least one row is required"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := serve(tt.table, tt.body)
+			if rec.Code != http.StatusBadRequest {
+				t.Fatalf("Expected status 400, got %d: %s", rec.Code, rec.Body.String())
+			}
+			if !strings.Contains(rec.Body.String(), tt.want) {
+				t.Errorf("Expected error containing %q, got %s", tt.want, rec.Body.String())
+			}
+		})
+	}
+}
+
+func TestCRUDHandler_Upsert_Permissions(t *testing.T) {
+	handler, mgr, cleanup := setupTestHandler(t)
+	defer cleanup()
+
+	// Readers cannot upsert
+	rec := upsert(t, handler, "reader", `{"data": {"id": 9, "name": "Mallory"}}`)
+	if rec.Code != http.StatusForbidden {
+		t.Errorf("Expected status 403 for reader, got %d", rec.Code)
+	}
+
+	// CREATE alone is not enough: an upsert may update existing rows
+	if _, err := mgr.ExecAuth(`INSERT INTO roles (role_name, description) VALUES ('ingest', 'insert only')`); err != nil {
+		t.Fatalf("Failed to create role: %v", err)
+	}
+	if _, err := mgr.ExecAuth(`INSERT INTO permissions (id, role_name, table_name, can_create, can_read, can_update, can_delete, can_query)
+		VALUES (nextval('permissions_id_seq'), 'ingest', 'test_users', true, true, false, false, false)`); err != nil {
+		t.Fatalf("Failed to grant permission: %v", err)
+	}
+	rec = upsert(t, handler, "ingest", `{"data": {"id": 1, "name": "Mallory"}}`)
+	if rec.Code != http.StatusForbidden {
+		t.Errorf("Expected status 403 without UPDATE permission, got %d", rec.Code)
+	}
+	if !strings.Contains(rec.Body.String(), "requires CREATE and UPDATE") {
+		t.Errorf("Expected the error to name both permissions, got %s", rec.Body.String())
+	}
+}
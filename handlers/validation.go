@@ -0,0 +1,160 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Supported validation rule types.
+const (
+	RuleMin   = "min"
+	RuleMax   = "max"
+	RuleEnum  = "enum"
+	RuleRegex = "regex"
+)
+
+// ValidationRule is a simple per-column business rule enforced on create and update.
+type ValidationRule struct {
+	// Column is the column the rule applies to.
+	Column string `json:"column"`
+
+	// Rule is the rule type: min, max, enum, or regex.
+	Rule string `json:"rule"`
+
+	// Values holds the rule arguments: a single bound for min/max,
+	// a single pattern for regex, or the allowed values for enum.
+	Values []string `json:"values"`
+}
+
+// String returns the rule in its Caddyfile form, e.g. "age min 0".
+func (r ValidationRule) String() string {
+	return fmt.Sprintf("%s %s %s", r.Column, r.Rule, strings.Join(r.Values, " "))
+}
+
+// ValidationError describes a value that violated a validation rule, or a
+// body that does not match the table's JSON Schema (Violations set, Rule empty).
+type ValidationError struct {
+	Rule       ValidationRule
+	Message    string
+	Violations []SchemaViolation
+}
+
+// Error implements the error interface.
+func (e *ValidationError) Error() string {
+	return e.Message
+}
+
+// columnValidator is a compiled validation rule.
+type columnValidator struct {
+	rule  ValidationRule
+	check func(value interface{}) error
+}
+
+// compileValidationRule parses a rule into a validator.
+// Called at provision time so invalid bounds or patterns fail fast.
+func compileValidationRule(rule ValidationRule) (*columnValidator, error) {
+	if err := SanitizeColumnName(rule.Column); err != nil {
+		return nil, fmt.Errorf("invalid column '%s': %v", rule.Column, err)
+	}
+
+	switch rule.Rule {
+	case RuleMin, RuleMax:
+		if len(rule.Values) != 1 {
+			return nil, fmt.Errorf("%s rule requires exactly one value", rule.Rule)
+		}
+		bound, err := strconv.ParseFloat(rule.Values[0], 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s value '%s': %v", rule.Rule, rule.Values[0], err)
+		}
+		isMin := rule.Rule == RuleMin
+		return &columnValidator{rule: rule, check: func(value interface{}) error {
+			n, ok := toFloat(value)
+			if !ok {
+				return fmt.Errorf("%s must be a number", rule.Column)
+			}
+			if isMin && n < bound {
+				return fmt.Errorf("%s must be >= %s", rule.Column, rule.Values[0])
+			}
+			if !isMin && n > bound {
+				return fmt.Errorf("%s must be <= %s", rule.Column, rule.Values[0])
+			}
+			return nil
+		}}, nil
+
+	case RuleEnum:
+		if len(rule.Values) == 0 {
+			return nil, fmt.Errorf("enum rule requires at least one value")
+		}
+		allowed := make(map[string]bool, len(rule.Values))
+		for _, v := range rule.Values {
+			allowed[v] = true
+		}
+		return &columnValidator{rule: rule, check: func(value interface{}) error {
+			if !allowed[fmt.Sprint(value)] {
+				return fmt.Errorf("%s must be one of: %s", rule.Column, strings.Join(rule.Values, ", "))
+			}
+			return nil
+		}}, nil
+
+	case RuleRegex:
+		if len(rule.Values) != 1 {
+			return nil, fmt.Errorf("regex rule requires exactly one pattern")
+		}
+		re, err := regexp.Compile(rule.Values[0])
+		if err != nil {
+			return nil, fmt.Errorf("invalid regex '%s': %v", rule.Values[0], err)
+		}
+		return &columnValidator{rule: rule, check: func(value interface{}) error {
+			if !re.MatchString(fmt.Sprint(value)) {
+				return fmt.Errorf("%s must match pattern %s", rule.Column, rule.Values[0])
+			}
+			return nil
+		}}, nil
+
+	default:
+		return nil, fmt.Errorf("unknown rule type '%s' (supported: min, max, enum, regex)", rule.Rule)
+	}
+}
+
+// validateValues checks the provided column values against compiled validators.
+// Columns that are absent or NULL are not validated.
+// Returns the first violation found, or nil.
+func validateValues(validators []*columnValidator, data map[string]interface{}) *ValidationError {
+	for _, v := range validators {
+		value, ok := data[v.rule.Column]
+		if !ok || value == nil {
+			continue
+		}
+		if err := v.check(value); err != nil {
+			return &ValidationError{Rule: v.rule, Message: err.Error()}
+		}
+	}
+	return nil
+}
+
+// toFloat converts a JSON-decoded value to float64.
+func toFloat(value interface{}) (float64, bool) {
+	switch v := value.(type) {
+	case float64:
+		return v, true
+	case float32:
+		return float64(v), true
+	case int:
+		return float64(v), true
+	case int64:
+		return float64(v), true
+	case int32:
+		return float64(v), true
+	case json.Number:
+		f, err := v.Float64()
+		return f, err == nil
+	case string:
+		f, err := strconv.ParseFloat(v, 64)
+		return f, err == nil
+	default:
+		return 0, false
+	}
+}
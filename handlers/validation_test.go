@@ -0,0 +1,115 @@
+package handlers
+
+import (
+	"testing"
+)
+
+func TestCompileValidationRule(t *testing.T) {
+	tests := []struct {
+		name    string
+		rule    ValidationRule
+		good    interface{}
+		bad     interface{}
+		wantErr bool
+	}{
+		{
+			name: "min",
+			rule: ValidationRule{Column: "age", Rule: RuleMin, Values: []string{"0"}},
+			good: float64(0),
+			bad:  float64(-1),
+		},
+		{
+			name: "max",
+			rule: ValidationRule{Column: "age", Rule: RuleMax, Values: []string{"150"}},
+			good: float64(150),
+			bad:  float64(151),
+		},
+		{
+			name: "min with non-numeric value",
+			rule: ValidationRule{Column: "age", Rule: RuleMin, Values: []string{"0"}},
+			good: "42",
+			bad:  "old",
+		},
+		{
+			name: "enum",
+			rule: ValidationRule{Column: "status", Rule: RuleEnum, Values: []string{"active", "inactive"}},
+			good: "active",
+			bad:  "deleted",
+		},
+		{
+			name: "regex",
+			rule: ValidationRule{Column: "email", Rule: RuleRegex, Values: []string{`^[^@]+@[^@]+$`}},
+			good: "alice@example.com",
+			bad:  "not-an-email",
+		},
+		{
+			name:    "invalid min bound",
+			rule:    ValidationRule{Column: "age", Rule: RuleMin, Values: []string{"zero"}},
+			wantErr: true,
+		},
+		{
+			name:    "invalid regex",
+			rule:    ValidationRule{Column: "email", Rule: RuleRegex, Values: []string{"("}},
+			wantErr: true,
+		},
+		{
+			name:    "empty enum",
+			rule:    ValidationRule{Column: "status", Rule: RuleEnum},
+			wantErr: true,
+		},
+		{
+			name:    "unknown rule",
+			rule:    ValidationRule{Column: "age", Rule: "between", Values: []string{"1"}},
+			wantErr: true,
+		},
+		{
+			name:    "invalid column",
+			rule:    ValidationRule{Column: "age;", Rule: RuleMin, Values: []string{"0"}},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v, err := compileValidationRule(tt.rule)
+			if tt.wantErr {
+				if err == nil {
+					t.Error("Expected error compiling rule")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error compiling rule: %v", err)
+			}
+			if err := v.check(tt.good); err != nil {
+				t.Errorf("Expected %v to pass, got: %v", tt.good, err)
+			}
+			if err := v.check(tt.bad); err == nil {
+				t.Errorf("Expected %v to fail", tt.bad)
+			}
+		})
+	}
+}
+
+func TestValidateValues(t *testing.T) {
+	cfg := &TableConfig{Rules: []ValidationRule{
+		{Column: "age", Rule: RuleMin, Values: []string{"0"}},
+		{Column: "status", Rule: RuleEnum, Values: []string{"a", "b"}},
+	}}
+	if err := cfg.Provision(); err != nil {
+		t.Fatalf("Provision failed: %v", err)
+	}
+
+	// Absent and NULL columns are not validated
+	if verr := validateValues(cfg.validators, map[string]interface{}{"status": nil}); verr != nil {
+		t.Errorf("Expected no violation, got: %v", verr)
+	}
+
+	verr := validateValues(cfg.validators, map[string]interface{}{"age": float64(5), "status": "c"})
+	if verr == nil {
+		t.Fatal("Expected enum violation")
+	}
+	if verr.Rule.Column != "status" || verr.Rule.Rule != RuleEnum {
+		t.Errorf("Expected status enum rule, got %v", verr.Rule)
+	}
+}
@@ -0,0 +1,76 @@
+package duckdb
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+)
+
+// responseHeaderScopes returns the paths of ResponseHeaders, least specific
+// first, so headers of a more specific path override those of its parents.
+func (d *DuckDB) responseHeaderScopes() []string {
+	scopes := make([]string, 0, len(d.ResponseHeaders))
+	for scope := range d.ResponseHeaders {
+		scopes = append(scopes, scope)
+	}
+	sort.Slice(scopes, func(i, j int) bool {
+		if len(scopes[i]) != len(scopes[j]) {
+			return len(scopes[i]) < len(scopes[j])
+		}
+		return scopes[i] < scopes[j]
+	})
+	return scopes
+}
+
+// setResponseHeaders sets the configured static headers of every path that
+// contains the request path. Handlers may still override them.
+func (d *DuckDB) setResponseHeaders(w http.ResponseWriter, r *http.Request) {
+	path := strings.TrimPrefix(r.URL.Path, d.routePrefix)
+	for _, scope := range d.headerScopes {
+		if scope != "/" && path != scope && !strings.HasPrefix(path, scope+"/") {
+			continue
+		}
+		for name, value := range d.ResponseHeaders[scope] {
+			w.Header().Set(name, value)
+		}
+	}
+}
+
+// validateResponseHeaders checks the paths, header names, and values of a
+// response_headers configuration.
+func validateResponseHeaders(headers map[string]map[string]string) error {
+	for scope, values := range headers {
+		if !strings.HasPrefix(scope, "/") || (scope != "/" && strings.HasSuffix(scope, "/")) {
+			return fmt.Errorf("response_headers path '%s' must start with '/' and must not end with '/'", scope)
+		}
+		for name, value := range values {
+			if !isHeaderName(name) {
+				return fmt.Errorf("invalid response header name '%s'", name)
+			}
+			if strings.EqualFold(name, "X-Request-ID") {
+				return fmt.Errorf("response header '%s' is set by the module and cannot be configured", name)
+			}
+			if strings.ContainsAny(value, "\r\n") {
+				return fmt.Errorf("response header '%s' must not contain line breaks", name)
+			}
+		}
+	}
+	return nil
+}
+
+// isHeaderName reports whether name is a valid HTTP header field name (an RFC 7230 token).
+func isHeaderName(name string) bool {
+	if name == "" {
+		return false
+	}
+	for _, c := range name {
+		switch {
+		case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c >= '0' && c <= '9':
+		case strings.ContainsRune("!#$%&'*+-.^_`|~", c):
+		default:
+			return false
+		}
+	}
+	return true
+}
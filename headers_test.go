@@ -0,0 +1,110 @@
+package duckdb
+
+import (
+	"testing"
+
+	"github.com/caddyserver/caddy/v2/caddyconfig/caddyfile"
+)
+
+func TestServeHTTP_ResponseHeaders(t *testing.T) {
+	d, cleanup := setupMaintenanceModule(t)
+	defer cleanup()
+
+	d.ResponseHeaders = map[string]map[string]string{
+		"/":               {"X-Api-Version": "2"},
+		"/query":          {"X-Deprecation": "true"},
+		"/api/test_data":  {"X-Api-Version": "3"},
+		"/api/test_dat":   {"X-Prefix-Only": "1"},
+		"/admin/indexes":  {"X-Not-Matched": "1"},
+		"/api/test_data2": {"X-Not-Matched": "1"},
+	}
+	d.headerScopes = d.responseHeaderScopes()
+
+	tests := []struct {
+		name        string
+		method      string
+		path        string
+		body        string
+		apiKey      string
+		version     string
+		deprecation string
+	}{
+		{"health", "GET", "/duckdb/health", "", "", "2", ""},
+		{"unauthorized", "GET", "/duckdb/api/test_data", "", "", "3", ""},
+		{"table read", "GET", "/duckdb/api/test_data", "", "test-api-key", "3", ""},
+		{"query", "POST", "/duckdb/query", `{"sql": "SELECT 1"}`, "test-api-key", "2", "true"},
+		{"query error", "POST", "/duckdb/query", `{"sql": "SELEC 1"}`, "test-api-key", "2", "true"},
+		{"unknown endpoint", "GET", "/duckdb/unknown", "", "test-api-key", "2", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := serve(t, d, tt.method, tt.path, tt.body, tt.apiKey)
+			if got := rec.Header().Get("X-Api-Version"); got != tt.version {
+				t.Errorf("Expected X-Api-Version %q, got %q (status %d)", tt.version, got, rec.Code)
+			}
+			if got := rec.Header().Get("X-Deprecation"); got != tt.deprecation {
+				t.Errorf("Expected X-Deprecation %q, got %q", tt.deprecation, got)
+			}
+			if rec.Header().Get("X-Prefix-Only") != "" || rec.Header().Get("X-Not-Matched") != "" {
+				t.Errorf("Expected headers of other paths not to be set, got %v", rec.Header())
+			}
+			if rec.Header().Get("X-Request-ID") == "" {
+				t.Error("Expected X-Request-ID to be set")
+			}
+		})
+	}
+}
+
+func TestValidate_ResponseHeaders(t *testing.T) {
+	invalid := []map[string]map[string]string{
+		{"query": {"X-Api-Version": "2"}},
+		{"/query/": {"X-Api-Version": "2"}},
+		{"/": {"X Api Version": "2"}},
+		{"/": {"": "2"}},
+		{"/": {"X-Request-ID": "fixed"}},
+		{"/": {"X-Api-Version": "2\r\nSet-Cookie: a=b"}},
+	}
+	for _, headers := range invalid {
+		d := &DuckDB{AccessMode: "read_write", MaxRowsPerPage: 100, Threads: 1, ResponseHeaders: headers}
+		if err := d.Validate(); err == nil {
+			t.Errorf("Expected error for %v", headers)
+		}
+	}
+}
+
+func TestUnmarshalCaddyfile_ResponseHeaders(t *testing.T) {
+	dispenser := caddyfile.NewTestDispenser(`duckdb {
+		response_headers {
+			X-Api-Version 2
+		}
+		response_headers /query {
+			X-Deprecation "use /api instead"
+		}
+		response_headers {
+			X-Team data
+		}
+	}`)
+	d := &DuckDB{}
+	if err := d.UnmarshalCaddyfile(dispenser); err != nil {
+		t.Fatalf("UnmarshalCaddyfile failed: %v", err)
+	}
+	if len(d.ResponseHeaders) != 2 {
+		t.Fatalf("Expected headers for 2 paths, got %v", d.ResponseHeaders)
+	}
+	if d.ResponseHeaders["/"]["X-Api-Version"] != "2" || d.ResponseHeaders["/"]["X-Team"] != "data" {
+		t.Errorf("Unexpected headers for all endpoints: %v", d.ResponseHeaders["/"])
+	}
+	if d.ResponseHeaders["/query"]["X-Deprecation"] != "use /api instead" {
+		t.Errorf("Unexpected headers for /query: %v", d.ResponseHeaders["/query"])
+	}
+
+	dispenser = caddyfile.NewTestDispenser(`duckdb {
+		response_headers {
+			X-Api-Version
+		}
+	}`)
+	d = &DuckDB{}
+	if err := d.UnmarshalCaddyfile(dispenser); err == nil {
+		t.Error("Expected error for a header without a value")
+	}
+}
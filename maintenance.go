@@ -0,0 +1,12 @@
+package duckdb
+
+import "github.com/caddyserver/caddy/v2"
+
+// MaintenanceConfig configures the background maintenance of the main database.
+type MaintenanceConfig struct {
+	// Interval between maintenance runs. Zero disables maintenance.
+	Interval caddy.Duration `json:"interval,omitempty"`
+
+	// Analyze also refreshes optimizer statistics with ANALYZE on each run.
+	Analyze bool `json:"analyze,omitempty"`
+}
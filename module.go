@@ -1,9 +1,12 @@
 package duckdb
 
 import (
+	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 	"os"
+	"sort"
 	"strconv"
 	"strings"
 	"time"
@@ -15,6 +18,7 @@ import (
 	"github.com/google/uuid"
 	"github.com/tobilg/caddy-duckdb-module/auth"
 	"github.com/tobilg/caddy-duckdb-module/database"
+	"github.com/tobilg/caddy-duckdb-module/formats"
 	"github.com/tobilg/caddy-duckdb-module/handlers"
 	"go.uber.org/zap"
 )
@@ -38,6 +42,12 @@ type DuckDB struct {
 	// Default is 10 seconds.
 	QueryTimeout caddy.Duration `json:"query_timeout,omitempty"`
 
+	// ConnectionAcquireTimeout is the maximum time a statement waits for a free
+	// database connection. When the pool is exhausted for longer, the request
+	// fails with 503 "Database busy" instead of queueing until the query
+	// timeout. Default is 0 (wait as long as the query timeout allows).
+	ConnectionAcquireTimeout caddy.Duration `json:"connection_acquire_timeout,omitempty"`
+
 	// MaxRowsPerPage is the default number of rows per page when pagination is used.
 	// Default is 100.
 	MaxRowsPerPage int `json:"max_rows_per_page,omitempty"`
@@ -48,6 +58,12 @@ type DuckDB struct {
 	// Default is 10000.
 	AbsoluteMaxRows int `json:"absolute_max_rows,omitempty"`
 
+	// OnRowLimit decides what a table read without pagination returns when
+	// more than AbsoluteMaxRows rows match: "truncate" returns the first rows
+	// flagged with "truncated": true, "error" fails with 400 and asks the client
+	// to paginate. Default is "truncate".
+	OnRowLimit string `json:"on_row_limit,omitempty"`
+
 	// Threads is the number of threads DuckDB should use.
 	// Default is 4.
 	Threads int `json:"threads,omitempty"`
@@ -68,14 +84,251 @@ type DuckDB struct {
 	// If empty, uses system default.
 	TempDirectory string `json:"temp_directory,omitempty"`
 
-	logger         *zap.Logger
-	dbMgr          *database.Manager
-	authorizer     *auth.Authorizer
-	authMw         *auth.Middleware
-	crudHandler    *handlers.CRUDHandler
-	queryHandler   *handlers.QueryHandler
-	openAPIHandler *handlers.OpenAPIHandler
-	routePrefix    string // set from DUCKDB_ROUTE_PREFIX env var, defaults to /duckdb
+	// ReadPoolSize opens a dedicated pool of this many connections to the main
+	// database for reads (table reads and SELECT queries), so reads do not queue
+	// behind writes for a connection. Writes keep the main pool. Requires
+	// access_mode read_write. Default is 0 (reads and writes share one pool).
+	ReadPoolSize int `json:"read_pool_size,omitempty"`
+
+	// TablePools route writes on the listed tables through named pools of
+	// dedicated connections to the main database, so transaction conflicts and
+	// retries on a hot table do not tie up connections other writes need.
+	// Requires access_mode read_write.
+	TablePools []database.TablePool `json:"table_pools,omitempty"`
+
+	// CSVCharset is the default charset for CSV responses when the client sends no
+	// Accept-Charset header. Supported: utf-8, iso-8859-1 (latin1), iso-8859-15 (latin9),
+	// windows-1252 (cp1252). Default is utf-8.
+	CSVCharset string `json:"csv_charset,omitempty"`
+
+	// RejectUnsupportedCharset responds with 406 Not Acceptable when the Accept-Charset
+	// header names no supported charset. When false, such requests fall back to UTF-8.
+	// Default is false.
+	RejectUnsupportedCharset bool `json:"reject_unsupported_charset,omitempty"`
+
+	// AutoCreateTables creates missing tables on the first POST, inferring column types
+	// from the JSON body. Requires create permission on all tables ('*').
+	// Intended for prototyping only - do not enable in production.
+	// Default is false.
+	AutoCreateTables bool `json:"auto_create_tables,omitempty"`
+
+	// JSONKeyCase transforms column names in JSON responses of the table API.
+	// "camel" writes snake_case columns as camelCase keys and maps camelCase keys in
+	// create/update bodies back to snake_case columns. "none" passes names through.
+	// Default is "none".
+	JSONKeyCase string `json:"json_key_case,omitempty"`
+
+	// DecimalAsString writes DECIMAL values in JSON responses as strings
+	// ("12345.67"). When false they are written as JSON numbers with the exact
+	// digits, which clients that parse numbers as floats may round.
+	// Default is true.
+	DecimalAsString *bool `json:"decimal_as_string,omitempty"`
+
+	// ResponseShape selects the JSON envelope of table reads and SELECT query
+	// results, for clients that expect an existing API contract: "result" wraps
+	// the response as {"result": {...}}, "items" renames "data" to "items".
+	// Tables can override it. Default is "default" (unchanged).
+	ResponseShape string `json:"response_shape,omitempty"`
+
+	// ArrowDictionaryThreshold dictionary-encodes string columns of Arrow
+	// responses that have at most this many distinct values in the first record
+	// batch, which shrinks low-cardinality columns such as status or country
+	// codes. Default is 0 (disabled).
+	ArrowDictionaryThreshold int `json:"arrow_dictionary_threshold,omitempty"`
+
+	// ArrowBatchSize is the number of rows per record batch of Arrow responses.
+	// Each batch is flushed to the client as soon as it is written. Default is
+	// 0 (1024 rows).
+	ArrowBatchSize int `json:"arrow_batch_size,omitempty"`
+
+	// ParquetRowGroupSize is the number of rows per row group of Parquet
+	// responses. Rows are streamed one row group at a time, so it also bounds
+	// memory use of large exports. Default is 0 (122880 rows, like DuckDB).
+	ParquetRowGroupSize int `json:"parquet_row_group_size,omitempty"`
+
+	// QueryTagging prefixes SQL executed for API requests with a comment naming the
+	// request ID and role (e.g. /* req=... role=admin */), so DuckDB profiling output
+	// and query logs can be attributed to requests. Default is false.
+	QueryTagging bool `json:"query_tagging,omitempty"`
+
+	// QueryPlanCache keeps this many prepared statements for parameterized raw SQL
+	// (/query and batch), so repeated queries skip parsing and planning. Queries
+	// without params and tagged queries (see QueryTagging) are not cached.
+	// Default is 0 (disabled).
+	QueryPlanCache int `json:"query_plan_cache,omitempty"`
+
+	// QueryCacheTTL caches the results of JSON table reads and their counts for
+	// this long, keyed by the generated SQL and its parameters. Inserts, updates,
+	// and deletes of a table invalidate its cached results; raw SQL writes purge
+	// the whole cache. Raw queries are never cached. Default is 0 (disabled).
+	QueryCacheTTL caddy.Duration `json:"query_cache_ttl,omitempty"`
+
+	// ValidateQueryTables checks that the tables referenced by a /query SELECT
+	// exist before executing it, answering typos with a 400 that suggests the
+	// closest table name. Queries the check cannot parse reliably run unchecked.
+	// Default is false.
+	ValidateQueryTables bool `json:"validate_query_tables,omitempty"`
+
+	// CoalesceReads executes identical concurrent JSON reads (table reads and
+	// /query SELECTs by the same role) once and shares the buffered response
+	// among the waiting requests. Default is false.
+	CoalesceReads bool `json:"coalesce_reads,omitempty"`
+
+	// MaxStreamsPerKey caps the number of concurrent long-lived streams (SSE query
+	// streams and change long-polls) a single API key may hold open. Requests over
+	// the limit are rejected with 429. Default is 0 (unlimited).
+	MaxStreamsPerKey int `json:"max_streams_per_key,omitempty"`
+
+	// RateLimit limits the request rate of each API key; requests over the
+	// limit are rejected with 429 and a Retry-After header. Default is unlimited.
+	RateLimit *RateLimitConfig `json:"rate_limit,omitempty"`
+
+	// ServerTiming adds a Server-Timing header to responses, breaking the request
+	// down into authentication (auth), query execution (db), serialization (ser)
+	// and total time, so it shows up in browser devtools. Serialization happens
+	// after the headers are sent, so the complete breakdown is also sent as a
+	// trailer. Default is false.
+	ServerTiming bool `json:"server_timing,omitempty"`
+
+	// MetricsPublic serves GET /metrics (connection pool and query metrics in
+	// the Prometheus text format) without an API key, for scrapers. Otherwise
+	// it requires a role with full access to all tables. Default is false.
+	MetricsPublic bool `json:"metrics_public,omitempty"`
+
+	// DownloadTokenTTL enables single-use download tokens: POST
+	// /api/{table}/download-token mints a token that GET /download/{token}
+	// redeems once, without an API key, to export the table read. It is the
+	// default and maximum token lifetime. Default is 0 (disabled).
+	DownloadTokenTTL caddy.Duration `json:"download_token_ttl,omitempty"`
+
+	// MaxColumns caps the number of columns accepted in a create or update body.
+	// Larger bodies are rejected with 400 before any per-column work is done.
+	// Default is 1000.
+	MaxColumns int `json:"max_columns,omitempty"`
+
+	// Pagination is the default pagination policy for tables without their own.
+	// The "cursor" style rejects page/limit requests that would skip more than
+	// max_offset rows, steering clients of large tables to keyset pagination.
+	// Default is unrestricted page/limit pagination.
+	Pagination *handlers.PaginationPolicy `json:"pagination,omitempty"`
+
+	// ErrorDetail controls how much of a database error is included in error
+	// responses: "full" includes the DuckDB message (for development), "safe"
+	// replaces it with a generic message and the request ID, and "minimal" only
+	// returns the status text and request ID. The full error is always logged
+	// with the request ID. Default is "full".
+	ErrorDetail string `json:"error_detail,omitempty"`
+
+	// TrailingSlash controls requests whose path ends in a slash, such as
+	// /duckdb/api/users/: "strip" serves them like the canonical path without
+	// the slash, and "redirect" sends a 308 redirect to it. Default is "strip".
+	TrailingSlash string `json:"trailing_slash,omitempty"`
+
+	// DebugSQL lets the listed roles add ?debug_sql=true to read, update, delete,
+	// and query requests to get the generated SQL and bound parameters back in a
+	// debug object. Values of the configured redact columns are masked. Default
+	// is disabled; do not grant it to production roles.
+	DebugSQL *handlers.DebugSQLConfig `json:"debug_sql,omitempty"`
+
+	// APIKeyHeader is the request header carrying the API key. With
+	// "Authorization" keys are sent as bearer tokens ("Authorization: Bearer
+	// <key>"), for gateways that only forward that header. Default is "X-API-Key".
+	APIKeyHeader string `json:"api_key_header,omitempty"`
+
+	// RequireTLS rejects authenticated requests that did not arrive over HTTPS
+	// with 403, so API keys are never accepted over plain HTTP. X-Forwarded-Proto
+	// is honored for requests from the server's trusted_proxies. Health and OpenAPI
+	// endpoints are not affected. Default is false (Caddy usually terminates TLS).
+	RequireTLS bool `json:"require_tls,omitempty"`
+
+	// Maintenance schedules a background CHECKPOINT of the main database (and
+	// optionally ANALYZE), which flushes the WAL and reclaims space from deleted
+	// rows. Skipped for in-memory and read-only databases. Default is disabled.
+	Maintenance *MaintenanceConfig `json:"maintenance,omitempty"`
+
+	// RequestLog enables an access log of module requests (method, path, table,
+	// role, API key namespace, status, duration, request ID), optionally sampled.
+	// Requests ending in a 4xx or 5xx status are always logged. Default is disabled.
+	RequestLog *RequestLogConfig `json:"request_log,omitempty"`
+
+	// IndexAdvisor counts the columns table reads filter and sort on, and
+	// recommends (or, with auto_create, creates) ART indexes for frequently
+	// used columns. Recommendations are listed at GET /admin/indexes. Default
+	// is disabled.
+	IndexAdvisor *handlers.IndexAdvisorConfig `json:"index_advisor,omitempty"`
+
+	// HealthSources maps a source name to a probe query that is run by the deep
+	// health check (GET /health?deep=true), e.g. a LIMIT 1 read from a remote
+	// parquet file. Attached databases are always checked in deep mode.
+	HealthSources map[string]string `json:"health_sources,omitempty"`
+
+	// NamespaceTables restricts the API keys of a namespace to the listed tables
+	// of the table API; keys of a restricted namespace cannot use /query or the
+	// admin endpoints. Namespaces without an entry are unrestricted.
+	NamespaceTables map[string][]string `json:"namespace_tables,omitempty"`
+
+	// ResponseHeaders adds static headers to responses, keyed by the path under
+	// the route prefix they apply to: "/" for every endpoint, or e.g. "/query"
+	// or "/api/orders" for an endpoint and the paths below it. Headers of a more
+	// specific path override those of its parents.
+	ResponseHeaders map[string]map[string]string `json:"response_headers,omitempty"`
+
+	// RequiredHeaders maps the names of headers every request must carry (e.g.
+	// X-Client-ID) to an optional regular expression the whole value must match;
+	// an empty pattern accepts any non-empty value. Requests without them get
+	// 400 before authentication. The health endpoint is not affected.
+	RequiredHeaders map[string]string `json:"required_headers,omitempty"`
+
+	// QueryLabels restricts the labels clients may send in the X-Query-Label
+	// header to attribute usage to a team or application. Labels are recorded
+	// in query tags, the request log, and metrics. Requests with a label not
+	// in the list get 400. Default is empty (any valid label is accepted).
+	QueryLabels []string `json:"query_labels,omitempty"`
+
+	// CORSOrigins are the browser origins (e.g. "https://app.example.com", or
+	// "*" for any) allowed to call the API. Preflight OPTIONS requests from them
+	// are answered, and their origin is echoed in Access-Control-Allow-Origin.
+	// Preflights from other origins get 403. Default is empty (CORS disabled).
+	CORSOrigins []string `json:"cors_origins,omitempty"`
+
+	// CORSMethods are the methods allowed in preflight responses. Default is
+	// GET, POST, PUT, PATCH, and DELETE.
+	CORSMethods []string `json:"cors_methods,omitempty"`
+
+	// SlowQueryThreshold logs a "Slow query" warning for requests whose query
+	// execution time (the db phase of Server-Timing) exceeds it. Default is 0
+	// (disabled).
+	SlowQueryThreshold caddy.Duration `json:"slow_query_threshold,omitempty"`
+
+	// SlowQueryThresholds overrides SlowQueryThreshold for requests to the
+	// table API of the given tables, e.g. 50ms for a small lookup table and 5s
+	// for a large analytical one. A threshold of 0 disables the warning for the
+	// table. Requests to other tables and to /query use the global threshold.
+	SlowQueryThresholds map[string]caddy.Duration `json:"slow_query_thresholds,omitempty"`
+
+	// Tables holds per-table configuration keyed by table name
+	// (e.g., soft delete column).
+	Tables map[string]*handlers.TableConfig `json:"tables,omitempty"`
+
+	logger          *zap.Logger
+	dbMgr           *database.Manager
+	authorizer      *auth.Authorizer
+	authMw          *auth.Middleware
+	crudHandler     *handlers.CRUDHandler
+	queryHandler    *handlers.QueryHandler
+	openAPIHandler  *handlers.OpenAPIHandler
+	catalogHandler  *handlers.CatalogHandler
+	downloads       *handlers.DownloadHandler
+	maintenance     *database.Maintenance
+	indexAdvisor    *handlers.IndexAdvisor
+	headerScopes    []string // paths of ResponseHeaders, least specific first
+	requiredHeaders []requiredHeader
+	routePrefix     string // set from DUCKDB_ROUTE_PREFIX env var, defaults to /duckdb
+
+	// maintenanceMode is 1 while writes are refused (see SetMaintenanceMode).
+	// An int32 rather than atomic.Bool, which must not be copied, since
+	// CaddyModule takes DuckDB by value.
+	maintenanceMode int32
 }
 
 // CaddyModule returns the Caddy module information.
@@ -102,6 +355,12 @@ func (d *DuckDB) Provision(ctx caddy.Context) error {
 	}
 	// Remove trailing slash if present
 	d.routePrefix = strings.TrimSuffix(d.routePrefix, "/")
+	d.headerScopes = d.responseHeaderScopes()
+	var err error
+	d.requiredHeaders, err = compileRequiredHeaders(d.RequiredHeaders)
+	if err != nil {
+		return err
+	}
 
 	if d.QueryTimeout == 0 {
 		d.QueryTimeout = caddy.Duration(10_000_000_000) // 10 seconds in nanoseconds
@@ -118,24 +377,60 @@ func (d *DuckDB) Provision(ctx caddy.Context) error {
 	if d.AccessMode == "" {
 		d.AccessMode = "read_write"
 	}
+	if d.MaxColumns == 0 {
+		d.MaxColumns = 1000
+	}
+	if d.ErrorDetail == "" {
+		d.ErrorDetail = handlers.ErrorDetailFull
+	}
+	if d.TrailingSlash == "" {
+		d.TrailingSlash = TrailingSlashStrip
+	}
+	if d.CSVCharset == "" {
+		d.CSVCharset = formats.DefaultCharset
+	}
+	if d.JSONKeyCase == "" {
+		d.JSONKeyCase = formats.KeyCaseNone
+	}
+	if d.ResponseShape == "" {
+		d.ResponseShape = formats.ShapeDefault
+	}
+	if d.OnRowLimit == "" {
+		d.OnRowLimit = handlers.RowLimitTruncate
+	}
 
 	// Validate AuthDatabasePath
 	if d.AuthDatabasePath == "" {
 		return fmt.Errorf("auth_database_path is required")
 	}
 
+	// Compile per-table validation rules
+	for name, cfg := range d.Tables {
+		if cfg == nil {
+			continue
+		}
+		if err := cfg.Provision(); err != nil {
+			return fmt.Errorf("invalid config for table '%s': %v", name, err)
+		}
+	}
+
 	// Initialize database manager
-	var err error
 	d.dbMgr, err = database.NewManager(database.Config{
-		MainDBPath:        d.DatabasePath,
-		AuthDBPath:        d.AuthDatabasePath,
-		Threads:           d.Threads,
-		AccessMode:        d.AccessMode,
-		MemoryLimit:       d.MemoryLimit,
-		EnableObjectCache: d.EnableObjectCache,
-		TempDirectory:     d.TempDirectory,
-		QueryTimeout:      time.Duration(d.QueryTimeout),
-		Logger:            d.logger,
+		MainDBPath:         d.DatabasePath,
+		AuthDBPath:         d.AuthDatabasePath,
+		Threads:            d.Threads,
+		AccessMode:         d.AccessMode,
+		MemoryLimit:        d.MemoryLimit,
+		EnableObjectCache:  d.EnableObjectCache,
+		TempDirectory:      d.TempDirectory,
+		QueryTimeout:       time.Duration(d.QueryTimeout),
+		AcquireTimeout:     time.Duration(d.ConnectionAcquireTimeout),
+		QueryTagging:       d.QueryTagging,
+		QueryPlanCacheSize: d.QueryPlanCache,
+		QueryCacheTTL:      time.Duration(d.QueryCacheTTL),
+		ReadPoolSize:       d.ReadPoolSize,
+		TablePools:         d.TablePools,
+		Logger:             d.logger,
 	})
 	if err != nil {
 		return fmt.Errorf("failed to initialize database manager: %v", err)
@@ -144,29 +439,128 @@ func (d *DuckDB) Provision(ctx caddy.Context) error {
 	// Initialize authorizer
 	d.authorizer = auth.NewAuthorizer(d.dbMgr.AuthDB())
 	d.authMw = auth.NewMiddleware(d.authorizer)
+	d.authMw.SetAPIKeyHeader(d.APIKeyHeader)
+	d.authMw.SetRateLimiter(d.RateLimit.rateLimiter())
 
 	// Initialize handlers
 	d.crudHandler = handlers.NewCRUDHandler(d.dbMgr, d.authorizer, d.MaxRowsPerPage, d.AbsoluteMaxRows, d.logger)
+	d.crudHandler.SetTableConfigs(d.Tables)
+	d.crudHandler.SetCSVCharset(d.CSVCharset, d.RejectUnsupportedCharset)
+	d.crudHandler.SetAutoCreateTables(d.AutoCreateTables)
+	d.crudHandler.SetJSONKeyCase(d.JSONKeyCase)
+	d.crudHandler.SetDecimalAsString(d.decimalAsString())
+	d.crudHandler.SetMaxColumns(d.MaxColumns)
+	d.crudHandler.SetPaginationPolicy(d.Pagination)
+	d.crudHandler.SetErrorDetail(d.ErrorDetail)
+	d.crudHandler.SetDebugSQL(d.DebugSQL)
+	d.crudHandler.SetResponseShape(d.ResponseShape)
+	d.crudHandler.SetRowLimitPolicy(d.OnRowLimit)
+	d.crudHandler.SetArrowDictionaryThreshold(d.ArrowDictionaryThreshold)
+	d.crudHandler.SetArrowBatchSize(d.ArrowBatchSize)
+	d.crudHandler.SetParquetRowGroupSize(d.ParquetRowGroupSize)
+	d.crudHandler.SetCoalesceReads(d.CoalesceReads)
+	d.crudHandler.SetMaintenanceMode(d.MaintenanceMode)
+	streams := auth.NewStreamLimiter(d.MaxStreamsPerKey)
+	d.crudHandler.SetStreamLimiter(streams)
 	d.queryHandler = handlers.NewQueryHandler(d.dbMgr, d.authorizer, d.logger)
+	d.queryHandler.SetAbsoluteMaxRows(d.AbsoluteMaxRows)
+	d.queryHandler.SetCSVCharset(d.CSVCharset, d.RejectUnsupportedCharset)
+	d.queryHandler.SetStreamLimiter(streams)
+	d.queryHandler.SetErrorDetail(d.ErrorDetail)
+	d.queryHandler.SetDebugSQL(d.DebugSQL)
+	d.queryHandler.SetResponseShape(d.ResponseShape)
+	d.queryHandler.SetDecimalAsString(d.decimalAsString())
+	d.queryHandler.SetArrowDictionaryThreshold(d.ArrowDictionaryThreshold)
+	d.queryHandler.SetArrowBatchSize(d.ArrowBatchSize)
+	d.queryHandler.SetParquetRowGroupSize(d.ParquetRowGroupSize)
+	d.queryHandler.SetValidateTables(d.ValidateQueryTables)
+	d.queryHandler.SetCoalesceReads(d.CoalesceReads)
+	d.queryHandler.SetMaintenanceMode(d.MaintenanceMode)
+	d.catalogHandler = handlers.NewCatalogHandler(d.dbMgr, d.authorizer, d.logger)
+	d.catalogHandler.SetErrorDetail(d.ErrorDetail)
 	d.openAPIHandler = handlers.NewOpenAPIHandler()
+	d.openAPIHandler.SetAPIKeyHeader(d.APIKeyHeader)
+	d.openAPIHandler.SetTableSource(d.dbMgr, d.authorizer)
+	d.openAPIHandler.SetDecimalAsString(d.decimalAsString())
+	if d.DownloadTokenTTL > 0 {
+		if err := d.authorizer.InitDownloadTokens(); err != nil {
+			return err
+		}
+		d.crudHandler.SetDownloadTokenTTL(time.Duration(d.DownloadTokenTTL))
+		d.downloads = handlers.NewDownloadHandler(d.authorizer, d.crudHandler, d.logger)
+	}
+	if d.IndexAdvisor != nil && d.IndexAdvisor.Enabled {
+		d.indexAdvisor = handlers.NewIndexAdvisor(d.dbMgr, *d.IndexAdvisor, d.logger)
+		d.crudHandler.SetIndexAdvisor(d.indexAdvisor)
+	}
+	if d.Maintenance != nil {
+		d.maintenance = d.dbMgr.StartMaintenance(time.Duration(d.Maintenance.Interval), d.Maintenance.Analyze)
+	}
 
 	d.logger.Info("DuckDB module provisioned",
 		zap.String("route_prefix", d.routePrefix),
 		zap.String("main_db", d.DatabasePath),
 		zap.String("auth_db", d.AuthDatabasePath),
 		zap.Duration("query_timeout", time.Duration(d.QueryTimeout)),
+		zap.Duration("connection_acquire_timeout", time.Duration(d.ConnectionAcquireTimeout)),
 		zap.Int("max_rows_per_page", d.MaxRowsPerPage),
 		zap.Int("absolute_max_rows", d.AbsoluteMaxRows),
+		zap.String("on_row_limit", d.OnRowLimit),
 		zap.Int("threads", d.Threads),
 		zap.String("access_mode", d.AccessMode),
 		zap.String("memory_limit", d.MemoryLimit),
 		zap.Bool("enable_object_cache", d.EnableObjectCache),
 		zap.String("temp_directory", d.TempDirectory),
+		zap.Int("read_pool_size", d.ReadPoolSize),
+		zap.Int("table_pools", len(d.TablePools)),
+		zap.String("csv_charset", d.CSVCharset),
+		zap.String("json_key_case", d.JSONKeyCase),
+		zap.Bool("decimal_as_string", d.decimalAsString()),
+		zap.String("response_shape", d.ResponseShape),
+		zap.Int("arrow_dictionary_threshold", d.ArrowDictionaryThreshold),
+		zap.Int("arrow_batch_size", d.ArrowBatchSize),
+		zap.Int("parquet_row_group_size", d.ParquetRowGroupSize),
+		zap.Bool("query_tagging", d.QueryTagging),
+		zap.Int("query_plan_cache", d.QueryPlanCache),
+		zap.Duration("query_cache_ttl", time.Duration(d.QueryCacheTTL)),
+		zap.Bool("validate_query_tables", d.ValidateQueryTables),
+		zap.Bool("coalesce_reads", d.CoalesceReads),
+		zap.Int("max_streams_per_key", d.MaxStreamsPerKey),
+		zap.Bool("rate_limit", d.RateLimit != nil),
+		zap.Int("max_columns", d.MaxColumns),
+		zap.Bool("server_timing", d.ServerTiming),
+		zap.Bool("metrics_public", d.MetricsPublic),
+		zap.Duration("download_token_ttl", time.Duration(d.DownloadTokenTTL)),
+		zap.String("error_detail", d.ErrorDetail),
+		zap.String("trailing_slash", d.TrailingSlash),
+		zap.String("api_key_header", d.authMw.APIKeyHeader()),
+		zap.Bool("require_tls", d.RequireTLS),
+		zap.Bool("request_log", d.RequestLog != nil && d.RequestLog.Enabled),
+		zap.Bool("maintenance", d.maintenance != nil),
+		zap.Bool("index_advisor", d.indexAdvisor != nil),
+		zap.Int("configured_tables", len(d.Tables)),
+		zap.Int("health_sources", len(d.HealthSources)),
+		zap.Int("restricted_namespaces", len(d.NamespaceTables)),
+		zap.Strings("response_header_paths", d.headerScopes),
+		zap.Int("required_headers", len(d.requiredHeaders)),
+		zap.Strings("query_labels", d.QueryLabels),
+		zap.Strings("cors_origins", d.CORSOrigins),
+		zap.Duration("slow_query_threshold", time.Duration(d.SlowQueryThreshold)),
+		zap.Int("slow_query_tables", len(d.SlowQueryThresholds)),
 	)
+	if d.AutoCreateTables {
+		d.logger.Warn("auto_create_tables is enabled: POST to a missing table creates it (not recommended for production)")
+	}
 
 	return nil
 }
 
+// decimalAsString reports whether DECIMAL values are written to JSON as
+// strings, which is the default.
+func (d *DuckDB) decimalAsString() bool {
+	return d.DecimalAsString == nil || *d.DecimalAsString
+}
+
 // Validate ensures the module configuration is valid.
 func (d *DuckDB) Validate() error {
 	if d.AccessMode != "read_only" && d.AccessMode != "read_write" {
@@ -178,9 +572,160 @@ func (d *DuckDB) Validate() error {
 	if d.AbsoluteMaxRows < 0 {
 		return fmt.Errorf("absolute_max_rows must be >= 0 (0 disables the limit)")
 	}
+	if !handlers.IsValidRowLimitPolicy(d.OnRowLimit) {
+		return fmt.Errorf("invalid on_row_limit: %s (must be 'truncate' or 'error')", d.OnRowLimit)
+	}
 	if d.Threads <= 0 {
 		return fmt.Errorf("threads must be greater than 0")
 	}
+	if d.QueryPlanCache < 0 {
+		return fmt.Errorf("query_plan_cache must be >= 0 (0 disables the cache)")
+	}
+	if d.QueryCacheTTL < 0 {
+		return fmt.Errorf("query_cache_ttl must be >= 0 (0 disables the cache)")
+	}
+	if d.ReadPoolSize < 0 {
+		return fmt.Errorf("read_pool_size must be >= 0 (0 disables the read pool)")
+	}
+	if d.ReadPoolSize > 0 && d.AccessMode == "read_only" {
+		return fmt.Errorf("read_pool_size requires access_mode read_write (a read-only database serves every request from one pool)")
+	}
+	if len(d.TablePools) > 0 && d.AccessMode == "read_only" {
+		return fmt.Errorf("table_pool requires access_mode read_write (table pools only serve writes)")
+	}
+	if err := database.ValidateTablePools(d.TablePools); err != nil {
+		return err
+	}
+	for _, p := range d.TablePools {
+		for _, table := range p.Tables {
+			if err := handlers.SanitizeQualifiedTableName(table); err != nil {
+				return fmt.Errorf("invalid table '%s' in table pool '%s': %v", table, p.Name, err)
+			}
+		}
+	}
+	if d.RequestLog != nil {
+		if rate := d.RequestLog.rate(); rate < 0 || rate > 1 {
+			return fmt.Errorf("request_log sample_rate must be between 0 and 1")
+		}
+	}
+	if d.IndexAdvisor != nil {
+		if err := d.IndexAdvisor.Validate(); err != nil {
+			return err
+		}
+		if d.IndexAdvisor.AutoCreate && d.AccessMode == "read_only" {
+			return fmt.Errorf("index_advisor auto_create requires access_mode read_write")
+		}
+	}
+	if d.Maintenance != nil && d.Maintenance.Interval < 0 {
+		return fmt.Errorf("maintenance interval must be >= 0 (0 disables maintenance)")
+	}
+	if d.DownloadTokenTTL < 0 {
+		return fmt.Errorf("download_token_ttl must be >= 0 (0 disables download tokens)")
+	}
+	if d.MaxColumns < 0 {
+		return fmt.Errorf("max_columns must be >= 0")
+	}
+	if d.ArrowDictionaryThreshold < 0 {
+		return fmt.Errorf("arrow_dictionary_threshold must be >= 0 (0 disables dictionary encoding)")
+	}
+	if d.ArrowBatchSize < 0 {
+		return fmt.Errorf("arrow_batch_size must be >= 0 (0 uses the default)")
+	}
+	if d.ParquetRowGroupSize < 0 {
+		return fmt.Errorf("parquet_row_group_size must be >= 0 (0 uses the default)")
+	}
+	if d.Pagination != nil {
+		if err := d.Pagination.Validate(); err != nil {
+			return err
+		}
+	}
+	if d.MaxStreamsPerKey < 0 {
+		return fmt.Errorf("max_streams_per_key must be >= 0 (0 disables the limit)")
+	}
+	if d.RateLimit != nil {
+		if err := d.RateLimit.validate(); err != nil {
+			return err
+		}
+	}
+	if d.CSVCharset != "" {
+		if _, ok := formats.NormalizeCharset(d.CSVCharset); !ok {
+			return fmt.Errorf("unsupported csv_charset: %s (must be utf-8, iso-8859-1, iso-8859-15, or windows-1252)", d.CSVCharset)
+		}
+	}
+	if !handlers.IsValidErrorDetail(d.ErrorDetail) {
+		return fmt.Errorf("invalid error_detail: %s (must be 'full', 'safe', or 'minimal')", d.ErrorDetail)
+	}
+	switch d.TrailingSlash {
+	case "", TrailingSlashStrip, TrailingSlashRedirect:
+	default:
+		return fmt.Errorf("invalid trailing_slash: %s (must be 'strip' or 'redirect')", d.TrailingSlash)
+	}
+	if !formats.IsValidKeyCase(d.JSONKeyCase) {
+		return fmt.Errorf("invalid json_key_case: %s (must be 'none' or 'camel')", d.JSONKeyCase)
+	}
+	if !formats.IsValidShape(d.ResponseShape) {
+		return fmt.Errorf("invalid response_shape: %s (must be 'default', 'result' or 'items')", d.ResponseShape)
+	}
+	for name, query := range d.HealthSources {
+		if strings.TrimSpace(query) == "" {
+			return fmt.Errorf("health_source '%s' must have a query", name)
+		}
+	}
+	for namespace, tables := range d.NamespaceTables {
+		if err := auth.ValidateNamespace(namespace); err != nil {
+			return fmt.Errorf("invalid namespace_tables: %v", err)
+		}
+		if len(tables) == 0 {
+			return fmt.Errorf("namespace_tables for '%s' must list at least one table", namespace)
+		}
+		for _, table := range tables {
+			if err := handlers.SanitizeQualifiedTableName(table); err != nil {
+				return fmt.Errorf("invalid table '%s' in namespace_tables for '%s': %v", table, namespace, err)
+			}
+		}
+	}
+	if d.APIKeyHeader != "" && !isHeaderName(d.APIKeyHeader) {
+		return fmt.Errorf("invalid api_key_header '%s'", d.APIKeyHeader)
+	}
+	if err := validateResponseHeaders(d.ResponseHeaders); err != nil {
+		return err
+	}
+	if _, err := compileRequiredHeaders(d.RequiredHeaders); err != nil {
+		return err
+	}
+	for _, label := range d.QueryLabels {
+		if err := auth.ValidateQueryLabel(label); err != nil {
+			return fmt.Errorf("invalid query_labels: %v", err)
+		}
+	}
+	if err := validateCORS(d.CORSOrigins, d.CORSMethods); err != nil {
+		return err
+	}
+	if d.ConnectionAcquireTimeout < 0 {
+		return fmt.Errorf("connection_acquire_timeout must not be negative")
+	}
+	if d.SlowQueryThreshold < 0 {
+		return fmt.Errorf("slow_query_threshold must not be negative")
+	}
+	for table, threshold := range d.SlowQueryThresholds {
+		if err := handlers.SanitizeQualifiedTableName(table); err != nil {
+			return fmt.Errorf("invalid table '%s' in slow_query_threshold: %v", table, err)
+		}
+		if threshold < 0 {
+			return fmt.Errorf("slow_query_threshold for '%s' must not be negative", table)
+		}
+	}
+	for name, cfg := range d.Tables {
+		if err := handlers.SanitizeQualifiedTableName(name); err != nil {
+			return fmt.Errorf("invalid table '%s': %v", name, err)
+		}
+		if cfg == nil {
+			continue
+		}
+		if err := cfg.Validate(); err != nil {
+			return fmt.Errorf("invalid config for table '%s': %v", name, err)
+		}
+	}
 	return nil
 }
 
@@ -200,42 +745,135 @@ func (d *DuckDB) ServeHTTP(w http.ResponseWriter, r *http.Request, next caddyhtt
 	ctx := auth.SetRequestID(r.Context(), requestID)
 	r = r.WithContext(ctx)
 	w.Header().Set("X-Request-ID", requestID)
+	d.setResponseHeaders(w, r)
+
+	// Browser clients: preflights are answered before any other check, and the
+	// CORS headers are set before any response is written, so browsers can
+	// read redirects and error responses as well
+	if d.handleCORS(w, r) {
+		return nil
+	}
 
-	// Health check endpoint (no authentication required)
-	if r.URL.Path == d.routePrefix+"/health" {
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusOK)
-		w.Write([]byte(`{"status":"ok"}`))
+	// Endpoints are served at their canonical path, without a trailing slash
+	if r = d.normalizeTrailingSlash(w, r); r == nil {
 		return nil
 	}
 
-	// OpenAPI specification endpoint (no authentication required)
-	if r.URL.Path == d.routePrefix+"/openapi.json" {
+	// Sampled request log, written once the request completes
+	if d.RequestLog != nil && d.RequestLog.Enabled {
+		rec := &statusRecorder{ResponseWriter: w}
+		w = rec
+		start := time.Now()
+		defer func() { d.logRequest(rec, r, start) }()
+	}
+
+	// Server-Timing breakdown of the request phases, also used to flag slow queries
+	if d.ServerTiming || d.slowQueryLogEnabled() {
+		timing := handlers.NewServerTiming()
+		r = r.WithContext(handlers.WithServerTiming(r.Context(), timing))
+		if d.slowQueryLogEnabled() {
+			defer func() { d.logSlowQuery(r, timing) }()
+		}
+		if d.ServerTiming {
+			tw := handlers.NewServerTimingWriter(w, timing)
+			w = tw
+			defer tw.Finish()
+		}
+	}
+
+	// Health check endpoint (no authentication required, except for deep checks,
+	// which probe every attached database and health source)
+	health := r.URL.Path == d.routePrefix+"/health"
+	if health && !isDeepHealthCheck(r) {
+		d.serveHealth(w, r)
+		return nil
+	}
+
+	// Operator-mandated headers are checked before anything else is served
+	if message := d.checkRequiredHeaders(r); message != "" {
+		writeModuleError(w, r, message, http.StatusBadRequest)
+		return nil
+	}
+
+	// Client-supplied label for usage attribution
+	if label, message := d.checkQueryLabel(r); message != "" {
+		writeModuleError(w, r, message, http.StatusBadRequest)
+		return nil
+	} else if label != "" {
+		r = r.WithContext(auth.SetQueryLabel(r.Context(), label))
+	}
+
+	// OpenAPI specification endpoint (no authentication required, except to list tables)
+	openAPI := r.URL.Path == d.routePrefix+"/openapi.json" || r.URL.Path == d.routePrefix+"/openapi.yaml"
+	if openAPI && !handlers.ParseOpenAPITables(r) {
 		d.openAPIHandler.ServeHTTP(w, r)
 		return nil
 	}
 
+	// Metrics for scrapers (no authentication required, if configured)
+	metrics := r.URL.Path == d.routePrefix+"/metrics"
+	if metrics && d.MetricsPublic {
+		handlers.ServeMetrics(w, r, d.dbMgr.Metrics())
+		return nil
+	}
+
+	// Refuse plain HTTP before an API key is looked at
+	if d.RequireTLS && !isSecureRequest(r) {
+		writeModuleError(w, r, "HTTPS is required; API keys are not accepted over plain HTTP", http.StatusForbidden)
+		return nil
+	}
+
+	// Single-use download links authorize themselves via the token
+	if d.downloads != nil && strings.HasPrefix(r.URL.Path, d.routePrefix+"/download/") {
+		d.downloads.ServeHTTP(w, r)
+		return nil
+	}
+
 	// Authenticate all other requests
 	authenticated := false
-	apiKey := r.Header.Get("X-API-Key")
+	apiKey := d.authMw.ExtractAPIKey(r)
+	message := fmt.Sprintf("Missing or invalid %s header", d.authMw.APIKeyHeader())
 	if apiKey != "" {
+		stopAuth := handlers.ServerTimingFromContext(r.Context()).Start(handlers.TimingAuth)
 		key, err := d.authorizer.AuthenticateAPIKey(apiKey)
+		stopAuth()
 		if err == nil && key != nil {
 			// Add to context
 			r = r.WithContext(auth.SetContextValues(r.Context(), key, key.RoleName))
 			authenticated = true
+		} else if errors.Is(err, auth.ErrExpiredAPIKey) || errors.Is(err, auth.ErrDisabledAPIKey) {
+			message = auth.AuthenticationErrorMessage(err)
 		}
 	}
 
 	if !authenticated {
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusUnauthorized)
-		w.Write([]byte(`{"error":"Unauthorized","message":"Missing or invalid X-API-Key header","code":401}`))
+		writeModuleError(w, r, message, http.StatusUnauthorized)
+		return nil
+	}
+
+	// Keys of a restricted namespace only reach their namespace's tables
+	if !d.namespaceAllows(r) {
+		writeModuleError(w, r, "Forbidden: not available to this API key namespace", http.StatusForbidden)
+		return nil
+	}
+
+	// Count labelled requests once they are authenticated
+	if label := auth.GetQueryLabelFromContext(r.Context()); label != "" {
+		d.dbMgr.CountQueryLabel(label)
+	}
+
+	// Per-key request rate limit
+	if !d.authMw.CheckRateLimit(w, r) {
+		writeModuleError(w, r, "Rate limit exceeded; retry after the number of seconds in Retry-After", http.StatusTooManyRequests)
 		return nil
 	}
 
 	// Route based on path
-	if strings.HasPrefix(r.URL.Path, d.routePrefix+"/query") {
+	if openAPI {
+		// OpenAPI specification with the role's tables
+		d.openAPIHandler.ServeHTTP(w, r)
+		return nil
+	} else if strings.HasPrefix(r.URL.Path, d.routePrefix+"/query") {
 		// Raw SQL query endpoint
 		d.queryHandler.ServeHTTP(w, r)
 		return nil
@@ -243,23 +881,144 @@ func (d *DuckDB) ServeHTTP(w http.ResponseWriter, r *http.Request, next caddyhtt
 		// CRUD operations endpoint
 		d.crudHandler.ServeHTTP(w, r)
 		return nil
+	} else if r.URL.Path == d.routePrefix+"/transaction" {
+		// Multi-table write transactions
+		d.crudHandler.ServeTransaction(w, r)
+		return nil
+	} else if r.URL.Path == d.routePrefix+"/schema" || strings.HasPrefix(r.URL.Path, d.routePrefix+"/schema/") {
+		// Table and column discovery
+		d.catalogHandler.ServeHTTP(w, r)
+		return nil
+	} else if r.URL.Path == d.routePrefix+"/admin/maintenance" {
+		// Runtime maintenance (read-only) mode
+		d.serveMaintenanceMode(w, r)
+		return nil
+	} else if r.URL.Path == d.routePrefix+"/admin/indexes" {
+		// Index advisor recommendations
+		d.serveIndexRecommendations(w, r)
+		return nil
+	} else if health {
+		// Deep health check
+		d.serveHealth(w, r)
+		return nil
+	} else if metrics {
+		// Connection pool and query metrics
+		if d.authorizeAdmin(w, r) {
+			handlers.ServeMetrics(w, r, d.dbMgr.Metrics())
+		}
+		return nil
 	}
 
 	// Unknown endpoint
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusNotFound)
-	w.Write([]byte(`{"error":"Not Found","message":"Unknown DuckDB endpoint","code":404}`))
+	writeModuleError(w, r, "Unknown DuckDB endpoint", http.StatusNotFound)
 	return nil
 }
 
 // Cleanup performs cleanup when the module is unloaded.
 func (d *DuckDB) Cleanup() error {
+	// Let a running checkpoint finish before the database is closed
+	d.maintenance.Stop()
 	if d.dbMgr != nil {
 		return d.dbMgr.Close()
 	}
 	return nil
 }
 
+// serveHealth handles GET /health. It runs SELECT 1 against the main and auth
+// databases and reports the connection pools, returning 503 with status
+// "degraded" if either database does not answer. With ?deep=true it also
+// verifies every attached database and configured health source; deep checks
+// are only served to authenticated requests.
+func (d *DuckDB) serveHealth(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	requestID := auth.GetRequestIDFromContext(r.Context())
+	mainErr := d.dbMgr.PingMain(r.Context())
+	if mainErr != nil {
+		d.logger.Warn("Main database unreachable", zap.Error(mainErr), zap.String("request_id", requestID))
+	}
+	authErr := d.dbMgr.PingAuth(r.Context())
+	if authErr != nil {
+		d.logger.Warn("Auth database unreachable", zap.Error(authErr), zap.String("request_id", requestID))
+	}
+
+	status := "ok"
+	code := http.StatusOK
+	if mainErr != nil || authErr != nil {
+		status = "degraded"
+		code = http.StatusServiceUnavailable
+	}
+	result := map[string]interface{}{
+		"main_db": mainErr == nil,
+		"auth_db": authErr == nil,
+		"pools":   d.dbMgr.PoolHealth(),
+	}
+
+	if !isDeepHealthCheck(r) {
+		result["status"] = status
+		w.WriteHeader(code)
+		json.NewEncoder(w).Encode(result)
+		return
+	}
+
+	sources, err := d.dbMgr.CheckAttachedDatabases()
+	if err != nil {
+		d.logger.Error("Deep health check failed", zap.Error(err), zap.String("request_id", requestID))
+		result["status"] = "degraded"
+		result["message"] = "Failed to list attached databases"
+		if d.fullErrorDetail() {
+			result["message"] = "Failed to list attached databases: " + err.Error()
+		}
+		result["sources"] = []database.SourceHealth{}
+		w.WriteHeader(http.StatusServiceUnavailable)
+		json.NewEncoder(w).Encode(result)
+		return
+	}
+
+	names := make([]string, 0, len(d.HealthSources))
+	for name := range d.HealthSources {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		sources = append(sources, d.dbMgr.CheckSource(name, d.HealthSources[name]))
+	}
+
+	for i, source := range sources {
+		if !source.Healthy {
+			status = "degraded"
+			code = http.StatusServiceUnavailable
+			d.logger.Warn("Health source unreachable",
+				zap.String("source", source.Name),
+				zap.String("type", source.Type),
+				zap.String("error", source.Error),
+				zap.String("request_id", requestID),
+			)
+			// Database errors are only shown at full error detail, as elsewhere
+			if !d.fullErrorDetail() {
+				sources[i].Error = "unreachable"
+			}
+		}
+	}
+
+	result["status"] = status
+	result["sources"] = sources
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(result)
+}
+
+// isDeepHealthCheck reports whether a health check request asks for ?deep=true.
+func isDeepHealthCheck(r *http.Request) bool {
+	deep := strings.ToLower(r.URL.Query().Get("deep"))
+	return deep == "true" || deep == "1"
+}
+
+// fullErrorDetail reports whether database errors are included in responses
+// (see ErrorDetail).
+func (d *DuckDB) fullErrorDetail() bool {
+	return d.ErrorDetail == "" || d.ErrorDetail == handlers.ErrorDetailFull
+}
+
 // UnmarshalCaddyfile implements caddyfile.Unmarshaler.
 func (d *DuckDB) UnmarshalCaddyfile(dispenser *caddyfile.Dispenser) error {
 	for dispenser.Next() {
@@ -283,6 +1042,16 @@ func (d *DuckDB) UnmarshalCaddyfile(dispenser *caddyfile.Dispenser) error {
 					return dispenser.Errf("invalid query_timeout: %v", err)
 				}
 				d.QueryTimeout = caddy.Duration(duration)
+			case "connection_acquire_timeout":
+				var timeout string
+				if !dispenser.Args(&timeout) {
+					return dispenser.ArgErr()
+				}
+				duration, err := caddy.ParseDuration(timeout)
+				if err != nil {
+					return dispenser.Errf("invalid connection_acquire_timeout: %v", err)
+				}
+				d.ConnectionAcquireTimeout = caddy.Duration(duration)
 			case "max_rows_per_page":
 				var maxRowsStr string
 				if !dispenser.Args(&maxRowsStr) {
@@ -303,6 +1072,11 @@ func (d *DuckDB) UnmarshalCaddyfile(dispenser *caddyfile.Dispenser) error {
 					return dispenser.Errf("invalid absolute_max_rows: %v", err)
 				}
 				d.AbsoluteMaxRows = absMaxRows
+			case "on_row_limit":
+				if !dispenser.Args(&d.OnRowLimit) {
+					return dispenser.ArgErr()
+				}
+				d.OnRowLimit = strings.ToLower(d.OnRowLimit)
 			case "threads":
 				var threadsStr string
 				if !dispenser.Args(&threadsStr) {
@@ -332,6 +1106,348 @@ func (d *DuckDB) UnmarshalCaddyfile(dispenser *caddyfile.Dispenser) error {
 				if !dispenser.Args(&d.TempDirectory) {
 					return dispenser.ArgErr()
 				}
+			case "csv_charset":
+				if !dispenser.Args(&d.CSVCharset) {
+					return dispenser.ArgErr()
+				}
+			case "reject_unsupported_charset":
+				var rejectStr string
+				if !dispenser.Args(&rejectStr) {
+					return dispenser.ArgErr()
+				}
+				rejectStr = strings.ToLower(rejectStr)
+				d.RejectUnsupportedCharset = rejectStr == "true" || rejectStr == "yes" || rejectStr == "1"
+			case "auto_create_tables":
+				var enableStr string
+				if !dispenser.Args(&enableStr) {
+					return dispenser.ArgErr()
+				}
+				enableStr = strings.ToLower(enableStr)
+				d.AutoCreateTables = enableStr == "true" || enableStr == "yes" || enableStr == "1"
+			case "json_key_case":
+				if !dispenser.Args(&d.JSONKeyCase) {
+					return dispenser.ArgErr()
+				}
+				d.JSONKeyCase = strings.ToLower(d.JSONKeyCase)
+			case "decimal_as_string":
+				var enableStr string
+				if !dispenser.Args(&enableStr) {
+					return dispenser.ArgErr()
+				}
+				enableStr = strings.ToLower(enableStr)
+				enabled := enableStr == "true" || enableStr == "yes" || enableStr == "1"
+				d.DecimalAsString = &enabled
+			case "response_shape":
+				if !dispenser.Args(&d.ResponseShape) {
+					return dispenser.ArgErr()
+				}
+				d.ResponseShape = strings.ToLower(d.ResponseShape)
+			case "arrow_dictionary_threshold":
+				var thresholdStr string
+				if !dispenser.Args(&thresholdStr) {
+					return dispenser.ArgErr()
+				}
+				threshold, err := strconv.Atoi(thresholdStr)
+				if err != nil {
+					return dispenser.Errf("invalid arrow_dictionary_threshold: %v", err)
+				}
+				d.ArrowDictionaryThreshold = threshold
+			case "arrow_batch_size":
+				var sizeStr string
+				if !dispenser.Args(&sizeStr) {
+					return dispenser.ArgErr()
+				}
+				size, err := strconv.Atoi(sizeStr)
+				if err != nil {
+					return dispenser.Errf("invalid arrow_batch_size: %v", err)
+				}
+				d.ArrowBatchSize = size
+			case "parquet_row_group_size":
+				var sizeStr string
+				if !dispenser.Args(&sizeStr) {
+					return dispenser.ArgErr()
+				}
+				size, err := strconv.Atoi(sizeStr)
+				if err != nil {
+					return dispenser.Errf("invalid parquet_row_group_size: %v", err)
+				}
+				d.ParquetRowGroupSize = size
+			case "query_tagging":
+				var enableStr string
+				if !dispenser.Args(&enableStr) {
+					return dispenser.ArgErr()
+				}
+				enableStr = strings.ToLower(enableStr)
+				d.QueryTagging = enableStr == "true" || enableStr == "yes" || enableStr == "1"
+			case "query_plan_cache":
+				var sizeStr string
+				if !dispenser.Args(&sizeStr) {
+					return dispenser.ArgErr()
+				}
+				size, err := strconv.Atoi(sizeStr)
+				if err != nil {
+					return dispenser.Errf("invalid query_plan_cache: %v", err)
+				}
+				d.QueryPlanCache = size
+			case "query_cache_ttl":
+				var ttl string
+				if !dispenser.Args(&ttl) {
+					return dispenser.ArgErr()
+				}
+				duration, err := caddy.ParseDuration(ttl)
+				if err != nil {
+					return dispenser.Errf("invalid query_cache_ttl: %v", err)
+				}
+				d.QueryCacheTTL = caddy.Duration(duration)
+			case "validate_query_tables":
+				var enableStr string
+				if !dispenser.Args(&enableStr) {
+					return dispenser.ArgErr()
+				}
+				enableStr = strings.ToLower(enableStr)
+				d.ValidateQueryTables = enableStr == "true" || enableStr == "yes" || enableStr == "1"
+			case "coalesce_reads":
+				var enableStr string
+				if !dispenser.Args(&enableStr) {
+					return dispenser.ArgErr()
+				}
+				enableStr = strings.ToLower(enableStr)
+				d.CoalesceReads = enableStr == "true" || enableStr == "yes" || enableStr == "1"
+			case "max_streams_per_key":
+				var maxStreamsStr string
+				if !dispenser.Args(&maxStreamsStr) {
+					return dispenser.ArgErr()
+				}
+				maxStreams, err := strconv.Atoi(maxStreamsStr)
+				if err != nil {
+					return dispenser.Errf("invalid max_streams_per_key: %v", err)
+				}
+				d.MaxStreamsPerKey = maxStreams
+			case "read_pool_size":
+				var sizeStr string
+				if !dispenser.Args(&sizeStr) {
+					return dispenser.ArgErr()
+				}
+				size, err := strconv.Atoi(sizeStr)
+				if err != nil {
+					return dispenser.Errf("invalid read_pool_size: %v", err)
+				}
+				d.ReadPoolSize = size
+			case "table_pool":
+				// table_pool <name> <size> <table...>
+				args := dispenser.RemainingArgs()
+				if len(args) < 3 {
+					return dispenser.ArgErr()
+				}
+				size, err := strconv.Atoi(args[1])
+				if err != nil {
+					return dispenser.Errf("invalid table_pool size: %v", err)
+				}
+				d.TablePools = append(d.TablePools, database.TablePool{Name: args[0], Size: size, Tables: args[2:]})
+			case "rate_limit":
+				rateLimit, err := unmarshalRateLimit(dispenser)
+				if err != nil {
+					return err
+				}
+				d.RateLimit = rateLimit
+			case "download_token_ttl":
+				var ttl string
+				if !dispenser.Args(&ttl) {
+					return dispenser.ArgErr()
+				}
+				duration, err := caddy.ParseDuration(ttl)
+				if err != nil {
+					return dispenser.Errf("invalid download_token_ttl: %v", err)
+				}
+				d.DownloadTokenTTL = caddy.Duration(duration)
+			case "max_columns":
+				var maxColumnsStr string
+				if !dispenser.Args(&maxColumnsStr) {
+					return dispenser.ArgErr()
+				}
+				maxColumns, err := strconv.Atoi(maxColumnsStr)
+				if err != nil {
+					return dispenser.Errf("invalid max_columns: %v", err)
+				}
+				d.MaxColumns = maxColumns
+			case "server_timing":
+				var enableStr string
+				if !dispenser.Args(&enableStr) {
+					return dispenser.ArgErr()
+				}
+				enableStr = strings.ToLower(enableStr)
+				d.ServerTiming = enableStr == "true" || enableStr == "yes" || enableStr == "1"
+			case "metrics_public":
+				var enableStr string
+				if !dispenser.Args(&enableStr) {
+					return dispenser.ArgErr()
+				}
+				enableStr = strings.ToLower(enableStr)
+				d.MetricsPublic = enableStr == "true" || enableStr == "yes" || enableStr == "1"
+			case "error_detail":
+				if !dispenser.Args(&d.ErrorDetail) {
+					return dispenser.ArgErr()
+				}
+				d.ErrorDetail = strings.ToLower(d.ErrorDetail)
+			case "trailing_slash":
+				if !dispenser.Args(&d.TrailingSlash) {
+					return dispenser.ArgErr()
+				}
+				d.TrailingSlash = strings.ToLower(d.TrailingSlash)
+			case "api_key_header":
+				if !dispenser.Args(&d.APIKeyHeader) {
+					return dispenser.ArgErr()
+				}
+			case "require_tls":
+				var enableStr string
+				if !dispenser.Args(&enableStr) {
+					return dispenser.ArgErr()
+				}
+				enableStr = strings.ToLower(enableStr)
+				d.RequireTLS = enableStr == "true" || enableStr == "yes" || enableStr == "1"
+			case "health_source":
+				var name, query string
+				if !dispenser.Args(&name, &query) {
+					return dispenser.ArgErr()
+				}
+				if d.HealthSources == nil {
+					d.HealthSources = make(map[string]string)
+				}
+				d.HealthSources[name] = query
+			case "namespace_tables":
+				// namespace_tables <namespace> <table...>
+				args := dispenser.RemainingArgs()
+				if len(args) < 2 {
+					return dispenser.ArgErr()
+				}
+				if d.NamespaceTables == nil {
+					d.NamespaceTables = make(map[string][]string)
+				}
+				d.NamespaceTables[args[0]] = append(d.NamespaceTables[args[0]], args[1:]...)
+			case "query_labels":
+				// query_labels <label...>
+				args := dispenser.RemainingArgs()
+				if len(args) == 0 {
+					return dispenser.ArgErr()
+				}
+				d.QueryLabels = append(d.QueryLabels, args...)
+			case "cors_origins":
+				// cors_origins <origin...>
+				args := dispenser.RemainingArgs()
+				if len(args) == 0 {
+					return dispenser.ArgErr()
+				}
+				d.CORSOrigins = append(d.CORSOrigins, args...)
+			case "cors_methods":
+				// cors_methods <method...>
+				args := dispenser.RemainingArgs()
+				if len(args) == 0 {
+					return dispenser.ArgErr()
+				}
+				for _, method := range args {
+					d.CORSMethods = append(d.CORSMethods, strings.ToUpper(method))
+				}
+			case "slow_query_threshold":
+				// slow_query_threshold [<table>] <duration>
+				args := dispenser.RemainingArgs()
+				if len(args) < 1 || len(args) > 2 {
+					return dispenser.ArgErr()
+				}
+				duration, err := caddy.ParseDuration(args[len(args)-1])
+				if err != nil {
+					return dispenser.Errf("invalid slow_query_threshold: %v", err)
+				}
+				if len(args) == 1 {
+					d.SlowQueryThreshold = caddy.Duration(duration)
+				} else {
+					if d.SlowQueryThresholds == nil {
+						d.SlowQueryThresholds = make(map[string]caddy.Duration)
+					}
+					d.SlowQueryThresholds[args[0]] = caddy.Duration(duration)
+				}
+			case "required_header":
+				// required_header <name> [<pattern>]
+				args := dispenser.RemainingArgs()
+				if len(args) < 1 || len(args) > 2 {
+					return dispenser.ArgErr()
+				}
+				if d.RequiredHeaders == nil {
+					d.RequiredHeaders = make(map[string]string)
+				}
+				pattern := ""
+				if len(args) == 2 {
+					pattern = args[1]
+				}
+				d.RequiredHeaders[args[0]] = pattern
+			case "response_headers":
+				// response_headers [<path>] { <name> <value> ... }
+				scope := "/"
+				args := dispenser.RemainingArgs()
+				if len(args) > 1 {
+					return dispenser.ArgErr()
+				}
+				if len(args) == 1 {
+					scope = args[0]
+				}
+				if d.ResponseHeaders == nil {
+					d.ResponseHeaders = make(map[string]map[string]string)
+				}
+				if d.ResponseHeaders[scope] == nil {
+					d.ResponseHeaders[scope] = make(map[string]string)
+				}
+				if err := unmarshalResponseHeaders(dispenser, d.ResponseHeaders[scope]); err != nil {
+					return err
+				}
+			case "request_log":
+				if d.RequestLog == nil {
+					d.RequestLog = &RequestLogConfig{}
+				}
+				if err := unmarshalRequestLogConfig(dispenser, d.RequestLog); err != nil {
+					return err
+				}
+			case "pagination":
+				policy, err := unmarshalPaginationPolicy(dispenser)
+				if err != nil {
+					return err
+				}
+				d.Pagination = policy
+			case "debug_sql":
+				if d.DebugSQL == nil {
+					d.DebugSQL = &handlers.DebugSQLConfig{}
+				}
+				if err := unmarshalDebugSQLConfig(dispenser, d.DebugSQL); err != nil {
+					return err
+				}
+			case "maintenance":
+				if d.Maintenance == nil {
+					d.Maintenance = &MaintenanceConfig{}
+				}
+				if err := unmarshalMaintenanceConfig(dispenser, d.Maintenance); err != nil {
+					return err
+				}
+			case "index_advisor":
+				if d.IndexAdvisor == nil {
+					d.IndexAdvisor = &handlers.IndexAdvisorConfig{}
+				}
+				if err := unmarshalIndexAdvisorConfig(dispenser, d.IndexAdvisor); err != nil {
+					return err
+				}
+			case "table":
+				var tableName string
+				if !dispenser.Args(&tableName) {
+					return dispenser.ArgErr()
+				}
+				if d.Tables == nil {
+					d.Tables = make(map[string]*handlers.TableConfig)
+				}
+				cfg, ok := d.Tables[tableName]
+				if !ok {
+					cfg = &handlers.TableConfig{}
+					d.Tables[tableName] = cfg
+				}
+				if err := unmarshalTableConfig(dispenser, cfg); err != nil {
+					return err
+				}
 			default:
 				return dispenser.Errf("unknown subdirective: %s", dispenser.Val())
 			}
@@ -340,6 +1456,258 @@ func (d *DuckDB) UnmarshalCaddyfile(dispenser *caddyfile.Dispenser) error {
 	return nil
 }
 
+// unmarshalTableConfig parses the body of a `table <name> { ... }` block.
+func unmarshalTableConfig(dispenser *caddyfile.Dispenser, cfg *handlers.TableConfig) error {
+	for nesting := dispenser.Nesting(); dispenser.NextBlock(nesting); {
+		switch dispenser.Val() {
+		case "soft_delete":
+			// Column name is optional and defaults to deleted_at
+			cfg.SoftDeleteColumn = handlers.DefaultSoftDeleteColumn
+			var column string
+			if dispenser.Args(&column) {
+				cfg.SoftDeleteColumn = column
+			}
+		case "validate":
+			// validate <column> <min|max|enum|regex> <value...>
+			args := dispenser.RemainingArgs()
+			if len(args) < 3 {
+				return dispenser.ArgErr()
+			}
+			rule := handlers.ValidationRule{
+				Column: args[0],
+				Rule:   strings.ToLower(args[1]),
+				Values: args[2:],
+			}
+			switch rule.Rule {
+			case handlers.RuleMin, handlers.RuleMax, handlers.RuleRegex:
+				if len(rule.Values) != 1 {
+					return dispenser.Errf("invalid validate rule: %s expects exactly one value", rule.Rule)
+				}
+			case handlers.RuleEnum:
+			default:
+				return dispenser.Errf("invalid validate rule: unknown rule type %s", rule.Rule)
+			}
+			cfg.Rules = append(cfg.Rules, rule)
+		case "filterable":
+			// filterable <column...>
+			columns := dispenser.RemainingArgs()
+			if len(columns) == 0 {
+				return dispenser.ArgErr()
+			}
+			cfg.Filterable = append(cfg.Filterable, columns...)
+		case "sortable":
+			// sortable <column...>
+			columns := dispenser.RemainingArgs()
+			if len(columns) == 0 {
+				return dispenser.ArgErr()
+			}
+			cfg.Sortable = append(cfg.Sortable, columns...)
+		case "hash_columns":
+			// hash_columns <column...>
+			columns := dispenser.RemainingArgs()
+			if len(columns) == 0 {
+				return dispenser.ArgErr()
+			}
+			cfg.HashColumns = append(cfg.HashColumns, columns...)
+		case "pagination":
+			// pagination <page|cursor> [max_offset]
+			policy, err := unmarshalPaginationPolicy(dispenser)
+			if err != nil {
+				return err
+			}
+			cfg.Pagination = policy
+		case "response_shape":
+			// response_shape <default|result|items>
+			if !dispenser.Args(&cfg.ResponseShape) {
+				return dispenser.ArgErr()
+			}
+			cfg.ResponseShape = strings.ToLower(cfg.ResponseShape)
+		case "change_column":
+			// change_column <column>
+			if !dispenser.Args(&cfg.ChangeColumn) {
+				return dispenser.ArgErr()
+			}
+		case "json_schema":
+			// json_schema <path>
+			if !dispenser.Args(&cfg.JSONSchema) {
+				return dispenser.ArgErr()
+			}
+		case "derived":
+			// derived <name> <expression>
+			var derived database.DerivedColumn
+			if !dispenser.Args(&derived.Name, &derived.Expression) {
+				return dispenser.ArgErr()
+			}
+			if dispenser.NextArg() {
+				return dispenser.Errf("invalid derived column: quote the expression for '%s'", derived.Name)
+			}
+			cfg.Derived = append(cfg.Derived, derived)
+		default:
+			return dispenser.Errf("unknown table subdirective: %s", dispenser.Val())
+		}
+	}
+	return nil
+}
+
+// unmarshalRequestLogConfig parses a `request_log { ... }` block. The directive
+// enables request logging unless the block sets `enabled false`.
+func unmarshalRequestLogConfig(dispenser *caddyfile.Dispenser, cfg *RequestLogConfig) error {
+	cfg.Enabled = true
+	for nesting := dispenser.Nesting(); dispenser.NextBlock(nesting); {
+		switch dispenser.Val() {
+		case "enabled":
+			var enableStr string
+			if !dispenser.Args(&enableStr) {
+				return dispenser.ArgErr()
+			}
+			enableStr = strings.ToLower(enableStr)
+			cfg.Enabled = enableStr == "true" || enableStr == "yes" || enableStr == "1"
+		case "sample_rate":
+			var rateStr string
+			if !dispenser.Args(&rateStr) {
+				return dispenser.ArgErr()
+			}
+			rate, err := strconv.ParseFloat(rateStr, 64)
+			if err != nil {
+				return dispenser.Errf("invalid sample_rate: %v", err)
+			}
+			cfg.SampleRate = &rate
+		default:
+			return dispenser.Errf("unknown request_log subdirective: %s", dispenser.Val())
+		}
+	}
+	return nil
+}
+
+// unmarshalResponseHeaders parses the `<name> <value>` lines of a
+// `response_headers { ... }` block.
+func unmarshalResponseHeaders(dispenser *caddyfile.Dispenser, headers map[string]string) error {
+	for nesting := dispenser.Nesting(); dispenser.NextBlock(nesting); {
+		name := dispenser.Val()
+		var value string
+		if !dispenser.Args(&value) {
+			return dispenser.ArgErr()
+		}
+		headers[name] = value
+	}
+	return nil
+}
+
+// unmarshalIndexAdvisorConfig parses an `index_advisor { ... }` block. The
+// block enables the advisor unless it sets `enabled false`.
+func unmarshalIndexAdvisorConfig(dispenser *caddyfile.Dispenser, cfg *handlers.IndexAdvisorConfig) error {
+	cfg.Enabled = true
+	for nesting := dispenser.Nesting(); dispenser.NextBlock(nesting); {
+		switch dispenser.Val() {
+		case "enabled":
+			var enableStr string
+			if !dispenser.Args(&enableStr) {
+				return dispenser.ArgErr()
+			}
+			enableStr = strings.ToLower(enableStr)
+			cfg.Enabled = enableStr == "true" || enableStr == "yes" || enableStr == "1"
+		case "auto_create":
+			var enableStr string
+			if !dispenser.Args(&enableStr) {
+				return dispenser.ArgErr()
+			}
+			enableStr = strings.ToLower(enableStr)
+			cfg.AutoCreate = enableStr == "true" || enableStr == "yes" || enableStr == "1"
+		case "min_uses":
+			var minUsesStr string
+			if !dispenser.Args(&minUsesStr) {
+				return dispenser.ArgErr()
+			}
+			minUses, err := strconv.Atoi(minUsesStr)
+			if err != nil {
+				return dispenser.Errf("invalid min_uses: %v", err)
+			}
+			cfg.MinUses = minUses
+		case "max_auto_indexes":
+			var maxStr string
+			if !dispenser.Args(&maxStr) {
+				return dispenser.ArgErr()
+			}
+			maxIndexes, err := strconv.Atoi(maxStr)
+			if err != nil {
+				return dispenser.Errf("invalid max_auto_indexes: %v", err)
+			}
+			cfg.MaxAutoIndexes = maxIndexes
+		default:
+			return dispenser.Errf("unknown index_advisor subdirective: %s", dispenser.Val())
+		}
+	}
+	return nil
+}
+
+// unmarshalMaintenanceConfig parses a `maintenance { ... }` block.
+func unmarshalMaintenanceConfig(dispenser *caddyfile.Dispenser, cfg *MaintenanceConfig) error {
+	for nesting := dispenser.Nesting(); dispenser.NextBlock(nesting); {
+		switch dispenser.Val() {
+		case "interval":
+			var intervalStr string
+			if !dispenser.Args(&intervalStr) {
+				return dispenser.ArgErr()
+			}
+			interval, err := caddy.ParseDuration(intervalStr)
+			if err != nil {
+				return dispenser.Errf("invalid maintenance interval: %v", err)
+			}
+			cfg.Interval = caddy.Duration(interval)
+		case "analyze":
+			var enableStr string
+			if !dispenser.Args(&enableStr) {
+				return dispenser.ArgErr()
+			}
+			enableStr = strings.ToLower(enableStr)
+			cfg.Analyze = enableStr == "true" || enableStr == "yes" || enableStr == "1"
+		default:
+			return dispenser.Errf("unknown maintenance subdirective: %s", dispenser.Val())
+		}
+	}
+	return nil
+}
+
+// unmarshalPaginationPolicy parses the arguments of a `pagination <style> [max_offset]` directive.
+func unmarshalPaginationPolicy(dispenser *caddyfile.Dispenser) (*handlers.PaginationPolicy, error) {
+	args := dispenser.RemainingArgs()
+	if len(args) == 0 || len(args) > 2 {
+		return nil, dispenser.ArgErr()
+	}
+	policy := &handlers.PaginationPolicy{Style: strings.ToLower(args[0])}
+	if len(args) == 2 {
+		maxOffset, err := strconv.Atoi(args[1])
+		if err != nil {
+			return nil, dispenser.Errf("invalid pagination max_offset: %v", err)
+		}
+		policy.MaxOffset = maxOffset
+	}
+	return policy, nil
+}
+
+// unmarshalDebugSQLConfig parses a `debug_sql { ... }` block.
+func unmarshalDebugSQLConfig(dispenser *caddyfile.Dispenser, cfg *handlers.DebugSQLConfig) error {
+	for nesting := dispenser.Nesting(); dispenser.NextBlock(nesting); {
+		switch dispenser.Val() {
+		case "roles":
+			roles := dispenser.RemainingArgs()
+			if len(roles) == 0 {
+				return dispenser.ArgErr()
+			}
+			cfg.Roles = append(cfg.Roles, roles...)
+		case "redact":
+			columns := dispenser.RemainingArgs()
+			if len(columns) == 0 {
+				return dispenser.ArgErr()
+			}
+			cfg.Redact = append(cfg.Redact, columns...)
+		default:
+			return dispenser.Errf("unknown debug_sql subdirective: %s", dispenser.Val())
+		}
+	}
+	return nil
+}
+
 // parseCaddyfile unmarshals tokens from h into a new Middleware.
 func parseCaddyfile(h httpcaddyfile.Helper) (caddyhttp.MiddlewareHandler, error) {
 	var d DuckDB
@@ -6,6 +6,8 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"os"
+	"path/filepath"
+	"strconv"
 	"strings"
 	"testing"
 	"time"
@@ -14,6 +16,7 @@ import (
 	"github.com/caddyserver/caddy/v2/caddyconfig/caddyfile"
 	"github.com/tobilg/caddy-duckdb-module/auth"
 	"github.com/tobilg/caddy-duckdb-module/database"
+	"github.com/tobilg/caddy-duckdb-module/formats"
 	"github.com/tobilg/caddy-duckdb-module/handlers"
 	"go.uber.org/zap"
 )
@@ -81,9 +84,10 @@ func setupTestModule(t *testing.T) (*DuckDB, func()) {
 	}
 
 	// Initialize handlers (matching Provision logic)
-	d.crudHandler = nil // Will use handlers package if needed
-	d.queryHandler = nil
-	d.openAPIHandler = nil
+	d.crudHandler = handlers.NewCRUDHandler(mgr, authorizer, d.MaxRowsPerPage, d.AbsoluteMaxRows, d.logger)
+	d.queryHandler = handlers.NewQueryHandler(mgr, authorizer, d.logger)
+	d.openAPIHandler = handlers.NewOpenAPIHandler()
+	d.openAPIHandler.SetTableSource(mgr, authorizer)
 
 	cleanup := func() {
 		mgr.Close()
@@ -165,6 +169,48 @@ func TestValidate_InvalidAbsoluteMaxRows(t *testing.T) {
 	}
 }
 
+func TestValidate_InvalidMaxColumns(t *testing.T) {
+	d := &DuckDB{
+		AccessMode:      "read_write",
+		MaxRowsPerPage:  100,
+		AbsoluteMaxRows: 10000,
+		Threads:         4,
+		MaxColumns:      -1,
+	}
+
+	if err := d.Validate(); err == nil {
+		t.Error("Expected error for negative max_columns")
+	}
+}
+
+func TestValidate_InvalidErrorDetail(t *testing.T) {
+	d := &DuckDB{
+		AccessMode:      "read_write",
+		MaxRowsPerPage:  100,
+		AbsoluteMaxRows: 10000,
+		Threads:         4,
+		ErrorDetail:     "verbose",
+	}
+
+	if err := d.Validate(); err == nil {
+		t.Error("Expected error for unknown error_detail")
+	}
+}
+
+func TestValidate_InvalidPagination(t *testing.T) {
+	d := &DuckDB{
+		AccessMode:      "read_write",
+		MaxRowsPerPage:  100,
+		AbsoluteMaxRows: 10000,
+		Threads:         4,
+		Pagination:      &handlers.PaginationPolicy{Style: "offset"},
+	}
+
+	if err := d.Validate(); err == nil {
+		t.Error("Expected error for unknown pagination style")
+	}
+}
+
 func TestValidate_InvalidThreads(t *testing.T) {
 	d := &DuckDB{
 		AccessMode:      "read_write",
@@ -179,6 +225,77 @@ func TestValidate_InvalidThreads(t *testing.T) {
 	}
 }
 
+func TestValidate_InvalidMaxStreamsPerKey(t *testing.T) {
+	d := &DuckDB{
+		AccessMode:       "read_write",
+		MaxRowsPerPage:   100,
+		AbsoluteMaxRows:  10000,
+		Threads:          4,
+		MaxStreamsPerKey: -1,
+	}
+
+	err := d.Validate()
+	if err == nil {
+		t.Error("Expected error for negative max_streams_per_key")
+	}
+}
+
+func TestValidate_ReadPoolSize(t *testing.T) {
+	tests := []struct {
+		name       string
+		accessMode string
+		size       int
+		wantErr    bool
+	}{
+		{"disabled", "read_write", 0, false},
+		{"read write", "read_write", 4, false},
+		{"negative", "read_write", -1, true},
+		{"read only", "read_only", 4, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := &DuckDB{
+				AccessMode:      tt.accessMode,
+				MaxRowsPerPage:  100,
+				AbsoluteMaxRows: 10000,
+				Threads:         4,
+				ReadPoolSize:    tt.size,
+			}
+			if err := d.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidate_TablePools(t *testing.T) {
+	tests := []struct {
+		name       string
+		accessMode string
+		pools      []database.TablePool
+		wantErr    bool
+	}{
+		{"valid", "read_write", []database.TablePool{{Name: "hot", Size: 2, Tables: []string{"events", "analytics.clicks"}}}, false},
+		{"zero size", "read_write", []database.TablePool{{Name: "hot", Size: 0, Tables: []string{"events"}}}, true},
+		{"invalid table", "read_write", []database.TablePool{{Name: "hot", Size: 2, Tables: []string{"events; DROP"}}}, true},
+		{"read only", "read_only", []database.TablePool{{Name: "hot", Size: 2, Tables: []string{"events"}}}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := &DuckDB{
+				AccessMode:      tt.accessMode,
+				MaxRowsPerPage:  100,
+				AbsoluteMaxRows: 10000,
+				Threads:         4,
+				TablePools:      tt.pools,
+			}
+			if err := d.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
 func TestValidate_ReadOnlyMode(t *testing.T) {
 	d := &DuckDB{
 		AccessMode:      "read_only",
@@ -215,6 +332,14 @@ func TestServeHTTP_HealthCheck(t *testing.T) {
 	if result["status"] != "ok" {
 		t.Errorf("Expected status 'ok', got '%v'", result["status"])
 	}
+	if result["main_db"] != true || result["auth_db"] != true {
+		t.Errorf("Expected both databases to be reachable, got %v", result)
+	}
+	pools, _ := result["pools"].(map[string]interface{})
+	mainPool, _ := pools["main"].(map[string]interface{})
+	if _, ok := mainPool["open_connections"]; !ok || pools["auth"] == nil {
+		t.Errorf("Expected main and auth pool stats, got %v", result["pools"])
+	}
 
 	// Health check should NOT call next handler
 	if next.called {
@@ -222,6 +347,140 @@ func TestServeHTTP_HealthCheck(t *testing.T) {
 	}
 }
 
+func TestServeHTTP_HealthCheck_ClosedDatabase(t *testing.T) {
+	d, cleanup := setupTestModule(t)
+	defer cleanup()
+
+	// Closing a pool again in cleanup is a no-op
+	if err := d.dbMgr.AuthDB().Close(); err != nil {
+		t.Fatalf("Failed to close auth database: %v", err)
+	}
+
+	req := httptest.NewRequest("GET", "/duckdb/health", nil)
+	rec := httptest.NewRecorder()
+	if err := d.ServeHTTP(rec, req, &mockNextHandler{}); err != nil {
+		t.Fatalf("ServeHTTP returned error: %v", err)
+	}
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Errorf("Expected status 503, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var result map[string]interface{}
+	if err := json.Unmarshal(rec.Body.Bytes(), &result); err != nil {
+		t.Fatalf("Failed to parse response: %v", err)
+	}
+	if result["status"] != "degraded" || result["main_db"] != true || result["auth_db"] != false {
+		t.Errorf("Expected a degraded status with only the auth database down, got %v", result)
+	}
+
+	// The main database is checked as well
+	if err := d.dbMgr.MainDB().Close(); err != nil {
+		t.Fatalf("Failed to close main database: %v", err)
+	}
+	rec = httptest.NewRecorder()
+	if err := d.ServeHTTP(rec, httptest.NewRequest("GET", "/duckdb/health", nil), &mockNextHandler{}); err != nil {
+		t.Fatalf("ServeHTTP returned error: %v", err)
+	}
+	result = nil
+	json.Unmarshal(rec.Body.Bytes(), &result)
+	if rec.Code != http.StatusServiceUnavailable || result["main_db"] != false {
+		t.Errorf("Expected 503 with main_db false, got %d: %v", rec.Code, result)
+	}
+}
+
+func TestServeHTTP_HealthCheck_Deep(t *testing.T) {
+	d, cleanup := setupTestModule(t)
+	defer cleanup()
+
+	if _, err := d.dbMgr.ExecMain(`ATTACH ':memory:' AS ext`); err != nil {
+		t.Fatalf("Failed to attach database: %v", err)
+	}
+	if _, err := d.dbMgr.ExecMain(`CREATE TABLE ext.events (id INTEGER)`); err != nil {
+		t.Fatalf("Failed to create table: %v", err)
+	}
+
+	deepHealth := func() (int, map[string]interface{}) {
+		req := httptest.NewRequest("GET", "/duckdb/health?deep=true", nil)
+		req.Header.Set("X-API-Key", "test-api-key")
+		rec := httptest.NewRecorder()
+		if err := d.ServeHTTP(rec, req, &mockNextHandler{}); err != nil {
+			t.Fatalf("ServeHTTP returned error: %v", err)
+		}
+		var result map[string]interface{}
+		if err := json.Unmarshal(rec.Body.Bytes(), &result); err != nil {
+			t.Fatalf("Failed to parse response: %v", err)
+		}
+		return rec.Code, result
+	}
+
+	// All attached databases reachable
+	code, result := deepHealth()
+	if code != http.StatusOK {
+		t.Errorf("Expected status 200, got %d: %v", code, result)
+	}
+	if result["status"] != "ok" {
+		t.Errorf("Expected status 'ok', got '%v'", result["status"])
+	}
+	sources, _ := result["sources"].([]interface{})
+	found := false
+	for _, s := range sources {
+		source := s.(map[string]interface{})
+		if source["name"] == "ext" {
+			found = true
+			if source["healthy"] != true {
+				t.Errorf("Expected 'ext' to be healthy, got %v", source)
+			}
+		}
+	}
+	if !found {
+		t.Errorf("Expected 'ext' in sources, got %v", sources)
+	}
+
+	// A missing source degrades the health check
+	d.HealthSources = map[string]string{
+		"archive": "SELECT * FROM read_parquet('/nonexistent/archive.parquet') LIMIT 1",
+	}
+	code, result = deepHealth()
+	if code != http.StatusServiceUnavailable {
+		t.Errorf("Expected status 503, got %d", code)
+	}
+	if result["status"] != "degraded" {
+		t.Errorf("Expected status 'degraded', got '%v'", result["status"])
+	}
+	sources, _ = result["sources"].([]interface{})
+	last := sources[len(sources)-1].(map[string]interface{})
+	if last["name"] != "archive" || last["healthy"] != false || last["error"] == "" {
+		t.Errorf("Expected unhealthy 'archive' source, got %v", last)
+	}
+
+	// Below full error detail, the DuckDB error is withheld
+	d.ErrorDetail = handlers.ErrorDetailSafe
+	_, result = deepHealth()
+	sources, _ = result["sources"].([]interface{})
+	last = sources[len(sources)-1].(map[string]interface{})
+	if last["error"] != "unreachable" {
+		t.Errorf("Expected the error to be withheld, got %v", last["error"])
+	}
+}
+
+func TestServeHTTP_HealthCheck_DeepRequiresAuth(t *testing.T) {
+	d, cleanup := setupTestModule(t)
+	defer cleanup()
+
+	req := httptest.NewRequest("GET", "/duckdb/health?deep=true", nil)
+	rec := httptest.NewRecorder()
+	if err := d.ServeHTTP(rec, req, &mockNextHandler{}); err != nil {
+		t.Fatalf("ServeHTTP returned error: %v", err)
+	}
+	if rec.Code != http.StatusUnauthorized {
+		t.Errorf("Expected status 401 for an anonymous deep health check, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var result map[string]interface{}
+	json.Unmarshal(rec.Body.Bytes(), &result)
+	if _, ok := result["sources"]; ok {
+		t.Errorf("Expected no sources for an anonymous request, got %v", result)
+	}
+}
+
 func TestServeHTTP_NonDuckDBPath(t *testing.T) {
 	d, cleanup := setupTestModule(t)
 	defer cleanup()
@@ -274,6 +533,48 @@ func TestServeHTTP_RequestID_Preserved(t *testing.T) {
 	}
 }
 
+func TestServeHTTP_RequestIDInBody(t *testing.T) {
+	d, cleanup := setupTestModule(t)
+	defer cleanup()
+
+	tests := []struct {
+		name       string
+		method     string
+		path       string
+		body       string
+		apiKey     string
+		wantStatus int
+	}{
+		{"unauthenticated", "GET", "/duckdb/api/test_data", "", "", http.StatusUnauthorized},
+		{"unknown endpoint", "GET", "/duckdb/nowhere", "", "test-api-key", http.StatusNotFound},
+		{"crud error", "GET", "/duckdb/api/test_data?sort=id:sideways", "", "test-api-key", http.StatusBadRequest},
+		{"crud success", "POST", "/duckdb/api/test_data", `{"id": 7, "value": "x"}`, "test-api-key", http.StatusCreated},
+		{"query error", "POST", "/duckdb/query", `{"sql": "SELEC 1"}`, "test-api-key", http.StatusBadRequest},
+		{"query dml", "POST", "/duckdb/query", `{"sql": "DELETE FROM test_data WHERE id = 7"}`, "test-api-key", http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.path, strings.NewReader(tt.body))
+			if tt.apiKey != "" {
+				req.Header.Set("X-API-Key", tt.apiKey)
+			}
+			rec := httptest.NewRecorder()
+			d.ServeHTTP(rec, req, &mockNextHandler{})
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("Expected status %d, got %d: %s", tt.wantStatus, rec.Code, rec.Body.String())
+			}
+			var body map[string]interface{}
+			if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+				t.Fatalf("Expected a JSON body: %v", err)
+			}
+			header := rec.Header().Get("X-Request-ID")
+			if header == "" || body["request_id"] != header {
+				t.Errorf("Expected request_id %q in the body, got %v", header, body["request_id"])
+			}
+		})
+	}
+}
+
 func TestServeHTTP_Unauthenticated(t *testing.T) {
 	d, cleanup := setupTestModule(t)
 	defer cleanup()
@@ -312,6 +613,145 @@ func TestServeHTTP_InvalidAPIKey(t *testing.T) {
 	}
 }
 
+func TestServeHTTP_APIKeyHeader(t *testing.T) {
+	d, cleanup := setupTestModule(t)
+	defer cleanup()
+	d.authMw.SetAPIKeyHeader("Authorization")
+
+	// Unknown endpoint: 404 once authenticated
+	request := func(header, value string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("GET", "/duckdb/unknown", nil)
+		req.Header.Set(header, value)
+		rec := httptest.NewRecorder()
+		d.ServeHTTP(rec, req, &mockNextHandler{})
+		return rec
+	}
+
+	if rec := request("Authorization", "Bearer test-api-key"); rec.Code != http.StatusNotFound {
+		t.Errorf("Expected bearer token to authenticate, got %d", rec.Code)
+	}
+	rec := request("X-API-Key", "test-api-key")
+	if rec.Code != http.StatusUnauthorized {
+		t.Errorf("Expected X-API-Key to be ignored, got %d", rec.Code)
+	}
+	if !strings.Contains(rec.Body.String(), "Missing or invalid Authorization header") {
+		t.Errorf("Expected the configured header in the error, got %s", rec.Body.String())
+	}
+}
+
+func TestServeHTTP_RejectedAPIKeys(t *testing.T) {
+	d, cleanup := setupTestModule(t)
+	defer cleanup()
+
+	past := time.Now().Add(-time.Minute)
+	if err := d.authorizer.CreateAPIKey("expired-api-key", "admin", &past); err != nil {
+		t.Fatalf("Failed to create API key: %v", err)
+	}
+	if err := d.authorizer.CreateAPIKey("disabled-api-key", "admin", nil); err != nil {
+		t.Fatalf("Failed to create API key: %v", err)
+	}
+	if err := d.authorizer.RevokeAPIKey("disabled-api-key"); err != nil {
+		t.Fatalf("Failed to revoke API key: %v", err)
+	}
+
+	tests := []struct {
+		key         string
+		wantMessage string
+	}{
+		{"expired-api-key", "API key has expired"},
+		{"disabled-api-key", "API key has been disabled"},
+		{"unknown-api-key", "Missing or invalid X-API-Key header"},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest("GET", "/duckdb/unknown", nil)
+		req.Header.Set("X-API-Key", tt.key)
+		rec := httptest.NewRecorder()
+		d.ServeHTTP(rec, req, &mockNextHandler{})
+
+		if rec.Code != http.StatusUnauthorized {
+			t.Errorf("%s: expected status 401, got %d", tt.key, rec.Code)
+		}
+		if !strings.Contains(rec.Body.String(), tt.wantMessage) {
+			t.Errorf("%s: expected %q in the error, got %s", tt.key, tt.wantMessage, rec.Body.String())
+		}
+	}
+}
+
+func TestUnmarshalCaddyfile_APIKeyHeader(t *testing.T) {
+	dispenser := caddyfile.NewTestDispenser(`duckdb {
+		api_key_header Authorization
+	}`)
+	d := &DuckDB{}
+	if err := d.UnmarshalCaddyfile(dispenser); err != nil {
+		t.Fatalf("UnmarshalCaddyfile failed: %v", err)
+	}
+	if d.APIKeyHeader != "Authorization" {
+		t.Errorf("Expected api_key_header 'Authorization', got '%s'", d.APIKeyHeader)
+	}
+
+	d = &DuckDB{AccessMode: "read_write", MaxRowsPerPage: 100, Threads: 1, APIKeyHeader: "X API Key"}
+	if err := d.Validate(); err == nil {
+		t.Error("Expected error for invalid api_key_header")
+	}
+}
+
+func TestServeHTTP_ServerTiming(t *testing.T) {
+	d, cleanup := setupTestModule(t)
+	defer cleanup()
+
+	serve := func() *httptest.ResponseRecorder {
+		req := httptest.NewRequest("GET", "/duckdb/unknown", nil)
+		req.Header.Set("X-API-Key", "test-api-key")
+		rec := httptest.NewRecorder()
+		d.ServeHTTP(rec, req, &mockNextHandler{})
+		return rec
+	}
+
+	if rec := serve(); rec.Header().Get("Server-Timing") != "" {
+		t.Error("Expected no Server-Timing header by default")
+	}
+
+	d.ServerTiming = true
+	header := serve().Header().Get("Server-Timing")
+	metrics := make(map[string]bool)
+	for _, entry := range strings.Split(header, ",") {
+		name, dur, ok := strings.Cut(strings.TrimSpace(entry), ";dur=")
+		if _, err := strconv.ParseFloat(dur, 64); !ok || err != nil {
+			t.Fatalf("Unparseable Server-Timing entry %q in %q", entry, header)
+		}
+		metrics[name] = true
+	}
+	if !metrics["auth"] || !metrics["total"] {
+		t.Errorf("Expected auth and total metrics, got %q", header)
+	}
+}
+
+func TestUnmarshalCaddyfile_ServerTiming(t *testing.T) {
+	dispenser := caddyfile.NewTestDispenser(`duckdb {
+		server_timing true
+	}`)
+	d := &DuckDB{}
+	if err := d.UnmarshalCaddyfile(dispenser); err != nil {
+		t.Fatalf("UnmarshalCaddyfile failed: %v", err)
+	}
+	if !d.ServerTiming {
+		t.Error("Expected server_timing to be enabled")
+	}
+}
+
+func TestUnmarshalCaddyfile_MetricsPublic(t *testing.T) {
+	dispenser := caddyfile.NewTestDispenser(`duckdb {
+		metrics_public yes
+	}`)
+	d := &DuckDB{}
+	if err := d.UnmarshalCaddyfile(dispenser); err != nil {
+		t.Fatalf("UnmarshalCaddyfile failed: %v", err)
+	}
+	if !d.MetricsPublic {
+		t.Error("Expected metrics_public to be enabled")
+	}
+}
+
 func TestServeHTTP_UnknownEndpoint(t *testing.T) {
 	d, cleanup := setupTestModule(t)
 	defer cleanup()
@@ -387,20 +827,21 @@ func TestServeHTTP_OpenAPI(t *testing.T) {
 	defer cleanup()
 
 	// Initialize OpenAPI handler for test
-	d.openAPIHandler = nil // Will return error without handler
+	d.openAPIHandler = handlers.NewOpenAPIHandler()
 
 	req := httptest.NewRequest("GET", "/duckdb/openapi.json", nil)
 	rec := httptest.NewRecorder()
 	next := &mockNextHandler{}
 
-	// This will work even without handler since we just check routing
 	d.ServeHTTP(rec, req, next)
 
-	// OpenAPI should not require auth (but may fail if handler is nil)
-	// The point is it shouldn't call next handler
+	// OpenAPI should not require auth and shouldn't call next handler
 	if next.called {
 		t.Error("OpenAPI endpoint should not call next handler")
 	}
+	if rec.Code != http.StatusOK {
+		t.Errorf("Expected status 200 without an API key, got %d", rec.Code)
+	}
 }
 
 // Benchmark tests
@@ -472,6 +913,12 @@ func provisionForTest(d *DuckDB) error {
 	}
 	// Remove trailing slash if present
 	d.routePrefix = strings.TrimSuffix(d.routePrefix, "/")
+	d.headerScopes = d.responseHeaderScopes()
+	var err error
+	d.requiredHeaders, err = compileRequiredHeaders(d.RequiredHeaders)
+	if err != nil {
+		return err
+	}
 
 	if d.QueryTimeout == 0 {
 		d.QueryTimeout = caddy.Duration(10_000_000_000) // 10 seconds
@@ -488,24 +935,60 @@ func provisionForTest(d *DuckDB) error {
 	if d.AccessMode == "" {
 		d.AccessMode = "read_write"
 	}
+	if d.MaxColumns == 0 {
+		d.MaxColumns = 1000
+	}
+	if d.ErrorDetail == "" {
+		d.ErrorDetail = handlers.ErrorDetailFull
+	}
+	if d.TrailingSlash == "" {
+		d.TrailingSlash = TrailingSlashStrip
+	}
+	if d.CSVCharset == "" {
+		d.CSVCharset = formats.DefaultCharset
+	}
+	if d.JSONKeyCase == "" {
+		d.JSONKeyCase = formats.KeyCaseNone
+	}
+	if d.ResponseShape == "" {
+		d.ResponseShape = formats.ShapeDefault
+	}
+	if d.OnRowLimit == "" {
+		d.OnRowLimit = handlers.RowLimitTruncate
+	}
 
 	// Validate AuthDatabasePath
 	if d.AuthDatabasePath == "" {
 		return fmt.Errorf("auth_database_path is required")
 	}
 
+	// Compile per-table validation rules
+	for name, cfg := range d.Tables {
+		if cfg == nil {
+			continue
+		}
+		if err := cfg.Provision(); err != nil {
+			return fmt.Errorf("invalid config for table '%s': %v", name, err)
+		}
+	}
+
 	// Initialize database manager (using testing version that creates schema)
-	var err error
 	d.dbMgr, err = database.NewManagerForTesting(database.Config{
-		MainDBPath:        d.DatabasePath,
-		AuthDBPath:        d.AuthDatabasePath,
-		Threads:           d.Threads,
-		AccessMode:        d.AccessMode,
-		MemoryLimit:       d.MemoryLimit,
-		EnableObjectCache: d.EnableObjectCache,
-		TempDirectory:     d.TempDirectory,
-		QueryTimeout:      time.Duration(d.QueryTimeout),
-		Logger:            d.logger,
+		MainDBPath:         d.DatabasePath,
+		AuthDBPath:         d.AuthDatabasePath,
+		Threads:            d.Threads,
+		AccessMode:         d.AccessMode,
+		MemoryLimit:        d.MemoryLimit,
+		EnableObjectCache:  d.EnableObjectCache,
+		TempDirectory:      d.TempDirectory,
+		QueryTimeout:       time.Duration(d.QueryTimeout),
+		AcquireTimeout:     time.Duration(d.ConnectionAcquireTimeout),
+		QueryTagging:       d.QueryTagging,
+		QueryPlanCacheSize: d.QueryPlanCache,
+		QueryCacheTTL:      time.Duration(d.QueryCacheTTL),
+		ReadPoolSize:       d.ReadPoolSize,
+		TablePools:         d.TablePools,
+		Logger:             d.logger,
 	})
 	if err != nil {
 		return fmt.Errorf("failed to initialize database manager: %v", err)
@@ -514,11 +997,61 @@ func provisionForTest(d *DuckDB) error {
 	// Initialize authorizer
 	d.authorizer = auth.NewAuthorizer(d.dbMgr.AuthDB())
 	d.authMw = auth.NewMiddleware(d.authorizer)
+	d.authMw.SetAPIKeyHeader(d.APIKeyHeader)
+	d.authMw.SetRateLimiter(d.RateLimit.rateLimiter())
 
 	// Initialize handlers
 	d.crudHandler = handlers.NewCRUDHandler(d.dbMgr, d.authorizer, d.MaxRowsPerPage, d.AbsoluteMaxRows, d.logger)
+	d.crudHandler.SetTableConfigs(d.Tables)
+	d.crudHandler.SetCSVCharset(d.CSVCharset, d.RejectUnsupportedCharset)
+	d.crudHandler.SetAutoCreateTables(d.AutoCreateTables)
+	d.crudHandler.SetJSONKeyCase(d.JSONKeyCase)
+	d.crudHandler.SetDecimalAsString(d.decimalAsString())
+	d.crudHandler.SetMaxColumns(d.MaxColumns)
+	d.crudHandler.SetPaginationPolicy(d.Pagination)
+	d.crudHandler.SetErrorDetail(d.ErrorDetail)
+	d.crudHandler.SetDebugSQL(d.DebugSQL)
+	d.crudHandler.SetResponseShape(d.ResponseShape)
+	d.crudHandler.SetRowLimitPolicy(d.OnRowLimit)
+	d.crudHandler.SetArrowDictionaryThreshold(d.ArrowDictionaryThreshold)
+	d.crudHandler.SetParquetRowGroupSize(d.ParquetRowGroupSize)
+	d.crudHandler.SetCoalesceReads(d.CoalesceReads)
+	d.crudHandler.SetMaintenanceMode(d.MaintenanceMode)
+	streams := auth.NewStreamLimiter(d.MaxStreamsPerKey)
+	d.crudHandler.SetStreamLimiter(streams)
 	d.queryHandler = handlers.NewQueryHandler(d.dbMgr, d.authorizer, d.logger)
+	d.queryHandler.SetAbsoluteMaxRows(d.AbsoluteMaxRows)
+	d.queryHandler.SetCSVCharset(d.CSVCharset, d.RejectUnsupportedCharset)
+	d.queryHandler.SetStreamLimiter(streams)
+	d.queryHandler.SetErrorDetail(d.ErrorDetail)
+	d.queryHandler.SetDebugSQL(d.DebugSQL)
+	d.queryHandler.SetResponseShape(d.ResponseShape)
+	d.queryHandler.SetDecimalAsString(d.decimalAsString())
+	d.queryHandler.SetArrowDictionaryThreshold(d.ArrowDictionaryThreshold)
+	d.queryHandler.SetParquetRowGroupSize(d.ParquetRowGroupSize)
+	d.queryHandler.SetValidateTables(d.ValidateQueryTables)
+	d.queryHandler.SetCoalesceReads(d.CoalesceReads)
+	d.queryHandler.SetMaintenanceMode(d.MaintenanceMode)
+	d.catalogHandler = handlers.NewCatalogHandler(d.dbMgr, d.authorizer, d.logger)
+	d.catalogHandler.SetErrorDetail(d.ErrorDetail)
 	d.openAPIHandler = handlers.NewOpenAPIHandler()
+	d.openAPIHandler.SetAPIKeyHeader(d.APIKeyHeader)
+	d.openAPIHandler.SetTableSource(d.dbMgr, d.authorizer)
+	d.openAPIHandler.SetDecimalAsString(d.decimalAsString())
+	if d.DownloadTokenTTL > 0 {
+		if err := d.authorizer.InitDownloadTokens(); err != nil {
+			return err
+		}
+		d.crudHandler.SetDownloadTokenTTL(time.Duration(d.DownloadTokenTTL))
+		d.downloads = handlers.NewDownloadHandler(d.authorizer, d.crudHandler, d.logger)
+	}
+	if d.IndexAdvisor != nil && d.IndexAdvisor.Enabled {
+		d.indexAdvisor = handlers.NewIndexAdvisor(d.dbMgr, *d.IndexAdvisor, d.logger)
+		d.crudHandler.SetIndexAdvisor(d.indexAdvisor)
+	}
+	if d.Maintenance != nil {
+		d.maintenance = d.dbMgr.StartMaintenance(time.Duration(d.Maintenance.Interval), d.Maintenance.Analyze)
+	}
 
 	return nil
 }
@@ -700,6 +1233,7 @@ func TestUnmarshalCaddyfile_AllOptions(t *testing.T) {
 		database_path /path/to/main.db
 		auth_database_path /path/to/auth.db
 		query_timeout 30s
+		connection_acquire_timeout 250ms
 		max_rows_per_page 200
 		absolute_max_rows 50000
 		threads 8
@@ -726,6 +1260,9 @@ func TestUnmarshalCaddyfile_AllOptions(t *testing.T) {
 	if d.QueryTimeout != caddy.Duration(30*time.Second) {
 		t.Errorf("Expected query_timeout 30s, got %v", time.Duration(d.QueryTimeout))
 	}
+	if d.ConnectionAcquireTimeout != caddy.Duration(250*time.Millisecond) {
+		t.Errorf("Expected connection_acquire_timeout 250ms, got %v", time.Duration(d.ConnectionAcquireTimeout))
+	}
 	if d.MaxRowsPerPage != 200 {
 		t.Errorf("Expected max_rows_per_page 200, got %d", d.MaxRowsPerPage)
 	}
@@ -924,42 +1461,815 @@ func TestUnmarshalCaddyfile_InMemoryDB(t *testing.T) {
 	}
 }
 
-// ===========================
-// Additional ServeHTTP Tests
-// ===========================
+func TestUnmarshalCaddyfile_TableSoftDelete(t *testing.T) {
+	input := `duckdb {
+		auth_database_path /path/to/auth.db
+		table users {
+			soft_delete
+		}
+		table orders {
+			soft_delete removed_at
+		}
+	}`
 
-func TestServeHTTP_QueryEndpoint_WithAuth(t *testing.T) {
-	d, cleanup := setupTestModule(t)
-	defer cleanup()
+	dispenser := caddyfile.NewTestDispenser(input)
+	d := &DuckDB{}
+	err := d.UnmarshalCaddyfile(dispenser)
+	if err != nil {
+		t.Fatalf("UnmarshalCaddyfile failed: %v", err)
+	}
 
-	// Properly initialize the query handler
-	d.queryHandler = handlers.NewQueryHandler(d.dbMgr, d.authorizer, d.logger)
+	if len(d.Tables) != 2 {
+		t.Fatalf("Expected 2 table configs, got %d", len(d.Tables))
+	}
+	if d.Tables["users"].SoftDeleteColumn != "deleted_at" {
+		t.Errorf("Expected default soft_delete column 'deleted_at', got '%s'", d.Tables["users"].SoftDeleteColumn)
+	}
+	if d.Tables["orders"].SoftDeleteColumn != "removed_at" {
+		t.Errorf("Expected soft_delete column 'removed_at', got '%s'", d.Tables["orders"].SoftDeleteColumn)
+	}
+}
 
-	req := httptest.NewRequest("POST", "/duckdb/query", strings.NewReader(`{"sql":"SELECT 1"}`))
-	req.Header.Set("X-API-Key", "test-api-key")
-	req.Header.Set("Content-Type", "application/json")
-	rec := httptest.NewRecorder()
-	next := &mockNextHandler{}
+func TestUnmarshalCaddyfile_TableValidate(t *testing.T) {
+	input := `duckdb {
+		table users {
+			validate age min 0
+			validate age max 150
+			validate status enum active inactive
+			validate email regex ^[^@]+@[^@]+$
+		}
+	}`
 
-	err := d.ServeHTTP(rec, req, next)
+	dispenser := caddyfile.NewTestDispenser(input)
+	d := &DuckDB{}
+	err := d.UnmarshalCaddyfile(dispenser)
 	if err != nil {
-		t.Errorf("ServeHTTP returned error: %v", err)
+		t.Fatalf("UnmarshalCaddyfile failed: %v", err)
 	}
 
-	// Should have called query handler, not next handler
-	if next.called {
-		t.Error("Query endpoint should not call next handler")
+	rules := d.Tables["users"].Rules
+	if len(rules) != 4 {
+		t.Fatalf("Expected 4 rules, got %d", len(rules))
 	}
-
-	// Should return OK for valid query
-	if rec.Code != http.StatusOK {
-		t.Errorf("Expected status 200, got %d. Body: %s", rec.Code, rec.Body.String())
+	if rules[2].Rule != handlers.RuleEnum || len(rules[2].Values) != 2 {
+		t.Errorf("Expected enum rule with 2 values, got %v", rules[2])
+	}
+	if err := d.Tables["users"].Provision(); err != nil {
+		t.Errorf("Expected rules to compile, got: %v", err)
 	}
 }
 
-func TestServeHTTP_CRUDEndpoint_WithAuth(t *testing.T) {
-	d, cleanup := setupTestModule(t)
-	defer cleanup()
+func TestUnmarshalCaddyfile_TableJSONSchema(t *testing.T) {
+	input := `duckdb {
+		table users {
+			json_schema /etc/caddy/schemas/users.json
+		}
+	}`
+
+	dispenser := caddyfile.NewTestDispenser(input)
+	d := &DuckDB{}
+	if err := d.UnmarshalCaddyfile(dispenser); err != nil {
+		t.Fatalf("UnmarshalCaddyfile failed: %v", err)
+	}
+	if d.Tables["users"].JSONSchema != "/etc/caddy/schemas/users.json" {
+		t.Errorf("Expected json_schema path, got '%s'", d.Tables["users"].JSONSchema)
+	}
+}
+
+func TestUnmarshalCaddyfile_TableFilterableSortable(t *testing.T) {
+	input := `duckdb {
+		table users {
+			filterable id status
+			filterable created_at
+			sortable created_at
+		}
+	}`
+
+	dispenser := caddyfile.NewTestDispenser(input)
+	d := &DuckDB{}
+	if err := d.UnmarshalCaddyfile(dispenser); err != nil {
+		t.Fatalf("UnmarshalCaddyfile failed: %v", err)
+	}
+
+	cfg := d.Tables["users"]
+	if len(cfg.Filterable) != 3 || cfg.Filterable[2] != "created_at" {
+		t.Errorf("Expected 3 filterable columns, got %v", cfg.Filterable)
+	}
+	if len(cfg.Sortable) != 1 || cfg.Sortable[0] != "created_at" {
+		t.Errorf("Expected sortable [created_at], got %v", cfg.Sortable)
+	}
+
+	dispenser = caddyfile.NewTestDispenser("duckdb {\n\ttable users {\n\t\tsortable\n\t}\n}")
+	if err := (&DuckDB{}).UnmarshalCaddyfile(dispenser); err == nil {
+		t.Error("Expected error for sortable without columns")
+	}
+}
+
+func TestUnmarshalCaddyfile_TableChangeColumn(t *testing.T) {
+	dispenser := caddyfile.NewTestDispenser(`duckdb {
+		table events {
+			change_column updated_at
+		}
+	}`)
+	d := &DuckDB{}
+	if err := d.UnmarshalCaddyfile(dispenser); err != nil {
+		t.Fatalf("UnmarshalCaddyfile failed: %v", err)
+	}
+	if got := d.Tables["events"].ChangeColumn; got != "updated_at" {
+		t.Errorf("Expected change_column 'updated_at', got '%s'", got)
+	}
+}
+
+func TestUnmarshalCaddyfile_TableHashColumns(t *testing.T) {
+	dispenser := caddyfile.NewTestDispenser(`duckdb {
+		table users {
+			hash_columns name email
+		}
+	}`)
+	d := &DuckDB{}
+	if err := d.UnmarshalCaddyfile(dispenser); err != nil {
+		t.Fatalf("UnmarshalCaddyfile failed: %v", err)
+	}
+	if got := d.Tables["users"].HashColumns; len(got) != 2 || got[0] != "name" || got[1] != "email" {
+		t.Errorf("Expected hash_columns [name email], got %v", got)
+	}
+}
+
+func TestUnmarshalCaddyfile_Pagination(t *testing.T) {
+	dispenser := caddyfile.NewTestDispenser(`duckdb {
+		pagination page
+		table events {
+			pagination CURSOR 5000
+		}
+	}`)
+	d := &DuckDB{}
+	if err := d.UnmarshalCaddyfile(dispenser); err != nil {
+		t.Fatalf("UnmarshalCaddyfile failed: %v", err)
+	}
+	if d.Pagination == nil || d.Pagination.Style != handlers.PaginationStylePage {
+		t.Errorf("Expected global page pagination, got %+v", d.Pagination)
+	}
+	got := d.Tables["events"].Pagination
+	if got == nil || got.Style != handlers.PaginationStyleCursor || got.MaxOffset != 5000 {
+		t.Errorf("Expected cursor pagination with max_offset 5000, got %+v", got)
+	}
+
+	dispenser = caddyfile.NewTestDispenser(`duckdb {
+		pagination cursor lots
+	}`)
+	if err := (&DuckDB{}).UnmarshalCaddyfile(dispenser); err == nil {
+		t.Error("Expected error for non-numeric max_offset")
+	}
+}
+
+func TestUnmarshalCaddyfile_TableDerived(t *testing.T) {
+	input := `duckdb {
+		table users {
+			derived full_name "CONCAT(first_name, ' ', last_name)"
+		}
+	}`
+
+	dispenser := caddyfile.NewTestDispenser(input)
+	d := &DuckDB{}
+	if err := d.UnmarshalCaddyfile(dispenser); err != nil {
+		t.Fatalf("UnmarshalCaddyfile failed: %v", err)
+	}
+
+	derived := d.Tables["users"].Derived
+	if len(derived) != 1 || derived[0].Name != "full_name" || derived[0].Expression != "CONCAT(first_name, ' ', last_name)" {
+		t.Errorf("Unexpected derived columns: %+v", derived)
+	}
+
+	dispenser = caddyfile.NewTestDispenser("duckdb {\n\ttable users {\n\t\tderived full_name CONCAT(a, b)\n\t}\n}")
+	if err := (&DuckDB{}).UnmarshalCaddyfile(dispenser); err == nil {
+		t.Error("Expected error for unquoted derived expression")
+	}
+}
+
+func TestUnmarshalCaddyfile_TableValidateInvalid(t *testing.T) {
+	testCases := []struct {
+		name  string
+		input string
+	}{
+		{"missing value", "duckdb {\n\ttable users {\n\t\tvalidate age min\n\t}\n}"},
+		{"unknown rule", "duckdb {\n\ttable users {\n\t\tvalidate age between 1 2\n\t}\n}"},
+		{"too many values", "duckdb {\n\ttable users {\n\t\tvalidate age max 1 2\n\t}\n}"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			dispenser := caddyfile.NewTestDispenser(tc.input)
+			d := &DuckDB{}
+			if err := d.UnmarshalCaddyfile(dispenser); err == nil {
+				t.Errorf("Expected error for %s", tc.name)
+			}
+		})
+	}
+}
+
+func TestUnmarshalCaddyfile_TableUnknownSubdirective(t *testing.T) {
+	input := `duckdb {
+		table users {
+			unknown_option value
+		}
+	}`
+
+	dispenser := caddyfile.NewTestDispenser(input)
+	d := &DuckDB{}
+	err := d.UnmarshalCaddyfile(dispenser)
+	if err == nil {
+		t.Fatal("Expected error for unknown table subdirective")
+	}
+	if !strings.Contains(err.Error(), "unknown table subdirective") {
+		t.Errorf("Expected 'unknown table subdirective' error, got: %v", err)
+	}
+}
+
+func TestValidate_InvalidTableConfig(t *testing.T) {
+	d := &DuckDB{
+		AccessMode:      "read_write",
+		MaxRowsPerPage:  100,
+		AbsoluteMaxRows: 1000,
+		Threads:         4,
+		Tables: map[string]*handlers.TableConfig{
+			"users": {SoftDeleteColumn: "deleted_at; DROP TABLE users"},
+		},
+	}
+
+	if err := d.Validate(); err == nil {
+		t.Error("Expected error for invalid soft_delete column")
+	}
+}
+
+func TestUnmarshalCaddyfile_CSVCharset(t *testing.T) {
+	input := `duckdb {
+		csv_charset windows-1252
+		reject_unsupported_charset true
+	}`
+
+	dispenser := caddyfile.NewTestDispenser(input)
+	d := &DuckDB{}
+	if err := d.UnmarshalCaddyfile(dispenser); err != nil {
+		t.Fatalf("UnmarshalCaddyfile failed: %v", err)
+	}
+
+	if d.CSVCharset != "windows-1252" {
+		t.Errorf("Expected csv_charset 'windows-1252', got '%s'", d.CSVCharset)
+	}
+	if !d.RejectUnsupportedCharset {
+		t.Error("Expected reject_unsupported_charset to be true")
+	}
+}
+
+func TestUnmarshalCaddyfile_AutoCreateTables(t *testing.T) {
+	input := `duckdb {
+		auto_create_tables yes
+	}`
+
+	dispenser := caddyfile.NewTestDispenser(input)
+	d := &DuckDB{}
+	if err := d.UnmarshalCaddyfile(dispenser); err != nil {
+		t.Fatalf("UnmarshalCaddyfile failed: %v", err)
+	}
+
+	if !d.AutoCreateTables {
+		t.Error("Expected auto_create_tables to be true")
+	}
+}
+
+func TestUnmarshalCaddyfile_JSONKeyCase(t *testing.T) {
+	dispenser := caddyfile.NewTestDispenser(`duckdb {
+		json_key_case Camel
+	}`)
+	d := &DuckDB{AccessMode: "read_write", MaxRowsPerPage: 100, Threads: 1}
+	if err := d.UnmarshalCaddyfile(dispenser); err != nil {
+		t.Fatalf("UnmarshalCaddyfile failed: %v", err)
+	}
+	if d.JSONKeyCase != "camel" {
+		t.Errorf("Expected json_key_case 'camel', got '%s'", d.JSONKeyCase)
+	}
+	if err := d.Validate(); err != nil {
+		t.Errorf("Expected valid config, got error: %v", err)
+	}
+
+	d.JSONKeyCase = "kebab"
+	if err := d.Validate(); err == nil {
+		t.Error("Expected error for unsupported json_key_case")
+	}
+}
+
+func TestUnmarshalCaddyfile_IndexAdvisor(t *testing.T) {
+	dispenser := caddyfile.NewTestDispenser(`duckdb {
+		index_advisor {
+			min_uses 50
+			auto_create true
+			max_auto_indexes 3
+		}
+	}`)
+	d := &DuckDB{AccessMode: "read_write", MaxRowsPerPage: 100, Threads: 1}
+	if err := d.UnmarshalCaddyfile(dispenser); err != nil {
+		t.Fatalf("UnmarshalCaddyfile failed: %v", err)
+	}
+	cfg := d.IndexAdvisor
+	if cfg == nil || !cfg.Enabled || cfg.MinUses != 50 || !cfg.AutoCreate || cfg.MaxAutoIndexes != 3 {
+		t.Fatalf("Unexpected index_advisor config: %+v", cfg)
+	}
+	if err := d.Validate(); err != nil {
+		t.Errorf("Expected valid config, got error: %v", err)
+	}
+
+	// Indexes cannot be created in a read-only database
+	d.AccessMode = "read_only"
+	if err := d.Validate(); err == nil {
+		t.Error("Expected error for auto_create with read_only access mode")
+	}
+
+	dispenser = caddyfile.NewTestDispenser(`duckdb {
+		index_advisor {
+			min_uses often
+		}
+	}`)
+	if err := (&DuckDB{}).UnmarshalCaddyfile(dispenser); err == nil {
+		t.Error("Expected error for non-numeric min_uses")
+	}
+}
+
+func TestUnmarshalCaddyfile_ParquetRowGroupSize(t *testing.T) {
+	dispenser := caddyfile.NewTestDispenser(`duckdb {
+		parquet_row_group_size 50000
+	}`)
+	d := &DuckDB{AccessMode: "read_write", MaxRowsPerPage: 100, Threads: 1}
+	if err := d.UnmarshalCaddyfile(dispenser); err != nil {
+		t.Fatalf("UnmarshalCaddyfile failed: %v", err)
+	}
+	if d.ParquetRowGroupSize != 50000 {
+		t.Errorf("Expected parquet_row_group_size 50000, got %d", d.ParquetRowGroupSize)
+	}
+
+	d.ParquetRowGroupSize = -1
+	if err := d.Validate(); err == nil {
+		t.Error("Expected error for negative parquet_row_group_size")
+	}
+
+	dispenser = caddyfile.NewTestDispenser(`duckdb {
+		parquet_row_group_size large
+	}`)
+	if err := (&DuckDB{}).UnmarshalCaddyfile(dispenser); err == nil {
+		t.Error("Expected error for non-numeric parquet_row_group_size")
+	}
+}
+
+func TestUnmarshalCaddyfile_ArrowDictionaryThreshold(t *testing.T) {
+	dispenser := caddyfile.NewTestDispenser(`duckdb {
+		arrow_dictionary_threshold 256
+	}`)
+	d := &DuckDB{AccessMode: "read_write", MaxRowsPerPage: 100, Threads: 1}
+	if err := d.UnmarshalCaddyfile(dispenser); err != nil {
+		t.Fatalf("UnmarshalCaddyfile failed: %v", err)
+	}
+	if d.ArrowDictionaryThreshold != 256 {
+		t.Errorf("Expected arrow_dictionary_threshold 256, got %d", d.ArrowDictionaryThreshold)
+	}
+
+	d.ArrowDictionaryThreshold = -1
+	if err := d.Validate(); err == nil {
+		t.Error("Expected error for negative arrow_dictionary_threshold")
+	}
+
+	dispenser = caddyfile.NewTestDispenser(`duckdb {
+		arrow_dictionary_threshold many
+	}`)
+	if err := (&DuckDB{}).UnmarshalCaddyfile(dispenser); err == nil {
+		t.Error("Expected error for non-numeric arrow_dictionary_threshold")
+	}
+}
+
+func TestUnmarshalCaddyfile_ResponseShape(t *testing.T) {
+	dispenser := caddyfile.NewTestDispenser(`duckdb {
+		response_shape Result
+		table legacy_orders {
+			response_shape items
+		}
+	}`)
+	d := &DuckDB{AccessMode: "read_write", MaxRowsPerPage: 100, Threads: 1}
+	if err := d.UnmarshalCaddyfile(dispenser); err != nil {
+		t.Fatalf("UnmarshalCaddyfile failed: %v", err)
+	}
+	if d.ResponseShape != "result" {
+		t.Errorf("Expected response_shape 'result', got '%s'", d.ResponseShape)
+	}
+	if cfg := d.Tables["legacy_orders"]; cfg == nil || cfg.ResponseShape != "items" {
+		t.Errorf("Expected table response_shape 'items', got %+v", cfg)
+	}
+	if err := d.Validate(); err != nil {
+		t.Errorf("Expected valid config, got error: %v", err)
+	}
+
+	d.ResponseShape = "template"
+	if err := d.Validate(); err == nil {
+		t.Error("Expected error for unsupported response_shape")
+	}
+	d.ResponseShape = ""
+	d.Tables["legacy_orders"].ResponseShape = "template"
+	if err := d.Validate(); err == nil {
+		t.Error("Expected error for unsupported table response_shape")
+	}
+}
+
+func TestUnmarshalCaddyfile_OnRowLimit(t *testing.T) {
+	dispenser := caddyfile.NewTestDispenser(`duckdb {
+		on_row_limit Error
+	}`)
+	d := &DuckDB{AccessMode: "read_write", MaxRowsPerPage: 100, Threads: 1}
+	if err := d.UnmarshalCaddyfile(dispenser); err != nil {
+		t.Fatalf("UnmarshalCaddyfile failed: %v", err)
+	}
+	if d.OnRowLimit != handlers.RowLimitError {
+		t.Errorf("Expected on_row_limit 'error', got '%s'", d.OnRowLimit)
+	}
+	if err := d.Validate(); err != nil {
+		t.Errorf("Expected valid config, got error: %v", err)
+	}
+
+	d.OnRowLimit = "drop"
+	if err := d.Validate(); err == nil || !strings.Contains(err.Error(), "on_row_limit") {
+		t.Errorf("Expected an on_row_limit error, got %v", err)
+	}
+}
+
+func TestUnmarshalCaddyfile_QueryTagging(t *testing.T) {
+	dispenser := caddyfile.NewTestDispenser(`duckdb {
+		query_tagging yes
+	}`)
+	d := &DuckDB{}
+	if err := d.UnmarshalCaddyfile(dispenser); err != nil {
+		t.Fatalf("UnmarshalCaddyfile failed: %v", err)
+	}
+	if !d.QueryTagging {
+		t.Error("Expected query_tagging to be true")
+	}
+}
+
+func TestUnmarshalCaddyfile_QueryPlanCache(t *testing.T) {
+	dispenser := caddyfile.NewTestDispenser(`duckdb {
+		query_plan_cache 128
+	}`)
+	d := &DuckDB{}
+	if err := d.UnmarshalCaddyfile(dispenser); err != nil {
+		t.Fatalf("UnmarshalCaddyfile failed: %v", err)
+	}
+	if d.QueryPlanCache != 128 {
+		t.Errorf("Expected query_plan_cache 128, got %d", d.QueryPlanCache)
+	}
+
+	invalid := caddyfile.NewTestDispenser(`duckdb {
+		query_plan_cache many
+	}`)
+	if err := (&DuckDB{}).UnmarshalCaddyfile(invalid); err == nil {
+		t.Error("Expected error for non-numeric query_plan_cache")
+	}
+}
+
+func TestUnmarshalCaddyfile_QueryCacheTTL(t *testing.T) {
+	dispenser := caddyfile.NewTestDispenser(`duckdb {
+		query_cache_ttl 5s
+	}`)
+	d := &DuckDB{}
+	if err := d.UnmarshalCaddyfile(dispenser); err != nil {
+		t.Fatalf("UnmarshalCaddyfile failed: %v", err)
+	}
+	if d.QueryCacheTTL != caddy.Duration(5*time.Second) {
+		t.Errorf("Expected query_cache_ttl 5s, got %v", time.Duration(d.QueryCacheTTL))
+	}
+
+	invalid := caddyfile.NewTestDispenser(`duckdb {
+		query_cache_ttl soon
+	}`)
+	if err := (&DuckDB{}).UnmarshalCaddyfile(invalid); err == nil {
+		t.Error("Expected error for invalid query_cache_ttl")
+	}
+}
+
+func TestUnmarshalCaddyfile_ValidateQueryTables(t *testing.T) {
+	dispenser := caddyfile.NewTestDispenser(`duckdb {
+		validate_query_tables true
+	}`)
+	d := &DuckDB{}
+	if err := d.UnmarshalCaddyfile(dispenser); err != nil {
+		t.Fatalf("UnmarshalCaddyfile failed: %v", err)
+	}
+	if !d.ValidateQueryTables {
+		t.Error("Expected validate_query_tables to be true")
+	}
+}
+
+func TestUnmarshalCaddyfile_CoalesceReads(t *testing.T) {
+	dispenser := caddyfile.NewTestDispenser(`duckdb {
+		coalesce_reads yes
+	}`)
+	d := &DuckDB{}
+	if err := d.UnmarshalCaddyfile(dispenser); err != nil {
+		t.Fatalf("UnmarshalCaddyfile failed: %v", err)
+	}
+	if !d.CoalesceReads {
+		t.Error("Expected coalesce_reads to be true")
+	}
+}
+
+func TestUnmarshalCaddyfile_DownloadTokenTTL(t *testing.T) {
+	dispenser := caddyfile.NewTestDispenser(`duckdb {
+		download_token_ttl 10m
+	}`)
+	d := &DuckDB{}
+	if err := d.UnmarshalCaddyfile(dispenser); err != nil {
+		t.Fatalf("UnmarshalCaddyfile failed: %v", err)
+	}
+	if time.Duration(d.DownloadTokenTTL) != 10*time.Minute {
+		t.Errorf("Expected download_token_ttl 10m, got %v", time.Duration(d.DownloadTokenTTL))
+	}
+
+	dispenser = caddyfile.NewTestDispenser(`duckdb {
+		download_token_ttl soon
+	}`)
+	if err := (&DuckDB{}).UnmarshalCaddyfile(dispenser); err == nil {
+		t.Error("Expected error for invalid download_token_ttl")
+	}
+}
+
+func TestUnmarshalCaddyfile_Maintenance(t *testing.T) {
+	dispenser := caddyfile.NewTestDispenser(`duckdb {
+		maintenance {
+			interval 1h
+			analyze true
+		}
+	}`)
+	d := &DuckDB{}
+	if err := d.UnmarshalCaddyfile(dispenser); err != nil {
+		t.Fatalf("UnmarshalCaddyfile failed: %v", err)
+	}
+	if d.Maintenance == nil {
+		t.Fatal("Expected maintenance config to be set")
+	}
+	if time.Duration(d.Maintenance.Interval) != time.Hour {
+		t.Errorf("Expected maintenance interval 1h, got %v", time.Duration(d.Maintenance.Interval))
+	}
+	if !d.Maintenance.Analyze {
+		t.Error("Expected maintenance analyze to be enabled")
+	}
+
+	dispenser = caddyfile.NewTestDispenser(`duckdb {
+		maintenance {
+			vacuum true
+		}
+	}`)
+	if err := (&DuckDB{}).UnmarshalCaddyfile(dispenser); err == nil {
+		t.Error("Expected error for unknown maintenance subdirective")
+	}
+}
+
+func TestUnmarshalCaddyfile_DebugSQL(t *testing.T) {
+	dispenser := caddyfile.NewTestDispenser(`duckdb {
+		debug_sql {
+			roles admin developer
+			redact password api_token
+		}
+	}`)
+	d := &DuckDB{}
+	if err := d.UnmarshalCaddyfile(dispenser); err != nil {
+		t.Fatalf("UnmarshalCaddyfile failed: %v", err)
+	}
+	if d.DebugSQL == nil {
+		t.Fatal("Expected debug_sql config to be set")
+	}
+	if strings.Join(d.DebugSQL.Roles, ",") != "admin,developer" {
+		t.Errorf("Unexpected debug_sql roles: %v", d.DebugSQL.Roles)
+	}
+	if strings.Join(d.DebugSQL.Redact, ",") != "password,api_token" {
+		t.Errorf("Unexpected debug_sql redact columns: %v", d.DebugSQL.Redact)
+	}
+
+	dispenser = caddyfile.NewTestDispenser(`duckdb {
+		debug_sql {
+			roles
+		}
+	}`)
+	if err := (&DuckDB{}).UnmarshalCaddyfile(dispenser); err == nil {
+		t.Error("Expected error for debug_sql roles without arguments")
+	}
+}
+
+func TestProvision_Maintenance(t *testing.T) {
+	dir := t.TempDir()
+	d := &DuckDB{
+		DatabasePath:     filepath.Join(dir, "main.db"),
+		AuthDatabasePath: filepath.Join(dir, "auth.db"),
+		AccessMode:       "read_write",
+		QueryTimeout:     caddy.Duration(10 * time.Second),
+		Threads:          1,
+		MaxRowsPerPage:   50,
+		Maintenance:      &MaintenanceConfig{Interval: caddy.Duration(20 * time.Millisecond)},
+	}
+	if err := provisionForTest(d); err != nil {
+		t.Fatalf("Provision failed: %v", err)
+	}
+	if d.maintenance == nil {
+		d.Cleanup()
+		t.Fatal("Expected maintenance to be started for a file database")
+	}
+
+	deadline := time.Now().Add(5 * time.Second)
+	for d.maintenance.Runs() == 0 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if d.maintenance.Runs() == 0 {
+		t.Error("Expected maintenance to have run")
+	}
+	if err := d.Cleanup(); err != nil {
+		t.Errorf("Cleanup failed: %v", err)
+	}
+}
+
+func TestUnmarshalCaddyfile_ErrorDetail(t *testing.T) {
+	dispenser := caddyfile.NewTestDispenser(`duckdb {
+		error_detail Safe
+	}`)
+	d := &DuckDB{}
+	if err := d.UnmarshalCaddyfile(dispenser); err != nil {
+		t.Fatalf("UnmarshalCaddyfile failed: %v", err)
+	}
+	if d.ErrorDetail != handlers.ErrorDetailSafe {
+		t.Errorf("Expected error_detail safe, got %q", d.ErrorDetail)
+	}
+}
+
+func TestUnmarshalCaddyfile_MaxColumns(t *testing.T) {
+	dispenser := caddyfile.NewTestDispenser(`duckdb {
+		max_columns 200
+	}`)
+	d := &DuckDB{}
+	if err := d.UnmarshalCaddyfile(dispenser); err != nil {
+		t.Fatalf("UnmarshalCaddyfile failed: %v", err)
+	}
+	if d.MaxColumns != 200 {
+		t.Errorf("Expected max_columns 200, got %d", d.MaxColumns)
+	}
+
+	dispenser = caddyfile.NewTestDispenser(`duckdb {
+		max_columns lots
+	}`)
+	if err := (&DuckDB{}).UnmarshalCaddyfile(dispenser); err == nil {
+		t.Error("Expected error for non-numeric max_columns")
+	}
+}
+
+func TestUnmarshalCaddyfile_ReadPoolSize(t *testing.T) {
+	dispenser := caddyfile.NewTestDispenser(`duckdb {
+		read_pool_size 8
+	}`)
+	d := &DuckDB{}
+	if err := d.UnmarshalCaddyfile(dispenser); err != nil {
+		t.Fatalf("UnmarshalCaddyfile failed: %v", err)
+	}
+	if d.ReadPoolSize != 8 {
+		t.Errorf("Expected read_pool_size 8, got %d", d.ReadPoolSize)
+	}
+
+	dispenser = caddyfile.NewTestDispenser(`duckdb {
+		read_pool_size lots
+	}`)
+	if err := (&DuckDB{}).UnmarshalCaddyfile(dispenser); err == nil {
+		t.Error("Expected error for non-numeric read_pool_size")
+	}
+}
+
+func TestUnmarshalCaddyfile_TablePool(t *testing.T) {
+	dispenser := caddyfile.NewTestDispenser(`duckdb {
+		table_pool hot 2 events clicks
+		table_pool bulk 1 imports
+	}`)
+	d := &DuckDB{}
+	if err := d.UnmarshalCaddyfile(dispenser); err != nil {
+		t.Fatalf("UnmarshalCaddyfile failed: %v", err)
+	}
+	if len(d.TablePools) != 2 {
+		t.Fatalf("Expected 2 table pools, got %d", len(d.TablePools))
+	}
+	if p := d.TablePools[0]; p.Name != "hot" || p.Size != 2 || len(p.Tables) != 2 || p.Tables[1] != "clicks" {
+		t.Errorf("Unexpected table pool: %+v", p)
+	}
+
+	for _, input := range []string{"table_pool hot 2", "table_pool hot many events"} {
+		dispenser = caddyfile.NewTestDispenser("duckdb {\n" + input + "\n}")
+		if err := (&DuckDB{}).UnmarshalCaddyfile(dispenser); err == nil {
+			t.Errorf("Expected error for %q", input)
+		}
+	}
+}
+
+func TestUnmarshalCaddyfile_DecimalAsString(t *testing.T) {
+	d := &DuckDB{}
+	if !d.decimalAsString() {
+		t.Error("Expected decimal_as_string to default to true")
+	}
+
+	dispenser := caddyfile.NewTestDispenser(`duckdb {
+		decimal_as_string false
+	}`)
+	if err := d.UnmarshalCaddyfile(dispenser); err != nil {
+		t.Fatalf("UnmarshalCaddyfile failed: %v", err)
+	}
+	if d.DecimalAsString == nil || d.decimalAsString() {
+		t.Errorf("Expected decimal_as_string false, got %v", d.DecimalAsString)
+	}
+}
+
+func TestUnmarshalCaddyfile_MaxStreamsPerKey(t *testing.T) {
+	dispenser := caddyfile.NewTestDispenser(`duckdb {
+		max_streams_per_key 3
+	}`)
+	d := &DuckDB{}
+	if err := d.UnmarshalCaddyfile(dispenser); err != nil {
+		t.Fatalf("UnmarshalCaddyfile failed: %v", err)
+	}
+	if d.MaxStreamsPerKey != 3 {
+		t.Errorf("Expected max_streams_per_key 3, got %d", d.MaxStreamsPerKey)
+	}
+
+	dispenser = caddyfile.NewTestDispenser(`duckdb {
+		max_streams_per_key many
+	}`)
+	if err := (&DuckDB{}).UnmarshalCaddyfile(dispenser); err == nil {
+		t.Error("Expected error for non-numeric max_streams_per_key")
+	}
+}
+
+func TestUnmarshalCaddyfile_HealthSource(t *testing.T) {
+	input := `duckdb {
+		health_source archive "SELECT 1 FROM read_parquet('s3://bucket/archive.parquet') LIMIT 1"
+	}`
+
+	dispenser := caddyfile.NewTestDispenser(input)
+	d := &DuckDB{}
+	if err := d.UnmarshalCaddyfile(dispenser); err != nil {
+		t.Fatalf("UnmarshalCaddyfile failed: %v", err)
+	}
+
+	want := "SELECT 1 FROM read_parquet('s3://bucket/archive.parquet') LIMIT 1"
+	if got := d.HealthSources["archive"]; got != want {
+		t.Errorf("Expected health source query %q, got %q", want, got)
+	}
+}
+
+func TestValidate_InvalidCSVCharset(t *testing.T) {
+	d := &DuckDB{
+		AccessMode:      "read_write",
+		MaxRowsPerPage:  100,
+		AbsoluteMaxRows: 1000,
+		Threads:         4,
+		CSVCharset:      "shift_jis",
+	}
+
+	if err := d.Validate(); err == nil {
+		t.Error("Expected error for unsupported csv_charset")
+	}
+}
+
+// ===========================
+// Additional ServeHTTP Tests
+// ===========================
+
+func TestServeHTTP_QueryEndpoint_WithAuth(t *testing.T) {
+	d, cleanup := setupTestModule(t)
+	defer cleanup()
+
+	// Properly initialize the query handler
+	d.queryHandler = handlers.NewQueryHandler(d.dbMgr, d.authorizer, d.logger)
+
+	req := httptest.NewRequest("POST", "/duckdb/query", strings.NewReader(`{"sql":"SELECT 1"}`))
+	req.Header.Set("X-API-Key", "test-api-key")
+	req.Header.Set("Content-Type", "application/json")
+	rec := httptest.NewRecorder()
+	next := &mockNextHandler{}
+
+	err := d.ServeHTTP(rec, req, next)
+	if err != nil {
+		t.Errorf("ServeHTTP returned error: %v", err)
+	}
+
+	// Should have called query handler, not next handler
+	if next.called {
+		t.Error("Query endpoint should not call next handler")
+	}
+
+	// Should return OK for valid query
+	if rec.Code != http.StatusOK {
+		t.Errorf("Expected status 200, got %d. Body: %s", rec.Code, rec.Body.String())
+	}
+}
+
+func TestServeHTTP_CRUDEndpoint_WithAuth(t *testing.T) {
+	d, cleanup := setupTestModule(t)
+	defer cleanup()
 
 	// Properly initialize the CRUD handler
 	d.crudHandler = handlers.NewCRUDHandler(d.dbMgr, d.authorizer, d.MaxRowsPerPage, d.AbsoluteMaxRows, d.logger)
@@ -985,6 +2295,66 @@ func TestServeHTTP_CRUDEndpoint_WithAuth(t *testing.T) {
 	}
 }
 
+func TestServeHTTP_SchemaEndpoint(t *testing.T) {
+	d, cleanup := setupTestModule(t)
+	defer cleanup()
+	d.catalogHandler = handlers.NewCatalogHandler(d.dbMgr, d.authorizer, d.logger)
+
+	for _, path := range []string{"/duckdb/schema", "/duckdb/schema/test_data"} {
+		req := httptest.NewRequest("GET", path, nil)
+		req.Header.Set("X-API-Key", "test-api-key")
+		rec := httptest.NewRecorder()
+		next := &mockNextHandler{}
+
+		if err := d.ServeHTTP(rec, req, next); err != nil {
+			t.Errorf("ServeHTTP returned error: %v", err)
+		}
+		if rec.Code != http.StatusOK {
+			t.Errorf("%s: expected status 200, got %d. Body: %s", path, rec.Code, rec.Body.String())
+		}
+	}
+
+	// The endpoint requires authentication
+	req := httptest.NewRequest("GET", "/duckdb/schema", nil)
+	rec := httptest.NewRecorder()
+	d.ServeHTTP(rec, req, &mockNextHandler{})
+	if rec.Code != http.StatusUnauthorized {
+		t.Errorf("Expected status 401 without API key, got %d", rec.Code)
+	}
+}
+
+func TestServeHTTP_TransactionEndpoint(t *testing.T) {
+	d, cleanup := setupTestModule(t)
+	defer cleanup()
+	d.crudHandler = handlers.NewCRUDHandler(d.dbMgr, d.authorizer, d.MaxRowsPerPage, d.AbsoluteMaxRows, d.logger)
+
+	body := `[{"op": "insert", "table": "test_data", "data": {"id": 1, "value": "a"}}, {"op": "insert", "table": "test_data", "data": {"id": 2, "value": "b"}}]`
+	req := httptest.NewRequest("POST", "/duckdb/transaction", strings.NewReader(body))
+	req.Header.Set("X-API-Key", "test-api-key")
+	rec := httptest.NewRecorder()
+	if err := d.ServeHTTP(rec, req, &mockNextHandler{}); err != nil {
+		t.Errorf("ServeHTTP returned error: %v", err)
+	}
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d. Body: %s", rec.Code, rec.Body.String())
+	}
+	var count int
+	if err := d.dbMgr.QueryRowScanMain("SELECT COUNT(*) FROM test_data", []interface{}{&count}); err != nil {
+		t.Fatalf("Failed to count rows: %v", err)
+	}
+	if count != 2 {
+		t.Errorf("Expected 2 rows, got %d", count)
+	}
+
+	// The endpoint requires authentication
+	req = httptest.NewRequest("POST", "/duckdb/transaction", strings.NewReader(body))
+	rec = httptest.NewRecorder()
+	d.ServeHTTP(rec, req, &mockNextHandler{})
+	if rec.Code != http.StatusUnauthorized {
+		t.Errorf("Expected status 401 without API key, got %d", rec.Code)
+	}
+}
+
 func TestServeHTTP_OpenAPIWithHandler(t *testing.T) {
 	d, cleanup := setupTestModule(t)
 	defer cleanup()
@@ -1018,3 +2388,57 @@ func TestServeHTTP_OpenAPIWithHandler(t *testing.T) {
 		t.Errorf("Expected OpenAPI 3.0.3, got %v", spec["openapi"])
 	}
 }
+
+func TestServeHTTP_OpenAPITables(t *testing.T) {
+	d, cleanup := setupTestModule(t)
+	defer cleanup()
+
+	// Listing tables requires an API key
+	req := httptest.NewRequest("GET", "/duckdb/openapi.json?tables=true", nil)
+	rec := httptest.NewRecorder()
+	d.ServeHTTP(rec, req, &mockNextHandler{})
+	if rec.Code != http.StatusUnauthorized {
+		t.Fatalf("Expected status 401 without an API key, got %d", rec.Code)
+	}
+
+	req = httptest.NewRequest("GET", "/duckdb/openapi.json?tables=true", nil)
+	req.Header.Set("X-API-Key", "test-api-key")
+	rec = httptest.NewRecorder()
+	d.ServeHTTP(rec, req, &mockNextHandler{})
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var spec map[string]interface{}
+	if err := json.Unmarshal(rec.Body.Bytes(), &spec); err != nil {
+		t.Fatalf("Expected valid JSON response: %v", err)
+	}
+	if _, ok := spec["paths"].(map[string]interface{})["/api/test_data"]; !ok {
+		t.Error("Expected a path for test_data")
+	}
+}
+
+func TestServeHTTP_OpenAPIYAML(t *testing.T) {
+	d, cleanup := setupTestModule(t)
+	defer cleanup()
+	d.openAPIHandler = handlers.NewOpenAPIHandler()
+
+	// Served without an API key, like openapi.json
+	req := httptest.NewRequest("GET", "/duckdb/openapi.yaml", nil)
+	rec := httptest.NewRecorder()
+	next := &mockNextHandler{}
+	if err := d.ServeHTTP(rec, req, next); err != nil {
+		t.Errorf("ServeHTTP returned error: %v", err)
+	}
+	if next.called {
+		t.Error("OpenAPI endpoint should not call next handler")
+	}
+	if rec.Code != http.StatusOK {
+		t.Errorf("Expected status 200, got %d", rec.Code)
+	}
+	if ct := rec.Header().Get("Content-Type"); ct != handlers.YAMLContentType {
+		t.Errorf("Expected Content-Type %s, got %s", handlers.YAMLContentType, ct)
+	}
+	if !strings.Contains(rec.Body.String(), "\nopenapi: 3.0.3\n") {
+		t.Errorf("Expected a YAML spec, got %.200s", rec.Body.String())
+	}
+}
@@ -0,0 +1,35 @@
+package duckdb
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/tobilg/caddy-duckdb-module/auth"
+	"github.com/tobilg/caddy-duckdb-module/handlers"
+)
+
+// namespaceAllows reports whether the authenticated API key may use the
+// requested endpoint. Keys of a namespace listed in NamespaceTables may only
+// use the table API on the namespace's tables; all other keys are unrestricted.
+func (d *DuckDB) namespaceAllows(r *http.Request) bool {
+	tables, restricted := d.NamespaceTables[auth.GetNamespaceFromContext(r.Context())]
+	if !restricted {
+		return true
+	}
+
+	apiPrefix := d.routePrefix + "/api/"
+	if !strings.HasPrefix(r.URL.Path, apiPrefix) {
+		return false
+	}
+	table, _, _ := strings.Cut(strings.TrimPrefix(r.URL.Path, apiPrefix), "/")
+	table, err := handlers.ResolveTableName(r, table, d.dbMgr)
+	if err != nil {
+		return false
+	}
+	for _, allowed := range tables {
+		if strings.EqualFold(allowed, table) {
+			return true
+		}
+	}
+	return false
+}
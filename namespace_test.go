@@ -0,0 +1,123 @@
+package duckdb
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/caddyserver/caddy/v2/caddyconfig/caddyfile"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestServeHTTP_NamespaceTables(t *testing.T) {
+	d, cleanup := setupMaintenanceModule(t)
+	defer cleanup()
+
+	if _, err := d.dbMgr.ExecMain(`CREATE TABLE other_data (id INTEGER)`); err != nil {
+		t.Fatalf("Failed to create table: %v", err)
+	}
+	if err := d.authorizer.CreateNamespacedAPIKey("app1_key", "admin", "app1", nil); err != nil {
+		t.Fatalf("Failed to create namespaced API key: %v", err)
+	}
+	if err := d.authorizer.CreateNamespacedAPIKey("app2_key", "admin", "app2", nil); err != nil {
+		t.Fatalf("Failed to create namespaced API key: %v", err)
+	}
+	d.NamespaceTables = map[string][]string{"app1": {"test_data"}}
+
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		body   string
+		apiKey string
+		status int
+	}{
+		{"allowed table", "GET", "/duckdb/api/test_data", "", "app1_key", http.StatusOK},
+		{"allowed table write", "POST", "/duckdb/api/test_data", `{"id": 1, "value": "a"}`, "app1_key", http.StatusCreated},
+		{"other table", "GET", "/duckdb/api/other_data", "", "app1_key", http.StatusForbidden},
+		{"other catalog", "GET", "/duckdb/api/test_data?catalog=temp", "", "app1_key", http.StatusForbidden},
+		{"raw query", "POST", "/duckdb/query", `{"sql": "SELECT * FROM other_data"}`, "app1_key", http.StatusForbidden},
+		{"admin", "GET", "/duckdb/admin/maintenance", "", "app1_key", http.StatusForbidden},
+		{"unrestricted namespace", "GET", "/duckdb/api/other_data", "", "app2_key", http.StatusOK},
+		{"no namespace", "POST", "/duckdb/query", `{"sql": "SELECT * FROM other_data"}`, "test-api-key", http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := serve(t, d, tt.method, tt.path, tt.body, tt.apiKey)
+			if rec.Code != tt.status {
+				t.Errorf("Expected status %d, got %d: %s", tt.status, rec.Code, rec.Body.String())
+			}
+		})
+	}
+}
+
+func TestServeHTTP_RequestLogNamespace(t *testing.T) {
+	d, cleanup := setupMaintenanceModule(t)
+	defer cleanup()
+
+	core, logs := observer.New(zapcore.InfoLevel)
+	d.logger = zap.New(core)
+	d.RequestLog = &RequestLogConfig{Enabled: true}
+	if err := d.authorizer.CreateNamespacedAPIKey("app1_key", "reader", "app1", nil); err != nil {
+		t.Fatalf("Failed to create namespaced API key: %v", err)
+	}
+
+	serve(t, d, "GET", "/duckdb/api/test_data", "", "app1_key")
+	serve(t, d, "GET", "/duckdb/api/test_data", "", "test-api-key")
+
+	entries := logs.FilterMessage("Request").AllUntimed()
+	if len(entries) != 2 {
+		t.Fatalf("Expected 2 logged requests, got %d", len(entries))
+	}
+	if ns := entries[0].ContextMap()["namespace"]; ns != "app1" {
+		t.Errorf("Expected namespace 'app1' in log fields, got %v", ns)
+	}
+	if ns := entries[1].ContextMap()["namespace"]; ns != "" {
+		t.Errorf("Expected empty namespace in log fields, got %v", ns)
+	}
+}
+
+func TestValidate_NamespaceTables(t *testing.T) {
+	tests := []struct {
+		name   string
+		tables map[string][]string
+		valid  bool
+	}{
+		{"valid", map[string][]string{"app1": {"orders", "archive.orders"}}, true},
+		{"invalid namespace", map[string][]string{"app_1": {"orders"}}, false},
+		{"no tables", map[string][]string{"app1": {}}, false},
+		{"invalid table", map[string][]string{"app1": {"orders;drop"}}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := &DuckDB{
+				AccessMode:      "read_write",
+				MaxRowsPerPage:  100,
+				Threads:         1,
+				NamespaceTables: tt.tables,
+			}
+			if err := d.Validate(); (err == nil) != tt.valid {
+				t.Errorf("Validate() = %v, expected valid=%v", err, tt.valid)
+			}
+		})
+	}
+}
+
+func TestUnmarshalCaddyfile_NamespaceTables(t *testing.T) {
+	d := &DuckDB{}
+	dispenser := caddyfile.NewTestDispenser(`duckdb {
+		namespace_tables app1 orders customers
+		namespace_tables app1 invoices
+		namespace_tables app2 reports
+	}`)
+	if err := d.UnmarshalCaddyfile(dispenser); err != nil {
+		t.Fatalf("UnmarshalCaddyfile failed: %v", err)
+	}
+	if got := d.NamespaceTables["app1"]; len(got) != 3 || got[2] != "invoices" {
+		t.Errorf("Expected three tables for app1, got %v", got)
+	}
+	if got := d.NamespaceTables["app2"]; len(got) != 1 || got[0] != "reports" {
+		t.Errorf("Expected reports for app2, got %v", got)
+	}
+}
@@ -0,0 +1,29 @@
+package duckdb
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/tobilg/caddy-duckdb-module/auth"
+)
+
+// checkQueryLabel returns the query label sent in the X-Query-Label header, or
+// a client error message if it is invalid or not in the query_labels allowlist.
+func (d *DuckDB) checkQueryLabel(r *http.Request) (label, message string) {
+	label = r.Header.Get(auth.QueryLabelHeader)
+	if label == "" {
+		return "", ""
+	}
+	if err := auth.ValidateQueryLabel(label); err != nil {
+		return "", fmt.Sprintf("Invalid %s header: %v", auth.QueryLabelHeader, err)
+	}
+	if len(d.QueryLabels) == 0 {
+		return label, ""
+	}
+	for _, allowed := range d.QueryLabels {
+		if label == allowed {
+			return label, ""
+		}
+	}
+	return "", fmt.Sprintf("Invalid %s header: query label '%s' is not allowed", auth.QueryLabelHeader, label)
+}
@@ -0,0 +1,96 @@
+package duckdb
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/caddyserver/caddy/v2/caddyconfig/caddyfile"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestServeHTTP_QueryLabel(t *testing.T) {
+	d, cleanup := setupMaintenanceModule(t)
+	defer cleanup()
+
+	core, logs := observer.New(zapcore.InfoLevel)
+	d.logger = zap.New(core)
+	d.RequestLog = &RequestLogConfig{Enabled: true}
+
+	serveLabelled := func(path, label string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("GET", path, nil)
+		req.Header.Set("X-API-Key", "test-api-key")
+		req.Header.Set("X-Query-Label", label)
+		rec := httptest.NewRecorder()
+		if err := d.ServeHTTP(rec, req, &mockNextHandler{}); err != nil {
+			t.Fatalf("ServeHTTP returned error: %v", err)
+		}
+		return rec
+	}
+
+	if rec := serveLabelled("/duckdb/api/test_data", "team-analytics"); rec.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	// The label is recorded in the request log
+	entries := logs.FilterMessage("Request").AllUntimed()
+	if len(entries) != 1 || entries[0].ContextMap()["label"] != "team-analytics" {
+		t.Fatalf("Expected a request log entry with the label, got %v", entries)
+	}
+
+	// ... and in the metrics
+	rec := serve(t, d, "GET", "/duckdb/metrics", "", "test-api-key")
+	if !strings.Contains(rec.Body.String(), `duckdb_labeled_requests_total{label="team-analytics"} 1`+"\n") {
+		t.Errorf("Expected the labelled request in the metrics, got:\n%s", rec.Body.String())
+	}
+
+	// Invalid labels are rejected
+	for _, label := range []string{"team analytics", "a*/b", strings.Repeat("a", 65)} {
+		if rec := serveLabelled("/duckdb/api/test_data", label); rec.Code != http.StatusBadRequest {
+			t.Errorf("Expected 400 for label %q, got %d", label, rec.Code)
+		}
+	}
+
+	// With an allowlist, only its labels are accepted
+	d.QueryLabels = []string{"team-analytics"}
+	if rec := serveLabelled("/duckdb/api/test_data", "team-analytics"); rec.Code != http.StatusOK {
+		t.Errorf("Expected an allowed label to succeed, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if rec := serveLabelled("/duckdb/api/test_data", "team-billing"); rec.Code != http.StatusBadRequest || !strings.Contains(rec.Body.String(), "not allowed") {
+		t.Errorf("Expected 400 for a label outside the allowlist, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if d.dbMgr.Metrics().Labels["team-billing"] != 0 {
+		t.Error("Expected rejected labels not to be counted")
+	}
+}
+
+func TestUnmarshalCaddyfile_QueryLabels(t *testing.T) {
+	dispenser := caddyfile.NewTestDispenser(`duckdb {
+		query_labels team-analytics team-billing
+		query_labels team-ops
+	}`)
+	d := &DuckDB{}
+	if err := d.UnmarshalCaddyfile(dispenser); err != nil {
+		t.Fatalf("UnmarshalCaddyfile failed: %v", err)
+	}
+	if strings.Join(d.QueryLabels, ",") != "team-analytics,team-billing,team-ops" {
+		t.Errorf("Unexpected query labels: %v", d.QueryLabels)
+	}
+}
+
+func TestValidate_InvalidQueryLabels(t *testing.T) {
+	d := &DuckDB{
+		AccessMode:      "read_write",
+		MaxRowsPerPage:  100,
+		AbsoluteMaxRows: 10000,
+		Threads:         4,
+		QueryLabels:     []string{"team-analytics", "team billing"},
+	}
+
+	if err := d.Validate(); err == nil {
+		t.Error("Expected error for an invalid query label")
+	}
+}
@@ -0,0 +1,59 @@
+package duckdb
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/caddyserver/caddy/v2"
+	"github.com/caddyserver/caddy/v2/caddyconfig/caddyfile"
+	"github.com/tobilg/caddy-duckdb-module/auth"
+)
+
+// RateLimitConfig limits the request rate of each API key. A key may send up
+// to Requests requests at once and regains Requests requests per Window at an
+// even rate (a token bucket); requests over the limit get 429.
+type RateLimitConfig struct {
+	// Requests is the number of requests allowed per window.
+	Requests int `json:"requests,omitempty"`
+
+	// Window is the period over which Requests requests are allowed.
+	Window caddy.Duration `json:"window,omitempty"`
+}
+
+// validate checks that the limit and window are positive.
+func (c *RateLimitConfig) validate() error {
+	if c.Requests <= 0 {
+		return fmt.Errorf("rate_limit requests must be > 0")
+	}
+	if c.Window <= 0 {
+		return fmt.Errorf("rate_limit window must be > 0")
+	}
+	return nil
+}
+
+// rateLimiter returns the limiter for the configuration, or nil if rate
+// limiting is not configured.
+func (c *RateLimitConfig) rateLimiter() *auth.RateLimiter {
+	if c == nil {
+		return nil
+	}
+	return auth.NewRateLimiter(c.Requests, time.Duration(c.Window))
+}
+
+// unmarshalRateLimit parses the arguments of `rate_limit <requests> <window>`.
+func unmarshalRateLimit(dispenser *caddyfile.Dispenser) (*RateLimitConfig, error) {
+	var requestsStr, windowStr string
+	if !dispenser.Args(&requestsStr, &windowStr) {
+		return nil, dispenser.ArgErr()
+	}
+	requests, err := strconv.Atoi(requestsStr)
+	if err != nil {
+		return nil, dispenser.Errf("invalid rate_limit requests: %v", err)
+	}
+	window, err := caddy.ParseDuration(windowStr)
+	if err != nil {
+		return nil, dispenser.Errf("invalid rate_limit window: %v", err)
+	}
+	return &RateLimitConfig{Requests: requests, Window: caddy.Duration(window)}, nil
+}
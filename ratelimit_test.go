@@ -0,0 +1,103 @@
+package duckdb
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/caddyserver/caddy/v2"
+	"github.com/caddyserver/caddy/v2/caddyconfig/caddyfile"
+)
+
+func TestUnmarshalCaddyfile_RateLimit(t *testing.T) {
+	dispenser := caddyfile.NewTestDispenser(`duckdb {
+		rate_limit 100 1m
+	}`)
+	d := &DuckDB{}
+	if err := d.UnmarshalCaddyfile(dispenser); err != nil {
+		t.Fatalf("UnmarshalCaddyfile failed: %v", err)
+	}
+	if d.RateLimit == nil || d.RateLimit.Requests != 100 || d.RateLimit.Window != caddy.Duration(time.Minute) {
+		t.Errorf("Expected rate_limit 100 per 1m, got %+v", d.RateLimit)
+	}
+
+	for _, input := range []string{
+		`duckdb {
+			rate_limit 100
+		}`,
+		`duckdb {
+			rate_limit many 1m
+		}`,
+		`duckdb {
+			rate_limit 100 soon
+		}`,
+	} {
+		if err := (&DuckDB{}).UnmarshalCaddyfile(caddyfile.NewTestDispenser(input)); err == nil {
+			t.Errorf("Expected error for %s", input)
+		}
+	}
+}
+
+func TestValidate_RateLimit(t *testing.T) {
+	for _, cfg := range []RateLimitConfig{
+		{Requests: 0, Window: caddy.Duration(time.Minute)},
+		{Requests: 10, Window: 0},
+	} {
+		d := &DuckDB{AccessMode: "read_write", Threads: 1, MaxRowsPerPage: 100, RateLimit: &cfg}
+		if err := d.Validate(); err == nil {
+			t.Errorf("Expected validation error for %+v", cfg)
+		}
+	}
+}
+
+func TestServeHTTP_RateLimit(t *testing.T) {
+	d, cleanup := setupTestModule(t)
+	defer cleanup()
+	d.authMw.SetRateLimiter((&RateLimitConfig{Requests: 3, Window: caddy.Duration(time.Minute)}).rateLimiter())
+
+	serve := func() *httptest.ResponseRecorder {
+		req := httptest.NewRequest("GET", "/duckdb/admin/maintenance", nil)
+		req.Header.Set("X-API-Key", "test-api-key")
+		rec := httptest.NewRecorder()
+		d.ServeHTTP(rec, req, &mockNextHandler{})
+		return rec
+	}
+
+	// More requests than allowed within the window
+	var limited []*httptest.ResponseRecorder
+	for i := 0; i < 5; i++ {
+		rec := serve()
+		switch rec.Code {
+		case http.StatusOK:
+		case http.StatusTooManyRequests:
+			limited = append(limited, rec)
+		default:
+			t.Fatalf("Request %d: unexpected status %d: %s", i+1, rec.Code, rec.Body.String())
+		}
+	}
+	if len(limited) != 2 {
+		t.Fatalf("Expected 2 of 5 requests to be rate limited, got %d", len(limited))
+	}
+
+	rec := limited[0]
+	if rec.Header().Get("Retry-After") != "20" {
+		t.Errorf("Expected Retry-After 20, got %q", rec.Header().Get("Retry-After"))
+	}
+	var body map[string]interface{}
+	if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+		t.Fatalf("Failed to parse response: %v", err)
+	}
+	if body["code"] != float64(http.StatusTooManyRequests) || body["request_id"] == "" {
+		t.Errorf("Expected a 429 error body with request ID, got %v", body)
+	}
+
+	// Unauthenticated requests are refused before the limit is charged
+	req := httptest.NewRequest("GET", "/duckdb/admin/maintenance", nil)
+	rec = httptest.NewRecorder()
+	d.ServeHTTP(rec, req, &mockNextHandler{})
+	if rec.Code != http.StatusUnauthorized {
+		t.Errorf("Expected status 401 without API key, got %d", rec.Code)
+	}
+}
@@ -0,0 +1,103 @@
+package duckdb
+
+import (
+	"hash/fnv"
+	"net/http"
+	"time"
+
+	"github.com/tobilg/caddy-duckdb-module/auth"
+	"go.uber.org/zap"
+)
+
+// RequestLogConfig configures the per-request access log written by the module.
+type RequestLogConfig struct {
+	// Enabled turns on request logging.
+	Enabled bool `json:"enabled,omitempty"`
+
+	// SampleRate is the fraction of successful requests that are logged, between
+	// 0 and 1. Requests that end in a 4xx or 5xx status are always logged.
+	// Default is 1 (log every request).
+	SampleRate *float64 `json:"sample_rate,omitempty"`
+}
+
+// rate returns the configured sample rate, defaulting to 1.
+func (c *RequestLogConfig) rate() float64 {
+	if c.SampleRate == nil {
+		return 1
+	}
+	return *c.SampleRate
+}
+
+// sampleRequest decides whether a request is in the logged sample. The decision
+// is derived from a hash of the request ID, so it is deterministic: a request ID
+// propagated via X-Request-ID is sampled the same way on every retry.
+func sampleRequest(requestID string, rate float64) bool {
+	if rate >= 1 {
+		return true
+	}
+	if rate <= 0 {
+		return false
+	}
+	h := fnv.New64a()
+	h.Write([]byte(requestID))
+	return float64(h.Sum64()>>11)/float64(1<<53) < rate
+}
+
+// statusRecorder captures the status code written by a handler.
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+// WriteHeader records the status code and forwards it.
+func (s *statusRecorder) WriteHeader(code int) {
+	if s.status == 0 {
+		s.status = code
+	}
+	s.ResponseWriter.WriteHeader(code)
+}
+
+// Write records an implicit 200 status and forwards the data.
+func (s *statusRecorder) Write(b []byte) (int, error) {
+	if s.status == 0 {
+		s.status = http.StatusOK
+	}
+	return s.ResponseWriter.Write(b)
+}
+
+// Flush forwards flushes so streaming responses (SSE) keep working.
+func (s *statusRecorder) Flush() {
+	if f, ok := s.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Unwrap returns the wrapped writer for http.ResponseController.
+func (s *statusRecorder) Unwrap() http.ResponseWriter {
+	return s.ResponseWriter
+}
+
+// logRequest writes the access log entry for a completed request if it is
+// sampled or ended in an error status.
+func (d *DuckDB) logRequest(rec *statusRecorder, r *http.Request, start time.Time) {
+	status := rec.status
+	if status == 0 {
+		status = http.StatusOK
+	}
+	requestID := auth.GetRequestIDFromContext(r.Context())
+	if status < 400 && !sampleRequest(requestID, d.RequestLog.rate()) {
+		return
+	}
+
+	d.logger.Info("Request",
+		zap.String("method", r.Method),
+		zap.String("path", r.URL.Path),
+		zap.String("table", auth.ExtractTableName(r.URL.Path)),
+		zap.String("role", auth.GetRoleFromContext(r.Context())),
+		zap.String("namespace", auth.GetNamespaceFromContext(r.Context())),
+		zap.String("label", auth.GetQueryLabelFromContext(r.Context())),
+		zap.Int("status", status),
+		zap.Int64("duration_ms", time.Since(start).Milliseconds()),
+		zap.String("request_id", requestID),
+	)
+}
@@ -0,0 +1,136 @@
+package duckdb
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/caddyserver/caddy/v2/caddyconfig/caddyfile"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestSampleRequest(t *testing.T) {
+	const total = 20000
+	sampled := 0
+	for i := 0; i < total; i++ {
+		if sampleRequest(uuid.New().String(), 0.1) {
+			sampled++
+		}
+	}
+	// Expect roughly 10%; allow a generous margin to keep the test stable
+	if sampled < total*8/100 || sampled > total*12/100 {
+		t.Errorf("Expected about 10%% of requests to be sampled, got %d of %d", sampled, total)
+	}
+
+	// Sampling is deterministic per request ID
+	id := uuid.New().String()
+	first := sampleRequest(id, 0.5)
+	for i := 0; i < 10; i++ {
+		if sampleRequest(id, 0.5) != first {
+			t.Fatal("Expected the same sampling decision for the same request ID")
+		}
+	}
+
+	if sampleRequest(id, 0) {
+		t.Error("Expected rate 0 to sample nothing")
+	}
+	if !sampleRequest(id, 1) {
+		t.Error("Expected rate 1 to sample everything")
+	}
+}
+
+func TestServeHTTP_RequestLog(t *testing.T) {
+	d, cleanup := setupTestModule(t)
+	defer cleanup()
+
+	core, logs := observer.New(zapcore.InfoLevel)
+	d.logger = zap.New(core)
+
+	serve := func(path, apiKey string) {
+		req := httptest.NewRequest("GET", path, nil)
+		if apiKey != "" {
+			req.Header.Set("X-API-Key", apiKey)
+		}
+		d.ServeHTTP(httptest.NewRecorder(), req, &mockNextHandler{})
+	}
+
+	// With sample_rate 0, successful requests are skipped but errors are always logged
+	rate := 0.0
+	d.RequestLog = &RequestLogConfig{Enabled: true, SampleRate: &rate}
+	serve("/duckdb/health", "")
+	serve("/duckdb/api/users", "")
+	serve("/duckdb/unknown", "test-api-key")
+
+	entries := logs.FilterMessage("Request").AllUntimed()
+	if len(entries) != 2 {
+		t.Fatalf("Expected 2 logged error requests, got %d", len(entries))
+	}
+	unauthorized := entries[0].ContextMap()
+	if unauthorized["status"] != int64(http.StatusUnauthorized) || unauthorized["table"] != "users" {
+		t.Errorf("Unexpected log fields for unauthorized request: %v", unauthorized)
+	}
+	notFound := entries[1].ContextMap()
+	if notFound["status"] != int64(http.StatusNotFound) || notFound["role"] != "admin" {
+		t.Errorf("Unexpected log fields for not found request: %v", notFound)
+	}
+	if notFound["request_id"] == "" || notFound["method"] != "GET" || notFound["path"] != "/duckdb/unknown" {
+		t.Errorf("Expected method, path and request_id in log fields: %v", notFound)
+	}
+
+	// Without a sample rate every request is logged
+	d.RequestLog = &RequestLogConfig{Enabled: true}
+	serve("/duckdb/health", "")
+	entries = logs.FilterMessage("Request").AllUntimed()
+	if len(entries) != 3 || entries[2].ContextMap()["status"] != int64(http.StatusOK) {
+		t.Errorf("Expected successful request to be logged, got %d entries", len(entries))
+	}
+
+	// Disabled request logging writes nothing
+	d.RequestLog.Enabled = false
+	serve("/duckdb/unknown", "test-api-key")
+	if n := logs.FilterMessage("Request").Len(); n != 3 {
+		t.Errorf("Expected no entries while disabled, got %d", n)
+	}
+}
+
+func TestUnmarshalCaddyfile_RequestLog(t *testing.T) {
+	dispenser := caddyfile.NewTestDispenser(`duckdb {
+		request_log {
+			enabled true
+			sample_rate 0.1
+		}
+	}`)
+	d := &DuckDB{}
+	if err := d.UnmarshalCaddyfile(dispenser); err != nil {
+		t.Fatalf("UnmarshalCaddyfile failed: %v", err)
+	}
+	if d.RequestLog == nil || !d.RequestLog.Enabled || d.RequestLog.rate() != 0.1 {
+		t.Errorf("Unexpected request_log config: %+v", d.RequestLog)
+	}
+
+	dispenser = caddyfile.NewTestDispenser(`duckdb {
+		request_log {
+			level debug
+		}
+	}`)
+	if err := (&DuckDB{}).UnmarshalCaddyfile(dispenser); err == nil {
+		t.Error("Expected error for unknown request_log subdirective")
+	}
+}
+
+func TestValidate_InvalidRequestLogSampleRate(t *testing.T) {
+	rate := 1.5
+	d := &DuckDB{
+		AccessMode:      "read_write",
+		MaxRowsPerPage:  100,
+		AbsoluteMaxRows: 10000,
+		Threads:         4,
+		RequestLog:      &RequestLogConfig{Enabled: true, SampleRate: &rate},
+	}
+	if err := d.Validate(); err == nil {
+		t.Error("Expected error for sample_rate above 1")
+	}
+}
@@ -0,0 +1,54 @@
+package duckdb
+
+import (
+	"fmt"
+	"net/http"
+	"regexp"
+	"sort"
+)
+
+// requiredHeader is a compiled entry of RequiredHeaders.
+type requiredHeader struct {
+	name    string
+	pattern *regexp.Regexp // nil accepts any non-empty value
+}
+
+// compileRequiredHeaders checks the header names of a required_headers
+// configuration and compiles their patterns, which must match the whole value.
+// The result is sorted by name so a request missing several headers always
+// gets the same error.
+func compileRequiredHeaders(headers map[string]string) ([]requiredHeader, error) {
+	compiled := make([]requiredHeader, 0, len(headers))
+	for name, pattern := range headers {
+		if !isHeaderName(name) {
+			return nil, fmt.Errorf("invalid required header name '%s'", name)
+		}
+		required := requiredHeader{name: http.CanonicalHeaderKey(name)}
+		if pattern != "" {
+			re, err := regexp.Compile("^(?:" + pattern + ")$")
+			if err != nil {
+				return nil, fmt.Errorf("invalid pattern for required header '%s': %v", name, err)
+			}
+			required.pattern = re
+		}
+		compiled = append(compiled, required)
+	}
+	sort.Slice(compiled, func(i, j int) bool { return compiled[i].name < compiled[j].name })
+	return compiled, nil
+}
+
+// checkRequiredHeaders returns a client error message for the first required
+// header the request is missing or whose value does not match its pattern,
+// or "" when all are present.
+func (d *DuckDB) checkRequiredHeaders(r *http.Request) string {
+	for _, required := range d.requiredHeaders {
+		value := r.Header.Get(required.name)
+		if value == "" {
+			return fmt.Sprintf("Missing required header: %s", required.name)
+		}
+		if required.pattern != nil && !required.pattern.MatchString(value) {
+			return fmt.Sprintf("Invalid value for required header: %s", required.name)
+		}
+	}
+	return ""
+}
@@ -0,0 +1,134 @@
+package duckdb
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/caddyserver/caddy/v2/caddyconfig/caddyfile"
+)
+
+func TestCompileRequiredHeaders(t *testing.T) {
+	compiled, err := compileRequiredHeaders(map[string]string{
+		"x-tenant":    "[a-z]+",
+		"X-Client-ID": "",
+	})
+	if err != nil {
+		t.Fatalf("compileRequiredHeaders failed: %v", err)
+	}
+	if len(compiled) != 2 || compiled[0].name != "X-Client-Id" || compiled[1].name != "X-Tenant" {
+		t.Fatalf("Expected canonical names sorted by name, got %+v", compiled)
+	}
+	if compiled[0].pattern != nil {
+		t.Error("Expected no pattern for X-Client-ID")
+	}
+	// Patterns match the whole value
+	if compiled[1].pattern.MatchString("acme-1") {
+		t.Error("Expected the pattern to be anchored")
+	}
+
+	if _, err := compileRequiredHeaders(map[string]string{"X Tenant": ""}); err == nil {
+		t.Error("Expected error for an invalid header name")
+	}
+	if _, err := compileRequiredHeaders(map[string]string{"X-Tenant": "[a-z"}); err == nil {
+		t.Error("Expected error for an invalid pattern")
+	}
+}
+
+func TestServeHTTP_RequiredHeaders(t *testing.T) {
+	d, cleanup := setupTestModule(t)
+	defer cleanup()
+	required, err := compileRequiredHeaders(map[string]string{
+		"X-Client-ID": "",
+		"X-Tenant":    "[a-z]+",
+	})
+	if err != nil {
+		t.Fatalf("compileRequiredHeaders failed: %v", err)
+	}
+	d.requiredHeaders = required
+
+	serve := func(path string, headers map[string]string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest("GET", path, nil)
+		for name, value := range headers {
+			r.Header.Set(name, value)
+		}
+		rec := httptest.NewRecorder()
+		d.ServeHTTP(rec, r, &mockNextHandler{})
+		return rec
+	}
+	errorOf := func(rec *httptest.ResponseRecorder) string {
+		var body map[string]interface{}
+		if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+			t.Fatalf("Failed to parse error body: %v", err)
+		}
+		message, _ := body["message"].(string)
+		return message
+	}
+
+	// Missing headers are reported before authentication
+	rec := serve("/duckdb/unknown", nil)
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("Expected status 400 without required headers, got %d", rec.Code)
+	}
+	if msg := errorOf(rec); msg != "Missing required header: X-Client-Id" {
+		t.Errorf("Unexpected error message: %q", msg)
+	}
+
+	rec = serve("/duckdb/unknown", map[string]string{"X-API-Key": "test-api-key", "X-Client-ID": "web"})
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("Expected status 400 without X-Tenant, got %d", rec.Code)
+	}
+	if msg := errorOf(rec); msg != "Missing required header: X-Tenant" {
+		t.Errorf("Unexpected error message: %q", msg)
+	}
+
+	rec = serve("/duckdb/unknown", map[string]string{"X-API-Key": "test-api-key", "X-Client-ID": "web", "X-Tenant": "Acme"})
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("Expected status 400 for a value not matching the pattern, got %d", rec.Code)
+	}
+	if msg := errorOf(rec); msg != "Invalid value for required header: X-Tenant" {
+		t.Errorf("Unexpected error message: %q", msg)
+	}
+
+	// With all headers the request is authenticated and routed
+	if rec := serve("/duckdb/unknown", map[string]string{"X-Client-ID": "web", "X-Tenant": "acme"}); rec.Code != http.StatusUnauthorized {
+		t.Errorf("Expected status 401 without an API key, got %d", rec.Code)
+	}
+	if rec := serve("/duckdb/unknown", map[string]string{"X-API-Key": "test-api-key", "X-Client-ID": "web", "X-Tenant": "acme"}); rec.Code != http.StatusNotFound {
+		t.Errorf("Expected status 404 with all required headers, got %d", rec.Code)
+	}
+
+	// Health checks do not need them
+	if rec := serve("/duckdb/health", nil); rec.Code != http.StatusOK {
+		t.Errorf("Expected health check to ignore required headers, got %d", rec.Code)
+	}
+}
+
+func TestUnmarshalCaddyfile_RequiredHeader(t *testing.T) {
+	dispenser := caddyfile.NewTestDispenser(`duckdb {
+		required_header X-Client-ID
+		required_header X-Tenant "[a-z0-9-]+"
+	}`)
+	d := &DuckDB{}
+	if err := d.UnmarshalCaddyfile(dispenser); err != nil {
+		t.Fatalf("UnmarshalCaddyfile failed: %v", err)
+	}
+	if len(d.RequiredHeaders) != 2 {
+		t.Fatalf("Expected 2 required headers, got %v", d.RequiredHeaders)
+	}
+	if pattern, ok := d.RequiredHeaders["X-Client-ID"]; !ok || pattern != "" {
+		t.Errorf("Expected X-Client-ID without a pattern, got %q", pattern)
+	}
+	if d.RequiredHeaders["X-Tenant"] != "[a-z0-9-]+" {
+		t.Errorf("Unexpected pattern for X-Tenant: %q", d.RequiredHeaders["X-Tenant"])
+	}
+
+	dispenser = caddyfile.NewTestDispenser(`duckdb {
+		required_header
+	}`)
+	d = &DuckDB{}
+	if err := d.UnmarshalCaddyfile(dispenser); err == nil {
+		t.Error("Expected error for required_header without a name")
+	}
+}
@@ -0,0 +1,46 @@
+package duckdb
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/tobilg/caddy-duckdb-module/auth"
+	"github.com/tobilg/caddy-duckdb-module/handlers"
+	"go.uber.org/zap"
+)
+
+// slowQueryLogEnabled reports whether any slow query threshold is configured.
+func (d *DuckDB) slowQueryLogEnabled() bool {
+	return d.SlowQueryThreshold > 0 || len(d.SlowQueryThresholds) > 0
+}
+
+// slowQueryThreshold returns the threshold for requests to table: its own
+// threshold if configured, otherwise the global one. Zero means disabled.
+func (d *DuckDB) slowQueryThreshold(table string) time.Duration {
+	if threshold, ok := d.SlowQueryThresholds[table]; ok && table != "" {
+		return time.Duration(threshold)
+	}
+	return time.Duration(d.SlowQueryThreshold)
+}
+
+// logSlowQuery warns about a completed request whose query execution time
+// exceeded the threshold of its table.
+func (d *DuckDB) logSlowQuery(r *http.Request, timing *handlers.ServerTiming) {
+	table := auth.ExtractTableName(r.URL.Path)
+	threshold := d.slowQueryThreshold(table)
+	duration := timing.Duration(handlers.TimingDB)
+	if threshold <= 0 || duration <= threshold {
+		return
+	}
+
+	d.logger.Warn("Slow query",
+		zap.String("method", r.Method),
+		zap.String("path", r.URL.Path),
+		zap.String("table", table),
+		zap.String("role", auth.GetRoleFromContext(r.Context())),
+		zap.String("label", auth.GetQueryLabelFromContext(r.Context())),
+		zap.Duration("threshold", threshold),
+		zap.Int64("duration_ms", duration.Milliseconds()),
+		zap.String("request_id", auth.GetRequestIDFromContext(r.Context())),
+	)
+}
@@ -0,0 +1,142 @@
+package duckdb
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/caddyserver/caddy/v2"
+	"github.com/caddyserver/caddy/v2/caddyconfig/caddyfile"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestSlowQueryThreshold(t *testing.T) {
+	d := &DuckDB{
+		SlowQueryThreshold: caddy.Duration(time.Second),
+		SlowQueryThresholds: map[string]caddy.Duration{
+			"lookup": caddy.Duration(50 * time.Millisecond),
+			"events": 0,
+		},
+	}
+	tests := map[string]time.Duration{
+		"lookup": 50 * time.Millisecond, // table-specific
+		"events": 0,                     // disabled for the table
+		"orders": time.Second,           // falls back to global
+		"":       time.Second,           // /query and other endpoints
+	}
+	for table, want := range tests {
+		if got := d.slowQueryThreshold(table); got != want {
+			t.Errorf("slowQueryThreshold(%q) = %v, want %v", table, got, want)
+		}
+	}
+}
+
+func TestServeHTTP_SlowQueryLog(t *testing.T) {
+	d, cleanup := setupMaintenanceModule(t)
+	defer cleanup()
+
+	if _, err := d.dbMgr.ExecMain(`CREATE TABLE other_data (id INTEGER)`); err != nil {
+		t.Fatalf("Failed to create table: %v", err)
+	}
+	core, logs := observer.New(zapcore.WarnLevel)
+	d.logger = zap.New(core)
+
+	// Any query against test_data is slow; other tables use the lenient global threshold
+	d.SlowQueryThreshold = caddy.Duration(time.Hour)
+	d.SlowQueryThresholds = map[string]caddy.Duration{"test_data": caddy.Duration(time.Nanosecond)}
+
+	for _, path := range []string{"/duckdb/api/test_data", "/duckdb/api/other_data"} {
+		if rec := serve(t, d, "GET", path, "", "test-api-key"); rec.Code != http.StatusOK {
+			t.Fatalf("Expected status 200 for %s, got %d: %s", path, rec.Code, rec.Body.String())
+		}
+	}
+
+	entries := logs.FilterMessage("Slow query").AllUntimed()
+	if len(entries) != 1 {
+		t.Fatalf("Expected 1 slow query entry, got %d: %v", len(entries), entries)
+	}
+	fields := entries[0].ContextMap()
+	if fields["table"] != "test_data" || fields["threshold"] != time.Nanosecond || fields["role"] != "admin" {
+		t.Errorf("Unexpected slow query entry fields: %v", fields)
+	}
+
+	// A strict global threshold flags the other table, but not one whose override is lenient
+	logs.TakeAll()
+	d.SlowQueryThreshold = caddy.Duration(time.Nanosecond)
+	d.SlowQueryThresholds = map[string]caddy.Duration{"test_data": caddy.Duration(time.Hour)}
+	for _, path := range []string{"/duckdb/api/test_data", "/duckdb/api/other_data"} {
+		serve(t, d, "GET", path, "", "test-api-key")
+	}
+	entries = logs.FilterMessage("Slow query").AllUntimed()
+	if len(entries) != 1 || entries[0].ContextMap()["table"] != "other_data" {
+		t.Fatalf("Expected only other_data to be flagged, got %v", entries)
+	}
+
+	// Without thresholds, nothing is flagged and no Server-Timing header is sent
+	logs.TakeAll()
+	d.SlowQueryThreshold = 0
+	d.SlowQueryThresholds = nil
+	rec := serve(t, d, "GET", "/duckdb/api/test_data", "", "test-api-key")
+	if logs.FilterMessage("Slow query").Len() != 0 {
+		t.Error("Expected no slow query entries without thresholds")
+	}
+	if rec.Header().Get("Server-Timing") != "" {
+		t.Error("Expected no Server-Timing header unless server_timing is enabled")
+	}
+}
+
+func TestUnmarshalCaddyfile_SlowQueryThreshold(t *testing.T) {
+	dispenser := caddyfile.NewTestDispenser(`duckdb {
+		slow_query_threshold 1s
+		slow_query_threshold lookup 50ms
+		slow_query_threshold events 5s
+	}`)
+	d := &DuckDB{}
+	if err := d.UnmarshalCaddyfile(dispenser); err != nil {
+		t.Fatalf("UnmarshalCaddyfile failed: %v", err)
+	}
+	if time.Duration(d.SlowQueryThreshold) != time.Second {
+		t.Errorf("Expected global threshold 1s, got %v", time.Duration(d.SlowQueryThreshold))
+	}
+	if time.Duration(d.SlowQueryThresholds["lookup"]) != 50*time.Millisecond || time.Duration(d.SlowQueryThresholds["events"]) != 5*time.Second {
+		t.Errorf("Unexpected table thresholds: %v", d.SlowQueryThresholds)
+	}
+
+	for _, config := range []string{
+		`duckdb {
+			slow_query_threshold
+		}`,
+		`duckdb {
+			slow_query_threshold lookup 50ms extra
+		}`,
+		`duckdb {
+			slow_query_threshold lookup fast
+		}`,
+	} {
+		if err := (&DuckDB{}).UnmarshalCaddyfile(caddyfile.NewTestDispenser(config)); err == nil {
+			t.Errorf("Expected an error for %s", config)
+		}
+	}
+}
+
+func TestValidate_SlowQueryThreshold(t *testing.T) {
+	tests := map[string]map[string]caddy.Duration{
+		"invalid table":      {"bad;table": caddy.Duration(time.Second)},
+		"negative threshold": {"lookup": caddy.Duration(-time.Second)},
+	}
+	for name, thresholds := range tests {
+		d := &DuckDB{
+			AccessMode:          "read_write",
+			MaxRowsPerPage:      100,
+			AbsoluteMaxRows:     10000,
+			Threads:             4,
+			SlowQueryThresholds: thresholds,
+		}
+		if err := d.Validate(); err == nil || !strings.Contains(err.Error(), "slow_query_threshold") {
+			t.Errorf("%s: expected a slow_query_threshold error, got %v", name, err)
+		}
+	}
+}
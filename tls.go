@@ -0,0 +1,25 @@
+package duckdb
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/caddyserver/caddy/v2/modules/caddyhttp"
+)
+
+// isSecureRequest reports whether the request reached the server over HTTPS.
+// X-Forwarded-Proto is only honored when Caddy marked the request as coming from
+// one of the server's trusted_proxies; otherwise any client could spoof it.
+func isSecureRequest(r *http.Request) bool {
+	if r.TLS != nil {
+		return true
+	}
+	trusted, _ := caddyhttp.GetVar(r.Context(), caddyhttp.TrustedProxyVarKey).(bool)
+	if !trusted {
+		return false
+	}
+	// With several proxies the header may hold a list; the first entry is the
+	// protocol the client used.
+	proto, _, _ := strings.Cut(r.Header.Get("X-Forwarded-Proto"), ",")
+	return strings.EqualFold(strings.TrimSpace(proto), "https")
+}
@@ -0,0 +1,111 @@
+package duckdb
+
+import (
+	"context"
+	"crypto/tls"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/caddyserver/caddy/v2/caddyconfig/caddyfile"
+	"github.com/caddyserver/caddy/v2/modules/caddyhttp"
+)
+
+// fromTrustedProxy marks the request the way Caddy does when it comes from one of
+// the server's trusted_proxies.
+func fromTrustedProxy(r *http.Request) *http.Request {
+	vars := map[string]interface{}{caddyhttp.TrustedProxyVarKey: true}
+	return r.WithContext(context.WithValue(r.Context(), caddyhttp.VarsCtxKey, vars))
+}
+
+func TestIsSecureRequest(t *testing.T) {
+	plain := httptest.NewRequest("GET", "/duckdb/api/test_data", nil)
+	if isSecureRequest(plain) {
+		t.Error("Expected plain HTTP request to be insecure")
+	}
+
+	direct := httptest.NewRequest("GET", "/duckdb/api/test_data", nil)
+	direct.TLS = &tls.ConnectionState{}
+	if !isSecureRequest(direct) {
+		t.Error("Expected TLS request to be secure")
+	}
+
+	// X-Forwarded-Proto is ignored from untrusted clients
+	spoofed := httptest.NewRequest("GET", "/duckdb/api/test_data", nil)
+	spoofed.Header.Set("X-Forwarded-Proto", "https")
+	if isSecureRequest(spoofed) {
+		t.Error("Expected X-Forwarded-Proto from an untrusted client to be ignored")
+	}
+
+	proxied := httptest.NewRequest("GET", "/duckdb/api/test_data", nil)
+	proxied.Header.Set("X-Forwarded-Proto", "HTTPS, http")
+	if !isSecureRequest(fromTrustedProxy(proxied)) {
+		t.Error("Expected X-Forwarded-Proto https from a trusted proxy to be secure")
+	}
+
+	proxiedPlain := httptest.NewRequest("GET", "/duckdb/api/test_data", nil)
+	proxiedPlain.Header.Set("X-Forwarded-Proto", "http")
+	if isSecureRequest(fromTrustedProxy(proxiedPlain)) {
+		t.Error("Expected X-Forwarded-Proto http from a trusted proxy to be insecure")
+	}
+}
+
+func TestServeHTTP_RequireTLS(t *testing.T) {
+	d, cleanup := setupTestModule(t)
+	defer cleanup()
+	d.RequireTLS = true
+
+	serve := func(r *http.Request) *httptest.ResponseRecorder {
+		r.Header.Set("X-API-Key", "test-api-key")
+		rec := httptest.NewRecorder()
+		d.ServeHTTP(rec, r, &mockNextHandler{})
+		return rec
+	}
+
+	// Plain HTTP is rejected before authentication
+	rec := serve(httptest.NewRequest("GET", "/duckdb/unknown", nil))
+	if rec.Code != http.StatusForbidden {
+		t.Errorf("Expected status 403 over plain HTTP, got %d", rec.Code)
+	}
+	spoofed := httptest.NewRequest("GET", "/duckdb/unknown", nil)
+	spoofed.Header.Set("X-Forwarded-Proto", "https")
+	if rec := serve(spoofed); rec.Code != http.StatusForbidden {
+		t.Errorf("Expected status 403 for spoofed X-Forwarded-Proto, got %d", rec.Code)
+	}
+
+	// HTTPS, directly or via a trusted proxy, passes through to routing
+	direct := httptest.NewRequest("GET", "/duckdb/unknown", nil)
+	direct.TLS = &tls.ConnectionState{}
+	if rec := serve(direct); rec.Code != http.StatusNotFound {
+		t.Errorf("Expected status 404 over HTTPS, got %d", rec.Code)
+	}
+	proxied := httptest.NewRequest("GET", "/duckdb/unknown", nil)
+	proxied.Header.Set("X-Forwarded-Proto", "https")
+	if rec := serve(fromTrustedProxy(proxied)); rec.Code != http.StatusNotFound {
+		t.Errorf("Expected status 404 via trusted proxy, got %d", rec.Code)
+	}
+
+	// Health stays reachable over plain HTTP
+	if rec := serve(httptest.NewRequest("GET", "/duckdb/health", nil)); rec.Code != http.StatusOK {
+		t.Errorf("Expected health check to ignore require_tls, got %d", rec.Code)
+	}
+
+	// Disabled by default
+	d.RequireTLS = false
+	if rec := serve(httptest.NewRequest("GET", "/duckdb/unknown", nil)); rec.Code != http.StatusNotFound {
+		t.Errorf("Expected plain HTTP to be allowed without require_tls, got %d", rec.Code)
+	}
+}
+
+func TestUnmarshalCaddyfile_RequireTLS(t *testing.T) {
+	dispenser := caddyfile.NewTestDispenser(`duckdb {
+		require_tls true
+	}`)
+	d := &DuckDB{}
+	if err := d.UnmarshalCaddyfile(dispenser); err != nil {
+		t.Fatalf("UnmarshalCaddyfile failed: %v", err)
+	}
+	if !d.RequireTLS {
+		t.Error("Expected require_tls to be enabled")
+	}
+}
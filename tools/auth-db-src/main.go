@@ -4,6 +4,7 @@ import (
 	"crypto/rand"
 	"database/sql"
 	"encoding/base64"
+	"encoding/json"
 	"fmt"
 	"os"
 	"strings"
@@ -12,6 +13,7 @@ import (
 
 	_ "github.com/duckdb/duckdb-go/v2"
 	"github.com/spf13/cobra"
+	"github.com/tobilg/caddy-duckdb-module/auth"
 )
 
 var (
@@ -28,7 +30,7 @@ func main() {
 This tool allows you to:
   - Initialize a new auth database with the required schema
   - Manage roles (add, remove, list)
-  - Manage API keys (add, remove, list)
+  - Manage API keys (add, remove, enable, disable, list, migrate)
   - Manage permissions (add, remove, list)
 
 The created database can be mounted into containers via volume mounts.`,
@@ -85,11 +87,15 @@ func roleCmd() *cobra.Command {
 		RunE: func(cmd *cobra.Command, args []string) error {
 			name, _ := cmd.Flags().GetString("name")
 			desc, _ := cmd.Flags().GetString("desc")
-			return runRoleAdd(name, desc)
+			parent, _ := cmd.Flags().GetString("parent")
+			defaultAccess, _ := cmd.Flags().GetString("default-access")
+			return runRoleAdd(name, desc, parent, defaultAccess)
 		},
 	}
 	addCmd.Flags().StringP("name", "n", "", "Role name (required)")
 	addCmd.Flags().StringP("desc", "", "", "Role description")
+	addCmd.Flags().StringP("parent", "", "", "Parent role whose permissions are inherited (the role's own permissions take precedence)")
+	addCmd.Flags().StringP("default-access", "", "", "Access to tables without a permission: deny, read, or crud (default: deny)")
 	addCmd.MarkFlagRequired("name")
 
 	// role remove
@@ -119,7 +125,7 @@ func roleCmd() *cobra.Command {
 	return cmd
 }
 
-// keyCmd creates the key subcommand with add/remove/list
+// keyCmd creates the key subcommand with add/remove/enable/disable/list/migrate
 func keyCmd() *cobra.Command {
 	cmd := &cobra.Command{
 		Use:   "key",
@@ -134,12 +140,14 @@ func keyCmd() *cobra.Command {
 			role, _ := cmd.Flags().GetString("role")
 			key, _ := cmd.Flags().GetString("key")
 			expires, _ := cmd.Flags().GetString("expires")
-			return runKeyAdd(role, key, expires)
+			namespace, _ := cmd.Flags().GetString("namespace")
+			return runKeyAdd(role, key, expires, namespace)
 		},
 	}
 	addCmd.Flags().StringP("role", "r", "", "Role name (required)")
 	addCmd.Flags().StringP("key", "k", "", "API key (if empty, generates a random one)")
 	addCmd.Flags().StringP("expires", "e", "", "Expiration date (RFC3339 format, e.g., 2025-12-31T23:59:59Z)")
+	addCmd.Flags().StringP("namespace", "n", "", "Application namespace; the key is prefixed with '<namespace>_'")
 	addCmd.MarkFlagRequired("role")
 
 	// key remove
@@ -154,18 +162,70 @@ func keyCmd() *cobra.Command {
 	removeCmd.Flags().StringP("key", "k", "", "API key to remove (required)")
 	removeCmd.MarkFlagRequired("key")
 
+	// key disable
+	disableCmd := &cobra.Command{
+		Use:   "disable",
+		Short: "Disable an API key without removing it",
+		Long: `Set is_active = false on an API key. Requests with the key are rejected
+with "API key has been disabled" until it is enabled again.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			key, _ := cmd.Flags().GetString("key")
+			return runKeySetActive(key, false)
+		},
+	}
+	disableCmd.Flags().StringP("key", "k", "", "API key to disable (required)")
+	disableCmd.MarkFlagRequired("key")
+
+	// key enable
+	enableCmd := &cobra.Command{
+		Use:   "enable",
+		Short: "Re-enable a disabled API key",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			key, _ := cmd.Flags().GetString("key")
+			return runKeySetActive(key, true)
+		},
+	}
+	enableCmd.Flags().StringP("key", "k", "", "API key to enable (required)")
+	enableCmd.MarkFlagRequired("key")
+
+	// key rotate
+	rotateCmd := &cobra.Command{
+		Use:   "rotate",
+		Short: "Replace an API key with a new one",
+		Long: `Create a new API key with the role, expiry, and namespace of an existing
+key and disable the old key, in one transaction. The new key is generated
+unless given with --new, and is printed once.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			key, _ := cmd.Flags().GetString("key")
+			newKey, _ := cmd.Flags().GetString("new")
+			return runKeyRotate(key, newKey)
+		},
+	}
+	rotateCmd.Flags().StringP("key", "k", "", "API key to rotate (required)")
+	rotateCmd.Flags().String("new", "", "New API key (if empty, generates a random one)")
+	rotateCmd.MarkFlagRequired("key")
+
 	// key list
 	listCmd := &cobra.Command{
 		Use:   "list",
 		Short: "List all API keys",
 		RunE: func(cmd *cobra.Command, args []string) error {
-			showKeys, _ := cmd.Flags().GetBool("show-keys")
-			return runKeyList(showKeys)
+			return runKeyList()
 		},
 	}
-	listCmd.Flags().Bool("show-keys", false, "Show full API keys (by default only shows first 8 characters)")
 
-	cmd.AddCommand(addCmd, removeCmd, listCmd)
+	// key migrate
+	migrateCmd := &cobra.Command{
+		Use:   "migrate",
+		Short: "Hash API keys stored in plaintext",
+		Long: `Replace API keys stored in plaintext by auth databases created before key
+hashing with their SHA-256 hash. Clients keep using the same keys.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runKeyMigrate()
+		},
+	}
+
+	cmd.AddCommand(addCmd, removeCmd, disableCmd, enableCmd, rotateCmd, listCmd, migrateCmd)
 	return cmd
 }
 
@@ -190,17 +250,28 @@ Operations can be specified as a comma-separated list:
   - delete (or d): Allow DELETE operations
   - query (or q): Allow raw SQL queries
   - all: All operations
-  - crud: create, read, update, delete (no query)`,
+  - crud: create, read, update, delete (no query)
+
+--deny-columns lists columns of the table the role may not read; table reads
+leave them out and reject requests that select, filter, or sort by them. An
+empty value lifts the restriction. Without the flag, an existing restriction
+is kept.`,
 		RunE: func(cmd *cobra.Command, args []string) error {
 			role, _ := cmd.Flags().GetString("role")
 			table, _ := cmd.Flags().GetString("table")
 			ops, _ := cmd.Flags().GetString("operations")
-			return runPermissionAdd(role, table, ops)
+			var denied *string
+			if cmd.Flags().Changed("deny-columns") {
+				columns, _ := cmd.Flags().GetString("deny-columns")
+				denied = &columns
+			}
+			return runPermissionAdd(role, table, ops, denied)
 		},
 	}
 	addCmd.Flags().StringP("role", "r", "", "Role name (required)")
 	addCmd.Flags().StringP("table", "t", "", "Table name or * for all tables (required)")
 	addCmd.Flags().StringP("operations", "o", "", "Operations to allow: c,r,u,d,q or create,read,update,delete,query or all,crud (required)")
+	addCmd.Flags().String("deny-columns", "", "Comma-separated columns the role may not read (empty to allow all)")
 	addCmd.MarkFlagRequired("role")
 	addCmd.MarkFlagRequired("table")
 	addCmd.MarkFlagRequired("operations")
@@ -237,13 +308,17 @@ Operations can be specified as a comma-separated list:
 
 // infoCmd creates the info subcommand
 func infoCmd() *cobra.Command {
-	return &cobra.Command{
+	var jsonOutput bool
+
+	cmd := &cobra.Command{
 		Use:   "info",
 		Short: "Show database information and statistics",
 		RunE: func(cmd *cobra.Command, args []string) error {
-			return runInfo()
+			return runInfo(jsonOutput)
 		},
 	}
+	cmd.Flags().BoolVar(&jsonOutput, "json", false, "Print the statistics as JSON, including the number of keys per role")
+	return cmd
 }
 
 // openDB opens the database connection
@@ -255,6 +330,50 @@ func openDB() (*sql.DB, error) {
 	return db, nil
 }
 
+// hasParentRoleColumn reports whether the roles table supports inheritance.
+// Databases created by older versions of this tool lack the parent_role column.
+func hasParentRoleColumn(db *sql.DB) bool {
+	var exists bool
+	db.QueryRow(`SELECT EXISTS (
+		SELECT 1 FROM information_schema.columns
+		WHERE table_name = 'roles' AND column_name = 'parent_role'
+	)`).Scan(&exists)
+	return exists
+}
+
+// hasDefaultAccessColumn reports whether the roles table has the
+// default_table_access column (auth databases created before it lack it)
+func hasDefaultAccessColumn(db *sql.DB) bool {
+	var exists bool
+	db.QueryRow(`SELECT EXISTS (
+		SELECT 1 FROM information_schema.columns
+		WHERE table_name = 'roles' AND column_name = 'default_table_access'
+	)`).Scan(&exists)
+	return exists
+}
+
+// hasDeniedColumnsColumn reports whether the permissions table has the
+// denied_columns column (auth databases created before column restrictions lack it)
+func hasDeniedColumnsColumn(db *sql.DB) bool {
+	var exists bool
+	db.QueryRow(`SELECT EXISTS (
+		SELECT 1 FROM information_schema.columns
+		WHERE table_name = 'permissions' AND column_name = 'denied_columns'
+	)`).Scan(&exists)
+	return exists
+}
+
+// hasNamespaceColumn reports whether the api_keys table has the namespace column
+// (auth databases created before key namespaces lack it)
+func hasNamespaceColumn(db *sql.DB) bool {
+	var exists bool
+	db.QueryRow(`SELECT EXISTS (
+		SELECT 1 FROM information_schema.columns
+		WHERE table_name = 'api_keys' AND column_name = 'namespace'
+	)`).Scan(&exists)
+	return exists
+}
+
 // runInit initializes the auth database
 func runInit(withDefaults bool) error {
 	// Check if file already exists
@@ -273,7 +392,9 @@ func runInit(withDefaults bool) error {
 		-- Roles table (must be created first due to foreign key constraints)
 		CREATE TABLE IF NOT EXISTS roles (
 			role_name VARCHAR PRIMARY KEY,
-			description VARCHAR
+			description VARCHAR,
+			parent_role VARCHAR,
+			default_table_access VARCHAR
 		);
 
 		-- API Keys table
@@ -283,6 +404,7 @@ func runInit(withDefaults bool) error {
 			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
 			expires_at TIMESTAMP,
 			is_active BOOLEAN DEFAULT true,
+			namespace VARCHAR,
 			FOREIGN KEY (role_name) REFERENCES roles(role_name)
 		);
 
@@ -296,6 +418,7 @@ func runInit(withDefaults bool) error {
 			can_update BOOLEAN DEFAULT false,
 			can_delete BOOLEAN DEFAULT false,
 			can_query BOOLEAN DEFAULT false,
+			denied_columns VARCHAR,
 			FOREIGN KEY (role_name) REFERENCES roles(role_name),
 			UNIQUE(role_name, table_name)
 		);
@@ -352,15 +475,51 @@ func runInit(withDefaults bool) error {
 	return nil
 }
 
-// runRoleAdd adds a new role
-func runRoleAdd(name, desc string) error {
+// runRoleAdd adds a new role, optionally inheriting from a parent role and
+// with a default access to tables without a permission
+func runRoleAdd(name, desc, parent, defaultAccess string) error {
+	if defaultAccess != "" && !auth.ValidDefaultAccess(defaultAccess) {
+		return fmt.Errorf("invalid default access '%s' (must be deny, read, or crud)", defaultAccess)
+	}
+
 	db, err := openDB()
 	if err != nil {
 		return err
 	}
 	defer db.Close()
 
-	_, err = db.Exec("INSERT INTO roles (role_name, description) VALUES (?, ?)", name, desc)
+	if defaultAccess != "" && !hasDefaultAccessColumn(db) {
+		return fmt.Errorf("this auth database does not support default table access (created before default_table_access was added)")
+	}
+
+	if !hasParentRoleColumn(db) {
+		if parent != "" {
+			return fmt.Errorf("this auth database does not support role inheritance (created before parent_role was added)")
+		}
+		_, err = db.Exec("INSERT INTO roles (role_name, description) VALUES (?, ?)", name, desc)
+		if err != nil {
+			if strings.Contains(err.Error(), "UNIQUE") || strings.Contains(err.Error(), "Duplicate") {
+				return fmt.Errorf("role '%s' already exists", name)
+			}
+			return fmt.Errorf("failed to create role: %w", err)
+		}
+		fmt.Printf("✓ Created role '%s'\n", name)
+		return nil
+	}
+
+	var parentRole interface{}
+	if parent != "" {
+		var exists bool
+		if err := db.QueryRow("SELECT EXISTS (SELECT 1 FROM roles WHERE role_name = ?)", parent).Scan(&exists); err != nil {
+			return fmt.Errorf("failed to query parent role: %w", err)
+		}
+		if !exists {
+			return fmt.Errorf("parent role '%s' not found", parent)
+		}
+		parentRole = parent
+	}
+
+	_, err = db.Exec("INSERT INTO roles (role_name, description, parent_role) VALUES (?, ?, ?)", name, desc, parentRole)
 	if err != nil {
 		if strings.Contains(err.Error(), "UNIQUE") || strings.Contains(err.Error(), "Duplicate") {
 			return fmt.Errorf("role '%s' already exists", name)
@@ -368,7 +527,20 @@ func runRoleAdd(name, desc string) error {
 		return fmt.Errorf("failed to create role: %w", err)
 	}
 
-	fmt.Printf("✓ Created role '%s'\n", name)
+	if defaultAccess != "" {
+		if _, err := db.Exec("UPDATE roles SET default_table_access = ? WHERE role_name = ?", defaultAccess, name); err != nil {
+			return fmt.Errorf("failed to set default access: %w", err)
+		}
+	}
+
+	fmt.Printf("✓ Created role '%s'", name)
+	if parent != "" {
+		fmt.Printf(" (inherits from '%s')", parent)
+	}
+	if defaultAccess != "" {
+		fmt.Printf(" (default access: %s)", defaultAccess)
+	}
+	fmt.Println()
 	return nil
 }
 
@@ -395,6 +567,13 @@ func runRoleRemove(name string, force bool) error {
 		db.Exec("DELETE FROM permissions WHERE role_name = ?", name)
 	}
 
+	// Roles inheriting from this role no longer have a parent
+	if hasParentRoleColumn(db) {
+		if _, err := db.Exec("UPDATE roles SET parent_role = NULL WHERE parent_role = ?", name); err != nil {
+			return fmt.Errorf("failed to detach child roles: %w", err)
+		}
+	}
+
 	result, err := db.Exec("DELETE FROM roles WHERE role_name = ?", name)
 	if err != nil {
 		return fmt.Errorf("failed to delete role: %w", err)
@@ -422,21 +601,35 @@ func runRoleList() error {
 	}
 	defer db.Close()
 
-	rows, err := db.Query("SELECT role_name, COALESCE(description, '') FROM roles ORDER BY role_name")
+	parentExpr := "''"
+	if hasParentRoleColumn(db) {
+		parentExpr = "COALESCE(parent_role, '')"
+	}
+	defaultAccessExpr := "''"
+	if hasDefaultAccessColumn(db) {
+		defaultAccessExpr = "COALESCE(default_table_access, '')"
+	}
+	rows, err := db.Query("SELECT role_name, COALESCE(description, ''), " + parentExpr + ", " + defaultAccessExpr + " FROM roles ORDER BY role_name")
 	if err != nil {
 		return fmt.Errorf("failed to query roles: %w", err)
 	}
 	defer rows.Close()
 
 	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
-	fmt.Fprintln(w, "ROLE\tDESCRIPTION")
-	fmt.Fprintln(w, "----\t-----------")
+	fmt.Fprintln(w, "ROLE\tPARENT\tDEFAULT ACCESS\tDESCRIPTION")
+	fmt.Fprintln(w, "----\t------\t--------------\t-----------")
 
 	count := 0
 	for rows.Next() {
-		var name, desc string
-		rows.Scan(&name, &desc)
-		fmt.Fprintf(w, "%s\t%s\n", name, desc)
+		var name, desc, parent, defaultAccess string
+		rows.Scan(&name, &desc, &parent, &defaultAccess)
+		if parent == "" {
+			parent = "-"
+		}
+		if defaultAccess == "" {
+			defaultAccess = auth.DefaultAccessDeny
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", name, parent, defaultAccess, desc)
 		count++
 	}
 	w.Flush()
@@ -458,7 +651,7 @@ func generateRandomKey() (string, error) {
 }
 
 // runKeyAdd adds a new API key
-func runKeyAdd(role, key, expires string) error {
+func runKeyAdd(role, key, expires, namespace string) error {
 	db, err := openDB()
 	if err != nil {
 		return err
@@ -478,6 +671,20 @@ func runKeyAdd(role, key, expires string) error {
 		if err != nil {
 			return fmt.Errorf("failed to generate API key: %w", err)
 		}
+		if namespace != "" {
+			key = namespace + auth.NamespaceSeparator + key
+		}
+	}
+
+	var keyNamespace interface{}
+	if namespace != "" {
+		if !hasNamespaceColumn(db) {
+			return fmt.Errorf("this auth database does not support key namespaces (created before namespace was added)")
+		}
+		if err := auth.CheckNamespacedKey(key, namespace); err != nil {
+			return err
+		}
+		keyNamespace = namespace
 	}
 
 	// Parse expiration if provided
@@ -490,7 +697,11 @@ func runKeyAdd(role, key, expires string) error {
 		expiresAt = &t
 	}
 
-	_, err = db.Exec("INSERT INTO api_keys (key, role_name, expires_at) VALUES (?, ?, ?)", key, role, expiresAt)
+	if keyNamespace != nil {
+		_, err = db.Exec("INSERT INTO api_keys (key, role_name, expires_at, namespace) VALUES (?, ?, ?, ?)", auth.HashAPIKey(key), role, expiresAt, keyNamespace)
+	} else {
+		_, err = db.Exec("INSERT INTO api_keys (key, role_name, expires_at) VALUES (?, ?, ?)", auth.HashAPIKey(key), role, expiresAt)
+	}
 	if err != nil {
 		if strings.Contains(err.Error(), "UNIQUE") || strings.Contains(err.Error(), "Duplicate") {
 			return fmt.Errorf("API key already exists")
@@ -502,6 +713,9 @@ func runKeyAdd(role, key, expires string) error {
 	fmt.Println()
 	fmt.Printf("  API Key:  %s\n", key)
 	fmt.Printf("  Role:     %s\n", role)
+	if namespace != "" {
+		fmt.Printf("  Namespace: %s\n", namespace)
+	}
 	fmt.Printf("  Created:  %s\n", time.Now().Format(time.RFC3339))
 	if expiresAt != nil {
 		fmt.Printf("  Expires:  %s\n", expiresAt.Format(time.RFC3339))
@@ -509,6 +723,7 @@ func runKeyAdd(role, key, expires string) error {
 		fmt.Printf("  Expires:  never\n")
 	}
 	fmt.Println()
+	fmt.Println("Only a hash of the key is stored; it cannot be shown again.")
 	fmt.Println("Use this in your requests:")
 	fmt.Printf("  curl -H \"X-API-Key: %s\" ...\n", key)
 
@@ -523,7 +738,7 @@ func runKeyRemove(key string) error {
 	}
 	defer db.Close()
 
-	result, err := db.Exec("DELETE FROM api_keys WHERE key = ?", key)
+	result, err := db.Exec("DELETE FROM api_keys WHERE "+keyMatch, keyMatchArgs(key)...)
 	if err != nil {
 		return fmt.Errorf("failed to delete API key: %w", err)
 	}
@@ -537,16 +752,151 @@ func runKeyRemove(key string) error {
 	return nil
 }
 
-// runKeyList lists all API keys
-func runKeyList(showKeys bool) error {
+// runKeySetActive enables or disables an API key
+func runKeySetActive(key string, active bool) error {
+	db, err := openDB()
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	args := append([]interface{}{active}, keyMatchArgs(key)...)
+	result, err := db.Exec("UPDATE api_keys SET is_active = ? WHERE "+keyMatch, args...)
+	if err != nil {
+		return fmt.Errorf("failed to update API key: %w", err)
+	}
+
+	rows, _ := result.RowsAffected()
+	if rows == 0 {
+		return fmt.Errorf("API key not found")
+	}
+
+	if active {
+		fmt.Printf("✓ API key enabled (%d row(s) updated)\n", rows)
+	} else {
+		fmt.Printf("✓ API key disabled (%d row(s) updated)\n", rows)
+	}
+	return nil
+}
+
+// runKeyRotate replaces an active API key with a new key of the same role,
+// expiry, and namespace, and disables the old key
+func runKeyRotate(oldKey, newKey string) error {
+	db, err := openDB()
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	namespace := "NULL"
+	withNamespace := hasNamespaceColumn(db)
+	if withNamespace {
+		namespace = "namespace"
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var role string
+	var expiresAt sql.NullTime
+	var isActive bool
+	var keyNamespace sql.NullString
+	err = tx.QueryRow("SELECT role_name, expires_at, is_active, "+namespace+" FROM api_keys WHERE "+keyMatch,
+		keyMatchArgs(oldKey)...).Scan(&role, &expiresAt, &isActive, &keyNamespace)
+	if err == sql.ErrNoRows {
+		return fmt.Errorf("API key not found")
+	}
+	if err != nil {
+		return fmt.Errorf("failed to query API key: %w", err)
+	}
+	if !isActive {
+		return fmt.Errorf("API key is disabled; enable it before rotating")
+	}
+
+	if newKey == "" {
+		newKey, err = generateRandomKey()
+		if err != nil {
+			return fmt.Errorf("failed to generate API key: %w", err)
+		}
+		if keyNamespace.Valid {
+			newKey = keyNamespace.String + auth.NamespaceSeparator + newKey
+		}
+	} else if keyNamespace.Valid {
+		if err := auth.CheckNamespacedKey(newKey, keyNamespace.String); err != nil {
+			return err
+		}
+	}
+
+	var expires interface{}
+	if expiresAt.Valid {
+		expires = expiresAt.Time
+	}
+	if withNamespace {
+		var ns interface{}
+		if keyNamespace.Valid {
+			ns = keyNamespace.String
+		}
+		_, err = tx.Exec("INSERT INTO api_keys (key, role_name, expires_at, namespace) VALUES (?, ?, ?, ?)", auth.HashAPIKey(newKey), role, expires, ns)
+	} else {
+		_, err = tx.Exec("INSERT INTO api_keys (key, role_name, expires_at) VALUES (?, ?, ?)", auth.HashAPIKey(newKey), role, expires)
+	}
+	if err != nil {
+		if strings.Contains(err.Error(), "UNIQUE") || strings.Contains(err.Error(), "Duplicate") {
+			return fmt.Errorf("API key already exists")
+		}
+		return fmt.Errorf("failed to create API key: %w", err)
+	}
+
+	if _, err := tx.Exec("UPDATE api_keys SET is_active = false WHERE "+keyMatch, keyMatchArgs(oldKey)...); err != nil {
+		return fmt.Errorf("failed to disable old API key: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit key rotation: %w", err)
+	}
+
+	fmt.Println("✓ API key rotated successfully!")
+	fmt.Println()
+	fmt.Printf("  API Key:  %s\n", newKey)
+	fmt.Printf("  Role:     %s\n", role)
+	if keyNamespace.Valid {
+		fmt.Printf("  Namespace: %s\n", keyNamespace.String)
+	}
+	if expiresAt.Valid {
+		fmt.Printf("  Expires:  %s\n", expiresAt.Time.Format(time.RFC3339))
+	} else {
+		fmt.Printf("  Expires:  never\n")
+	}
+	fmt.Println()
+	fmt.Println("The old key is disabled. Only a hash of the new key is stored; it cannot be shown again.")
+
+	return nil
+}
+
+// keyMatch is the WHERE condition matching an API key given on the command
+// line; keyMatchArgs returns its arguments. Keys not yet migrated match in plaintext.
+const keyMatch = "(key = ? OR (key = ? AND NOT starts_with(key, ?)))"
+
+func keyMatchArgs(key string) []interface{} {
+	return []interface{}{auth.HashAPIKey(key), key, auth.HashedKeyPrefix}
+}
+
+// runKeyList lists all API keys, showing only a prefix of each key's hash
+func runKeyList() error {
 	db, err := openDB()
 	if err != nil {
 		return err
 	}
 	defer db.Close()
 
+	namespace := "NULL"
+	if hasNamespaceColumn(db) {
+		namespace = "namespace"
+	}
 	rows, err := db.Query(`
-		SELECT key, role_name, created_at, expires_at, is_active
+		SELECT key, role_name, created_at, expires_at, is_active, ` + namespace + `
 		FROM api_keys
 		ORDER BY created_at DESC
 	`)
@@ -556,20 +906,22 @@ func runKeyList(showKeys bool) error {
 	defer rows.Close()
 
 	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
-	fmt.Fprintln(w, "KEY\tROLE\tCREATED\tEXPIRES\tACTIVE")
-	fmt.Fprintln(w, "---\t----\t-------\t-------\t------")
+	fmt.Fprintln(w, "KEY\tROLE\tNAMESPACE\tCREATED\tEXPIRES\tACTIVE")
+	fmt.Fprintln(w, "---\t----\t---------\t-------\t-------\t------")
 
-	count := 0
+	count, plaintext := 0, 0
 	for rows.Next() {
 		var key, role string
 		var createdAt time.Time
 		var expiresAt sql.NullTime
 		var isActive bool
-		rows.Scan(&key, &role, &createdAt, &expiresAt, &isActive)
+		var keyNamespace sql.NullString
+		rows.Scan(&key, &role, &createdAt, &expiresAt, &isActive, &keyNamespace)
 
-		displayKey := key
-		if !showKeys && len(key) > 8 {
-			displayKey = key[:8] + "..."
+		displayKey := keyHashPrefix(key)
+		if !auth.IsHashedAPIKey(key) {
+			displayKey += " (plaintext)"
+			plaintext++
 		}
 
 		expiresStr := "never"
@@ -582,9 +934,15 @@ func runKeyList(showKeys bool) error {
 			activeStr = "no"
 		}
 
-		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n",
+		namespaceStr := "-"
+		if keyNamespace.Valid {
+			namespaceStr = keyNamespace.String
+		}
+
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\n",
 			displayKey,
 			role,
+			namespaceStr,
 			createdAt.Format("2006-01-02"),
 			expiresStr,
 			activeStr,
@@ -596,7 +954,39 @@ func runKeyList(showKeys bool) error {
 	if count == 0 {
 		fmt.Println("No API keys found.")
 	}
+	if plaintext > 0 {
+		fmt.Printf("\n%d key(s) stored in plaintext; run 'auth-db key migrate' to hash them.\n", plaintext)
+	}
+
+	return nil
+}
 
+// keyHashPrefix returns the first characters of a stored key's hash, enough to
+// tell keys apart. Keys stored in plaintext are hashed for display.
+func keyHashPrefix(stored string) string {
+	if !auth.IsHashedAPIKey(stored) {
+		stored = auth.HashAPIKey(stored)
+	}
+	return stored[:len(auth.HashedKeyPrefix)+12] + "..."
+}
+
+// runKeyMigrate hashes API keys stored in plaintext
+func runKeyMigrate() error {
+	db, err := openDB()
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	migrated, err := auth.NewAuthorizer(db).MigratePlaintextAPIKeys()
+	if err != nil {
+		return err
+	}
+	if migrated == 0 {
+		fmt.Println("No plaintext API keys found.")
+		return nil
+	}
+	fmt.Printf("✓ Hashed %d API key(s)\n", migrated)
 	return nil
 }
 
@@ -634,7 +1024,8 @@ func parseOperations(ops string) (canCreate, canRead, canUpdate, canDelete, canQ
 }
 
 // runPermissionAdd adds a permission
-func runPermissionAdd(role, table, ops string) error {
+// deniedColumns, if not nil, replaces the columns the role may not read.
+func runPermissionAdd(role, table, ops string, deniedColumns *string) error {
 	db, err := openDB()
 	if err != nil {
 		return err
@@ -667,9 +1058,27 @@ func runPermissionAdd(role, table, ops string) error {
 		return fmt.Errorf("failed to create permission: %w", err)
 	}
 
+	var denied []string
+	if deniedColumns != nil {
+		if !hasDeniedColumnsColumn(db) {
+			return fmt.Errorf("this auth database does not support column restrictions (created before denied_columns was added)")
+		}
+		denied = auth.ParseColumnList(*deniedColumns)
+		var list interface{}
+		if len(denied) > 0 {
+			list = strings.Join(denied, ",")
+		}
+		if _, err := db.Exec("UPDATE permissions SET denied_columns = ? WHERE role_name = ? AND table_name = ?", list, role, table); err != nil {
+			return fmt.Errorf("failed to set denied columns: %w", err)
+		}
+	}
+
 	fmt.Printf("✓ Permission set for role '%s' on table '%s'\n", role, table)
 	fmt.Printf("  Create: %v, Read: %v, Update: %v, Delete: %v, Query: %v\n",
 		canCreate, canRead, canUpdate, canDelete, canQuery)
+	if len(denied) > 0 {
+		fmt.Printf("  Denied columns: %s\n", strings.Join(denied, ", "))
+	}
 
 	return nil
 }
@@ -704,7 +1113,11 @@ func runPermissionList(role string) error {
 	}
 	defer db.Close()
 
-	query := "SELECT role_name, table_name, can_create, can_read, can_update, can_delete, can_query FROM permissions"
+	deniedColumns := "NULL"
+	if hasDeniedColumnsColumn(db) {
+		deniedColumns = "denied_columns"
+	}
+	query := "SELECT role_name, table_name, can_create, can_read, can_update, can_delete, can_query, " + deniedColumns + " FROM permissions"
 	var args []interface{}
 	if role != "" {
 		query += " WHERE role_name = ?"
@@ -719,16 +1132,21 @@ func runPermissionList(role string) error {
 	defer rows.Close()
 
 	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
-	fmt.Fprintln(w, "ROLE\tTABLE\tCREATE\tREAD\tUPDATE\tDELETE\tQUERY")
-	fmt.Fprintln(w, "----\t-----\t------\t----\t------\t------\t-----")
+	fmt.Fprintln(w, "ROLE\tTABLE\tCREATE\tREAD\tUPDATE\tDELETE\tQUERY\tDENIED COLUMNS")
+	fmt.Fprintln(w, "----\t-----\t------\t----\t------\t------\t-----\t--------------")
 
 	count := 0
 	for rows.Next() {
 		var roleName, tableName string
 		var canCreate, canRead, canUpdate, canDelete, canQuery bool
-		rows.Scan(&roleName, &tableName, &canCreate, &canRead, &canUpdate, &canDelete, &canQuery)
-		fmt.Fprintf(w, "%s\t%s\t%v\t%v\t%v\t%v\t%v\n",
-			roleName, tableName, canCreate, canRead, canUpdate, canDelete, canQuery)
+		var denied sql.NullString
+		rows.Scan(&roleName, &tableName, &canCreate, &canRead, &canUpdate, &canDelete, &canQuery, &denied)
+		deniedStr := "-"
+		if columns := auth.ParseColumnList(denied.String); len(columns) > 0 {
+			deniedStr = strings.Join(columns, ",")
+		}
+		fmt.Fprintf(w, "%s\t%s\t%v\t%v\t%v\t%v\t%v\t%s\n",
+			roleName, tableName, canCreate, canRead, canUpdate, canDelete, canQuery, deniedStr)
 		count++
 	}
 	w.Flush()
@@ -740,28 +1158,65 @@ func runPermissionList(role string) error {
 	return nil
 }
 
+// infoStats is the output of `info --json`.
+type infoStats struct {
+	DBPath        string         `json:"db_path"`
+	Roles         int            `json:"roles"`
+	APIKeys       int            `json:"api_keys"`
+	ActiveAPIKeys int            `json:"active_api_keys"`
+	Permissions   int            `json:"permissions"`
+	KeysByRole    map[string]int `json:"keys_by_role"`
+}
+
 // runInfo shows database info
-func runInfo() error {
+func runInfo(jsonOutput bool) error {
 	db, err := openDB()
 	if err != nil {
 		return err
 	}
 	defer db.Close()
 
-	var roleCount, keyCount, permCount int
-	var activeKeyCount int
-
-	db.QueryRow("SELECT COUNT(*) FROM roles").Scan(&roleCount)
-	db.QueryRow("SELECT COUNT(*) FROM api_keys").Scan(&keyCount)
-	db.QueryRow("SELECT COUNT(*) FROM api_keys WHERE is_active = true").Scan(&activeKeyCount)
-	db.QueryRow("SELECT COUNT(*) FROM permissions").Scan(&permCount)
+	stats := infoStats{DBPath: dbPath, KeysByRole: make(map[string]int)}
+
+	db.QueryRow("SELECT COUNT(*) FROM roles").Scan(&stats.Roles)
+	db.QueryRow("SELECT COUNT(*) FROM api_keys").Scan(&stats.APIKeys)
+	db.QueryRow("SELECT COUNT(*) FROM api_keys WHERE is_active = true").Scan(&stats.ActiveAPIKeys)
+	db.QueryRow("SELECT COUNT(*) FROM permissions").Scan(&stats.Permissions)
+
+	if !jsonOutput {
+		fmt.Printf("Auth Database: %s\n", dbPath)
+		fmt.Println()
+		fmt.Printf("Statistics:\n")
+		fmt.Printf("  Roles:            %d\n", stats.Roles)
+		fmt.Printf("  API Keys:         %d (%d active)\n", stats.APIKeys, stats.ActiveAPIKeys)
+		fmt.Printf("  Permissions:      %d\n", stats.Permissions)
+		return nil
+	}
 
-	fmt.Printf("Auth Database: %s\n", dbPath)
-	fmt.Println()
-	fmt.Printf("Statistics:\n")
-	fmt.Printf("  Roles:            %d\n", roleCount)
-	fmt.Printf("  API Keys:         %d (%d active)\n", keyCount, activeKeyCount)
-	fmt.Printf("  Permissions:      %d\n", permCount)
+	// Roles without keys are listed with 0
+	rows, err := db.Query(`
+		SELECT r.role_name, COUNT(k.role_name)
+		FROM roles r
+		LEFT JOIN api_keys k ON k.role_name = r.role_name
+		GROUP BY r.role_name
+	`)
+	if err != nil {
+		return fmt.Errorf("failed to count keys per role: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var role string
+		var count int
+		if err := rows.Scan(&role, &count); err != nil {
+			return fmt.Errorf("failed to scan key count: %w", err)
+		}
+		stats.KeysByRole[role] = count
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("failed to count keys per role: %w", err)
+	}
 
-	return nil
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(stats)
 }
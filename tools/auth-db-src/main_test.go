@@ -0,0 +1,276 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/tobilg/caddy-duckdb-module/auth"
+)
+
+// setupAuthDB initializes an auth database with the default roles in a
+// temporary directory and points the commands at it.
+func setupAuthDB(t *testing.T) {
+	t.Helper()
+	dbPath = filepath.Join(t.TempDir(), "auth.db")
+	if err := runInit(true); err != nil {
+		t.Fatalf("Failed to initialize auth database: %v", err)
+	}
+}
+
+// captureStdout returns what fn prints to stdout.
+func captureStdout(t *testing.T, fn func() error) string {
+	t.Helper()
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatalf("Failed to create pipe: %v", err)
+	}
+	stdout := os.Stdout
+	os.Stdout = w
+	runErr := fn()
+	os.Stdout = stdout
+	w.Close()
+
+	var buf bytes.Buffer
+	io.Copy(&buf, r)
+	if runErr != nil {
+		t.Fatalf("Command failed: %v", runErr)
+	}
+	return buf.String()
+}
+
+// keyListActive returns the ACTIVE column of the only key in `key list`.
+func keyListActive(t *testing.T) string {
+	t.Helper()
+	out := captureStdout(t, runKeyList)
+	// Footers (e.g. the plaintext key count) follow the table after a blank line
+	table, _, _ := strings.Cut(strings.TrimSpace(out), "\n\n")
+	lines := strings.Split(table, "\n")
+	if len(lines) != 3 {
+		t.Fatalf("Expected a header and one key, got:\n%s", out)
+	}
+	fields := strings.Fields(lines[2])
+	return fields[len(fields)-1]
+}
+
+func TestKeyDisableEnable(t *testing.T) {
+	setupAuthDB(t)
+
+	captureStdout(t, func() error { return runKeyAdd("reader", "rotate-me-12345", "", "") })
+	if active := keyListActive(t); active != "yes" {
+		t.Errorf("Expected new key to be active, got %s", active)
+	}
+
+	captureStdout(t, func() error { return runKeySetActive("rotate-me-12345", false) })
+	if active := keyListActive(t); active != "no" {
+		t.Errorf("Expected disabled key to show ACTIVE=no, got %s", active)
+	}
+
+	captureStdout(t, func() error { return runKeySetActive("rotate-me-12345", true) })
+	if active := keyListActive(t); active != "yes" {
+		t.Errorf("Expected enabled key to show ACTIVE=yes, got %s", active)
+	}
+
+	if err := runKeySetActive("unknown-key", false); err == nil {
+		t.Error("Expected disabling an unknown key to fail")
+	}
+}
+
+func TestKeyDisable_Plaintext(t *testing.T) {
+	setupAuthDB(t)
+
+	// A key stored before hashing was introduced
+	db, err := openDB()
+	if err != nil {
+		t.Fatalf("Failed to open auth database: %v", err)
+	}
+	_, err = db.Exec("INSERT INTO api_keys (key, role_name) VALUES ('legacy-key-12345', 'reader')")
+	db.Close()
+	if err != nil {
+		t.Fatalf("Failed to insert key: %v", err)
+	}
+
+	captureStdout(t, func() error { return runKeySetActive("legacy-key-12345", false) })
+	if active := keyListActive(t); active != "no" {
+		t.Errorf("Expected disabled plaintext key to show ACTIVE=no, got %s", active)
+	}
+}
+
+func TestKeyRotate(t *testing.T) {
+	setupAuthDB(t)
+
+	captureStdout(t, func() error { return runKeyAdd("editor", "old-key-12345", "2099-12-31T23:59:59Z", "") })
+	out := captureStdout(t, func() error { return runKeyRotate("old-key-12345", "") })
+
+	var newKey string
+	for _, line := range strings.Split(out, "\n") {
+		if value, ok := strings.CutPrefix(strings.TrimSpace(line), "API Key:"); ok {
+			newKey = strings.TrimSpace(value)
+		}
+	}
+	if newKey == "" || newKey == "old-key-12345" {
+		t.Fatalf("Expected the new key to be printed, got:\n%s", out)
+	}
+
+	db, err := openDB()
+	if err != nil {
+		t.Fatalf("Failed to open auth database: %v", err)
+	}
+	authorizer := auth.NewAuthorizer(db)
+
+	key, err := authorizer.AuthenticateAPIKey(newKey)
+	if err != nil {
+		t.Fatalf("Expected the new key to authenticate, got %v", err)
+	}
+	if key.RoleName != "editor" {
+		t.Errorf("Expected role editor to carry over, got %s", key.RoleName)
+	}
+	want := time.Date(2099, 12, 31, 23, 59, 59, 0, time.UTC)
+	if key.ExpiresAt == nil || !key.ExpiresAt.Equal(want) {
+		t.Errorf("Expected expiry %v to carry over, got %v", want, key.ExpiresAt)
+	}
+	if _, err := authorizer.AuthenticateAPIKey("old-key-12345"); !errors.Is(err, auth.ErrDisabledAPIKey) {
+		t.Errorf("Expected the old key to be disabled, got %v", err)
+	}
+	db.Close()
+
+	// An explicit new key; the disabled key cannot be rotated again
+	captureStdout(t, func() error { return runKeyRotate(newKey, "third-key-12345") })
+	if err := runKeyRotate("old-key-12345", ""); err == nil {
+		t.Error("Expected rotating a disabled key to fail")
+	}
+	if err := runKeyRotate("unknown-key", ""); err == nil {
+		t.Error("Expected rotating an unknown key to fail")
+	}
+
+	db, err = openDB()
+	if err != nil {
+		t.Fatalf("Failed to open auth database: %v", err)
+	}
+	defer db.Close()
+	key, err = auth.NewAuthorizer(db).AuthenticateAPIKey("third-key-12345")
+	if err != nil {
+		t.Fatalf("Expected the given key to authenticate, got %v", err)
+	}
+	if key.RoleName != "editor" {
+		t.Errorf("Expected role editor to carry over, got %s", key.RoleName)
+	}
+}
+
+func TestRoleAdd_DefaultAccess(t *testing.T) {
+	setupAuthDB(t)
+
+	out := captureStdout(t, func() error { return runRoleAdd("dev", "Development", "", "crud") })
+	if !strings.Contains(out, "default access: crud") {
+		t.Errorf("Expected default access in output, got %q", out)
+	}
+
+	out = captureStdout(t, runRoleList)
+	for _, line := range strings.Split(out, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 3 {
+			continue
+		}
+		switch fields[0] {
+		case "dev":
+			if fields[2] != "crud" {
+				t.Errorf("Expected dev to list default access crud, got %q", line)
+			}
+		case "reader":
+			if fields[2] != "deny" {
+				t.Errorf("Expected reader to list default access deny, got %q", line)
+			}
+		}
+	}
+
+	if err := runRoleAdd("broken", "", "", "all"); err == nil {
+		t.Error("Expected an invalid default access to be rejected")
+	}
+}
+
+func TestInfo_JSON(t *testing.T) {
+	setupAuthDB(t)
+
+	captureStdout(t, func() error { return runKeyAdd("reader", "reader-key-12345", "", "") })
+	captureStdout(t, func() error { return runKeyAdd("reader", "reader-key-67890", "", "") })
+	captureStdout(t, func() error { return runKeySetActive("reader-key-67890", false) })
+
+	cmd := infoCmd()
+	cmd.SetArgs([]string{"--json"})
+	out := captureStdout(t, cmd.Execute)
+
+	var stats struct {
+		DBPath        string         `json:"db_path"`
+		Roles         int            `json:"roles"`
+		APIKeys       int            `json:"api_keys"`
+		ActiveAPIKeys int            `json:"active_api_keys"`
+		Permissions   int            `json:"permissions"`
+		KeysByRole    map[string]int `json:"keys_by_role"`
+	}
+	if err := json.Unmarshal([]byte(out), &stats); err != nil {
+		t.Fatalf("Expected JSON output, got %q: %v", out, err)
+	}
+	if stats.DBPath != dbPath {
+		t.Errorf("Expected db_path %q, got %q", dbPath, stats.DBPath)
+	}
+	if stats.APIKeys != 2 || stats.ActiveAPIKeys != 1 {
+		t.Errorf("Expected 2 keys (1 active), got %d (%d active)", stats.APIKeys, stats.ActiveAPIKeys)
+	}
+	if stats.Roles == 0 || stats.Permissions == 0 {
+		t.Errorf("Expected the default roles and permissions, got %+v", stats)
+	}
+	if stats.KeysByRole["reader"] != 2 {
+		t.Errorf("Expected 2 reader keys, got %v", stats.KeysByRole)
+	}
+	if count, ok := stats.KeysByRole["admin"]; !ok || count != 0 {
+		t.Errorf("Expected admin to be listed without keys, got %v", stats.KeysByRole)
+	}
+
+	// Without --json, the human-readable statistics are printed
+	out = captureStdout(t, func() error { return runInfo(false) })
+	if !strings.Contains(out, "API Keys:         2 (1 active)") {
+		t.Errorf("Expected the tabular statistics, got %q", out)
+	}
+}
+
+func TestPermissionAdd_DenyColumns(t *testing.T) {
+	setupAuthDB(t)
+
+	denied := "customer_ssn, card_number"
+	captureStdout(t, func() error { return runPermissionAdd("reader", "orders", "r", &denied) })
+	out := captureStdout(t, func() error { return runPermissionList("reader") })
+	if !strings.Contains(out, "DENIED COLUMNS") || !strings.Contains(out, "customer_ssn,card_number") {
+		t.Errorf("Expected denied columns in permission list, got:\n%s", out)
+	}
+
+	// Without the flag the restriction is kept
+	captureStdout(t, func() error { return runPermissionAdd("reader", "orders", "r", nil) })
+
+	db, err := openDB()
+	if err != nil {
+		t.Fatalf("Failed to open auth database: %v", err)
+	}
+	columns, err := auth.NewAuthorizer(db).DeniedColumns("reader", "orders")
+	db.Close()
+	if err != nil || len(columns) != 2 || columns[0] != "customer_ssn" || columns[1] != "card_number" {
+		t.Errorf("Expected customer_ssn and card_number to be denied, got %v (err: %v)", columns, err)
+	}
+
+	// An empty value lifts it
+	empty := ""
+	captureStdout(t, func() error { return runPermissionAdd("reader", "orders", "r", &empty) })
+	db, err = openDB()
+	if err != nil {
+		t.Fatalf("Failed to open auth database: %v", err)
+	}
+	defer db.Close()
+	if columns, err := auth.NewAuthorizer(db).DeniedColumns("reader", "orders"); err != nil || columns != nil {
+		t.Errorf("Expected no denied columns, got %v (err: %v)", columns, err)
+	}
+}
@@ -0,0 +1,44 @@
+package duckdb
+
+import (
+	"net/http"
+	"strings"
+)
+
+// Trailing slash handling modes of TrailingSlash.
+const (
+	// TrailingSlashStrip serves /duckdb/api/users/ as /duckdb/api/users.
+	TrailingSlashStrip = "strip"
+	// TrailingSlashRedirect redirects /duckdb/api/users/ to /duckdb/api/users
+	// with 308 Permanent Redirect, which keeps the method and body.
+	TrailingSlashRedirect = "redirect"
+)
+
+// canonicalPath returns path without trailing slashes. The canonical form of
+// every endpoint has no trailing slash.
+func canonicalPath(path string) string {
+	return strings.TrimRight(path, "/")
+}
+
+// normalizeTrailingSlash rewrites a request path with trailing slashes to its
+// canonical form, or, in redirect mode, redirects the client to it. It returns
+// the request to serve, or nil if the redirect has been sent.
+func (d *DuckDB) normalizeTrailingSlash(w http.ResponseWriter, r *http.Request) *http.Request {
+	path := canonicalPath(r.URL.Path)
+	if path == r.URL.Path || path == "" {
+		return r
+	}
+
+	u := *r.URL
+	u.Path = path
+	if u.RawPath != "" {
+		u.RawPath = canonicalPath(u.RawPath)
+	}
+	if d.TrailingSlash == TrailingSlashRedirect {
+		http.Redirect(w, r, u.RequestURI(), http.StatusPermanentRedirect)
+		return nil
+	}
+	r2 := r.Clone(r.Context())
+	r2.URL = &u
+	return r2
+}
@@ -0,0 +1,130 @@
+package duckdb
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/caddyserver/caddy/v2/caddyconfig/caddyfile"
+	"github.com/tobilg/caddy-duckdb-module/handlers"
+)
+
+// setupTrailingSlashModule creates a module with handlers for every endpoint.
+func setupTrailingSlashModule(t *testing.T) (*DuckDB, func()) {
+	d, cleanup := setupMaintenanceModule(t)
+	d.catalogHandler = handlers.NewCatalogHandler(d.dbMgr, d.authorizer, d.logger)
+	d.openAPIHandler = handlers.NewOpenAPIHandler()
+	if _, err := d.dbMgr.ExecMain(`INSERT INTO test_data VALUES (1, 'a'), (2, 'b')`); err != nil {
+		cleanup()
+		t.Fatalf("Failed to insert test data: %v", err)
+	}
+	return d, cleanup
+}
+
+func TestServeHTTP_TrailingSlashStrip(t *testing.T) {
+	d, cleanup := setupTrailingSlashModule(t)
+	defer cleanup()
+
+	tests := []struct {
+		method      string
+		path        string
+		body        string
+		compareBody bool
+	}{
+		{"GET", "/duckdb/health", "", true},
+		{"GET", "/duckdb/openapi.json", "", true},
+		{"GET", "/duckdb/api/test_data", "", true},
+		{"GET", "/duckdb/api/test_data?filter=id:eq:1", "", true},
+		{"GET", "/duckdb/api/test_data/cardinality?column=value", "", true},
+		{"POST", "/duckdb/query", `{"sql": "SELECT COUNT(*) AS n FROM test_data"}`, true},
+		{"GET", "/duckdb/schema", "", true},
+		{"GET", "/duckdb/schema/test_data", "", true},
+		{"GET", "/duckdb/admin/maintenance", "", true},
+		{"GET", "/duckdb/metrics", "", false},
+		{"POST", "/duckdb/transaction", `[{"op": "update", "table": "test_data", "set": {"value": "c"}, "where": [{"column": "id", "op": "eq", "value": 2}]}]`, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.method+" "+tt.path, func(t *testing.T) {
+			path, query, _ := strings.Cut(tt.path, "?")
+			slashed := path + "/"
+			if query != "" {
+				slashed += "?" + query
+			}
+			plain := serve(t, d, tt.method, tt.path, tt.body, "test-api-key")
+			withSlash := serve(t, d, tt.method, slashed, tt.body, "test-api-key")
+			if plain.Code != http.StatusOK {
+				t.Fatalf("Expected status 200 for %s, got %d: %s", tt.path, plain.Code, plain.Body.String())
+			}
+			if withSlash.Code != plain.Code {
+				t.Errorf("Expected status %d for %s, got %d: %s", plain.Code, slashed, withSlash.Code, withSlash.Body.String())
+			}
+			if tt.compareBody && withSlash.Body.String() != plain.Body.String() {
+				t.Errorf("Expected the same body for %s:\n%s\ngot:\n%s", slashed, plain.Body.String(), withSlash.Body.String())
+			}
+		})
+	}
+}
+
+func TestServeHTTP_TrailingSlashRedirect(t *testing.T) {
+	d, cleanup := setupTrailingSlashModule(t)
+	defer cleanup()
+	d.TrailingSlash = TrailingSlashRedirect
+
+	rec := serve(t, d, "POST", "/duckdb/api/test_data//?dry_run=true", `{"id": 3, "value": "c"}`, "test-api-key")
+	if rec.Code != http.StatusPermanentRedirect {
+		t.Fatalf("Expected status 308, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if location := rec.Header().Get("Location"); location != "/duckdb/api/test_data?dry_run=true" {
+		t.Errorf("Expected redirect to the canonical path, got %q", location)
+	}
+
+	// Canonical paths are served as usual
+	if rec := serve(t, d, "GET", "/duckdb/api/test_data", "", "test-api-key"); rec.Code != http.StatusOK {
+		t.Errorf("Expected status 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+}
+
+func TestCanonicalPath(t *testing.T) {
+	tests := []struct {
+		path     string
+		expected string
+	}{
+		{"/duckdb/api/users", "/duckdb/api/users"},
+		{"/duckdb/api/users/", "/duckdb/api/users"},
+		{"/duckdb/api/users//", "/duckdb/api/users"},
+		{"/duckdb/", "/duckdb"},
+		{"/", ""},
+	}
+	for _, tt := range tests {
+		if got := canonicalPath(tt.path); got != tt.expected {
+			t.Errorf("canonicalPath(%q) = %q, expected %q", tt.path, got, tt.expected)
+		}
+	}
+}
+
+func TestUnmarshalCaddyfile_TrailingSlash(t *testing.T) {
+	dispenser := caddyfile.NewTestDispenser(`duckdb {
+		trailing_slash Redirect
+	}`)
+	d := &DuckDB{}
+	if err := d.UnmarshalCaddyfile(dispenser); err != nil {
+		t.Fatalf("UnmarshalCaddyfile failed: %v", err)
+	}
+	if d.TrailingSlash != TrailingSlashRedirect {
+		t.Errorf("Expected trailing_slash redirect, got %q", d.TrailingSlash)
+	}
+}
+
+func TestValidate_InvalidTrailingSlash(t *testing.T) {
+	d := &DuckDB{
+		AccessMode:      "read_write",
+		MaxRowsPerPage:  100,
+		AbsoluteMaxRows: 10000,
+		Threads:         4,
+		TrailingSlash:   "append",
+	}
+
+	if err := d.Validate(); err == nil {
+		t.Error("Expected error for unknown trailing_slash")
+	}
+}